@@ -0,0 +1,15 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package authz
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ApplySchemaRequest) Validate() error {
+	if err := o.Schema.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package authz
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o AttributeCheck) Validate() error {
+	if o.SourceObjectID.IsNil() {
+		return ucerr.Friendlyf(nil, "AttributeCheck.SourceObjectID can't be nil")
+	}
+	if o.TargetObjectID.IsNil() {
+		return ucerr.Friendlyf(nil, "AttributeCheck.TargetObjectID can't be nil")
+	}
+	if o.Attribute == "" {
+		return ucerr.Friendlyf(nil, "AttributeCheck.Attribute can't be empty")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package authz
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -41,6 +42,12 @@ type options struct {
 	jsonclientOptions     []jsonclient.Option
 	bypassAuthHeaderCheck bool // if we're using per-request header forwarding via PassthroughAuthorization, don't check for auth header
 	source                *string
+	includeAttributePaths bool
+	validFrom             time.Time
+	validUntil            time.Time
+	asOf                  time.Time
+	atLeastAsFresh        ConsistencyToken
+	consistencyToken      *ConsistencyToken
 }
 
 // Option makes authz.Client extensible
@@ -89,6 +96,50 @@ func Source(source string) Option {
 	})
 }
 
+// IncludeAttributePaths returns an Option that will cause the client to request the attribute path for each result
+// (supported by ListSourcesWithAttribute)
+func IncludeAttributePaths() Option {
+	return optFunc(func(opts *options) {
+		opts.includeAttributePaths = true
+	})
+}
+
+// ValidBetween returns an Option that limits the edge being created to a window of time, eg. for temporary access
+// (supported by CreateEdge). Either bound may be zero to leave that side of the window open.
+func ValidBetween(validFrom, validUntil time.Time) Option {
+	return optFunc(func(opts *options) {
+		// the database only stores microseconds, so truncate here to keep the edge comparable to what's stored
+		opts.validFrom = validFrom.UTC().Truncate(time.Microsecond)
+		opts.validUntil = validUntil.UTC().Truncate(time.Microsecond)
+	})
+}
+
+// AsOf returns an Option that evaluates the authorization graph as it existed at the given time, based on the
+// history of edge and edge type changes (supported by CheckAttribute and ListAttributes). Results are never cached.
+func AsOf(asOf time.Time) Option {
+	return optFunc(func(opts *options) {
+		opts.asOf = asOf.UTC()
+	})
+}
+
+// AtLeastAsFresh returns an Option that makes a read observe at least the writes that returned the given token
+// (supported by CheckAttribute, CheckAttributes, ListAttributes, ListObjectsReachableWithAttribute and
+// ListSourcesWithAttribute). Cached results that may be older than the token are skipped, both in the client and on the server.
+func AtLeastAsFresh(token ConsistencyToken) Option {
+	return optFunc(func(opts *options) {
+		opts.atLeastAsFresh = token
+	})
+}
+
+// ReturnConsistencyToken returns an Option that stores the consistency token issued by a write in token, for use with
+// AtLeastAsFresh (supported by CreateEdge, DeleteEdge, DeleteEdgesByObject, DeleteObject, CreateEdgeType, UpdateEdgeType
+// and DeleteEdgeType). The token is left unchanged if the write was satisfied from the client cache without calling the server.
+func ReturnConsistencyToken(token *ConsistencyToken) Option {
+	return optFunc(func(opts *options) {
+		opts.consistencyToken = token
+	})
+}
+
 // Pagination is a wrapper around pagination.Option
 func Pagination(opt ...pagination.Option) Option {
 	return optFunc(func(opts *options) {
@@ -415,11 +466,16 @@ func (c *Client) ListObjectTypesPaginated(ctx context.Context, opts ...Option) (
 }
 
 // DeleteObjectType deletes an object type by ID.
-func (c *Client) DeleteObjectType(ctx context.Context, objectTypeID uuid.UUID) error {
+func (c *Client) DeleteObjectType(ctx context.Context, objectTypeID uuid.UUID, opts ...Option) error {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	// We don't take a delete lock since we will flush the cache after the delete anyway
-	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/objecttypes/%s", objectTypeID), nil); err != nil {
+	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/objecttypes/%s%s", objectTypeID, sourceQuery(options)), nil); err != nil {
 		if jsonclient.IsHTTPNotFound(err) {
 			return ucerr.Wrap(ucerr.Combine(err, ErrObjectTypeNotFound))
 		}
@@ -433,6 +489,7 @@ func (c *Client) DeleteObjectType(ctx context.Context, objectTypeID uuid.UUID) e
 // CreateEdgeTypeRequest is the request body for creating an edge type
 type CreateEdgeTypeRequest struct {
 	EdgeType EdgeType `json:"edge_type" yaml:"edge_type"`
+	Source   *string  `json:"source,omitempty" yaml:"source,omitempty"` // recorded in the edge type history
 }
 
 // CreateEdgeType creates a new type of edge for the authz system.
@@ -456,10 +513,10 @@ func (c *Client) CreateEdgeType(ctx context.Context, id uuid.UUID, sourceObjectT
 
 	return cache.CreateItemClient[EdgeType](ctx, &c.cm, id, &input, EdgeTypeKeyID, c.cm.N.GetKeyNameWithString(EdgeTypeNameKeyID, typeName), options.ifNotExists, options.bypassCache, nil,
 		func(i *EdgeType) (*EdgeType, error) {
-			req := CreateEdgeTypeRequest{*i}
+			req := CreateEdgeTypeRequest{EdgeType: *i, Source: options.source}
 			var resp EdgeType
 			if options.ifNotExists {
-				exists, existingID, err := c.client.CreateIfNotExists(ctx, "/authz/edgetypes", req, &resp)
+				exists, existingID, err := c.client.CreateIfNotExists(ctx, "/authz/edgetypes", req, &resp, consistencyOptions(options)...)
 				if err != nil {
 					return nil, ucerr.Wrap(err)
 				}
@@ -472,7 +529,7 @@ func (c *Client) CreateEdgeType(ctx context.Context, id uuid.UUID, sourceObjectT
 					}
 				}
 			} else {
-				if err := c.client.Post(ctx, "/authz/edgetypes", req, &resp); err != nil {
+				if err := c.client.Post(ctx, "/authz/edgetypes", req, &resp, consistencyOptions(options)...); err != nil {
 					return nil, ucerr.Wrap(err)
 				}
 			}
@@ -486,6 +543,7 @@ func (c *Client) CreateEdgeType(ctx context.Context, id uuid.UUID, sourceObjectT
 type UpdateEdgeTypeRequest struct {
 	TypeName   string     `json:"type_name" yaml:"type_name" validate:"notempty"`
 	Attributes Attributes `json:"attributes" yaml:"attributes"`
+	Source     *string    `json:"source,omitempty" yaml:"source,omitempty"` // recorded in the edge type history
 }
 
 // UpdateEdgeType updates an existing edge type in the authz system.
@@ -500,6 +558,7 @@ func (c *Client) UpdateEdgeType(ctx context.Context, id uuid.UUID, sourceObjectT
 	req := UpdateEdgeTypeRequest{
 		TypeName:   typeName,
 		Attributes: attributes,
+		Source:     options.source,
 	}
 
 	eT := EdgeType{
@@ -531,7 +590,7 @@ func (c *Client) UpdateEdgeType(ctx context.Context, id uuid.UUID, sourceObjectT
 	defer cache.ReleaseItemLock(ctx, c.cm, cache.Update, eT, s)
 
 	var resp EdgeType
-	if err := c.client.Put(ctx, fmt.Sprintf("/authz/edgetypes/%s", id), req, &resp); err != nil {
+	if err := c.client.Put(ctx, fmt.Sprintf("/authz/edgetypes/%s", id), req, &resp, consistencyOptions(options)...); err != nil {
 		if jsonclient.IsHTTPNotFound(err) {
 			return nil, ucerr.Wrap(ucerr.Combine(err, ErrEdgeTypeNotFound))
 		}
@@ -699,11 +758,16 @@ func (c *Client) ListEdgeTypesPaginated(ctx context.Context, opts ...Option) (*L
 }
 
 // DeleteEdgeType deletes an edge type by ID.
-func (c *Client) DeleteEdgeType(ctx context.Context, edgeTypeID uuid.UUID) error {
+func (c *Client) DeleteEdgeType(ctx context.Context, edgeTypeID uuid.UUID, opts ...Option) error {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	// We don't take a delete lock since we will flush the cache after the delete anyway
-	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/edgetypes/%s", edgeTypeID), nil); err != nil {
+	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/edgetypes/%s%s", edgeTypeID, sourceQuery(options)), nil, consistencyOptions(options)...); err != nil {
 		if jsonclient.IsHTTPNotFound(err) {
 			return ucerr.Wrap(ucerr.Combine(err, ErrEdgeTypeNotFound))
 		}
@@ -886,9 +950,14 @@ func (c *Client) UpdateObject(ctx context.Context, id uuid.UUID, alias *string,
 }
 
 // DeleteObject deletes an object by ID.
-func (c *Client) DeleteObject(ctx context.Context, id uuid.UUID) error {
+func (c *Client) DeleteObject(ctx context.Context, id uuid.UUID, opts ...Option) error {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	obj := &Object{BaseModel: ucdb.NewBaseWithID(id)}
 	// Stop in flight reads/writes of this object, edges leading to/from this object, paths including this object and object collection from committing to the cache
 	obj, _, _, err := cache.GetItemFromCache[Object](ctx, c.cm, obj.GetPrimaryKey(c.cm.N), false)
@@ -905,7 +974,7 @@ func (c *Client) DeleteObject(ctx context.Context, id uuid.UUID) error {
 	}
 	defer cache.ReleaseItemLock(ctx, c.cm, cache.Delete, *obj, s)
 
-	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/objects/%s", id), nil); err != nil {
+	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/objects/%s%s", id, sourceQuery(options)), nil, consistencyOptions(options)...); err != nil {
 		if jsonclient.IsHTTPNotFound(err) {
 			return ucerr.Wrap(ucerr.Combine(err, ErrObjectNotFound))
 		}
@@ -915,9 +984,14 @@ func (c *Client) DeleteObject(ctx context.Context, id uuid.UUID) error {
 }
 
 // DeleteEdgesByObject deletes all edges going in or  out of an object by ID.
-func (c *Client) DeleteEdgesByObject(ctx context.Context, id uuid.UUID) error {
+func (c *Client) DeleteEdgesByObject(ctx context.Context, id uuid.UUID, opts ...Option) error {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	// Stop in flight reads of edges that include this object as source or target as well as paths starting from this object from committing to the cache
 	// We don't block reads of collections/paths that end at this object since we may not have full set of edges without reading the server
 	obj := Object{BaseModel: ucdb.NewBaseWithID(id)}
@@ -931,7 +1005,7 @@ func (c *Client) DeleteEdgesByObject(ctx context.Context, id uuid.UUID) error {
 	}
 	defer cache.ReleasePerItemCollectionLock[Object](ctx, c.cm, nil, obj, s)
 
-	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/objects/%s/edges", id), nil); err != nil {
+	if err := c.client.Delete(ctx, fmt.Sprintf("/authz/objects/%s/edges%s", id, sourceQuery(options)), nil, consistencyOptions(options)...); err != nil {
 		return ucerr.Wrap(err)
 	}
 	return nil
@@ -1245,7 +1319,8 @@ func (c *Client) FindEdge(ctx context.Context, sourceObjectID, targetObjectID, e
 
 // CreateEdgeRequest is the request body for creating an edge
 type CreateEdgeRequest struct {
-	Edge Edge `json:"edge" yaml:"edge"`
+	Edge   Edge    `json:"edge" yaml:"edge"`
+	Source *string `json:"source,omitempty" yaml:"source,omitempty"` // recorded in the edge history
 }
 
 // CreateEdge creates an edge (relationship) between two objects.
@@ -1260,6 +1335,8 @@ func (c *Client) CreateEdge(ctx context.Context, id, sourceObjectID, targetObjec
 		EdgeTypeID:     edgeTypeID,
 		SourceObjectID: sourceObjectID,
 		TargetObjectID: targetObjectID,
+		ValidFrom:      options.validFrom,
+		ValidUntil:     options.validUntil,
 	}
 	if !id.IsNil() {
 		input.ID = id
@@ -1273,10 +1350,10 @@ func (c *Client) CreateEdge(ctx context.Context, id, sourceObjectID, targetObjec
 
 	return cache.CreateItemClient[Edge](ctx, &c.cm, id, &input, EdgeKeyID, c.cm.N.GetKeyName(EdgeFullKeyID, []string{sourceObjectID.String(), targetObjectID.String(), edgeTypeID.String()}), options.ifNotExists, options.bypassCache, additionalKeys,
 		func(i *Edge) (*Edge, error) {
-			req := CreateEdgeRequest{*i}
+			req := CreateEdgeRequest{Edge: *i, Source: options.source}
 			var resp Edge
 			if options.ifNotExists {
-				exists, existingID, err := c.client.CreateIfNotExists(ctx, "/authz/edges", req, &resp)
+				exists, existingID, err := c.client.CreateIfNotExists(ctx, "/authz/edges", req, &resp, consistencyOptions(options)...)
 				if err != nil {
 					return nil, ucerr.Wrap(err)
 				}
@@ -1289,7 +1366,7 @@ func (c *Client) CreateEdge(ctx context.Context, id, sourceObjectID, targetObjec
 					}
 				}
 			} else {
-				if err := c.client.Post(ctx, "/authz/edges", req, &resp); err != nil {
+				if err := c.client.Post(ctx, "/authz/edges", req, &resp, consistencyOptions(options)...); err != nil {
 					return nil, ucerr.Wrap(err)
 				}
 			}
@@ -1300,9 +1377,14 @@ func (c *Client) CreateEdge(ctx context.Context, id, sourceObjectID, targetObjec
 }
 
 // DeleteEdge deletes an edge by ID.
-func (c *Client) DeleteEdge(ctx context.Context, edgeID uuid.UUID) error {
+func (c *Client) DeleteEdge(ctx context.Context, edgeID uuid.UUID, opts ...Option) error {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	edge, _, _, err := cache.GetItemFromCache[Edge](ctx, c.cm, c.cm.N.GetKeyNameWithID(EdgeKeyID, edgeID), false)
 	if err != nil {
 		return ucerr.Wrap(err)
@@ -1318,7 +1400,7 @@ func (c *Client) DeleteEdge(ctx context.Context, edgeID uuid.UUID) error {
 	}
 	defer cache.ReleaseItemLock(ctx, c.cm, cache.Delete, *edge, s)
 
-	if err = c.client.Delete(ctx, fmt.Sprintf("/authz/edges/%s", edgeID), nil); err != nil {
+	if err = c.client.Delete(ctx, fmt.Sprintf("/authz/edges/%s%s", edgeID, sourceQuery(options)), nil, consistencyOptions(options)...); err != nil {
 		if jsonclient.IsHTTPNotFound(err) {
 			return ucerr.Wrap(ucerr.Combine(err, ErrEdgeNotFound))
 		}
@@ -1328,6 +1410,31 @@ func (c *Client) DeleteEdge(ctx context.Context, edgeID uuid.UUID) error {
 	return nil
 }
 
+// sourceQuery returns the query string for passing the Source option on a delete request, which has no body
+func sourceQuery(options options) string {
+	if options.source == nil {
+		return ""
+	}
+	return "?" + url.Values{"source": []string{*options.source}}.Encode()
+}
+
+// consistencyOptions returns the jsonclient options that pass the AtLeastAsFresh token on a read, and that capture
+// the token issued by a write for ReturnConsistencyToken
+func consistencyOptions(options options) []jsonclient.Option {
+	opts := []jsonclient.Option{}
+	if options.atLeastAsFresh != "" {
+		opts = append(opts, jsonclient.Header(ConsistencyTokenHeader, string(options.atLeastAsFresh)))
+	}
+	if token := options.consistencyToken; token != nil {
+		opts = append(opts, jsonclient.ResponseHeaders(func(h http.Header) {
+			if t := h.Get(ConsistencyTokenHeader); t != "" {
+				*token = ConsistencyToken(t)
+			}
+		}))
+	}
+	return opts
+}
+
 // AttributePathNode is a node in a path list from source to target, if CheckAttribute succeeds.
 type AttributePathNode struct {
 	ObjectID uuid.UUID `json:"object_id" yaml:"object_id" validate:"notnil"`
@@ -1345,6 +1452,10 @@ func (a AttributePathNode) GetID() uuid.UUID {
 type CheckAttributeResponse struct {
 	HasAttribute bool                `json:"has_attribute" yaml:"has_attribute"`
 	Path         []AttributePathNode `json:"path" yaml:"path"`
+
+	// TimeBounded is true if the path goes through an edge that is only valid during a window of time, in which case
+	// the result can change without any edge being written to invalidate it, so it must not be cached
+	TimeBounded bool `json:"time_bounded,omitempty" yaml:"time_bounded,omitempty"`
 }
 
 // CheckAttribute returns true if the source object has the given attribute on the target object.
@@ -1356,10 +1467,24 @@ func (c *Client) CheckAttribute(ctx context.Context, sourceObjectID, targetObjec
 		opt.apply(&options)
 	}
 
+	if !options.asOf.IsZero() {
+		// point-in-time results don't change as the graph changes, and must not be confused with current ones
+		var resp CheckAttributeResponse
+		query := url.Values{}
+		query.Add("source_object_id", sourceObjectID.String())
+		query.Add("target_object_id", targetObjectID.String())
+		query.Add("attribute", attributeName)
+		query.Add("as_of", options.asOf.Format(time.RFC3339Nano))
+		if err := c.client.Get(ctx, fmt.Sprintf("/authz/checkattribute?%s", query.Encode()), &resp); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return &resp, nil
+	}
+
 	ckey := c.cm.N.GetKeyName(AttributePathObjToObjID, []string{sourceObjectID.String(), targetObjectID.String(), attributeName})
 
 	s := cache.NoLockSentinel
-	if !options.bypassCache {
+	if !options.bypassCache && options.atLeastAsFresh == "" {
 		var path *[]AttributePathNode
 		var err error
 
@@ -1381,26 +1506,150 @@ func (c *Client) CheckAttribute(ctx context.Context, sourceObjectID, targetObjec
 	query.Add("source_object_id", sourceObjectID.String())
 	query.Add("target_object_id", targetObjectID.String())
 	query.Add("attribute", attributeName)
-	if err := c.client.Get(ctx, fmt.Sprintf("/authz/checkattribute?%s", query.Encode()), &resp); err != nil {
+	if err := c.client.Get(ctx, fmt.Sprintf("/authz/checkattribute?%s", query.Encode()), &resp, consistencyOptions(options)...); err != nil {
 		return nil, ucerr.Wrap(err)
 	}
 
-	if resp.HasAttribute {
+	if resp.HasAttribute && !resp.TimeBounded {
 		// We can only cache positive responses, since we don't know when the path will be added to invalidate the negative result.
+		// Paths through time-bounded edges aren't cached either, since they expire without any write to invalidate them.
 		cache.SaveItemsToCollection(ctx, c.cm, obj, resp.Path, ckey, ckey, s, false)
 	}
 	return &resp, nil
 }
 
+// MaxAttributeChecks is the maximum number of checks in a single checkattributes request
+const MaxAttributeChecks = 1000
+
+// AttributeCheck is a single (source object, target object, attribute) tuple to check with CheckAttributes
+type AttributeCheck struct {
+	SourceObjectID uuid.UUID `json:"source_object_id" yaml:"source_object_id" validate:"notnil"`
+	TargetObjectID uuid.UUID `json:"target_object_id" yaml:"target_object_id" validate:"notnil"`
+	Attribute      string    `json:"attribute" yaml:"attribute" validate:"notempty"`
+}
+
+//go:generate genvalidate AttributeCheck
+
+// CheckAttributesRequest is the request body for the checkattributes endpoint
+type CheckAttributesRequest struct {
+	Checks       []AttributeCheck `json:"checks" yaml:"checks"`
+	IncludePaths bool             `json:"include_paths" yaml:"include_paths"`
+}
+
+func (r CheckAttributesRequest) extraValidate() error {
+	if len(r.Checks) == 0 {
+		return ucerr.Friendlyf(nil, "at least one check must be specified")
+	}
+	if len(r.Checks) > MaxAttributeChecks {
+		return ucerr.Friendlyf(nil, "at most %d checks can be specified in a single request", MaxAttributeChecks)
+	}
+	for _, check := range r.Checks {
+		if err := check.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	return nil
+}
+
+//go:generate genvalidate CheckAttributesRequest
+
+// CheckAttributesResponse is returned by the checkattributes endpoint, with a result for each check in request order
+type CheckAttributesResponse struct {
+	Results []CheckAttributeResponse `json:"results" yaml:"results"`
+}
+
+// CheckAttributes checks a batch of attributes in as few requests as possible, returning a result for each check
+// in the same order. Like CheckAttribute, positive results that aren't time-bounded are read from and saved to the client cache.
+func (c *Client) CheckAttributes(ctx context.Context, checks []AttributeCheck, opts ...Option) ([]CheckAttributeResponse, error) {
+	ctx = request.NewRequestID(ctx)
+
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	type pendingCheck struct {
+		index    int
+		ckey     cache.Key
+		sentinel cache.Sentinel
+	}
+
+	results := make([]CheckAttributeResponse, len(checks))
+	pending := []pendingCheck{}
+	for i, check := range checks {
+		ckey := c.cm.N.GetKeyName(AttributePathObjToObjID, []string{check.SourceObjectID.String(), check.TargetObjectID.String(), check.Attribute})
+
+		s := cache.NoLockSentinel
+		if !options.bypassCache && options.atLeastAsFresh == "" {
+			var path *[]AttributePathNode
+			var err error
+
+			path, _, s, _, err = cache.GetItemsArrayFromCache[AttributePathNode](ctx, c.cm, ckey, true)
+			if err != nil {
+				uclog.Errorf(ctx, "CheckAttributes failed to get item from cache: %v", err)
+			} else if path != nil {
+				results[i] = CheckAttributeResponse{HasAttribute: true, Path: *path}
+				continue
+			}
+		}
+
+		pending = append(pending, pendingCheck{index: i, ckey: ckey, sentinel: s})
+	}
+
+	// Release the locks in case of error
+	defer func() {
+		for _, p := range pending {
+			obj := Object{BaseModel: ucdb.NewBaseWithID(checks[p.index].SourceObjectID)}
+			cache.ReleasePerItemCollectionLock(ctx, c.cm, []cache.Key{p.ckey}, obj, p.sentinel)
+		}
+	}()
+
+	for start := 0; start < len(pending); start += MaxAttributeChecks {
+		batch := pending[start:min(start+MaxAttributeChecks, len(pending))]
+
+		// we always request paths so that positive results can be cached the same way as CheckAttribute's
+		req := CheckAttributesRequest{IncludePaths: true}
+		for _, p := range batch {
+			req.Checks = append(req.Checks, checks[p.index])
+		}
+
+		var resp CheckAttributesResponse
+		if err := c.client.Post(ctx, "/authz/checkattributes", req, &resp, consistencyOptions(options)...); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if len(resp.Results) != len(batch) {
+			return nil, ucerr.Errorf("expected %d results from checkattributes, got %d", len(batch), len(resp.Results))
+		}
+
+		for i, p := range batch {
+			results[p.index] = resp.Results[i]
+			if resp.Results[i].HasAttribute && !resp.Results[i].TimeBounded {
+				obj := Object{BaseModel: ucdb.NewBaseWithID(checks[p.index].SourceObjectID)}
+				cache.SaveItemsToCollection(ctx, c.cm, obj, resp.Results[i].Path, p.ckey, p.ckey, p.sentinel, false)
+			}
+		}
+	}
+
+	return results, nil
+}
+
 // ListAttributes returns a list of attributes that the source object has on the target object.
-func (c *Client) ListAttributes(ctx context.Context, sourceObjectID, targetObjectID uuid.UUID) ([]string, error) {
+func (c *Client) ListAttributes(ctx context.Context, sourceObjectID, targetObjectID uuid.UUID, opts ...Option) ([]string, error) {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	var resp []string
 	query := url.Values{}
 	query.Add("source_object_id", sourceObjectID.String())
 	query.Add("target_object_id", targetObjectID.String())
-	if err := c.client.Get(ctx, fmt.Sprintf("/authz/listattributes?%s", query.Encode()), &resp); err != nil {
+	if !options.asOf.IsZero() {
+		query.Add("as_of", options.asOf.Format(time.RFC3339Nano))
+	}
+	if err := c.client.Get(ctx, fmt.Sprintf("/authz/listattributes?%s", query.Encode()), &resp, consistencyOptions(options)...); err != nil {
 		return nil, ucerr.Wrap(err)
 	}
 	// This is currently unreachable until we return a path for each attribute from the server that we can use for invalidation.
@@ -1413,15 +1662,20 @@ type ListObjectsReachableWithAttributeResponse struct {
 }
 
 // ListObjectsReachableWithAttribute returns a list of object IDs of a certain type that are reachable from the source object with the given attribute
-func (c *Client) ListObjectsReachableWithAttribute(ctx context.Context, sourceObjectID uuid.UUID, targetObjectTypeID uuid.UUID, attributeName string) ([]uuid.UUID, error) {
+func (c *Client) ListObjectsReachableWithAttribute(ctx context.Context, sourceObjectID uuid.UUID, targetObjectTypeID uuid.UUID, attributeName string, opts ...Option) ([]uuid.UUID, error) {
 	ctx = request.NewRequestID(ctx)
 
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	var resp ListObjectsReachableWithAttributeResponse
 	query := url.Values{}
 	query.Add("source_object_id", sourceObjectID.String())
 	query.Add("target_object_type_id", targetObjectTypeID.String())
 	query.Add("attribute", attributeName)
-	if err := c.client.Get(ctx, fmt.Sprintf("/authz/listobjectsreachablewithattribute?%s", query.Encode()), &resp); err != nil {
+	if err := c.client.Get(ctx, fmt.Sprintf("/authz/listobjectsreachablewithattribute?%s", query.Encode()), &resp, consistencyOptions(options)...); err != nil {
 		return nil, ucerr.Wrap(err)
 	}
 
@@ -1429,6 +1683,44 @@ func (c *Client) ListObjectsReachableWithAttribute(ctx context.Context, sourceOb
 	return resp.Data, nil
 }
 
+// SourceWithAttribute is an object that has an attribute on a target object, and optionally the shortest path
+// through which it has the attribute
+type SourceWithAttribute struct {
+	ObjectID uuid.UUID           `json:"object_id" yaml:"object_id"`
+	Path     []AttributePathNode `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// ListSourcesWithAttributeResponse is the response from the ListSourcesWithAttribute endpoint.
+type ListSourcesWithAttributeResponse struct {
+	Data []SourceWithAttribute `json:"data" yaml:"data"`
+}
+
+// ListSourcesWithAttribute returns the objects of a certain type that have the given attribute on the target object,
+// i.e. the inverse of ListObjectsReachableWithAttribute. Use the IncludeAttributePaths option to also return the path
+// through which each source object has the attribute.
+func (c *Client) ListSourcesWithAttribute(ctx context.Context, targetObjectID uuid.UUID, sourceObjectTypeID uuid.UUID, attributeName string, opts ...Option) ([]SourceWithAttribute, error) {
+	ctx = request.NewRequestID(ctx)
+
+	options := c.options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	var resp ListSourcesWithAttributeResponse
+	query := url.Values{}
+	query.Add("target_object_id", targetObjectID.String())
+	query.Add("source_object_type_id", sourceObjectTypeID.String())
+	query.Add("attribute", attributeName)
+	if options.includeAttributePaths {
+		query.Add("include_paths", "true")
+	}
+	if err := c.client.Get(ctx, fmt.Sprintf("/authz/listsourceswithattribute?%s", query.Encode()), &resp, consistencyOptions(options)...); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return resp.Data, nil
+}
+
 // ListOrganizationsResponse is the response from the ListOrganizations endpoint.
 type ListOrganizationsResponse struct {
 	Data []Organization `json:"data" yaml:"data"`
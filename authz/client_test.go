@@ -6,6 +6,7 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sort"
 	"sync"
 	"testing"
@@ -50,6 +51,13 @@ func (tf *testFixture) listObjectsReachableWithAttribute(t *testing.T, srcID, tg
 	return resp
 }
 
+func (tf *testFixture) listSourcesWithAttribute(t *testing.T, tgtID, srcTypeID uuid.UUID, attr string, opts ...authz.Option) []authz.SourceWithAttribute {
+	t.Helper()
+	resp, err := tf.client.ListSourcesWithAttribute(context.Background(), tgtID, srcTypeID, attr, opts...)
+	assert.NoErr(t, err)
+	return resp
+}
+
 func uniqueName(name string) string {
 	return name + "_" + uuid.Must(uuid.NewV4()).String()
 }
@@ -105,6 +113,53 @@ func TestAuthzClient(t *testing.T) {
 		listResp := tf.listAttributes(t, user.ID, group.ID)
 		assert.Equal(t, []string{attributeName}, listResp)
 	})
+	t.Run("test_consistency_token", func(t *testing.T) {
+		t.Parallel()
+		etID := uuid.Must(uuid.NewV4())
+		attributeName := "view"
+		var token authz.ConsistencyToken
+		_, err := tf.client.CreateEdgeType(ctx, etID, authz.UserObjectTypeID, authz.GroupObjectTypeID, uniqueName("et"),
+			authz.Attributes{{Name: attributeName, Direct: true}}, authz.ReturnConsistencyToken(&token))
+		assert.NoErr(t, err)
+		assert.NotEqual(t, token, authz.ConsistencyToken(""))
+
+		user := tf.newTestUser()
+		group, err := tf.client.CreateObject(ctx, uuid.Must(uuid.NewV4()), authz.GroupObjectTypeID, uniqueName("group"))
+		assert.NoErr(t, err)
+
+		// warm the edges cache before the write
+		assert.False(t, tf.checkAttribute(t, user.ID, group.ID, attributeName).HasAttribute)
+
+		edgeToken := token
+		edge, err := tf.client.CreateEdge(ctx, uuid.Must(uuid.NewV4()), user.ID, group.ID, etID, authz.ReturnConsistencyToken(&edgeToken))
+		assert.NoErr(t, err)
+		assert.NotEqual(t, edgeToken, token)
+
+		resp, err := tf.client.CheckAttribute(ctx, user.ID, group.ID, attributeName, authz.AtLeastAsFresh(edgeToken))
+		assert.NoErr(t, err)
+		assert.True(t, resp.HasAttribute)
+
+		deleteToken := edgeToken
+		assert.NoErr(t, tf.client.DeleteEdge(ctx, edge.ID, authz.ReturnConsistencyToken(&deleteToken)))
+		assert.NotEqual(t, deleteToken, edgeToken)
+
+		results, err := tf.client.CheckAttributes(ctx, []authz.AttributeCheck{{SourceObjectID: user.ID, TargetObjectID: group.ID, Attribute: attributeName}},
+			authz.AtLeastAsFresh(deleteToken))
+		assert.NoErr(t, err)
+		assert.False(t, results[0].HasAttribute)
+
+		// tokens are opaque, so anything else is rejected
+		_, err = tf.client.CheckAttribute(ctx, user.ID, group.ID, attributeName, authz.AtLeastAsFresh("foo"))
+		assert.Equal(t, jsonclient.GetHTTPStatusCode(err), http.StatusBadRequest)
+
+		// a token from the future is clamped to the current database time rather than forcing a reload on every read
+		futureToken := authz.NewConsistencyToken(time.Now().Add(24 * time.Hour))
+		for range 2 {
+			resp, err = tf.client.CheckAttribute(ctx, user.ID, group.ID, attributeName, authz.AtLeastAsFresh(futureToken))
+			assert.NoErr(t, err)
+			assert.False(t, resp.HasAttribute)
+		}
+	})
 	t.Run("test_inherit_and_propogate", func(t *testing.T) {
 		t.Parallel()
 
@@ -271,6 +326,51 @@ func TestAuthzClient(t *testing.T) {
 
 		reachResp = tf.listObjectsReachableWithAttribute(t, person2.ID, ot, "write")
 		assert.Equal(t, len(reachResp), 2)
+
+		sourcesResp := tf.listSourcesWithAttribute(t, subResource.ID, ot, "read")
+		sourceIDs := []uuid.UUID{}
+		for _, source := range sourcesResp {
+			assert.Equal(t, len(source.Path), 0)
+			sourceIDs = append(sourceIDs, source.ObjectID)
+		}
+		assert.Equal(t, len(sourceIDs), 3)
+		assert.True(t, slices.Contains(sourceIDs, person1.ID))
+		assert.True(t, slices.Contains(sourceIDs, person2.ID))
+		assert.True(t, slices.Contains(sourceIDs, team.ID))
+
+		sourcesResp = tf.listSourcesWithAttribute(t, subResource.ID, ot, "write", authz.IncludeAttributePaths())
+		assert.Equal(t, len(sourcesResp), 2)
+		for _, source := range sourcesResp {
+			if source.ObjectID == person2.ID {
+				assert.Equal(t, source.Path, person2SubResourceWritePath.Path)
+			}
+		}
+
+		// person2's shortest read path is the direct edge
+		sourcesResp = tf.listSourcesWithAttribute(t, subResource.ID, ot, "read", authz.IncludeAttributePaths())
+		for _, source := range sourcesResp {
+			if source.ObjectID == person2.ID {
+				assert.Equal(t, len(source.Path), 2)
+				assert.Equal(t, source.Path[1].EdgeID, person2SubResourceEdge.ID)
+			}
+		}
+		assert.Equal(t, len(tf.listSourcesWithAttribute(t, subResource.ID, authz.UserObjectTypeID, "read")), 0)
+
+		checks := []authz.AttributeCheck{
+			{SourceObjectID: person1.ID, TargetObjectID: subResource.ID, Attribute: "read"},
+			{SourceObjectID: person1.ID, TargetObjectID: subResource.ID, Attribute: "write"},
+			{SourceObjectID: person2.ID, TargetObjectID: subResource.ID, Attribute: "write"},
+			{SourceObjectID: team.ID, TargetObjectID: subResource.ID, Attribute: "delete"},
+		}
+		for _, opts := range [][]authz.Option{{authz.BypassCache()}, {}} {
+			checkResp, err := tf.client.CheckAttributes(ctx, checks, opts...)
+			assert.NoErr(t, err)
+			assert.Equal(t, len(checkResp), len(checks), assert.Must())
+			assert.True(t, checkResp[0].HasAttribute)
+			assert.False(t, checkResp[1].HasAttribute)
+			assert.Equal(t, checkResp[2], *person2SubResourceWritePath)
+			assert.True(t, checkResp[3].HasAttribute)
+		}
 	})
 	t.Run("test_user_entry", func(t *testing.T) {
 		t.Parallel()
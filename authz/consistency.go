@@ -0,0 +1,46 @@
+package authz
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// ConsistencyTokenHeader is the HTTP header that carries a ConsistencyToken, both on the responses to
+// authz writes and on the reads that must observe those writes
+const ConsistencyTokenHeader = "X-Authz-Consistency-Token"
+
+const consistencyTokenVersion = "v1"
+
+// ConsistencyToken is an opaque token returned by authz writes (see ReturnConsistencyToken). Passing it to a
+// later read (see AtLeastAsFresh) guarantees that the read observes the write, without bypassing caches
+// for reads that don't need to.
+type ConsistencyToken string
+
+// NewConsistencyToken returns a token for the given database time
+func NewConsistencyToken(t time.Time) ConsistencyToken {
+	return ConsistencyToken(base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%s:%d", consistencyTokenVersion, t.UnixMicro())))
+}
+
+// Time returns the database time that a read must be at least as fresh as to observe the writes that issued the token
+func (t ConsistencyToken) Time() (time.Time, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(string(t))
+	if err != nil {
+		return time.Time{}, ucerr.Friendlyf(err, "invalid consistency token '%s'", t)
+	}
+
+	version, micros, found := strings.Cut(string(bs), ":")
+	if !found || version != consistencyTokenVersion {
+		return time.Time{}, ucerr.Friendlyf(nil, "invalid consistency token '%s'", t)
+	}
+
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, ucerr.Friendlyf(err, "invalid consistency token '%s'", t)
+	}
+	return time.UnixMicro(us).UTC(), nil
+}
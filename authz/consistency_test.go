@@ -0,0 +1,22 @@
+package authz_test
+
+import (
+	"testing"
+	"time"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/assert"
+)
+
+func TestConsistencyToken(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	token := authz.NewConsistencyToken(now)
+	parsed, err := token.Time()
+	assert.NoErr(t, err)
+	assert.Equal(t, parsed, now)
+
+	for _, invalid := range []authz.ConsistencyToken{"", "foo", authz.ConsistencyToken("djI6MTIz"), authz.ConsistencyToken("djE6Zm9v")} {
+		_, err := invalid.Time()
+		assert.NotNil(t, err, assert.Errorf("token %s", invalid))
+	}
+}
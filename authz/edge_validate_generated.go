@@ -20,5 +20,9 @@ func (o Edge) Validate() error {
 	if o.TargetObjectID.IsNil() {
 		return ucerr.Friendlyf(nil, "Edge.TargetObjectID (%v) can't be nil", o.ID)
 	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
 	return nil
 }
@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz/internal"
+	"userclouds.com/infra/cache"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+)
+
+// CleanExpiredEdgesForTenant cleans up edges whose validity window has ended for a tenant
+func CleanExpiredEdgesForTenant(ctx context.Context, tenantID uuid.UUID, tenantDB *ucdb.DB, cacheCfg *cache.Config, maxCandidates int, dryRun bool) error {
+	s := internal.NewStorage(ctx, tenantID, tenantDB, cacheCfg)
+	return ucerr.Wrap(s.CleanExpiredEdges(ctx, maxCandidates, dryRun))
+}
+
+// EraseObjectHistoryForTenant permanently deletes the edge history of an object for a tenant and pseudonymizes it as
+// the actor of any changes it made, returning the number of history records deleted or changed
+func EraseObjectHistoryForTenant(ctx context.Context, tenantID uuid.UUID, tenantDB *ucdb.DB, cacheCfg *cache.Config, objectID uuid.UUID) (int, error) {
+	s := internal.NewStorage(ctx, tenantID, tenantDB, cacheCfg)
+	n, err := s.EraseObjectHistory(ctx, objectID)
+	return n, ucerr.Wrap(err)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"userclouds.com/authz"
+	"userclouds.com/authz/internal"
+	"userclouds.com/authz/internal/tenantstate"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// consistencyMiddleware implements consistency tokens: successful writes return a token for the database time after
+// the write, and reads that pass a token back are guaranteed to observe that write, even if the edges cache is behind
+func consistencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isReadRequest(r) {
+			if token := r.Header.Get(authz.ConsistencyTokenHeader); token != "" {
+				ctx := r.Context()
+				minFreshness, err := authz.ConsistencyToken(token).Time()
+				if err != nil {
+					jsonapi.MarshalError(ctx, w, ucerr.Wrap(err), jsonapi.Code(http.StatusBadRequest))
+					return
+				}
+				minFreshness, err = tenantstate.MustGet(ctx).Storage.ClampMinFreshness(ctx, minFreshness)
+				if err != nil {
+					jsonapi.MarshalError(ctx, w, ucerr.Wrap(err), jsonapi.Code(http.StatusInternalServerError))
+					return
+				}
+				r = r.WithContext(internal.WithMinFreshness(ctx, minFreshness))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&consistencyTokenWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+// isReadRequest returns true for requests that don't change the graph, including checkattributes which is a POST
+// only so that the checks can be passed in the body
+func isReadRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet || strings.HasSuffix(r.URL.Path, "/checkattributes")
+}
+
+// consistencyTokenWriter sets the consistency token header on successful responses before they're written
+type consistencyTokenWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	wroteHeader bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *consistencyTokenWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if code >= http.StatusOK && code < http.StatusMultipleChoices {
+			ctx := w.r.Context()
+			// the token is only an optimization for the caller, so don't fail the write if we can't issue one
+			if token, err := tenantstate.MustGet(ctx).Storage.GetConsistencyToken(ctx); err != nil {
+				uclog.Errorf(ctx, "failed to get consistency token: %v", err)
+			} else {
+				w.Header().Set(authz.ConsistencyTokenHeader, string(token))
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (w *consistencyTokenWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -65,10 +66,10 @@ func NewHandler(ctx context.Context, storage *companyconfig.Storage, cfg config.
 		Put(h.migrateEdgeType).
 		WithAuthorizer(h.newRoleBasedAuthorizer())
 
-	return hb.Build()
+	return consistencyMiddleware(hb.Build())
 }
 
-//go:generate genhandler /authz collection,ObjectType,h.newRoleBasedAuthorizer(),/objecttypes collection,Object,h.newRoleBasedAuthorizer(),/objects collection,EdgeType,h.newRoleBasedAuthorizer(),/edgetypes collection,Edge,h.newRoleBasedAuthorizer(),/edges collection,Organization,h.newRoleBasedAuthorizer(),/organizations GET,listAttributes,/listattributes nestedcollection,Edge,h.newNestedRoleBasedAuthorizer(),/edges,Object GET,checkAttribute,/checkattribute GET,listObjectsReachableWithAttribute,/listobjectsreachablewithattribute
+//go:generate genhandler /authz collection,ObjectType,h.newRoleBasedAuthorizer(),/objecttypes collection,Object,h.newRoleBasedAuthorizer(),/objects collection,EdgeType,h.newRoleBasedAuthorizer(),/edgetypes collection,Edge,h.newRoleBasedAuthorizer(),/edges collection,Organization,h.newRoleBasedAuthorizer(),/organizations GET,listAttributes,/listattributes nestedcollection,Edge,h.newNestedRoleBasedAuthorizer(),/edges,Object GET,checkAttribute,/checkattribute POST,checkAttributes,/checkattributes GET,listObjectsReachableWithAttribute,/listobjectsreachablewithattribute GET,listSourcesWithAttribute,/listsourceswithattribute GET,exportSchema,/schema POST,applySchema,/schema/apply
 
 func (h *handler) newRoleBasedAuthorizer() uchttp.CollectionAuthorizer {
 	return &uchttp.MethodAuthorizer{
@@ -215,7 +216,7 @@ var ProtectedTypes = map[string]bool{
 // OpenAPI Summary: Delete Object Type
 // OpenAPI Tags: Object Types
 // OpenAPI Description: This endpoint deletes an object type by ID. It also deletes all objects, edge types and edges which use the object type.
-func (h *handler) deleteObjectType(ctx context.Context, id uuid.UUID, _ url.Values) (int, []auditlog.Entry, error) {
+func (h *handler) deleteObjectType(ctx context.Context, id uuid.UUID, query url.Values) (int, []auditlog.Entry, error) {
 
 	if err := h.checkEmployeeRequest(ctx); err != nil {
 		return http.StatusForbidden, nil, ucerr.Wrap(err)
@@ -232,7 +233,7 @@ func (h *handler) deleteObjectType(ctx context.Context, id uuid.UUID, _ url.Valu
 	}
 
 	// This also deletes all objects, edge types, & edges which use this object type
-	if err := tenantState.Storage.DeleteObjectType(ctx, ot.ID); err != nil {
+	if err := tenantState.Storage.DeleteObjectType(withChangeSource(ctx, query.Get("source")), ot.ID); err != nil {
 		return uchttp.SQLDeleteErrorMapper(err), nil, ucerr.Wrap(err)
 	}
 
@@ -392,6 +393,10 @@ func (h *handler) createEdgeType(ctx context.Context, req authz.CreateEdgeTypeRe
 		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
 	}
 
+	if err := tenantState.Storage.RecordEdgeTypeChange(withChangeSource(ctx, stringOrEmpty(req.Source)), internal.HistoryActionCreate, &req.EdgeType); err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
 	return &req.EdgeType, http.StatusCreated, auditlog.NewEntryArray(auth.GetAuditLogActor(ctx), auditlog.CreateEdgeType, auditlog.Payload{
 		"ID":                 req.EdgeType.ID,
 		"Name":               req.EdgeType.TypeName,
@@ -444,6 +449,10 @@ func (h *handler) updateEdgeType(ctx context.Context, id uuid.UUID, req authz.Up
 		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
 	}
 
+	if err := tenantState.Storage.RecordEdgeTypeChange(withChangeSource(ctx, stringOrEmpty(req.Source)), internal.HistoryActionUpdate, edgeType); err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
 	if err := tenantState.Storage.FlushCacheForEdgeType(ctx, id); err != nil {
 		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
 	}
@@ -462,7 +471,7 @@ func (h *handler) updateEdgeType(ctx context.Context, id uuid.UUID, req authz.Up
 // OpenAPI Summary: Delete Edge Type
 // OpenAPI Tags: Edge Types
 // OpenAPI Description: This endpoint deletes an edge type by ID. It also deletes all edges which use this edge type.
-func (h *handler) deleteEdgeType(ctx context.Context, id uuid.UUID, _ url.Values) (int, []auditlog.Entry, error) {
+func (h *handler) deleteEdgeType(ctx context.Context, id uuid.UUID, query url.Values) (int, []auditlog.Entry, error) {
 	tenantState := tenantstate.MustGet(ctx)
 
 	et, err := tenantState.Storage.GetEdgeType(ctx, id)
@@ -475,7 +484,7 @@ func (h *handler) deleteEdgeType(ctx context.Context, id uuid.UUID, _ url.Values
 	}
 
 	// This also deletes all edges which use this edge type
-	if err := tenantState.Storage.DeleteEdgeType(ctx, et.ID); err != nil {
+	if err := tenantState.Storage.DeleteEdgeType(withChangeSource(ctx, query.Get("source")), et.ID); err != nil {
 		// TODO: differentiate error types, e.g. object not found?
 		return uchttp.SQLDeleteErrorMapper(err), nil, ucerr.Wrap(err)
 	}
@@ -525,6 +534,11 @@ func (h *handler) migrateEdgeType(w http.ResponseWriter, r *http.Request, id uui
 		return
 	}
 
+	if err := tenantState.Storage.RecordEdgeTypeChange(withChangeSource(ctx, "migration"), internal.HistoryActionUpdate, et); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
 	jsonapi.Marshal(w, et)
 }
 
@@ -795,7 +809,7 @@ func (h *handler) updateObject(ctx context.Context, id uuid.UUID, req authz.Upda
 // OpenAPI Summary: Delete Object
 // OpenAPI Tags: Objects
 // OpenAPI Description: This endpoint deletes an object by ID. This also deletes all edges that use that object.
-func (h *handler) deleteObject(ctx context.Context, id uuid.UUID, _ url.Values) (int, []auditlog.Entry, error) {
+func (h *handler) deleteObject(ctx context.Context, id uuid.UUID, query url.Values) (int, []auditlog.Entry, error) {
 	tenantState := tenantstate.MustGet(ctx)
 
 	obj, err := tenantState.Storage.GetObject(ctx, id)
@@ -809,7 +823,7 @@ func (h *handler) deleteObject(ctx context.Context, id uuid.UUID, _ url.Values)
 	}
 
 	// This also deletes all edges.
-	if err := tenantState.Storage.DeleteObject(ctx, obj.ID); err != nil {
+	if err := tenantState.Storage.DeleteObject(withChangeSource(ctx, query.Get("source")), obj.ID); err != nil {
 		// TODO: differentiate error types, e.g. object not found?
 		return uchttp.SQLDeleteErrorMapper(err), nil, ucerr.Wrap(err)
 	}
@@ -995,7 +1009,7 @@ func (h *handler) createEdge(ctx context.Context, req authz.CreateEdgeRequest) (
 		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
-	if err := tenantState.Storage.InsertEdge(ctx, &req.Edge); err != nil {
+	if err := tenantState.Storage.InsertEdgeWithHistory(withChangeSource(ctx, stringOrEmpty(req.Source)), &req.Edge); err != nil {
 		if ucdb.IsUniqueViolation(err) {
 			if existing, err := tenantState.Storage.FindEdge(ctx, req.Edge.EdgeTypeID, req.Edge.SourceObjectID, req.Edge.TargetObjectID); err == nil {
 				if existing.ID == req.Edge.ID && existing.EqualsIgnoringID(&req.Edge) {
@@ -1025,13 +1039,15 @@ func (h *handler) createEdge(ctx context.Context, req authz.CreateEdgeRequest) (
 		"TypeID":         req.Edge.EdgeTypeID,
 		"SourceObjectID": req.Edge.SourceObjectID,
 		"TargetObjectID": req.Edge.TargetObjectID,
+		"ValidFrom":      req.Edge.ValidFrom,
+		"ValidUntil":     req.Edge.ValidUntil,
 	}), nil
 }
 
 // OpenAPI Summary: Delete Edges on Object
 // OpenAPI Tags: Edges
 // OpenAPI Description: This endpoint deletes all edges associated with an object (specified by ID).
-func (h *handler) deleteAllEdgesOnObject(ctx context.Context, objectID uuid.UUID, _ url.Values) (int, []auditlog.Entry, error) {
+func (h *handler) deleteAllEdgesOnObject(ctx context.Context, objectID uuid.UUID, query url.Values) (int, []auditlog.Entry, error) {
 	tenantState := tenantstate.MustGet(ctx)
 
 	obj, err := tenantState.Storage.GetObject(ctx, objectID)
@@ -1044,7 +1060,7 @@ func (h *handler) deleteAllEdgesOnObject(ctx context.Context, objectID uuid.UUID
 		return http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
-	if err := tenantState.Storage.DeleteEdgesFromObject(ctx, objectID); err != nil {
+	if err := tenantState.Storage.DeleteEdgesFromObject(withChangeSource(ctx, query.Get("source")), objectID); err != nil {
 		// TODO: differentiate error types, e.g. object not found?
 		return uchttp.SQLDeleteErrorMapper(err), nil, ucerr.Wrap(err)
 	}
@@ -1055,7 +1071,7 @@ func (h *handler) deleteAllEdgesOnObject(ctx context.Context, objectID uuid.UUID
 // OpenAPI Summary: Delete Edge
 // OpenAPI Tags: Edges
 // OpenAPI Description: This endpoint deletes an edge by ID.
-func (h *handler) deleteEdge(ctx context.Context, id uuid.UUID, _ url.Values) (int, []auditlog.Entry, error) {
+func (h *handler) deleteEdge(ctx context.Context, id uuid.UUID, query url.Values) (int, []auditlog.Entry, error) {
 	tenantState := tenantstate.MustGet(ctx)
 
 	e, err := tenantState.Storage.GetEdge(ctx, id)
@@ -1066,7 +1082,7 @@ func (h *handler) deleteEdge(ctx context.Context, id uuid.UUID, _ url.Values) (i
 		return http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
-	if err := tenantState.Storage.DeleteEdge(ctx, e.ID); err != nil {
+	if err := tenantState.Storage.DeleteEdgeWithHistory(withChangeSource(ctx, query.Get("source")), e.ID); err != nil {
 		return uchttp.SQLDeleteErrorMapper(err), nil, ucerr.Wrap(err)
 	}
 
@@ -1084,6 +1100,7 @@ type CheckAttributeParams struct {
 	SourceObjectID *string `description:"The object for which permissions are to be checked" query:"source_object_id"`
 	TargetObjectID *string `description:"The object on which permissions are to be checked" query:"target_object_id"`
 	Attribute      *string `description:"The permission to check" query:"attribute"`
+	AsOf           *string `description:"Optional - an RFC 3339 timestamp; if set, the check is evaluated against the graph as it existed at that time" query:"as_of"`
 }
 
 // OpenAPI Summary: Check Attribute
@@ -1111,7 +1128,22 @@ func (h *handler) checkAttribute(ctx context.Context, req CheckAttributeParams)
 		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
-	found, path, err := tenantState.Storage.CheckAttribute(ctx, h.checkAttributeServiceNameMap[tenantState.TenantID], sourceObjectID, targetObjectID, *req.Attribute)
+	if req.AsOf != nil {
+		asOf, err := parseAsOf(*req.AsOf)
+		if err != nil {
+			return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+		}
+		found, path, err := internal.CheckAttributeAsOfBFS(ctx, tenantState.Storage, tenantState.TenantID, sourceObjectID, targetObjectID, *req.Attribute, asOf)
+		if err != nil {
+			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+		}
+		return &authz.CheckAttributeResponse{
+			HasAttribute: found,
+			Path:         path,
+		}, http.StatusOK, nil, nil
+	}
+
+	resp, err := tenantState.Storage.CheckAttribute(ctx, h.checkAttributeServiceNameMap[tenantState.TenantID], sourceObjectID, targetObjectID, *req.Attribute)
 	if err != nil {
 		if ucdb.IsTransactionConflict(err) {
 			return nil, http.StatusConflict, nil, ucerr.WrapWithFriendlyStructure(nil, jsonclient.SDKStructuredError{
@@ -1121,15 +1153,54 @@ func (h *handler) checkAttribute(ctx context.Context, req CheckAttributeParams)
 		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
 	}
 
-	return &authz.CheckAttributeResponse{
-		HasAttribute: found,
-		Path:         path,
-	}, http.StatusOK, nil, nil
+	return resp, http.StatusOK, nil, nil
+}
+
+// OpenAPI Summary: Check Attributes
+// OpenAPI Tags: Permissions
+// OpenAPI Description: This endpoint receives a list of (source object ID, target object ID, attribute) checks. It returns a result for each check, in the same order, indicating whether the source object has the attribute permission on the target object.
+func (h *handler) checkAttributes(ctx context.Context, req authz.CheckAttributesRequest) (*authz.CheckAttributesResponse, int, []auditlog.Entry, error) {
+	if err := h.ensureTenantMember(ctx, false); err != nil {
+		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
+	}
+	tenantState := tenantstate.MustGet(ctx)
+
+	// rows on a list page typically share the same source object, so only validate each pair of objects once
+	validated := map[[2]uuid.UUID]bool{}
+	for _, check := range req.Checks {
+		pair := [2]uuid.UUID{check.SourceObjectID, check.TargetObjectID}
+		if validated[pair] {
+			continue
+		}
+		if err := h.validateTwoObjectOrganizations(ctx, check.SourceObjectID, check.TargetObjectID, false); err != nil {
+			return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
+		}
+		validated[pair] = true
+	}
+
+	results, err := tenantState.Storage.CheckAttributes(ctx, h.checkAttributeServiceNameMap[tenantState.TenantID], req.Checks)
+	if err != nil {
+		if ucdb.IsTransactionConflict(err) {
+			return nil, http.StatusConflict, nil, ucerr.WrapWithFriendlyStructure(nil, jsonclient.SDKStructuredError{
+				Error: "Conflict with write/delete operations in another region. Please retry the call",
+			})
+		}
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	if !req.IncludePaths {
+		for i := range results {
+			results[i].Path = nil
+		}
+	}
+
+	return &authz.CheckAttributesResponse{Results: results}, http.StatusOK, nil, nil
 }
 
 type listAttributesParams struct {
 	SourceObjectID *string `description:"Optional - allows filtering to a particular source object ID" query:"source_object_id"`
 	TargetObjectID *string `description:"Optional - allows filtering to a particular target object ID" query:"target_object_id"`
+	AsOf           *string `description:"Optional - an RFC 3339 timestamp; if set, the attributes are evaluated against the graph as it existed at that time" query:"as_of"`
 }
 
 // OpenAPI Summary: List Attributes
@@ -1154,6 +1225,18 @@ func (h *handler) listAttributes(ctx context.Context, req listAttributesParams)
 		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
+	if req.AsOf != nil {
+		asOf, err := parseAsOf(*req.AsOf)
+		if err != nil {
+			return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+		}
+		attributeNames, err := internal.ListAttributesAsOfBFS(ctx, tenantState.Storage, tenantState.TenantID, sourceObjectID, targetObjectID, asOf)
+		if err != nil {
+			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+		}
+		return attributeNames, http.StatusOK, nil, nil
+	}
+
 	candidateAttributes := map[string]bool{}
 
 	edgeTypeCache := make(map[uuid.UUID]*authz.EdgeType)
@@ -1228,11 +1311,11 @@ func (h *handler) listAttributes(ctx context.Context, req listAttributesParams)
 	attributeNames := []string{}
 	for attrName, lookup := range candidateAttributes {
 		if lookup {
-			found, _, err := tenantState.Storage.CheckAttribute(ctx, h.checkAttributeServiceNameMap[tenantState.TenantID], sourceObjectID, targetObjectID, attrName)
+			resp, err := tenantState.Storage.CheckAttribute(ctx, h.checkAttributeServiceNameMap[tenantState.TenantID], sourceObjectID, targetObjectID, attrName)
 			if err != nil {
 				return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
 			}
-			if found {
+			if resp.HasAttribute {
 				attributeNames = append(attributeNames, attrName)
 			}
 		}
@@ -1291,6 +1374,57 @@ func (h *handler) listObjectsReachableWithAttribute(ctx context.Context, req lis
 	return &authz.ListObjectsReachableWithAttributeResponse{Data: objectIDs}, http.StatusOK, nil, nil
 }
 
+type listSourcesWithAttributeParams struct {
+	TargetObjectID     *string `description:"The target object for which you are searching for source objects with the attribute" query:"target_object_id"`
+	SourceObjectTypeID *string `description:"The type of source objects to return" query:"source_object_type_id"`
+	Attribute          *string `description:"The permission that source objects must have on the target object" query:"attribute"`
+	IncludePaths       *string `description:"Optional - if true, the path through which each source object has the attribute is returned" query:"include_paths"`
+}
+
+// OpenAPI Summary: List Sources with Attribute
+// OpenAPI Tags: Permissions
+// OpenAPI Description: This endpoint receives a target object ID, source object type ID and attribute. It returns a list of objects of the source type that have the attribute on the target object.
+func (h *handler) listSourcesWithAttribute(ctx context.Context, req listSourcesWithAttributeParams) (*authz.ListSourcesWithAttributeResponse, int, []auditlog.Entry, error) {
+
+	if err := h.ensureTenantMember(ctx, false); err != nil {
+		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
+	}
+
+	tenantState := tenantstate.MustGet(ctx)
+
+	if req.TargetObjectID == nil || req.SourceObjectTypeID == nil || req.Attribute == nil || *req.Attribute == "" {
+		return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(nil, "missing required query parameter")
+	}
+
+	targetObjectID, err := uuid.FromString(*req.TargetObjectID)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	object, err := tenantState.Storage.GetObject(ctx, targetObjectID)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	if _, err := h.validateOrganizationForRequest(ctx, object.OrganizationID); err != nil {
+		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
+	}
+
+	sourceObjectTypeID, err := uuid.FromString(*req.SourceObjectTypeID)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	includePaths := req.IncludePaths != nil && *req.IncludePaths == "true"
+
+	sources, err := internal.ListSourcesWithAttributeBFS(ctx, tenantState.Storage, tenantState.TenantID, targetObjectID, sourceObjectTypeID, *req.Attribute, includePaths)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	return &authz.ListSourcesWithAttributeResponse{Data: sources}, http.StatusOK, nil, nil
+}
+
 // OpenAPI Summary: Create Organization
 // OpenAPI Tags: Organizations
 // OpenAPI Description: This endpoint creates an organization.
@@ -1575,3 +1709,28 @@ func (h *handler) checkEmployeeRequest(ctx context.Context) error {
 
 	return ucerr.Friendlyf(nil, "insufficient permissions to perform this action")
 }
+
+// parseAsOf parses the as_of query parameter for point-in-time checks, which can't be in the future
+func parseAsOf(asOf string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, asOf)
+	if err != nil {
+		return time.Time{}, ucerr.Friendlyf(err, "invalid as_of timestamp '%s', expected RFC 3339 format", asOf)
+	}
+	if t.After(time.Now().UTC()) {
+		return time.Time{}, ucerr.Friendlyf(nil, "as_of timestamp '%s' is in the future", asOf)
+	}
+	return t.UTC(), nil
+}
+
+// withChangeSource attributes the edge and edge type mutations made with the returned context to the
+// given (client-provided) source and the subject of the request, for the authz history tables
+func withChangeSource(ctx context.Context, source string) context.Context {
+	return internal.WithChangeSource(ctx, internal.ChangeSource{Source: source, Actor: auth.GetAuditLogActor(ctx)})
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
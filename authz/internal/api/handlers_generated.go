@@ -60,10 +60,39 @@ func handlerBuilder(builder *builder.HandlerBuilder, h *handler) {
 
 	builder.MethodHandler("/checkattribute").Get(h.checkAttributeGenerated)
 
+	builder.MethodHandler("/checkattributes").Post(h.checkAttributesGenerated)
+
 	builder.MethodHandler("/listattributes").Get(h.listAttributesGenerated)
 
 	builder.MethodHandler("/listobjectsreachablewithattribute").Get(h.listObjectsReachableWithAttributeGenerated)
 
+	builder.MethodHandler("/listsourceswithattribute").Get(h.listSourcesWithAttributeGenerated)
+
+	builder.MethodHandler("/schema").Get(h.exportSchemaGenerated)
+
+	builder.MethodHandler("/schema/apply").Post(h.applySchemaGenerated)
+
+}
+
+func (h *handler) applySchemaGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req authz.ApplySchemaRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	var res *authz.ApplySchemaResponse
+	res, code, entries, err := h.applySchema(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
 func (h *handler) checkAttributeGenerated(w http.ResponseWriter, r *http.Request) {
@@ -71,6 +100,10 @@ func (h *handler) checkAttributeGenerated(w http.ResponseWriter, r *http.Request
 	urlValues := r.URL.Query()
 
 	req := CheckAttributeParams{}
+	if urlValues.Has("as_of") && urlValues.Get("as_of") != "null" {
+		v := urlValues.Get("as_of")
+		req.AsOf = &v
+	}
 	if urlValues.Has("attribute") && urlValues.Get("attribute") != "null" {
 		v := urlValues.Get("attribute")
 		req.Attribute = &v
@@ -96,11 +129,58 @@ func (h *handler) checkAttributeGenerated(w http.ResponseWriter, r *http.Request
 	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
+func (h *handler) checkAttributesGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req authz.CheckAttributesRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	var res *authz.CheckAttributesResponse
+	res, code, entries, err := h.checkAttributes(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
+}
+
+func (h *handler) exportSchemaGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	urlValues := r.URL.Query()
+
+	req := exportSchemaParams{}
+	if urlValues.Has("include_ids") && urlValues.Get("include_ids") != "null" {
+		v := urlValues.Get("include_ids")
+		req.IncludeIDs = &v
+	}
+
+	var res *authz.Schema
+	res, code, entries, err := h.exportSchema(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
+}
+
 func (h *handler) listAttributesGenerated(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	urlValues := r.URL.Query()
 
 	req := listAttributesParams{}
+	if urlValues.Has("as_of") && urlValues.Get("as_of") != "null" {
+		v := urlValues.Get("as_of")
+		req.AsOf = &v
+	}
 	if urlValues.Has("source_object_id") && urlValues.Get("source_object_id") != "null" {
 		v := urlValues.Get("source_object_id")
 		req.SourceObjectID = &v
@@ -152,6 +232,40 @@ func (h *handler) listObjectsReachableWithAttributeGenerated(w http.ResponseWrit
 	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
+func (h *handler) listSourcesWithAttributeGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	urlValues := r.URL.Query()
+
+	req := listSourcesWithAttributeParams{}
+	if urlValues.Has("attribute") && urlValues.Get("attribute") != "null" {
+		v := urlValues.Get("attribute")
+		req.Attribute = &v
+	}
+	if urlValues.Has("include_paths") && urlValues.Get("include_paths") != "null" {
+		v := urlValues.Get("include_paths")
+		req.IncludePaths = &v
+	}
+	if urlValues.Has("source_object_type_id") && urlValues.Get("source_object_type_id") != "null" {
+		v := urlValues.Get("source_object_type_id")
+		req.SourceObjectTypeID = &v
+	}
+	if urlValues.Has("target_object_id") && urlValues.Get("target_object_id") != "null" {
+		v := urlValues.Get("target_object_id")
+		req.TargetObjectID = &v
+	}
+
+	var res *authz.ListSourcesWithAttributeResponse
+	res, code, entries, err := h.listSourcesWithAttribute(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
+}
+
 func (h *handler) createEdgeGenerated(w http.ResponseWriter, r *http.Request) {
 	entries := h.createEdgeGeneratedOverride(w, r)
 	auditlog.PostMultipleAsync(r.Context(), entries)
@@ -48,6 +48,24 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 		}
 	}
 
+	{
+		op, err := reflector.NewOperationContext(http.MethodPost, "/authz/checkattributes")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Check Attributes")
+		op.SetDescription("This endpoint receives a list of (source object ID, target object ID, attribute) checks. It returns a result for each check, in the same order, indicating whether the source object has the attribute permission on the target object.")
+		op.SetTags("Permissions")
+		op.AddReqStructure(new(authz.CheckAttributesRequest))
+		op.AddRespStructure(new(authz.CheckAttributesResponse), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusConflict))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusForbidden))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
+
 	{
 		op, err := reflector.NewOperationContext(http.MethodGet, "/authz/edges")
 		if err != nil {
@@ -266,6 +284,24 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 		}
 	}
 
+	{
+		op, err := reflector.NewOperationContext(http.MethodGet, "/authz/listsourceswithattribute")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("List Sources with Attribute")
+		op.SetDescription("This endpoint receives a target object ID, source object type ID and attribute. It returns a list of objects of the source type that have the attribute on the target object.")
+		op.SetTags("Permissions")
+		op.AddReqStructure(new(listSourcesWithAttributeParams))
+		op.AddRespStructure(new(authz.ListSourcesWithAttributeResponse), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusBadRequest))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusForbidden))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
+
 	{
 		op, err := reflector.NewOperationContext(http.MethodGet, "/authz/objects")
 		if err != nil {
@@ -586,4 +622,40 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 			uclog.Fatalf(ctx, "failed to add operation: %v", err)
 		}
 	}
+
+	{
+		op, err := reflector.NewOperationContext(http.MethodGet, "/authz/schema")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Export Schema")
+		op.SetDescription("This endpoint exports the tenant's object types, edge types and organizations as a portable schema, which references entities by name. System types and the company's default organization are excluded, and edge types in the default organization reference it as _default.")
+		op.SetTags("Schema")
+		op.AddReqStructure(new(exportSchemaParams))
+		op.AddRespStructure(new(authz.Schema), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusForbidden))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
+
+	{
+		op, err := reflector.NewOperationContext(http.MethodPost, "/authz/schema/apply")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Apply Schema")
+		op.SetDescription("This endpoint idempotently creates and updates object types, edge types and organizations to match the given schema, and returns the list of changes. With dry_run set, the changes are computed but not made. With delete_missing set, object types, edge types and organizations that are not in the schema are deleted, along with all of the objects and edges of deleted types. Organizations that still contain objects are not deleted.")
+		op.SetTags("Schema")
+		op.AddReqStructure(new(authz.ApplySchemaRequest))
+		op.AddRespStructure(new(authz.ApplySchemaResponse), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusBadRequest))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusConflict))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusForbidden))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
 }
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/authz/internal"
+	"userclouds.com/authz/internal/tenantstate"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uchttp"
+	"userclouds.com/infra/uctypes/set"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/security"
+	"userclouds.com/plex/manager"
+)
+
+// schemaChangeSource is recorded in the authz history for edge types created, updated and deleted by applying a schema
+const schemaChangeSource = "schema"
+
+type exportSchemaParams struct {
+	IncludeIDs *string `description:"Optional - if true, the ID of each entity is included so that applying the schema to another tenant creates entities with the same IDs" query:"include_ids"`
+}
+
+// OpenAPI Summary: Export Schema
+// OpenAPI Tags: Schema
+// OpenAPI Description: This endpoint exports the tenant's object types, edge types and organizations as a portable schema, which references entities by name. System types and the company's default organization are excluded, and edge types in the default organization reference it as _default.
+func (h *handler) exportSchema(ctx context.Context, req exportSchemaParams) (*authz.Schema, int, []auditlog.Entry, error) {
+	if err := h.checkEmployeeRequest(ctx); err != nil {
+		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
+	}
+
+	tenantState := tenantstate.MustGet(ctx)
+
+	schema, err := internal.ExportSchema(ctx, tenantState.Storage, tenantState.CompanyID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	if req.IncludeIDs == nil || *req.IncludeIDs != "true" {
+		schema.StripIDs()
+	}
+
+	return schema, http.StatusOK, nil, nil
+}
+
+// OpenAPI Summary: Apply Schema
+// OpenAPI Tags: Schema
+// OpenAPI Description: This endpoint idempotently creates and updates object types, edge types and organizations to match the given schema, and returns the list of changes. With dry_run set, the changes are computed but not made. With delete_missing set, object types, edge types and organizations that are not in the schema are deleted, along with all of the objects and edges of deleted types. Organizations that still contain objects are not deleted.
+func (h *handler) applySchema(ctx context.Context, req authz.ApplySchemaRequest) (*authz.ApplySchemaResponse, int, []auditlog.Entry, error) {
+	if err := h.checkEmployeeRequest(ctx); err != nil {
+		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
+	}
+
+	tenantState := tenantstate.MustGet(ctx)
+
+	current, err := internal.LoadSchema(ctx, tenantState.Storage, tenantState.CompanyID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	steps, err := internal.PlanSchema(*current, req.Schema, tenantState.CompanyID, req.DeleteMissing)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	if !tenantState.UseOrganizations {
+		for _, step := range steps {
+			if step.Kind == authz.SchemaEntityKindOrganization || (step.EdgeType != nil && step.EdgeType.Organization != "") {
+				return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(nil, "schema %s %s uses organizations, which are disabled for this tenant", step.Kind, step.Name)
+			}
+		}
+	}
+
+	// objects of deleted object types are deleted before any organizations are
+	deletedObjectTypeIDs := set.NewUUIDSet()
+	for _, step := range steps {
+		if step.Kind == authz.SchemaEntityKindObjectType && step.Action == authz.SchemaChangeActionDelete {
+			deletedObjectTypeIDs.Insert(step.ObjectType.ID)
+		}
+	}
+
+	resp := &authz.ApplySchemaResponse{Changes: []authz.SchemaChange{}, DryRun: req.DryRun}
+	for _, step := range steps {
+		// check up front (and for dry runs) that organizations can be deleted, rather than failing after other changes are made
+		if step.Kind == authz.SchemaEntityKindOrganization && step.Action == authz.SchemaChangeActionDelete {
+			if err := ensureOrganizationEmpty(ctx, tenantState.Storage, step.Organization.ID, deletedObjectTypeIDs); err != nil {
+				return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(err, "cannot delete organization %s", step.Name)
+			}
+		}
+		change := step.SchemaChange
+		if step.Kind == authz.SchemaEntityKindObjectType && step.Action == authz.SchemaChangeActionDelete {
+			objects, edges, err := tenantState.Storage.CountObjectTypeDependents(ctx, step.ObjectType.ID)
+			if err != nil {
+				return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+			}
+			change.Details = fmt.Sprintf("deletes %d objects and %d edges", objects, edges)
+		}
+		resp.Changes = append(resp.Changes, change)
+	}
+	if req.DryRun {
+		return resp, http.StatusOK, nil, nil
+	}
+
+	objectTypeIDs := map[string]uuid.UUID{}
+	for _, ot := range current.ObjectTypes {
+		objectTypeIDs[ot.TypeName] = ot.ID
+	}
+	orgIDs := map[string]uuid.UUID{authz.SchemaDefaultOrganization: tenantState.CompanyID}
+	for _, org := range current.Organizations {
+		orgIDs[org.Name] = org.ID
+	}
+
+	// each step goes through the same handler as the equivalent single-entity request, so that
+	// validation, cache invalidation and audit logging are identical. If a step fails, the steps
+	// before it have been applied, and re-applying the schema picks up where this left off.
+	var entries []auditlog.Entry
+	for _, step := range steps {
+		httpStatus, stepEntries, err := h.applySchemaStep(ctx, step, objectTypeIDs, orgIDs)
+		entries = append(entries, stepEntries...)
+		if err != nil {
+			if httpStatus < http.StatusBadRequest || httpStatus >= http.StatusInternalServerError {
+				httpStatus = http.StatusInternalServerError
+			}
+			return nil, httpStatus, entries, ucerr.Friendlyf(err, "failed to %s %s %s", step.Action, step.Kind, step.Name)
+		}
+	}
+
+	return resp, http.StatusOK, entries, nil
+}
+
+func (h *handler) applySchemaStep(ctx context.Context, step internal.SchemaStep, objectTypeIDs, orgIDs map[string]uuid.UUID) (int, []auditlog.Entry, error) {
+	switch step.Kind {
+	case authz.SchemaEntityKindOrganization:
+		switch step.Action {
+		case authz.SchemaChangeActionDelete:
+			code, entries, err := h.deleteSchemaOrganization(ctx, *step.Organization)
+			return code, entries, ucerr.Wrap(err)
+		case authz.SchemaChangeActionUpdate:
+			_, code, entries, err := h.updateOrganization(ctx, step.Organization.ID, authz.UpdateOrganizationRequest{
+				Name:   step.DesiredOrganization.Name,
+				Region: step.DesiredOrganization.Region,
+			})
+			return code, entries, ucerr.Wrap(err)
+		}
+
+		org := authz.Organization{
+			BaseModel: newSchemaBaseModel(step.Organization.ID),
+			Name:      step.Organization.Name,
+			Region:    step.Organization.Region,
+		}
+		_, code, entries, err := h.createOrganization(ctx, authz.CreateOrganizationRequest{Organization: org})
+		if err == nil {
+			orgIDs[org.Name] = org.ID
+		}
+		return code, entries, ucerr.Wrap(err)
+
+	case authz.SchemaEntityKindObjectType:
+		if step.Action == authz.SchemaChangeActionDelete {
+			code, entries, err := h.deleteObjectType(ctx, step.ObjectType.ID, url.Values{"source": []string{schemaChangeSource}})
+			return code, entries, ucerr.Wrap(err)
+		}
+
+		ot := authz.ObjectType{
+			BaseModel: newSchemaBaseModel(step.ObjectType.ID),
+			TypeName:  step.ObjectType.TypeName,
+		}
+		_, code, entries, err := h.createObjectType(ctx, authz.CreateObjectTypeRequest{ObjectType: ot})
+		if err == nil {
+			objectTypeIDs[ot.TypeName] = ot.ID
+		}
+		return code, entries, ucerr.Wrap(err)
+
+	case authz.SchemaEntityKindEdgeType:
+		source := schemaChangeSource
+		switch step.Action {
+		case authz.SchemaChangeActionDelete:
+			code, entries, err := h.deleteEdgeType(ctx, step.EdgeType.ID, url.Values{"source": []string{schemaChangeSource}})
+			return code, entries, ucerr.Wrap(err)
+		case authz.SchemaChangeActionUpdate:
+			_, code, entries, err := h.updateEdgeType(ctx, step.EdgeType.ID, authz.UpdateEdgeTypeRequest{
+				TypeName:   step.DesiredEdgeType.TypeName,
+				Attributes: step.DesiredEdgeType.Attributes,
+				Source:     &source,
+			})
+			return code, entries, ucerr.Wrap(err)
+		}
+
+		et := authz.EdgeType{
+			BaseModel:          newSchemaBaseModel(step.EdgeType.ID),
+			TypeName:           step.EdgeType.TypeName,
+			SourceObjectTypeID: objectTypeIDs[step.EdgeType.SourceObjectType],
+			TargetObjectTypeID: objectTypeIDs[step.EdgeType.TargetObjectType],
+			Attributes:         step.EdgeType.Attributes,
+		}
+		if step.EdgeType.Organization != "" {
+			et.OrganizationID = orgIDs[step.EdgeType.Organization]
+		}
+		_, code, entries, err := h.createEdgeType(ctx, authz.CreateEdgeTypeRequest{EdgeType: et, Source: &source})
+		return code, entries, ucerr.Wrap(err)
+	}
+
+	return http.StatusInternalServerError, nil, ucerr.Errorf("unexpected schema step %v", step.SchemaChange)
+}
+
+// ensureOrganizationEmpty returns an error if the organization contains any objects other than its own
+// group object, the objects for its login apps (which are deleted along with it), and objects of the
+// given object types (which are being deleted)
+func ensureOrganizationEmpty(ctx context.Context, s *internal.Storage, orgID uuid.UUID, deletedObjectTypeIDs set.Set[uuid.UUID]) error {
+	pager, err := authz.NewObjectPaginatorFromOptions(
+		pagination.Limit(pagination.MaxLimit),
+		pagination.Filter(fmt.Sprintf("('organization_id',EQ,'%v')", orgID)),
+	)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	for {
+		objects, respFields, err := s.ListObjectsPaginated(ctx, *pager)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		for _, obj := range objects {
+			if obj.ID != orgID && obj.TypeID != authz.LoginAppObjectTypeID && !deletedObjectTypeIDs.Contains(obj.TypeID) {
+				return ucerr.Friendlyf(nil, "organization still contains object %v", obj.ID)
+			}
+		}
+		if !pager.AdvanceCursor(*respFields) {
+			return nil
+		}
+	}
+}
+
+// deleteSchemaOrganization deletes an (empty) organization that is missing from a schema, along with its login apps
+func (h *handler) deleteSchemaOrganization(ctx context.Context, org authz.SchemaOrganization) (int, []auditlog.Entry, error) {
+	tenantState := tenantstate.MustGet(ctx)
+
+	if err := tenantState.Storage.DeleteOrganization(ctx, org.ID); err != nil {
+		return uchttp.SQLDeleteErrorMapper(err), nil, ucerr.Wrap(err)
+	}
+
+	entries := auditlog.NewEntryArray(auth.GetAuditLogActor(ctx), auditlog.DeleteOrganization, auditlog.Payload{
+		"ID":     org.ID,
+		"Name":   org.Name,
+		"Region": org.Region,
+	})
+
+	authzClient, err := authz.NewClient(tenantState.TenantURL.String(), authz.PassthroughAuthorization(), authz.JSONClient(security.PassXForwardedFor()))
+	if err != nil {
+		return http.StatusInternalServerError, entries, ucerr.Wrap(err)
+	}
+	ts := multitenant.MustGetTenantState(ctx)
+	mgr := manager.NewFromDB(ts.TenantDB, ts.CacheConfig)
+	apps, err := mgr.GetLoginApps(ctx, tenantState.TenantID, org.ID)
+	if err != nil {
+		return http.StatusInternalServerError, entries, ucerr.Wrap(err)
+	}
+	for _, app := range apps {
+		if err := mgr.DeleteLoginApp(ctx, tenantState.TenantID, authzClient, app.ID); err != nil {
+			return http.StatusInternalServerError, entries, ucerr.Wrap(err)
+		}
+	}
+
+	return http.StatusNoContent, entries, nil
+}
+
+// newSchemaBaseModel uses the ID from the schema if one was specified, so that IDs can be kept
+// consistent across tenants, and otherwise generates a new one
+func newSchemaBaseModel(id uuid.UUID) ucdb.BaseModel {
+	if id.IsNil() {
+		return ucdb.NewBase()
+	}
+	return ucdb.NewBaseWithID(id)
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -105,6 +106,9 @@ type bfsNode struct {
 
 	// The last node in the path
 	prevNodeIdx int
+
+	// True if any edge on the path to this node is only valid during a window of time
+	timeBounded bool
 }
 
 type bfsSearcher struct {
@@ -113,10 +117,12 @@ type bfsSearcher struct {
 	targetObjectTypeID uuid.UUID
 	attributeName      string
 
-	tenantID    uuid.UUID                               // for logging
-	edgeMap     map[uuid.UUID]map[uuid.UUID]*authz.Edge // map from source object id to outbound edges
-	edgeTypeMap map[uuid.UUID]*authz.EdgeType           // map from edge type id to edge type
-	mu          *sync.RWMutex                           // if not nil, mu is needed to synchronize access to the edge map and edge type map
+	tenantID       uuid.UUID                               // for logging
+	now            time.Time                               // edges are only traversed if they are valid at this time
+	edgeMap        map[uuid.UUID]map[uuid.UUID]*authz.Edge // map from source object id to outbound edges
+	reverseEdgeMap map[uuid.UUID]map[uuid.UUID]*authz.Edge // map from target object id to inbound edges, if loaded from the edge cache
+	edgeTypeMap    map[uuid.UUID]*authz.EdgeType           // map from edge type id to edge type
+	mu             *sync.RWMutex                           // if not nil, mu is needed to synchronize access to the edge map and edge type map
 
 	// Map of nodes that have been visited with a bitfield to indicate how they've been visited
 	// (i.e. what type of edge type attribute led to the node)
@@ -146,6 +152,7 @@ func newBfsSearcher(tenantID, sourceObjectID, targetObjectID, targetObjectTypeID
 		targetObjectTypeID: targetObjectTypeID,
 		attributeName:      attributeName,
 		tenantID:           tenantID,
+		now:                time.Now().UTC(),
 		visitedMap:         map[uuid.UUID]int{},
 		candidates:         []bfsNode{},
 		results:            []uuid.UUID{},
@@ -216,6 +223,10 @@ func (bfs *bfsSearcher) processEdges(i int, maxCandidatesSeen *int) (bool, error
 			// Only traverse outbound edges.
 			continue
 		}
+		if !edge.IsValidAt(bfs.now) {
+			// Skip edges that aren't valid yet or have expired (but haven't been cleaned up yet)
+			continue
+		}
 
 		// Look up the edge type in cache. There is a small chance of inconsistency between when we cached the edges and the edgetypes
 		edgeType, ok := bfs.edgeTypeMap[edge.EdgeTypeID]
@@ -232,6 +243,7 @@ func (bfs *bfsSearcher) processEdges(i int, maxCandidatesSeen *int) (bool, error
 						EdgeID:   edge.ID,
 					},
 					prevNodeIdx: i,
+					timeBounded: node.timeBounded || edge.IsTimeBounded(),
 				}
 
 				// Based on the attribute flag that got us to this candidate node in the first place,
@@ -319,21 +331,23 @@ func (bfs *bfsSearcher) doBFSFromStorage(ctx context.Context, s *Storage, skipCa
 }
 
 func (bfs *bfsSearcher) populateEdgeMapFromStorage(ctx context.Context, s *Storage, skipCache bool) error {
-	var edgeMap map[uuid.UUID]map[uuid.UUID]*authz.Edge
+	var edgeMap, reverseEdgeMap map[uuid.UUID]map[uuid.UUID]*authz.Edge
 	var err error
 
 	if !skipCache {
 		if bfs.edgeMap != nil {
 			return nil
 		}
-		if edgeMap, err = s.GetBFSEdgeGlobalCache(ctx); err != nil {
+		if edgeMap, reverseEdgeMap, err = s.getBFSEdgeGlobalCache(ctx); err != nil {
 			return ucerr.Wrap(err)
 		}
 	}
 
 	if edgeMap != nil {
 		bfs.edgeMap = edgeMap
+		bfs.reverseEdgeMap = reverseEdgeMap
 	} else {
+		bfs.reverseEdgeMap = nil
 		if bfs.edgeMap, err = uctrace.Wrap1(ctx, tracer, "loadEdgeMapFromDB", false, func(ctx context.Context) (map[uuid.UUID]map[uuid.UUID]*authz.Edge, error) {
 			return loadEdgeMapFromDB(ctx, s)
 		}); err != nil {
@@ -360,31 +374,146 @@ func (bfs *bfsSearcher) populateEdgeMapFromStorage(ctx context.Context, s *Stora
 //     'propagate' edges on the path).
 //  3. Zero or more edges marked 'propagate' may connect an intermediate (non-source) object to the target object.
 //  4. Cycles are disallowed, though an object may be revisited with a different edge attribute type (hard to imagine why?).
-func CheckAttributeBFS(ctx context.Context, s *Storage, tenantID, sourceObjectID, targetObjectID uuid.UUID, attributeName string, skipCache bool) (bool, []authz.AttributePathNode, error) {
-
+//  5. Edges with a validity window are only traversed if the current time is within the window.
+func CheckAttributeBFS(ctx context.Context, s *Storage, tenantID, sourceObjectID, targetObjectID uuid.UUID, attributeName string, skipCache bool) (*authz.CheckAttributeResponse, error) {
 	bfs := newBfsSearcher(tenantID, sourceObjectID, targetObjectID, uuid.Nil, attributeName)
 
-	var found bool
-	var err error
+	found, err := bfs.doBFSFromStorage(ctx, s, skipCache)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
 
-	found, err = bfs.doBFSFromStorage(ctx, s, skipCache)
+	result := bfs.result(found)
+	return &result, nil
+}
+
+// result returns the result of the search, including the path from the source to the target if one was found
+func (bfs *bfsSearcher) result(found bool) authz.CheckAttributeResponse {
+	if !found {
+		return authz.CheckAttributeResponse{}
+	}
+
+	result := authz.CheckAttributeResponse{
+		HasAttribute: true,
+		Path:         make([]authz.AttributePathNode, 0),
+		TimeBounded:  bfs.candidates[len(bfs.candidates)-1].timeBounded,
+	}
+	for i := len(bfs.candidates) - 1; i != -1; {
+		node := bfs.candidates[i]
+		result.Path = append([]authz.AttributePathNode{{
+			ObjectID: node.ObjectID,
+			EdgeID:   node.EdgeID,
+		}}, result.Path...)
+		i = node.prevNodeIdx
+	}
+	return result
+}
+
+// CheckAttributeAsOfBFS performs the same search as CheckAttributeBFS, but over the graph as it existed at the given time,
+// reconstructed from the edge and edge type history. Edge validity windows are also evaluated at that time.
+func CheckAttributeAsOfBFS(ctx context.Context, s *Storage, tenantID, sourceObjectID, targetObjectID uuid.UUID, attributeName string, asOf time.Time) (bool, []authz.AttributePathNode, error) {
+	edgeMap, edgeTypeMap, err := loadGraphAsOf(ctx, s, asOf)
 	if err != nil {
 		return false, nil, ucerr.Wrap(err)
 	}
 
-	var path []authz.AttributePathNode
-	if found {
-		path = make([]authz.AttributePathNode, 0)
-		for i := len(bfs.candidates) - 1; i != -1; {
-			node := bfs.candidates[i]
-			path = append([]authz.AttributePathNode{{
-				ObjectID: node.ObjectID,
-				EdgeID:   node.EdgeID,
-			}}, path...)
-			i = node.prevNodeIdx
+	bfs := newBfsSearcher(tenantID, sourceObjectID, targetObjectID, uuid.Nil, attributeName)
+	bfs.now = asOf
+	bfs.edgeMap = edgeMap
+	bfs.edgeTypeMap = edgeTypeMap
+
+	found, err := bfs.doBFS(ctx)
+	if err != nil {
+		return false, nil, ucerr.Wrap(err)
+	}
+
+	result := bfs.result(found)
+	return result.HasAttribute, result.Path, nil
+}
+
+// ListAttributesAsOfBFS returns the attributes that the source object had on the target object at the given time,
+// evaluated over the graph reconstructed from the edge and edge type history
+func ListAttributesAsOfBFS(ctx context.Context, s *Storage, tenantID, sourceObjectID, targetObjectID uuid.UUID, asOf time.Time) ([]string, error) {
+	edgeMap, edgeTypeMap, err := loadGraphAsOf(ctx, s, asOf)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return listAttributesInGraph(ctx, tenantID, sourceObjectID, targetObjectID, edgeMap, edgeTypeMap, asOf)
+}
+
+// listAttributesInGraph finds the candidate attributes from the edges leaving the source object and entering the target
+// object (like the listattributes handler does from storage), and then checks each candidate with a BFS over the given graph
+func listAttributesInGraph(ctx context.Context, tenantID, sourceObjectID, targetObjectID uuid.UUID,
+	edgeMap map[uuid.UUID]map[uuid.UUID]*authz.Edge, edgeTypeMap map[uuid.UUID]*authz.EdgeType, now time.Time) ([]string, error) {
+
+	candidateAttributes := map[string]bool{}
+	for _, edge := range edgeMap[sourceObjectID] {
+		for _, attr := range edgeTypeMap[edge.EdgeTypeID].Attributes {
+			if attr.Direct || attr.Inherit {
+				candidateAttributes[attr.Name] = false
+			}
+		}
+	}
+	for _, edges := range edgeMap {
+		for _, edge := range edges {
+			if edge.TargetObjectID != targetObjectID {
+				continue
+			}
+			for _, attr := range edgeTypeMap[edge.EdgeTypeID].Attributes {
+				if _, ok := candidateAttributes[attr.Name]; ok && (attr.Direct || attr.Propagate) {
+					candidateAttributes[attr.Name] = true
+				}
+			}
+		}
+	}
+
+	attributeNames := []string{}
+	for attrName, lookup := range candidateAttributes {
+		if !lookup {
+			continue
+		}
+
+		bfs := newBfsSearcher(tenantID, sourceObjectID, targetObjectID, uuid.Nil, attrName)
+		bfs.now = now
+		bfs.edgeMap = edgeMap
+		bfs.edgeTypeMap = edgeTypeMap
+
+		found, err := bfs.doBFS(ctx)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if found {
+			attributeNames = append(attributeNames, attrName)
 		}
 	}
-	return found, path, nil
+	sort.Strings(attributeNames)
+
+	return attributeNames, nil
+}
+
+// checkAttributesBFS performs CheckAttributeBFS for each of a batch of checks, loading the edge map only once and
+// sharing it between the searches. The results are returned in the same order as the checks.
+func checkAttributesBFS(ctx context.Context, s *Storage, tenantID uuid.UUID, checks []authz.AttributeCheck) ([]authz.CheckAttributeResponse, error) {
+	results := make([]authz.CheckAttributeResponse, 0, len(checks))
+
+	var edgeMap map[uuid.UUID]map[uuid.UUID]*authz.Edge
+	var edgeTypeMap map[uuid.UUID]*authz.EdgeType
+	for _, check := range checks {
+		bfs := newBfsSearcher(tenantID, check.SourceObjectID, check.TargetObjectID, uuid.Nil, check.Attribute)
+		bfs.edgeMap = edgeMap
+		bfs.edgeTypeMap = edgeTypeMap
+
+		found, err := bfs.doBFSFromStorage(ctx, s, false)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		edgeMap = bfs.edgeMap
+		edgeTypeMap = bfs.edgeTypeMap
+
+		results = append(results, bfs.result(found))
+	}
+
+	return results, nil
 }
 
 // ListObjectsReachableWithAttributeBFS performs the same BFS as CheckAttributeBFS, but passes in a nil target object ID and returns the list of
@@ -397,3 +526,200 @@ func ListObjectsReachableWithAttributeBFS(ctx context.Context, s *Storage, tenan
 
 	return bfs.results, nil
 }
+
+// reverseBfsSearcher walks the graph backwards from a target object to find all source objects of a given
+// type that have an attribute on the target. A valid path is still inherit* -> direct -> propagate* from source
+// to target, so walking backwards we start in the "propagate" state, where we may follow propagate edges or a
+// single direct edge, after which we may only follow inherit edges. Every object reached via the direct edge or
+// a subsequent inherit edge has the attribute on the target.
+type reverseBfsSearcher struct {
+	*bfsSearcher
+
+	sourceObjectTypeID uuid.UUID
+	found              map[uuid.UUID]bool
+	resultNodes        []int // index into candidates of the shortest path for each result
+}
+
+func newReverseBfsSearcher(tenantID, targetObjectID, sourceObjectTypeID uuid.UUID, attributeName string) *reverseBfsSearcher {
+	bfs := &reverseBfsSearcher{
+		bfsSearcher: &bfsSearcher{
+			targetObjectID: targetObjectID,
+			attributeName:  attributeName,
+			tenantID:       tenantID,
+			now:            time.Now().UTC(),
+			visitedMap:     map[uuid.UUID]int{targetObjectID: int(edgeAttrTypePropagate)},
+			candidates:     []bfsNode{},
+			results:        []uuid.UUID{},
+		},
+		sourceObjectTypeID: sourceObjectTypeID,
+		found:              map[uuid.UUID]bool{},
+		resultNodes:        []int{},
+	}
+
+	bfs.candidates = append(bfs.candidates, bfsNode{
+		AttributePathNode: authz.AttributePathNode{
+			ObjectID: targetObjectID,
+			EdgeID:   uuid.Nil},
+		attrType:    edgeAttrTypePropagate,
+		prevNodeIdx: -1,
+	})
+
+	return bfs
+}
+
+// buildReverseEdgeMap indexes the (source keyed) edge map by target object so we can walk edges backwards,
+// for edge maps that weren't loaded from the edge cache (which maintains its own reverse map)
+func buildReverseEdgeMap(edgeMap map[uuid.UUID]map[uuid.UUID]*authz.Edge) map[uuid.UUID]map[uuid.UUID]*authz.Edge {
+	reverseEdgeMap := map[uuid.UUID]map[uuid.UUID]*authz.Edge{}
+	for _, edges := range edgeMap {
+		for edgeID, edge := range edges {
+			if reverseEdgeMap[edge.TargetObjectID] == nil {
+				reverseEdgeMap[edge.TargetObjectID] = map[uuid.UUID]*authz.Edge{}
+			}
+			reverseEdgeMap[edge.TargetObjectID][edgeID] = edge
+		}
+	}
+	return reverseEdgeMap
+}
+
+func (bfs *reverseBfsSearcher) doReverseBFS(ctx context.Context) error {
+	if bfs.edgeMap == nil || bfs.edgeTypeMap == nil {
+		return ucerr.Errorf("edgeMap and/or edgeTypeMap is not set: %v %v", bfs.edgeMap, bfs.edgeTypeMap)
+	}
+
+	if bfs.mu != nil {
+		bfs.mu.RLock()
+		defer bfs.mu.RUnlock()
+	}
+
+	if bfs.reverseEdgeMap == nil {
+		bfs.reverseEdgeMap = buildReverseEdgeMap(bfs.edgeMap)
+	}
+
+	maxCandidatesSeen := 0
+	defer func() {
+		maxCandidatesMetric.WithLabelValues(bfs.tenantID.String()).Set(float64(maxCandidatesSeen))
+	}()
+	// NOTE: the candidates array grows as a result of the iterations in the loop itself
+	for i := 0; i < len(bfs.candidates); i++ {
+		maxCandidatesSeen = max(maxCandidatesSeen, i)
+		if i > maxCandidatesAllowed {
+			return ucerr.Errorf("exceeded max # of candidates (%d) in reverse BFS: %d", maxCandidatesAllowed, i)
+		}
+		if err := bfs.processInboundEdges(i); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// visit adds the node to the candidate list if the object hasn't been visited in the same state yet,
+// recording it as a result if it is of the requested type and reached in a state where it has the attribute
+func (bfs *reverseBfsSearcher) visit(node bfsNode, objectTypeID uuid.UUID) {
+	if checkVisited(bfs.visitedMap, node.ObjectID, node.attrType) {
+		return
+	}
+	bfs.visitedMap[node.ObjectID] = bfs.visitedMap[node.ObjectID] | int(node.attrType)
+	bfs.candidates = append(bfs.candidates, node)
+
+	if node.attrType == edgeAttrTypePropagate || objectTypeID != bfs.sourceObjectTypeID {
+		return
+	}
+	// an object may be reached with both direct and inherit edges, but BFS order means the first is the shortest
+	if bfs.found[node.ObjectID] {
+		return
+	}
+	bfs.found[node.ObjectID] = true
+	bfs.results = append(bfs.results, node.ObjectID)
+	bfs.resultNodes = append(bfs.resultNodes, len(bfs.candidates)-1)
+}
+
+func (bfs *reverseBfsSearcher) processInboundEdges(i int) error {
+	node := bfs.candidates[i]
+	for _, edge := range bfs.reverseEdgeMap[node.ObjectID] {
+		if !edge.IsValidAt(bfs.now) {
+			continue
+		}
+
+		edgeType, ok := bfs.edgeTypeMap[edge.EdgeTypeID]
+		if !ok {
+			return ucerr.Wrap(edgeCacheSyncError{ucerr.Errorf("Inconsistency detected for edgeType %v edge %v. Repeat the call", edge.EdgeTypeID, edge.BaseModel)})
+		}
+
+		for _, attr := range edgeType.Attributes {
+			if attr.Name != bfs.attributeName {
+				continue
+			}
+
+			newNode := bfsNode{
+				AttributePathNode: authz.AttributePathNode{
+					ObjectID: edge.SourceObjectID,
+					EdgeID:   edge.ID,
+				},
+				prevNodeIdx: i,
+			}
+
+			switch node.attrType {
+			case edgeAttrTypePropagate:
+				// The target itself, or an intermediate object that the attribute was propagated to, so
+				// the path backwards continues via either the direct edge or more propagate edges.
+				if attr.Direct {
+					newNode.attrType = edgeAttrTypeDirect
+					bfs.visit(newNode, edgeType.SourceObjectTypeID)
+				}
+				if attr.Propagate {
+					newNode.attrType = edgeAttrTypePropagate
+					bfs.visit(newNode, edgeType.SourceObjectTypeID)
+				}
+			case edgeAttrTypeDirect, edgeAttrTypeInherit:
+				// Objects that inherit from an object with the attribute also have it, but (as in the forward
+				// search) the target can't be an intermediate object that is inherited from.
+				if attr.Inherit && node.ObjectID != bfs.targetObjectID {
+					newNode.attrType = edgeAttrTypeInherit
+					bfs.visit(newNode, edgeType.SourceObjectTypeID)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the attribute path from the source object found at the given candidate index to the target
+func (bfs *reverseBfsSearcher) path(i int) []authz.AttributePathNode {
+	node := bfs.candidates[i]
+	path := []authz.AttributePathNode{{ObjectID: node.ObjectID, EdgeID: uuid.Nil}}
+	for node.prevNodeIdx != -1 {
+		next := bfs.candidates[node.prevNodeIdx]
+		path = append(path, authz.AttributePathNode{ObjectID: next.ObjectID, EdgeID: node.EdgeID})
+		node = next
+	}
+	return path
+}
+
+// ListSourcesWithAttributeBFS is the inverse of ListObjectsReachableWithAttributeBFS: it walks the same valid paths
+// (see CheckAttributeBFS) backwards from the target object, and returns all objects of the given type that have the
+// attribute on the target, optionally along with the shortest path from each of them to the target.
+func ListSourcesWithAttributeBFS(ctx context.Context, s *Storage, tenantID, targetObjectID uuid.UUID, sourceObjectTypeID uuid.UUID, attributeName string, includePaths bool) ([]authz.SourceWithAttribute, error) {
+	bfs := newReverseBfsSearcher(tenantID, targetObjectID, sourceObjectTypeID, attributeName)
+	if err := bfs.populateEdgeMapFromStorage(ctx, s, false); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if err := bfs.doReverseBFS(ctx); err != nil {
+		var ecse edgeCacheSyncError
+		if errors.As(err, &ecse) {
+			resetGlobalCacheForTenant(bfs.tenantID, s.edgeCache, true)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	sources := make([]authz.SourceWithAttribute, 0, len(bfs.results))
+	for i, objectID := range bfs.results {
+		source := authz.SourceWithAttribute{ObjectID: objectID}
+		if includePaths {
+			source.Path = bfs.path(bfs.resultNodes[i])
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
@@ -1 +1,166 @@
 package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/assert"
+)
+
+type testGraph struct {
+	edgeMap     map[uuid.UUID]map[uuid.UUID]*authz.Edge
+	edgeTypeMap map[uuid.UUID]*authz.EdgeType
+}
+
+func (g *testGraph) addEdgeType(sourceTypeID, targetTypeID uuid.UUID, attributes authz.Attributes) uuid.UUID {
+	id := uuid.Must(uuid.NewV4())
+	g.edgeTypeMap[id] = &authz.EdgeType{
+		SourceObjectTypeID: sourceTypeID,
+		TargetObjectTypeID: targetTypeID,
+		Attributes:         attributes,
+	}
+	return id
+}
+
+func (g *testGraph) addEdge(source, target, edgeTypeID uuid.UUID) uuid.UUID {
+	edge := &authz.Edge{
+		EdgeTypeID:     edgeTypeID,
+		SourceObjectID: source,
+		TargetObjectID: target,
+	}
+	edge.ID = uuid.Must(uuid.NewV4())
+	if g.edgeMap[source] == nil {
+		g.edgeMap[source] = map[uuid.UUID]*authz.Edge{}
+	}
+	g.edgeMap[source][edge.ID] = edge
+	return edge.ID
+}
+
+func TestReverseBFS(t *testing.T) {
+	g := &testGraph{edgeMap: map[uuid.UUID]map[uuid.UUID]*authz.Edge{}, edgeTypeMap: map[uuid.UUID]*authz.EdgeType{}}
+	userType := uuid.Must(uuid.NewV4())
+	resourceType := uuid.Must(uuid.NewV4())
+
+	member := g.addEdgeType(userType, userType, authz.Attributes{{Name: "read", Inherit: true}})
+	owner := g.addEdgeType(userType, resourceType, authz.Attributes{{Name: "read", Direct: true}})
+	parent := g.addEdgeType(resourceType, resourceType, authz.Attributes{{Name: "read", Propagate: true}})
+
+	// alice -member-> team -owner-> folder -parent-> doc, bob -owner-> doc, carol -member-> bob
+	alice, bob, carol, team := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	folder, doc := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	aliceTeam := g.addEdge(alice, team, member)
+	teamFolder := g.addEdge(team, folder, owner)
+	folderDoc := g.addEdge(folder, doc, parent)
+	g.addEdge(bob, doc, owner)
+	g.addEdge(carol, bob, member)
+
+	search := func(target uuid.UUID, attribute string) *reverseBfsSearcher {
+		bfs := newReverseBfsSearcher(uuid.Nil, target, userType, attribute)
+		bfs.edgeMap = g.edgeMap
+		bfs.edgeTypeMap = g.edgeTypeMap
+		assert.NoErr(t, bfs.doReverseBFS(context.Background()))
+		return bfs
+	}
+
+	bfs := search(doc, "read")
+	assert.Equal(t, len(bfs.results), 4)
+	for _, id := range []uuid.UUID{alice, bob, carol, team} {
+		found := false
+		for _, result := range bfs.results {
+			found = found || result == id
+		}
+		assert.True(t, found, assert.Errorf("missing source %v", id))
+	}
+
+	for i, id := range bfs.results {
+		if id == alice {
+			assert.Equal(t, bfs.path(bfs.resultNodes[i]), []authz.AttributePathNode{
+				{ObjectID: alice, EdgeID: uuid.Nil},
+				{ObjectID: team, EdgeID: aliceTeam},
+				{ObjectID: folder, EdgeID: teamFolder},
+				{ObjectID: doc, EdgeID: folderDoc},
+			})
+		}
+	}
+
+	// only team members have read access on the folder
+	bfs = search(folder, "read")
+	assert.Equal(t, len(bfs.results), 2)
+
+	assert.Equal(t, len(search(doc, "write").results), 0)
+
+	// the edge cache keeps its reverse edge map in sync with the forward one as edges are added and removed
+	cacheRecord := &EdgeCacheRecord{EdgesMap: map[uuid.UUID]map[uuid.UUID]*authz.Edge{}}
+	for _, edges := range g.edgeMap {
+		for _, edge := range edges {
+			cacheRecord.addEdge(edge)
+		}
+	}
+	assert.Equal(t, cacheRecord.ReverseEdgesMap, buildReverseEdgeMap(g.edgeMap))
+
+	cacheRecord.removeEdge(folder, folderDoc)
+	delete(g.edgeMap[folder], folderDoc)
+	assert.Equal(t, cacheRecord.ReverseEdgesMap, buildReverseEdgeMap(g.edgeMap))
+
+	bfs = newReverseBfsSearcher(uuid.Nil, doc, userType, "read")
+	bfs.edgeMap = cacheRecord.EdgesMap
+	bfs.reverseEdgeMap = cacheRecord.ReverseEdgesMap
+	bfs.edgeTypeMap = g.edgeTypeMap
+	assert.NoErr(t, bfs.doReverseBFS(context.Background()))
+	assert.Equal(t, len(bfs.results), 2)
+}
+
+func TestTimeBoundedEdges(t *testing.T) {
+	g := &testGraph{edgeMap: map[uuid.UUID]map[uuid.UUID]*authz.Edge{}, edgeTypeMap: map[uuid.UUID]*authz.EdgeType{}}
+	userType := uuid.Must(uuid.NewV4())
+	resourceType := uuid.Must(uuid.NewV4())
+
+	member := g.addEdgeType(userType, userType, authz.Attributes{{Name: "read", Inherit: true}})
+	owner := g.addEdgeType(userType, resourceType, authz.Attributes{{Name: "read", Direct: true}})
+
+	// alice's team membership has expired, bob's ownership hasn't started yet, carol's is temporary and dave's is permanent
+	alice, bob, carol, dave, team := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	doc := uuid.Must(uuid.NewV4())
+	now := time.Now().UTC()
+	g.edgeMap[alice][g.addEdge(alice, team, member)].ValidUntil = now.Add(-time.Minute)
+	g.addEdge(team, doc, owner)
+	g.edgeMap[bob][g.addEdge(bob, doc, owner)].ValidFrom = now.Add(time.Hour)
+	carolDoc := g.addEdge(carol, doc, owner)
+	g.edgeMap[carol][carolDoc].ValidFrom = now.Add(-time.Hour)
+	g.edgeMap[carol][carolDoc].ValidUntil = now.Add(time.Hour)
+	g.addEdge(dave, doc, owner)
+
+	check := func(source uuid.UUID) authz.CheckAttributeResponse {
+		bfs := newBfsSearcher(uuid.Nil, source, doc, uuid.Nil, "read")
+		bfs.edgeMap = g.edgeMap
+		bfs.edgeTypeMap = g.edgeTypeMap
+		found, err := bfs.doBFS(context.Background())
+		assert.NoErr(t, err)
+		return bfs.result(found)
+	}
+
+	assert.False(t, check(alice).HasAttribute)
+	assert.False(t, check(bob).HasAttribute)
+
+	result := check(carol)
+	assert.True(t, result.HasAttribute)
+	assert.True(t, result.TimeBounded)
+	assert.Equal(t, result.Path, []authz.AttributePathNode{{ObjectID: carol, EdgeID: uuid.Nil}, {ObjectID: doc, EdgeID: carolDoc}})
+
+	result = check(dave)
+	assert.True(t, result.HasAttribute)
+	assert.False(t, result.TimeBounded)
+
+	bfs := newReverseBfsSearcher(uuid.Nil, doc, userType, "read")
+	bfs.edgeMap = g.edgeMap
+	bfs.edgeTypeMap = g.edgeTypeMap
+	assert.NoErr(t, bfs.doReverseBFS(context.Background()))
+	assert.Equal(t, len(bfs.results), 3)
+	for _, id := range bfs.results {
+		assert.True(t, id == team || id == carol || id == dave, assert.Errorf("unexpected source %v", id))
+	}
+}
@@ -16,7 +16,7 @@ import (
 )
 
 // CheckAttributeViaService checks if a source object has a specific attribute on a target object
-func CheckAttributeViaService(ctx context.Context, checkAttributeServiceName string, tenantID, sourceObjectID, targetObjectID uuid.UUID, attributeName string) (bool, []authz.AttributePathNode, error) {
+func CheckAttributeViaService(ctx context.Context, checkAttributeServiceName string, tenantID, sourceObjectID, targetObjectID uuid.UUID, attributeName string) (*authz.CheckAttributeResponse, error) {
 	ctx = request.NewRequestID(ctx)
 
 	var host string
@@ -33,8 +33,8 @@ func CheckAttributeViaService(ctx context.Context, checkAttributeServiceName str
 	query.Add("target_object_id", targetObjectID.String())
 	query.Add("attribute", attributeName)
 	if err := client.Get(ctx, fmt.Sprintf("/checkattribute/%s?%s", tenantID, query.Encode()), &resp); err != nil {
-		return false, nil, ucerr.Wrap(err)
+		return nil, ucerr.Wrap(err)
 	}
 
-	return resp.HasAttribute, resp.Path, nil
+	return &resp, nil
 }
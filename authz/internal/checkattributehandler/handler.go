@@ -5,7 +5,6 @@ import (
 
 	"github.com/gofrs/uuid"
 
-	"userclouds.com/authz"
 	"userclouds.com/authz/internal"
 	"userclouds.com/infra/jsonapi"
 	"userclouds.com/infra/ucerr"
@@ -65,15 +64,12 @@ func (h *handler) checkAttribute(w http.ResponseWriter, r *http.Request, tenantI
 	}
 	s := internal.NewStorage(ctx, ts.ID, ts.TenantDB, ts.CacheConfig)
 
-	hasAttribute, path, err := internal.CheckAttributeBFS(ctx, s, tenantID, sourceObjectID, targetObjectID, attributeName, false)
+	resp, err := internal.CheckAttributeBFS(ctx, s, tenantID, sourceObjectID, targetObjectID, attributeName, false)
 	if err != nil {
 		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
 		return
 	}
 
-	jsonapi.Marshal(w, authz.CheckAttributeResponse{
-		HasAttribute: hasAttribute,
-		Path:         path,
-	}, jsonapi.Code(http.StatusOK))
+	jsonapi.Marshal(w, resp, jsonapi.Code(http.StatusOK))
 
 }
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/ucerr"
+)
+
+const ctxMinFreshness contextKey = 2
+
+// WithMinFreshness returns a context whose reads of the edges observe every change made at or before minFreshness,
+// reloading the edges cache from the primary if needed
+func WithMinFreshness(ctx context.Context, minFreshness time.Time) context.Context {
+	return context.WithValue(ctx, ctxMinFreshness, minFreshness)
+}
+
+func getMinFreshness(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(ctxMinFreshness).(time.Time); ok {
+		return t
+	}
+	return time.Time{}
+}
+
+// GetConsistencyToken returns a token for the current database time, which reads can pass to observe every write made so far
+func (s *Storage) GetConsistencyToken(ctx context.Context) (authz.ConsistencyToken, error) {
+	t, err := s.getDBTime(ctx)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return authz.NewConsistencyToken(t), nil
+}
+
+// ClampMinFreshness returns minFreshness, or the current database time if the token is from the future. No read could
+// observe a later time, so each read passing such a token would otherwise reload the edges from the primary.
+func (s *Storage) ClampMinFreshness(ctx context.Context, minFreshness time.Time) (time.Time, error) {
+	t, err := s.getDBTime(ctx)
+	if err != nil {
+		return time.Time{}, ucerr.Wrap(err)
+	}
+	if minFreshness.After(t) {
+		return t, nil
+	}
+	return minFreshness, nil
+}
+
+// getDBTime returns the current time on the primary, which is comparable to the updated and deleted times of edges
+func (s *Storage) getDBTime(ctx context.Context) (time.Time, error) {
+	const q = `/* lint-sql-ok */ SELECT CLOCK_TIMESTAMP();`
+	var t time.Time
+	if err := s.db.GetContext(ctx, "GetDBTime", &t, q); err != nil {
+		return time.Time{}, ucerr.Wrap(err)
+	}
+	return t.UTC(), nil
+}
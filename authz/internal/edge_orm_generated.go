@@ -57,7 +57,7 @@ func (s *Storage) IsEdgeSoftDeleted(ctx context.Context, id uuid.UUID) (bool, er
 func (s *Storage) GetEdge(ctx context.Context, id uuid.UUID) (*authz.Edge, error) {
 	return cache.ServerGetItem(ctx, s.cm, id, authz.EdgeKeyID, authz.IsModifiedKeyID,
 		func(id uuid.UUID, conflict cache.Sentinel, obj *authz.Edge) error {
-			const q = "SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+			const q = "SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
 
 			if err := s.db.GetContextWithDirty(ctx, "GetEdge", obj, q, cache.IsTombstoneSentinel(string(conflict)), id); err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
@@ -98,7 +98,7 @@ func (s *Storage) getEdgeByColumns(ctx context.Context, secondaryKey cache.Key,
 		}
 		args += fmt.Sprintf("%s=$%d", columnNames[i], i+1)
 	}
-	q := fmt.Sprintf("SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE %s AND deleted='0001-01-01 00:00:00';", args)
+	q := fmt.Sprintf("SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE %s AND deleted='0001-01-01 00:00:00';", args)
 
 	var obj authz.Edge
 	if err := s.db.GetContextWithDirty(ctx, "GetEdgeForName", &obj, q, cache.IsTombstoneSentinel(string(conflict)), columnValues...); err != nil {
@@ -140,7 +140,7 @@ func (s *Storage) GetEdgeSoftDeleted(ctx context.Context, id uuid.UUID) (*authz.
 			return nil, ucerr.Friendlyf(err, "soft-deleted Edge %v not found", id)
 		}
 	}
-	const q = "SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
 
 	var obj authz.Edge
 	if err := s.db.GetContextWithDirty(ctx, "GetEdgeSoftDeleted", &obj, q, cache.IsTombstoneSentinel(string(conflict)), id); err != nil {
@@ -214,7 +214,7 @@ func (s *Storage) GetEdgesForIDs(ctx context.Context, errorOnMissing bool, ids .
 
 // getEdgesHelperForIDs loads multiple Edge for a given list of IDs from the DB
 func (s *Storage) getEdgesHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]authz.Edge, error) {
-	const q = "SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
 	var objects []authz.Edge
 	if err := s.db.SelectContextWithDirty(ctx, "GetEdgesForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -297,7 +297,7 @@ func (s *Storage) listInnerEdgesPaginated(ctx context.Context, p pagination.Pagi
 
 	// the inner query requires an alias for postgres, so we always call it tmp
 	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
-	q := fmt.Sprintf("SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM (SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+	q := fmt.Sprintf("SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM (SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
 
 	var objsDB []authz.Edge
 	if err := s.db.SelectContextWithDirty(ctx, "ListEdgesPaginated", &objsDB, q, cache.IsTombstoneSentinel(string(conflict)), queryFields...); err != nil {
@@ -337,8 +337,8 @@ func (s *Storage) SaveEdge(ctx context.Context, obj *authz.Edge) error {
 
 // SaveEdge saves a Edge
 func (s *Storage) saveInnerEdge(ctx context.Context, obj *authz.Edge) error {
-	const q = "INSERT INTO edges (id, updated, deleted, edge_type_id, source_object_id, target_object_id) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, edge_type_id = $3, source_object_id = $4, target_object_id = $5 WHERE (edges.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
-	if err := s.db.GetContext(ctx, "SaveEdge", obj, q, obj.ID, obj.Deleted, obj.EdgeTypeID, obj.SourceObjectID, obj.TargetObjectID); err != nil {
+	const q = "INSERT INTO edges (id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, edge_type_id = $3, source_object_id = $4, target_object_id = $5, valid_from = $6, valid_until = $7 WHERE (edges.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveEdge", obj, q, obj.ID, obj.Deleted, obj.EdgeTypeID, obj.SourceObjectID, obj.TargetObjectID, obj.ValidFrom, obj.ValidUntil); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ucerr.Friendlyf(err, "Edge %v not found", obj.ID)
 		}
@@ -362,8 +362,8 @@ func (s *Storage) InsertEdge(ctx context.Context, obj *authz.Edge) error {
 
 // insertInnerEdge inserts a Edge without resolving conflict with existing rows
 func (s *Storage) insertInnerEdge(ctx context.Context, obj *authz.Edge) error {
-	const q = "INSERT INTO edges (id, updated, deleted, edge_type_id, source_object_id, target_object_id) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5) RETURNING id, created, updated;"
-	if err := s.db.GetContext(ctx, "InsertEdge", obj, q, obj.ID, obj.Deleted, obj.EdgeTypeID, obj.SourceObjectID, obj.TargetObjectID); err != nil {
+	const q = "INSERT INTO edges (id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7) RETURNING id, created, updated;"
+	if err := s.db.GetContext(ctx, "InsertEdge", obj, q, obj.ID, obj.Deleted, obj.EdgeTypeID, obj.SourceObjectID, obj.TargetObjectID, obj.ValidFrom, obj.ValidUntil); err != nil {
 		return ucerr.Wrap(err)
 	}
 	return nil
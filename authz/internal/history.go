@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+)
+
+// HistoryAction is the kind of mutation recorded in the edge and edge type history tables
+type HistoryAction string
+
+// HistoryAction values
+const (
+	HistoryActionCreate HistoryAction = "create"
+	HistoryActionUpdate HistoryAction = "update"
+	HistoryActionDelete HistoryAction = "delete"
+)
+
+// Sources recorded for mutations that aren't made on behalf of an API request
+const (
+	ChangeSourceExpiredEdgeCleanup = "expired_edge_cleanup"
+	ChangeSourceProvisioning       = "provisioning"
+)
+
+// ChangeSource identifies who made a mutation, and through what, for the history tables
+type ChangeSource struct {
+	// Source is the (client-provided) system that made the change, e.g. "idp" or "console"
+	Source string
+	// Actor is the subject of the request that made the change
+	Actor string
+}
+
+type contextKey int
+
+const ctxChangeSource contextKey = 1
+
+// WithChangeSource returns a context that attributes any edge or edge type mutations made with it to the given source
+func WithChangeSource(ctx context.Context, cs ChangeSource) context.Context {
+	return context.WithValue(ctx, ctxChangeSource, cs)
+}
+
+func getChangeSource(ctx context.Context) ChangeSource {
+	if cs, ok := ctx.Value(ctxChangeSource).(ChangeSource); ok {
+		return cs
+	}
+	return ChangeSource{}
+}
+
+// EdgeHistoryRecord is a single row in the append-only edge history, capturing the edge as it was after the change
+type EdgeHistoryRecord struct {
+	ucdb.BaseModel
+
+	EdgeID         uuid.UUID     `db:"edge_id"`
+	Action         HistoryAction `db:"action"`
+	Source         string        `db:"source"`
+	Actor          string        `db:"actor"`
+	EdgeTypeID     uuid.UUID     `db:"edge_type_id"`
+	SourceObjectID uuid.UUID     `db:"source_object_id"`
+	TargetObjectID uuid.UUID     `db:"target_object_id"`
+	ValidFrom      time.Time     `db:"valid_from"`
+	ValidUntil     time.Time     `db:"valid_until"`
+}
+
+// EdgeTypeHistoryRecord is a single row in the append-only edge type history, capturing the edge type as it was after the change
+type EdgeTypeHistoryRecord struct {
+	ucdb.BaseModel
+
+	EdgeTypeID         uuid.UUID        `db:"edge_type_id"`
+	Action             HistoryAction    `db:"action"`
+	Source             string           `db:"source"`
+	Actor              string           `db:"actor"`
+	TypeName           string           `db:"type_name"`
+	SourceObjectTypeID uuid.UUID        `db:"source_object_type_id"`
+	TargetObjectTypeID uuid.UUID        `db:"target_object_type_id"`
+	Attributes         authz.Attributes `db:"attributes"`
+	OrganizationID     uuid.UUID        `db:"organization_id"`
+}
+
+// RecordEdgeTypeChange appends a change to the given edge type to the edge type history, attributed to the change source in the context
+func (s *Storage) RecordEdgeTypeChange(ctx context.Context, action HistoryAction, edgeType *authz.EdgeType) error {
+	cs := getChangeSource(ctx)
+	const q = `/* lint-sql-ok */ INSERT INTO edge_type_history (id, updated, deleted, edge_type_id, action, source, actor, type_name, source_object_type_id, target_object_type_id, attributes, organization_id)
+		VALUES (gen_random_uuid(), NOW(), '0001-01-01 00:00:00', $1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	if _, err := s.db.ExecContext(ctx, "RecordEdgeTypeChange", q, edgeType.ID, action, cs.Source, cs.Actor,
+		edgeType.TypeName, edgeType.SourceObjectTypeID, edgeType.TargetObjectTypeID, edgeType.Attributes, edgeType.OrganizationID); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// RecordStoredEdgeTypeChange appends a live edge type to the edge type history as it is stored (deleting an edge type
+// records its history, along with that of its edges, as part of the delete)
+func (s *Storage) RecordStoredEdgeTypeChange(ctx context.Context, action HistoryAction, edgeTypeID uuid.UUID) error {
+	cs := getChangeSource(ctx)
+	const q = `/* lint-sql-ok */ INSERT INTO edge_type_history (id, updated, deleted, edge_type_id, action, source, actor, type_name, source_object_type_id, target_object_type_id, attributes, organization_id)
+		SELECT gen_random_uuid(), NOW(), '0001-01-01 00:00:00', id, $2, $3, $4, type_name, source_object_type_id, target_object_type_id, attributes, organization_id FROM edge_types WHERE id=$1 AND deleted='0001-01-01 00:00:00';`
+	if _, err := s.db.ExecContext(ctx, "RecordStoredEdgeTypeChange", q, edgeTypeID, action, cs.Source, cs.Actor); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// erasedActor replaces the ID of an erased user as the actor of the changes they made in the history tables
+const erasedActor = "erased"
+
+// EraseObjectHistory permanently deletes the history of every edge to or from an object, and replaces the object's
+// ID as the actor of any edge or edge type change it made, so that an erased user can't be recovered from the
+// history. It returns the number of history records deleted or changed.
+func (s *Storage) EraseObjectHistory(ctx context.Context, objectID uuid.UUID) (int, error) {
+	const deleteEdgesQuery = `/* lint-sql-ok */ DELETE FROM edge_history WHERE source_object_id=$1 OR target_object_id=$1;`
+	const edgeActorQuery = `/* lint-sql-ok */ UPDATE edge_history SET actor=$2, updated=NOW() WHERE actor=$1;`
+	const edgeTypeActorQuery = `/* lint-sql-ok */ UPDATE edge_type_history SET actor=$2, updated=NOW() WHERE actor=$1;`
+
+	count := 0
+	for _, q := range []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{"EraseObjectEdgeHistory", deleteEdgesQuery, []any{objectID}},
+		{"EraseObjectEdgeHistoryActor", edgeActorQuery, []any{objectID.String(), erasedActor}},
+		{"EraseObjectEdgeTypeHistoryActor", edgeTypeActorQuery, []any{objectID.String(), erasedActor}},
+	} {
+		res, err := s.db.ExecContext(ctx, q.name, q.query, q.args...)
+		if err != nil {
+			return 0, ucerr.Wrap(err)
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return 0, ucerr.Wrap(err)
+		}
+		count += int(ra)
+	}
+	return count, nil
+}
+
+// listEdgeHistoryAsOf returns the latest history record for each edge that was changed at or before asOf
+func (s *Storage) listEdgeHistoryAsOf(ctx context.Context, asOf time.Time) ([]EdgeHistoryRecord, error) {
+	const q = `/* lint-sql-ok */ SELECT DISTINCT ON (edge_id) id, created, updated, deleted, edge_id, action, source, actor, edge_type_id, source_object_id, target_object_id, valid_from, valid_until
+		FROM edge_history WHERE created<=$1 AND deleted='0001-01-01 00:00:00' ORDER BY edge_id, created DESC;`
+	var records []EdgeHistoryRecord
+	if err := s.db.SelectContext(ctx, "ListEdgeHistoryAsOf", &records, q, asOf); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return records, nil
+}
+
+// listEdgeTypeHistoryAsOf returns the latest history record for each edge type that was changed at or before asOf
+func (s *Storage) listEdgeTypeHistoryAsOf(ctx context.Context, asOf time.Time) ([]EdgeTypeHistoryRecord, error) {
+	const q = `/* lint-sql-ok */ SELECT DISTINCT ON (edge_type_id) id, created, updated, deleted, edge_type_id, action, source, actor, type_name, source_object_type_id, target_object_type_id, attributes, organization_id
+		FROM edge_type_history WHERE created<=$1 AND deleted='0001-01-01 00:00:00' ORDER BY edge_type_id, created DESC;`
+	var records []EdgeTypeHistoryRecord
+	if err := s.db.SelectContext(ctx, "ListEdgeTypeHistoryAsOf", &records, q, asOf); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return records, nil
+}
+
+// graphFromHistory builds the BFS edge and edge type maps from the latest history record of each edge and edge type,
+// skipping deleted ones. Edges whose edge type didn't exist yet (or anymore) are skipped too, since they couldn't be traversed.
+func graphFromHistory(edgeRecords []EdgeHistoryRecord, edgeTypeRecords []EdgeTypeHistoryRecord) (map[uuid.UUID]map[uuid.UUID]*authz.Edge, map[uuid.UUID]*authz.EdgeType) {
+	eTM := make(map[uuid.UUID]*authz.EdgeType)
+	for _, r := range edgeTypeRecords {
+		if r.Action == HistoryActionDelete {
+			continue
+		}
+		eTM[r.EdgeTypeID] = &authz.EdgeType{
+			BaseModel:          ucdb.NewBaseWithID(r.EdgeTypeID),
+			TypeName:           r.TypeName,
+			SourceObjectTypeID: r.SourceObjectTypeID,
+			TargetObjectTypeID: r.TargetObjectTypeID,
+			Attributes:         r.Attributes,
+			OrganizationID:     r.OrganizationID,
+		}
+	}
+
+	eM := make(map[uuid.UUID]map[uuid.UUID]*authz.Edge)
+	for _, r := range edgeRecords {
+		if r.Action == HistoryActionDelete {
+			continue
+		}
+		if _, ok := eTM[r.EdgeTypeID]; !ok {
+			continue
+		}
+		if eM[r.SourceObjectID] == nil {
+			eM[r.SourceObjectID] = make(map[uuid.UUID]*authz.Edge)
+		}
+		eM[r.SourceObjectID][r.EdgeID] = &authz.Edge{
+			BaseModel:      ucdb.NewBaseWithID(r.EdgeID),
+			EdgeTypeID:     r.EdgeTypeID,
+			SourceObjectID: r.SourceObjectID,
+			TargetObjectID: r.TargetObjectID,
+			ValidFrom:      r.ValidFrom,
+			ValidUntil:     r.ValidUntil,
+		}
+	}
+
+	return eM, eTM
+}
+
+// loadGraphAsOf loads the edge and edge type maps for the graph as it existed at the given time
+func loadGraphAsOf(ctx context.Context, s *Storage, asOf time.Time) (map[uuid.UUID]map[uuid.UUID]*authz.Edge, map[uuid.UUID]*authz.EdgeType, error) {
+	edgeRecords, err := s.listEdgeHistoryAsOf(ctx, asOf)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	edgeTypeRecords, err := s.listEdgeTypeHistoryAsOf(ctx, asOf)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	edgeMap, edgeTypeMap := graphFromHistory(edgeRecords, edgeTypeRecords)
+	return edgeMap, edgeTypeMap, nil
+}
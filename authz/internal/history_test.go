@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/assert"
+)
+
+func TestGraphFromHistory(t *testing.T) {
+	ctx := context.Background()
+	userType, docType := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	alice, doc := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	viewer, editor, deletedType := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+
+	// the latest record for each edge type, as loaded for some point in time
+	edgeTypeRecords := []EdgeTypeHistoryRecord{
+		{EdgeTypeID: viewer, Action: HistoryActionUpdate, SourceObjectTypeID: userType, TargetObjectTypeID: docType,
+			Attributes: authz.Attributes{{Name: "read", Direct: true}}},
+		{EdgeTypeID: editor, Action: HistoryActionCreate, SourceObjectTypeID: userType, TargetObjectTypeID: docType,
+			Attributes: authz.Attributes{{Name: "read", Direct: true}, {Name: "write", Direct: true}}},
+		{EdgeTypeID: deletedType, Action: HistoryActionDelete, SourceObjectTypeID: userType, TargetObjectTypeID: docType,
+			Attributes: authz.Attributes{{Name: "delete", Direct: true}}},
+	}
+
+	marchFirst := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	marchThird := marchFirst.Add(48 * time.Hour)
+	edgeRecords := []EdgeHistoryRecord{
+		{EdgeID: uuid.Must(uuid.NewV4()), Action: HistoryActionCreate, EdgeTypeID: viewer, SourceObjectID: alice, TargetObjectID: doc},
+		{EdgeID: uuid.Must(uuid.NewV4()), Action: HistoryActionDelete, EdgeTypeID: editor, SourceObjectID: alice, TargetObjectID: doc},
+		// edges of an edge type that had been deleted can't grant anything
+		{EdgeID: uuid.Must(uuid.NewV4()), Action: HistoryActionCreate, EdgeTypeID: deletedType, SourceObjectID: alice, TargetObjectID: doc},
+	}
+
+	edgeMap, edgeTypeMap := graphFromHistory(edgeRecords, edgeTypeRecords)
+	assert.Equal(t, len(edgeTypeMap), 2)
+	assert.Equal(t, len(edgeMap[alice]), 1)
+
+	attributes, err := listAttributesInGraph(ctx, uuid.Nil, alice, doc, edgeMap, edgeTypeMap, marchThird)
+	assert.NoErr(t, err)
+	assert.Equal(t, attributes, []string{"read"})
+
+	// an edge that grants write access for a window of time is only traversed within that window
+	edgeRecords = append(edgeRecords, EdgeHistoryRecord{EdgeID: uuid.Must(uuid.NewV4()), Action: HistoryActionCreate, EdgeTypeID: editor,
+		SourceObjectID: alice, TargetObjectID: doc, ValidFrom: marchFirst, ValidUntil: marchFirst.Add(24 * time.Hour)})
+	edgeMap, edgeTypeMap = graphFromHistory(edgeRecords, edgeTypeRecords)
+
+	attributes, err = listAttributesInGraph(ctx, uuid.Nil, alice, doc, edgeMap, edgeTypeMap, marchFirst.Add(time.Hour))
+	assert.NoErr(t, err)
+	assert.Equal(t, attributes, []string{"read", "write"})
+
+	attributes, err = listAttributesInGraph(ctx, uuid.Nil, alice, doc, edgeMap, edgeTypeMap, marchThird)
+	assert.NoErr(t, err)
+	assert.Equal(t, attributes, []string{"read"})
+
+	attributes, err = listAttributesInGraph(ctx, uuid.Nil, doc, alice, edgeMap, edgeTypeMap, marchThird)
+	assert.NoErr(t, err)
+	assert.Equal(t, len(attributes), 0)
+}
@@ -0,0 +1,320 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+)
+
+// LoadSchema returns every object type, edge type and organization in the tenant as a Schema. Unlike
+// ExportSchema, the result includes system types and the company's default organization so that
+// references to them can be resolved. Edge types in the default organization reference it as
+// authz.SchemaDefaultOrganization, so that they match across tenants.
+func LoadSchema(ctx context.Context, s *Storage, companyID uuid.UUID) (*authz.Schema, error) {
+	schema := authz.Schema{
+		ObjectTypes:   []authz.SchemaObjectType{},
+		EdgeTypes:     []authz.SchemaEdgeType{},
+		Organizations: []authz.SchemaOrganization{},
+	}
+
+	objectTypeNames := map[uuid.UUID]string{}
+	otPager, err := authz.NewObjectTypePaginatorFromOptions(pagination.Limit(pagination.MaxLimit))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	for {
+		objectTypes, respFields, err := s.ListObjectTypesPaginated(ctx, *otPager)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		for _, ot := range objectTypes {
+			objectTypeNames[ot.ID] = ot.TypeName
+			schema.ObjectTypes = append(schema.ObjectTypes, authz.SchemaObjectType{ID: ot.ID, TypeName: ot.TypeName})
+		}
+		if !otPager.AdvanceCursor(*respFields) {
+			break
+		}
+	}
+
+	orgNames := map[uuid.UUID]string{}
+	orgPager, err := authz.NewOrganizationPaginatorFromOptions(pagination.Limit(pagination.MaxLimit))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	for {
+		orgs, respFields, err := s.ListOrganizationsPaginated(ctx, *orgPager)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		for _, org := range orgs {
+			orgNames[org.ID] = org.Name
+			schema.Organizations = append(schema.Organizations, authz.SchemaOrganization{ID: org.ID, Name: org.Name, Region: org.Region})
+		}
+		if !orgPager.AdvanceCursor(*respFields) {
+			break
+		}
+	}
+
+	etPager, err := authz.NewEdgeTypePaginatorFromOptions(pagination.Limit(pagination.MaxLimit))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	for {
+		edgeTypes, respFields, err := s.ListEdgeTypesPaginated(ctx, *etPager)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		for _, et := range edgeTypes {
+			set := authz.SchemaEdgeType{
+				ID:               et.ID,
+				TypeName:         et.TypeName,
+				SourceObjectType: objectTypeNames[et.SourceObjectTypeID],
+				TargetObjectType: objectTypeNames[et.TargetObjectTypeID],
+				Attributes:       et.Attributes,
+			}
+			if et.OrganizationID == companyID {
+				set.Organization = authz.SchemaDefaultOrganization
+			} else if !et.OrganizationID.IsNil() {
+				set.Organization = orgNames[et.OrganizationID]
+			}
+			schema.EdgeTypes = append(schema.EdgeTypes, set)
+		}
+		if !etPager.AdvanceCursor(*respFields) {
+			break
+		}
+	}
+
+	return &schema, nil
+}
+
+// ExportSchema returns the tenant's authz schema, excluding system types and the company's default organization
+func ExportSchema(ctx context.Context, s *Storage, companyID uuid.UUID) (*authz.Schema, error) {
+	schema, err := LoadSchema(ctx, s, companyID)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	exported := exportableSchema(*schema, companyID)
+	return &exported, nil
+}
+
+func exportableSchema(schema authz.Schema, companyID uuid.UUID) authz.Schema {
+	exported := authz.Schema{
+		ObjectTypes:   []authz.SchemaObjectType{},
+		EdgeTypes:     []authz.SchemaEdgeType{},
+		Organizations: []authz.SchemaOrganization{},
+	}
+	for _, ot := range schema.ObjectTypes {
+		if !authz.IsSystemTypeName(ot.TypeName) {
+			exported.ObjectTypes = append(exported.ObjectTypes, ot)
+		}
+	}
+	for _, et := range schema.EdgeTypes {
+		if !authz.IsSystemTypeName(et.TypeName) {
+			exported.EdgeTypes = append(exported.EdgeTypes, et)
+		}
+	}
+	for _, org := range schema.Organizations {
+		if org.ID != companyID {
+			exported.Organizations = append(exported.Organizations, org)
+		}
+	}
+
+	sort.Slice(exported.ObjectTypes, func(i, j int) bool { return exported.ObjectTypes[i].TypeName < exported.ObjectTypes[j].TypeName })
+	sort.Slice(exported.EdgeTypes, func(i, j int) bool { return exported.EdgeTypes[i].TypeName < exported.EdgeTypes[j].TypeName })
+	sort.Slice(exported.Organizations, func(i, j int) bool { return exported.Organizations[i].Name < exported.Organizations[j].Name })
+	return exported
+}
+
+// SchemaStep is a single change in a schema plan, along with the entity it applies to. For creates the
+// entity is the desired one, for updates and deletes it is the existing one (and carries its ID).
+type SchemaStep struct {
+	authz.SchemaChange
+
+	ObjectType   *authz.SchemaObjectType
+	EdgeType     *authz.SchemaEdgeType
+	Organization *authz.SchemaOrganization
+
+	// DesiredEdgeType and DesiredOrganization hold the target state for updates
+	DesiredEdgeType     *authz.SchemaEdgeType
+	DesiredOrganization *authz.SchemaOrganization
+}
+
+// PlanSchema computes the ordered steps needed to bring the current schema (as returned by LoadSchema)
+// in line with the desired one. Steps are ordered so that every step's dependencies exist when it runs:
+// organizations, then object types, then edge types are created and updated, and deletes happen last in
+// reverse dependency order. System types and the company's default organization are never changed, and
+// nothing is deleted unless deleteMissing is set.
+func PlanSchema(current, desired authz.Schema, companyID uuid.UUID, deleteMissing bool) ([]SchemaStep, error) {
+	currentObjectTypes := map[string]authz.SchemaObjectType{}
+	for _, ot := range current.ObjectTypes {
+		currentObjectTypes[ot.TypeName] = ot
+	}
+	currentEdgeTypes := map[string]authz.SchemaEdgeType{}
+	for _, et := range current.EdgeTypes {
+		currentEdgeTypes[et.TypeName] = et
+	}
+	currentOrgs := map[string]authz.SchemaOrganization{}
+	defaultOrgName := ""
+	for _, org := range current.Organizations {
+		currentOrgs[org.Name] = org
+		if org.ID == companyID {
+			defaultOrgName = org.Name
+		}
+	}
+
+	desiredObjectTypes := map[string]bool{}
+	for _, ot := range desired.ObjectTypes {
+		desiredObjectTypes[ot.TypeName] = true
+	}
+	desiredEdgeTypes := map[string]bool{}
+	for _, et := range desired.EdgeTypes {
+		desiredEdgeTypes[et.TypeName] = true
+	}
+	desiredOrgs := map[string]bool{}
+	for _, org := range desired.Organizations {
+		desiredOrgs[org.Name] = true
+	}
+
+	var orgSteps, objectTypeCreates, edgeTypeSteps, edgeTypeDeletes, objectTypeDeletes, orgDeletes []SchemaStep
+
+	for _, org := range desired.Organizations {
+		existing, found := currentOrgs[org.Name]
+		if !found {
+			orgSteps = append(orgSteps, SchemaStep{
+				SchemaChange: authz.SchemaChange{Action: authz.SchemaChangeActionCreate, Kind: authz.SchemaEntityKindOrganization, Name: org.Name},
+				Organization: &org,
+			})
+			continue
+		}
+		if existing.ID == companyID {
+			return nil, ucerr.Friendlyf(nil, "organization %s is the company's default organization and cannot be managed by a schema", org.Name)
+		}
+		if existing.Region != org.Region {
+			orgSteps = append(orgSteps, SchemaStep{
+				SchemaChange: authz.SchemaChange{
+					Action:  authz.SchemaChangeActionUpdate,
+					Kind:    authz.SchemaEntityKindOrganization,
+					Name:    org.Name,
+					Details: fmt.Sprintf("region: %s -> %s", existing.Region, org.Region),
+				},
+				Organization:        &existing,
+				DesiredOrganization: &org,
+			})
+		}
+	}
+
+	for _, ot := range desired.ObjectTypes {
+		if _, found := currentObjectTypes[ot.TypeName]; !found {
+			objectTypeCreates = append(objectTypeCreates, SchemaStep{
+				SchemaChange: authz.SchemaChange{Action: authz.SchemaChangeActionCreate, Kind: authz.SchemaEntityKindObjectType, Name: ot.TypeName},
+				ObjectType:   &ot,
+			})
+		}
+	}
+
+	objectTypeExists := func(typeName string) bool {
+		if desiredObjectTypes[typeName] {
+			return true
+		}
+		// objects types missing from the schema are only kept around if they're system types or we aren't deleting
+		_, found := currentObjectTypes[typeName]
+		return found && (authz.IsSystemTypeName(typeName) || !deleteMissing)
+	}
+
+	orgExists := func(name string) bool {
+		if name == authz.SchemaDefaultOrganization || desiredOrgs[name] {
+			return true
+		}
+		// likewise, organizations missing from the schema are only kept around if we aren't deleting
+		_, found := currentOrgs[name]
+		return found && !deleteMissing
+	}
+
+	for _, et := range desired.EdgeTypes {
+		for _, typeName := range []string{et.SourceObjectType, et.TargetObjectType} {
+			if !objectTypeExists(typeName) {
+				return nil, ucerr.Friendlyf(nil, "edge type %s references object type %s, which is not defined in the schema", et.TypeName, typeName)
+			}
+		}
+		if et.Organization != "" && defaultOrgName != "" && et.Organization == defaultOrgName {
+			// the default organization may also be referenced by its name in this tenant
+			et.Organization = authz.SchemaDefaultOrganization
+		}
+		if et.Organization != "" && !orgExists(et.Organization) {
+			return nil, ucerr.Friendlyf(nil, "edge type %s references organization %s, which is not defined in the schema", et.TypeName, et.Organization)
+		}
+
+		existing, found := currentEdgeTypes[et.TypeName]
+		if !found {
+			edgeTypeSteps = append(edgeTypeSteps, SchemaStep{
+				SchemaChange: authz.SchemaChange{Action: authz.SchemaChangeActionCreate, Kind: authz.SchemaEntityKindEdgeType, Name: et.TypeName},
+				EdgeType:     &et,
+			})
+			continue
+		}
+
+		if existing.SourceObjectType != et.SourceObjectType || existing.TargetObjectType != et.TargetObjectType {
+			return nil, ucerr.Friendlyf(nil, "edge type %s cannot change from %s -> %s to %s -> %s; rename it or delete it first",
+				et.TypeName, existing.SourceObjectType, existing.TargetObjectType, et.SourceObjectType, et.TargetObjectType)
+		}
+		if existing.Organization != et.Organization {
+			return nil, ucerr.Friendlyf(nil, "edge type %s cannot change organization from '%s' to '%s'; rename it or delete it first",
+				et.TypeName, existing.Organization, et.Organization)
+		}
+		if !existing.Attributes.Equal(et.Attributes) {
+			edgeTypeSteps = append(edgeTypeSteps, SchemaStep{
+				SchemaChange: authz.SchemaChange{
+					Action:  authz.SchemaChangeActionUpdate,
+					Kind:    authz.SchemaEntityKindEdgeType,
+					Name:    et.TypeName,
+					Details: fmt.Sprintf("attributes: %s -> %s", existing.Attributes, et.Attributes),
+				},
+				EdgeType:        &existing,
+				DesiredEdgeType: &et,
+			})
+		}
+	}
+
+	if deleteMissing {
+		for _, et := range current.EdgeTypes {
+			if authz.IsSystemTypeName(et.TypeName) || desiredEdgeTypes[et.TypeName] {
+				continue
+			}
+			edgeTypeDeletes = append(edgeTypeDeletes, SchemaStep{
+				SchemaChange: authz.SchemaChange{Action: authz.SchemaChangeActionDelete, Kind: authz.SchemaEntityKindEdgeType, Name: et.TypeName},
+				EdgeType:     &et,
+			})
+		}
+		for _, ot := range current.ObjectTypes {
+			if authz.IsSystemTypeName(ot.TypeName) || desiredObjectTypes[ot.TypeName] {
+				continue
+			}
+			objectTypeDeletes = append(objectTypeDeletes, SchemaStep{
+				SchemaChange: authz.SchemaChange{Action: authz.SchemaChangeActionDelete, Kind: authz.SchemaEntityKindObjectType, Name: ot.TypeName},
+				ObjectType:   &ot,
+			})
+		}
+		for _, org := range current.Organizations {
+			if org.ID == companyID || desiredOrgs[org.Name] {
+				continue
+			}
+			orgDeletes = append(orgDeletes, SchemaStep{
+				SchemaChange: authz.SchemaChange{Action: authz.SchemaChangeActionDelete, Kind: authz.SchemaEntityKindOrganization, Name: org.Name},
+				Organization: &org,
+			})
+		}
+	}
+
+	steps := []SchemaStep{}
+	for _, group := range [][]SchemaStep{orgSteps, objectTypeCreates, edgeTypeSteps, edgeTypeDeletes, objectTypeDeletes, orgDeletes} {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Name < group[j].Name })
+		steps = append(steps, group...)
+	}
+	return steps, nil
+}
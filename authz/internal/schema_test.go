@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/assert"
+)
+
+func changes(steps []SchemaStep) []string {
+	strs := []string{}
+	for _, step := range steps {
+		strs = append(strs, step.String())
+	}
+	return strs
+}
+
+func TestPlanSchema(t *testing.T) {
+	companyID := uuid.Must(uuid.NewV4())
+	viewer := authz.Attributes{{Name: "read", Direct: true}}
+	editor := authz.Attributes{{Name: "read", Direct: true}, {Name: "write", Direct: true}}
+
+	current := authz.Schema{
+		ObjectTypes: []authz.SchemaObjectType{
+			{ID: authz.UserObjectTypeID, TypeName: authz.ObjectTypeUser},
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "document"},
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "folder"},
+		},
+		EdgeTypes: []authz.SchemaEdgeType{
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "_system_edge", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: authz.ObjectTypeUser},
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "document_editor", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "document", Attributes: viewer},
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "folder_viewer", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "folder", Attributes: viewer},
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "company_admin", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "folder", Organization: authz.SchemaDefaultOrganization},
+			{ID: uuid.Must(uuid.NewV4()), TypeName: "acme_admin", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "folder", Organization: "Acme"},
+		},
+		Organizations: []authz.SchemaOrganization{
+			{ID: companyID, Name: "Company", Region: "aws-us-east-1"},
+			{ID: uuid.Must(uuid.NewV4()), Name: "Acme", Region: "aws-us-east-1"},
+			{ID: uuid.Must(uuid.NewV4()), Name: "Globex", Region: "aws-us-east-1"},
+		},
+	}
+
+	exported := exportableSchema(current, companyID)
+	assert.Equal(t, len(exported.ObjectTypes), 2)
+	assert.Equal(t, len(exported.EdgeTypes), 4)
+	assert.Equal(t, len(exported.Organizations), 2)
+
+	// applying the exported schema is a no-op, even when deleting missing entities
+	steps, err := PlanSchema(current, exported, companyID, true)
+	assert.NoErr(t, err)
+	assert.Equal(t, len(steps), 0)
+
+	desired := authz.Schema{
+		ObjectTypes: []authz.SchemaObjectType{{TypeName: "document"}, {TypeName: "team"}},
+		EdgeTypes: []authz.SchemaEdgeType{
+			// attributes are compared regardless of order
+			{TypeName: "document_editor", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "document", Attributes: authz.Attributes{editor[1], editor[0]}},
+			{TypeName: "team_member", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "team", Organization: "Initech"},
+		},
+		Organizations: []authz.SchemaOrganization{{Name: "Acme", Region: "aws-us-west-2"}, {Name: "Initech", Region: "aws-us-east-1"}},
+	}
+	assert.NoErr(t, desired.Validate())
+
+	steps, err = PlanSchema(current, desired, companyID, false)
+	assert.NoErr(t, err)
+	assert.Equal(t, changes(steps), []string{
+		"~ organization Acme (region: aws-us-east-1 -> aws-us-west-2)",
+		"+ organization Initech",
+		"+ object_type team",
+		"~ edge_type document_editor (attributes: [{read true false false}] -> [{read true false false} {write true false false}])",
+		"+ edge_type team_member",
+	})
+
+	steps, err = PlanSchema(current, desired, companyID, true)
+	assert.NoErr(t, err)
+	assert.Equal(t, changes(steps)[5:], []string{
+		"- edge_type acme_admin",
+		"- edge_type company_admin",
+		"- edge_type folder_viewer",
+		"- object_type folder",
+		"- organization Globex",
+	})
+
+	// edge types in the default organization can be referenced by its name in this tenant as well
+	desired.ObjectTypes = append(desired.ObjectTypes, authz.SchemaObjectType{TypeName: "folder"})
+	desired.EdgeTypes = append(desired.EdgeTypes,
+		authz.SchemaEdgeType{TypeName: "company_admin", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "folder", Organization: "Company"})
+	steps, err = PlanSchema(current, desired, companyID, false)
+	assert.NoErr(t, err)
+	assert.Equal(t, len(steps), 5)
+
+	// edge types can't reference organizations that would be deleted
+	desired.EdgeTypes = append(desired.EdgeTypes,
+		authz.SchemaEdgeType{TypeName: "globex_admin", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "folder", Organization: "Globex"})
+	_, err = PlanSchema(current, desired, companyID, false)
+	assert.NoErr(t, err)
+	_, err = PlanSchema(current, desired, companyID, true)
+	assert.NotNil(t, err)
+	desired.ObjectTypes = desired.ObjectTypes[:2]
+	desired.EdgeTypes = desired.EdgeTypes[:2]
+
+	// edge types can't reference object types that would be deleted
+	desired.EdgeTypes = append(desired.EdgeTypes, authz.SchemaEdgeType{TypeName: "folder_editor", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "folder"})
+	_, err = PlanSchema(current, desired, companyID, false)
+	assert.NoErr(t, err)
+	_, err = PlanSchema(current, desired, companyID, true)
+	assert.NotNil(t, err)
+
+	// edge type endpoints are immutable
+	desired.EdgeTypes = []authz.SchemaEdgeType{{TypeName: "folder_viewer", SourceObjectType: authz.ObjectTypeUser, TargetObjectType: "document", Attributes: viewer}}
+	_, err = PlanSchema(current, desired, companyID, false)
+	assert.NotNil(t, err)
+
+	// the company's default organization can't be managed by a schema
+	desired.EdgeTypes = nil
+	desired.Organizations = []authz.SchemaOrganization{{Name: "Company", Region: "aws-us-west-2"}}
+	_, err = PlanSchema(current, desired, companyID, false)
+	assert.NotNil(t, err)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	for _, tc := range []struct {
+		schema authz.Schema
+		valid  bool
+	}{
+		{authz.Schema{ObjectTypes: []authz.SchemaObjectType{{TypeName: "document"}}}, true},
+		{authz.Schema{ObjectTypes: []authz.SchemaObjectType{{TypeName: "document"}, {TypeName: "document"}}}, false},
+		{authz.Schema{ObjectTypes: []authz.SchemaObjectType{{TypeName: "_document"}}}, false},
+		{authz.Schema{EdgeTypes: []authz.SchemaEdgeType{{TypeName: "viewer", SourceObjectType: "_user", TargetObjectType: "document"}}}, true},
+		{authz.Schema{EdgeTypes: []authz.SchemaEdgeType{{TypeName: "viewer", SourceObjectType: "_user"}}}, false},
+		{authz.Schema{EdgeTypes: []authz.SchemaEdgeType{{TypeName: "viewer", SourceObjectType: "_user", TargetObjectType: "document",
+			Attributes: authz.Attributes{{Name: "read", Direct: true, Inherit: true}}}}}, false},
+		{authz.Schema{EdgeTypes: []authz.SchemaEdgeType{{TypeName: "viewer", SourceObjectType: "_user", TargetObjectType: "document",
+			Attributes: authz.Attributes{{Name: "read", Direct: true}, {Name: "read", Inherit: true}}}}}, false},
+		{authz.Schema{Organizations: []authz.SchemaOrganization{{Name: ""}}}, false},
+		{authz.Schema{Organizations: []authz.SchemaOrganization{{Name: authz.SchemaDefaultOrganization}}}, false},
+	} {
+		assert.Equal(t, tc.schema.Validate() == nil, tc.valid, assert.Errorf("schema %+v", tc.schema))
+	}
+}
@@ -2,6 +2,9 @@ package internal
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"maps"
 	"sort"
 	"sync"
@@ -199,6 +202,23 @@ func (s *Storage) preDeleteObjectType(ctx context.Context, id uuid.UUID, wrapped
 	return nil
 }
 
+// CountObjectTypeDependents returns the number of live objects of the given object type, and the number of live edges
+// of edge types to or from it, which are deleted along with the object type
+func (s *Storage) CountObjectTypeDependents(ctx context.Context, id uuid.UUID) (int, int, error) {
+	const q = `/* lint-sql-ok */ SELECT
+		(SELECT COUNT(*) FROM objects WHERE type_id=$1 AND deleted='0001-01-01 00:00:00') AS objects,
+		(SELECT COUNT(*) FROM edges e JOIN edge_types et ON e.edge_type_id=et.id
+			WHERE (et.source_object_type_id=$1 OR et.target_object_type_id=$1) AND e.deleted='0001-01-01 00:00:00' AND et.deleted='0001-01-01 00:00:00') AS edges;`
+	var counts struct {
+		Objects int `db:"objects"`
+		Edges   int `db:"edges"`
+	}
+	if err := s.db.GetContext(ctx, "CountObjectTypeDependents", &counts, q, id); err != nil {
+		return 0, 0, ucerr.Wrap(err)
+	}
+	return counts.Objects, counts.Edges, nil
+}
+
 //go:generate genorm --cache --followerreads --includeinsertonly --getbyname authz.EdgeType edge_types tenantdb
 
 // GetEdgeTypeForName returns the definition of a single edge type by its name.
@@ -240,8 +260,18 @@ func (s *Storage) preDeleteEdgeType(ctx context.Context, id uuid.UUID, wrappedDe
 	// Delete all edges that use this edge type.
 	// NOTE: this is not transactional, so we can get into a bad state;
 	// probably want a reconciler to clean things up, OR use a transaction
-	const deleteEdgeQuery = `UPDATE edges SET deleted=CLOCK_TIMESTAMP() WHERE edge_type_id=$1 AND deleted='0001-01-01 00:00:00';`
-	if _, err := s.db.ExecContext(ctx, "preDeleteEdgeType", deleteEdgeQuery, id); err != nil {
+	// Each deleted edge (and the edge type itself) is also recorded in the history, so that point-in-time checks still see them before now
+	cs := getChangeSource(ctx)
+	const deleteEdgeQuery = `/* lint-sql-ok */ WITH d AS (UPDATE edges SET deleted=CLOCK_TIMESTAMP() WHERE edge_type_id=$1 AND deleted='0001-01-01 00:00:00'
+		RETURNING id, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		INSERT INTO edge_history (id, created, updated, deleted, edge_id, action, source, actor, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		SELECT gen_random_uuid(), d.deleted, d.deleted, '0001-01-01 00:00:00', d.id, 'delete', $2, $3, d.edge_type_id, d.source_object_id, d.target_object_id, d.valid_from, d.valid_until FROM d;`
+	if _, err := s.db.ExecContext(ctx, "preDeleteEdgeType", deleteEdgeQuery, id, cs.Source, cs.Actor); err != nil {
+		return ucerr.Wrap(err)
+	}
+	const edgeTypeHistoryQuery = `/* lint-sql-ok */ INSERT INTO edge_type_history (id, updated, deleted, edge_type_id, action, source, actor, type_name, source_object_type_id, target_object_type_id, attributes, organization_id)
+		SELECT gen_random_uuid(), NOW(), '0001-01-01 00:00:00', id, 'delete', $2, $3, type_name, source_object_type_id, target_object_type_id, attributes, organization_id FROM edge_types WHERE id=$1 AND deleted='0001-01-01 00:00:00';`
+	if _, err := s.db.ExecContext(ctx, "preDeleteEdgeTypeHistory", edgeTypeHistoryQuery, id, cs.Source, cs.Actor); err != nil {
 		return ucerr.Wrap(err)
 	}
 	// TODO move this post type delete
@@ -453,6 +483,70 @@ func (s *Storage) additionalSaveKeysForEdge(edge *authz.Edge) []cache.Key {
 	return []cache.Key{}
 }
 
+// InsertEdgeWithHistory inserts an edge like InsertEdge, recording it in the edge history in the same statement so the
+// history can't miss an edge that was written, attributed to the change source in the context
+func (s *Storage) InsertEdgeWithHistory(ctx context.Context, edge *authz.Edge) error {
+	if err := edge.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := s.preSaveEdge(ctx, edge); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	cs := getChangeSource(ctx)
+	const q = `/* lint-sql-ok */ WITH i AS (INSERT INTO edges (id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7) RETURNING id, created, updated, edge_type_id, source_object_id, target_object_id, valid_from, valid_until),
+		h AS (INSERT INTO edge_history (id, created, updated, deleted, edge_id, action, source, actor, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		SELECT gen_random_uuid(), i.updated, i.updated, '0001-01-01 00:00:00', i.id, 'create', $8, $9, i.edge_type_id, i.source_object_id, i.target_object_id, i.valid_from, i.valid_until FROM i)
+		SELECT id, created, updated FROM i;`
+	return ucerr.Wrap(cache.CreateItemServer(ctx, s.cm, edge, authz.EdgeKeyID, s.additionalSaveKeysForEdge(edge), func(i *authz.Edge) error {
+		return ucerr.Wrap(s.db.GetContext(ctx, "InsertEdgeWithHistory", edge, q, edge.ID, edge.Deleted, edge.EdgeTypeID, edge.SourceObjectID, edge.TargetObjectID,
+			edge.ValidFrom, edge.ValidUntil, cs.Source, cs.Actor))
+	}))
+}
+
+// DeleteEdgeWithHistory soft-deletes a live edge like DeleteEdge, recording the edge as it was stored in the edge
+// history in the same statement, attributed to the change source in the context
+func (s *Storage) DeleteEdgeWithHistory(ctx context.Context, id uuid.UUID) error {
+	if s.cm != nil {
+		obj, _, _, err := cache.GetItemFromCache[authz.Edge](ctx, *s.cm, s.cm.N.GetKeyNameWithID(authz.EdgeKeyID, id), false)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		objBase := authz.Edge{BaseModel: ucdb.NewBaseWithID(id)}
+		if obj == nil {
+			obj = &objBase
+		}
+		sentinel, err := cache.TakeItemLock(ctx, cache.Delete, *s.cm, *obj)
+		if err != nil {
+			uclog.Warningf(ctx, "Error taking lock for delete: %v", err)
+			return ucerr.Wrap(err)
+		}
+		// This generates an extra invalidation for items that don't exist
+		defer cache.DeleteItemFromCache[authz.Edge](ctx, *s.cm, *obj, sentinel)
+	}
+
+	cs := getChangeSource(ctx)
+	const q = `/* lint-sql-ok */ WITH d AS (UPDATE edges SET deleted=CLOCK_TIMESTAMP() WHERE id=$1 AND deleted='0001-01-01 00:00:00'
+		RETURNING id, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		INSERT INTO edge_history (id, created, updated, deleted, edge_id, action, source, actor, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		SELECT gen_random_uuid(), d.deleted, d.deleted, '0001-01-01 00:00:00', d.id, 'delete', $2, $3, d.edge_type_id, d.source_object_id, d.target_object_id, d.valid_from, d.valid_until FROM d;`
+	res, err := s.db.ExecContext(ctx, "DeleteEdgeWithHistory", q, id, cs.Source, cs.Actor)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Errorf("Error deleting Edge %v: %w", id, err)
+	}
+	if ra == 0 {
+		// we wrap sql.ErrNoRows here to be consistent with DeleteEdge
+		return ucerr.Friendlyf(sql.ErrNoRows, "Edge %v not found", id)
+	}
+	return nil
+}
+
 // DeleteEdgesFromObject removes the edges from/to given object
 func (s *Storage) DeleteEdgesFromObject(ctx context.Context, objectID uuid.UUID) error {
 	if s.cm != nil {
@@ -468,8 +562,13 @@ func (s *Storage) DeleteEdgesFromObject(ctx context.Context, objectID uuid.UUID)
 		defer cache.ReleasePerItemCollectionLock(ctx, *s.cm, nil, obj, sentinel)
 	}
 
-	const edgeQuery = `UPDATE edges SET deleted=NOW() WHERE (source_object_id=$1 OR target_object_id=$1) AND deleted='0001-01-01 00:00:00'; /* allow-multiple-target-use */`
-	_, err := s.db.ExecContext(ctx, "DeleteEdgesFromObject", edgeQuery, objectID)
+	// Each deleted edge is also recorded in the history, so that point-in-time checks still see it before now
+	cs := getChangeSource(ctx)
+	const edgeQuery = `/* lint-sql-ok */ WITH d AS (UPDATE edges SET deleted=NOW() WHERE (source_object_id=$1 OR target_object_id=$1) AND deleted='0001-01-01 00:00:00'
+		RETURNING id, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		INSERT INTO edge_history (id, created, updated, deleted, edge_id, action, source, actor, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+		SELECT gen_random_uuid(), d.deleted, d.deleted, '0001-01-01 00:00:00', d.id, 'delete', $2, $3, d.edge_type_id, d.source_object_id, d.target_object_id, d.valid_from, d.valid_until FROM d; /* allow-multiple-target-use */`
+	_, err := s.db.ExecContext(ctx, "DeleteEdgesFromObject", edgeQuery, objectID, cs.Source, cs.Actor)
 	if s.cm != nil {
 		// We need to also reset the global edges collection and set the isModified flag
 		edge := authz.Edge{BaseModel: ucdb.NewBase()}
@@ -499,6 +598,71 @@ func (s *Storage) DeleteEdgesFromObject(ctx context.Context, objectID uuid.UUID)
 	return ucerr.Wrap(err)
 }
 
+// eventEdgeExpired is logged (with the edge as payload) for each expired edge that is cleaned up
+const eventEdgeExpired = "authz.EdgeExpired"
+
+// CleanExpiredEdges will look for edges whose validity window has ended, evaluating up to maxCandidates
+// edges and only actually deleting them if dryRun is false
+func (s *Storage) CleanExpiredEdges(ctx context.Context, maxCandidates int, dryRun bool) error {
+	if maxCandidates < 1 {
+		return ucerr.Errorf("maxCandidates must be greater than or equal to one: %d", maxCandidates)
+	}
+
+	// edges that never expire have a zero valid_until, so exclude those
+	pager, err := authz.NewEdgePaginatorFromOptions(
+		pagination.Limit(min(maxCandidates, pagination.MaxLimit)),
+		pagination.Filter(fmt.Sprintf("(('valid_until',GT,'%d'),AND,('valid_until',LE,'%d'))", time.Time{}.UnixMicro(), time.Now().UTC().UnixMicro())),
+	)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	numCandidates := 0
+
+	uclog.Infof(ctx, "evaluating up to %d expired edges", maxCandidates)
+
+	for {
+		edges, respFields, err := s.ListEdgesPaginated(ctx, *pager)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		for _, edge := range edges {
+			if err := s.cleanExpiredEdge(ctx, edge, dryRun); err != nil {
+				return ucerr.Wrap(err)
+			}
+
+			numCandidates++
+			if numCandidates == maxCandidates {
+				return nil
+			}
+		}
+
+		if !pager.AdvanceCursor(*respFields) {
+			return nil
+		}
+	}
+}
+
+func (s *Storage) cleanExpiredEdge(ctx context.Context, edge authz.Edge, dryRun bool) error {
+	if dryRun {
+		uclog.Infof(ctx, "would delete edge '%v' which expired at %v", edge.ID, edge.ValidUntil)
+		return nil
+	}
+
+	if err := s.DeleteEdgeWithHistory(WithChangeSource(ctx, ChangeSource{Source: ChangeSourceExpiredEdgeCleanup}), edge.ID); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	payload, err := json.Marshal(edge)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	uclog.IncrementEventWithPayload(ctx, eventEdgeExpired, string(payload))
+	uclog.Infof(ctx, "deleted edge '%v' which expired at %v", edge.ID, edge.ValidUntil)
+	return nil
+}
+
 func (s *Storage) preSaveOrganization(ctx context.Context, org *authz.Organization) error {
 	obj := authz.Object{
 		BaseModel:      ucdb.NewBaseWithID(org.ID),
@@ -539,22 +703,25 @@ func (s *Storage) GetOrganizationForName(ctx context.Context, orgName string) (*
 }
 
 // CheckAttribute checks if the source object has the given attribute on the target object.
-func (s *Storage) CheckAttribute(ctx context.Context, checkAttributeServiceName *string, sourceObjectID, targetObjectID uuid.UUID, attributeName string) (bool, []authz.AttributePathNode, error) {
+func (s *Storage) CheckAttribute(ctx context.Context, checkAttributeServiceName *string, sourceObjectID, targetObjectID uuid.UUID, attributeName string) (*authz.CheckAttributeResponse, error) {
+	// Cached paths and the check attribute service may not reflect the writes that issued a consistency token
+	consistent := !getMinFreshness(ctx).IsZero()
+
 	var ckey cache.Key
 	var obj authz.Object
 	sentinel := cache.NoLockSentinel
-	if s.cm != nil {
+	if s.cm != nil && !consistent {
 		var path *[]authz.AttributePathNode
 		var err error
 
 		ckey = s.cm.N.GetKeyName(authz.AttributePathObjToObjID, []string{sourceObjectID.String(), targetObjectID.String(), attributeName})
 		path, _, sentinel, _, err = cache.GetItemsArrayFromCache[authz.AttributePathNode](ctx, *s.cm, ckey, true)
 		if err != nil {
-			return false, nil, ucerr.Wrap(err)
+			return nil, ucerr.Wrap(err)
 		}
 
 		if path != nil {
-			return true, *path, nil
+			return &authz.CheckAttributeResponse{HasAttribute: true, Path: *path}, nil
 		}
 		obj = authz.Object{BaseModel: ucdb.NewBaseWithID(sourceObjectID)}
 
@@ -562,35 +729,116 @@ func (s *Storage) CheckAttribute(ctx context.Context, checkAttributeServiceName
 		defer cache.ReleasePerItemCollectionLock(ctx, *s.cm, []cache.Key{ckey}, obj, sentinel)
 	}
 
-	var hasAttribute bool
-	var path []authz.AttributePathNode
+	var resp *authz.CheckAttributeResponse
 	var err error
 
-	if featureflags.IsEnabledForTenant(ctx, featureflags.CheckAttributeViaService, s.tenantID) && checkAttributeServiceName != nil {
-		hasAttribute, path, err = CheckAttributeViaService(ctx, *checkAttributeServiceName, s.tenantID, sourceObjectID, targetObjectID, attributeName)
+	if featureflags.IsEnabledForTenant(ctx, featureflags.CheckAttributeViaService, s.tenantID) && checkAttributeServiceName != nil && !consistent {
+		resp, err = CheckAttributeViaService(ctx, *checkAttributeServiceName, s.tenantID, sourceObjectID, targetObjectID, attributeName)
 	} else if !featureflags.IsEnabledForTenant(ctx, featureflags.OnMachineEdgesCacheCompareResults, s.tenantID) {
-		hasAttribute, path, err = CheckAttributeBFS(ctx, s, s.tenantID, sourceObjectID, targetObjectID, attributeName, false)
+		resp, err = CheckAttributeBFS(ctx, s, s.tenantID, sourceObjectID, targetObjectID, attributeName, false)
 	} else {
-		if hasAttribute, path, err = CheckAttributeBFS(ctx, s, s.tenantID, sourceObjectID, targetObjectID, attributeName, true); err != nil {
-			return false, nil, ucerr.Wrap(err)
+		resp, err = CheckAttributeBFS(ctx, s, s.tenantID, sourceObjectID, targetObjectID, attributeName, true)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
 		}
 
-		hasAttributeC, pathC, err := CheckAttributeBFS(ctx, s, s.tenantID, sourceObjectID, targetObjectID, attributeName, false)
+		respC, err := CheckAttributeBFS(ctx, s, s.tenantID, sourceObjectID, targetObjectID, attributeName, false)
 
 		if err != nil {
 			uclog.Errorf(ctx, "Error checking attribute with cache %v for source %v and target %v: %v", attributeName, sourceObjectID, targetObjectID, err)
-		} else if hasAttribute != hasAttributeC || !cmp.Equal(path, pathC, cmpopts.EquateEmpty()) {
+		} else if resp.HasAttribute != respC.HasAttribute || !cmp.Equal(resp.Path, respC.Path, cmpopts.EquateEmpty()) {
 			uclog.Errorf(ctx, "Error checking attribute with cache %v for source %v and target %v: mismatched results full %v inc %v full %v inc %v",
-				attributeName, sourceObjectID, targetObjectID, hasAttribute, hasAttributeC, path, pathC)
+				attributeName, sourceObjectID, targetObjectID, resp.HasAttribute, respC.HasAttribute, resp.Path, respC.Path)
 		}
 	}
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
 
-	if s.cm != nil && hasAttribute && err == nil {
+	if s.cm != nil && !consistent && resp.HasAttribute && !resp.TimeBounded {
 		// We can only cache positive responses, since we don't know when the path will be added to invalidate the negative result.
-		cache.SaveItemsToCollection(ctx, *s.cm, obj, path, ckey, ckey, sentinel, false)
+		// Paths through time-bounded edges aren't cached either, since they expire without any write to invalidate them.
+		cache.SaveItemsToCollection(ctx, *s.cm, obj, resp.Path, ckey, ckey, sentinel, false)
+	}
+
+	return resp, nil
+}
+
+// CheckAttributes checks a batch of (source, target, attribute) tuples, returning the results in the same order. Positive
+// results are cached the same way as CheckAttribute, and the remaining checks share a single edge map load.
+func (s *Storage) CheckAttributes(ctx context.Context, checkAttributeServiceName *string, checks []authz.AttributeCheck) ([]authz.CheckAttributeResponse, error) {
+	results := make([]authz.CheckAttributeResponse, len(checks))
+	consistent := !getMinFreshness(ctx).IsZero()
+
+	if featureflags.IsEnabledForTenant(ctx, featureflags.CheckAttributeViaService, s.tenantID) && checkAttributeServiceName != nil && !consistent {
+		for i, check := range checks {
+			resp, err := s.CheckAttribute(ctx, checkAttributeServiceName, check.SourceObjectID, check.TargetObjectID, check.Attribute)
+			if err != nil {
+				return nil, ucerr.Wrap(err)
+			}
+			results[i] = *resp
+		}
+		return results, nil
+	}
+
+	type pendingCheck struct {
+		index    int
+		ckey     cache.Key
+		sentinel cache.Sentinel
+	}
+
+	pending := []pendingCheck{}
+	uncached := []authz.AttributeCheck{}
+	for i, check := range checks {
+		p := pendingCheck{index: i, sentinel: cache.NoLockSentinel}
+		if s.cm != nil && !consistent {
+			var path *[]authz.AttributePathNode
+			var err error
+
+			p.ckey = s.cm.N.GetKeyName(authz.AttributePathObjToObjID, []string{check.SourceObjectID.String(), check.TargetObjectID.String(), check.Attribute})
+			path, _, p.sentinel, _, err = cache.GetItemsArrayFromCache[authz.AttributePathNode](ctx, *s.cm, p.ckey, true)
+			if err != nil {
+				return nil, ucerr.Wrap(err)
+			}
+
+			if path != nil {
+				results[i] = authz.CheckAttributeResponse{HasAttribute: true, Path: *path}
+				continue
+			}
+		}
+		pending = append(pending, p)
+		uncached = append(uncached, check)
+	}
+
+	if s.cm != nil && !consistent {
+		// Release the locks in case of error
+		defer func() {
+			for _, p := range pending {
+				obj := authz.Object{BaseModel: ucdb.NewBaseWithID(checks[p.index].SourceObjectID)}
+				cache.ReleasePerItemCollectionLock(ctx, *s.cm, []cache.Key{p.ckey}, obj, p.sentinel)
+			}
+		}()
+	}
+
+	if len(uncached) == 0 {
+		return results, nil
 	}
 
-	return hasAttribute, path, ucerr.Wrap(err)
+	computed, err := checkAttributesBFS(ctx, s, s.tenantID, uncached)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	for i, p := range pending {
+		results[p.index] = computed[i]
+		if s.cm != nil && !consistent && computed[i].HasAttribute && !computed[i].TimeBounded {
+			// We can only cache positive responses (not relying on time-bounded edges), as in CheckAttribute
+			obj := authz.Object{BaseModel: ucdb.NewBaseWithID(checks[p.index].SourceObjectID)}
+			cache.SaveItemsToCollection(ctx, *s.cm, obj, computed[i].Path, p.ckey, p.ckey, p.sentinel, false)
+		}
+	}
+
+	return results, nil
 }
 
 // edgeBFSCacheGlobal is per process cache of edges for BFS traversal. It is stored as a map of per tenant
@@ -606,12 +854,16 @@ func (s *Storage) CheckAttribute(ctx context.Context, checkAttributeServiceName
 type EdgeCacheRecord struct {
 	// Map of ObjectID -> []edges out of that object
 	EdgesMap map[uuid.UUID]map[uuid.UUID]*authz.Edge
+	// Map of ObjectID -> []edges into that object, kept in sync with EdgesMap for reverse traversal
+	ReverseEdgesMap map[uuid.UUID]map[uuid.UUID]*authz.Edge
 	// True the cache is outdated and should be updated prior to use
 	outdated bool
 	// Latest validated time
 	validatedTime time.Time
 	// Latest updated time of any edge in the cache
 	updatedTime time.Time
+	// Database time before the latest read from the primary, so the cache reflects every change made before it (zero if unknown)
+	syncedTime time.Time
 	// True the cache is in process of being updated updatedTime/edgesMap are not in valid state
 	inProgress bool
 	// Lock protecting the refresh/initialization of the edgesMap
@@ -732,23 +984,30 @@ func (s *Storage) ensureRegistration(ctx context.Context) error {
 
 // GetBFSEdgeGlobalCache returns the global cache of edges for BFS traversal
 func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[uuid.UUID]*authz.Edge, error) {
+	edgesMap, _, err := s.getBFSEdgeGlobalCache(ctx)
+	return edgesMap, ucerr.Wrap(err)
+}
+
+// getBFSEdgeGlobalCache returns the global cache of edges keyed by source object for BFS traversal, along with the same
+// edges keyed by target object for reverse BFS traversal
+func (s *Storage) getBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[uuid.UUID]*authz.Edge, map[uuid.UUID]map[uuid.UUID]*authz.Edge, error) {
 	if s.cm == nil || s.edgeCache == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	if featureflags.IsEnabledForTenant(ctx, featureflags.OnMachineEdgesCacheDisable, s.tenantID) {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	if err := s.ensureRegistration(ctx); err != nil {
-		return nil, ucerr.Wrap(err)
+		return nil, nil, ucerr.Wrap(err)
 	}
 
 	s.edgeCache.RLock()
 	edgeCacheRecordsTenantMap := s.edgeCache.CacheTenantRecords[s.tenantID].CacheRecords
 	s.edgeCache.RUnlock()
 	if edgeCacheRecordsTenantMap == nil {
-		return nil, ucerr.Errorf("cacheTenantRecords[%v] is nil. Expected to be initialized in ensureRegistration()", s.tenantID)
+		return nil, nil, ucerr.Errorf("cacheTenantRecords[%v] is nil. Expected to be initialized in ensureRegistration()", s.tenantID)
 	}
 
 	mkey := s.cm.N.GetKeyNameStatic(authz.EdgeCollectionKeyID)
@@ -758,7 +1017,7 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 	}
 	_, conflict, _, _, err := cache.GetItemsArrayFromCache[authz.Edge](ctx, *s.cm, mkey, false)
 	if err != nil {
-		return nil, ucerr.Wrap(err)
+		return nil, nil, ucerr.Wrap(err)
 	}
 
 	// Check if global cache for conflict==XX is already populated or if we need to populate it
@@ -798,6 +1057,12 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 		cacheRecordOutdated := edgeCacheRecord.outdated
 		s.edgeCache.RUnlock()
 
+		// A read with a consistency token can only use the cache if it's known to reflect the writes that issued the token
+		if minFreshness := getMinFreshness(ctx); edgeCacheRecord.syncedTime.Before(minFreshness) {
+			uclog.Verbosef(ctx, "getBFSGlobalCache: %v global cache for conflict %s synced at %v is older than consistency token %v", s.edgeCache.id, conflict, edgeCacheRecord.syncedTime, minFreshness)
+			cacheRecordOutdated = true
+		}
+
 		if !cacheRecordOutdated { // lint: ignore
 			uclog.Verbosef(ctx, "getBFSGlobalCache: %v returning global cache for conflict %s, %v edges time %v", s.edgeCache.id, conflict, getEdgeCountForCacheRecord(edgeCacheRecord), edgeCacheRecord.updatedTime)
 			if conflict == cache.NoLockSentinel {
@@ -809,7 +1074,7 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 					}()
 				}
 			}
-			return edgeCacheRecord.EdgesMap, nil
+			return edgeCacheRecord.EdgesMap, edgeCacheRecord.ReverseEdgesMap, nil
 		}
 	}
 
@@ -827,6 +1092,7 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 			edgeCacheRecord.inProgress = false
 			edgeCacheRecord.outdated = true
 			edgeCacheRecord.updatedTime = time.Time{} // Reset the updated time so that the entry is not used as valid baseline
+			edgeCacheRecord.syncedTime = time.Time{}
 			s.edgeCache.Unlock()
 		}
 	}()
@@ -841,7 +1107,7 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 
 	// Otherwise kick off the population of the global cache for this conflict value
 	if err := readEdgesCacheFromServer(ctx, s, edgeCacheRecord, s.tenantID, string(conflict), false); err != nil {
-		return nil, ucerr.Wrap(err)
+		return nil, nil, ucerr.Wrap(err)
 	}
 
 	// TODO temporarily always reload the cache on conflict = NoLockSentinel
@@ -851,7 +1117,7 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 		copyCacheRecordUnlocked(edgeCacheRecord, localSrcCacheRecordCopy)
 		// do full reload from server
 		if err := readEdgesCacheFromServer(ctx, s, edgeCacheRecord, s.tenantID, string(conflict), true); err != nil {
-			return nil, ucerr.Wrap(err)
+			return nil, nil, ucerr.Wrap(err)
 		}
 
 		// Even if times are the same there is a small chance that the cache was updated between the time we did incremental load and full load
@@ -886,7 +1152,7 @@ func (s *Storage) GetBFSEdgeGlobalCache(ctx context.Context) (map[uuid.UUID]map[
 		}
 	}
 
-	return edgeCacheRecord.EdgesMap, nil
+	return edgeCacheRecord.EdgesMap, edgeCacheRecord.ReverseEdgesMap, nil
 }
 
 func readEdgesCacheFromServer(ctx context.Context, s *Storage, edgeCacheTenantRecord *EdgeCacheRecord, tenantID uuid.UUID, conflict string, forceReload bool) error {
@@ -895,12 +1161,22 @@ func readEdgesCacheFromServer(ctx context.Context, s *Storage, edgeCacheTenantRe
 		fullLoad = true // if the map is nil or updated time is zero, we need to load all edges
 	}
 
-	dirty := conflict != string(cache.NoLockSentinel)
+	// Reads that need to observe a consistency token go to the primary, like reads during a conflict, and then
+	// the cache reflects every change made before the read started
+	dirty := conflict != string(cache.NoLockSentinel) || !getMinFreshness(ctx).IsZero()
+	var syncedTime time.Time
+	if dirty {
+		var err error
+		if syncedTime, err = s.getDBTime(ctx); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 
 	if fullLoad { // load all edges
 		uclog.Verbosef(ctx, "getBFSGlobalCache: %v populating edges cache for %v conflict '%s'", s.edgeCache.id, tenantID, conflict)
 
-		edgeCacheTenantRecord.EdgesMap = make(map[uuid.UUID]map[uuid.UUID]*authz.Edge) // reset the map since we are doing a full load
+		edgeCacheTenantRecord.EdgesMap = make(map[uuid.UUID]map[uuid.UUID]*authz.Edge) // reset the maps since we are doing a full load
+		edgeCacheTenantRecord.ReverseEdgesMap = make(map[uuid.UUID]map[uuid.UUID]*authz.Edge)
 		edgeCacheTenantRecord.updatedTime = time.Time{}
 		deletedTime := time.Time{}
 		edgeCache := edgeCacheTenantRecord.EdgesMap
@@ -916,22 +1192,14 @@ func readEdgesCacheFromServer(ctx context.Context, s *Storage, edgeCacheTenantRe
 
 			for i, edge := range edges {
 				if edge.Deleted.IsZero() {
-					if edgeCache[edge.SourceObjectID] == nil {
-						edgeCache[edge.SourceObjectID] = make(map[uuid.UUID]*authz.Edge)
-					}
-					edgeCache[edge.SourceObjectID][edge.ID] = &edges[i]
+					edgeCacheTenantRecord.addEdge(&edges[i])
 
 					if edges[i].Updated.After(edgeCacheTenantRecord.updatedTime) {
 						edgeCacheTenantRecord.updatedTime = edges[i].Updated
 					}
 				} else {
 					// There were some edges deleted after the initial read so we need to process the tombstones
-					if edgeCache[edge.SourceObjectID] != nil {
-						delete(edgeCache[edge.SourceObjectID], edge.ID)
-						if len(edgeCache[edge.SourceObjectID]) == 0 {
-							delete(edgeCache, edge.SourceObjectID)
-						}
-					}
+					edgeCacheTenantRecord.removeEdge(edge.SourceObjectID, edge.ID)
 					if edges[i].Deleted.After(deletedTime) {
 						deletedTime = edges[i].Deleted
 					}
@@ -987,18 +1255,10 @@ func readEdgesCacheFromServer(ctx context.Context, s *Storage, edgeCacheTenantRe
 			}
 			if edge.Deleted.IsZero() {
 				// Add the edge to the cache if this is not a tombstone
-				if edgeCache[edge.SourceObjectID] == nil {
-					edgeCache[edge.SourceObjectID] = make(map[uuid.UUID]*authz.Edge)
-				}
-				edgeCache[edge.SourceObjectID][edge.ID] = &edges[i]
+				edgeCacheTenantRecord.addEdge(&edges[i])
 			} else {
 				// If we have a tombstone we need to remove the edge from the cache
-				if edgeCache[edge.SourceObjectID] != nil {
-					delete(edgeCache[edge.SourceObjectID], edge.ID)
-					if len(edgeCache[edge.SourceObjectID]) == 0 {
-						delete(edgeCache, edge.SourceObjectID)
-					}
-				}
+				edgeCacheTenantRecord.removeEdge(edge.SourceObjectID, edge.ID)
 			}
 
 			if edges[i].Updated.After(edgeCacheTenantRecord.updatedTime) {
@@ -1013,12 +1273,19 @@ func readEdgesCacheFromServer(ctx context.Context, s *Storage, edgeCacheTenantRe
 			conflict, edgeCacheTenantRecord.updatedTime)
 	}
 
+	if dirty {
+		edgeCacheTenantRecord.syncedTime = syncedTime
+	} else if fullLoad {
+		// a full load from a follower may be behind the changes that a previous read from the primary observed
+		edgeCacheTenantRecord.syncedTime = time.Time{}
+	}
+
 	return nil
 }
 
 func (s *Storage) listEdgesUpdated(ctx context.Context, updatedTime time.Time, dirty bool) ([]authz.Edge, error) {
 	// TODO add limit to this query and if exceeded fall back to full reload
-	const q = "/* lint-sql-ok */ SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE updated >= ($1 at time zone 'utc' - interval '10 milliseconds')::timestamp OR deleted >= ($1 at time zone 'utc' - interval '10 milliseconds')::timestamp;"
+	const q = "/* lint-sql-ok */ SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE updated >= ($1 at time zone 'utc' - interval '10 milliseconds')::timestamp OR deleted >= ($1 at time zone 'utc' - interval '10 milliseconds')::timestamp;"
 
 	var edges []authz.Edge
 	if err := s.db.SelectContextWithDirty(ctx, "ListEdgesUpdated", &edges, q, dirty, updatedTime); err != nil {
@@ -1029,7 +1296,7 @@ func (s *Storage) listEdgesUpdated(ctx context.Context, updatedTime time.Time, d
 }
 
 func (s *Storage) listEdgesForCachePaginated(ctx context.Context, updatedTime time.Time, deletedTime time.Time, dirty bool) ([]authz.Edge, int, error) {
-	const q = "/* lint-sql-ok */ SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE updated >= $1 and deleted ='0001-01-01 00:00:00' ORDER by updated LIMIT $2;"
+	const q = "/* lint-sql-ok */ SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE updated >= $1 and deleted ='0001-01-01 00:00:00' ORDER by updated LIMIT $2;"
 
 	// First get a set of undeleted edges ordered by updated time for pagination.MaxLimit edges with updated > updatedTime
 	var edges []authz.Edge
@@ -1045,7 +1312,7 @@ func (s *Storage) listEdgesForCachePaginated(ctx context.Context, updatedTime ti
 		if maxTime.After(deletedTime) {
 			deletedTime = maxTime
 		}
-		const qd = "/* lint-sql-ok */ SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, created FROM edges WHERE updated <= $1 AND deleted >= $2;"
+		const qd = "/* lint-sql-ok */ SELECT id, updated, deleted, edge_type_id, source_object_id, target_object_id, valid_from, valid_until, created FROM edges WHERE updated <= $1 AND deleted >= $2;"
 
 		var edgesDeleted []authz.Edge
 		if err := s.db.SelectContextWithDirty(ctx, "ListEdgesForCacheDeleted", &edgesDeleted, qd, dirty, maxTime, deletedTime); err != nil {
@@ -1071,7 +1338,48 @@ func copyCacheRecordUnlocked(src *EdgeCacheRecord, dst *EdgeCacheRecord) {
 	for k, v := range src.EdgesMap {
 		dst.EdgesMap[k] = maps.Clone(v)
 	}
+	dst.ReverseEdgesMap = make(map[uuid.UUID]map[uuid.UUID]*authz.Edge, len(src.ReverseEdgesMap))
+	for k, v := range src.ReverseEdgesMap {
+		dst.ReverseEdgesMap[k] = maps.Clone(v)
+	}
 	dst.updatedTime = src.updatedTime
+	dst.syncedTime = src.syncedTime
+}
+
+// addEdge adds the edge to both the outbound and inbound edge maps of the cache record, replacing any previous version of it
+func (cacheRecord *EdgeCacheRecord) addEdge(edge *authz.Edge) {
+	cacheRecord.removeEdge(edge.SourceObjectID, edge.ID)
+
+	if cacheRecord.EdgesMap[edge.SourceObjectID] == nil {
+		cacheRecord.EdgesMap[edge.SourceObjectID] = make(map[uuid.UUID]*authz.Edge)
+	}
+	cacheRecord.EdgesMap[edge.SourceObjectID][edge.ID] = edge
+
+	if cacheRecord.ReverseEdgesMap == nil {
+		cacheRecord.ReverseEdgesMap = make(map[uuid.UUID]map[uuid.UUID]*authz.Edge)
+	}
+	if cacheRecord.ReverseEdgesMap[edge.TargetObjectID] == nil {
+		cacheRecord.ReverseEdgesMap[edge.TargetObjectID] = make(map[uuid.UUID]*authz.Edge)
+	}
+	cacheRecord.ReverseEdgesMap[edge.TargetObjectID][edge.ID] = edge
+}
+
+// removeEdge removes the edge from both the outbound and inbound edge maps of the cache record, if present
+func (cacheRecord *EdgeCacheRecord) removeEdge(sourceObjectID, edgeID uuid.UUID) {
+	edge, ok := cacheRecord.EdgesMap[sourceObjectID][edgeID]
+	if !ok {
+		return
+	}
+
+	delete(cacheRecord.EdgesMap[sourceObjectID], edgeID)
+	if len(cacheRecord.EdgesMap[sourceObjectID]) == 0 {
+		delete(cacheRecord.EdgesMap, sourceObjectID)
+	}
+
+	delete(cacheRecord.ReverseEdgesMap[edge.TargetObjectID], edgeID)
+	if len(cacheRecord.ReverseEdgesMap[edge.TargetObjectID]) == 0 {
+		delete(cacheRecord.ReverseEdgesMap, edge.TargetObjectID)
+	}
 }
 
 func getEdgeCountForCacheRecord(cacheRecord *EdgeCacheRecord) int {
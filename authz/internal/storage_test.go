@@ -641,6 +641,81 @@ func TestEdges(t *testing.T) {
 	}
 }
 
+func TestEdgeWithHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := initStorage(ctx, t)
+
+	createObjectType(t, ctx, s, "TestType1")
+	createObjectType(t, ctx, s, "TestType2")
+	obj1 := createObject(t, ctx, s, "TestType1", "TestObj1")
+	obj2 := createObject(t, ctx, s, "TestType2", "TestObj2")
+	edgeType := createEdgeType(t, ctx, s, "EdgeType1", "TestType1", "TestType2")
+	assert.NoErr(t, s.RecordStoredEdgeTypeChange(ctx, internal.HistoryActionCreate, edgeType.ID))
+	edge := &authz.Edge{
+		BaseModel:      ucdb.NewBase(),
+		EdgeTypeID:     edgeType.ID,
+		SourceObjectID: obj1.ID,
+		TargetObjectID: obj2.ID,
+	}
+	assert.NoErr(t, s.InsertEdgeWithHistory(ctx, edge))
+
+	time.Sleep(10 * time.Millisecond)
+	beforeDelete := time.Now().UTC()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoErr(t, s.DeleteEdgeWithHistory(ctx, edge.ID))
+
+	// deleting an edge that's already deleted fails without recording it again
+	err := s.DeleteEdgeWithHistory(ctx, edge.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	found, _, err := internal.CheckAttributeAsOfBFS(ctx, s, uuid.Nil, obj1.ID, obj2.ID, "read", beforeDelete)
+	assert.NoErr(t, err)
+	assert.True(t, found)
+
+	found, _, err = internal.CheckAttributeAsOfBFS(ctx, s, uuid.Nil, obj1.ID, obj2.ID, "read", time.Now().UTC())
+	assert.NoErr(t, err)
+	assert.False(t, found)
+}
+
+func TestEraseObjectHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := initStorage(ctx, t)
+
+	createObjectType(t, ctx, s, "TestType1")
+	createObjectType(t, ctx, s, "TestType2")
+	obj1 := createObject(t, ctx, s, "TestType1", "TestObj1")
+	obj2 := createObject(t, ctx, s, "TestType2", "TestObj2")
+	edgeType := createEdgeType(t, ctx, s, "EdgeType1", "TestType1", "TestType2")
+	assert.NoErr(t, s.RecordStoredEdgeTypeChange(ctx, internal.HistoryActionCreate, edgeType.ID))
+	actorCtx := internal.WithChangeSource(ctx, internal.ChangeSource{Source: "test", Actor: obj1.ID.String()})
+	assert.NoErr(t, s.InsertEdgeWithHistory(actorCtx, &authz.Edge{
+		BaseModel:      ucdb.NewBase(),
+		EdgeTypeID:     edgeType.ID,
+		SourceObjectID: obj1.ID,
+		TargetObjectID: obj2.ID,
+	}))
+
+	found, _, err := internal.CheckAttributeAsOfBFS(ctx, s, uuid.Nil, obj1.ID, obj2.ID, "read", time.Now().UTC())
+	assert.NoErr(t, err)
+	assert.True(t, found)
+
+	n, err := s.EraseObjectHistory(ctx, obj1.ID)
+	assert.NoErr(t, err)
+	assert.Equal(t, n, 1)
+
+	found, _, err = internal.CheckAttributeAsOfBFS(ctx, s, uuid.Nil, obj1.ID, obj2.ID, "read", time.Now().UTC())
+	assert.NoErr(t, err)
+	assert.False(t, found)
+
+	// erasing is idempotent so the erasure can be retried
+	n, err = s.EraseObjectHistory(ctx, obj1.ID)
+	assert.NoErr(t, err)
+	assert.Equal(t, n, 0)
+}
+
 func getEdgeFilter(sourceObjectID uuid.UUID, targetObjectID uuid.UUID) pagination.Option {
 	if targetObjectID.IsNil() {
 		return pagination.Filter(
@@ -928,6 +1003,12 @@ func TestCascadeDelete(t *testing.T) {
 	_, err = s.GetEdgeType(ctx, et2.ID)
 	assert.NotNil(t, err)
 
+	// The counts of what deleting an object type cascades to match what's actually deleted
+	objects, edges, err := s.CountObjectTypeDependents(ctx, ot1.ID)
+	assert.NoErr(t, err)
+	assert.Equal(t, objects, 1)
+	assert.Equal(t, edges, 3)
+
 	// Delete one object type, ensure all associated edge types, edges, and objects get deleted.
 	err = s.DeleteObjectType(ctx, ot1.ID)
 	assert.NoErr(t, err)
@@ -1197,9 +1278,9 @@ func TestGlobalEdgesCacheInvalidation(t *testing.T) {
 			withTimeoutCtx, cancel := context.WithTimeout(ctx, startTimeout)
 			defer cancel()
 
-			val, _, err := s.CheckAttribute(withTimeoutCtx, nil, objs[0].ID, objs[1].ID, "read")
+			resp, err := s.CheckAttribute(withTimeoutCtx, nil, objs[0].ID, objs[1].ID, "read")
 			assert.Equal(t, (err == nil || errors.Is(err, context.DeadlineExceeded)), true)
-			assert.Equal(t, val, false)
+			assert.Equal(t, err == nil && resp.HasAttribute, false)
 			// If we cancel the context in time check that the map is fully populated
 			if err != nil {
 				callBefore := dbm.GetTotalCalls()
@@ -1427,13 +1508,13 @@ func TestGlobalEdgesCacheInvalidation(t *testing.T) {
 		time.Sleep(5 * time.Second)
 
 		// Populate the steady state global cache (cache.NoConflictSentinel)
-		path, _, err := s1.CheckAttribute(ctx, nil, objs[0].ID, objs[1].ID, "read")
+		resp, err := s1.CheckAttribute(ctx, nil, objs[0].ID, objs[1].ID, "read")
 		assert.NoErr(t, err)
-		assert.False(t, path)
+		assert.False(t, resp.HasAttribute)
 
-		path, _, err = s2.CheckAttribute(ctx, nil, objs[0].ID, objs[1].ID, "read")
+		resp, err = s2.CheckAttribute(ctx, nil, objs[0].ID, objs[1].ID, "read")
 		assert.NoErr(t, err)
-		assert.False(t, path)
+		assert.False(t, resp.HasAttribute)
 
 		for range 1 {
 			threadCount := 10
@@ -1642,8 +1723,9 @@ func validateEdges(t *testing.T, ctx context.Context, s1 *internal.Storage, s2 *
 	t.Helper()
 
 	uclog.Debugf(ctx, "Checking attribute for %v -> %v", objID1, objID2)
-	val, _, err := s1.CheckAttribute(ctx, nil, objID1, objID2, "read")
+	resp, err := s1.CheckAttribute(ctx, nil, objID1, objID2, "read")
 	assert.NoErr(t, err)
+	val := resp.HasAttribute
 	assert.Equal(t, val, expectedVal, assert.Errorf("Error for s1 in edge cache for %v -> %v expected %v returned %v", objID1, objID2, expectedVal, val))
 	if val != expectedVal {
 		uclog.Errorf(ctx, "Error for s1 in edge cache for %v -> %v expected %v returned %v", objID1, objID2, expectedVal, val)
@@ -1653,7 +1735,8 @@ func validateEdges(t *testing.T, ctx context.Context, s1 *internal.Storage, s2 *
 		return
 	}
 
-	val, _, err = s2.CheckAttribute(ctx, nil, objID1, objID2, "read")
+	resp, err = s2.CheckAttribute(ctx, nil, objID1, objID2, "read")
 	assert.NoErr(t, err)
+	val = resp.HasAttribute
 	assert.Equal(t, val, expectedVal, assert.Errorf("Error for s2 in edge cache for %v -> %v expected %v returned %v", objID1, objID2, expectedVal, val))
 }
@@ -3,6 +3,7 @@ package authz
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -87,6 +88,28 @@ func (attrs Attributes) String() string {
 	return fmt.Sprintf("%v", strs)
 }
 
+// Equal returns true if both collections contain the same attributes, regardless of order
+func (attrs Attributes) Equal(other Attributes) bool {
+	if len(attrs) != len(other) {
+		return false
+	}
+	otherAttrsMap := make(map[string]Attribute, len(other))
+	for _, attr := range other {
+		otherAttrsMap[attr.Name] = attr
+	}
+
+	for _, attr := range attrs {
+		oattr, ok := otherAttrsMap[attr.Name]
+		if !ok {
+			return false
+		}
+		if oattr != attr {
+			return false
+		}
+	}
+	return true
+}
+
 //go:generate gendbjson Attributes
 
 // EdgeType defines a single, strongly-typed relationship
@@ -104,27 +127,11 @@ type EdgeType struct {
 
 // EqualsIgnoringID returns true if the two edges are equal, ignoring the ID field
 func (e *EdgeType) EqualsIgnoringID(other *EdgeType) bool {
-	if e.TypeName == other.TypeName && e.SourceObjectTypeID == other.SourceObjectTypeID && e.TargetObjectTypeID == other.TargetObjectTypeID && e.OrganizationID == other.OrganizationID {
-		if len(e.Attributes) != len(other.Attributes) {
-			return false
-		}
-		otherAttrsMap := make(map[string]Attribute, len(e.Attributes))
-		for _, attr := range other.Attributes {
-			otherAttrsMap[attr.Name] = attr
-		}
-
-		for _, attr := range e.Attributes {
-			oattr, ok := otherAttrsMap[attr.Name]
-			if !ok {
-				return false
-			}
-			if oattr != attr {
-				return false
-			}
-		}
-		return true
-	}
-	return false
+	return e.TypeName == other.TypeName &&
+		e.SourceObjectTypeID == other.SourceObjectTypeID &&
+		e.TargetObjectTypeID == other.TargetObjectTypeID &&
+		e.OrganizationID == other.OrganizationID &&
+		e.Attributes.Equal(other.Attributes)
 }
 
 //go:generate genvalidate EdgeType
@@ -162,11 +169,37 @@ type Edge struct {
 	// These must be valid ObjectType.ID values
 	SourceObjectID uuid.UUID `db:"source_object_id" json:"source_object_id" validate:"notnil" required:"true"`
 	TargetObjectID uuid.UUID `db:"target_object_id" json:"target_object_id" validate:"notnil" required:"true"`
+
+	// Optional - the window during which the edge grants its attributes, eg. for temporary access.
+	// A zero ValidFrom means the edge is valid from creation, and a zero ValidUntil means it never expires.
+	ValidFrom  time.Time `db:"valid_from" json:"valid_from"`
+	ValidUntil time.Time `db:"valid_until" json:"valid_until"`
 }
 
 // EqualsIgnoringID returns true if two edges are equal, ignoring the ID field
 func (e *Edge) EqualsIgnoringID(other *Edge) bool {
-	return e.EdgeTypeID == other.EdgeTypeID && e.SourceObjectID == other.SourceObjectID && e.TargetObjectID == other.TargetObjectID
+	return e.EdgeTypeID == other.EdgeTypeID && e.SourceObjectID == other.SourceObjectID && e.TargetObjectID == other.TargetObjectID &&
+		e.ValidFrom.Equal(other.ValidFrom) && e.ValidUntil.Equal(other.ValidUntil)
+}
+
+// IsTimeBounded returns true if the edge is only valid during a window of time
+func (e *Edge) IsTimeBounded() bool {
+	return !e.ValidFrom.IsZero() || !e.ValidUntil.IsZero()
+}
+
+// IsValidAt returns true if the edge grants its attributes at the given time
+func (e *Edge) IsValidAt(t time.Time) bool {
+	if !e.ValidFrom.IsZero() && t.Before(e.ValidFrom) {
+		return false
+	}
+	return e.ValidUntil.IsZero() || t.Before(e.ValidUntil)
+}
+
+func (e *Edge) extraValidate() error {
+	if !e.ValidFrom.IsZero() && !e.ValidUntil.IsZero() && !e.ValidUntil.After(e.ValidFrom) {
+		return ucerr.Friendlyf(nil, "Edge.ValidUntil (%v) must be after Edge.ValidFrom (%v)", e.ValidUntil, e.ValidFrom)
+	}
+	return nil
 }
 
 //go:generate genvalidate Edge
@@ -69,6 +69,8 @@ func (Edge) getPaginationKeys() pagination.KeyTypes {
 	return pagination.KeyTypes{
 		"source_object_id": pagination.UUIDKeyType,
 		"target_object_id": pagination.UUIDKeyType,
+		"valid_from":       pagination.TimestampKeyType,
+		"valid_until":      pagination.TimestampKeyType,
 		"created":          pagination.TimestampKeyType,
 		"updated":          pagination.TimestampKeyType,
 	}
@@ -17,6 +17,11 @@ import (
 	"userclouds.com/internal/provisioning/types"
 )
 
+// withProvisioningChangeSource attributes the edge and edge type changes made during provisioning in their history
+func withProvisioningChangeSource(ctx context.Context) context.Context {
+	return internal.WithChangeSource(ctx, internal.ChangeSource{Source: internal.ChangeSourceProvisioning})
+}
+
 func provisionObjectType(ctx context.Context, storage *internal.Storage, id uuid.UUID, typeName string) error {
 	objectType := authz.ObjectType{
 		BaseModel: ucdb.NewBaseWithID(id),
@@ -50,6 +55,9 @@ func provisionEdgeType(ctx context.Context, storage *internal.Storage, id uuid.U
 	if err := storage.SaveEdgeType(ctx, &edgeType); err != nil {
 		return ucerr.Wrap(err)
 	}
+	if err := storage.RecordStoredEdgeTypeChange(withProvisioningChangeSource(ctx), internal.HistoryActionCreate, id); err != nil {
+		return ucerr.Wrap(err)
+	}
 	return nil
 }
 
@@ -67,7 +75,8 @@ func validateEdgeType(ctx context.Context, storage *internal.Storage, typeName s
 
 func deleteEdgeType(ctx context.Context, storage *internal.Storage, id uuid.UUID, typeName string, sourceObjectTypeID, targetObjectTypeID uuid.UUID) error {
 	uclog.Debugf(ctx, "Deleting EdgeType ID %v Type Name %s SourceID %v TargetID %v", id, typeName, sourceObjectTypeID, targetObjectTypeID)
-	if err := storage.DeleteEdgeType(ctx, id); err != nil {
+	// the edge type and its edges are recorded in the history as part of the delete
+	if err := storage.DeleteEdgeType(withProvisioningChangeSource(ctx), id); err != nil {
 		return ucerr.Wrap(err)
 	}
 	return nil
@@ -130,7 +139,7 @@ func deleteObject(ctx context.Context, storage *internal.Storage, id uuid.UUID,
 		aliasExp = *alias
 	}
 	uclog.Debugf(ctx, "Deleting Object ID %v Type ID %v Alias %s", id, typeID, aliasExp)
-	if err := storage.DeleteObject(ctx, id); err != nil {
+	if err := storage.DeleteObject(withProvisioningChangeSource(ctx), id); err != nil {
 		return ucerr.Wrap(err)
 	}
 	return nil
@@ -149,7 +158,7 @@ func provisionEdge(ctx context.Context, storage *internal.Storage, edgeTypeID, s
 	}
 	uclog.Debugf(ctx, "Provisioning Edge ID %v Type ID %v Source %v Target %v", edge.ID, edgeTypeID, sourceObjectID, targetObjectID)
 
-	if err := storage.SaveEdge(ctx, &edge); err != nil {
+	if err := storage.InsertEdgeWithHistory(withProvisioningChangeSource(ctx), &edge); err != nil {
 		return uuid.Nil, ucerr.Wrap(err)
 	}
 	return edge.ID, nil
@@ -176,7 +185,7 @@ func deleteEdge(ctx context.Context, storage *internal.Storage, edgeTypeID, sour
 		return ucerr.Errorf("During cleanup couldn't find edge of type %v from %v to %v", edgeTypeID, sourceObjectID, targetObjectID)
 	}
 
-	if err := storage.DeleteEdge(ctx, edge.ID); err != nil {
+	if err := storage.DeleteEdgeWithHistory(withProvisioningChangeSource(ctx), edge.ID); err != nil {
 		return ucerr.Errorf("During cleanup couldn't delete edge of type %v from %v to %v", edgeTypeID, sourceObjectID, targetObjectID)
 	}
 	return nil
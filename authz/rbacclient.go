@@ -2,6 +2,7 @@ package authz
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -102,6 +103,7 @@ func (u User) GetMemberships(ctx context.Context) ([]Membership, error) {
 	authZClient := u.gbacClient.client
 
 	memberships := []Membership{}
+	now := time.Now().UTC()
 	cursor := pagination.CursorBegin
 	for {
 		resp, err := authZClient.ListEdgesOnObject(ctx, u.ID, Pagination(pagination.StartingAfter(cursor)))
@@ -115,6 +117,11 @@ func (u User) GetMemberships(ctx context.Context) ([]Membership, error) {
 				continue
 			}
 
+			// Ignore time-bounded memberships that aren't currently in effect
+			if !edge.IsValidAt(now) {
+				continue
+			}
+
 			edgeType, err := authZClient.GetEdgeType(ctx, edge.EdgeTypeID)
 			if err != nil {
 				return nil, ucerr.Wrap(err)
@@ -171,6 +178,7 @@ func (g Group) GetMemberships(ctx context.Context) ([]Membership, error) {
 	authZClient := g.gbacClient.client
 
 	memberships := []Membership{}
+	now := time.Now().UTC()
 	cursor := pagination.CursorBegin
 	for {
 		resp, err := authZClient.ListEdgesOnObject(ctx, g.ID, Pagination(pagination.StartingAfter(cursor)))
@@ -178,7 +186,7 @@ func (g Group) GetMemberships(ctx context.Context) ([]Membership, error) {
 			return nil, ucerr.Wrap(err)
 		}
 		for _, edge := range resp.Data {
-			if edge.TargetObjectID != g.ID {
+			if edge.TargetObjectID != g.ID || !edge.IsValidAt(now) {
 				continue
 			}
 
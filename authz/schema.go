@@ -0,0 +1,260 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/namespace/region"
+	"userclouds.com/infra/request"
+	"userclouds.com/infra/ucerr"
+)
+
+// SystemTypePrefix is the prefix shared by all object and edge types that UserClouds provisions
+// for every tenant. These types are never exported in a Schema and are never modified by ApplySchema,
+// but edge types in a Schema may still reference system object types (e.g. _user, _group).
+const SystemTypePrefix = "_"
+
+// SchemaDefaultOrganization is the organization name that a Schema uses to reference the company's default
+// organization, whose name differs from tenant to tenant
+const SchemaDefaultOrganization = SystemTypePrefix + "default"
+
+// IsSystemTypeName returns true if the object or edge type name is reserved for a system type
+func IsSystemTypeName(typeName string) bool {
+	return strings.HasPrefix(typeName, SystemTypePrefix)
+}
+
+// Schema is a portable, declarative description of a tenant's authz model. Object types, edge types
+// and organizations are matched by name rather than ID so that a schema exported from one tenant
+// (e.g. staging) can be applied to another (e.g. prod).
+type Schema struct {
+	ObjectTypes   []SchemaObjectType   `json:"object_types" yaml:"object_types"`
+	EdgeTypes     []SchemaEdgeType     `json:"edge_types" yaml:"edge_types"`
+	Organizations []SchemaOrganization `json:"organizations,omitempty" yaml:"organizations,omitempty"`
+}
+
+func (s Schema) extraValidate() error {
+	objectTypes := map[string]bool{}
+	for _, ot := range s.ObjectTypes {
+		if err := ot.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if objectTypes[ot.TypeName] {
+			return ucerr.Friendlyf(nil, "object type %s is defined more than once", ot.TypeName)
+		}
+		objectTypes[ot.TypeName] = true
+	}
+
+	edgeTypes := map[string]bool{}
+	for _, et := range s.EdgeTypes {
+		if err := et.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if edgeTypes[et.TypeName] {
+			return ucerr.Friendlyf(nil, "edge type %s is defined more than once", et.TypeName)
+		}
+		edgeTypes[et.TypeName] = true
+	}
+
+	orgs := map[string]bool{}
+	for _, org := range s.Organizations {
+		if err := org.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if orgs[org.Name] {
+			return ucerr.Friendlyf(nil, "organization %s is defined more than once", org.Name)
+		}
+		orgs[org.Name] = true
+	}
+
+	return nil
+}
+
+//go:generate genvalidate Schema
+
+// StripIDs clears the IDs of all entities in the schema, so that applying it always generates new IDs
+func (s *Schema) StripIDs() {
+	for i := range s.ObjectTypes {
+		s.ObjectTypes[i].ID = uuid.Nil
+	}
+	for i := range s.EdgeTypes {
+		s.EdgeTypes[i].ID = uuid.Nil
+	}
+	for i := range s.Organizations {
+		s.Organizations[i].ID = uuid.Nil
+	}
+}
+
+// SchemaObjectType describes an object type in a Schema
+type SchemaObjectType struct {
+	// ID is only used when the object type is created; existing object types are matched by name
+	ID       uuid.UUID `json:"id,omitempty" yaml:"id,omitempty"`
+	TypeName string    `json:"type_name" yaml:"type_name" validate:"notempty"`
+}
+
+func (ot SchemaObjectType) extraValidate() error {
+	if IsSystemTypeName(ot.TypeName) {
+		return ucerr.Friendlyf(nil, "object type %s uses the reserved prefix '%s'", ot.TypeName, SystemTypePrefix)
+	}
+	return nil
+}
+
+//go:generate genvalidate SchemaObjectType
+
+// SchemaEdgeType describes an edge type in a Schema, referencing its object types and organization by name.
+// Edge types in the company's default organization reference it as SchemaDefaultOrganization.
+type SchemaEdgeType struct {
+	// ID is only used when the edge type is created; existing edge types are matched by name
+	ID               uuid.UUID  `json:"id,omitempty" yaml:"id,omitempty"`
+	TypeName         string     `json:"type_name" yaml:"type_name" validate:"notempty"`
+	SourceObjectType string     `json:"source_object_type" yaml:"source_object_type" validate:"notempty"`
+	TargetObjectType string     `json:"target_object_type" yaml:"target_object_type" validate:"notempty"`
+	Attributes       Attributes `json:"attributes" yaml:"attributes"`
+	Organization     string     `json:"organization,omitempty" yaml:"organization,omitempty"`
+}
+
+func (et SchemaEdgeType) extraValidate() error {
+	if IsSystemTypeName(et.TypeName) {
+		return ucerr.Friendlyf(nil, "edge type %s uses the reserved prefix '%s'", et.TypeName, SystemTypePrefix)
+	}
+	attributes := map[string]bool{}
+	for _, attr := range et.Attributes {
+		if err := attr.Validate(); err != nil {
+			return ucerr.Friendlyf(err, "edge type %s has an invalid attribute %s", et.TypeName, attr.Name)
+		}
+		if attributes[attr.Name] {
+			return ucerr.Friendlyf(nil, "edge type %s defines attribute %s more than once", et.TypeName, attr.Name)
+		}
+		attributes[attr.Name] = true
+	}
+	return nil
+}
+
+//go:generate genvalidate SchemaEdgeType
+
+// SchemaOrganization describes an organization in a Schema
+type SchemaOrganization struct {
+	// ID is only used when the organization is created; existing organizations are matched by name
+	ID     uuid.UUID         `json:"id,omitempty" yaml:"id,omitempty"`
+	Name   string            `json:"name" yaml:"name" validate:"notempty"`
+	Region region.DataRegion `json:"region" yaml:"region"`
+}
+
+func (org SchemaOrganization) extraValidate() error {
+	if org.Name == SchemaDefaultOrganization {
+		return ucerr.Friendlyf(nil, "organization name %s is reserved for the company's default organization", SchemaDefaultOrganization)
+	}
+	return nil
+}
+
+//go:generate genvalidate SchemaOrganization
+
+// SchemaChangeAction is the kind of change ApplySchema makes to a schema entity
+type SchemaChangeAction string
+
+// SchemaChangeAction values
+const (
+	SchemaChangeActionCreate SchemaChangeAction = "create"
+	SchemaChangeActionUpdate SchemaChangeAction = "update"
+	SchemaChangeActionDelete SchemaChangeAction = "delete"
+)
+
+// SchemaEntityKind identifies which kind of schema entity a SchemaChange applies to
+type SchemaEntityKind string
+
+// SchemaEntityKind values
+const (
+	SchemaEntityKindObjectType   SchemaEntityKind = "object_type"
+	SchemaEntityKindEdgeType     SchemaEntityKind = "edge_type"
+	SchemaEntityKindOrganization SchemaEntityKind = "organization"
+)
+
+// SchemaChange is a single create, update or delete that is needed to bring a tenant in line with a Schema
+type SchemaChange struct {
+	Action  SchemaChangeAction `json:"action" yaml:"action"`
+	Kind    SchemaEntityKind   `json:"kind" yaml:"kind"`
+	Name    string             `json:"name" yaml:"name"`
+	Details string             `json:"details,omitempty" yaml:"details,omitempty"`
+}
+
+func (c SchemaChange) String() string {
+	prefix := map[SchemaChangeAction]string{
+		SchemaChangeActionCreate: "+",
+		SchemaChangeActionUpdate: "~",
+		SchemaChangeActionDelete: "-",
+	}[c.Action]
+
+	s := fmt.Sprintf("%s %s %s", prefix, c.Kind, c.Name)
+	if c.Details != "" {
+		s = fmt.Sprintf("%s (%s)", s, c.Details)
+	}
+	return s
+}
+
+// ApplySchemaRequest is the request body for the schema apply endpoint
+type ApplySchemaRequest struct {
+	Schema Schema `json:"schema" yaml:"schema"`
+
+	// DryRun computes and returns the changes without making them
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+
+	// DeleteMissing deletes object types, edge types and organizations that exist in the tenant but not in the schema.
+	// Deleting an object type or edge type also deletes all objects and edges of that type. Organizations are only
+	// deleted if they no longer contain any objects, and the company's default organization is never deleted.
+	DeleteMissing bool `json:"delete_missing" yaml:"delete_missing"`
+}
+
+//go:generate genvalidate ApplySchemaRequest
+
+// ApplySchemaResponse lists the changes made (or, for a dry run, that would be made) by ApplySchema.
+// Applying a schema that the tenant already matches returns no changes.
+type ApplySchemaResponse struct {
+	Changes []SchemaChange `json:"changes" yaml:"changes"`
+	DryRun  bool           `json:"dry_run" yaml:"dry_run"`
+}
+
+// ExportSchema returns the tenant's current authz schema, excluding system types and the company's default
+// organization. If includeIDs is true, entity IDs are included so that they are preserved when the schema is applied elsewhere.
+func (c *Client) ExportSchema(ctx context.Context, includeIDs bool) (*Schema, error) {
+	ctx = request.NewRequestID(ctx)
+
+	query := url.Values{}
+	if includeIDs {
+		query.Set("include_ids", "true")
+	}
+
+	var resp Schema
+	if err := c.client.Get(ctx, fmt.Sprintf("/authz/schema?%s", query.Encode()), &resp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return &resp, nil
+}
+
+// ApplySchema idempotently brings the tenant's object types, edge types and organizations in line with
+// the given schema, returning the list of changes. If dryRun is true, no changes are made.
+func (c *Client) ApplySchema(ctx context.Context, schema Schema, dryRun bool, deleteMissing bool) (*ApplySchemaResponse, error) {
+	ctx = request.NewRequestID(ctx)
+
+	req := ApplySchemaRequest{
+		Schema:        schema,
+		DryRun:        dryRun,
+		DeleteMissing: deleteMissing,
+	}
+
+	var resp ApplySchemaResponse
+	if err := c.client.Post(ctx, "/authz/schema/apply", req, &resp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if !resp.DryRun && len(resp.Changes) > 0 {
+		// types may have been created, updated or deleted out from under the cache
+		if err := c.FlushCache(); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,30 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package authz
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o SchemaEdgeType) Validate() error {
+	if o.TypeName == "" {
+		return ucerr.Friendlyf(nil, "SchemaEdgeType.TypeName (%v) can't be empty", o.ID)
+	}
+	if o.SourceObjectType == "" {
+		return ucerr.Friendlyf(nil, "SchemaEdgeType.SourceObjectType (%v) can't be empty", o.ID)
+	}
+	if o.TargetObjectType == "" {
+		return ucerr.Friendlyf(nil, "SchemaEdgeType.TargetObjectType (%v) can't be empty", o.ID)
+	}
+	for _, item := range o.Attributes {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
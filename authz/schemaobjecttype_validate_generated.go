@@ -0,0 +1,19 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package authz
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o SchemaObjectType) Validate() error {
+	if o.TypeName == "" {
+		return ucerr.Friendlyf(nil, "SchemaObjectType.TypeName (%v) can't be empty", o.ID)
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package authz
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o SchemaOrganization) Validate() error {
+	if o.Name == "" {
+		return ucerr.Friendlyf(nil, "SchemaOrganization.Name (%v) can't be empty", o.ID)
+	}
+	if err := o.Region.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
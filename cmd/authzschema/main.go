@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/alecthomas/kong"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// exit code used by the diff subcommand when the tenant doesn't match the schema, so that CI can fail on drift
+const exitCodeChanges = 2
+
+type cliContext struct {
+	Context context.Context
+}
+
+// CLI flags for subcommands that access a tenant
+type tenantConfig struct {
+	TenantURL    string `env:"USERCLOUDS_TENANT_URL" required:"" help:"Tenant URL."`
+	ClientID     string `env:"USERCLOUDS_CLIENT_ID" required:"" help:"Client ID."`
+	ClientSecret string `env:"USERCLOUDS_CLIENT_SECRET" required:"" help:"Client secret."`
+}
+
+func (cfg tenantConfig) newAuthZClient() (*authz.Client, error) {
+	tokenSource, err := jsonclient.ClientCredentialsForURL(cfg.TenantURL, cfg.ClientID, cfg.ClientSecret, nil)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	azc, err := authz.NewClient(cfg.TenantURL, authz.JSONClient(tokenSource))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return azc, nil
+}
+
+type exportCmd struct {
+	tenantConfig
+	SchemaPath string `arg:"" name:"schema-path" help:"Path to write the schema to (.json for JSON, otherwise YAML)" type:"path"`
+	IncludeIDs bool   `help:"Include entity IDs, so that applying the schema elsewhere creates entities with the same IDs."`
+}
+
+// Run implements the export subcommand
+func (c *exportCmd) Run(ctx *cliContext) error {
+	azc, err := c.newAuthZClient()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	schema, err := azc.ExportSchema(ctx.Context, c.IncludeIDs)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	var bs []byte
+	if filepath.Ext(c.SchemaPath) == ".json" {
+		bs, err = json.MarshalIndent(schema, "", "  ")
+	} else {
+		bs, err = yaml.Marshal(schema)
+	}
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if err := os.WriteFile(c.SchemaPath, bs, 0644); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx.Context, "exported %d object types, %d edge types and %d organizations to %s",
+		len(schema.ObjectTypes), len(schema.EdgeTypes), len(schema.Organizations), c.SchemaPath)
+	return nil
+}
+
+type diffCmd struct {
+	tenantConfig
+	SchemaPath    string `arg:"" name:"schema-path" help:"Path to a JSON or YAML schema file" type:"existingfile"`
+	DeleteMissing bool   `help:"Include deletes of object types, edge types and organizations that aren't in the schema."`
+}
+
+// Run implements the diff subcommand
+func (c *diffCmd) Run(ctx *cliContext) error {
+	resp, err := applySchemaFile(ctx.Context, c.tenantConfig, c.SchemaPath, true, c.DeleteMissing)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if len(resp.Changes) > 0 {
+		logtransports.Close()
+		os.Exit(exitCodeChanges)
+	}
+	return nil
+}
+
+type applyCmd struct {
+	tenantConfig
+	SchemaPath    string `arg:"" name:"schema-path" help:"Path to a JSON or YAML schema file" type:"existingfile"`
+	DeleteMissing bool   `help:"Delete object types, edge types and organizations that aren't in the schema, along with all objects and edges of deleted types. Organizations that still contain objects are not deleted."`
+	DryRun        bool   `help:"Don't actually apply the schema, just print what would be done."`
+}
+
+// Run implements the apply subcommand
+func (c *applyCmd) Run(ctx *cliContext) error {
+	_, err := applySchemaFile(ctx.Context, c.tenantConfig, c.SchemaPath, c.DryRun, c.DeleteMissing)
+	return ucerr.Wrap(err)
+}
+
+func applySchemaFile(ctx context.Context, cfg tenantConfig, schemaPath string, dryRun, deleteMissing bool) (*authz.ApplySchemaResponse, error) {
+	bs, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	// YAML is a superset of JSON, so this handles both
+	var schema authz.Schema
+	if err := yaml.UnmarshalStrict(bs, &schema); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	azc, err := cfg.newAuthZClient()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	resp, err := azc.ApplySchema(ctx, schema, dryRun, deleteMissing)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if len(resp.Changes) == 0 {
+		uclog.Infof(ctx, "tenant %s already matches %s", cfg.TenantURL, schemaPath)
+		return resp, nil
+	}
+
+	verb := "applied"
+	if resp.DryRun {
+		verb = "would apply"
+	}
+	uclog.Infof(ctx, "%s %d changes to tenant %s:", verb, len(resp.Changes), cfg.TenantURL)
+	for _, change := range resp.Changes {
+		uclog.Infof(ctx, "  %s", change)
+	}
+	return resp, nil
+}
+
+var cli struct {
+	Export exportCmd `cmd:"" help:"Export the tenant's authz schema to a file."`
+	Diff   diffCmd   `cmd:"" help:"Show the changes needed to make the tenant match a schema file. Exits with status 2 if there are any."`
+	Apply  applyCmd  `cmd:"" help:"Make the tenant match a schema file."`
+}
+
+func main() {
+	ctx := context.Background()
+	logtransports.InitLoggerAndTransportsForTools(ctx, uclog.LogLevelInfo, uclog.LogLevelVerbose, "authzschema")
+	defer logtransports.Close()
+
+	kctx := kong.Parse(&cli, kong.Description("Manage a tenant's authz object types, edge types and organizations as code"), kong.UsageOnError())
+	if err := kctx.Run(&cliContext{Context: ctx}); err != nil {
+		uclog.Fatalf(ctx, "error: %v", err)
+	}
+}
@@ -440,6 +440,48 @@ const LoginSettingsEditor = ({
               >
                 Authenticator app
               </Checkbox>
+              <Checkbox
+                name="mfaMethods"
+                disabled={
+                  !modifiedPageParameters ||
+                  !arrayParamAsSet(
+                    modifiedPageParameters,
+                    'every_page',
+                    'enabledMFAMethods'
+                  ).has('webauthn')
+                }
+                value="webauthn"
+                onChange={(e: React.ChangeEvent) => {
+                  if (modifiedPageParameters) {
+                    dispatch(
+                      modifyPageParameters(
+                        updatePageParameters(
+                          modifiedPageParameters,
+                          'every_page',
+                          'mfaMethods',
+                          toggleArrayParam(
+                            modifiedPageParameters.page_type_parameters
+                              .every_page.mfaMethods.current_value,
+                            'webauthn',
+                            (e.currentTarget as HTMLInputElement).checked
+                          )
+                        )
+                      )
+                    );
+                  }
+                }}
+                checked={
+                  modifiedPageParameters
+                    ? arrayParamAsSet(
+                        modifiedPageParameters,
+                        'every_page',
+                        'mfaMethods'
+                      ).has('webauthn')
+                    : false
+                }
+              >
+                Security key or passkey
+              </Checkbox>
               <Checkbox
                 name="mfaMethods"
                 disabled={
@@ -0,0 +1,30 @@
+import ProviderApp from './ProviderApp';
+
+export type LDAPAttributeMapping = {
+  claim: string;
+  attribute: string;
+};
+
+export type LDAPGroupMapping = {
+  group_dn: string;
+  object_id: string;
+  edge_type_id: string;
+};
+
+type LDAPProvider = {
+  url: string;
+  start_tls?: boolean;
+  root_cas?: string;
+  bind_dn: string;
+  bind_password: string;
+  base_dn: string;
+  user_filter: string;
+  id_attribute: string;
+  attribute_mappings?: LDAPAttributeMapping[];
+  group_attribute?: string;
+  group_mappings?: LDAPGroupMapping[];
+
+  apps: ProviderApp[];
+};
+
+export default LDAPProvider;
@@ -1,5 +1,12 @@
 import { blankResourceID, ResourceID } from './ResourceID';
 
+export type ObjectStoreFieldMapping = {
+  key_prefix: string;
+  field_path: string;
+  column: ResourceID;
+  transformer: ResourceID;
+};
+
 export type ObjectStore = {
   id: string;
   name: string;
@@ -9,6 +16,11 @@ export type ObjectStore = {
   secret_access_key: string;
   role_arn: string;
   access_policy: ResourceID;
+  field_mappings?: ObjectStoreFieldMapping[];
+  tokenize_on_write?: boolean;
+  write_access_policy?: ResourceID;
+  export_bucket?: string;
+  export_prefix?: string;
 };
 
 export const OBJECT_STORE_PREFIX = 'objectstore_';
@@ -1,11 +1,15 @@
 import Auth0Provider from './Auth0Provider';
 import CognitoProvider from './CognitoProvider';
+import LDAPProvider from './LDAPProvider';
 import UCProvider from './UCProvider';
+import UpstreamOIDCProvider from './UpstreamOIDCProvider';
 
 enum ProviderType {
   auth0 = 'auth0',
   uc = 'uc',
   cognito = 'cognito',
+  ldap = 'ldap',
+  oidc = 'oidc',
 }
 
 export type ProviderApp = {
@@ -20,6 +24,8 @@ type Provider = {
   auth0?: Auth0Provider;
   uc?: UCProvider;
   cognito?: CognitoProvider;
+  ldap?: LDAPProvider;
+  oidc?: UpstreamOIDCProvider;
 };
 
 export default Provider;
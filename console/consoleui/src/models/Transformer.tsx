@@ -7,6 +7,7 @@ export enum TransformType {
   Transform = 'transform',
   TokenizeByValue = 'tokenizebyvalue',
   TokenizeByReference = 'tokenizebyreference',
+  FormatPreservingEncryption = 'formatpreservingencryption',
 }
 
 export const TransformTypeFriendly = {
@@ -14,11 +15,13 @@ export const TransformTypeFriendly = {
   [TransformType.Transform]: 'Transform',
   [TransformType.TokenizeByValue]: 'Tokenize by value',
   [TransformType.TokenizeByReference]: 'Tokenize by reference',
+  [TransformType.FormatPreservingEncryption]: 'Format preserving encryption',
 };
 
 export const TokenizingTransformerTypes = [
   TransformType.TokenizeByReference.toString(),
   TransformType.TokenizeByValue.toString(),
+  TransformType.FormatPreservingEncryption.toString(),
 ];
 
 type Transformer = {
@@ -0,0 +1,20 @@
+import ProviderApp from './ProviderApp';
+
+export type UpstreamOIDCClaimMapping = {
+  claim: string;
+  column: string;
+};
+
+type UpstreamOIDCProvider = {
+  issuer_url: string;
+  client_id: string;
+  client_secret: string;
+  scopes?: string[];
+  link_accounts_by_email: boolean;
+  disable_jit_provisioning: boolean;
+  claim_mappings?: UpstreamOIDCClaimMapping[];
+
+  apps: ProviderApp[];
+};
+
+export default UpstreamOIDCProvider;
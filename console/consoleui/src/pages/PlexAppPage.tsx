@@ -240,6 +240,14 @@ const grantTypes = [
   { id: 'client_credentials', name: 'Client Credentials' },
   { id: 'refresh_token', name: 'Refresh Token' },
   { id: 'password', name: 'Resource Owner Password' },
+  {
+    id: 'urn:ietf:params:oauth:grant-type:device_code',
+    name: 'Device Authorization',
+  },
+  {
+    id: 'urn:ietf:params:oauth:grant-type:token-exchange',
+    name: 'Token Exchange',
+  },
 ];
 
 const toggleGrantTypeSelection =
@@ -284,6 +284,10 @@ func NewHandler(cfg *internal.Config, getConsoleURLCallback auth.GetConsoleURLCa
 
 	tenants.NestedMethodHandler("/keys/actions/rotate").Put(h.rotateKeys)
 
+	tenants.NestedMethodHandler("/keys/actions/schedule").Post(h.scheduleKeyRotation)
+
+	tenants.NestedMethodHandler("/keys/actions/revoke").Post(h.revokeKey)
+
 	tenants.NestedMethodHandler("/keys/private").Get(h.getTenantPrivateKey)
 
 	// /tenants/<uuid>/oidcproviders/*
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-http-utils/headers"
 	"github.com/gofrs/uuid"
@@ -10,14 +11,26 @@ import (
 	"userclouds.com/infra/jsonapi"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/internal/provisioning"
+	"userclouds.com/internal/tenantplex"
 	"userclouds.com/plex/manager"
 )
 
+// tenantSigningKey describes one of a tenant's signing keys, without its private key
+type tenantSigningKey struct {
+	KeyID      string              `json:"key_id"`
+	State      tenantplex.KeyState `json:"state"`
+	PublicKey  string              `json:"public_key"`
+	Created    time.Time           `json:"created,omitempty"`
+	ActivateAt time.Time           `json:"activate_at,omitempty"`
+	RetireAt   time.Time           `json:"retire_at,omitempty"`
+	RevokedAt  time.Time           `json:"revoked_at,omitempty"`
+}
+
 type listTenantPublicKeysResponse struct {
-	PublicKeys []string `json:"public_keys"`
+	PublicKeys []string           `json:"public_keys"` // the published public keys, starting with the active key
+	Keys       []tenantSigningKey `json:"keys"`
 }
 
-// naming this list instead of get since someday (soon?) we'll support a list of keypairs
 func (h *handler) listTenantPublicKeys(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) {
 	ctx := r.Context()
 
@@ -35,7 +48,28 @@ func (h *handler) listTenantPublicKeys(w http.ResponseWriter, r *http.Request, t
 	}
 
 	resp := listTenantPublicKeysResponse{
-		PublicKeys: []string{tp.PlexConfig.Keys.PublicKey},
+		PublicKeys: []string{},
+		Keys: []tenantSigningKey{
+			{
+				KeyID:     tp.PlexConfig.Keys.KeyID,
+				State:     tenantplex.KeyStateActive,
+				PublicKey: tp.PlexConfig.Keys.PublicKey,
+			},
+		},
+	}
+	for _, k := range tp.PlexConfig.GetPublishedKeys() {
+		resp.PublicKeys = append(resp.PublicKeys, k.PublicKey)
+	}
+	for _, k := range tp.PlexConfig.KeySet {
+		resp.Keys = append(resp.Keys, tenantSigningKey{
+			KeyID:      k.Keys.KeyID,
+			State:      k.State,
+			PublicKey:  k.Keys.PublicKey,
+			Created:    k.Created,
+			ActivateAt: k.ActivateAt,
+			RetireAt:   k.RetireAt,
+			RevokedAt:  k.RevokedAt,
+		})
 	}
 	jsonapi.Marshal(w, resp)
 }
@@ -101,20 +135,150 @@ func (h *handler) rotateKeys(w http.ResponseWriter, r *http.Request, tenantID uu
 		return
 	}
 
+	// activate the next key now if a rotation is already scheduled, and otherwise generate a new key
+	// to activate. Either way the previously active key is kept as a retiring key, so tokens it signed
+	// stay valid until they expire.
+	now := time.Now().UTC()
+	if next := tp.PlexConfig.GetNextKey(); next != nil {
+		next.ActivateAt = now
+	} else {
+		keys, err := provisioning.GeneratePlexKeys(ctx, tenantID)
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err)
+			return
+		}
+		if err := tp.PlexConfig.ScheduleKeyRotation(*keys, now, now); err != nil {
+			jsonapi.MarshalError(ctx, w, err)
+			return
+		}
+	}
+	tp.PlexConfig.AdvanceKeyRotation(now)
+
+	if err := mgr.SaveTenantPlex(ctx, tp); err != nil {
+		jsonapi.MarshalSQLError(ctx, w, err)
+		return
+	}
+
+	// plex's tenantconfig cache will timeout and update soon
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ScheduleKeyRotationRequest is the request body for scheduling a tenant signing key rotation
+type ScheduleKeyRotationRequest struct {
+	ActivateAt time.Time `json:"activate_at"`
+}
+
+// scheduleKeyRotation publishes a new signing key that the worker will make the active key at the
+// requested time
+func (h *handler) scheduleKeyRotation(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) {
+	ctx := r.Context()
+
+	var req ScheduleKeyRotationRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	now := time.Now().UTC()
+	if req.ActivateAt.Before(now) {
+		jsonapi.MarshalError(ctx, w, ucerr.Friendlyf(nil, "activate_at must be in the future"), jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	tenantDB, err := h.tenantCache.GetTenantDB(ctx, tenantID)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	mgr := manager.NewFromDB(tenantDB, h.cacheConfig)
+	tp, err := mgr.GetTenantPlex(ctx, tenantID)
+	if err != nil {
+		jsonapi.MarshalSQLError(ctx, w, err)
+		return
+	}
+
 	keys, err := provisioning.GeneratePlexKeys(ctx, tenantID)
 	if err != nil {
 		jsonapi.MarshalError(ctx, w, err)
 		return
 	}
 
-	tp.PlexConfig.Keys = *keys
+	if err := tp.PlexConfig.ScheduleKeyRotation(*keys, now, req.ActivateAt.UTC()); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusConflict))
+		return
+	}
 
 	if err := mgr.SaveTenantPlex(ctx, tp); err != nil {
 		jsonapi.MarshalSQLError(ctx, w, err)
 		return
 	}
 
-	// plex's tenantconfig cache will timeout and update soon
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeKeyRequest is the request body for revoking a tenant signing key
+type RevokeKeyRequest struct {
+	KeyID string `json:"key_id"`
+}
+
+// revokeKey immediately stops publishing a signing key, for use when a key has been compromised.
+// Revoking the active key activates a replacement, and invalidates every token it signed.
+func (h *handler) revokeKey(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) {
+	ctx := r.Context()
+
+	var req RevokeKeyRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	tenant, err := h.storage.GetTenant(ctx, tenantID)
+	if err != nil {
+		jsonapi.MarshalSQLError(ctx, w, err)
+		return
+	}
+
+	isAdmin, err := h.ensureEmployeeAccessToTenant(r, tenant)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusForbidden))
+		return
+	}
+
+	if !isAdmin {
+		jsonapi.MarshalError(ctx, w, ucerr.Friendlyf(nil, "User must be an admin of the tenant"), jsonapi.Code(http.StatusForbidden))
+		return
+	}
+
+	tenantDB, err := h.tenantCache.GetTenantDB(ctx, tenantID)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	mgr := manager.NewFromDB(tenantDB, h.cacheConfig)
+	tp, err := mgr.GetTenantPlex(ctx, tenantID)
+	if err != nil {
+		jsonapi.MarshalSQLError(ctx, w, err)
+		return
+	}
+
+	if err := tp.PlexConfig.RevokeKey(
+		req.KeyID,
+		time.Now().UTC(),
+		func() (*tenantplex.Keys, error) {
+			return provisioning.GeneratePlexKeys(ctx, tenantID)
+		},
+	); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	if err := mgr.SaveTenantPlex(ctx, tp); err != nil {
+		jsonapi.MarshalSQLError(ctx, w, err)
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -1,7 +1,10 @@
 package idp
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -25,12 +28,15 @@ import (
 type options struct {
 	ifNotExists         bool
 	debug               bool
+	explain             bool
 	organizationID      uuid.UUID
 	userID              uuid.UUID
 	dataRegion          region.DataRegion
 	accessPrimaryDBOnly bool
 	paginationOptions   []pagination.Option
 	jsonclientOptions   []jsonclient.Option
+	tokenExpiresAt      *time.Time
+	tokenTTL            time.Duration
 }
 
 // Option makes idp.Client extensible
@@ -58,6 +64,14 @@ func Debug() Option {
 	})
 }
 
+// Explain returns an Option that will cause an accessor or mutator execution to return a trace of the decisions made
+// for each row and column instead of the data. Mutators are not applied when explained.
+func Explain() Option {
+	return optFunc(func(opts *options) {
+		opts.explain = true
+	})
+}
+
 // OrganizationID returns an Option that will cause the client to use the specified organization ID for the request
 func OrganizationID(organizationID uuid.UUID) Option {
 	return optFunc(func(opts *options) {
@@ -86,6 +100,20 @@ func AccessPrimaryDBOnly() Option {
 	})
 }
 
+// TokenExpiresAt returns an Option that will cause the tokenizer to create tokens that expire at the specified time
+func TokenExpiresAt(expiresAt time.Time) Option {
+	return optFunc(func(opts *options) {
+		opts.tokenExpiresAt = &expiresAt
+	})
+}
+
+// TokenTTL returns an Option that will cause the tokenizer to create tokens that expire after the specified duration
+func TokenTTL(ttl time.Duration) Option {
+	return optFunc(func(opts *options) {
+		opts.tokenTTL = ttl
+	})
+}
+
 // Pagination is a wrapper around pagination.Option
 func Pagination(opt ...pagination.Option) Option {
 	return optFunc(func(opts *options) {
@@ -1318,6 +1346,7 @@ type ExecuteAccessorRequest struct {
 	Region              region.DataRegion            `json:"region"`                        // only return users in this data region
 	AccessPrimaryDBOnly bool                         `json:"access_primary_db_only"`        // whether to read from primary db only
 	Debug               bool                         `json:"debug,omitempty"`               // whether to include debug information in the response
+	Explain             bool                         `json:"explain,omitempty"`             // whether to return a decision trace instead of the data
 }
 
 //go:generate genvalidate ExecuteAccessorRequest
@@ -1327,10 +1356,74 @@ type ExecuteAccessorResponse struct {
 	Data  []string       `json:"data"`
 	Debug map[string]any `json:"debug,omitempty"`
 	// TODO: Truncated will need to be added to our python SDK if we keep it
-	Truncated bool `json:"truncated" description:"Will be true if an incomplete set of results could be returned for the query"`
+	Truncated   bool                  `json:"truncated" description:"Will be true if an incomplete set of results could be returned for the query"`
+	Explanation *ExecutionExplanation `json:"explanation,omitempty"`
 	pagination.ResponseFields
 }
 
+// ExplainDecision is the decision made for a row or column while explaining an accessor or mutator execution
+type ExplainDecision string
+
+// ExplainDecision constants
+const (
+	ExplainDecisionAllowed                 ExplainDecision = "allowed"
+	ExplainDecisionNoValue                 ExplainDecision = "no_value"
+	ExplainDecisionPurposeDenied           ExplainDecision = "purpose_denied"
+	ExplainDecisionAccessPolicyDenied      ExplainDecision = "access_policy_denied"
+	ExplainDecisionResultThresholdExceeded ExplainDecision = "result_threshold_exceeded"
+	ExplainDecisionBeyondLimit             ExplainDecision = "beyond_limit"
+)
+
+//go:generate genconstant ExplainDecision
+
+// AccessPolicyEvaluation records the evaluation of an access policy, or of one of its templates, along with the
+// evaluations of its components and any console output of the templates
+type AccessPolicyEvaluation struct {
+	AccessPolicyID         uuid.UUID                `json:"access_policy_id,omitempty"`
+	AccessPolicyTemplateID uuid.UUID                `json:"access_policy_template_id,omitempty"`
+	Allowed                bool                     `json:"allowed"`
+	Console                string                   `json:"console,omitempty"`
+	DurationMicroseconds   int64                    `json:"duration_us"`
+	Components             []AccessPolicyEvaluation `json:"components,omitempty"`
+}
+
+// ExplainedColumn is the decision made for one column of a row. For accessors the transformer is the one used to
+// transform the value, and for mutators it is the normalizer.
+type ExplainedColumn struct {
+	Column               string                  `json:"column"`
+	Decision             ExplainDecision         `json:"decision"`
+	AccessPolicy         *AccessPolicyEvaluation `json:"access_policy,omitempty"`
+	TransformerID        uuid.UUID               `json:"transformer_id,omitempty"`
+	TransformerName      string                  `json:"transformer_name,omitempty"`
+	TransformerConsole   string                  `json:"transformer_console,omitempty"`
+	DurationMicroseconds int64                   `json:"duration_us"`
+}
+
+// ExplainedRow is the decision made for one user matched by the selector, along with the access policies that
+// were evaluated for it and the decision made for each of its columns
+type ExplainedRow struct {
+	UserID         uuid.UUID                `json:"user_id"`
+	Region         region.DataRegion        `json:"region,omitempty"`
+	Decision       ExplainDecision          `json:"decision"`
+	AccessPolicies []AccessPolicyEvaluation `json:"access_policies"`
+	Columns        []ExplainedColumn        `json:"columns"`
+}
+
+// ExecutionExplanation is a trace of why each user matched by the selector of an accessor or mutator was or was not
+// returned (or mutated), which contains the IDs of what was evaluated but none of the user data
+type ExecutionExplanation struct {
+	ID                      uuid.UUID      `json:"id"`
+	Version                 int            `json:"version"`
+	PurposeIDs              []uuid.UUID    `json:"purpose_ids,omitempty"`
+	SearchRowCount          int            `json:"search_row_count"`
+	SelectorRowCount        int            `json:"selector_row_count"`
+	ThresholdAccessPolicyID uuid.UUID      `json:"threshold_access_policy_id"`
+	RateThresholdExceeded   bool           `json:"rate_threshold_exceeded"`
+	ResultThresholdExceeded bool           `json:"result_threshold_exceeded"`
+	Rows                    []ExplainedRow `json:"rows"`
+	DurationMicroseconds    int64          `json:"duration_us"`
+}
+
 // ExecuteAccessor accesses a column via an accessor for the associated tenant
 func (c *Client) ExecuteAccessor(ctx context.Context, accessorID uuid.UUID, clientContext policy.ClientContext, selectorValues userstore.UserSelectorValues, opts ...Option) (*ExecuteAccessorResponse, error) {
 	options := c.options
@@ -1350,6 +1443,7 @@ func (c *Client) ExecuteAccessor(ctx context.Context, accessorID uuid.UUID, clie
 		SelectorValues:      selectorValues,
 		Region:              options.dataRegion,
 		Debug:               options.debug,
+		Explain:             options.explain,
 		AccessPrimaryDBOnly: options.accessPrimaryDBOnly,
 	}
 
@@ -1361,6 +1455,153 @@ func (c *Client) ExecuteAccessor(ctx context.Context, accessorID uuid.UUID, clie
 	return &res, nil
 }
 
+// ExportFormat is the file format of an accessor export
+type ExportFormat string
+
+// ExportFormat constants
+const (
+	ExportFormatNDJSON  ExportFormat = "ndjson"
+	ExportFormatCSV     ExportFormat = "csv"
+	ExportFormatParquet ExportFormat = "parquet"
+)
+
+//go:generate genconstant ExportFormat
+
+// ExportCheckpointPrefixNDJSON and ExportCheckpointPrefixCSV begin the checkpoint records that are interleaved
+// with streamed NDJSON and CSV exports when IncludeCheckpoints is set; the rest of the record is the cursor
+const (
+	ExportCheckpointPrefixNDJSON = `{"_checkpoint":`
+	ExportCheckpointPrefixCSV    = "#checkpoint:"
+)
+
+// ExportAccessorRequest is the request body for exporting all of the user data an accessor returns
+type ExportAccessorRequest struct {
+	AccessorID          uuid.UUID                    `json:"accessor_id" validate:"notnil"` // the accessor that specifies what data to access
+	Context             policy.ClientContext         `json:"context"`                       // context that is provided to the accessor Access Policy
+	SelectorValues      userstore.UserSelectorValues `json:"selector_values"`               // the values to use for the selector
+	Region              region.DataRegion            `json:"region"`                        // only export users in this data region
+	AccessPrimaryDBOnly bool                         `json:"access_primary_db_only"`        // whether to read from primary db only
+	Format              ExportFormat                 `json:"format"`                        // the format of the exported data
+
+	// StartingAfter resumes an interrupted export from a checkpoint, and is ignored for object store exports,
+	// which resume from the checkpoint manifest in the destination
+	StartingAfter pagination.Cursor `json:"starting_after,omitempty"`
+
+	// IncludeCheckpoints interleaves a checkpoint record after every page of a streamed NDJSON or CSV export
+	IncludeCheckpoints bool `json:"include_checkpoints,omitempty"`
+}
+
+//go:generate genvalidate ExportAccessorRequest
+
+// ExportDestination is the location in an object store that an export is written to
+type ExportDestination struct {
+	ObjectStoreID uuid.UUID `json:"object_store_id" validate:"notnil"` // the object store whose credentials are used to write the export
+	Bucket        string    `json:"bucket" validate:"notempty"`
+	Prefix        string    `json:"prefix" validate:"notempty"` // part files and the checkpoint manifest are written under this prefix
+}
+
+//go:generate genvalidate ExportDestination
+
+// ExportAccessorToObjectStoreRequest is the request body for exporting all of the user data an accessor returns
+// to an object store in the background
+type ExportAccessorToObjectStoreRequest struct {
+	ExportAccessorRequest
+	Destination ExportDestination `json:"destination"`
+}
+
+//go:generate genvalidate ExportAccessorToObjectStoreRequest
+
+// ExportAccessorToObjectStoreResponse is the response body for an object store export
+type ExportAccessorToObjectStoreResponse struct {
+	CheckpointKey string `json:"checkpoint_key"` // the object key of the manifest that records the progress of the export
+}
+
+// ExportAccessor streams all of the user data an accessor returns to w in the requested format. Rows are only
+// written to w once the page they belong to is complete, and the cursor of the last complete page is returned
+// even if the export fails, so that it can be resumed by passing that cursor as req.StartingAfter.
+// pagination.CursorEnd is returned once the export is complete. Parquet exports can't be resumed part way.
+func (c *Client) ExportAccessor(ctx context.Context, req ExportAccessorRequest, w io.Writer) (pagination.Cursor, error) {
+	checkpoint := req.StartingAfter
+	req.IncludeCheckpoints = req.Format != ExportFormatParquet
+
+	exportDecoder := func(ctx context.Context, body io.ReadCloser) error {
+		if req.Format == ExportFormatParquet {
+			if _, err := io.Copy(w, body); err != nil {
+				return ucerr.Wrap(err)
+			}
+			checkpoint = pagination.CursorEnd
+			return nil
+		}
+
+		checkpointPrefix := ExportCheckpointPrefixNDJSON
+		if req.Format == ExportFormatCSV {
+			checkpointPrefix = ExportCheckpointPrefixCSV
+		}
+
+		var page strings.Builder
+		r := bufio.NewReader(body)
+		for {
+			line, err := r.ReadString('\n')
+			if strings.HasPrefix(line, checkpointPrefix) && strings.HasSuffix(line, "\n") {
+				cursor, err := parseExportCheckpoint(req.Format, line)
+				if err != nil {
+					return ucerr.Wrap(err)
+				}
+				if _, err := io.WriteString(w, page.String()); err != nil {
+					return ucerr.Wrap(err)
+				}
+				page.Reset()
+				checkpoint = cursor
+			} else {
+				page.WriteString(line)
+			}
+
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+
+		if checkpoint != pagination.CursorEnd {
+			return ucerr.Errorf("export ended before it was complete, resume after checkpoint '%s'", checkpoint)
+		}
+		return nil
+	}
+
+	if err := c.client.Post(ctx, paths.ExportAccessorPath, req, nil, jsonclient.CustomDecoder(exportDecoder)); err != nil {
+		return checkpoint, ucerr.Wrap(err)
+	}
+
+	return checkpoint, nil
+}
+
+func parseExportCheckpoint(format ExportFormat, line string) (pagination.Cursor, error) {
+	line = strings.TrimSuffix(line, "\n")
+	if format == ExportFormatCSV {
+		return pagination.Cursor(strings.TrimPrefix(line, ExportCheckpointPrefixCSV)), nil
+	}
+
+	var checkpoint struct {
+		Cursor pagination.Cursor `json:"_checkpoint"`
+	}
+	if err := json.Unmarshal([]byte(line), &checkpoint); err != nil {
+		return pagination.CursorBegin, ucerr.Wrap(err)
+	}
+	return checkpoint.Cursor, nil
+}
+
+// ExportAccessorToObjectStore starts a background export of all of the user data an accessor returns to the
+// destination object store. Sending the same request again resumes an interrupted export.
+func (c *Client) ExportAccessorToObjectStore(ctx context.Context, req ExportAccessorToObjectStoreRequest) (*ExportAccessorToObjectStoreResponse, error) {
+	var res ExportAccessorToObjectStoreResponse
+	if err := c.client.Post(ctx, paths.ExportAccessorToObjectStorePath, req, &res); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &res, nil
+}
+
 // MutatorColumnDefaultValue is a special value that can be used to set a column to its default value
 const MutatorColumnDefaultValue = "UCDEF-7f55f479-3822-4976-a8a9-b789d5c6f152"
 
@@ -1383,13 +1624,15 @@ type ExecuteMutatorRequest struct {
 	SelectorValues userstore.UserSelectorValues `json:"selector_values"`              // the values to use for the selector
 	RowData        map[string]ValueAndPurposes  `json:"row_data"`                     // the values to use for the users table row
 	Region         region.DataRegion            `json:"region"`                       // restrict mutations to users in this data region
+	Explain        bool                         `json:"explain,omitempty"`            // whether to return a decision trace instead of applying the mutation
 }
 
 //go:generate genvalidate ExecuteMutatorRequest
 
 // ExecuteMutatorResponse is the response body for modifying data in the userstore
 type ExecuteMutatorResponse struct {
-	UserIDs []uuid.UUID `json:"user_ids"`
+	UserIDs     []uuid.UUID           `json:"user_ids"`
+	Explanation *ExecutionExplanation `json:"explanation,omitempty"`
 }
 
 // ExecuteMutator modifies columns in userstore via a mutator for the associated tenant
@@ -1405,6 +1648,7 @@ func (c *Client) ExecuteMutator(ctx context.Context, mutatorID uuid.UUID, client
 		SelectorValues: selectorValues,
 		RowData:        rowData,
 		Region:         options.dataRegion,
+		Explain:        options.explain,
 	}
 
 	var resp ExecuteMutatorResponse
@@ -1592,6 +1836,244 @@ func (c *Client) GetConsentedPurposesForUser(ctx context.Context, userID uuid.UU
 	return resp, nil
 }
 
+// DSARExportStatus is the status of a data subject access request export
+type DSARExportStatus string
+
+// DSARExportStatus constants
+const (
+	DSARExportStatusPending   DSARExportStatus = "pending"
+	DSARExportStatusCompleted DSARExportStatus = "completed"
+	DSARExportStatusFailed    DSARExportStatus = "failed"
+)
+
+//go:generate genconstant DSARExportStatus
+
+// DSARExportFormat is the format a DSAR export is downloaded in
+type DSARExportFormat string
+
+// DSARExportFormat constants
+const (
+	DSARExportFormatJSON DSARExportFormat = "json"
+	DSARExportFormatHTML DSARExportFormat = "html"
+)
+
+//go:generate genconstant DSARExportFormat
+
+// CreateDSARExportRequest is the request body for starting a data subject access request export for a user
+type CreateDSARExportRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"notnil"`
+}
+
+//go:generate genvalidate CreateDSARExportRequest
+
+// DSARExport describes a data subject access request export, which assembles everything stored about a user in
+// the background. Once it is completed, the bundle can be downloaded from either link until it expires.
+type DSARExport struct {
+	ID              uuid.UUID        `json:"id"`
+	UserID          uuid.UUID        `json:"user_id"`
+	Status          DSARExportStatus `json:"status"`
+	Error           string           `json:"error,omitempty"`
+	Created         time.Time        `json:"created"`
+	ExpiresAt       time.Time        `json:"expires_at"`
+	JSONDownloadURL string           `json:"json_download_url,omitempty"`
+	HTMLDownloadURL string           `json:"html_download_url,omitempty"`
+}
+
+// DSARBundle is everything stored about a single user, as assembled by a DSAR export
+type DSARBundle struct {
+	ExportID    uuid.UUID         `json:"export_id"`
+	TenantID    uuid.UUID         `json:"tenant_id"`
+	UserID      uuid.UUID         `json:"user_id"`
+	Region      region.DataRegion `json:"region"`
+	GeneratedAt time.Time         `json:"generated_at"`
+
+	Columns            []DSARColumnValue      `json:"columns"`
+	SoftDeletedColumns []DSARColumnValue      `json:"soft_deleted_columns"`
+	ConsentedPurposes  []DSARConsentedPurpose `json:"consented_purposes"`
+	Tokens             []DSARToken            `json:"tokens"`
+	AuthzEdges         []DSARAuthzEdge        `json:"authz_edges"`
+	Authns             []UserAuthn            `json:"authns"`
+	MFAChannels        []UserMFAChannel       `json:"mfa_channels"`
+	AuditLog           []DSARAuditLogEntry    `json:"audit_log"`
+}
+
+// DSARColumnValue is a single value of a userstore column, along with the purposes it was consented for
+type DSARColumnValue struct {
+	Column            string                 `json:"column"`
+	Value             any                    `json:"value"`
+	ConsentedPurposes []DSARConsentedPurpose `json:"consented_purposes"`
+}
+
+// DSARConsentedPurpose is a purpose that a user has consented to, and when the data retained for it expires
+type DSARConsentedPurpose struct {
+	Purpose          string    `json:"purpose"`
+	Description      string    `json:"description,omitempty"`
+	RetentionTimeout time.Time `json:"retention_timeout,omitempty"`
+}
+
+// DSARToken is a token that was created by reference to one of the user's column values
+type DSARToken struct {
+	Token       string    `json:"token"`
+	Column      string    `json:"column"`
+	Transformer string    `json:"transformer"`
+	Created     time.Time `json:"created"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
+}
+
+// DSARAuthzEdge is an authz relationship that the user's object is the source or target of
+type DSARAuthzEdge struct {
+	ID             uuid.UUID `json:"id"`
+	EdgeType       string    `json:"edge_type"`
+	SourceObjectID uuid.UUID `json:"source_object_id"`
+	TargetObjectID uuid.UUID `json:"target_object_id"`
+	Created        time.Time `json:"created"`
+	ValidFrom      time.Time `json:"valid_from,omitempty"`
+	ValidUntil     time.Time `json:"valid_until,omitempty"`
+}
+
+// DSARAuditLogEntry is an audit log entry that was recorded for an action by or about the user
+type DSARAuditLogEntry struct {
+	ID      uuid.UUID      `json:"id"`
+	Type    string         `json:"type"`
+	Actor   string         `json:"actor_id"`
+	Created time.Time      `json:"created"`
+	Payload map[string]any `json:"payload"`
+}
+
+// DSARSignedBundle is the JSON download of a DSAR export. Signature is a JWS signed with the tenant's signing key,
+// whose bundle_sha256 claim is the base64url-encoded SHA-256 digest of Bundle exactly as it appears here, so the
+// bundle can be verified against the tenant's JWKS.
+type DSARSignedBundle struct {
+	Bundle    json.RawMessage `json:"bundle"`
+	Signature string          `json:"signature"`
+}
+
+// CreateDSARExport starts a background export of everything stored about a user
+func (c *Client) CreateDSARExport(ctx context.Context, userID uuid.UUID) (*DSARExport, error) {
+	req := CreateDSARExportRequest{UserID: userID}
+
+	var res DSARExport
+	if err := c.client.Post(ctx, paths.CreateDSARExportPath, req, &res); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &res, nil
+}
+
+// GetDSARExport gets the status of a DSAR export
+func (c *Client) GetDSARExport(ctx context.Context, exportID uuid.UUID) (*DSARExport, error) {
+	var res DSARExport
+	if err := c.client.Get(ctx, paths.GetDSARExportPath(exportID), &res); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &res, nil
+}
+
+// DownloadDSARExport downloads the bundle of a completed DSAR export in the requested format
+func (c *Client) DownloadDSARExport(ctx context.Context, exportID uuid.UUID, format DSARExportFormat) ([]byte, error) {
+	var data []byte
+	rawBodyDecoder := func(ctx context.Context, body io.ReadCloser) error {
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		data = b
+		return nil
+	}
+
+	if err := c.client.Get(ctx, paths.DownloadDSARExportPath(exportID, string(format)), nil, jsonclient.CustomDecoder(rawBodyDecoder)); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return data, nil
+}
+
+// UserErasureStatus is the status of a user erasure, or of erasing the user's data from one system
+type UserErasureStatus string
+
+// UserErasureStatus constants
+const (
+	UserErasureStatusPending   UserErasureStatus = "pending"
+	UserErasureStatusCompleted UserErasureStatus = "completed"
+	UserErasureStatusFailed    UserErasureStatus = "failed"
+)
+
+//go:generate genconstant UserErasureStatus
+
+// UserErasureSystem is one of the systems that a user erasure deletes the user's data from
+type UserErasureSystem string
+
+// UserErasureSystem constants
+const (
+	UserErasureSystemUserstore  UserErasureSystem = "userstore"
+	UserErasureSystemTokenizer  UserErasureSystem = "tokenizer"
+	UserErasureSystemAuthz      UserErasureSystem = "authz"
+	UserErasureSystemPlex       UserErasureSystem = "plex"
+	UserErasureSystemUserEvents UserErasureSystem = "userevent"
+)
+
+//go:generate genconstant UserErasureSystem
+
+// CreateUserErasureRequest is the request body for starting the erasure of a user from every system
+type CreateUserErasureRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"notnil"`
+
+	// UserEventAliases are any aliases other than the user ID that the user's events were reported under
+	UserEventAliases []string `json:"user_event_aliases,omitempty"`
+}
+
+//go:generate genvalidate CreateUserErasureRequest
+
+// UserErasureSystemState tracks the erasure of the user's data from a single system. DeletedCount is the number of
+// records deleted from the system, and RetainedUntil is set if some of the user's userstore values are being kept
+// until the retention duration of their column and purpose runs out.
+type UserErasureSystemState struct {
+	System        UserErasureSystem `json:"system"`
+	Status        UserErasureStatus `json:"status"`
+	Attempts      int               `json:"attempts"`
+	Error         string            `json:"error,omitempty"`
+	DeletedCount  int               `json:"deleted_count"`
+	RetainedUntil time.Time         `json:"retained_until,omitempty"`
+	CompletedAt   time.Time         `json:"completed_at,omitempty"`
+}
+
+// UserErasure describes the erasure of a user from the userstore, tokenizer, authz, plex and user events, which is
+// carried out in the background. Once every system is completed, Certificate is a JWS signed with the tenant's
+// signing key whose claims record the user, the erasure and the final state of each system.
+type UserErasure struct {
+	ID          uuid.UUID                `json:"id"`
+	UserID      uuid.UUID                `json:"user_id"`
+	Status      UserErasureStatus        `json:"status"`
+	Systems     []UserErasureSystemState `json:"systems"`
+	Created     time.Time                `json:"created"`
+	CompletedAt time.Time                `json:"completed_at,omitempty"`
+	Certificate string                   `json:"certificate,omitempty"`
+}
+
+// CreateUserErasure starts a background erasure of a user's data from every system. If the user already has an
+// erasure that has not completed, because it failed or because some values are still being retained, it is resumed.
+func (c *Client) CreateUserErasure(ctx context.Context, userID uuid.UUID, userEventAliases ...string) (*UserErasure, error) {
+	req := CreateUserErasureRequest{UserID: userID, UserEventAliases: userEventAliases}
+
+	var res UserErasure
+	if err := c.client.Post(ctx, paths.CreateUserErasurePath, req, &res); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &res, nil
+}
+
+// GetUserErasure gets the status of a user erasure, and its completion certificate once it is completed
+func (c *Client) GetUserErasure(ctx context.Context, erasureID uuid.UUID) (*UserErasure, error) {
+	var res UserErasure
+	if err := c.client.Get(ctx, paths.GetUserErasurePath(erasureID), &res); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &res, nil
+}
+
 // DownloadGolangSDK downloads the generated Golang SDK for this tenant's userstore configuration
 func (c *Client) DownloadGolangSDK(ctx context.Context) (string, error) {
 	path := paths.DownloadGolangSDKPath
@@ -0,0 +1,15 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o CreateDSARExportRequest) Validate() error {
+	if o.UserID.IsNil() {
+		return ucerr.Friendlyf(nil, "CreateDSARExportRequest.UserID can't be nil")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t DSARExportFormat) MarshalText() ([]byte, error) {
+	switch t {
+	case DSARExportFormatHTML:
+		return []byte("html"), nil
+	case DSARExportFormatJSON:
+		return []byte("json"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown DSARExportFormat value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *DSARExportFormat) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "html":
+		*t = DSARExportFormatHTML
+	case "json":
+		*t = DSARExportFormatJSON
+	default:
+		return ucerr.Friendlyf(nil, "unknown DSARExportFormat value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *DSARExportFormat) Validate() error {
+	switch *t {
+	case DSARExportFormatHTML:
+		return nil
+	case DSARExportFormatJSON:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown DSARExportFormat value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t DSARExportFormat) Enum() []any {
+	return []any{
+		"html",
+		"json",
+	}
+}
+
+// AllDSARExportFormats is a slice of all DSARExportFormat values
+var AllDSARExportFormats = []DSARExportFormat{
+	DSARExportFormatHTML,
+	DSARExportFormatJSON,
+}
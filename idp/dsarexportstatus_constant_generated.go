@@ -0,0 +1,65 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t DSARExportStatus) MarshalText() ([]byte, error) {
+	switch t {
+	case DSARExportStatusCompleted:
+		return []byte("completed"), nil
+	case DSARExportStatusFailed:
+		return []byte("failed"), nil
+	case DSARExportStatusPending:
+		return []byte("pending"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown DSARExportStatus value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *DSARExportStatus) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "completed":
+		*t = DSARExportStatusCompleted
+	case "failed":
+		*t = DSARExportStatusFailed
+	case "pending":
+		*t = DSARExportStatusPending
+	default:
+		return ucerr.Friendlyf(nil, "unknown DSARExportStatus value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *DSARExportStatus) Validate() error {
+	switch *t {
+	case DSARExportStatusCompleted:
+		return nil
+	case DSARExportStatusFailed:
+		return nil
+	case DSARExportStatusPending:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown DSARExportStatus value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t DSARExportStatus) Enum() []any {
+	return []any{
+		"completed",
+		"failed",
+		"pending",
+	}
+}
+
+// AllDSARExportStatuss is a slice of all DSARExportStatus values
+var AllDSARExportStatuss = []DSARExportStatus{
+	DSARExportStatusCompleted,
+	DSARExportStatusFailed,
+	DSARExportStatusPending,
+}
@@ -0,0 +1,89 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t ExplainDecision) MarshalText() ([]byte, error) {
+	switch t {
+	case ExplainDecisionAccessPolicyDenied:
+		return []byte("access_policy_denied"), nil
+	case ExplainDecisionAllowed:
+		return []byte("allowed"), nil
+	case ExplainDecisionBeyondLimit:
+		return []byte("beyond_limit"), nil
+	case ExplainDecisionNoValue:
+		return []byte("no_value"), nil
+	case ExplainDecisionPurposeDenied:
+		return []byte("purpose_denied"), nil
+	case ExplainDecisionResultThresholdExceeded:
+		return []byte("result_threshold_exceeded"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown ExplainDecision value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *ExplainDecision) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "access_policy_denied":
+		*t = ExplainDecisionAccessPolicyDenied
+	case "allowed":
+		*t = ExplainDecisionAllowed
+	case "beyond_limit":
+		*t = ExplainDecisionBeyondLimit
+	case "no_value":
+		*t = ExplainDecisionNoValue
+	case "purpose_denied":
+		*t = ExplainDecisionPurposeDenied
+	case "result_threshold_exceeded":
+		*t = ExplainDecisionResultThresholdExceeded
+	default:
+		return ucerr.Friendlyf(nil, "unknown ExplainDecision value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *ExplainDecision) Validate() error {
+	switch *t {
+	case ExplainDecisionAccessPolicyDenied:
+		return nil
+	case ExplainDecisionAllowed:
+		return nil
+	case ExplainDecisionBeyondLimit:
+		return nil
+	case ExplainDecisionNoValue:
+		return nil
+	case ExplainDecisionPurposeDenied:
+		return nil
+	case ExplainDecisionResultThresholdExceeded:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown ExplainDecision value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t ExplainDecision) Enum() []any {
+	return []any{
+		"access_policy_denied",
+		"allowed",
+		"beyond_limit",
+		"no_value",
+		"purpose_denied",
+		"result_threshold_exceeded",
+	}
+}
+
+// AllExplainDecisions is a slice of all ExplainDecision values
+var AllExplainDecisions = []ExplainDecision{
+	ExplainDecisionAccessPolicyDenied,
+	ExplainDecisionAllowed,
+	ExplainDecisionBeyondLimit,
+	ExplainDecisionNoValue,
+	ExplainDecisionPurposeDenied,
+	ExplainDecisionResultThresholdExceeded,
+}
@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ExportAccessorRequest) Validate() error {
+	if o.AccessorID.IsNil() {
+		return ucerr.Friendlyf(nil, "ExportAccessorRequest.AccessorID can't be nil")
+	}
+	if err := o.Region.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := o.Format.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ExportAccessorToObjectStoreRequest) Validate() error {
+	if err := o.ExportAccessorRequest.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := o.Destination.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
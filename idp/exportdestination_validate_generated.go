@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ExportDestination) Validate() error {
+	if o.ObjectStoreID.IsNil() {
+		return ucerr.Friendlyf(nil, "ExportDestination.ObjectStoreID can't be nil")
+	}
+	if o.Bucket == "" {
+		return ucerr.Friendlyf(nil, "ExportDestination.Bucket can't be empty")
+	}
+	if o.Prefix == "" {
+		return ucerr.Friendlyf(nil, "ExportDestination.Prefix can't be empty")
+	}
+	return nil
+}
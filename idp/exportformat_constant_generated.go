@@ -0,0 +1,65 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t ExportFormat) MarshalText() ([]byte, error) {
+	switch t {
+	case ExportFormatCSV:
+		return []byte("csv"), nil
+	case ExportFormatNDJSON:
+		return []byte("ndjson"), nil
+	case ExportFormatParquet:
+		return []byte("parquet"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown ExportFormat value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *ExportFormat) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "csv":
+		*t = ExportFormatCSV
+	case "ndjson":
+		*t = ExportFormatNDJSON
+	case "parquet":
+		*t = ExportFormatParquet
+	default:
+		return ucerr.Friendlyf(nil, "unknown ExportFormat value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *ExportFormat) Validate() error {
+	switch *t {
+	case ExportFormatCSV:
+		return nil
+	case ExportFormatNDJSON:
+		return nil
+	case ExportFormatParquet:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown ExportFormat value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t ExportFormat) Enum() []any {
+	return []any{
+		"csv",
+		"ndjson",
+		"parquet",
+	}
+}
+
+// AllExportFormats is a slice of all ExportFormat values
+var AllExportFormats = []ExportFormat{
+	ExportFormatCSV,
+	ExportFormatNDJSON,
+	ExportFormatParquet,
+}
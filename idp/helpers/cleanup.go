@@ -2,9 +2,11 @@ package helpers
 
 import (
 	"context"
+	"time"
 
 	"userclouds.com/idp/internal/storage"
 	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
 	"userclouds.com/internal/tenantmap"
 )
 
@@ -18,3 +20,29 @@ func CleanForTenant(ctx context.Context, ts *tenantmap.TenantState, maxCandidate
 	umrs := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
 	return umrs.CleanupUsers(ctx, cm, maxCandidates, dryRun)
 }
+
+// CleanExpiredTokensForTenant cleans up tokenizer tokens that have expired for a tenant
+func CleanExpiredTokensForTenant(ctx context.Context, ts *tenantmap.TenantState, maxCandidates int, dryRun bool) error {
+	s := storage.NewFromTenantState(ctx, ts)
+	return ucerr.Wrap(s.CleanExpiredTokenRecords(ctx, maxCandidates, dryRun))
+}
+
+// CleanExpiredDSARExportsForTenant clears the bundles of up to maxCandidates of a tenant's DSAR exports in each
+// region that can no longer be downloaded
+func CleanExpiredDSARExportsForTenant(ctx context.Context, ts *tenantmap.TenantState, maxCandidates int, dryRun bool) error {
+	if maxCandidates < 1 {
+		return ucerr.Errorf("maxCandidates must be greater than or equal to one: %d", maxCandidates)
+	}
+	if dryRun {
+		uclog.Infof(ctx, "dry run, not clearing expired DSAR export bundles for tenant %v", ts.ID)
+		return nil
+	}
+
+	umrs := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+	cleared, err := umrs.ClearExpiredDSARExportBundles(ctx, time.Now().UTC(), maxCandidates)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	uclog.Infof(ctx, "cleared %d expired DSAR export bundles for tenant %v", cleared, ts.ID)
+	return nil
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/authz/ucauthz"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/apiclient"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/multitenant"
+)
+
+// AdminChecker is the subset of the authz client used to check whether a user is a tenant admin, so it can be faked in tests
+type AdminChecker interface {
+	CheckAttribute(ctx context.Context, sourceObjectID, targetObjectID uuid.UUID, attributeName string, opts ...authz.Option) (*authz.CheckAttributeResponse, error)
+}
+
+// NewAdminChecker returns an authz client for the tenant in the context, authorized as the caller
+func NewAdminChecker(ctx context.Context) (AdminChecker, error) {
+	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return authzClient, nil
+}
+
+// EnsureCompanyAdmin returns forbiddenErr unless the caller is an admin of the tenant's company, or its token has one
+// of the allowed subject types (e.g. an M2M token), for endpoints that reach across all of a tenant's users and data
+func EnsureCompanyAdmin(
+	ctx context.Context,
+	getAdminChecker func(context.Context) (AdminChecker, error),
+	forbiddenErr error,
+	allowedSubjectTypes ...string,
+) (int, error) {
+	if slices.Contains(allowedSubjectTypes, auth.GetSubjectType(ctx)) {
+		return http.StatusOK, nil
+	}
+
+	subjectID := auth.GetSubjectUUID(ctx)
+	if subjectID.IsNil() {
+		return http.StatusForbidden, ucerr.Wrap(forbiddenErr)
+	}
+
+	ac, err := getAdminChecker(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	ts := multitenant.MustGetTenantState(ctx)
+	resp, err := ac.CheckAttribute(ctx, subjectID, ts.CompanyID, ucauthz.EdgeTypeAdmin)
+	if err != nil {
+		return http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+	if !resp.HasAttribute {
+		return http.StatusForbidden, ucerr.Wrap(forbiddenErr)
+	}
+	return http.StatusOK, nil
+}
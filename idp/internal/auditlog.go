@@ -21,11 +21,13 @@ const (
 	AuditLogEventTypeUpdateMutatorConfig auditlog.EventType = "UpdateMutatorConfig"
 	AuditLogEventTypeDeleteMutatorConfig auditlog.EventType = "DeleteMutatorConfig"
 
-	AuditLogEventTypeCreateToken  auditlog.EventType = "CreateToken"
-	AuditLogEventTypeResolveToken auditlog.EventType = "ResolveToken"
-	AuditLogEventTypeDeleteToken  auditlog.EventType = "DeleteToken"
-	AuditLogEventTypeInspectToken auditlog.EventType = "InspectToken"
-	AuditLogEventTypeLookupToken  auditlog.EventType = "LookupToken"
+	AuditLogEventTypeCreateToken   auditlog.EventType = "CreateToken"
+	AuditLogEventTypeResolveToken  auditlog.EventType = "ResolveToken"
+	AuditLogEventTypeDeleteToken   auditlog.EventType = "DeleteToken"
+	AuditLogEventTypeInspectToken  auditlog.EventType = "InspectToken"
+	AuditLogEventTypeLookupToken   auditlog.EventType = "LookupToken"
+	AuditLogEventTypeRevokeTokens  auditlog.EventType = "RevokeTokens"
+	AuditLogEventTypeDecryptTokens auditlog.EventType = "DecryptTokens"
 
 	AuditLogEventTypeCreateAccessPolicy auditlog.EventType = "CreateAccessPolicy"
 	AuditLogEventTypeUpdateAccessPolicy auditlog.EventType = "UpdateAccessPolicy"
@@ -42,5 +44,11 @@ const (
 	AuditLogEventTypeSetAccessorUserSearchIndex    auditlog.EventType = "SetAccessorUserSearchIndex"
 
 	AuditLogEventTypeExecuteAccessor       auditlog.EventType = "ExecuteAccessor"
+	AuditLogEventTypeExportAccessor        auditlog.EventType = "ExportAccessor"
 	AuditLogEventTypeSqlshimUnhandledQuery auditlog.EventType = "UnhandledQuery"
+
+	AuditLogEventTypeCreateDSARExport   auditlog.EventType = "CreateDSARExport"
+	AuditLogEventTypeDownloadDSARExport auditlog.EventType = "DownloadDSARExport"
+
+	AuditLogEventTypeCreateUserErasure auditlog.EventType = "CreateUserErasure"
 )
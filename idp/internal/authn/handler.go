@@ -16,11 +16,9 @@ import (
 	"userclouds.com/authz"
 	"userclouds.com/idp"
 	"userclouds.com/idp/config"
-	"userclouds.com/idp/internal/constants"
 	"userclouds.com/idp/internal/shared"
 	"userclouds.com/idp/internal/storage"
 	userstoreInternal "userclouds.com/idp/internal/userstore"
-	"userclouds.com/idp/policy"
 	"userclouds.com/idp/userstore"
 	"userclouds.com/infra/jsonapi"
 	"userclouds.com/infra/migrate"
@@ -32,7 +30,6 @@ import (
 	"userclouds.com/infra/uchttp"
 	"userclouds.com/infra/uchttp/builder"
 	"userclouds.com/infra/uclog"
-	"userclouds.com/internal/apiclient"
 	"userclouds.com/internal/auditlog"
 	"userclouds.com/internal/auth"
 	"userclouds.com/internal/auth/m2m"
@@ -391,109 +388,8 @@ func (h *handler) updateUser(ctx context.Context, id uuid.UUID, req idp.UpdateUs
 	}
 
 	if req.Profile != nil {
-		cm, err := storage.NewUserstoreColumnManager(ctx, tenantAuthn.ConfigStorage)
-		if err != nil {
-			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
-		}
-		if err := coerceRecordToSchema(cm, req.Profile); err != nil {
-			return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
-		}
-
-		mutator, err := tenantAuthn.ConfigStorage.GetLatestMutator(ctx, constants.UpdateUserMutatorID)
-		if err != nil {
-			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
-		}
-
-		values := map[string]idp.ValueAndPurposes{}
-
-		for _, columnID := range mutator.ColumnIDs {
-			c := cm.GetColumnByID(columnID)
-			if c == nil {
-				return nil, http.StatusInternalServerError, nil, ucerr.Errorf("column %v not found", columnID)
-			}
-
-			if value, found := req.Profile[c.Name]; found {
-				// If the specified value is nil, remove the operational purpose
-				// for any existing value. Otherwise, add the operational purpose
-				// for the specified value.
-
-				if value == nil {
-					if c.Attributes.Constraints.PartialUpdates {
-						values[c.Name] = idp.ValueAndPurposes{
-							ValueDeletions: idp.MutatorColumnCurrentValue,
-							PurposeDeletions: []userstore.ResourceID{
-								{ID: constants.OperationalPurposeID},
-							},
-						}
-					} else {
-						values[c.Name] = idp.ValueAndPurposes{
-							Value: idp.MutatorColumnCurrentValue,
-							PurposeDeletions: []userstore.ResourceID{
-								{ID: constants.OperationalPurposeID},
-							},
-						}
-					}
-				} else if c.Attributes.Constraints.PartialUpdates {
-					values[c.Name] = idp.ValueAndPurposes{
-						ValueAdditions: value,
-						PurposeAdditions: []userstore.ResourceID{
-							{ID: constants.OperationalPurposeID},
-						},
-					}
-				} else {
-					values[c.Name] = idp.ValueAndPurposes{
-						Value: value,
-						PurposeAdditions: []userstore.ResourceID{
-							{ID: constants.OperationalPurposeID},
-						},
-					}
-				}
-			} else {
-				// The column is unspecified, so do not change any values or
-				// associated purposes.
-
-				if c.Attributes.Constraints.PartialUpdates {
-					values[c.Name] = idp.ValueAndPurposes{
-						ValueAdditions: idp.MutatorColumnCurrentValue,
-					}
-				} else {
-					values[c.Name] = idp.ValueAndPurposes{
-						Value: idp.MutatorColumnCurrentValue,
-					}
-				}
-			}
-		}
-
-		authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
-		if err != nil {
-			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
-		}
-
-		userIDs, code, err := userstoreInternal.ExecuteMutator(
-			ctx,
-			idp.ExecuteMutatorRequest{
-				MutatorID:      constants.UpdateUserMutatorID,
-				Context:        policy.ClientContext{},
-				SelectorValues: []any{id},
-				RowData:        values,
-				Region:         reg,
-			},
-			tenantAuthn.ID,
-			authzClient,
-			h.searchUpdateConfig,
-		)
-		if err != nil {
-			switch code {
-			case http.StatusBadRequest:
-				return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
-			case http.StatusConflict:
-				return nil, http.StatusConflict, nil, ucerr.Wrap(err)
-			default:
-				return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
-			}
-		}
-		if len(userIDs) != 1 {
-			return nil, http.StatusInternalServerError, nil, ucerr.Errorf("expected exactly one value from mutator")
+		if code, err := tenantAuthn.Manager.UpdateUserProfile(ctx, h.searchUpdateConfig, id, reg, req.Profile); err != nil {
+			return nil, code, nil, ucerr.Wrap(err)
 		}
 	}
 
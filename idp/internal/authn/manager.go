@@ -17,10 +17,13 @@ import (
 	userstoreInternal "userclouds.com/idp/internal/userstore"
 	"userclouds.com/idp/policy"
 	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/namespace/region"
 	"userclouds.com/infra/oidc"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uchttp"
+	"userclouds.com/internal/apiclient"
+	"userclouds.com/internal/multitenant"
 )
 
 // ErrUsernamePasswordIncorrect represents a bad username or password
@@ -206,6 +209,123 @@ func (m *Manager) CreateUser(
 	return baseUser, http.StatusOK, nil
 }
 
+// UpdateUserProfile updates the specified profile columns for an existing user via the UpdateUser mutator,
+// leaving unspecified columns unchanged. A nil value removes the operational purpose for that column.
+func (m *Manager) UpdateUserProfile(
+	ctx context.Context,
+	searchUpdateConfig *config.SearchUpdateConfig,
+	id uuid.UUID,
+	reg region.DataRegion,
+	profile userstore.Record,
+) (int, error) {
+	cm, err := storage.NewUserstoreColumnManager(ctx, m.configStorage)
+	if err != nil {
+		return http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+	if err := coerceRecordToSchema(cm, profile); err != nil {
+		return http.StatusBadRequest, ucerr.Wrap(err)
+	}
+
+	mutator, err := m.configStorage.GetLatestMutator(ctx, constants.UpdateUserMutatorID)
+	if err != nil {
+		return http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	values := map[string]idp.ValueAndPurposes{}
+
+	for _, columnID := range mutator.ColumnIDs {
+		c := cm.GetColumnByID(columnID)
+		if c == nil {
+			return http.StatusInternalServerError, ucerr.Errorf("column %v not found", columnID)
+		}
+
+		if value, found := profile[c.Name]; found {
+			// If the specified value is nil, remove the operational purpose
+			// for any existing value. Otherwise, add the operational purpose
+			// for the specified value.
+
+			if value == nil {
+				if c.Attributes.Constraints.PartialUpdates {
+					values[c.Name] = idp.ValueAndPurposes{
+						ValueDeletions: idp.MutatorColumnCurrentValue,
+						PurposeDeletions: []userstore.ResourceID{
+							{ID: constants.OperationalPurposeID},
+						},
+					}
+				} else {
+					values[c.Name] = idp.ValueAndPurposes{
+						Value: idp.MutatorColumnCurrentValue,
+						PurposeDeletions: []userstore.ResourceID{
+							{ID: constants.OperationalPurposeID},
+						},
+					}
+				}
+			} else if c.Attributes.Constraints.PartialUpdates {
+				values[c.Name] = idp.ValueAndPurposes{
+					ValueAdditions: value,
+					PurposeAdditions: []userstore.ResourceID{
+						{ID: constants.OperationalPurposeID},
+					},
+				}
+			} else {
+				values[c.Name] = idp.ValueAndPurposes{
+					Value: value,
+					PurposeAdditions: []userstore.ResourceID{
+						{ID: constants.OperationalPurposeID},
+					},
+				}
+			}
+		} else {
+			// The column is unspecified, so do not change any values or
+			// associated purposes.
+
+			if c.Attributes.Constraints.PartialUpdates {
+				values[c.Name] = idp.ValueAndPurposes{
+					ValueAdditions: idp.MutatorColumnCurrentValue,
+				}
+			} else {
+				values[c.Name] = idp.ValueAndPurposes{
+					Value: idp.MutatorColumnCurrentValue,
+				}
+			}
+		}
+	}
+
+	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	userIDs, code, err := userstoreInternal.ExecuteMutator(
+		ctx,
+		idp.ExecuteMutatorRequest{
+			MutatorID:      constants.UpdateUserMutatorID,
+			Context:        policy.ClientContext{},
+			SelectorValues: []any{id},
+			RowData:        values,
+			Region:         reg,
+		},
+		multitenant.MustGetTenantState(ctx).ID,
+		authzClient,
+		searchUpdateConfig,
+	)
+	if err != nil {
+		switch code {
+		case http.StatusBadRequest:
+			return http.StatusBadRequest, ucerr.Wrap(err)
+		case http.StatusConflict:
+			return http.StatusConflict, ucerr.Wrap(err)
+		default:
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+	}
+	if len(userIDs) != 1 {
+		return http.StatusInternalServerError, ucerr.Errorf("expected exactly one value from mutator")
+	}
+
+	return http.StatusOK, nil
+}
+
 // CreateUserWithPassword creates a new user account and credential-based AuthN entry.
 // TODO: detect/disallow duplicate email? Could be a per-tenant setting (default to disallow).
 func (m *Manager) CreateUserWithPassword(
@@ -23,6 +23,7 @@ import (
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/webauthn"
 	"userclouds.com/internal/multitenant"
 	"userclouds.com/plex/manager"
 )
@@ -135,7 +136,9 @@ func (m *Manager) GetMFASettings(ctx context.Context,
 	return mfaChannels, evaluateSettings, nil
 }
 
-func (h *handler) generateMFACode(ctx context.Context, tenantAuthn *AuthN, mfaReq *storage.MFARequest, channel oidc.MFAChannel) (mfaCode string, err error) {
+// generateMFACode issues a new code for the channel. For WebAuthn channels the code is a challenge that
+// the browser needs in order to run the ceremony, so it is also stored on the channel as its challenge key.
+func (h *handler) generateMFACode(ctx context.Context, tenantAuthn *AuthN, mfaReq *storage.MFARequest, userSettings *storage.UserMFAConfiguration, channel *oidc.MFAChannel) (mfaCode string, err error) {
 	switch channel.ChannelType {
 	case oidc.MFAEmailChannel, oidc.MFASMSChannel:
 		mfaCode = crypto.MustRandomDigits(mfaCodeLength)
@@ -143,6 +146,16 @@ func (h *handler) generateMFACode(ctx context.Context, tenantAuthn *AuthN, mfaRe
 	case oidc.MFAAuthenticatorChannel, oidc.MFARecoveryCodeChannel:
 		mfaCode = channel.ChannelName
 		mfaReq.SetCode(channel.ID, channel.ChannelTypeID)
+	case oidc.MFAWebAuthnChannel:
+		mfaCode = webauthn.NewChallenge()
+		mfaReq.SetCode(channel.ID, mfaCode)
+		channel.ChallengeKey = mfaCode
+		if err := userSettings.MFAChannels.UpdateChannel(*channel); err != nil {
+			return mfaCode, ucerr.Wrap(err)
+		}
+		if err := tenantAuthn.ConfigStorage.SaveUserMFAConfiguration(ctx, userSettings); err != nil {
+			return mfaCode, ucerr.Wrap(err)
+		}
 	default:
 		return mfaCode, ucerr.Errorf("unsupported channel type: '%v'", channel.ChannelType)
 	}
@@ -221,7 +234,7 @@ func (h *handler) HandleMFACodeRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mfaCode, err := h.generateMFACode(ctx, tenantAuthn, mfaReq, channel)
+	mfaCode, err := h.generateMFACode(ctx, tenantAuthn, mfaReq, userSettings, &channel)
 	if err != nil {
 		jsonapi.MarshalError(ctx, w, ucerr.Wrap(err))
 		return
@@ -263,6 +276,64 @@ func (h *handler) generateAuthenticatorAppKey(r *http.Request, userID uuid.UUID)
 	return totpKey.String(), nil
 }
 
+func (h *handler) generateWebAuthnChannelTypeID(r *http.Request, userID uuid.UUID) (string, error) {
+	userProfile, err := getUserProfile(r, userID)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	userName := userProfile.StringValue("email")
+	if userName == "" {
+		return "", ucerr.Errorf("no email address found for user '%v'", userID)
+	}
+
+	// the user handle must not contain personally identifying information, so we use the user ID
+	channelTypeID, err := oidc.NewWebAuthnChannelTypeID(userID.Bytes(), userName)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	return channelTypeID, nil
+}
+
+// verifyWebAuthnResponse verifies the ceremony response for a WebAuthn channel against the issued
+// challenge, returning the channel updated with the newly registered credential or new signature counter
+func verifyWebAuthnResponse(channel oidc.MFAChannel, challenge string, mfaCode string) (oidc.MFAChannel, error) {
+	cr, err := webauthn.DecodeCeremonyResponse(mfaCode)
+	if err != nil {
+		return channel, ucerr.Wrap(err)
+	}
+
+	cred, err := channel.GetWebAuthnCredential()
+	if err != nil {
+		return channel, ucerr.Wrap(err)
+	}
+
+	if cred.IsRegistered() {
+		signCount, err := webauthn.VerifyAssertion(cr.RelyingParty, challenge, *cred, cr.Response)
+		if err != nil {
+			return channel, ucerr.Wrap(err)
+		}
+		cred.SignCount = signCount
+	} else {
+		registered, err := webauthn.VerifyRegistration(cr.RelyingParty, challenge, cr.Response)
+		if err != nil {
+			return channel, ucerr.Wrap(err)
+		}
+		registered.UserHandle = cred.UserHandle
+		registered.UserName = cred.UserName
+		cred = registered
+	}
+
+	channel.ChannelTypeID, err = cred.Encode()
+	if err != nil {
+		return channel, ucerr.Wrap(err)
+	}
+	channel.ChallengeKey = ""
+
+	return channel, nil
+}
+
 func (h *handler) HandleMFAClearPrimaryChannelRequest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	tenantAuthn := MustGetTenantAuthn(ctx)
@@ -362,6 +433,22 @@ func (h *handler) HandleMFACreateChannelRequest(w http.ResponseWriter, r *http.R
 			jsonapi.MarshalError(ctx, w, err)
 			return
 		}
+	case oidc.MFAWebAuthnChannel:
+		// the channel type ID in the request is the user's name for the security key or passkey
+		channelTypeID, err := h.generateWebAuthnChannelTypeID(r, userSettings.ID)
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err)
+			return
+		}
+		channelName := req.ChannelTypeID
+		if channelName == "" {
+			channelName = oidc.DefaultWebAuthnChannelName
+		}
+		channel, err = userSettings.MFAChannels.AddChannel(req.ChannelType, channelTypeID, channelName, false)
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err)
+			return
+		}
 	default:
 		jsonapi.MarshalError(ctx, w, ucerr.Errorf("channel type '%v' is not supported", req.ChannelType))
 		return
@@ -373,7 +460,7 @@ func (h *handler) HandleMFACreateChannelRequest(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	mfaCode, err := h.generateMFACode(ctx, tenantAuthn, mfaReq, channel)
+	mfaCode, err := h.generateMFACode(ctx, tenantAuthn, mfaReq, userSettings, &channel)
 	if err != nil {
 		jsonapi.MarshalError(ctx, w, ucerr.Wrap(err))
 		return
@@ -683,6 +770,18 @@ func (h *handler) HandleMFAResponse(w http.ResponseWriter, r *http.Request) {
 			uclog.Debugf(ctx, "Recovery Code auth failed for req '%v'", req.MFAToken)
 			resp.Status = idp.LoginStatusMFACodeInvalid
 		}
+	case oidc.MFAWebAuthnChannel:
+		now := time.Now().UTC()
+		if issued.Add(mfaCodeExpiration).Before(now) {
+			uclog.Debugf(ctx, "WebAuthn auth %v failed because it took too long", req.MFAToken)
+			resp.Status = idp.LoginStatusMFACodeExpired
+		} else if updatedChannel, err := verifyWebAuthnResponse(channel, code, req.MFACode); err != nil {
+			uclog.Debugf(ctx, "WebAuthn auth failed for req '%v' with error '%v'", req.MFAToken, err)
+			resp.Status = idp.LoginStatusMFACodeInvalid
+		} else if err := userSettings.MFAChannels.UpdateChannel(updatedChannel); err != nil {
+			jsonapi.MarshalError(ctx, w, ucerr.Wrap(err))
+			return
+		}
 	default:
 		jsonapi.MarshalError(ctx, w, ucerr.Errorf("unsupported channel type: '%v'", channel.ChannelType))
 		return
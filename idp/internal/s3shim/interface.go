@@ -1,9 +1,24 @@
 package s3shim
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 // Controller is the interface for the s3shim controller
 type Controller interface {
-	CheckPermission(ctx context.Context, jwt string, path string) (bool, error)
-	TransformData(ctx context.Context, data []byte) ([]byte, error)
+	CheckPermission(ctx context.Context, jwt string, method string, path string) (bool, error)
+
+	// TransformData returns a reader for the transformed object data, and whether the data was changed (in which
+	// case its length is no longer known up front)
+	TransformData(ctx context.Context, path string, contentType string, data io.Reader) (io.ReadCloser, bool, error)
+
+	// TransformsDownload returns whether objects read from path are transformed before they are returned
+	TransformsDownload(path string) bool
+
+	// TransformsUpload returns whether objects uploaded to path are transformed before they are stored
+	TransformsUpload(path string, contentType string) bool
+
+	// TransformUpload returns a reader for the data to store for an uploaded object, and whether it was changed
+	TransformUpload(ctx context.Context, path string, contentType string, data io.Reader) (io.ReadCloser, bool, error)
 }
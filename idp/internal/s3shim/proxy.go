@@ -1,7 +1,6 @@
 package s3shim
 
 import (
-	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
@@ -28,13 +27,12 @@ import (
 	"userclouds.com/internal/tenantmap"
 )
 
-func copyHeader(dst, src http.Header, bodyLength int) {
+func copyHeader(dst, src http.Header, transformed bool) {
 	for k, vv := range src {
-		if k == "Content-Length" {
-			dst.Set("Content-Length", fmt.Sprintf("%d", bodyLength))
+		if k == "X-Forwarded-For" || k == "X-Forwarded-Host" || k == "X-Forwarded-Proto" {
 			continue
 		}
-		if k == "X-Forwarded-For" || k == "X-Forwarded-Host" || k == "X-Forwarded-Proto" {
+		if transformed && (k == "Content-Length" || k == "Content-Md5" || k == "Etag" || strings.HasPrefix(k, "X-Amz-Checksum-")) {
 			continue
 		}
 		for _, v := range vv {
@@ -100,8 +98,8 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Check the request method
-	if req.Method != http.MethodGet && req.Method != http.MethodHead {
-		uchttp.Error(ctx, w, getFriendlyXMLError(nil, "NotAllowed", "only GET and HEAD methods allowed"), http.StatusMethodNotAllowed)
+	if !isAllowedRequest(req) {
+		uchttp.Error(ctx, w, getFriendlyXMLError(nil, "NotAllowed", "only GET, HEAD, PUT (without a copy source, subresources or permission headers) and multipart upload requests allowed"), http.StatusMethodNotAllowed)
 		return
 	}
 
@@ -161,7 +159,7 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	// Check if the user has permission to access the bucket and object
 	controller := userstore.NewIdpS3ShimController(ts, p.jwtVerifier, p.cacheConfig, objectStoreInfo)
-	if ok, err := controller.CheckPermission(ctx, jwt, path); err != nil {
+	if ok, err := controller.CheckPermission(ctx, jwt, req.Method, path); err != nil {
 		uchttp.Error(ctx, w, getFriendlyXMLError(err, "InternalServerError", "failed to check permission: %s", ucerr.UserFriendlyMessage(err)), http.StatusInternalServerError)
 		return
 	} else if !ok {
@@ -169,15 +167,76 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Transformed objects are parsed as a whole, so reads of part of one would be returned as stored, and overriding
+	// the response content type could change how it's parsed
+	if req.Method == http.MethodGet && isPartialOrOverriddenRead(req) && controller.TransformsDownload(path) {
+		uchttp.Error(ctx, w, getFriendlyXMLError(nil, "NotImplemented", "objects with transformed fields must be read whole, without response header overrides"), http.StatusNotImplemented)
+		return
+	}
+
+	// Parts of a multipart upload are arbitrary byte ranges of the object, so we can't parse them to tokenize fields
+	contentType := req.Header.Get("Content-Type")
+	if isMultipartUploadRequest(req) && controller.TransformsUpload(path, contentType) {
+		uchttp.Error(ctx, w, getFriendlyXMLError(nil, "NotImplemented", "objects with tokenized fields must be uploaded with a single PUT"), http.StatusNotImplemented)
+		return
+	}
+
+	// Tokenize the configured fields of uploaded objects before they reach the bucket
+	body := io.Reader(req.Body)
+	contentLength := req.ContentLength
+	uploadTransformed := false
+	if req.Method == http.MethodPut && !req.URL.Query().Has("uploadId") &&
+		controller.TransformsUpload(path, contentType) {
+		if isAWSChunked(req) {
+			body = newAWSChunkedReader(req.Body)
+		}
+
+		transformed, _, err := controller.TransformUpload(ctx, path, contentType, body)
+		if err != nil {
+			uchttp.Error(ctx, w, getFriendlyXMLError(err, "InternalServerError", "failed to transform upload: %s", ucerr.UserFriendlyMessage(err)), http.StatusInternalServerError)
+			return
+		}
+		defer func() {
+			if err := transformed.Close(); err != nil {
+				uclog.Errorf(ctx, "failed to close transformed upload: %v", err)
+			}
+		}()
+
+		spooled, size, err := spoolToTempFile(ctx, transformed)
+		if err != nil {
+			uchttp.Error(ctx, w, getFriendlyXMLError(err, "BadRequest", "failed to transform upload: %s", ucerr.UserFriendlyMessage(err)), http.StatusBadRequest)
+			return
+		}
+		defer func() {
+			if err := spooled.Close(); err != nil {
+				uclog.Errorf(ctx, "failed to close spooled upload: %v", err)
+			}
+		}()
+		body = spooled
+		contentLength = size
+		uploadTransformed = true
+	}
+
 	// Create a new request based on the incoming request
 	awsURL := fmt.Sprintf("https://s3.%s.amazonaws.com/%s", objectStoreInfo.Region, path)
-	reqAWS, err := http.NewRequestWithContext(ctx, req.Method, awsURL, req.Body)
+	if query := forwardedQuery(req.URL); query != "" {
+		awsURL += "?" + query
+	}
+	reqAWS, err := http.NewRequestWithContext(ctx, req.Method, awsURL, body)
 	if err != nil {
 		uchttp.Error(ctx, w, getFriendlyXMLError(err, "BadRequest", "invalid request: %s", ucerr.UserFriendlyMessage(err)), http.StatusBadRequest)
 		return
 	}
 	reqAWS.Host = req.URL.Host
-	copyHeader(reqAWS.Header, req.Header, 0)
+	reqAWS.ContentLength = contentLength
+	copyHeader(reqAWS.Header, req.Header, false)
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		removePermissionHeaders(reqAWS.Header)
+	}
+	if uploadTransformed {
+		removeUploadHeaders(reqAWS.Header)
+		reqAWS.Header.Set("X-Amz-Content-SHA256", unsignedPayload)
+	}
 	t := time.Now().UTC()
 	reqAWS.Header.Set("X-Amz-Date", t.Format("20060102T150405Z"))
 
@@ -207,23 +266,33 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		uchttp.Error(ctx, w, getFriendlyXMLError(err, "InternalServerError", "failed to read response body: %s", ucerr.UserFriendlyMessage(err)), http.StatusInternalServerError)
-		return
-	}
-
-	transformed, err := controller.TransformData(ctx, body)
-	if err != nil {
-		uchttp.Error(ctx, w, getFriendlyXMLError(err, "InternalServerError", "failed to transform data: %s", ucerr.UserFriendlyMessage(err)), http.StatusInternalServerError)
-		return
+	// only successful reads of object data are transformed, errors and HEAD responses are passed through
+	respBody := io.ReadCloser(resp.Body)
+	transformed := false
+	if req.Method == http.MethodGet && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		respBody, transformed, err = controller.TransformData(ctx, path, resp.Header.Get("Content-Type"), resp.Body)
+		if err != nil {
+			uchttp.Error(ctx, w, getFriendlyXMLError(err, "InternalServerError", "failed to transform data: %s", ucerr.UserFriendlyMessage(err)), http.StatusInternalServerError)
+			return
+		}
+		defer func() {
+			if err := respBody.Close(); err != nil {
+				uclog.Errorf(ctx, "failed to close transformed body: %v", err)
+			}
+		}()
 	}
 
-	// Create the response headers (with new body length), then write the headers and body
-	copyHeader(w.Header(), resp.Header, len(transformed))
+	// Copy the response headers, then stream the body; if the data was transformed its length isn't known up front,
+	// and the checksums S3 computed for it no longer apply
+	copyHeader(w.Header(), resp.Header, transformed)
 	uclog.Verbosef(ctx, "Outgoing headers: %v", resp.Header)
 	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, bytes.NewReader(transformed))
+	if _, err := io.Copy(w, respBody); err != nil {
+		// the status has already been sent, so abort the response rather than let the client see a truncated body
+		// as a complete one
+		uclog.Errorf(ctx, "failed to stream response body: %v", err)
+		panic(http.ErrAbortHandler)
+	}
 }
 
 // RunNewProxy starts a new s3 proxy server on the given port with the given region and credentials
@@ -0,0 +1,295 @@
+package s3shim
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// unsignedPayload is the payload hash we sign requests with when we've changed the body, since the hash the client
+// computed no longer applies
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// allowedQueryParams are the (lowercased) query parameters that object reads, PUT uploads and multipart uploads use.
+// Any other parameter selects a subresource like "?acl" or "?policy", which would change the bucket or object with
+// the shim's own credentials rather than read or write object data.
+var allowedQueryParams = map[string]bool{
+	"uploads":    true,
+	"uploadid":   true,
+	"partnumber": true,
+	"versionid":  true,
+	"x-id":       true, // added by the AWS SDKs to name the operation
+}
+
+// isAllowedQueryParam returns whether the query parameter can be sent to the shim. Presigned URL parameters are
+// allowed since they are dropped before forwarding, and response-* parameters override the headers of a read.
+func isAllowedQueryParam(method string, param string) bool {
+	param = strings.ToLower(param)
+	if allowedQueryParams[param] || strings.HasPrefix(param, "x-amz-") {
+		return true
+	}
+	return method == http.MethodGet && strings.HasPrefix(param, "response-")
+}
+
+// permissionHeaders are the request headers (or prefixes of them) that change who can access an object or how it is
+// served, rather than describing its data
+var permissionHeaders = []string{
+	"X-Amz-Acl",
+	"X-Amz-Grant-",
+	"X-Amz-Website-Redirect-Location",
+	"X-Amz-Tagging",
+	"X-Amz-Object-Lock-",
+}
+
+func isPermissionHeader(k string) bool {
+	k = http.CanonicalHeaderKey(k)
+	for _, h := range permissionHeaders {
+		if k == h || (strings.HasSuffix(h, "-") && strings.HasPrefix(k, h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// removePermissionHeaders removes any permissionHeaders from a request that writes to S3
+func removePermissionHeaders(h http.Header) {
+	for k := range h {
+		if isPermissionHeader(k) {
+			h.Del(k)
+		}
+	}
+}
+
+// isAllowedRequest returns whether the request is one the shim proxies: reads, single PUT uploads, and the requests
+// that make up a multipart upload. Copies (CopyObject and UploadPartCopy) are rejected, since S3 reads their source
+// object directly, so it would neither be authorized against its own path nor transformed. Requests for any other
+// subresource, or that set an object's permissions, are rejected since the access policy only sees the path and method.
+func isAllowedRequest(req *http.Request) bool {
+	if req.Header.Get("X-Amz-Copy-Source") != "" {
+		return false
+	}
+	for k := range req.Header {
+		if isPermissionHeader(k) {
+			return false
+		}
+	}
+
+	q := req.URL.Query()
+	for param := range q {
+		if !isAllowedQueryParam(req.Method, param) {
+			return false
+		}
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+		return true
+	case http.MethodPost:
+		// CreateMultipartUpload and CompleteMultipartUpload
+		return q.Has("uploads") || q.Has("uploadId")
+	case http.MethodDelete:
+		// AbortMultipartUpload
+		return q.Has("uploadId")
+	}
+	return false
+}
+
+// isPartialOrOverriddenRead returns whether a read asks for only part of an object (with a Range header or a part
+// number) or overrides any of the response headers, like the content type, with response-* parameters
+func isPartialOrOverriddenRead(req *http.Request) bool {
+	if req.Header.Get("Range") != "" {
+		return true
+	}
+	for param := range req.URL.Query() {
+		param = strings.ToLower(param)
+		if param == "partnumber" || strings.HasPrefix(param, "response-") {
+			return true
+		}
+	}
+	return false
+}
+
+// isMultipartUploadRequest returns whether the request starts a multipart upload or uploads one of its parts
+func isMultipartUploadRequest(req *http.Request) bool {
+	q := req.URL.Query()
+	return (req.Method == http.MethodPost && q.Has("uploads")) || (req.Method == http.MethodPut && q.Has("uploadId"))
+}
+
+// forwardedQuery returns the query string to send to S3, without any presigned URL parameters since we sign the
+// forwarded request ourselves. The remaining parameters are kept as they were sent, since S3 distinguishes some
+// subresources like "?uploads" by name alone.
+func forwardedQuery(u *url.URL) string {
+	var params []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" || strings.HasPrefix(strings.ToLower(param), "x-amz-") {
+			continue
+		}
+		params = append(params, param)
+	}
+	return strings.Join(params, "&")
+}
+
+// isAWSChunked returns whether the request body uses the aws-chunked encoding of streaming signed uploads
+func isAWSChunked(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("X-Amz-Content-Sha256"), "STREAMING-")
+}
+
+// awsChunkedReader decodes an aws-chunked request body, where each chunk is preceded by a line with its hex length
+// and (optionally) its signature. We authorize uploads through the JWT rather than the chunk signatures, so they are
+// not verified. Any trailing headers after the last chunk are discarded.
+type awsChunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	done      bool
+}
+
+func newAWSChunkedReader(r io.Reader) *awsChunkedReader {
+	return &awsChunkedReader{r: bufio.NewReader(r)}
+}
+
+// Read implements io.Reader
+func (cr *awsChunkedReader) Read(p []byte) (int, error) {
+	for cr.remaining == 0 {
+		if cr.done {
+			return 0, io.EOF // lint: ucerr-ignore
+		}
+		if err := cr.nextChunk(); err != nil {
+			return 0, ucerr.Wrap(err)
+		}
+	}
+
+	if int64(len(p)) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+	n, err := cr.r.Read(p)
+	cr.remaining -= int64(n)
+	if errors.Is(err, io.EOF) {
+		return n, ucerr.Wrap(io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return n, ucerr.Wrap(err)
+	}
+
+	if cr.remaining == 0 {
+		// each chunk's data is followed by a CRLF
+		if _, err := cr.readLine(); err != nil {
+			return n, ucerr.Wrap(err)
+		}
+	}
+	return n, nil
+}
+
+func (cr *awsChunkedReader) readLine() (string, error) {
+	line, err := cr.r.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", ucerr.Wrap(io.ErrUnexpectedEOF)
+		}
+		return "", ucerr.Wrap(err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (cr *awsChunkedReader) nextChunk() error {
+	line, err := cr.readLine()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	sizeHex, _, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeHex), 16, 64)
+	if err != nil || size < 0 {
+		return ucerr.Errorf("invalid aws-chunked chunk header '%s'", line)
+	}
+
+	if size == 0 {
+		// skip any trailing headers up to the final blank line, which some clients omit
+		for {
+			trailer, err := cr.r.ReadString('\n')
+			if errors.Is(err, io.EOF) || strings.TrimRight(trailer, "\r\n") == "" {
+				break
+			} else if err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+		cr.done = true
+		return nil
+	}
+
+	cr.remaining = size
+	return nil
+}
+
+// spoolToTempFile copies data to a temporary file, since S3 requires the length of an upload up front and we don't
+// want to hold large objects in memory. The caller is responsible for closing the returned file, which removes it.
+func spoolToTempFile(ctx context.Context, data io.Reader) (*tempFile, int64, error) {
+	f, err := os.CreateTemp("", "s3shim-upload-*")
+	if err != nil {
+		return nil, 0, ucerr.Wrap(err)
+	}
+	tf := &tempFile{File: f, ctx: ctx}
+
+	size, err := io.Copy(f, data)
+	if err != nil {
+		tf.Close()
+		return nil, 0, ucerr.Wrap(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		tf.Close()
+		return nil, 0, ucerr.Wrap(err)
+	}
+	return tf, size, nil
+}
+
+// tempFile is a temporary file that is removed when closed
+type tempFile struct {
+	*os.File
+	ctx       context.Context
+	closeOnce sync.Once
+}
+
+// Close implements io.Closer, and can be called more than once since the HTTP client closes request bodies itself
+func (tf *tempFile) Close() error {
+	var closeErr error
+	tf.closeOnce.Do(func() {
+		closeErr = tf.File.Close()
+		if err := os.Remove(tf.Name()); err != nil {
+			uclog.Warningf(tf.ctx, "failed to remove temporary upload file %s: %v", tf.Name(), err)
+		}
+	})
+	return ucerr.Wrap(closeErr)
+}
+
+// uploadHeaders are the request headers that describe the encoding or checksum of the original upload body, which
+// no longer apply once the body has been transformed
+var uploadHeaders = []string{
+	"Content-Length",
+	"Content-Md5",
+	"Content-Encoding",
+	"X-Amz-Content-Sha256",
+	"X-Amz-Decoded-Content-Length",
+	"X-Amz-Trailer",
+	"X-Amz-Sdk-Checksum-Algorithm",
+	"X-Amz-Checksum-Algorithm",
+}
+
+func removeUploadHeaders(h http.Header) {
+	for _, k := range uploadHeaders {
+		h.Del(k)
+	}
+	for k := range h {
+		if strings.HasPrefix(k, "X-Amz-Checksum-") {
+			h.Del(k)
+		}
+	}
+}
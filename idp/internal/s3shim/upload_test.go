@@ -0,0 +1,150 @@
+package s3shim
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"userclouds.com/infra/assert"
+)
+
+func TestAllowedRequests(t *testing.T) {
+	for _, tc := range []struct {
+		method    string
+		target    string
+		allowed   bool
+		multipart bool
+	}{
+		{http.MethodGet, "/bucket/key.json", true, false},
+		{http.MethodHead, "/bucket/key.json", true, false},
+		{http.MethodPut, "/bucket/key.json", true, false},
+		{http.MethodPost, "/bucket/key.json?uploads", true, true},
+		{http.MethodPut, "/bucket/key.json?partNumber=1&uploadId=abc", true, true},
+		{http.MethodPost, "/bucket/key.json?uploadId=abc", true, false},
+		{http.MethodDelete, "/bucket/key.json?uploadId=abc", true, false},
+		{http.MethodDelete, "/bucket/key.json", false, false},
+		{http.MethodPost, "/bucket/key.json", false, false},
+		{http.MethodPatch, "/bucket/key.json", false, false},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		assert.Equal(t, isAllowedRequest(req), tc.allowed, assert.Errorf("%s %s", tc.method, tc.target))
+		assert.Equal(t, isMultipartUploadRequest(req), tc.multipart, assert.Errorf("%s %s", tc.method, tc.target))
+	}
+
+	// copies would read the source object without authorizing or transforming it
+	for _, target := range []string{"/bucket/key.json", "/bucket/key.json?partNumber=1&uploadId=abc"} {
+		req := httptest.NewRequest(http.MethodPut, target, nil)
+		req.Header.Set("X-Amz-Copy-Source", "/bucket/other.json")
+		assert.False(t, isAllowedRequest(req), assert.Errorf("copy to %s", target))
+	}
+
+	// subresources would change the bucket or object with the shim's credentials, and reads of them aren't object data
+	for _, tc := range []struct {
+		method string
+		target string
+	}{
+		{http.MethodPut, "/bucket?policy"},
+		{http.MethodPut, "/bucket/key.json?acl"},
+		{http.MethodPut, "/bucket/key.json?tagging"},
+		{http.MethodPut, "/bucket?lifecycle"},
+		{http.MethodPut, "/bucket?versioning"},
+		{http.MethodGet, "/bucket?policy"},
+		{http.MethodGet, "/bucket/key.json?acl"},
+		{http.MethodPut, "/bucket/key.json?response-content-type=text%2Fplain"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		assert.False(t, isAllowedRequest(req), assert.Errorf("%s %s", tc.method, tc.target))
+	}
+	for _, target := range []string{"/bucket/key.json?versionId=3&x-id=GetObject", "/bucket/key.json?X-Amz-Signature=abc&response-content-type=text%2Fplain"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		assert.True(t, isAllowedRequest(req), assert.Errorf("GET %s", target))
+	}
+
+	// as are uploads that set the object's permissions
+	for header, value := range map[string]string{
+		"x-amz-acl":                       "public-read",
+		"X-Amz-Grant-Read":                "uri=http://acs.amazonaws.com/groups/global/AllUsers",
+		"x-amz-website-redirect-location": "https://example.com",
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/bucket/key.json", nil)
+		req.Header.Set(header, value)
+		assert.False(t, isAllowedRequest(req), assert.Errorf("PUT with %s", header))
+	}
+
+	for target, partial := range map[string]bool{
+		"/bucket/key.json?versionId=3":                             false,
+		"/bucket/key.json?partNumber=1":                            true,
+		"/bucket/key.json?response-content-type=text%2Fplain":      true,
+		"/bucket/key.json?Response-Content-Disposition=attachment": true,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		assert.Equal(t, isPartialOrOverriddenRead(req), partial, assert.Errorf("GET %s", target))
+	}
+	req := httptest.NewRequest(http.MethodGet, "/bucket/key.json", nil)
+	req.Header.Set("Range", "bytes=0-10")
+	assert.True(t, isPartialOrOverriddenRead(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/bucket/key.json?versionId=3&X-Amz-Signature=abc&x-amz-expires=60", nil)
+	assert.Equal(t, forwardedQuery(req.URL), "versionId=3")
+	req = httptest.NewRequest(http.MethodPost, "/bucket/key.json?uploads", nil)
+	assert.Equal(t, forwardedQuery(req.URL), "uploads")
+}
+
+func TestAWSChunkedReader(t *testing.T) {
+	signed := "9;chunk-signature=0123\r\n{\"email\":\r\n9;chunk-signature=4567\r\n\"a@b.com\"\r\n1;chunk-signature=89ab\r\n}\r\n0;chunk-signature=cdef\r\n\r\n"
+	data, err := io.ReadAll(newAWSChunkedReader(strings.NewReader(signed)))
+	assert.NoErr(t, err)
+	assert.Equal(t, string(data), `{"email":"a@b.com"}`)
+
+	// unsigned chunks with trailing checksum headers
+	trailer := "5\r\nhello\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n"
+	data, err = io.ReadAll(newAWSChunkedReader(strings.NewReader(trailer)))
+	assert.NoErr(t, err)
+	assert.Equal(t, string(data), "hello")
+
+	_, err = io.ReadAll(newAWSChunkedReader(strings.NewReader("a\r\nshort")))
+	assert.NotNil(t, err)
+	_, err = io.ReadAll(newAWSChunkedReader(strings.NewReader("zz\r\n")))
+	assert.NotNil(t, err)
+}
+
+func TestSpoolToTempFile(t *testing.T) {
+	tf, size, err := spoolToTempFile(context.Background(), strings.NewReader("some data"))
+	assert.NoErr(t, err)
+	assert.Equal(t, size, int64(9))
+
+	data, err := io.ReadAll(tf)
+	assert.NoErr(t, err)
+	assert.Equal(t, string(data), "some data")
+
+	assert.NoErr(t, tf.Close())
+	assert.NoErr(t, tf.Close())
+	_, err = os.Stat(tf.Name())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRemovePermissionHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Amz-Acl", "public-read")
+	h.Set("X-Amz-Grant-Full-Control", "id=abc")
+	h.Set("X-Amz-Website-Redirect-Location", "/other")
+	h.Set("X-Amz-Meta-Owner", "alice")
+	removePermissionHeaders(h)
+	assert.Equal(t, h, http.Header{"Content-Type": {"application/json"}, "X-Amz-Meta-Owner": {"alice"}})
+}
+
+func TestRemoveUploadHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-MD5", "abc")
+	h.Set("X-Amz-Decoded-Content-Length", "19")
+	h.Set("X-Amz-Checksum-Crc32", "AAAAAA==")
+	h.Set("X-Amz-Meta-Owner", "alice")
+	removeUploadHeaders(h)
+	assert.Equal(t, h, http.Header{"Content-Type": {"application/json"}, "X-Amz-Meta-Owner": {"alice"}})
+}
@@ -0,0 +1,232 @@
+package scim
+
+import (
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// multiValuedAttributes are the core SCIM user attributes whose values are lists of complex values,
+// see https://datatracker.ietf.org/doc/html/rfc7643#section-4.1.2
+var multiValuedAttributes = map[string]bool{
+	"addresses":        true,
+	"emails":           true,
+	"entitlements":     true,
+	"ims":              true,
+	"phonenumbers":     true,
+	"photos":           true,
+	"roles":            true,
+	"x509certificates": true,
+}
+
+// attributePath is a parsed SCIM attribute path, eg. "name.givenName" or
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department"
+type attributePath struct {
+	schema string // extension schema URN, empty for core attributes
+	name   string
+	sub    string
+}
+
+func parseAttributePath(path string) (attributePath, error) {
+	var ap attributePath
+
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		i := strings.LastIndex(path, ":")
+		ap.schema = path[:i]
+		path = path[i+1:]
+		if strings.EqualFold(ap.schema, schemaUser) || strings.EqualFold(ap.schema, schemaGroup) {
+			ap.schema = ""
+		}
+	}
+
+	name, sub, _ := strings.Cut(path, ".")
+	if name == "" || strings.Contains(sub, ".") {
+		return ap, ucerr.Friendlyf(nil, "invalid attribute path '%s'", path)
+	}
+	ap.name = name
+	ap.sub = sub
+	return ap, nil
+}
+
+func (ap attributePath) String() string {
+	s := ap.name
+	if ap.sub != "" {
+		s += "." + ap.sub
+	}
+	if ap.schema != "" {
+		s = ap.schema + ":" + s
+	}
+	return s
+}
+
+func (ap attributePath) equals(other attributePath) bool {
+	return strings.EqualFold(ap.String(), other.String())
+}
+
+func (ap attributePath) isMultiValued() bool {
+	return ap.schema == "" && multiValuedAttributes[strings.ToLower(ap.name)]
+}
+
+// getKey returns the value for a key in a resource, matching the key case-insensitively
+// since SCIM attribute names are case-insensitive
+func getKey(resource map[string]any, key string) (string, any, bool) {
+	if v, found := resource[key]; found {
+		return key, v, true
+	}
+	for k, v := range resource {
+		if strings.EqualFold(k, key) {
+			return k, v, true
+		}
+	}
+	return key, nil, false
+}
+
+// container returns the (possibly nested) object holding the attribute, creating it if requested
+func (ap attributePath) container(resource map[string]any, create bool) map[string]any {
+	if ap.schema == "" {
+		return resource
+	}
+	key, v, found := getKey(resource, ap.schema)
+	if ext, ok := v.(map[string]any); found && ok {
+		return ext
+	}
+	if !create {
+		return nil
+	}
+	ext := map[string]any{}
+	resource[key] = ext
+	return ext
+}
+
+// primaryValue returns the primary (or failing that, the first) value of a multi-valued attribute
+func primaryValue(values []any) map[string]any {
+	var first map[string]any
+	for _, v := range values {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if primary, ok := m["primary"].(bool); ok && primary {
+			return m
+		}
+		if first == nil {
+			first = m
+		}
+	}
+	return first
+}
+
+// getValues returns all values of the attribute in the resource, which will be more than one
+// for a sub-attribute of a multi-valued attribute with several values
+func (ap attributePath) getValues(resource map[string]any) []any {
+	c := ap.container(resource, false)
+	if c == nil {
+		return nil
+	}
+	_, v, found := getKey(c, ap.name)
+	if !found || v == nil {
+		return nil
+	}
+	if ap.sub == "" {
+		return []any{v}
+	}
+
+	var values []any
+	switch t := v.(type) {
+	case map[string]any:
+		if _, sv, found := getKey(t, ap.sub); found && sv != nil {
+			values = append(values, sv)
+		}
+	case []any:
+		for _, elem := range t {
+			if m, ok := elem.(map[string]any); ok {
+				if _, sv, found := getKey(m, ap.sub); found && sv != nil {
+					values = append(values, sv)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// getValue returns the single value of the attribute in the resource, using the primary value
+// for sub-attributes of multi-valued attributes
+func (ap attributePath) getValue(resource map[string]any) any {
+	c := ap.container(resource, false)
+	if c == nil {
+		return nil
+	}
+	_, v, found := getKey(c, ap.name)
+	if !found || v == nil {
+		return nil
+	}
+	if ap.sub == "" {
+		return v
+	}
+
+	var m map[string]any
+	switch t := v.(type) {
+	case map[string]any:
+		m = t
+	case []any:
+		m = primaryValue(t)
+	}
+	if m == nil {
+		return nil
+	}
+	_, sv, _ := getKey(m, ap.sub)
+	return sv
+}
+
+// setValue sets the attribute in the resource, setting the primary value for sub-attributes of
+// multi-valued attributes; a nil value removes the attribute
+func (ap attributePath) setValue(resource map[string]any, value any) {
+	c := ap.container(resource, value != nil)
+	if c == nil {
+		return
+	}
+	key, v, found := getKey(c, ap.name)
+	if ap.sub == "" {
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return
+	}
+
+	if ap.isMultiValued() {
+		values, _ := v.([]any)
+		m := primaryValue(values)
+		if m == nil {
+			if value == nil {
+				return
+			}
+			m = map[string]any{"primary": true}
+			values = append(values, m)
+			c[key] = values
+		}
+		subKey, _, _ := getKey(m, ap.sub)
+		if value == nil {
+			delete(m, subKey)
+		} else {
+			m[subKey] = value
+		}
+		return
+	}
+
+	m, ok := v.(map[string]any)
+	if !found || !ok {
+		if value == nil {
+			return
+		}
+		m = map[string]any{}
+		c[key] = m
+	}
+	subKey, _, _ := getKey(m, ap.sub)
+	if value == nil {
+		delete(m, subKey)
+	} else {
+		m[subKey] = value
+	}
+}
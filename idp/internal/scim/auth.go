@@ -0,0 +1,34 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+
+	"userclouds.com/authz"
+	"userclouds.com/idp/internal"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/auth/m2m"
+)
+
+// errProvisioningForbidden is returned for SCIM requests made with an end user's token
+var errProvisioningForbidden = ucerr.Friendlyf(nil, "SCIM requests require a client credentials token or a tenant admin")
+
+// ensureProvisioningClient returns an error unless the request was made by a tenant client (with a token from
+// the client credentials grant), with an M2M token, or by a tenant admin. Any user can get a tenant token by
+// logging in, and they must not be able to create, change or delete other users.
+func ensureProvisioningClient(ctx context.Context, getAdminChecker func(context.Context) (internal.AdminChecker, error)) (int, error) {
+	code, err := internal.EnsureCompanyAdmin(ctx, getAdminChecker, errProvisioningForbidden, authz.ObjectTypeLoginApp, m2m.SubjectTypeM2M)
+	return code, ucerr.Wrap(err)
+}
+
+// requireProvisioningClient wraps a handler so it's only called for requests allowed by ensureProvisioningClient
+func (h *handler) requireProvisioningClient(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if code, err := ensureProvisioningClient(ctx, h.getAdminChecker); err != nil {
+			marshalError(ctx, w, err, code, "")
+			return
+		}
+		f(w, r)
+	}
+}
@@ -0,0 +1,71 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/authz/ucauthz"
+	"userclouds.com/idp/internal"
+	"userclouds.com/infra/assert"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/auth/m2m"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantmap"
+)
+
+type fakeAdminChecker struct {
+	companyID uuid.UUID
+	admins    map[uuid.UUID]bool
+}
+
+func (f *fakeAdminChecker) CheckAttribute(_ context.Context, sourceObjectID, targetObjectID uuid.UUID, attributeName string, _ ...authz.Option) (*authz.CheckAttributeResponse, error) {
+	return &authz.CheckAttributeResponse{HasAttribute: targetObjectID == f.companyID && attributeName == ucauthz.EdgeTypeAdmin && f.admins[sourceObjectID]}, nil
+}
+
+func TestProvisioningClientRequired(t *testing.T) {
+	companyID := uuid.Must(uuid.NewV4())
+	adminID := uuid.Must(uuid.NewV4())
+	userID := uuid.Must(uuid.NewV4())
+
+	ac := &fakeAdminChecker{companyID: companyID, admins: map[uuid.UUID]bool{adminID: true}}
+	getAdminChecker := func(context.Context) (internal.AdminChecker, error) { return ac, nil }
+	ctx := multitenant.SetTenantState(context.Background(), &tenantmap.TenantState{CompanyID: companyID})
+
+	for _, tc := range []struct {
+		subjectID   uuid.UUID
+		subjectType string
+		code        int
+	}{
+		{uuid.Must(uuid.NewV4()), authz.ObjectTypeLoginApp, http.StatusOK},
+		{uuid.Must(uuid.NewV4()), m2m.SubjectTypeM2M, http.StatusOK},
+		{adminID, authz.ObjectTypeUser, http.StatusOK},
+		{userID, authz.ObjectTypeUser, http.StatusForbidden},
+		{uuid.Nil, "", http.StatusForbidden},
+	} {
+		code, err := ensureProvisioningClient(auth.SetSubjectTypeAndUUID(ctx, tc.subjectID, tc.subjectType), getAdminChecker)
+		assert.Equal(t, code, tc.code, assert.Errorf("%s %v: %v", tc.subjectType, tc.subjectID, err))
+	}
+
+	// end users can't create, change or delete users or groups
+	h := newHandler(&handler{getAdminChecker: getAdminChecker})
+	userCtx := auth.SetSubjectTypeAndUUID(ctx, userID, authz.ObjectTypeUser)
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/Users"},
+		{http.MethodPatch, "/Users/" + userID.String()},
+		{http.MethodDelete, "/Users/" + adminID.String()},
+		{http.MethodGet, "/Users"},
+		{http.MethodPost, "/Groups"},
+	} {
+		r := httptest.NewRequest(req.method, req.path, strings.NewReader(`{"userName":"mallory"}`)).WithContext(userCtx)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Code, http.StatusForbidden, assert.Errorf("%s %s", req.method, req.path))
+		assert.Contains(t, w.Body.String(), schemaError)
+	}
+}
@@ -0,0 +1,464 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+)
+
+// filterOperator is a SCIM filter operator, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2
+type filterOperator string
+
+const (
+	filterAnd filterOperator = "and"
+	filterOr  filterOperator = "or"
+	filterNot filterOperator = "not"
+
+	filterEq filterOperator = "eq"
+	filterNe filterOperator = "ne"
+	filterCo filterOperator = "co"
+	filterSw filterOperator = "sw"
+	filterEw filterOperator = "ew"
+	filterGt filterOperator = "gt"
+	filterGe filterOperator = "ge"
+	filterLt filterOperator = "lt"
+	filterLe filterOperator = "le"
+	filterPr filterOperator = "pr"
+)
+
+func (op filterOperator) isComparison() bool {
+	switch op {
+	case filterEq, filterNe, filterCo, filterSw, filterEw, filterGt, filterGe, filterLt, filterLe:
+		return true
+	}
+	return false
+}
+
+// filterExpression is a parsed SCIM filter
+type filterExpression struct {
+	operator filterOperator
+	path     attributePath       // for comparisons & presence
+	value    any                 // for comparisons: string, float64, bool or nil
+	children []*filterExpression // two for and/or, one for not
+}
+
+type filterParser struct {
+	filter string
+	tokens []string
+	index  int
+}
+
+func tokenizeFilter(filter string) ([]string, error) {
+	var tokens []string
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, ucerr.Friendlyf(nil, "filter '%s' has an unterminated string", filter)
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune(`()[]"`, runes[j]); j++ {
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (fp *filterParser) makeError(s string) error {
+	return ucerr.Friendlyf(nil, "filter '%s' %s", fp.filter, s)
+}
+
+func (fp *filterParser) peek() string {
+	if fp.index >= len(fp.tokens) {
+		return ""
+	}
+	return fp.tokens[fp.index]
+}
+
+func (fp *filterParser) next() string {
+	t := fp.peek()
+	fp.index++
+	return t
+}
+
+func (fp *filterParser) parseOr() (*filterExpression, error) {
+	left, err := fp.parseAnd()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	for strings.EqualFold(fp.peek(), string(filterOr)) {
+		fp.next()
+		right, err := fp.parseAnd()
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		left = &filterExpression{operator: filterOr, children: []*filterExpression{left, right}}
+	}
+	return left, nil
+}
+
+func (fp *filterParser) parseAnd() (*filterExpression, error) {
+	left, err := fp.parseFactor()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	for strings.EqualFold(fp.peek(), string(filterAnd)) {
+		fp.next()
+		right, err := fp.parseFactor()
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		left = &filterExpression{operator: filterAnd, children: []*filterExpression{left, right}}
+	}
+	return left, nil
+}
+
+func (fp *filterParser) parseFactor() (*filterExpression, error) {
+	negate := false
+	if strings.EqualFold(fp.peek(), string(filterNot)) {
+		fp.next()
+		negate = true
+	}
+
+	var fe *filterExpression
+	if fp.peek() == "(" {
+		fp.next()
+		inner, err := fp.parseOr()
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if fp.next() != ")" {
+			return nil, ucerr.Wrap(fp.makeError("has unbalanced parentheses"))
+		}
+		fe = inner
+	} else if negate {
+		return nil, ucerr.Wrap(fp.makeError("must use parentheses after 'not'"))
+	} else {
+		comparison, err := fp.parseComparison()
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		fe = comparison
+	}
+
+	if negate {
+		return &filterExpression{operator: filterNot, children: []*filterExpression{fe}}, nil
+	}
+	return fe, nil
+}
+
+func (fp *filterParser) parseComparison() (*filterExpression, error) {
+	token := fp.next()
+	if token == "" {
+		return nil, ucerr.Wrap(fp.makeError("ends unexpectedly"))
+	}
+	if fp.peek() == "[" {
+		return nil, ucerr.Wrap(fp.makeError("uses unsupported value filters"))
+	}
+
+	path, err := parseAttributePath(token)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	op := filterOperator(strings.ToLower(fp.next()))
+	if op == filterPr {
+		return &filterExpression{operator: op, path: path}, nil
+	}
+	if !op.isComparison() {
+		return nil, ucerr.Wrap(fp.makeError(fmt.Sprintf("has unsupported operator '%s'", op)))
+	}
+
+	value, err := parseFilterValue(fp.next())
+	if err != nil {
+		return nil, ucerr.Wrap(fp.makeError(fmt.Sprintf("has invalid value: %v", err)))
+	}
+
+	return &filterExpression{operator: op, path: path, value: value}, nil
+}
+
+func parseFilterValue(token string) (any, error) {
+	switch {
+	case token == "":
+		return nil, ucerr.New("missing value")
+	case strings.HasPrefix(token, `"`):
+		var s string
+		if err := json.Unmarshal([]byte(token), &s); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return s, nil
+	case strings.EqualFold(token, "true"):
+		return true, nil
+	case strings.EqualFold(token, "false"):
+		return false, nil
+	case strings.EqualFold(token, "null"):
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return f, nil
+}
+
+// parseFilter parses a SCIM filter, eg. `userName eq "bjensen" and not (title pr)`
+func parseFilter(filter string) (*filterExpression, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if len(tokens) == 0 {
+		return nil, ucerr.Friendlyf(nil, "filter is empty")
+	}
+
+	fp := &filterParser{filter: filter, tokens: tokens}
+	fe, err := fp.parseOr()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if fp.index != len(fp.tokens) {
+		return nil, ucerr.Wrap(fp.makeError(fmt.Sprintf("has unexpected token '%s'", fp.peek())))
+	}
+	return fe, nil
+}
+
+func compareValues(op filterOperator, actual any, expected any) bool {
+	switch e := expected.(type) {
+	case nil:
+		return op == filterNe
+	case bool:
+		a, ok := actual.(bool)
+		if !ok {
+			return op == filterNe
+		}
+		switch op {
+		case filterEq:
+			return a == e
+		case filterNe:
+			return a != e
+		}
+		return false
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return op == filterNe
+		}
+		return compareOrdered(op, a, e)
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return op == filterNe
+		}
+
+		// date-times are compared chronologically rather than lexically
+		if at, err := time.Parse(time.RFC3339Nano, a); err == nil {
+			if et, err := time.Parse(time.RFC3339Nano, e); err == nil {
+				return compareOrdered(op, at.UnixNano(), et.UnixNano())
+			}
+		}
+
+		a = strings.ToLower(a)
+		e = strings.ToLower(e)
+		switch op {
+		case filterCo:
+			return strings.Contains(a, e)
+		case filterSw:
+			return strings.HasPrefix(a, e)
+		case filterEw:
+			return strings.HasSuffix(a, e)
+		}
+		return compareOrdered(op, a, e)
+	}
+	return false
+}
+
+func compareOrdered[T int64 | float64 | string](op filterOperator, a T, e T) bool {
+	switch op {
+	case filterEq:
+		return a == e
+	case filterNe:
+		return a != e
+	case filterGt:
+		return a > e
+	case filterGe:
+		return a >= e
+	case filterLt:
+		return a < e
+	case filterLe:
+		return a <= e
+	}
+	return false
+}
+
+// matches evaluates the filter against a rendered SCIM resource; it's used for PATCH value filters,
+// since list filters are always evaluated by the database
+func (fe *filterExpression) matches(resource map[string]any) bool {
+	switch fe.operator {
+	case filterAnd:
+		return fe.children[0].matches(resource) && fe.children[1].matches(resource)
+	case filterOr:
+		return fe.children[0].matches(resource) || fe.children[1].matches(resource)
+	case filterNot:
+		return !fe.children[0].matches(resource)
+	case filterPr:
+		for _, v := range fe.path.getValues(resource) {
+			if s, ok := v.(string); !ok || s != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	path := fe.path
+	if path.sub == "" && path.isMultiValued() {
+		// comparisons against a multi-valued attribute apply to its "value" sub-attribute,
+		// see https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2
+		path.sub = "value"
+	}
+
+	values := path.getValues(resource)
+	if len(values) == 0 {
+		return fe.operator == filterNe && fe.value != nil
+	}
+	for _, v := range values {
+		if compareValues(fe.operator, v, fe.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// paginationKey describes how a SCIM attribute is stored in a pageable table
+type paginationKey struct {
+	name string
+	kind pagination.KeyType
+}
+
+var paginationOperators = map[filterOperator]string{
+	filterEq: "EQ",
+	filterNe: "NE",
+	filterGt: "GT",
+	filterGe: "GE",
+	filterLt: "LT",
+	filterLe: "LE",
+	filterCo: "IL",
+	filterSw: "IL",
+	filterEw: "IL",
+}
+
+func (fe *filterExpression) paginationLeaf(keys map[string]paginationKey) (string, bool) {
+	var key paginationKey
+	found := false
+	for path, k := range keys {
+		if strings.EqualFold(path, fe.path.String()) {
+			key = k
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	value, ok := fe.value.(string)
+	if !ok || strings.ContainsAny(value, `'"\`) {
+		return "", false
+	}
+
+	op := paginationOperators[fe.operator]
+	switch key.kind {
+	case pagination.UUIDKeyType:
+		if op != "EQ" && op != "NE" {
+			return "", false
+		}
+		if _, err := uuid.FromString(value); err != nil {
+			return "", false
+		}
+	case pagination.TimestampKeyType:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil || op == "IL" {
+			return "", false
+		}
+		value = strconv.FormatInt(t.UnixMicro(), 10)
+	case pagination.StringKeyType:
+		if strings.ContainsAny(value, "%_") {
+			return "", false
+		}
+		switch fe.operator {
+		case filterEq:
+			// SCIM string comparisons are case-insensitive
+			op = "IL"
+		case filterCo:
+			value = "%" + value + "%"
+		case filterSw:
+			value = value + "%"
+		case filterEw:
+			value = "%" + value
+		}
+	default:
+		return "", false
+	}
+
+	return fmt.Sprintf("('%s',%s,'%s')", key.name, op, value), true
+}
+
+func (fe *filterExpression) paginationFilterString(keys map[string]paginationKey) (string, bool) {
+	switch fe.operator {
+	case filterAnd, filterOr:
+		left, ok := fe.children[0].paginationFilterString(keys)
+		if !ok {
+			return "", false
+		}
+		right, ok := fe.children[1].paginationFilterString(keys)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("(%s,%s,%s)", left, strings.ToUpper(string(fe.operator)), right), true
+	case filterNot, filterPr:
+		return "", false
+	}
+	return fe.paginationLeaf(keys)
+}
+
+// paginationFilter translates the filter into the infra/pagination filter syntax, so that it can be
+// evaluated by the database, returning false if the filter refers to attributes or uses operators
+// that can't be expressed that way
+func (fe *filterExpression) paginationFilter(keys map[string]paginationKey) (string, bool) {
+	filter, ok := fe.paginationFilterString(keys)
+	if !ok {
+		return "", false
+	}
+
+	if _, err := pagination.CreateFilterQuery(filter); err != nil {
+		return "", false
+	}
+
+	return filter, true
+}
@@ -0,0 +1,104 @@
+package scim
+
+import (
+	"testing"
+
+	"userclouds.com/infra/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, tc := range []struct {
+		filter string
+		valid  bool
+	}{
+		{`userName eq "bjensen"`, true},
+		{`name.familyName co "O'Malley"`, true},
+		{`meta.lastModified gt "2011-05-13T04:42:34Z"`, true},
+		{`title pr and userType eq "Employee"`, true},
+		{`not (emails co "example.com" or emails.value co "example.org")`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "J"`, true},
+		{`userName eq`, false},
+		{`userName foo "bjensen"`, false},
+		{`(userName eq "bjensen"`, false},
+		{`emails[type eq "work"]`, false},
+	} {
+		_, err := parseFilter(tc.filter)
+		assert.Equal(t, err == nil, tc.valid, assert.Errorf("filter %s: %v", tc.filter, err))
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	resource := map[string]any{
+		"userName":    "BJensen@example.com",
+		"displayName": "Babs Jensen",
+		"active":      true,
+		"name":        map[string]any{"familyName": "Jensen"},
+		"emails": []any{
+			map[string]any{"value": "babs@jensen.org", "type": "home"},
+			map[string]any{"value": "bjensen@example.com", "type": "work", "primary": true},
+		},
+		"meta": map[string]any{"lastModified": "2011-05-13T04:42:34Z"},
+	}
+
+	for _, tc := range []struct {
+		filter  string
+		matches bool
+	}{
+		{`userName eq "bjensen@example.com"`, true},
+		{`userName ne "bjensen@example.com"`, false},
+		{`name.familyName sw "jen"`, true},
+		{`emails.value ew "jensen.org"`, true},
+		{`emails co "example.com"`, true},
+		{`title pr`, false},
+		{`not (title pr)`, true},
+		{`active eq true and displayName co "Babs"`, true},
+		{`active eq false or displayName co "Barbara"`, false},
+		{`meta.lastModified gt "2011-05-13T04:42:34.000+01:00"`, true},
+		{`meta.lastModified lt "2011-01-01T00:00:00Z"`, false},
+	} {
+		fe, err := parseFilter(tc.filter)
+		assert.NoErr(t, err)
+		assert.Equal(t, fe.matches(resource), tc.matches, assert.Errorf("filter %s", tc.filter))
+	}
+}
+
+func TestPaginationFilter(t *testing.T) {
+	for _, tc := range []struct {
+		filter   string
+		expected string
+		ok       bool
+	}{
+		{`id eq "0a2a4b18-7e0b-4d2e-9c2a-7d9d1b5d7d8c"`, "('id',EQ,'0a2a4b18-7e0b-4d2e-9c2a-7d9d1b5d7d8c')", true},
+		{`displayName eq "Admins"`, "('alias',IL,'Admins')", true},
+		{`displayName sw "Eng" or displayName co "ops"`, "(('alias',IL,'Eng%'),OR,('alias',IL,'%ops%'))", true},
+		{`meta.created ge "2024-01-01T00:00:00Z"`, "('created',GE,'1704067200000000')", true},
+		{`id eq "not-a-uuid"`, "", false},
+		{`displayName eq "100%"`, "", false},
+		{`displayName pr`, "", false},
+		{`members.value eq "0a2a4b18-7e0b-4d2e-9c2a-7d9d1b5d7d8c"`, "", false},
+	} {
+		fe, err := parseFilter(tc.filter)
+		assert.NoErr(t, err)
+		filter, ok := fe.paginationFilter(groupPaginationKeys)
+		assert.Equal(t, ok, tc.ok, assert.Errorf("filter %s", tc.filter))
+		assert.Equal(t, filter, tc.expected)
+	}
+
+	// users can only be filtered on the columns of the users table
+	for _, tc := range []struct {
+		filter   string
+		expected string
+		ok       bool
+	}{
+		{`meta.lastModified lt "2024-01-01T00:00:00Z"`, "('updated',LT,'1704067200000000')", true},
+		{`id eq "0a2a4b18-7e0b-4d2e-9c2a-7d9d1b5d7d8c" or meta.created gt "2024-01-01T00:00:00Z"`, "(('id',EQ,'0a2a4b18-7e0b-4d2e-9c2a-7d9d1b5d7d8c'),OR,('created',GT,'1704067200000000'))", true},
+		{`userName eq "bjensen"`, "", false},
+		{`id eq "0a2a4b18-7e0b-4d2e-9c2a-7d9d1b5d7d8c" and active eq true`, "", false},
+	} {
+		fe, err := parseFilter(tc.filter)
+		assert.NoErr(t, err)
+		filter, ok := fe.paginationFilter(userPaginationKeys)
+		assert.Equal(t, ok, tc.ok, assert.Errorf("filter %s", tc.filter))
+		assert.Equal(t, filter, tc.expected)
+	}
+}
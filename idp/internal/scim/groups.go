@@ -0,0 +1,469 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/authz/ucauthz"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/apiclient"
+)
+
+// groupPaginationKeys are the group attributes that can be filtered on in the authz objects table
+var groupPaginationKeys = map[string]paginationKey{
+	"id":                {name: "id", kind: pagination.UUIDKeyType},
+	"displayName":       {name: "alias", kind: pagination.StringKeyType},
+	"meta.created":      {name: "created", kind: pagination.TimestampKeyType},
+	"meta.lastModified": {name: "updated", kind: pagination.TimestampKeyType},
+}
+
+// groupClient wraps the authz clients used to store SCIM groups as authz group objects,
+// with members connected by edges of the configured member role
+type groupClient struct {
+	authzClient *authz.Client
+	rbacClient  *authz.RBACClient
+	memberRole  string
+}
+
+func newGroupClient(ctx context.Context) (*groupClient, error) {
+	cfg, err := getConfig(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	memberRole := cfg.GroupMemberRole
+	if memberRole == "" {
+		memberRole = ucauthz.MemberRole
+	}
+
+	return &groupClient{
+		authzClient: authzClient,
+		rbacClient:  authz.NewRBACClient(authzClient),
+		memberRole:  memberRole,
+	}, nil
+}
+
+// authzErrorCode returns the status code to return for an error from the authz service
+func authzErrorCode(err error) int {
+	if errors.Is(err, authz.ErrObjectNotFound) {
+		return http.StatusNotFound
+	}
+	if code := jsonclient.GetHTTPStatusCode(err); code >= http.StatusBadRequest && code < http.StatusInternalServerError {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// getMembers returns the group's memberships with the member role
+func (gc *groupClient) getMembers(ctx context.Context, g *authz.Group) ([]authz.Membership, error) {
+	memberships, err := g.GetMemberships(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	members := []authz.Membership{}
+	for _, m := range memberships {
+		if m.Role == gc.memberRole {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+// renderGroup returns the SCIM resource for a group object and its ETag
+func (gc *groupClient) renderGroup(ctx context.Context, obj *authz.Object) (*group, string, error) {
+	g, err := gc.rbacClient.GetGroup(ctx, obj.ID)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	memberships, err := gc.getMembers(ctx, g)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	res := &group{
+		Schemas:     []string{schemaGroup},
+		ID:          obj.ID.String(),
+		DisplayName: g.Name,
+		Members:     []groupMember{},
+	}
+	for _, m := range memberships {
+		if !res.hasMember(m.User.ID.String()) {
+			res.Members = append(res.Members, groupMember{
+				Value: m.User.ID.String(),
+				Ref:   resourceLocation(ctx, resourceTypeUser, m.User.ID),
+			})
+		}
+	}
+	// sort members so the ETag doesn't depend on the order edges are listed in
+	slices.SortFunc(res.Members, func(a, b groupMember) int { return strings.Compare(a.Value, b.Value) })
+
+	etag, err := resourceETag(res)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	res.Meta = &meta{
+		ResourceType: resourceTypeGroup,
+		Created:      formatTime(obj.Created),
+		LastModified: formatTime(obj.Updated),
+		Location:     resourceLocation(ctx, resourceTypeGroup, obj.ID),
+		Version:      etag,
+	}
+
+	return res, etag, nil
+}
+
+// getGroup returns the current SCIM resource & ETag for a group
+func (gc *groupClient) getGroup(ctx context.Context, id uuid.UUID) (*group, string, int, error) {
+	obj, err := gc.authzClient.GetObject(ctx, id, authz.BypassCache())
+	if err != nil {
+		return nil, "", authzErrorCode(err), ucerr.Wrap(err)
+	}
+	if obj.TypeID != authz.GroupObjectTypeID {
+		return nil, "", http.StatusNotFound, ucerr.Friendlyf(nil, "group '%v' not found", id)
+	}
+
+	res, etag, err := gc.renderGroup(ctx, obj)
+	if err != nil {
+		return nil, "", authzErrorCode(err), ucerr.Wrap(err)
+	}
+	return res, etag, http.StatusOK, nil
+}
+
+// setMembers updates the group's memberships to match the desired members
+func (gc *groupClient) setMembers(ctx context.Context, id uuid.UUID, members []groupMember) (int, error) {
+	g, err := gc.rbacClient.GetGroup(ctx, id)
+	if err != nil {
+		return authzErrorCode(err), ucerr.Wrap(err)
+	}
+
+	current, err := gc.getMembers(ctx, g)
+	if err != nil {
+		return authzErrorCode(err), ucerr.Wrap(err)
+	}
+
+	desired := map[uuid.UUID]bool{}
+	for _, m := range members {
+		userID, err := uuid.FromString(m.Value)
+		if err != nil {
+			return http.StatusBadRequest, ucerr.Friendlyf(err, "group member '%s' is not a valid user ID", m.Value)
+		}
+		desired[userID] = true
+	}
+
+	existing := map[uuid.UUID]bool{}
+	for _, m := range current {
+		existing[m.User.ID] = true
+		if !desired[m.User.ID] {
+			if err := g.RemoveMembership(ctx, m); err != nil {
+				return authzErrorCode(err), ucerr.Wrap(err)
+			}
+		}
+	}
+
+	for userID := range desired {
+		if existing[userID] {
+			continue
+		}
+		user, err := gc.rbacClient.GetUser(ctx, userID)
+		if err != nil {
+			if code := authzErrorCode(err); code != http.StatusNotFound {
+				return code, ucerr.Wrap(err)
+			}
+			return http.StatusBadRequest, ucerr.Friendlyf(err, "group member '%v' is not a user", userID)
+		}
+		if _, err := g.AddUserRole(ctx, *user, gc.memberRole); err != nil {
+			return authzErrorCode(err), ucerr.Wrap(err)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+func (h *handler) handleGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseResourceID(r.URL.Path)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusNotFound, "")
+		return
+	}
+
+	gc, err := newGroupClient(ctx)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	switch {
+	case id.IsNil() && r.Method == http.MethodGet:
+		h.listGroups(w, r, gc)
+	case id.IsNil() && r.Method == http.MethodPost:
+		h.createGroup(w, r, gc)
+	case !id.IsNil() && r.Method == http.MethodGet:
+		h.getGroupByID(w, r, gc, id)
+	case !id.IsNil() && r.Method == http.MethodPut:
+		h.replaceGroup(w, r, gc, id)
+	case !id.IsNil() && r.Method == http.MethodPatch:
+		h.patchGroup(w, r, gc, id)
+	case !id.IsNil() && r.Method == http.MethodDelete:
+		h.deleteGroup(w, r, gc, id)
+	default:
+		marshalMethodNotAllowed(ctx, w, r)
+	}
+}
+
+// toMap converts a group to the generic representation used for filtering
+func (g group) toMap() (map[string]any, error) {
+	bs, err := json.Marshal(g)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	var resource map[string]any
+	if err := json.Unmarshal(bs, &resource); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return resource, nil
+}
+
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request, gc *groupClient) {
+	ctx := r.Context()
+
+	lr, err := parseListRequest(r.URL.Query())
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidFilter)
+		return
+	}
+
+	filter := fmt.Sprintf("('type_id',EQ,'%v')", authz.GroupObjectTypeID)
+	if lr.filter != nil {
+		pf, ok := lr.filter.paginationFilter(groupPaginationKeys)
+		if !ok {
+			marshalError(ctx, w,
+				ucerr.Friendlyf(nil, "groups can only be filtered on id, displayName, meta.created and meta.lastModified"),
+				http.StatusBadRequest,
+				errorTypeInvalidFilter)
+			return
+		}
+		filter = fmt.Sprintf("(%s,AND,%s)", filter, pf)
+	}
+
+	resources := []any{}
+	totalResults := 0
+	cursor := pagination.CursorBegin
+	for {
+		resp, err := gc.authzClient.ListObjects(ctx,
+			authz.Pagination(
+				pagination.Filter(filter),
+				pagination.Limit(pagination.MaxLimit),
+				pagination.StartingAfter(cursor)))
+		if err != nil {
+			marshalError(ctx, w, err, authzErrorCode(err), "")
+			return
+		}
+
+		for i := range resp.Data {
+			// only render the groups on the requested page, since rendering requires listing the group's edges
+			if lr.inWindow(totalResults) {
+				res, _, err := gc.renderGroup(ctx, &resp.Data[i])
+				if err != nil {
+					marshalError(ctx, w, err, authzErrorCode(err), "")
+					return
+				}
+				resources = append(resources, res)
+			}
+			totalResults++
+		}
+
+		if !resp.HasNext {
+			break
+		}
+		cursor = resp.Next
+	}
+
+	jsonapi.Marshal(w, newListResponse(resources, totalResults, lr.startIndex))
+}
+
+func (h *handler) getGroupByID(w http.ResponseWriter, r *http.Request, gc *groupClient, id uuid.UUID) {
+	ctx := r.Context()
+
+	res, etag, code, err := gc.getGroup(ctx, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	jsonapi.Marshal(w, res)
+}
+
+func (h *handler) createGroup(w http.ResponseWriter, r *http.Request, gc *groupClient) {
+	ctx := r.Context()
+
+	var req group
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	id := uuid.Must(uuid.NewV4())
+	if _, err := gc.rbacClient.CreateGroup(ctx, id, req.DisplayName); err != nil {
+		if code := authzErrorCode(err); code == http.StatusConflict {
+			marshalError(ctx, w, err, code, errorTypeUniqueness)
+		} else {
+			marshalError(ctx, w, err, code, "")
+		}
+		return
+	}
+
+	if code, err := gc.setMembers(ctx, id, req.Members); err != nil {
+		// don't leave a partially provisioned group behind, since the client will retry the create
+		if err := gc.authzClient.DeleteObject(ctx, id); err != nil {
+			marshalError(ctx, w, err, http.StatusInternalServerError, "")
+			return
+		}
+		marshalError(ctx, w, err, code, errorTypeInvalidValue)
+		return
+	}
+
+	res, etag, code, err := gc.getGroup(ctx, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Location", resourceLocation(ctx, resourceTypeGroup, id))
+	jsonapi.Marshal(w, res, jsonapi.Code(http.StatusCreated))
+}
+
+// updateGroup writes the changes from the current to the updated group and returns the updated resource
+func (gc *groupClient) updateGroup(ctx context.Context, id uuid.UUID, current *group, updated *group) (*group, string, int, error) {
+	if err := updated.Validate(); err != nil {
+		return nil, "", http.StatusBadRequest, ucerr.Wrap(err)
+	}
+
+	if updated.DisplayName != current.DisplayName {
+		if _, err := gc.authzClient.UpdateObject(ctx, id, &updated.DisplayName); err != nil {
+			return nil, "", authzErrorCode(err), ucerr.Wrap(err)
+		}
+	}
+
+	if code, err := gc.setMembers(ctx, id, updated.Members); err != nil {
+		return nil, "", code, ucerr.Wrap(err)
+	}
+
+	return gc.getGroup(ctx, id)
+}
+
+func (h *handler) replaceGroup(w http.ResponseWriter, r *http.Request, gc *groupClient, id uuid.UUID) {
+	ctx := r.Context()
+
+	current, etag, code, err := gc.getGroup(ctx, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	var req group
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	res, etag, code, err := gc.updateGroup(ctx, id, current, &req)
+	if err != nil {
+		marshalUpdateError(ctx, w, err, code)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	jsonapi.Marshal(w, res)
+}
+
+func (h *handler) patchGroup(w http.ResponseWriter, r *http.Request, gc *groupClient, id uuid.UUID) {
+	ctx := r.Context()
+
+	current, etag, code, err := gc.getGroup(ctx, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	var req patchRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidSyntax)
+		return
+	}
+
+	updated := *current
+	updated.Members = slices.Clone(current.Members)
+	if err := applyGroupPatch(&updated, req.Operations); err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	res, etag, code, err := gc.updateGroup(ctx, id, current, &updated)
+	if err != nil {
+		marshalUpdateError(ctx, w, err, code)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	jsonapi.Marshal(w, res)
+}
+
+func (h *handler) deleteGroup(w http.ResponseWriter, r *http.Request, gc *groupClient, id uuid.UUID) {
+	ctx := r.Context()
+
+	_, etag, code, err := gc.getGroup(ctx, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	if err := gc.authzClient.DeleteObject(ctx, id); err != nil {
+		marshalError(ctx, w, err, authzErrorCode(err), "")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,230 @@
+package scim
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp/config"
+	"userclouds.com/idp/internal"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uchttp/builder"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/internal/tenantplex/storage"
+)
+
+// maxCount is the largest page of resources we'll return for a single list request
+const maxCount = pagination.MaxLimit
+
+type handler struct {
+	searchUpdateConfig *config.SearchUpdateConfig
+	getAdminChecker    func(context.Context) (internal.AdminChecker, error)
+}
+
+// NewHandler returns a handler for the SCIM 2.0 provisioning API (RFC 7644), which lets customers
+// push users and groups from their own directory into the tenant
+func NewHandler(searchUpdateConfig *config.SearchUpdateConfig) http.Handler {
+	return newHandler(&handler{searchUpdateConfig: searchUpdateConfig, getAdminChecker: internal.NewAdminChecker})
+}
+
+func newHandler(h *handler) http.Handler {
+	hb := builder.NewHandlerBuilder()
+	hb.HandleFunc("/ServiceProviderConfig", h.getServiceProviderConfig)
+	// Handle (unlike HandleFunc) strips the resource type, leaving the handlers the resource ID if there is one
+	hb.Handle("/Users", h.requireProvisioningClient(h.handleUsers))
+	hb.Handle("/Groups", h.requireProvisioningClient(h.handleGroups))
+	return hb.Build()
+}
+
+// marshalError writes a SCIM error response, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+func marshalError(ctx context.Context, w http.ResponseWriter, err error, code int, scimType string) {
+	if code >= http.StatusInternalServerError {
+		uclog.Errorf(ctx, "SCIM request failed: %v", err)
+	} else {
+		uclog.Debugf(ctx, "SCIM request failed with %d: %v", code, err)
+	}
+
+	jsonapi.Marshal(w,
+		errorResponse{
+			Schemas:  []string{schemaError},
+			Status:   strconv.Itoa(code),
+			SCIMType: scimType,
+			Detail:   ucerr.UserFriendlyMessage(err),
+		},
+		jsonapi.Code(code))
+}
+
+func marshalMethodNotAllowed(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	marshalError(ctx, w, ucerr.Friendlyf(nil, "method %s is not supported", r.Method), http.StatusMethodNotAllowed, "")
+}
+
+// parseResourceID returns the resource ID from a request path relative to the resource type endpoint,
+// or uuid.Nil if the request is for the resource type endpoint itself
+func parseResourceID(path string) (uuid.UUID, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.FromString(path)
+	if err != nil {
+		return uuid.Nil, ucerr.Friendlyf(err, "resource '%s' not found", path)
+	}
+	return id, nil
+}
+
+func getConfig(ctx context.Context) (*tenantplex.SCIMConfig, error) {
+	ts := multitenant.MustGetTenantState(ctx)
+	s := storage.New(ctx, ts.TenantDB, ts.CacheConfig)
+	tenantPlex, err := s.GetTenantPlex(ctx, ts.ID)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return &tenantPlex.PlexConfig.SCIM, nil
+}
+
+func resourceLocation(ctx context.Context, resourceType string, id uuid.UUID) string {
+	ts := multitenant.MustGetTenantState(ctx)
+	return fmt.Sprintf("%s/scim/v2/%ss/%v", ts.GetTenantURL(), resourceType, id)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (m meta) toMap() map[string]any {
+	return map[string]any{
+		"resourceType": m.ResourceType,
+		"created":      m.Created,
+		"lastModified": m.LastModified,
+		"location":     m.Location,
+		"version":      m.Version,
+	}
+}
+
+// resourceETag returns a weak ETag for a rendered resource (before meta is added). We hash the
+// representation rather than using a row version since a group's members are stored as authz
+// edges, which don't change the group object.
+func resourceETag(resource any) (string, error) {
+	bs, err := json.Marshal(resource)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	sum := sha256.Sum256(bs)
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString(sum[:8])), nil
+}
+
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions returns a non-zero status code if the request's If-Match or If-None-Match
+// headers aren't satisfied by the current version of the resource
+func checkPreconditions(r *http.Request, etag string) int {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && !etagMatches(ifMatch, etag) {
+		return http.StatusPreconditionFailed
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		if r.Method == http.MethodGet {
+			return http.StatusNotModified
+		}
+		return http.StatusPreconditionFailed
+	}
+	return 0
+}
+
+func marshalPreconditionFailure(ctx context.Context, w http.ResponseWriter, code int, etag string) {
+	if code == http.StatusNotModified {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(code)
+		return
+	}
+	marshalError(ctx, w, ucerr.Friendlyf(nil, "resource has been modified"), code, "")
+}
+
+// listRequest holds the query parameters for listing resources,
+// see https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2
+type listRequest struct {
+	filter     *filterExpression
+	startIndex int
+	count      int
+}
+
+func parseListRequest(query url.Values) (*listRequest, error) {
+	lr := &listRequest{startIndex: 1, count: maxCount}
+
+	if f := query.Get("filter"); f != "" {
+		filter, err := parseFilter(f)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		lr.filter = filter
+	}
+
+	if s := query.Get("startIndex"); s != "" {
+		startIndex, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, ucerr.Friendlyf(err, "invalid startIndex '%s'", s)
+		}
+		// per the RFC, values less than one are interpreted as one
+		lr.startIndex = max(startIndex, 1)
+	}
+
+	if c := query.Get("count"); c != "" {
+		count, err := strconv.Atoi(c)
+		if err != nil {
+			return nil, ucerr.Friendlyf(err, "invalid count '%s'", c)
+		}
+		lr.count = min(max(count, 0), maxCount)
+	}
+
+	return lr, nil
+}
+
+// inWindow returns true if the (zero-based) index of a matching resource is in the requested page
+func (lr listRequest) inWindow(index int) bool {
+	return index >= lr.startIndex-1 && index < lr.startIndex-1+lr.count
+}
+
+func (h *handler) getServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		marshalMethodNotAllowed(ctx, w, r)
+		return
+	}
+
+	jsonapi.Marshal(w, serviceProviderConfig{
+		Schemas:        []string{schemaServiceProviderConfig},
+		Patch:          supported{Supported: true},
+		Bulk:           bulkSupport{},
+		Filter:         filterSupport{Supported: true, MaxResults: maxCount},
+		ChangePassword: supported{},
+		Sort:           supported{},
+		ETag:           supported{Supported: true},
+		AuthenticationSchemes: []authenticationScheme{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "OAuth Bearer Token",
+				Description: "Authentication using an access token issued to a tenant client via the client credentials grant",
+			},
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package scim
+
+import (
+	"slices"
+	"strings"
+
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantplex"
+)
+
+// defaultAttributeMappings is used when a tenant hasn't configured its own SCIM attribute mappings,
+// and maps onto the columns every userstore is created with
+var defaultAttributeMappings = []tenantplex.SCIMAttributeMapping{
+	{Attribute: "userName", Column: "email"},
+	{Attribute: "displayName", Column: "name"},
+	{Attribute: "nickName", Column: "nickname"},
+	{Attribute: "photos.value", Column: "picture"},
+}
+
+var activePath = attributePath{name: "active"}
+
+type attributeMapping struct {
+	path   attributePath
+	column string
+}
+
+// userMapper converts between SCIM user resources and userstore profiles
+type userMapper struct {
+	mappings []attributeMapping
+}
+
+func newUserMapper(cfg tenantplex.SCIMConfig) (*userMapper, error) {
+	configured := cfg.UserAttributeMappings
+	if len(configured) == 0 {
+		configured = defaultAttributeMappings
+	}
+
+	um := &userMapper{}
+	for _, m := range configured {
+		path, err := parseAttributePath(m.Attribute)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		um.mappings = append(um.mappings, attributeMapping{path: path, column: m.Column})
+	}
+	return um, nil
+}
+
+// isMapped returns true if the attribute is stored in a userstore column
+func (um userMapper) isMapped(path attributePath) bool {
+	for _, m := range um.mappings {
+		if m.path.equals(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// toResource renders the mapped columns of a userstore profile as a SCIM user resource
+func (um userMapper) toResource(profile userstore.Record) map[string]any {
+	resource := map[string]any{}
+	schemas := []string{schemaUser}
+	for _, m := range um.mappings {
+		v, found := profile[m.column]
+		if !found || v == nil {
+			continue
+		}
+		m.path.setValue(resource, v)
+		if m.path.schema != "" && !slices.ContainsFunc(schemas, func(s string) bool { return strings.EqualFold(s, m.path.schema) }) {
+			schemas = append(schemas, m.path.schema)
+		}
+	}
+
+	// users that exist are active unless the tenant tracks activation in a column
+	if !um.isMapped(activePath) {
+		resource[activePath.name] = true
+	}
+
+	resource["schemas"] = schemas
+	return resource
+}
+
+// toProfile returns the values for the mapped columns from a SCIM user resource, with columns
+// for attributes missing from the resource set to nil
+func (um userMapper) toProfile(resource map[string]any) userstore.Record {
+	profile := userstore.Record{}
+	for _, m := range um.mappings {
+		profile[m.column] = m.path.getValue(resource)
+	}
+	return profile
+}
@@ -0,0 +1,162 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// SCIM schema and message URNs, see https://datatracker.ietf.org/doc/html/rfc7643#section-8.7
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+const (
+	resourceTypeUser  = "User"
+	resourceTypeGroup = "Group"
+)
+
+// SCIM error types, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+const (
+	errorTypeInvalidFilter = "invalidFilter"
+	errorTypeInvalidSyntax = "invalidSyntax"
+	errorTypeInvalidValue  = "invalidValue"
+	errorTypeUniqueness    = "uniqueness"
+)
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created"`
+	LastModified string `json:"lastModified"`
+	Location     string `json:"location"`
+	Version      string `json:"version"`
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+func newListResponse(resources []any, totalResults int, startIndex int) listResponse {
+	return listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: totalResults,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+type groupMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type group struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id,omitempty"`
+	DisplayName string        `json:"displayName"`
+	Members     []groupMember `json:"members"`
+	Meta        *meta         `json:"meta,omitempty"`
+}
+
+// Validate implements Validateable
+func (g group) Validate() error {
+	if g.DisplayName == "" {
+		return ucerr.Friendlyf(nil, "group displayName is required")
+	}
+	return nil
+}
+
+const (
+	patchOpAdd     = "add"
+	patchOpRemove  = "remove"
+	patchOpReplace = "replace"
+)
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+// Validate implements Validateable
+func (pr *patchRequest) Validate() error {
+	if len(pr.Operations) == 0 {
+		return ucerr.Friendlyf(nil, "PATCH request must include at least one operation")
+	}
+
+	for i := range pr.Operations {
+		// some clients (notably Azure AD) capitalize operation names
+		op := &pr.Operations[i]
+		op.Op = strings.ToLower(op.Op)
+		switch op.Op {
+		case patchOpAdd, patchOpReplace:
+			if len(op.Value) == 0 {
+				return ucerr.Friendlyf(nil, "PATCH operation '%s' requires a value", op.Op)
+			}
+		case patchOpRemove:
+			if op.Path == "" {
+				return ucerr.Friendlyf(nil, "PATCH operation 'remove' requires a path")
+			}
+		default:
+			return ucerr.Friendlyf(nil, "unsupported PATCH operation '%s'", op.Op)
+		}
+	}
+
+	return nil
+}
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type serviceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkSupport            `json:"bulk"`
+	Filter                filterSupport          `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	ETag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+}
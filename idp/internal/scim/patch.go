@@ -0,0 +1,188 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// parsePatchPath parses a PATCH operation path, which may include a value filter selecting
+// values of a multi-valued attribute, eg. `members[value eq "2819c223"]` or `emails[type eq "work"].value`
+func parsePatchPath(path string) (attributePath, *filterExpression, error) {
+	open := strings.Index(path, "[")
+	if open < 0 {
+		ap, err := parseAttributePath(path)
+		return ap, nil, ucerr.Wrap(err)
+	}
+
+	end := strings.LastIndex(path, "]")
+	if end < open {
+		return attributePath{}, nil, ucerr.Friendlyf(nil, "path '%s' has an unterminated value filter", path)
+	}
+
+	valueFilter, err := parseFilter(path[open+1 : end])
+	if err != nil {
+		return attributePath{}, nil, ucerr.Wrap(err)
+	}
+
+	ap, err := parseAttributePath(path[:open] + path[end+1:])
+	if err != nil {
+		return attributePath{}, nil, ucerr.Wrap(err)
+	}
+
+	return ap, valueFilter, nil
+}
+
+// applyUserPatch applies PATCH operations to a rendered SCIM user resource. Since we only store one
+// value of each multi-valued attribute, value filters in paths (eg. `emails[type eq "work"].value`)
+// are treated as addressing that value, and adding a value replaces it.
+func applyUserPatch(resource map[string]any, ops []patchOperation) error {
+	for _, op := range ops {
+		if op.Path == "" {
+			var values map[string]any
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return ucerr.Friendlyf(err, "PATCH operation '%s' without a path requires an object value", op.Op)
+			}
+			for key, value := range values {
+				// extension attributes may be sent as a nested object keyed by the schema URN
+				if ext, ok := value.(map[string]any); ok && strings.HasPrefix(strings.ToLower(key), "urn:") {
+					for name, v := range ext {
+						path, err := parseAttributePath(key + ":" + name)
+						if err != nil {
+							return ucerr.Wrap(err)
+						}
+						path.setValue(resource, v)
+					}
+					continue
+				}
+
+				path, err := parseAttributePath(key)
+				if err != nil {
+					return ucerr.Wrap(err)
+				}
+				path.setValue(resource, value)
+			}
+			continue
+		}
+
+		path, _, err := parsePatchPath(op.Path)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		if op.Op == patchOpRemove {
+			path.setValue(resource, nil)
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return ucerr.Friendlyf(err, "PATCH operation on '%s' has an invalid value", op.Path)
+		}
+		path.setValue(resource, value)
+	}
+
+	return nil
+}
+
+func (g *group) addMembers(members []groupMember) {
+	for _, m := range members {
+		if !g.hasMember(m.Value) {
+			g.Members = append(g.Members, groupMember{Value: m.Value})
+		}
+	}
+}
+
+func (g *group) hasMember(value string) bool {
+	for _, m := range g.Members {
+		if strings.EqualFold(m.Value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *group) removeMembers(remove func(groupMember) bool) {
+	members := []groupMember{}
+	for _, m := range g.Members {
+		if !remove(m) {
+			members = append(members, m)
+		}
+	}
+	g.Members = members
+}
+
+// applyGroupPatch applies PATCH operations to a group's display name and members
+func applyGroupPatch(g *group, ops []patchOperation) error {
+	for _, op := range ops {
+		if op.Path == "" {
+			var update struct {
+				DisplayName *string       `json:"displayName"`
+				Members     []groupMember `json:"members"`
+			}
+			if err := json.Unmarshal(op.Value, &update); err != nil {
+				return ucerr.Friendlyf(err, "PATCH operation '%s' without a path requires an object value", op.Op)
+			}
+			if update.DisplayName != nil {
+				g.DisplayName = *update.DisplayName
+			}
+			if update.Members != nil {
+				if op.Op == patchOpReplace {
+					g.Members = []groupMember{}
+				}
+				g.addMembers(update.Members)
+			}
+			continue
+		}
+
+		path, valueFilter, err := parsePatchPath(op.Path)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		switch {
+		case path.equals(attributePath{name: "displayName"}):
+			if op.Op == patchOpRemove {
+				return ucerr.Friendlyf(nil, "group displayName is required")
+			}
+			if err := json.Unmarshal(op.Value, &g.DisplayName); err != nil {
+				return ucerr.Friendlyf(err, "group displayName must be a string")
+			}
+
+		case path.equals(attributePath{name: "members"}):
+			var members []groupMember
+			if len(op.Value) > 0 {
+				if err := json.Unmarshal(op.Value, &members); err != nil {
+					return ucerr.Friendlyf(err, "group members must be a list of members")
+				}
+			}
+
+			switch op.Op {
+			case patchOpAdd:
+				g.addMembers(members)
+			case patchOpReplace:
+				g.Members = []groupMember{}
+				g.addMembers(members)
+			case patchOpRemove:
+				switch {
+				case valueFilter != nil:
+					g.removeMembers(func(m groupMember) bool {
+						return valueFilter.matches(map[string]any{"value": m.Value, "display": m.Display})
+					})
+				case len(members) > 0:
+					remove := &group{}
+					remove.addMembers(members)
+					g.removeMembers(func(m groupMember) bool { return remove.hasMember(m.Value) })
+				default:
+					g.Members = []groupMember{}
+				}
+			}
+
+		default:
+			return ucerr.Friendlyf(nil, "PATCH path '%s' is not supported for groups", op.Path)
+		}
+	}
+
+	return nil
+}
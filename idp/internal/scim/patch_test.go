@@ -0,0 +1,79 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"userclouds.com/infra/assert"
+	"userclouds.com/internal/tenantplex"
+)
+
+func parsePatch(t *testing.T, body string) []patchOperation {
+	t.Helper()
+	var req patchRequest
+	assert.NoErr(t, json.Unmarshal([]byte(body), &req))
+	assert.NoErr(t, req.Validate())
+	return req.Operations
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	um, err := newUserMapper(tenantplex.SCIMConfig{
+		UserAttributeMappings: []tenantplex.SCIMAttributeMapping{
+			{Attribute: "userName", Column: "email"},
+			{Attribute: "name.givenName", Column: "given_name"},
+			{Attribute: "phoneNumbers.value", Column: "phone"},
+			{Attribute: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", Column: "department"},
+		},
+	})
+	assert.NoErr(t, err)
+
+	resource := um.toResource(map[string]any{
+		"email":      "bjensen@example.com",
+		"given_name": "Barbara",
+		"phone":      "555-555-5555",
+	})
+	assert.Equal(t, resource["active"], true)
+
+	ops := parsePatch(t, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "name.givenName", "value": "Babs"},
+			{"op": "remove", "path": "phoneNumbers[type eq \"work\"].value"},
+			{"op": "add", "value": {"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "Tour Operations"}}}
+		]
+	}`)
+	assert.NoErr(t, applyUserPatch(resource, ops))
+
+	profile := um.toProfile(resource)
+	assert.Equal(t, profile["email"], "bjensen@example.com")
+	assert.Equal(t, profile["given_name"], "Babs")
+	assert.IsNil(t, profile["phone"])
+	assert.Equal(t, profile["department"], "Tour Operations")
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	g := &group{
+		DisplayName: "Engineering",
+		Members:     []groupMember{{Value: "a"}, {Value: "b"}, {Value: "c"}},
+	}
+
+	ops := parsePatch(t, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "d"}, {"value": "a"}]},
+			{"op": "remove", "path": "members[value eq \"b\"]"},
+			{"op": "remove", "path": "members", "value": [{"value": "c"}]},
+			{"op": "replace", "value": {"displayName": "Platform"}}
+		]
+	}`)
+	assert.NoErr(t, applyGroupPatch(g, ops))
+	assert.Equal(t, g.DisplayName, "Platform")
+	assert.Equal(t, g.Members, []groupMember{{Value: "a"}, {Value: "d"}})
+
+	ops = parsePatch(t, `{"Operations": [{"op": "remove", "path": "members"}]}`)
+	assert.NoErr(t, applyGroupPatch(g, ops))
+	assert.Equal(t, len(g.Members), 0)
+
+	ops = parsePatch(t, `{"Operations": [{"op": "remove", "path": "displayName"}]}`)
+	assert.NotNil(t, applyGroupPatch(g, ops))
+}
@@ -0,0 +1,545 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal/authn"
+	"userclouds.com/idp/internal/shared"
+	"userclouds.com/idp/internal/storage"
+	userstoreInternal "userclouds.com/idp/internal/userstore"
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/namespace/region"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uchttp"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/multitenant"
+)
+
+// userPaginationKeys are the user attributes that can be filtered on in the users table
+var userPaginationKeys = map[string]paginationKey{
+	"id":                {name: "id", kind: pagination.UUIDKeyType},
+	"meta.created":      {name: "created", kind: pagination.TimestampKeyType},
+	"meta.lastModified": {name: "updated", kind: pagination.TimestampKeyType},
+}
+
+var userNamePath = attributePath{name: "userName"}
+
+// emailFilter returns the email address if the filter is an equality match on the attribute mapped
+// to the email column, which is by far the most common filter (used by directories to find an existing
+// user before provisioning) and which is looked up by the email column rather than the users table
+func (um userMapper) emailFilter(fe *filterExpression) (string, bool) {
+	if fe == nil || fe.operator != filterEq {
+		return "", false
+	}
+	email, ok := fe.value.(string)
+	if !ok {
+		return "", false
+	}
+	for _, m := range um.mappings {
+		if m.path.equals(fe.path) && m.column == "email" {
+			return email, true
+		}
+	}
+	return "", false
+}
+
+func (h *handler) getUserMapper(ctx context.Context) (*userMapper, error) {
+	cfg, err := getConfig(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	um, err := newUserMapper(*cfg)
+	return um, ucerr.Wrap(err)
+}
+
+// renderUser returns the SCIM resource for a user and its ETag
+func renderUser(ctx context.Context, um *userMapper, user storage.BaseUser, profile userstore.Record) (map[string]any, string, error) {
+	resource := um.toResource(profile)
+	resource["id"] = user.ID.String()
+
+	etag, err := resourceETag(resource)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	resource["meta"] = meta{
+		ResourceType: resourceTypeUser,
+		Created:      formatTime(user.Created),
+		LastModified: formatTime(user.Updated),
+		Location:     resourceLocation(ctx, resourceTypeUser, user.ID),
+		Version:      etag,
+	}.toMap()
+
+	return resource, etag, nil
+}
+
+// renderUsers returns the SCIM resources for a set of users in the same order
+func renderUsers(ctx context.Context, um *userMapper, reg region.DataRegion, users []storage.BaseUser) ([]map[string]any, error) {
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]string, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID.String())
+	}
+
+	userData, entries, err := userstoreInternal.GetUsers(ctx, true, reg, false, userIDs...)
+	auditlog.PostMultipleAsync(ctx, entries)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	profiles := map[string]userstore.Record{}
+	for _, value := range userData {
+		var profile userstore.Record
+		if err := json.Unmarshal([]byte(value), &profile); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		profiles[profile.StringValue("id")] = profile
+	}
+
+	resources := make([]map[string]any, 0, len(users))
+	for _, u := range users {
+		profile, found := profiles[u.ID.String()]
+		if !found {
+			return nil, ucerr.Errorf("profile for user '%v' not returned by GetUserAccessor", u.ID)
+		}
+		resource, _, err := renderUser(ctx, um, u, profile)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// getUser returns the user, its region and its current SCIM resource & ETag
+func (h *handler) getUser(ctx context.Context, um *userMapper, id uuid.UUID) (*storage.BaseUser, region.DataRegion, map[string]any, string, int, error) {
+	tenantAuthn := authn.MustGetTenantAuthn(ctx)
+
+	user, reg, err := tenantAuthn.UserMultiRegionStorage.GetBaseUser(ctx, id, false)
+	if err != nil {
+		return nil, "", nil, "", uchttp.SQLReadErrorMapper(err), ucerr.Wrap(err)
+	}
+
+	if _, err := shared.ValidateUserOrganizationForRequest(ctx, user.OrganizationID); err != nil {
+		return nil, "", nil, "", http.StatusForbidden, ucerr.Wrap(err)
+	}
+
+	resources, err := renderUsers(ctx, um, reg, []storage.BaseUser{*user})
+	if err != nil {
+		return nil, "", nil, "", http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	resource := resources[0]
+	etag, _ := resource["meta"].(map[string]any)["version"].(string)
+	return user, reg, resource, etag, http.StatusOK, nil
+}
+
+func (h *handler) handleUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseResourceID(r.URL.Path)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusNotFound, "")
+		return
+	}
+
+	um, err := h.getUserMapper(ctx)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	switch {
+	case id.IsNil() && r.Method == http.MethodGet:
+		h.listUsers(w, r, um)
+	case id.IsNil() && r.Method == http.MethodPost:
+		h.createUser(w, r, um)
+	case !id.IsNil() && r.Method == http.MethodGet:
+		h.getUserByID(w, r, um, id)
+	case !id.IsNil() && r.Method == http.MethodPut:
+		h.replaceUser(w, r, um, id)
+	case !id.IsNil() && r.Method == http.MethodPatch:
+		h.patchUser(w, r, um, id)
+	case !id.IsNil() && r.Method == http.MethodDelete:
+		h.deleteUser(w, r, um, id)
+	default:
+		marshalMethodNotAllowed(ctx, w, r)
+	}
+}
+
+// organizationFilter returns a pagination filter limiting a list to the users the caller can see, the same way
+// authn's listUsers does, or "" if the caller can see every user
+func organizationFilter(ctx context.Context) (string, error) {
+	ts := multitenant.MustGetTenantState(ctx)
+	if !ts.UseOrganizations || auth.GetOrganizationUUID(ctx) == ts.CompanyID {
+		return "", nil
+	}
+
+	orgID, err := shared.ValidateUserOrganizationForRequest(ctx, uuid.Nil)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	if orgID.IsNil() || orgID == ts.CompanyID {
+		return "", nil
+	}
+
+	return fmt.Sprintf("(('organization_id',EQ,'%v'),OR,('organization_id',EQ,'%v'))", orgID, uuid.Nil), nil
+}
+
+func (h *handler) listUsers(w http.ResponseWriter, r *http.Request, um *userMapper) {
+	ctx := r.Context()
+	tenantAuthn := authn.MustGetTenantAuthn(ctx)
+
+	lr, err := parseListRequest(r.URL.Query())
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidFilter)
+		return
+	}
+
+	if email, ok := um.emailFilter(lr.filter); ok {
+		h.listUsersForEmail(w, r, um, lr, email)
+		return
+	}
+
+	var filters []string
+	if lr.filter != nil {
+		filter, ok := lr.filter.paginationFilter(userPaginationKeys)
+		if !ok {
+			marshalError(ctx, w,
+				ucerr.Friendlyf(nil, "users can only be filtered on id, meta.created and meta.lastModified, or by email equality"),
+				http.StatusBadRequest,
+				errorTypeInvalidFilter)
+			return
+		}
+		filters = append(filters, filter)
+	}
+
+	orgFilter, err := organizationFilter(ctx)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusForbidden, "")
+		return
+	}
+	if orgFilter != "" {
+		filters = append(filters, orgFilter)
+	}
+
+	// we only need to read users up to the end of the requested page
+	options := []pagination.Option{pagination.Limit(min(max(lr.startIndex-1+lr.count, 1), pagination.MaxLimit))}
+	switch len(filters) {
+	case 1:
+		options = append(options, pagination.Filter(filters[0]))
+	case 2:
+		options = append(options, pagination.Filter(fmt.Sprintf("(%s,AND,%s)", filters[0], filters[1])))
+	}
+
+	pager, err := storage.NewBaseUserPaginatorFromOptions(options...)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidFilter)
+		return
+	}
+
+	totalResults, err := tenantAuthn.UserMultiRegionStorage.CountBaseUsers(ctx, *pager, false)
+	if err != nil {
+		marshalError(ctx, w, err, uchttp.SQLReadErrorMapper(err), "")
+		return
+	}
+
+	var page []storage.BaseUser
+	skipped := 0
+	for len(page) < lr.count {
+		users, respFields, err := tenantAuthn.UserMultiRegionStorage.ListBaseUsersPaginated(ctx, *pager, false)
+		if err != nil {
+			marshalError(ctx, w, err, uchttp.SQLReadErrorMapper(err), "")
+			return
+		}
+
+		for _, u := range users {
+			if skipped < lr.startIndex-1 {
+				skipped++
+			} else if len(page) < lr.count {
+				page = append(page, u)
+			}
+		}
+
+		if !pager.AdvanceCursor(*respFields) {
+			break
+		}
+	}
+
+	h.marshalUserList(w, r, um, page, totalResults, lr)
+}
+
+// listUsersForEmail lists the users with an email address, which is by far the most common filter
+func (h *handler) listUsersForEmail(w http.ResponseWriter, r *http.Request, um *userMapper, lr *listRequest, email string) {
+	ctx := r.Context()
+	tenantAuthn := authn.MustGetTenantAuthn(ctx)
+
+	users, err := tenantAuthn.UserMultiRegionStorage.ListUsersForEmail(ctx, tenantAuthn.ConfigStorage, email)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	var visible []storage.BaseUser
+	for _, u := range users {
+		if _, err := shared.ValidateUserOrganizationForRequest(ctx, u.OrganizationID); err == nil {
+			visible = append(visible, u)
+		}
+	}
+
+	start := min(lr.startIndex-1, len(visible))
+	end := min(start+lr.count, len(visible))
+	h.marshalUserList(w, r, um, visible[start:end], len(visible), lr)
+}
+
+// marshalUserList renders a page of users and writes the list response
+func (h *handler) marshalUserList(w http.ResponseWriter, r *http.Request, um *userMapper, page []storage.BaseUser, totalResults int, lr *listRequest) {
+	ctx := r.Context()
+
+	rendered, err := renderUsers(ctx, um, "", page)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	resources := make([]any, 0, len(rendered))
+	for _, resource := range rendered {
+		resources = append(resources, resource)
+	}
+
+	jsonapi.Marshal(w, newListResponse(resources, totalResults, lr.startIndex))
+}
+
+func (h *handler) getUserByID(w http.ResponseWriter, r *http.Request, um *userMapper, id uuid.UUID) {
+	ctx := r.Context()
+
+	_, _, resource, etag, code, err := h.getUser(ctx, um, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	jsonapi.Marshal(w, resource)
+}
+
+// parseUserResource reads a user resource from the request body and returns the profile values
+// for the mapped columns
+func parseUserResource(r *http.Request, um *userMapper) (userstore.Record, error) {
+	var resource map[string]any
+	if err := jsonapi.Unmarshal(r, &resource); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if userName, ok := userNamePath.getValue(resource).(string); !ok || userName == "" {
+		return nil, ucerr.Friendlyf(nil, "userName is required")
+	}
+
+	return um.toProfile(resource), nil
+}
+
+func (h *handler) createUser(w http.ResponseWriter, r *http.Request, um *userMapper) {
+	ctx := r.Context()
+	tenantAuthn := authn.MustGetTenantAuthn(ctx)
+
+	profile, err := parseUserResource(r, um)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	// unset attributes are simply not stored for a new user
+	for column, value := range profile {
+		if value == nil {
+			delete(profile, column)
+		}
+	}
+
+	organizationID, err := shared.ValidateUserOrganizationForRequest(ctx, uuid.Nil)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusForbidden, "")
+		return
+	}
+	if !tenantAuthn.UseOrganizations {
+		organizationID = tenantAuthn.CompanyID
+	} else if organizationID.IsNil() {
+		marshalError(ctx, w, ucerr.Friendlyf(nil, "could not determine organization for new user"), http.StatusBadRequest, "")
+		return
+	}
+
+	user, code, err := tenantAuthn.Manager.CreateUser(ctx,
+		h.searchUpdateConfig,
+		idp.CreateUserAndAuthnRequest{
+			Profile:        profile,
+			OrganizationID: organizationID,
+		})
+	if err != nil {
+		switch code {
+		case http.StatusBadRequest:
+			marshalError(ctx, w, err, code, errorTypeInvalidValue)
+		case http.StatusConflict:
+			marshalError(ctx, w, err, code, errorTypeUniqueness)
+		case http.StatusForbidden:
+			marshalError(ctx, w, err, code, "")
+		default:
+			marshalError(ctx, w, err, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	_, _, resource, etag, code, err := h.getUser(ctx, um, user.ID)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Location", resourceLocation(ctx, resourceTypeUser, user.ID))
+	jsonapi.Marshal(w, resource, jsonapi.Code(http.StatusCreated))
+}
+
+// updateUser writes the profile columns that differ from the current values and returns the updated resource
+func (h *handler) updateUser(ctx context.Context, um *userMapper, id uuid.UUID, reg region.DataRegion, current userstore.Record, updated userstore.Record) (map[string]any, string, int, error) {
+	changed := userstore.Record{}
+	for column, value := range updated {
+		if !reflect.DeepEqual(current[column], value) {
+			changed[column] = value
+		}
+	}
+
+	if len(changed) > 0 {
+		tenantAuthn := authn.MustGetTenantAuthn(ctx)
+		if code, err := tenantAuthn.Manager.UpdateUserProfile(ctx, h.searchUpdateConfig, id, reg, changed); err != nil {
+			return nil, "", code, ucerr.Wrap(err)
+		}
+	}
+
+	_, _, resource, etag, code, err := h.getUser(ctx, um, id)
+	if err != nil {
+		return nil, "", code, ucerr.Wrap(err)
+	}
+	return resource, etag, http.StatusOK, nil
+}
+
+func marshalUpdateError(ctx context.Context, w http.ResponseWriter, err error, code int) {
+	switch code {
+	case http.StatusBadRequest:
+		marshalError(ctx, w, err, code, errorTypeInvalidValue)
+	case http.StatusConflict:
+		marshalError(ctx, w, err, code, errorTypeUniqueness)
+	default:
+		marshalError(ctx, w, err, code, "")
+	}
+}
+
+func (h *handler) replaceUser(w http.ResponseWriter, r *http.Request, um *userMapper, id uuid.UUID) {
+	ctx := r.Context()
+
+	_, reg, current, etag, code, err := h.getUser(ctx, um, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	profile, err := parseUserResource(r, um)
+	if err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	resource, etag, code, err := h.updateUser(ctx, um, id, reg, um.toProfile(current), profile)
+	if err != nil {
+		marshalUpdateError(ctx, w, err, code)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	jsonapi.Marshal(w, resource)
+}
+
+func (h *handler) patchUser(w http.ResponseWriter, r *http.Request, um *userMapper, id uuid.UUID) {
+	ctx := r.Context()
+
+	_, reg, resource, etag, code, err := h.getUser(ctx, um, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	var req patchRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidSyntax)
+		return
+	}
+
+	current := um.toProfile(resource)
+	if err := applyUserPatch(resource, req.Operations); err != nil {
+		marshalError(ctx, w, err, http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	if userName, ok := userNamePath.getValue(resource).(string); !ok || userName == "" {
+		marshalError(ctx, w, ucerr.Friendlyf(nil, "userName is required"), http.StatusBadRequest, errorTypeInvalidValue)
+		return
+	}
+
+	resource, etag, code, err = h.updateUser(ctx, um, id, reg, current, um.toProfile(resource))
+	if err != nil {
+		marshalUpdateError(ctx, w, err, code)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	jsonapi.Marshal(w, resource)
+}
+
+func (h *handler) deleteUser(w http.ResponseWriter, r *http.Request, um *userMapper, id uuid.UUID) {
+	ctx := r.Context()
+
+	_, _, _, etag, code, err := h.getUser(ctx, um, id)
+	if err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	if code := checkPreconditions(r, etag); code != 0 {
+		marshalPreconditionFailure(ctx, w, code, etag)
+		return
+	}
+
+	if code, err := userstoreInternal.DeleteUser(ctx, h.searchUpdateConfig, id); err != nil {
+		marshalError(ctx, w, err, code, "")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
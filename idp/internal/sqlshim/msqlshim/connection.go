@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	"github.com/go-mysql-org/go-mysql/client"
 	"github.com/go-mysql-org/go-mysql/mysql"
@@ -74,7 +75,7 @@ func (c ConnectionFactory) NewConnection(ctx context.Context,
 func (c *connection) HandleQuery(query string) (*mysql.Result, error) {
 	ctx := c.serverContext
 
-	if handleResponse, transformers, _, err := c.observer.HandleQuery(ctx, internalSqlshim.DatabaseTypeMySQL, query+";", c.currentDB, c.connectionID); err != nil {
+	if handleResponse, transformers, _, err := c.observer.HandleQuery(ctx, internalSqlshim.DatabaseTypeMySQL, query+";", c.currentDB, c.connectionID, nil); err != nil {
 		uclog.Errorf(ctx, "[msqlshim connection ID %s] Error by query handler: %v", c.connectionID, err)
 	} else if handleResponse == internalSqlshim.TransformResponse {
 		uclog.Infof(ctx, "handled query: %s", query)
@@ -83,6 +84,13 @@ func (c *connection) HandleQuery(query string) (*mysql.Result, error) {
 		uclog.Warningf(ctx, "query denied: %s", query)
 		c.observer.TransformSummary(ctx, transformers, 0, 0, 0)
 		return nil, ucerr.Errorf("ACCESS DENIED")
+	} else if handleResponse == internalSqlshim.RewriteQuery {
+		rewrite, ok := transformers.(internalSqlshim.QueryRewrite)
+		if !ok || rewrite.Query == "" {
+			return nil, ucerr.Errorf("query handler returned an invalid query rewrite")
+		}
+		uclog.Infof(ctx, "rewritten query: %s", query)
+		return c.serverConn.Execute(strings.TrimSuffix(rewrite.Query, ";"))
 	} else {
 		uclog.Infof(ctx, "passthrough query: %s", query)
 	}
@@ -0,0 +1,231 @@
+package psqlshim
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgproto3"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// type OIDs from pg_type.dat for the types we can convert between binary and text formats
+const (
+	oidBool        uint32 = 16
+	oidName        uint32 = 19
+	oidInt8        uint32 = 20
+	oidInt2        uint32 = 21
+	oidInt4        uint32 = 23
+	oidText        uint32 = 25
+	oidJSON        uint32 = 114
+	oidFloat4      uint32 = 700
+	oidFloat8      uint32 = 701
+	oidUnknown     uint32 = 705
+	oidBPChar      uint32 = 1042
+	oidVarchar     uint32 = 1043
+	oidDate        uint32 = 1082
+	oidTimestamp   uint32 = 1114
+	oidTimestampTZ uint32 = 1184
+	oidUUID        uint32 = 2950
+	oidJSONB       uint32 = 3802
+)
+
+const (
+	jsonbVersion          = 1
+	timestampTextFormat   = "2006-01-02 15:04:05.999999"
+	timestampTZTextFormat = "2006-01-02 15:04:05.999999Z07:00"
+	dateTextFormat        = "2006-01-02"
+)
+
+// postgresEpoch is the zero point of binary format dates and timestamps
+var postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// decodeValue decodes a bound parameter to a string, or nil for NULL
+func decodeValue(oid uint32, format int16, value []byte) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if format == pgproto3.TextFormat {
+		return string(value), nil
+	}
+
+	text, err := binaryToText(oid, value)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return string(text), nil
+}
+
+func checkLength(oid uint32, value []byte, length int) error {
+	if len(value) != length {
+		return ucerr.Errorf("binary value for type OID %d should be %d bytes, got %d", oid, length, len(value))
+	}
+	return nil
+}
+
+// binaryToText converts a binary format value to the text format the server would have sent for it
+func binaryToText(oid uint32, value []byte) ([]byte, error) {
+	switch oid {
+	case oidText, oidVarchar, oidBPChar, oidName, oidJSON, oidUnknown:
+		return value, nil
+
+	case oidJSONB:
+		if len(value) == 0 || value[0] != jsonbVersion {
+			return nil, ucerr.Errorf("unsupported jsonb binary format")
+		}
+		return value[1:], nil
+
+	case oidBool:
+		if err := checkLength(oid, value, 1); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if value[0] != 0 {
+			return []byte("t"), nil
+		}
+		return []byte("f"), nil
+
+	case oidInt2:
+		if err := checkLength(oid, value, 2); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return strconv.AppendInt(nil, int64(int16(binary.BigEndian.Uint16(value))), 10), nil
+
+	case oidInt4:
+		if err := checkLength(oid, value, 4); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return strconv.AppendInt(nil, int64(int32(binary.BigEndian.Uint32(value))), 10), nil
+
+	case oidInt8:
+		if err := checkLength(oid, value, 8); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return strconv.AppendInt(nil, int64(binary.BigEndian.Uint64(value)), 10), nil
+
+	case oidFloat4:
+		if err := checkLength(oid, value, 4); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return strconv.AppendFloat(nil, float64(math.Float32frombits(binary.BigEndian.Uint32(value))), 'g', -1, 32), nil
+
+	case oidFloat8:
+		if err := checkLength(oid, value, 8); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return strconv.AppendFloat(nil, math.Float64frombits(binary.BigEndian.Uint64(value)), 'g', -1, 64), nil
+
+	case oidUUID:
+		id, err := uuid.FromBytes(value)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return []byte(id.String()), nil
+
+	case oidDate:
+		if err := checkLength(oid, value, 4); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		days := int(int32(binary.BigEndian.Uint32(value)))
+		return []byte(postgresEpoch.AddDate(0, 0, days).Format(dateTextFormat)), nil
+
+	case oidTimestamp, oidTimestampTZ:
+		if err := checkLength(oid, value, 8); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		t := time.UnixMicro(postgresEpoch.UnixMicro() + int64(binary.BigEndian.Uint64(value))).UTC()
+		if oid == oidTimestampTZ {
+			return []byte(t.Format(timestampTZTextFormat)), nil
+		}
+		return []byte(t.Format(timestampTextFormat)), nil
+	}
+
+	return nil, ucerr.Errorf("binary format is not supported for type OID %d", oid)
+}
+
+// textToBinary converts a text format value back to binary format, used after a binary value has been transformed
+func textToBinary(oid uint32, value []byte) ([]byte, error) {
+	text := string(value)
+	switch oid {
+	case oidText, oidVarchar, oidBPChar, oidName, oidJSON, oidUnknown:
+		return value, nil
+
+	case oidJSONB:
+		return append([]byte{jsonbVersion}, value...), nil
+
+	case oidBool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+
+	case oidInt2:
+		i, err := strconv.ParseInt(text, 10, 16)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return binary.BigEndian.AppendUint16(nil, uint16(i)), nil
+
+	case oidInt4:
+		i, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return binary.BigEndian.AppendUint32(nil, uint32(i)), nil
+
+	case oidInt8:
+		i, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return binary.BigEndian.AppendUint64(nil, uint64(i)), nil
+
+	case oidFloat4:
+		f, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f))), nil
+
+	case oidFloat8:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return binary.BigEndian.AppendUint64(nil, math.Float64bits(f)), nil
+
+	case oidUUID:
+		id, err := uuid.FromString(text)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return id.Bytes(), nil
+
+	case oidDate:
+		t, err := time.Parse(dateTextFormat, text)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		days := int32((t.Unix() - postgresEpoch.Unix()) / (24 * 60 * 60))
+		return binary.BigEndian.AppendUint32(nil, uint32(days)), nil
+
+	case oidTimestamp, oidTimestampTZ:
+		layout := timestampTextFormat
+		if oid == oidTimestampTZ {
+			layout = timestampTZTextFormat
+		}
+		t, err := time.Parse(layout, text)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return binary.BigEndian.AppendUint64(nil, uint64(t.UnixMicro()-postgresEpoch.UnixMicro())), nil
+	}
+
+	return nil, ucerr.Errorf("binary format is not supported for type OID %d", oid)
+}
@@ -31,6 +31,13 @@ type connection struct {
 	connectionID uuid.UUID
 	observer     internalSqlshim.Observer
 	dbName       string
+
+	// extended query protocol state
+	statements       map[string]*preparedStatement
+	portals          map[string]*portal
+	pendingResponses []*pendingResponse
+	inExtendedBatch  bool
+	failedBatch      bool
 }
 
 type dummyWriter int
@@ -88,6 +95,8 @@ func (c ConnectionFactory) NewConnection(ctx context.Context,
 		client:       client,
 		server:       server,
 		observer:     observer,
+		statements:   map[string]*preparedStatement{},
+		portals:      map[string]*portal{},
 	}, nil
 }
 
@@ -138,8 +147,6 @@ func (c *connection) Handle(ctx context.Context) error {
 		default:
 		}
 
-		start := time.Now().UTC()
-
 		ctx = request.SetRequestID(ctx, uuid.Must(uuid.NewV4()))
 
 		// manually read the message from the client (since pgproto3 has bugs in re-encoding messages)
@@ -168,27 +175,15 @@ func (c *connection) Handle(ctx context.Context) error {
 		}
 
 		var queryString *string
+		extendedQuery := false
 		// handle query messages
 		if query, ok := clientMsg.(*pgproto3.Query); ok {
 			uclog.Debugf(ctx, "[psqlshim connection ID %s] Query: %v", c.connectionID, query)
 			queryString = &query.String
 			pauseCopy <- true
 			time.Sleep(syncTime)
-		} else if parse, ok := clientMsg.(*pgproto3.Parse); ok {
-			uclog.Debugf(ctx, "[psqlshim connection ID %s] Parse: %v", c.connectionID, parse)
-			queryString = &parse.Query
-			pauseCopy <- true
-			time.Sleep(syncTime)
-		} else if describe, ok := clientMsg.(*pgproto3.Describe); ok {
-			uclog.Warningf(ctx, "[psqlshim connection ID %s] Describe: %v", c.connectionID, describe)
-		} else if bind, ok := clientMsg.(*pgproto3.Bind); ok {
-			uclog.Warningf(ctx, "[psqlshim connection ID %s] Bind: %v", c.connectionID, bind)
-		} else if execute, ok := clientMsg.(*pgproto3.Execute); ok {
-			uclog.Warningf(ctx, "[psqlshim connection ID %s] Execute: %v", c.connectionID, execute)
-		} else if sync, ok := clientMsg.(*pgproto3.Sync); ok {
-			uclog.Warningf(ctx, "[psqlshim connection ID %s] Sync: %v", c.connectionID, sync)
-		} else if close, ok := clientMsg.(*pgproto3.Close); ok {
-			uclog.Warningf(ctx, "[psqlshim connection ID %s] Close: %v", c.connectionID, close)
+		} else if isExtendedQueryMessage(clientMsg) {
+			extendedQuery = true
 		} else if copyData, ok := clientMsg.(*pgproto3.CopyData); ok {
 			uclog.Warningf(ctx, "[psqlshim connection ID %s] CopyData: %v", c.connectionID, copyData)
 		} else if copyFail, ok := clientMsg.(*pgproto3.CopyFail); ok {
@@ -199,9 +194,13 @@ func (c *connection) Handle(ctx context.Context) error {
 			uclog.Warningf(ctx, "[psqlshim connection ID %s] Unknown message: %v", c.connectionID, clientMsg)
 		}
 
-		if queryString != nil {
+		if extendedQuery {
+			if err := c.handleExtendedQueryMessage(ctx, clientMsg, completeMessage, pauseCopy, resumeCopy); err != nil {
+				return ucerr.Wrap(err)
+			}
+		} else if queryString != nil {
 
-			handleResponse, transformers, _, err := c.observer.HandleQuery(ctx, internalSqlshim.DatabaseTypePostgres, *queryString, c.dbName, c.connectionID)
+			handleResponse, transformers, _, err := c.observer.HandleQuery(ctx, internalSqlshim.DatabaseTypePostgres, *queryString, c.dbName, c.connectionID, nil)
 			if err != nil {
 				uclog.Errorf(ctx, "[psqlshim connection ID %s] Error by query handler: %v", c.connectionID, err)
 			}
@@ -210,6 +209,7 @@ func (c *connection) Handle(ctx context.Context) error {
 					return ucerr.Wrap(err)
 				}
 			} else if handleResponse == internalSqlshim.TransformResponse {
+				start := time.Now().UTC()
 				if _, err := c.serverConn.Write(completeMessage); err != nil {
 					return ucerr.Wrap(err)
 				}
@@ -219,6 +219,14 @@ func (c *connection) Handle(ctx context.Context) error {
 				end := time.Now().UTC()
 				duration := end.Sub(start)
 				uclog.DebugfPII(ctx, "psqlshim query returned in %v", duration)
+			} else if handleResponse == internalSqlshim.RewriteQuery {
+				rewrite, ok := transformers.(internalSqlshim.QueryRewrite)
+				if !ok || rewrite.Query == "" {
+					return ucerr.Errorf("[psqlshim connection ID %s] query handler returned an invalid query rewrite", c.connectionID)
+				}
+				if _, err := c.serverConn.Write((&pgproto3.Query{String: rewrite.Query}).Encode(nil)); err != nil {
+					return ucerr.Wrap(err)
+				}
 			} else if handleResponse == internalSqlshim.AccessDenied {
 				c.observer.TransformSummary(ctx, transformers, 0, 0, 0)
 
@@ -393,29 +401,7 @@ func (c *connection) readAndTransformQueryResponse(ctx context.Context, transfor
 		default:
 		}
 
-		// manually read the message from the server (since pgproto3 has bugs in re-encoding messages)
-		var completeMessage []byte
-		buf, err := readN(c.serverConn, 5)
-		if err != nil {
-			return ucerr.Wrap(err)
-		}
-		completeMessage = append(completeMessage, buf...)
-
-		if msgSize := int(binary.BigEndian.Uint32(buf[1:5]) - 4); msgSize > 0 {
-			buf, err = readN(c.serverConn, msgSize)
-			if err != nil {
-				return ucerr.Wrap(err)
-			}
-			completeMessage = append(completeMessage, buf...)
-		}
-
-		// Parse the response from the server by passing the message through a new frontend
-		f, err := pgproto3.NewFrontend(pgproto3.NewChunkReader(bytes.NewReader(completeMessage)), dummyWriterInstance)
-		if err != nil {
-			return ucerr.Wrap(err)
-		}
-
-		msg, err := f.Receive()
+		completeMessage, msg, err := readServerMessage(c.serverConn)
 		if err != nil {
 			return ucerr.Wrap(err)
 		}
@@ -461,6 +447,54 @@ func (c *connection) readAndTransformQueryResponse(ctx context.Context, transfor
 	}
 }
 
+// portalSuspendedMessageType is the type of the message the server sends when an Execute hits its row limit, which
+// pgproto3 doesn't know how to parse
+const portalSuspendedMessageType = 's'
+
+// readServerMessage reads a complete message from the server, returning both the raw bytes and the parsed message
+// (which is nil for PortalSuspended)
+func readServerMessage(conn net.Conn) ([]byte, pgproto3.BackendMessage, error) {
+	// manually read the message from the server (since pgproto3 has bugs in re-encoding messages)
+	var completeMessage []byte
+	buf, err := readN(conn, 5)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	completeMessage = append(completeMessage, buf...)
+
+	if msgSize := int(binary.BigEndian.Uint32(buf[1:5]) - 4); msgSize > 0 {
+		buf, err = readN(conn, msgSize)
+		if err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+		completeMessage = append(completeMessage, buf...)
+	}
+
+	if completeMessage[0] == portalSuspendedMessageType {
+		return completeMessage, nil, nil
+	}
+
+	// Parse the response from the server by passing the message through a new frontend
+	f, err := pgproto3.NewFrontend(pgproto3.NewChunkReader(bytes.NewReader(completeMessage)), dummyWriterInstance)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	msg, err := f.Receive()
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	return completeMessage, msg, nil
+}
+
+func isExtendedQueryMessage(msg pgproto3.FrontendMessage) bool {
+	switch msg.(type) {
+	case *pgproto3.Parse, *pgproto3.Bind, *pgproto3.Describe, *pgproto3.Execute, *pgproto3.Close, *pgproto3.Sync, *pgproto3.Flush:
+		return true
+	}
+	return false
+}
+
 func (c *connection) getCurrentDB() (string, error) {
 	if err := c.server.Send(&pgproto3.Query{String: "SELECT current_database()"}); err != nil {
 		return "", ucerr.Wrap(err)
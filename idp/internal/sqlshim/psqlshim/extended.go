@@ -0,0 +1,419 @@
+package psqlshim
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgproto3"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	internalSqlshim "userclouds.com/internal/sqlshim"
+)
+
+// The extended query protocol splits a query into Parse (creating a named statement), Bind (binding parameter
+// values to a statement, creating a named portal), Describe and Execute messages, and the server only guarantees to
+// respond once the client sends a Sync or Flush. Since parameter values only arrive with the Bind, that's where we
+// hand the query to the observer, and we read the server's responses for the whole batch ourselves so we can match
+// them up with the statements and portals they belong to.
+
+// preparedStatement is a statement created by a Parse message
+type preparedStatement struct {
+	query     string
+	paramOIDs []uint32
+	fields    []pgproto3.FieldDescription
+}
+
+// portal is a statement with bound parameters created by a Bind message
+type portal struct {
+	statement      *preparedStatement
+	resultFormats  []int16
+	fields         []pgproto3.FieldDescription
+	handleResponse internalSqlshim.HandleQueryResponse
+	transformInfo  any
+
+	numSelectorRows int
+	numReturned     int
+	numDenied       int
+}
+
+// columns returns the description of the portal's result columns, with the formats the client asked for
+func (p *portal) columns() []pgproto3.FieldDescription {
+	if p.fields != nil {
+		return p.fields
+	}
+
+	fields := make([]pgproto3.FieldDescription, len(p.statement.fields))
+	copy(fields, p.statement.fields)
+	for i := range fields {
+		fields[i].Format = formatCode(p.resultFormats, i)
+	}
+	return fields
+}
+
+// formatCode returns the format for the i'th value given a list of format codes, which per the protocol is either
+// empty (everything is text), a single code that applies to everything, or a code per value
+func formatCode(formatCodes []int16, i int) int16 {
+	switch len(formatCodes) {
+	case 0:
+		return pgproto3.TextFormat
+	case 1:
+		return formatCodes[0]
+	}
+	if i < len(formatCodes) {
+		return formatCodes[i]
+	}
+	return pgproto3.TextFormat
+}
+
+// pendingResponse is an extended query protocol message we've forwarded to the server (or denied) and haven't yet
+// seen the complete response to
+type pendingResponse struct {
+	msg    pgproto3.FrontendMessage
+	portal *portal
+	denied bool
+}
+
+func (c *connection) closePortal(ctx context.Context, name string) {
+	p, found := c.portals[name]
+	if !found {
+		return
+	}
+	if p.transformInfo != nil {
+		c.observer.TransformSummary(ctx, p.transformInfo, p.numSelectorRows, p.numReturned, p.numDenied)
+		c.observer.CleanupTransformerExecution(p.transformInfo)
+	}
+	delete(c.portals, name)
+}
+
+// closeAllPortals is called when a transaction ends, which destroys all of the portals created in it
+func (c *connection) closeAllPortals(ctx context.Context) {
+	for name := range c.portals {
+		c.closePortal(ctx, name)
+	}
+}
+
+// handleExtendedQueryMessage tracks an extended query protocol message from the client and forwards it to the
+// server, handling the responses once the client asks for them with a Sync or Flush
+func (c *connection) handleExtendedQueryMessage(
+	ctx context.Context,
+	clientMsg pgproto3.FrontendMessage,
+	completeMessage []byte,
+	pauseCopy chan<- bool,
+	resumeCopy chan<- bool,
+) error {
+	if !c.inExtendedBatch {
+		c.inExtendedBatch = true
+		pauseCopy <- true
+		time.Sleep(syncTime)
+	}
+
+	_, isSync := clientMsg.(*pgproto3.Sync)
+	if c.failedBatch && !isSync {
+		// like the server, ignore everything after an error until the client syncs
+		return nil
+	}
+
+	forward := true
+	pending := &pendingResponse{msg: clientMsg}
+	switch msg := clientMsg.(type) {
+	case *pgproto3.Parse:
+		uclog.Debugf(ctx, "[psqlshim connection ID %s] Parse: %v", c.connectionID, msg)
+		c.statements[msg.Name] = &preparedStatement{query: msg.Query, paramOIDs: msg.ParameterOIDs}
+
+	case *pgproto3.Bind:
+		uclog.Debugf(ctx, "[psqlshim connection ID %s] Bind: portal '%s', statement '%s'", c.connectionID, msg.DestinationPortal, msg.PreparedStatement)
+		c.closePortal(ctx, msg.DestinationPortal)
+		stmt, found := c.statements[msg.PreparedStatement]
+		if !found {
+			// let the server report the error
+			break
+		}
+
+		params := make([]any, 0, len(msg.Parameters))
+		for i, value := range msg.Parameters {
+			var oid uint32
+			if i < len(stmt.paramOIDs) {
+				oid = stmt.paramOIDs[i]
+			}
+			param, err := decodeValue(oid, formatCode(msg.ParameterFormatCodes, i), value)
+			if err != nil {
+				uclog.Debugf(ctx, "[psqlshim connection ID %s] could not decode parameter $%d: %v", c.connectionID, i+1, err)
+			}
+			params = append(params, param)
+		}
+
+		handleResponse, transformInfo, _, err := c.observer.HandleQuery(ctx, internalSqlshim.DatabaseTypePostgres, stmt.query, c.dbName, c.connectionID, params)
+		if err != nil {
+			uclog.Errorf(ctx, "[psqlshim connection ID %s] Error by query handler: %v", c.connectionID, err)
+		}
+
+		if handleResponse == internalSqlshim.AccessDenied {
+			c.observer.TransformSummary(ctx, transformInfo, 0, 0, 0)
+			forward = false
+			pending.denied = true
+			c.failedBatch = true
+			break
+		}
+
+		if handleResponse == internalSqlshim.RewriteQuery {
+			rewritten, err := c.rewriteBindParameters(msg, stmt, transformInfo)
+			if err != nil {
+				// never forward the original values if we can't replace them
+				uclog.Errorf(ctx, "[psqlshim connection ID %s] could not rewrite bound parameters: %v", c.connectionID, err)
+				forward = false
+				pending.denied = true
+				c.failedBatch = true
+				break
+			}
+			completeMessage = rewritten
+		}
+
+		p := &portal{statement: stmt, resultFormats: msg.ResultFormatCodes, handleResponse: handleResponse}
+		if handleResponse == internalSqlshim.TransformResponse {
+			p.transformInfo = transformInfo
+		}
+		c.portals[msg.DestinationPortal] = p
+
+	case *pgproto3.Describe:
+		uclog.Debugf(ctx, "[psqlshim connection ID %s] Describe: %v", c.connectionID, msg)
+		if msg.ObjectType == 'P' {
+			pending.portal = c.portals[msg.Name]
+		}
+
+	case *pgproto3.Execute:
+		uclog.Debugf(ctx, "[psqlshim connection ID %s] Execute: %v", c.connectionID, msg)
+		pending.portal = c.portals[msg.Portal]
+
+	case *pgproto3.Close:
+		uclog.Debugf(ctx, "[psqlshim connection ID %s] Close: %v", c.connectionID, msg)
+		if msg.ObjectType == 'S' {
+			delete(c.statements, msg.Name)
+		} else {
+			c.closePortal(ctx, msg.Name)
+		}
+
+	case *pgproto3.Sync:
+		c.failedBatch = false
+
+	case *pgproto3.Flush:
+		// Flush doesn't get a response of its own
+		pending = nil
+	}
+
+	if forward {
+		if _, err := c.serverConn.Write(completeMessage); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	if pending != nil {
+		c.pendingResponses = append(c.pendingResponses, pending)
+	}
+
+	_, isFlush := clientMsg.(*pgproto3.Flush)
+	if !isSync && !isFlush {
+		return nil
+	}
+
+	if err := c.readExtendedQueryResponses(ctx); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	c.inExtendedBatch = false
+	resumeCopy <- true
+	time.Sleep(syncTime)
+	return nil
+}
+
+// rewriteBindParameters replaces the bound parameter values the observer transformed, and returns the re-encoded Bind
+// message to forward in place of the original
+func (c *connection) rewriteBindParameters(msg *pgproto3.Bind, stmt *preparedStatement, transformInfo any) ([]byte, error) {
+	rewrite, ok := transformInfo.(internalSqlshim.QueryRewrite)
+	if !ok {
+		return nil, ucerr.Errorf("query handler returned an invalid query rewrite")
+	}
+	if rewrite.Query != "" {
+		// the statement has already been parsed by the server, so its text can't change
+		return nil, ucerr.Errorf("can't rewrite literal values in a prepared statement")
+	}
+
+	params := make([][]byte, len(msg.Parameters))
+	copy(params, msg.Parameters)
+	formats := make([]int16, len(params))
+	for i := range params {
+		formats[i] = formatCode(msg.ParameterFormatCodes, i)
+	}
+
+	for i, value := range rewrite.Params {
+		if i < 0 || i >= len(params) {
+			return nil, ucerr.Errorf("parameter $%d is not bound", i+1)
+		}
+		if formats[i] == pgproto3.TextFormat {
+			params[i] = []byte(value)
+			continue
+		}
+
+		var oid uint32
+		if i < len(stmt.paramOIDs) {
+			oid = stmt.paramOIDs[i]
+		}
+		bs, err := textToBinary(oid, []byte(value))
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		params[i] = bs
+	}
+
+	rewritten := *msg
+	rewritten.Parameters = params
+	rewritten.ParameterFormatCodes = formats
+	return rewritten.Encode(nil), nil
+}
+
+// skipUntilSync drops the pending responses that the server won't send after an error
+func (c *connection) skipUntilSync() {
+	for len(c.pendingResponses) > 0 {
+		if _, ok := c.pendingResponses[0].msg.(*pgproto3.Sync); ok {
+			return
+		}
+		c.pendingResponses = c.pendingResponses[1:]
+	}
+}
+
+// readExtendedQueryResponses reads the server's responses to the pending messages and passes them on to the client,
+// transforming the rows returned for portals the observer asked us to transform
+func (c *connection) readExtendedQueryResponses(ctx context.Context) error {
+	for len(c.pendingResponses) > 0 {
+		pending := c.pendingResponses[0]
+
+		if pending.denied {
+			errMsg := &pgproto3.ErrorResponse{
+				Severity: "ERROR",
+				Code:     "42501",
+				Message:  "permission denied",
+			}
+			if err := c.client.Send(errMsg); err != nil {
+				return ucerr.Wrap(err)
+			}
+			c.skipUntilSync()
+			continue
+		}
+
+		completeMessage, msg, err := readServerMessage(c.serverConn)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		done := true
+		switch m := msg.(type) {
+		case *pgproto3.ParameterDescription:
+			if describe, ok := pending.msg.(*pgproto3.Describe); ok {
+				if stmt, found := c.statements[describe.Name]; found {
+					stmt.paramOIDs = m.ParameterOIDs
+				}
+			}
+			done = false
+
+		case *pgproto3.RowDescription:
+			if describe, ok := pending.msg.(*pgproto3.Describe); ok {
+				if describe.ObjectType == 'S' {
+					if stmt, found := c.statements[describe.Name]; found {
+						stmt.fields = m.Fields
+					}
+				} else if pending.portal != nil {
+					pending.portal.fields = m.Fields
+				}
+			}
+
+		case *pgproto3.DataRow:
+			done = false
+			if p := pending.portal; p != nil && p.transformInfo != nil {
+				if err := c.transformPortalDataRow(ctx, p, m); err != nil {
+					return ucerr.Wrap(err)
+				}
+				continue
+			}
+
+		case *pgproto3.CommandComplete:
+			// the portal has returned all of its rows, so record the summary now rather than waiting for it to close
+			if p := pending.portal; p != nil && p.transformInfo != nil {
+				c.observer.TransformSummary(ctx, p.transformInfo, p.numSelectorRows, p.numReturned, p.numDenied)
+				c.observer.CleanupTransformerExecution(p.transformInfo)
+				p.transformInfo = nil
+			}
+
+		case *pgproto3.ErrorResponse:
+			if _, err := c.clientConn.Write(completeMessage); err != nil {
+				return ucerr.Wrap(err)
+			}
+			c.skipUntilSync()
+			continue
+
+		case *pgproto3.ReadyForQuery:
+			if m.TxStatus == 'I' {
+				c.closeAllPortals(ctx)
+			}
+
+		case *pgproto3.NoticeResponse, *pgproto3.ParameterStatus, *pgproto3.NotificationResponse:
+			// asynchronous messages can arrive at any time
+			done = false
+		}
+
+		if _, err := c.clientConn.Write(completeMessage); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if done {
+			c.pendingResponses = c.pendingResponses[1:]
+		}
+	}
+
+	return nil
+}
+
+// transformPortalDataRow transforms a row returned by a portal, converting any binary format values to text for the
+// observer and back again
+func (c *connection) transformPortalDataRow(ctx context.Context, p *portal, dr *pgproto3.DataRow) error {
+	columns := p.columns()
+	if len(columns) != len(dr.Values) {
+		return ucerr.Errorf("[psqlshim connection ID %s] portal returned %d values, but has %d columns described", c.connectionID, len(dr.Values), len(columns))
+	}
+
+	colNames := make([]string, len(columns))
+	values := make([][]byte, len(columns))
+	for i, col := range columns {
+		colNames[i] = col.Name
+		if dr.Values[i] == nil || col.Format == pgproto3.TextFormat {
+			values[i] = dr.Values[i]
+			continue
+		}
+
+		text, err := binaryToText(col.DataTypeOID, dr.Values[i])
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		values[i] = text
+	}
+
+	p.numSelectorRows++
+	passedAP, err := c.observer.TransformDataRow(ctx, colNames, values, p.transformInfo, p.numReturned)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if !passedAP {
+		p.numDenied++
+		return nil
+	}
+	p.numReturned++
+
+	for i, col := range columns {
+		if values[i] != nil && col.Format == pgproto3.BinaryFormat {
+			bs, err := textToBinary(col.DataTypeOID, values[i])
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			values[i] = bs
+		}
+	}
+
+	return ucerr.Wrap(c.client.Send(&pgproto3.DataRow{Values: values}))
+}
@@ -0,0 +1,342 @@
+package psqlshim
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgproto3"
+
+	"userclouds.com/infra/assert"
+	internalSqlshim "userclouds.com/internal/sqlshim"
+)
+
+type testObserver struct {
+	handleResponse internalSqlshim.HandleQueryResponse
+	rewrite        internalSqlshim.QueryRewrite
+	queries        []string
+	params         [][]any
+	summaries      [][3]int
+	cleanups       int
+}
+
+func (o *testObserver) NotifySchemaSelected(context.Context, string) {}
+
+func (o *testObserver) HandleQuery(_ context.Context, _ internalSqlshim.DatabaseType, query string, _ string, _ uuid.UUID, params []any) (internalSqlshim.HandleQueryResponse, any, string, error) {
+	o.queries = append(o.queries, query)
+	o.params = append(o.params, params)
+	if o.handleResponse == internalSqlshim.RewriteQuery {
+		return o.handleResponse, o.rewrite, "", nil
+	}
+	return o.handleResponse, "transformInfo", "", nil
+}
+
+func (o *testObserver) CleanupTransformerExecution(any) {
+	o.cleanups++
+}
+
+// TransformDataRow denies rows named "denied", and upper-cases everything else
+func (o *testObserver) TransformDataRow(_ context.Context, colNames []string, values [][]byte, _ any, _ int) (bool, error) {
+	for i, name := range colNames {
+		if name == "name" && string(values[i]) == "denied" {
+			return false, nil
+		}
+	}
+	for i := range values {
+		values[i] = []byte(strings.ToUpper(string(values[i])))
+	}
+	return true, nil
+}
+
+func (o *testObserver) TransformSummary(_ context.Context, _ any, numSelectorRows, numReturned, numDenied int) {
+	o.summaries = append(o.summaries, [3]int{numSelectorRows, numReturned, numDenied})
+}
+
+// tcpPair returns both ends of a loopback TCP connection, which unlike net.Pipe is buffered
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoErr(t, err)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := ln.Accept()
+		assert.NoErr(t, err)
+		accepted <- conn
+	}()
+	dialed, err := net.Dial("tcp", ln.Addr().String())
+	assert.NoErr(t, err)
+	return dialed, <-accepted
+}
+
+// fakeServer answers extended query protocol messages with a result set of (name, id) rows, where id is binary
+func fakeServer(t *testing.T, conn net.Conn, rows [][2]string) {
+	backend, err := pgproto3.NewBackend(pgproto3.NewChunkReader(conn), conn)
+	assert.NoErr(t, err)
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		switch msg.(type) {
+		case *pgproto3.Parse:
+			assert.NoErr(t, backend.Send(&pgproto3.ParseComplete{}))
+		case *pgproto3.Bind:
+			assert.NoErr(t, backend.Send(&pgproto3.BindComplete{}))
+		case *pgproto3.Describe:
+			assert.NoErr(t, backend.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+				{Name: "name", DataTypeOID: oidText, Format: pgproto3.TextFormat},
+				{Name: "id", DataTypeOID: oidUUID, Format: pgproto3.BinaryFormat},
+			}}))
+		case *pgproto3.Execute:
+			for _, row := range rows {
+				assert.NoErr(t, backend.Send(&pgproto3.DataRow{Values: [][]byte{[]byte(row[0]), uuid.FromStringOrNil(row[1]).Bytes()}}))
+			}
+			assert.NoErr(t, backend.Send(&pgproto3.CommandComplete{CommandTag: "SELECT"}))
+		case *pgproto3.Sync:
+			assert.NoErr(t, backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'}))
+		}
+	}
+}
+
+func TestExtendedQueryProtocol(t *testing.T) {
+	ctx := context.Background()
+	id := "2d9e3c9e-8ef4-4a4c-9a3b-6a4d1f4c9b10"
+
+	shimServerConn, serverConn := tcpPair(t)
+	defer serverConn.Close()
+	go fakeServer(t, serverConn, [][2]string{{"alice", id}, {"denied", id}})
+
+	shimClientConn, clientConn := tcpPair(t)
+	defer clientConn.Close()
+	frontend, err := pgproto3.NewFrontend(pgproto3.NewChunkReader(clientConn), clientConn)
+	assert.NoErr(t, err)
+	backend, err := pgproto3.NewBackend(dummyChunkReaderInstance, shimClientConn)
+	assert.NoErr(t, err)
+
+	observer := &testObserver{handleResponse: internalSqlshim.TransformResponse}
+	c := &connection{
+		clientConn:   shimClientConn,
+		serverConn:   shimServerConn,
+		client:       backend,
+		connectionID: uuid.Must(uuid.NewV4()),
+		observer:     observer,
+		statements:   map[string]*preparedStatement{},
+		portals:      map[string]*portal{},
+	}
+	pauseCopy := make(chan bool, 10)
+	resumeCopy := make(chan bool, 10)
+
+	send := func(msgs ...pgproto3.FrontendMessage) {
+		for _, msg := range msgs {
+			assert.NoErr(t, c.handleExtendedQueryMessage(ctx, msg, msg.Encode(nil), pauseCopy, resumeCopy))
+		}
+	}
+	receive := func() pgproto3.BackendMessage {
+		msg, err := frontend.Receive()
+		assert.NoErr(t, err)
+		return msg
+	}
+
+	// the parameters are only known once bound, and binary parameters are decoded using the statement's types
+	query := "SELECT name, id FROM users WHERE age = $1 AND name = $2"
+	send(
+		&pgproto3.Parse{Name: "stmt", Query: query, ParameterOIDs: []uint32{oidInt4, oidText}},
+		&pgproto3.Bind{
+			PreparedStatement:    "stmt",
+			ParameterFormatCodes: []int16{pgproto3.BinaryFormat, pgproto3.TextFormat},
+			Parameters:           [][]byte{binary.BigEndian.AppendUint32(nil, 42), []byte("alice")},
+			ResultFormatCodes:    []int16{pgproto3.TextFormat, pgproto3.BinaryFormat},
+		},
+		&pgproto3.Describe{ObjectType: 'P'},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	assert.Equal(t, observer.queries, []string{query})
+	assert.Equal(t, observer.params, [][]any{{"42", "alice"}})
+
+	_, ok := receive().(*pgproto3.ParseComplete)
+	assert.True(t, ok)
+	_, ok = receive().(*pgproto3.BindComplete)
+	assert.True(t, ok)
+	_, ok = receive().(*pgproto3.RowDescription)
+	assert.True(t, ok)
+	dr, ok := receive().(*pgproto3.DataRow)
+	assert.True(t, ok)
+	assert.Equal(t, string(dr.Values[0]), "ALICE")
+	assert.Equal(t, dr.Values[1], uuid.FromStringOrNil(id).Bytes())
+	_, ok = receive().(*pgproto3.CommandComplete)
+	assert.True(t, ok)
+	rfq, ok := receive().(*pgproto3.ReadyForQuery)
+	assert.True(t, ok)
+	assert.Equal(t, rfq.TxStatus, byte('I'))
+
+	assert.Equal(t, observer.summaries, [][3]int{{2, 1, 1}})
+	assert.Equal(t, observer.cleanups, 1)
+	assert.Equal(t, len(c.portals), 0)
+	assert.False(t, c.inExtendedBatch)
+
+	// a denied query never reaches the server, and the rest of the batch is skipped like after a server error
+	observer.handleResponse = internalSqlshim.AccessDenied
+	send(
+		&pgproto3.Bind{PreparedStatement: "stmt", Parameters: [][]byte{[]byte("7"), nil}},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	assert.Equal(t, observer.params[1], []any{"7", nil})
+
+	errMsg, ok := receive().(*pgproto3.ErrorResponse)
+	assert.True(t, ok)
+	assert.Equal(t, errMsg.Code, "42501")
+	_, ok = receive().(*pgproto3.ReadyForQuery)
+	assert.True(t, ok)
+	assert.False(t, c.failedBatch)
+}
+
+// bindServer records the parameters of each Bind it receives
+func bindServer(t *testing.T, conn net.Conn, binds chan<- *pgproto3.Bind) {
+	backend, err := pgproto3.NewBackend(pgproto3.NewChunkReader(conn), conn)
+	assert.NoErr(t, err)
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		switch m := msg.(type) {
+		case *pgproto3.Parse:
+			assert.NoErr(t, backend.Send(&pgproto3.ParseComplete{}))
+		case *pgproto3.Bind:
+			b := *m
+			b.Parameters = make([][]byte, len(m.Parameters))
+			for i, p := range m.Parameters {
+				b.Parameters[i] = append([]byte(nil), p...)
+			}
+			binds <- &b
+			assert.NoErr(t, backend.Send(&pgproto3.BindComplete{}))
+		case *pgproto3.Execute:
+			assert.NoErr(t, backend.Send(&pgproto3.CommandComplete{CommandTag: "INSERT 0 1"}))
+		case *pgproto3.Sync:
+			assert.NoErr(t, backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'}))
+		}
+	}
+}
+
+func TestExtendedQueryParameterRewrite(t *testing.T) {
+	ctx := context.Background()
+
+	shimServerConn, serverConn := tcpPair(t)
+	defer serverConn.Close()
+	binds := make(chan *pgproto3.Bind, 10)
+	go bindServer(t, serverConn, binds)
+
+	shimClientConn, clientConn := tcpPair(t)
+	defer clientConn.Close()
+	frontend, err := pgproto3.NewFrontend(pgproto3.NewChunkReader(clientConn), clientConn)
+	assert.NoErr(t, err)
+	backend, err := pgproto3.NewBackend(dummyChunkReaderInstance, shimClientConn)
+	assert.NoErr(t, err)
+
+	observer := &testObserver{
+		handleResponse: internalSqlshim.RewriteQuery,
+		rewrite:        internalSqlshim.QueryRewrite{Params: map[int]string{1: "token-1234"}},
+	}
+	c := &connection{
+		clientConn:   shimClientConn,
+		serverConn:   shimServerConn,
+		client:       backend,
+		connectionID: uuid.Must(uuid.NewV4()),
+		observer:     observer,
+		statements:   map[string]*preparedStatement{},
+		portals:      map[string]*portal{},
+	}
+	pauseCopy := make(chan bool, 10)
+	resumeCopy := make(chan bool, 10)
+
+	send := func(msgs ...pgproto3.FrontendMessage) {
+		for _, msg := range msgs {
+			assert.NoErr(t, c.handleExtendedQueryMessage(ctx, msg, msg.Encode(nil), pauseCopy, resumeCopy))
+		}
+	}
+	receiveUntilReady := func() []pgproto3.BackendMessage {
+		var msgs []pgproto3.BackendMessage
+		for {
+			msg, err := frontend.Receive()
+			assert.NoErr(t, err)
+			msgs = append(msgs, msg)
+			if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+				return msgs
+			}
+		}
+	}
+
+	// the transformed value replaces the bound parameter, whatever format it was sent in
+	query := "INSERT INTO users (id, email) VALUES ($1, $2)"
+	send(
+		&pgproto3.Parse{Name: "stmt", Query: query, ParameterOIDs: []uint32{oidInt4, oidText}},
+		&pgproto3.Bind{
+			PreparedStatement:    "stmt",
+			ParameterFormatCodes: []int16{pgproto3.BinaryFormat},
+			Parameters:           [][]byte{binary.BigEndian.AppendUint32(nil, 42), []byte("alice@example.com")},
+		},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	assert.Equal(t, observer.params, [][]any{{"42", "alice@example.com"}})
+
+	bind := <-binds
+	assert.Equal(t, bind.Parameters[0], binary.BigEndian.AppendUint32(nil, 42))
+	assert.Equal(t, string(bind.Parameters[1]), "token-1234")
+	assert.Equal(t, bind.ParameterFormatCodes, []int16{pgproto3.BinaryFormat, pgproto3.BinaryFormat})
+	assert.Equal(t, len(receiveUntilReady()), 4)
+
+	// literal values in a prepared statement can't be rewritten, so the query is denied rather than forwarded
+	observer.rewrite = internalSqlshim.QueryRewrite{Query: "INSERT INTO users (email) VALUES ('token-1234')"}
+	send(
+		&pgproto3.Bind{PreparedStatement: "stmt", Parameters: [][]byte{[]byte("7"), []byte("bob@example.com")}},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	msgs := receiveUntilReady()
+	assert.Equal(t, len(msgs), 2)
+	errMsg, ok := msgs[0].(*pgproto3.ErrorResponse)
+	assert.True(t, ok)
+	assert.Equal(t, errMsg.Code, "42501")
+	assert.Equal(t, len(binds), 0)
+}
+
+func TestBinaryValueConversion(t *testing.T) {
+	for _, tc := range []struct {
+		oid  uint32
+		text string
+	}{
+		{oidBool, "t"},
+		{oidInt2, "-12"},
+		{oidInt4, "123456"},
+		{oidInt8, "-1234567890123"},
+		{oidFloat8, "3.25"},
+		{oidText, "hello"},
+		{oidJSONB, `{"a":1}`},
+		{oidUUID, "2d9e3c9e-8ef4-4a4c-9a3b-6a4d1f4c9b10"},
+		{oidDate, "1999-12-31"},
+		{oidTimestamp, "2024-02-29 13:14:15.123456"},
+		{oidTimestampTZ, "2024-02-29 13:14:15Z"},
+	} {
+		bs, err := textToBinary(tc.oid, []byte(tc.text))
+		assert.NoErr(t, err)
+		text, err := binaryToText(tc.oid, bs)
+		assert.NoErr(t, err)
+		assert.Equal(t, string(text), tc.text)
+	}
+
+	_, err := binaryToText(oidInt4, []byte{1, 2})
+	assert.NotNil(t, err)
+	_, err = textToBinary(oidInt4, []byte("not a number"))
+	assert.NotNil(t, err)
+	_, err = binaryToText(1186, []byte{}) // interval
+	assert.NotNil(t, err)
+}
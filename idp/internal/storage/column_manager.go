@@ -899,8 +899,7 @@ func (cm *ColumnManager) validateDefaultTransformer(ctx context.Context, c *Colu
 		return ucerr.Wrap(err)
 	}
 
-	if t.TransformType == transformTypeTokenizeByReference ||
-		t.TransformType == transformTypeTokenizeByValue {
+	if t.RequiresTokenAccessPolicy() {
 		if c.DefaultTokenAccessPolicyID.IsNil() {
 			return ucerr.Friendlyf(nil, "column %v has no default token access policy", c.ID)
 		}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/namespace/region"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// EraseDSARExportsForUser permanently deletes every DSAR export of a user, since their bundles contain the user's
+// data, and returns the number of exports deleted
+func (s *UserStorage) EraseDSARExportsForUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	const q = "DELETE FROM dsar_exports WHERE user_id=$1;"
+
+	res, err := s.db.ExecContext(ctx, "EraseDSARExportsForUser", q, userID)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return int(ra), nil
+}
+
+// ClearExpiredDSARExportBundles clears the bundles (and signatures) of up to maxCandidates DSAR exports that expired
+// at or before now, since they can no longer be downloaded, and returns the number of exports cleared
+func (s *UserStorage) ClearExpiredDSARExportBundles(ctx context.Context, now time.Time, maxCandidates int) (int, error) {
+	const q = `UPDATE dsar_exports SET updated=CLOCK_TIMESTAMP(), bundle='', signature=''
+		WHERE id IN (SELECT id FROM dsar_exports WHERE expires_at<=$1 AND bundle<>'' AND deleted='0001-01-01 00:00:00' ORDER BY expires_at LIMIT $2);`
+
+	res, err := s.db.ExecContext(ctx, "ClearExpiredDSARExportBundles", q, now, maxCandidates)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return int(ra), nil
+}
+
+type getDSARExportOutput struct {
+	export *DSARExport
+	region region.DataRegion
+}
+
+// GetDSARExport returns a DSAR export, and the region it is stored in, from the first region that has it
+func (umrs *UserMultiRegionStorage) GetDSARExport(ctx context.Context, id uuid.UUID) (*DSARExport, region.DataRegion, error) {
+	out := runAcrossRegionsOutput{
+		mutex: &sync.Mutex{},
+	}
+
+	_, err := umrs.runAcrossRegions(ctx, func(ctx context.Context, s *UserStorage, out *runAcrossRegionsOutput) (int, error) {
+		export, err := s.GetDSARExport(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			// mask not found errors, since the export may be in another region
+			return http.StatusOK, nil
+		}
+		if err != nil {
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+
+		out.mutex.Lock()
+		defer out.mutex.Unlock()
+		if out.getDSARExportOutput.export != nil {
+			uclog.Errorf(ctx, "DSAR export %v found in multiple regions", id)
+		} else {
+			out.getDSARExportOutput.export = export
+			out.getDSARExportOutput.region = s.GetRegion()
+		}
+		return http.StatusOK, nil
+	}, &out)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+	if out.getDSARExportOutput.export == nil {
+		return nil, "", ucerr.Friendlyf(sql.ErrNoRows, "DSARExport %v not found", id)
+	}
+
+	return out.getDSARExportOutput.export, out.getDSARExportOutput.region, nil
+}
+
+// EraseDSARExportsForUser permanently deletes every DSAR export of a user in every region, and returns the number
+// of exports deleted
+func (umrs *UserMultiRegionStorage) EraseDSARExportsForUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	out := runAcrossRegionsOutput{
+		mutex: &sync.Mutex{},
+	}
+
+	_, err := umrs.runAcrossRegions(ctx, func(ctx context.Context, s *UserStorage, out *runAcrossRegionsOutput) (int, error) {
+		n, err := s.EraseDSARExportsForUser(ctx, userID)
+		if err != nil {
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+
+		out.mutex.Lock()
+		defer out.mutex.Unlock()
+		out.dsarExportCountOutput += n
+		return http.StatusOK, nil
+	}, &out)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	return out.dsarExportCountOutput, nil
+}
+
+// ClearExpiredDSARExportBundles clears the bundles of up to maxCandidates DSAR exports in each region that expired at
+// or before now, and returns the number of exports cleared
+func (umrs *UserMultiRegionStorage) ClearExpiredDSARExportBundles(ctx context.Context, now time.Time, maxCandidates int) (int, error) {
+	out := runAcrossRegionsOutput{
+		mutex: &sync.Mutex{},
+	}
+
+	_, err := umrs.runAcrossRegions(ctx, func(ctx context.Context, s *UserStorage, out *runAcrossRegionsOutput) (int, error) {
+		n, err := s.ClearExpiredDSARExportBundles(ctx, now, maxCandidates)
+		if err != nil {
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+
+		out.mutex.Lock()
+		defer out.mutex.Unlock()
+		out.dsarExportCountOutput += n
+		return http.StatusOK, nil
+	}, &out)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	return out.dsarExportCountOutput, nil
+}
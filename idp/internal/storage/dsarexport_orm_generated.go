@@ -0,0 +1,191 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uctypes/set"
+)
+
+// IsDSARExportSoftDeleted returns true if the id is associated with a soft-deleted row but no undeleted rows
+func (s *UserStorage) IsDSARExportSoftDeleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	const q = "/* lint-sql-ok */ SELECT deleted FROM dsar_exports WHERE id=$1 ORDER By deleted LIMIT 1;"
+
+	var deleted time.Time
+	if err := s.db.GetContext(ctx, "IsDSARExportSoftDeleted", &deleted, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, ucerr.Wrap(err)
+	}
+
+	return !deleted.IsZero(), nil
+}
+
+// GetDSARExport loads a DSARExport by ID
+func (s *UserStorage) GetDSARExport(ctx context.Context, id uuid.UUID) (*DSARExport, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, error, bundle, signature, expires_at, created FROM dsar_exports WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+
+	var obj DSARExport
+	if err := s.db.GetContext(ctx, "GetDSARExport", &obj, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Friendlyf(err, "DSARExport %v not found", id)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+	return &obj, nil
+}
+
+// GetDSARExportSoftDeleted loads a DSARExport by ID iff it's soft-deleted
+func (s *UserStorage) GetDSARExportSoftDeleted(ctx context.Context, id uuid.UUID) (*DSARExport, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, error, bundle, signature, expires_at, created FROM dsar_exports WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+
+	var obj DSARExport
+	if err := s.db.GetContext(ctx, "GetDSARExportSoftDeleted", &obj, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Friendlyf(err, "soft-deleted DSARExport %v not found", id)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &obj, nil
+}
+
+// GetDSARExportsForIDs loads multiple DSARExport for a given list of IDs
+func (s *UserStorage) GetDSARExportsForIDs(ctx context.Context, errorOnMissing bool, ids ...uuid.UUID) ([]DSARExport, error) {
+	items := make([]DSARExport, 0, len(ids))
+
+	missed := set.NewUUIDSet(ids...) // Assume we will miss all keys, and remove from this list if we get them from the cache
+	dirty := true
+	if missed.Size() > 0 {
+		itemsFromDB, err := s.getDSARExportsHelperForIDs(ctx, dirty, true, missed.Items()...)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		items = append(items, itemsFromDB...)
+	}
+
+	return items, nil
+}
+
+// getDSARExportsHelperForIDs loads multiple DSARExport for a given list of IDs from the DB
+func (s *UserStorage) getDSARExportsHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]DSARExport, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, error, bundle, signature, expires_at, created FROM dsar_exports WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	var objects []DSARExport
+	if err := s.db.SelectContextWithDirty(ctx, "GetDSARExportsForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if errorOnMissing && len(ids) != len(objects) {
+		requestedIDs := set.NewUUIDSet(ids...)
+		loadedIDs := set.NewUUIDSet()
+		for _, obj := range objects {
+			loadedIDs.Insert(obj.ID)
+		}
+		missingIDs := requestedIDs.Difference(loadedIDs)
+		return nil, ucerr.Friendlyf(nil, "Not all requested DSARExports  were loaded. requested: %v loaded: %v missing: [%v]", len(ids), len(objects), missingIDs)
+	}
+	return objects, nil
+}
+
+// ListDSARExportsPaginated loads a paginated list of DSARExports for the specified paginator settings
+func (s *UserStorage) ListDSARExportsPaginated(ctx context.Context, p pagination.Paginator) ([]DSARExport, *pagination.ResponseFields, error) {
+	return s.listInnerDSARExportsPaginated(ctx, p, false)
+}
+
+// listInnerDSARExportsPaginated loads a paginated list of DSARExports for the specified paginator settings
+func (s *UserStorage) listInnerDSARExportsPaginated(ctx context.Context, p pagination.Paginator, forceDBRead bool) ([]DSARExport, *pagination.ResponseFields, error) {
+	queryFields, err := p.GetQueryFields()
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	// the inner query requires an alias for postgres, so we always call it tmp
+	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
+	q := fmt.Sprintf("SELECT id, updated, deleted, user_id, status, error, bundle, signature, expires_at, created FROM (SELECT id, updated, deleted, user_id, status, error, bundle, signature, expires_at, created FROM dsar_exports WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+
+	var objsDB []DSARExport
+	if err := s.db.SelectContext(ctx, "ListDSARExportsPaginated", &objsDB, q, queryFields...); err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	objs, respFields := pagination.ProcessResults(objsDB, p.GetCursor(), p.GetLimit(), p.IsForward(), p.GetSortKey())
+	if respFields.HasNext {
+		if err := p.ValidateCursor(respFields.Next); err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+	}
+
+	if respFields.HasPrev {
+		if err := p.ValidateCursor(respFields.Prev); err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+	}
+
+	return objs, &respFields, nil
+}
+
+// ListDSARExportsForUserID loads the list of DSARExports with a matching UserID field
+func (s *UserStorage) ListDSARExportsForUserID(ctx context.Context, userID uuid.UUID) ([]DSARExport, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, error, bundle, signature, expires_at, created FROM dsar_exports WHERE user_id=$1 AND deleted='0001-01-01 00:00:00';"
+	var objs []DSARExport
+	if err := s.db.SelectContext(ctx, "ListDSARExportsForUserID", &objs, q, userID); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return objs, nil
+}
+
+// SaveDSARExport saves a DSARExport
+func (s *UserStorage) SaveDSARExport(ctx context.Context, obj *DSARExport) error {
+	if err := obj.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return ucerr.Wrap(s.saveInnerDSARExport(ctx, obj))
+}
+
+// SaveDSARExport saves a DSARExport
+func (s *UserStorage) saveInnerDSARExport(ctx context.Context, obj *DSARExport) error {
+	const q = "INSERT INTO dsar_exports (id, updated, deleted, user_id, status, error, bundle, signature, expires_at) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, user_id = $3, status = $4, error = $5, bundle = $6, signature = $7, expires_at = $8 WHERE (dsar_exports.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveDSARExport", obj, q, obj.ID, obj.Deleted, obj.UserID, obj.Status, obj.Error, obj.Bundle, obj.Signature, obj.ExpiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ucerr.Friendlyf(err, "DSARExport %v not found", obj.ID)
+		}
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteDSARExport soft-deletes a DSARExport which is currently alive
+// Note that this will fail on an already-deleted object (since we don't want to re-delete
+// tombstoned objects and corrupt the deletion timestamp)
+func (s *UserStorage) DeleteDSARExport(ctx context.Context, objID uuid.UUID) error {
+	return ucerr.Wrap(s.deleteInnerDSARExport(ctx, objID, false))
+}
+
+// deleteInnerDSARExport soft-deletes a DSARExport which is currently alive
+func (s *UserStorage) deleteInnerDSARExport(ctx context.Context, objID uuid.UUID, wrappedDelete bool) error {
+	const q = "UPDATE dsar_exports SET deleted=CLOCK_TIMESTAMP() WHERE id=$1 AND deleted='0001-01-01 00:00:00' RETURNING deleted;"
+	res, err := s.db.ExecContext(ctx, "DeleteDSARExport", q, objID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Errorf("Error deleting DSARExport %v: %w", objID, err)
+	}
+	if ra == 0 {
+		// we wrap sql.ErrNoRows here to be consistent
+		return ucerr.Friendlyf(sql.ErrNoRows, "DSARExport %v not found", objID)
+	}
+	return nil
+}
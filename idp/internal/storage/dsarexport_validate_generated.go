@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o DSARExport) Validate() error {
+	if err := o.UserBaseModel.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.Status == "" {
+		return ucerr.Friendlyf(nil, "DSARExport.Status (%v) can't be empty", o.ID)
+	}
+	return nil
+}
@@ -492,8 +492,7 @@ func (mm *MethodManager) validateAccessorSettings(ctx context.Context, updated *
 			}
 		}
 
-		if transformer.TransformType == transformTypeTokenizeByReference ||
-			transformer.TransformType == transformTypeTokenizeByValue {
+		if transformer.RequiresTokenAccessPolicy() {
 
 			tokenAccessPolicyID := updated.TokenAccessPolicyIDs[i]
 			if tokenAccessPolicyID.IsNil() {
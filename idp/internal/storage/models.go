@@ -925,8 +925,35 @@ type ShimObjectStore struct {
 	SecretAccessKey secret.String   `db:"secret_access_key"`
 	RoleARN         string          `db:"role_arn"`
 	AccessPolicyID  uuid.UUID       `db:"access_policy_id" validate:"notnil"`
+
+	FieldMappings   ShimObjectStoreFieldMappings `db:"field_mappings"`
+	TokenizeOnWrite bool                         `db:"tokenize_on_write"`
+
+	// WriteAccessPolicyID is checked for uploads and deletes instead of AccessPolicyID, and writes are denied if it is nil
+	WriteAccessPolicyID uuid.UUID `db:"write_access_policy_id"`
+
+	// ExportBucket and ExportPrefix are where accessor exports may be written with the object store's credentials,
+	// and exports to the object store are rejected if ExportBucket isn't set
+	ExportBucket string `db:"export_bucket"`
+	ExportPrefix string `db:"export_prefix"`
 }
 
+// ShimObjectStoreFieldMapping maps a field of the objects stored under a key prefix to a userstore column, and the
+// transformer the S3 shim applies to the field's values when the objects are read
+type ShimObjectStoreFieldMapping struct {
+	KeyPrefix     string    `json:"key_prefix"`
+	FieldPath     string    `json:"field_path" validate:"notempty"`
+	ColumnID      uuid.UUID `json:"column_id" validate:"notnil"`
+	TransformerID uuid.UUID `json:"transformer_id" validate:"notnil"`
+}
+
+//go:generate genvalidate ShimObjectStoreFieldMapping
+
+// ShimObjectStoreFieldMappings is the list of field mappings for a ShimObjectStore
+type ShimObjectStoreFieldMappings []ShimObjectStoreFieldMapping
+
+//go:generate gendbjson ShimObjectStoreFieldMappings
+
 func (s ShimObjectStore) extraValidate() error {
 	if (s.AccessKeyID == "" || s.SecretAccessKey.IsEmpty()) && s.RoleARN == "" {
 		return ucerr.Friendlyf(nil, "ShimObjectStore must have both AccessKeyID and SecretAccessKey; otherwise RoleARN must be provided")
@@ -945,15 +972,30 @@ func (s ShimObjectStore) extraValidate() error {
 
 // ToClientModel translates from a storage.ShimObjectStore to a userstore.ShimObjectStore
 func (s ShimObjectStore) ToClientModel() userstore.ShimObjectStore {
-	return userstore.ShimObjectStore{
-		ID:           s.ID,
-		Name:         s.Name,
-		Type:         string(s.Type),
-		Region:       s.Region,
-		AccessKeyID:  s.AccessKeyID,
-		RoleARN:      s.RoleARN,
-		AccessPolicy: userstore.ResourceID{ID: s.AccessPolicyID},
-	}
+	objStore := userstore.ShimObjectStore{
+		ID:              s.ID,
+		Name:            s.Name,
+		Type:            string(s.Type),
+		Region:          s.Region,
+		AccessKeyID:     s.AccessKeyID,
+		RoleARN:         s.RoleARN,
+		AccessPolicy:    userstore.ResourceID{ID: s.AccessPolicyID},
+		TokenizeOnWrite: s.TokenizeOnWrite,
+		ExportBucket:    s.ExportBucket,
+		ExportPrefix:    s.ExportPrefix,
+	}
+	if !s.WriteAccessPolicyID.IsNil() {
+		objStore.WriteAccessPolicy = userstore.ResourceID{ID: s.WriteAccessPolicyID}
+	}
+	for _, fm := range s.FieldMappings {
+		objStore.FieldMappings = append(objStore.FieldMappings, userstore.ShimObjectStoreFieldMapping{
+			KeyPrefix:   fm.KeyPrefix,
+			FieldPath:   fm.FieldPath,
+			Column:      userstore.ResourceID{ID: fm.ColumnID},
+			Transformer: userstore.ResourceID{ID: fm.TransformerID},
+		})
+	}
+	return objStore
 }
 
 func (ShimObjectStore) getPaginationKeys() pagination.KeyTypes {
@@ -976,3 +1018,98 @@ func (s ShimObjectStore) getCursor(key pagination.Key, cursor *pagination.Cursor
 		)
 	}
 }
+
+// DSARExportStatus is the status of a DSARExport
+type DSARExportStatus string
+
+// DSARExportStatus constants
+const (
+	DSARExportStatusPending   DSARExportStatus = "pending"
+	DSARExportStatusCompleted DSARExportStatus = "completed"
+	DSARExportStatusFailed    DSARExportStatus = "failed"
+)
+
+// DSARExport is a data subject access request export of everything we store about a single user. The bundle is
+// assembled in the background, and is kept until it expires so that it can be downloaded. Since the bundle contains
+// the user's data, the export is stored in the user's region.
+type DSARExport struct {
+	ucdb.UserBaseModel
+
+	Status DSARExportStatus `db:"status" validate:"notempty"`
+	Error  string           `db:"error"`
+
+	// Bundle is the JSON bundle, and Signature is a JWS signed with the tenant's signing key over its digest
+	Bundle    string `db:"bundle"`
+	Signature string `db:"signature"`
+
+	// ExpiresAt is the time after which the bundle can no longer be downloaded
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// IsExpired returns true if the export can no longer be downloaded
+func (e DSARExport) IsExpired(now time.Time) bool {
+	return !e.ExpiresAt.After(now)
+}
+
+//go:generate genpageable DSARExport
+
+//go:generate genvalidate DSARExport
+
+//go:generate genorm --storageclassprefix DSARExport dsar_exports tenantdb User
+
+// UserErasureStatus is the status of a UserErasure, or of erasing the user's data from one of its systems
+type UserErasureStatus string
+
+// UserErasureStatus constants
+const (
+	UserErasureStatusPending   UserErasureStatus = "pending"
+	UserErasureStatusCompleted UserErasureStatus = "completed"
+	UserErasureStatusFailed    UserErasureStatus = "failed"
+)
+
+// UserErasureSystemState tracks the erasure of a user's data from a single system
+type UserErasureSystemState struct {
+	System   idp.UserErasureSystem `json:"system" validate:"notempty"`
+	Status   UserErasureStatus     `json:"status" validate:"notempty"`
+	Attempts int                   `json:"attempts"`
+	Error    string                `json:"error"`
+
+	DeletedCount  int       `json:"deleted_count"`
+	RetainedUntil time.Time `json:"retained_until"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+//go:generate genvalidate UserErasureSystemState
+
+// UserErasureSystemStates is the list of per-system states of a UserErasure
+type UserErasureSystemStates []UserErasureSystemState
+
+//go:generate gendbjson UserErasureSystemStates
+
+// UserErasure is a request to erase a user from every system that stores data about them. Each system is erased
+// in the background and retried independently, and once all of them are completed the erasure is certified.
+type UserErasure struct {
+	ucdb.UserBaseModel
+
+	Status  UserErasureStatus       `db:"status" validate:"notempty"`
+	Systems UserErasureSystemStates `db:"systems"`
+
+	// UserEventAliases are the aliases other than the user ID that the user's events were reported under
+	UserEventAliases pq.StringArray `db:"user_event_aliases"`
+
+	// Certificate is a JWS signed with the tenant's signing key once every system has been erased
+	Certificate string    `db:"certificate"`
+	CompletedAt time.Time `db:"completed_at"`
+}
+
+func (UserErasure) getPaginationKeys() pagination.KeyTypes {
+	return pagination.KeyTypes{
+		"status": pagination.StringKeyType,
+	}
+}
+
+//go:generate genpageable UserErasure
+
+//go:generate genvalidate UserErasure
+
+//go:generate genorm UserErasure user_erasures tenantdb
@@ -56,7 +56,7 @@ func (s *Storage) IsShimObjectStoreSoftDeleted(ctx context.Context, id uuid.UUID
 func (s *Storage) GetShimObjectStore(ctx context.Context, id uuid.UUID) (*ShimObjectStore, error) {
 	return cache.ServerGetItem(ctx, s.cm, id, ShimObjectStoreKeyID, IsModifiedKeyID,
 		func(id uuid.UUID, conflict cache.Sentinel, obj *ShimObjectStore) error {
-			const q = "SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, created FROM shim_object_stores WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+			const q = "SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, field_mappings, tokenize_on_write, write_access_policy_id, export_bucket, export_prefix, created FROM shim_object_stores WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
 
 			if err := s.db.GetContextWithDirty(ctx, "GetShimObjectStore", obj, q, cache.IsTombstoneSentinel(string(conflict)), id); err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
@@ -86,7 +86,7 @@ func (s *Storage) GetShimObjectStoreSoftDeleted(ctx context.Context, id uuid.UUI
 			return nil, ucerr.Friendlyf(err, "soft-deleted ShimObjectStore %v not found", id)
 		}
 	}
-	const q = "SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, created FROM shim_object_stores WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, field_mappings, tokenize_on_write, write_access_policy_id, export_bucket, export_prefix, created FROM shim_object_stores WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
 
 	var obj ShimObjectStore
 	if err := s.db.GetContextWithDirty(ctx, "GetShimObjectStoreSoftDeleted", &obj, q, cache.IsTombstoneSentinel(string(conflict)), id); err != nil {
@@ -160,7 +160,7 @@ func (s *Storage) GetShimObjectStoresForIDs(ctx context.Context, errorOnMissing
 
 // getShimObjectStoresHelperForIDs loads multiple ShimObjectStore for a given list of IDs from the DB
 func (s *Storage) getShimObjectStoresHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]ShimObjectStore, error) {
-	const q = "SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, created FROM shim_object_stores WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, field_mappings, tokenize_on_write, write_access_policy_id, export_bucket, export_prefix, created FROM shim_object_stores WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
 	var objects []ShimObjectStore
 	if err := s.db.SelectContextWithDirty(ctx, "GetShimObjectStoresForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -210,7 +210,7 @@ func (s *Storage) listInnerShimObjectStoresPaginated(ctx context.Context, p pagi
 
 	// the inner query requires an alias for postgres, so we always call it tmp
 	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
-	q := fmt.Sprintf("SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, created FROM (SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, created FROM shim_object_stores WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+	q := fmt.Sprintf("SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, field_mappings, tokenize_on_write, write_access_policy_id, export_bucket, export_prefix, created FROM (SELECT id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, field_mappings, tokenize_on_write, write_access_policy_id, export_bucket, export_prefix, created FROM shim_object_stores WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
 
 	var objsDB []ShimObjectStore
 	if err := s.db.SelectContextWithDirty(ctx, "ListShimObjectStoresPaginated", &objsDB, q, cache.IsTombstoneSentinel(string(conflict)), queryFields...); err != nil {
@@ -247,8 +247,8 @@ func (s *Storage) SaveShimObjectStore(ctx context.Context, obj *ShimObjectStore)
 
 // SaveShimObjectStore saves a ShimObjectStore
 func (s *Storage) saveInnerShimObjectStore(ctx context.Context, obj *ShimObjectStore) error {
-	const q = "INSERT INTO shim_object_stores (id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, name = $3, type = $4, region = $5, access_key_id = $6, secret_access_key = $7, role_arn = $8, access_policy_id = $9 WHERE (shim_object_stores.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
-	if err := s.db.GetContext(ctx, "SaveShimObjectStore", obj, q, obj.ID, obj.Deleted, obj.Name, obj.Type, obj.Region, obj.AccessKeyID, obj.SecretAccessKey, obj.RoleARN, obj.AccessPolicyID); err != nil {
+	const q = "INSERT INTO shim_object_stores (id, updated, deleted, name, type, region, access_key_id, secret_access_key, role_arn, access_policy_id, field_mappings, tokenize_on_write, write_access_policy_id, export_bucket, export_prefix) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, name = $3, type = $4, region = $5, access_key_id = $6, secret_access_key = $7, role_arn = $8, access_policy_id = $9, field_mappings = $10, tokenize_on_write = $11, write_access_policy_id = $12, export_bucket = $13, export_prefix = $14 WHERE (shim_object_stores.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveShimObjectStore", obj, q, obj.ID, obj.Deleted, obj.Name, obj.Type, obj.Region, obj.AccessKeyID, obj.SecretAccessKey, obj.RoleARN, obj.AccessPolicyID, obj.FieldMappings, obj.TokenizeOnWrite, obj.WriteAccessPolicyID, obj.ExportBucket, obj.ExportPrefix); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ucerr.Friendlyf(err, "ShimObjectStore %v not found", obj.ID)
 		}
@@ -26,6 +26,11 @@ func (o ShimObjectStore) Validate() error {
 	if o.AccessPolicyID.IsNil() {
 		return ucerr.Friendlyf(nil, "ShimObjectStore.AccessPolicyID (%v) can't be nil", o.ID)
 	}
+	for _, item := range o.FieldMappings {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	// .extraValidate() lets you do any validation you can't express in codegen tags yet
 	if err := o.extraValidate(); err != nil {
 		return ucerr.Wrap(err)
@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ShimObjectStoreFieldMapping) Validate() error {
+	if o.FieldPath == "" {
+		return ucerr.Friendlyf(nil, "ShimObjectStoreFieldMapping.FieldPath can't be empty")
+	}
+	if o.ColumnID.IsNil() {
+		return ucerr.Friendlyf(nil, "ShimObjectStoreFieldMapping.ColumnID can't be nil")
+	}
+	if o.TransformerID.IsNil() {
+		return ucerr.Friendlyf(nil, "ShimObjectStoreFieldMapping.TransformerID can't be nil")
+	}
+	return nil
+}
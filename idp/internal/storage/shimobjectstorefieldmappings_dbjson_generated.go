@@ -0,0 +1,24 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// Value implements sql.Valuer
+func (o ShimObjectStoreFieldMappings) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// Scan implements sql.Scanner
+func (o *ShimObjectStoreFieldMappings) Scan(value any) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return ucerr.New("type assertion failed for ShimObjectStoreFieldMappings.Scan()")
+	}
+	return ucerr.Wrap(json.Unmarshal(b, &o))
+}
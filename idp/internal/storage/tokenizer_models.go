@@ -39,6 +39,20 @@ type TokenRecord struct {
 	TransformerID      uuid.UUID `db:"transformer_id" validate:"notnil"`
 	TransformerVersion int       `db:"transformer_version"`
 	AccessPolicyID     uuid.UUID `db:"access_policy_id" validate:"notnil"`
+
+	// ExpiresAt is the time after which the token can no longer be resolved, or zero if it never expires
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// IsExpired returns true if the token has an expiration time that is at or before now
+func (tr TokenRecord) IsExpired(now time.Time) bool {
+	return !tr.ExpiresAt.IsZero() && !tr.ExpiresAt.After(now)
+}
+
+func (TokenRecord) getPaginationKeys() pagination.KeyTypes {
+	return pagination.KeyTypes{
+		"expires_at": pagination.TimestampKeyType,
+	}
 }
 
 func (tr *TokenRecord) extraValidate() error {
@@ -387,12 +401,33 @@ type AccessPolicy struct {
 //go:generate genvalidate AccessPolicy
 
 // CheckRateThreshold will return false if the configured max execution rate for the access policy
-// and specified context and entity id is exceeded
+// and specified context and entity id is exceeded, reserving an execution slot otherwise
 func (ap AccessPolicy) CheckRateThreshold(
 	ctx context.Context,
 	s *Storage,
 	apc policy.AccessPolicyContext,
 	entityID uuid.UUID,
+) (bool, error) {
+	return ap.checkRateThreshold(ctx, s, apc, entityID, true)
+}
+
+// PeekRateThreshold will return false if the configured max execution rate for the access policy
+// and specified context and entity id is exceeded, without counting as an execution
+func (ap AccessPolicy) PeekRateThreshold(
+	ctx context.Context,
+	s *Storage,
+	apc policy.AccessPolicyContext,
+	entityID uuid.UUID,
+) (bool, error) {
+	return ap.checkRateThreshold(ctx, s, apc, entityID, false)
+}
+
+func (ap AccessPolicy) checkRateThreshold(
+	ctx context.Context,
+	s *Storage,
+	apc policy.AccessPolicyContext,
+	entityID uuid.UUID,
+	takeSlot bool,
 ) (bool, error) {
 	if !ap.Thresholds.hasRateLimit() {
 		return true, nil
@@ -408,7 +443,7 @@ func (ap AccessPolicy) CheckRateThreshold(
 		return false, ucerr.Wrap(err)
 	}
 
-	reserved, _, err := cache.ReserveRateLimitSlot(ctx, *s.cm, *aprl, true)
+	reserved, _, err := cache.ReserveRateLimitSlot(ctx, *s.cm, *aprl, takeSlot)
 	if err != nil {
 		return false, ucerr.Wrap(err)
 	}
@@ -532,16 +567,18 @@ func (ap *AccessPolicy) ToClientModel() *policy.AccessPolicy {
 type InternalTransformType int
 
 const (
-	transformTypePassThrough         InternalTransformType = 1
-	transformTypeTransform           InternalTransformType = 2
-	transformTypeTokenizeByValue     InternalTransformType = 3
-	transformTypeTokenizeByReference InternalTransformType = 4
+	transformTypePassThrough                InternalTransformType = 1
+	transformTypeTransform                  InternalTransformType = 2
+	transformTypeTokenizeByValue            InternalTransformType = 3
+	transformTypeTokenizeByReference        InternalTransformType = 4
+	transformTypeFormatPreservingEncryption InternalTransformType = 5
 )
 
 // Validate implements Validateable
 func (tt InternalTransformType) Validate() error {
 	switch tt {
-	case transformTypePassThrough, transformTypeTransform, transformTypeTokenizeByValue, transformTypeTokenizeByReference:
+	case transformTypePassThrough, transformTypeTransform, transformTypeTokenizeByValue, transformTypeTokenizeByReference,
+		transformTypeFormatPreservingEncryption:
 		return nil
 	}
 	return ucerr.Friendlyf(nil, "Invalid transform type %d", tt)
@@ -558,6 +595,8 @@ func (tt InternalTransformType) ToClient() policy.TransformType {
 		return policy.TransformTypeTokenizeByValue
 	case transformTypeTokenizeByReference:
 		return policy.TransformTypeTokenizeByReference
+	case transformTypeFormatPreservingEncryption:
+		return policy.TransformTypeFormatPreservingEncryption
 	}
 
 	return policy.TransformTypePassThrough
@@ -574,6 +613,8 @@ func InternalTransformTypeFromClient(tt policy.TransformType) InternalTransformT
 		return transformTypeTokenizeByValue
 	case policy.TransformTypeTokenizeByReference:
 		return transformTypeTokenizeByReference
+	case policy.TransformTypeFormatPreservingEncryption:
+		return transformTypeFormatPreservingEncryption
 	default:
 		return transformTypePassThrough
 	}
@@ -698,10 +739,23 @@ func (t Transformer) RequiresDataProvenance() bool {
 
 // RequiresTokenAccessPolicy returns whether an access policy is required for execution
 func (t Transformer) RequiresTokenAccessPolicy() bool {
+	return t.TransformType == transformTypeTokenizeByValue ||
+		t.TransformType == transformTypeTokenizeByReference ||
+		t.TransformType == transformTypeFormatPreservingEncryption
+}
+
+// CreatesTokens returns whether executing the transformer saves token records
+func (t Transformer) CreatesTokens() bool {
 	return t.TransformType == transformTypeTokenizeByValue ||
 		t.TransformType == transformTypeTokenizeByReference
 }
 
+// IsFormatPreservingEncryption returns whether the transformer is a native format preserving encryption transformer,
+// whose outputs are decrypted rather than resolved from token records
+func (t Transformer) IsFormatPreservingEncryption() bool {
+	return t.TransformType == transformTypeFormatPreservingEncryption
+}
+
 // ValidateProvisioningUpdate ensures that no disallowed properties of a transformer have changed
 func (t Transformer) ValidateProvisioningUpdate(updated Transformer) error {
 	// TODO: this is necessary until we unify transformer update logic between
@@ -750,6 +804,16 @@ func (t Transformer) ToClientModel() policy.Transformer {
 }
 
 func (t Transformer) extraValidate() error {
+	if t.IsFormatPreservingEncryption() {
+		if t.Function != "" {
+			return ucerr.Friendlyf(nil, "format preserving encryption transformers can't have a function")
+		}
+		if _, err := policy.NewFormatPreservingEncryptionParameters(t.Parameters); err != nil {
+			return ucerr.Wrap(err)
+		}
+		return nil
+	}
+
 	if err := validateJSScript("Transformer", t.Function, auditlog.TransformerCustom); err != nil {
 		return ucerr.Wrap(err)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/lib/pq"
@@ -11,6 +12,7 @@ import (
 	"userclouds.com/idp/userstore"
 	"userclouds.com/infra/pagination"
 	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
 	"userclouds.com/infra/uctypes/set"
 	"userclouds.com/infra/uctypes/uuidarray"
 )
@@ -532,7 +534,7 @@ func (s Storage) CheckTransformerUnused(ctx context.Context, id uuid.UUID) error
 
 // GetTokenRecordByToken looks up a token record by the actual token, used for resolve
 func (s Storage) GetTokenRecordByToken(ctx context.Context, token string) (*TokenRecord, error) {
-	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id FROM token_records WHERE token=$1 AND deleted='0001-01-01 00:00:00';`
+	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id, expires_at FROM token_records WHERE token=$1 AND deleted='0001-01-01 00:00:00';`
 
 	var tr TokenRecord
 	if err := s.db.GetContext(ctx, "GetTokenRecordByToken", &tr, q, token); err != nil {
@@ -543,7 +545,7 @@ func (s Storage) GetTokenRecordByToken(ctx context.Context, token string) (*Toke
 
 // ListTokenRecordsByTokens looks up token records by the actual tokens, used for resolve
 func (s Storage) ListTokenRecordsByTokens(ctx context.Context, tokens []string) ([]TokenRecord, error) {
-	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id FROM token_records WHERE deleted='0001-01-01 00:00:00' AND token = ANY ($1)`
+	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id, expires_at FROM token_records WHERE deleted='0001-01-01 00:00:00' AND token = ANY ($1)`
 
 	var trs []TokenRecord
 
@@ -554,29 +556,56 @@ func (s Storage) ListTokenRecordsByTokens(ctx context.Context, tokens []string)
 	return trs, nil
 }
 
-// ListTokenRecordsByDataAndPolicy looks up a token record by the data, transformer, and access policy id
+// ListTokenRecordsByDataAndPolicy looks up the unexpired token records for the data, transformer, and access policy id
 func (s Storage) ListTokenRecordsByDataAndPolicy(ctx context.Context, data string, transformerID, accessPolicyID uuid.UUID) ([]TokenRecord, error) {
-	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id FROM token_records WHERE data=$1 AND transformer_id=$2 AND access_policy_id=$3 AND deleted='0001-01-01 00:00:00';`
+	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id, expires_at FROM token_records WHERE data=$1 AND transformer_id=$2 AND access_policy_id=$3 AND (expires_at='0001-01-01 00:00:00' OR expires_at>$4) AND deleted='0001-01-01 00:00:00';`
 
 	var trs []TokenRecord
-	if err := s.db.SelectContext(ctx, "ListTokenRecordsByDataAndPolicy", &trs, q, data, transformerID, accessPolicyID); err != nil {
+	if err := s.db.SelectContext(ctx, "ListTokenRecordsByDataAndPolicy", &trs, q, data, transformerID, accessPolicyID, time.Now().UTC()); err != nil {
 		return nil, ucerr.Wrap(err)
 	}
 	return trs, nil
 }
 
-// ListTokenRecordsByDataProvenanceAndPolicy looks up a token record by the userid/columnid reference, transformer, and access policy id
+// ListTokenRecordsByDataProvenanceAndPolicy looks up the unexpired token records for the userid/columnid reference, transformer, and access policy id
 func (s Storage) ListTokenRecordsByDataProvenanceAndPolicy(ctx context.Context, userID uuid.UUID, columnID uuid.UUID, transformerID, accessPolicyID uuid.UUID) ([]TokenRecord, error) {
-	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id FROM token_records WHERE data=$1 AND user_id=$2 AND column_id=$3 AND transformer_id=$4 AND access_policy_id=$5 AND deleted='0001-01-01 00:00:00';`
+	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id, expires_at FROM token_records WHERE data=$1 AND user_id=$2 AND column_id=$3 AND transformer_id=$4 AND access_policy_id=$5 AND (expires_at='0001-01-01 00:00:00' OR expires_at>$6) AND deleted='0001-01-01 00:00:00';`
 
 	var trs []TokenRecord
-	if err := s.db.SelectContext(ctx, "ListTokenRecordsByDataProvenanceAndPolicy", &trs, q, "", userID, columnID, transformerID, accessPolicyID); err != nil {
+	if err := s.db.SelectContext(ctx, "ListTokenRecordsByDataProvenanceAndPolicy", &trs, q, "", userID, columnID, transformerID, accessPolicyID, time.Now().UTC()); err != nil {
 		return nil, ucerr.Wrap(err)
 	}
 	return trs, nil
 }
 
-// BatchListTokensByDataAndPolicy looks up a tokens by the data, transformers, and access policy ids
+// ListTokenRecordsByUserID looks up all of the live token records that were created by reference to one of a user's values
+func (s Storage) ListTokenRecordsByUserID(ctx context.Context, userID uuid.UUID) ([]TokenRecord, error) {
+	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id, expires_at FROM token_records WHERE user_id=$1 AND deleted='0001-01-01 00:00:00' ORDER BY created;`
+
+	var trs []TokenRecord
+	if err := s.db.SelectContext(ctx, "ListTokenRecordsByUserID", &trs, q, userID); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return trs, nil
+}
+
+// EraseTokenRecordsByUserID permanently deletes every token record (including revoked ones) that was created by
+// reference to one of a user's values, and returns the number of records deleted
+func (s Storage) EraseTokenRecordsByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	const q = "DELETE FROM token_records WHERE user_id=$1;"
+
+	res, err := s.db.ExecContext(ctx, "EraseTokenRecordsByUserID", q, userID)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return int(ra), nil
+}
+
+// BatchListTokensByDataAndPolicy looks up unexpired tokens by the data, transformers, and access policy ids
 func (s Storage) BatchListTokensByDataAndPolicy(ctx context.Context, data []string, transformerIDs, accessPolicyIDs []uuid.UUID) ([]string, error) {
 	if len(data) != len(transformerIDs) || len(data) != len(accessPolicyIDs) {
 		return nil, ucerr.Errorf("length of data, transformerIDs, and accessPolicyIDs must match")
@@ -587,10 +616,10 @@ func (s Storage) BatchListTokensByDataAndPolicy(ctx context.Context, data []stri
 	uniqueTransformerIDs := set.NewUUIDSet(transformerIDs...)
 
 	// Note this query sorts in reverse order of creation time so that we always return the last token created for a given data/transformer/access policy
-	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id FROM token_records WHERE data=ANY($1) AND transformer_id=ANY($2) AND access_policy_id=ANY($3) AND deleted='0001-01-01 00:00:00' ORDER BY created DESC;`
+	const q = `SELECT id, created, updated, deleted, data, token, transformer_id, transformer_version, access_policy_id, user_id, column_id, expires_at FROM token_records WHERE data=ANY($1) AND transformer_id=ANY($2) AND access_policy_id=ANY($3) AND (expires_at='0001-01-01 00:00:00' OR expires_at>$4) AND deleted='0001-01-01 00:00:00' ORDER BY created DESC;`
 
 	var trs []TokenRecord
-	if err := s.db.SelectContext(ctx, "BatchListTokenRecordsByDataAndPolicy", &trs, q, pq.Array(uniqueNames.Items()), pq.Array(uniqueTransformerIDs.Items()), pq.Array(uniqueAccessPolicyIDs.Items())); err != nil {
+	if err := s.db.SelectContext(ctx, "BatchListTokenRecordsByDataAndPolicy", &trs, q, pq.Array(uniqueNames.Items()), pq.Array(uniqueTransformerIDs.Items()), pq.Array(uniqueAccessPolicyIDs.Items()), time.Now().UTC()); err != nil {
 		return nil, ucerr.Wrap(err)
 	}
 
@@ -607,6 +636,85 @@ func (s Storage) BatchListTokensByDataAndPolicy(ctx context.Context, data []stri
 	return tokens, nil
 }
 
+// RevokeTokenRecords soft-deletes all live token records created with the given transformer and/or access policy,
+// either of which may be nil to match any, and returns the number of tokens revoked
+func (s Storage) RevokeTokenRecords(ctx context.Context, transformerID, accessPolicyID uuid.UUID) (int, error) {
+	if transformerID.IsNil() && accessPolicyID.IsNil() {
+		return 0, ucerr.New("either transformerID or accessPolicyID must be specified")
+	}
+
+	const q = `UPDATE token_records SET deleted=CLOCK_TIMESTAMP() WHERE ($1='00000000-0000-0000-0000-000000000000'::UUID OR transformer_id=$1) AND ($2='00000000-0000-0000-0000-000000000000'::UUID OR access_policy_id=$2) AND deleted='0001-01-01 00:00:00';`
+
+	res, err := s.db.ExecContext(ctx, "RevokeTokenRecords", q, transformerID, accessPolicyID)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return int(ra), nil
+}
+
+// CleanExpiredTokenRecords will look for token records that have expired, evaluating up to maxCandidates
+// records and only actually deleting them if dryRun is false
+func (s Storage) CleanExpiredTokenRecords(ctx context.Context, maxCandidates int, dryRun bool) error {
+	if maxCandidates < 1 {
+		return ucerr.Errorf("maxCandidates must be greater than or equal to one: %d", maxCandidates)
+	}
+
+	// tokens that never expire have a zero expires_at, so exclude those
+	pager, err := NewTokenRecordPaginatorFromOptions(
+		pagination.Limit(min(maxCandidates, pagination.MaxLimit)),
+		pagination.Filter(fmt.Sprintf("(('expires_at',GT,'%d'),AND,('expires_at',LE,'%d'))", time.Time{}.UnixMicro(), time.Now().UTC().UnixMicro())),
+	)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	numCandidates := 0
+
+	uclog.Infof(ctx, "evaluating up to %d expired token records", maxCandidates)
+
+	for {
+		trs, respFields, err := s.ListTokenRecordsPaginated(ctx, *pager)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		for _, tr := range trs {
+			if err := s.cleanExpiredTokenRecord(ctx, tr, dryRun); err != nil {
+				return ucerr.Wrap(err)
+			}
+
+			numCandidates++
+			if numCandidates == maxCandidates {
+				return nil
+			}
+		}
+
+		if !pager.AdvanceCursor(*respFields) {
+			return nil
+		}
+	}
+}
+
+func (s Storage) cleanExpiredTokenRecord(ctx context.Context, tr TokenRecord, dryRun bool) error {
+	if dryRun {
+		uclog.Infof(ctx, "would delete token record '%v' which expired at %v", tr.ID, tr.ExpiresAt)
+		return nil
+	}
+
+	// expired tokens can never be resolved again, so there's no reason to keep a soft-deleted copy of the data around
+	const q = "DELETE FROM token_records WHERE id=$1;"
+	if _, err := s.db.ExecContext(ctx, "CleanExpiredTokenRecord", q, tr.ID); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "deleted token record '%v' which expired at %v", tr.ID, tr.ExpiresAt)
+	return nil
+}
+
 // GetSecretByName is used for retrieving a secret by name
 func (s Storage) GetSecretByName(ctx context.Context, name string) (*Secret, error) {
 	const q = `SELECT created, deleted, id, name, updated, value FROM policy_secrets WHERE LOWER(name)=LOWER($1) AND deleted='0001-01-01 00:00:00';`
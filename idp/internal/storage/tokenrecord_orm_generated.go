@@ -35,7 +35,7 @@ func (s *Storage) IsTokenRecordSoftDeleted(ctx context.Context, id uuid.UUID) (b
 
 // GetTokenRecord loads a TokenRecord by ID
 func (s *Storage) GetTokenRecord(ctx context.Context, id uuid.UUID) (*TokenRecord, error) {
-	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, created FROM token_records WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at, created FROM token_records WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
 
 	var obj TokenRecord
 	if err := s.db.GetContext(ctx, "GetTokenRecord", &obj, q, id); err != nil {
@@ -49,7 +49,7 @@ func (s *Storage) GetTokenRecord(ctx context.Context, id uuid.UUID) (*TokenRecor
 
 // GetTokenRecordSoftDeleted loads a TokenRecord by ID iff it's soft-deleted
 func (s *Storage) GetTokenRecordSoftDeleted(ctx context.Context, id uuid.UUID) (*TokenRecord, error) {
-	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, created FROM token_records WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at, created FROM token_records WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
 
 	var obj TokenRecord
 	if err := s.db.GetContext(ctx, "GetTokenRecordSoftDeleted", &obj, q, id); err != nil {
@@ -81,7 +81,7 @@ func (s *Storage) GetTokenRecordsForIDs(ctx context.Context, errorOnMissing bool
 
 // getTokenRecordsHelperForIDs loads multiple TokenRecord for a given list of IDs from the DB
 func (s *Storage) getTokenRecordsHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]TokenRecord, error) {
-	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, created FROM token_records WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at, created FROM token_records WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
 	var objects []TokenRecord
 	if err := s.db.SelectContextWithDirty(ctx, "GetTokenRecordsForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -113,7 +113,7 @@ func (s *Storage) listInnerTokenRecordsPaginated(ctx context.Context, p paginati
 
 	// the inner query requires an alias for postgres, so we always call it tmp
 	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
-	q := fmt.Sprintf("SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, created FROM (SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, created FROM token_records WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+	q := fmt.Sprintf("SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at, created FROM (SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at, created FROM token_records WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
 
 	var objsDB []TokenRecord
 	if err := s.db.SelectContext(ctx, "ListTokenRecordsPaginated", &objsDB, q, queryFields...); err != nil {
@@ -137,7 +137,7 @@ func (s *Storage) listInnerTokenRecordsPaginated(ctx context.Context, p paginati
 
 // ListTokenRecordsForUserID loads the list of TokenRecords with a matching UserID field
 func (s *Storage) ListTokenRecordsForUserID(ctx context.Context, userID uuid.UUID) ([]TokenRecord, error) {
-	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, created FROM token_records WHERE user_id=$1 AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at, created FROM token_records WHERE user_id=$1 AND deleted='0001-01-01 00:00:00';"
 	var objs []TokenRecord
 	if err := s.db.SelectContext(ctx, "ListTokenRecordsForUserID", &objs, q, userID); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -155,8 +155,8 @@ func (s *Storage) SaveTokenRecord(ctx context.Context, obj *TokenRecord) error {
 
 // SaveTokenRecord saves a TokenRecord
 func (s *Storage) saveInnerTokenRecord(ctx context.Context, obj *TokenRecord) error {
-	const q = "INSERT INTO token_records (id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, data = $3, token = $4, user_id = $5, column_id = $6, transformer_id = $7, transformer_version = $8, access_policy_id = $9 WHERE (token_records.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
-	if err := s.db.GetContext(ctx, "SaveTokenRecord", obj, q, obj.ID, obj.Deleted, obj.Data, obj.Token, obj.UserID, obj.ColumnID, obj.TransformerID, obj.TransformerVersion, obj.AccessPolicyID); err != nil {
+	const q = "INSERT INTO token_records (id, updated, deleted, data, token, user_id, column_id, transformer_id, transformer_version, access_policy_id, expires_at) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, data = $3, token = $4, user_id = $5, column_id = $6, transformer_id = $7, transformer_version = $8, access_policy_id = $9, expires_at = $10 WHERE (token_records.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveTokenRecord", obj, q, obj.ID, obj.Deleted, obj.Data, obj.Token, obj.UserID, obj.ColumnID, obj.TransformerID, obj.TransformerVersion, obj.AccessPolicyID, obj.ExpiresAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ucerr.Friendlyf(err, "TokenRecord %v not found", obj.ID)
 		}
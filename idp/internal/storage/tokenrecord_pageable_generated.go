@@ -20,7 +20,8 @@ func (o TokenRecord) GetCursor(k pagination.Key) pagination.Cursor {
 
 // GetPaginationKeys is part of the pagination.PageableType interface
 func (o TokenRecord) GetPaginationKeys() pagination.KeyTypes {
-	keyTypes := pagination.KeyTypes{}
+	// .getPaginationKeys() lets you add additional supported pagination keys
+	keyTypes := o.getPaginationKeys()
 	keyTypes["id"] = pagination.UUIDKeyType
 	return keyTypes
 }
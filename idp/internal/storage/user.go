@@ -27,7 +27,9 @@ type BaseUser struct {
 
 func (BaseUser) getPaginationKeys() pagination.KeyTypes {
 	return pagination.KeyTypes{
+		"created":         pagination.TimestampKeyType,
 		"organization_id": pagination.UUIDKeyType,
+		"updated":         pagination.TimestampKeyType,
 	}
 }
 
@@ -666,6 +666,49 @@ func (s *UserStorage) DeleteUserColumnSoftDeletedValues(ctx context.Context, val
 	return nil
 }
 
+// EraseExpiredSoftDeletedValuesForUser permanently deletes the soft-deleted values of a user whose retention
+// timeouts have all passed, returning the number of values deleted and the latest retention timeout of the
+// values that must still be retained (or the zero time if none are)
+func (s *UserStorage) EraseExpiredSoftDeletedValuesForUser(ctx context.Context, userID uuid.UUID, now time.Time) (int, time.Time, error) {
+	const q = "SELECT id, retention_timeouts FROM user_column_post_delete_values WHERE user_id=$1; /* lint-sql-select-partial-columns */"
+
+	var values []struct {
+		ID                uuid.UUID                     `db:"id"`
+		RetentionTimeouts timestamparray.TimestampArray `db:"retention_timeouts"`
+	}
+	if err := s.db.SelectContext(ctx, "EraseExpiredSoftDeletedValuesForUser", &values, q, userID); err != nil {
+		return 0, time.Time{}, ucerr.Wrap(err)
+	}
+
+	var expiredIDs uuidarray.UUIDArray
+	var retainedUntil time.Time
+	for _, value := range values {
+		var latest time.Time
+		for _, rt := range value.RetentionTimeouts {
+			if rt.After(latest) {
+				latest = rt
+			}
+		}
+
+		if latest.After(now) {
+			if latest.After(retainedUntil) {
+				retainedUntil = latest
+			}
+		} else {
+			expiredIDs = append(expiredIDs, value.ID)
+		}
+	}
+
+	if len(expiredIDs) > 0 {
+		const dq = "DELETE FROM user_column_post_delete_values WHERE id = ANY($1);"
+		if _, err := s.db.ExecContext(ctx, "EraseExpiredSoftDeletedValuesForUser", dq, expiredIDs); err != nil {
+			return 0, time.Time{}, ucerr.Wrap(err)
+		}
+	}
+
+	return len(expiredIDs), retainedUntil, nil
+}
+
 const insertUserColumnSoftDeletedValuesQueryTemplate = `
 /* lint-sql-ok */
 INSERT INTO user_column_post_delete_values (
@@ -0,0 +1,191 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uctypes/set"
+)
+
+// IsUserErasureSoftDeleted returns true if the id is associated with a soft-deleted row but no undeleted rows
+func (s *Storage) IsUserErasureSoftDeleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	const q = "/* lint-sql-ok */ SELECT deleted FROM user_erasures WHERE id=$1 ORDER By deleted LIMIT 1;"
+
+	var deleted time.Time
+	if err := s.db.GetContext(ctx, "IsUserErasureSoftDeleted", &deleted, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, ucerr.Wrap(err)
+	}
+
+	return !deleted.IsZero(), nil
+}
+
+// GetUserErasure loads a UserErasure by ID
+func (s *Storage) GetUserErasure(ctx context.Context, id uuid.UUID) (*UserErasure, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at, created FROM user_erasures WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+
+	var obj UserErasure
+	if err := s.db.GetContext(ctx, "GetUserErasure", &obj, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Friendlyf(err, "UserErasure %v not found", id)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+	return &obj, nil
+}
+
+// GetUserErasureSoftDeleted loads a UserErasure by ID iff it's soft-deleted
+func (s *Storage) GetUserErasureSoftDeleted(ctx context.Context, id uuid.UUID) (*UserErasure, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at, created FROM user_erasures WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+
+	var obj UserErasure
+	if err := s.db.GetContext(ctx, "GetUserErasureSoftDeleted", &obj, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Friendlyf(err, "soft-deleted UserErasure %v not found", id)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &obj, nil
+}
+
+// GetUserErasuresForIDs loads multiple UserErasure for a given list of IDs
+func (s *Storage) GetUserErasuresForIDs(ctx context.Context, errorOnMissing bool, ids ...uuid.UUID) ([]UserErasure, error) {
+	items := make([]UserErasure, 0, len(ids))
+
+	missed := set.NewUUIDSet(ids...) // Assume we will miss all keys, and remove from this list if we get them from the cache
+	dirty := true
+	if missed.Size() > 0 {
+		itemsFromDB, err := s.getUserErasuresHelperForIDs(ctx, dirty, true, missed.Items()...)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		items = append(items, itemsFromDB...)
+	}
+
+	return items, nil
+}
+
+// getUserErasuresHelperForIDs loads multiple UserErasure for a given list of IDs from the DB
+func (s *Storage) getUserErasuresHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]UserErasure, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at, created FROM user_erasures WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	var objects []UserErasure
+	if err := s.db.SelectContextWithDirty(ctx, "GetUserErasuresForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if errorOnMissing && len(ids) != len(objects) {
+		requestedIDs := set.NewUUIDSet(ids...)
+		loadedIDs := set.NewUUIDSet()
+		for _, obj := range objects {
+			loadedIDs.Insert(obj.ID)
+		}
+		missingIDs := requestedIDs.Difference(loadedIDs)
+		return nil, ucerr.Friendlyf(nil, "Not all requested UserErasures  were loaded. requested: %v loaded: %v missing: [%v]", len(ids), len(objects), missingIDs)
+	}
+	return objects, nil
+}
+
+// ListUserErasuresPaginated loads a paginated list of UserErasures for the specified paginator settings
+func (s *Storage) ListUserErasuresPaginated(ctx context.Context, p pagination.Paginator) ([]UserErasure, *pagination.ResponseFields, error) {
+	return s.listInnerUserErasuresPaginated(ctx, p, false)
+}
+
+// listInnerUserErasuresPaginated loads a paginated list of UserErasures for the specified paginator settings
+func (s *Storage) listInnerUserErasuresPaginated(ctx context.Context, p pagination.Paginator, forceDBRead bool) ([]UserErasure, *pagination.ResponseFields, error) {
+	queryFields, err := p.GetQueryFields()
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	// the inner query requires an alias for postgres, so we always call it tmp
+	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
+	q := fmt.Sprintf("SELECT id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at, created FROM (SELECT id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at, created FROM user_erasures WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+
+	var objsDB []UserErasure
+	if err := s.db.SelectContext(ctx, "ListUserErasuresPaginated", &objsDB, q, queryFields...); err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	objs, respFields := pagination.ProcessResults(objsDB, p.GetCursor(), p.GetLimit(), p.IsForward(), p.GetSortKey())
+	if respFields.HasNext {
+		if err := p.ValidateCursor(respFields.Next); err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+	}
+
+	if respFields.HasPrev {
+		if err := p.ValidateCursor(respFields.Prev); err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+	}
+
+	return objs, &respFields, nil
+}
+
+// ListUserErasuresForUserID loads the list of UserErasures with a matching UserID field
+func (s *Storage) ListUserErasuresForUserID(ctx context.Context, userID uuid.UUID) ([]UserErasure, error) {
+	const q = "SELECT id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at, created FROM user_erasures WHERE user_id=$1 AND deleted='0001-01-01 00:00:00';"
+	var objs []UserErasure
+	if err := s.db.SelectContext(ctx, "ListUserErasuresForUserID", &objs, q, userID); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return objs, nil
+}
+
+// SaveUserErasure saves a UserErasure
+func (s *Storage) SaveUserErasure(ctx context.Context, obj *UserErasure) error {
+	if err := obj.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return ucerr.Wrap(s.saveInnerUserErasure(ctx, obj))
+}
+
+// SaveUserErasure saves a UserErasure
+func (s *Storage) saveInnerUserErasure(ctx context.Context, obj *UserErasure) error {
+	const q = "INSERT INTO user_erasures (id, updated, deleted, user_id, status, systems, user_event_aliases, certificate, completed_at) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, user_id = $3, status = $4, systems = $5, user_event_aliases = $6, certificate = $7, completed_at = $8 WHERE (user_erasures.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveUserErasure", obj, q, obj.ID, obj.Deleted, obj.UserID, obj.Status, obj.Systems, obj.UserEventAliases, obj.Certificate, obj.CompletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ucerr.Friendlyf(err, "UserErasure %v not found", obj.ID)
+		}
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteUserErasure soft-deletes a UserErasure which is currently alive
+// Note that this will fail on an already-deleted object (since we don't want to re-delete
+// tombstoned objects and corrupt the deletion timestamp)
+func (s *Storage) DeleteUserErasure(ctx context.Context, objID uuid.UUID) error {
+	return ucerr.Wrap(s.deleteInnerUserErasure(ctx, objID, false))
+}
+
+// deleteInnerUserErasure soft-deletes a UserErasure which is currently alive
+func (s *Storage) deleteInnerUserErasure(ctx context.Context, objID uuid.UUID, wrappedDelete bool) error {
+	const q = "UPDATE user_erasures SET deleted=CLOCK_TIMESTAMP() WHERE id=$1 AND deleted='0001-01-01 00:00:00' RETURNING deleted;"
+	res, err := s.db.ExecContext(ctx, "DeleteUserErasure", q, objID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Errorf("Error deleting UserErasure %v: %w", objID, err)
+	}
+	if ra == 0 {
+		// we wrap sql.ErrNoRows here to be consistent
+		return ucerr.Friendlyf(sql.ErrNoRows, "UserErasure %v not found", objID)
+	}
+	return nil
+}
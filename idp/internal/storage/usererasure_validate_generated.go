@@ -0,0 +1,23 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o UserErasure) Validate() error {
+	if err := o.UserBaseModel.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.Status == "" {
+		return ucerr.Friendlyf(nil, "UserErasure.Status (%v) can't be empty", o.ID)
+	}
+	for _, item := range o.Systems {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	return nil
+}
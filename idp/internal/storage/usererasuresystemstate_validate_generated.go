@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o UserErasureSystemState) Validate() error {
+	if o.System == "" {
+		return ucerr.Friendlyf(nil, "UserErasureSystemState.System can't be empty")
+	}
+	if o.Status == "" {
+		return ucerr.Friendlyf(nil, "UserErasureSystemState.Status can't be empty")
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"slices"
 	"sort"
@@ -155,11 +156,16 @@ type runAcrossRegionsOutput struct {
 
 	getBaseUserOutput         getBaseUserOutput
 	listBaseUsersOutput       listBaseUsersOutput
+	countBaseUsersOutput      int
 	getUserOutput             getUserOutput
 	getAllUserValuesOutput    getAllUserValuesOutput
 	getUsersForSelectorOutput getUsersForSelectorOutput
 	deleteUserOutput          deleteUserOutput
 	listUsersForEmailOutput   listUsersForEmailOutput
+
+	eraseExpiredSoftDeletedValuesOutput eraseExpiredSoftDeletedValuesOutput
+	getDSARExportOutput                 getDSARExportOutput
+	dsarExportCountOutput               int
 }
 
 func (umrs *UserMultiRegionStorage) runAcrossRegions(ctx context.Context, f func(context.Context, *UserStorage, *runAcrossRegionsOutput) (int, error), out *runAcrossRegionsOutput) (int, error) {
@@ -456,6 +462,40 @@ func (umrs *UserMultiRegionStorage) CleanupUsers(ctx context.Context, cm *Column
 	return code, ucerr.Wrap(err)
 }
 
+type eraseExpiredSoftDeletedValuesOutput struct {
+	deletedCount  int
+	retainedUntil time.Time
+}
+
+// EraseExpiredSoftDeletedValuesForUser permanently deletes the soft-deleted values of a user that are past their
+// retention timeouts in every region, returning the number of values deleted and when the remaining ones expire
+func (umrs *UserMultiRegionStorage) EraseExpiredSoftDeletedValuesForUser(ctx context.Context, userID uuid.UUID, now time.Time) (int, time.Time, error) {
+	out := runAcrossRegionsOutput{
+		mutex: &sync.Mutex{},
+	}
+
+	_, err := umrs.runAcrossRegions(ctx, func(ctx context.Context, s *UserStorage, out *runAcrossRegionsOutput) (int, error) {
+		deletedCount, retainedUntil, err := s.EraseExpiredSoftDeletedValuesForUser(ctx, userID, now)
+		if err != nil {
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+
+		out.mutex.Lock()
+		defer out.mutex.Unlock()
+		out.eraseExpiredSoftDeletedValuesOutput.deletedCount += deletedCount
+		if retainedUntil.After(out.eraseExpiredSoftDeletedValuesOutput.retainedUntil) {
+			out.eraseExpiredSoftDeletedValuesOutput.retainedUntil = retainedUntil
+		}
+
+		return http.StatusOK, nil
+	}, &out)
+	if err != nil {
+		return 0, time.Time{}, ucerr.Wrap(err)
+	}
+
+	return out.eraseExpiredSoftDeletedValuesOutput.deletedCount, out.eraseExpiredSoftDeletedValuesOutput.retainedUntil, nil
+}
+
 type listBaseUsersOutput struct {
 	baseUsers      map[region.DataRegion][]BaseUser
 	responseFields map[region.DataRegion]*pagination.ResponseFields
@@ -544,6 +584,51 @@ func (umrs *UserMultiRegionStorage) ListBaseUsersPaginated(ctx context.Context,
 	return retUsers, &respFields, ucerr.Wrap(err)
 }
 
+// countBaseUsers returns the number of users matching the paginator's filter, which must be at the start
+// (since the cursor is part of the where clause)
+func (s *UserStorage) countBaseUsers(ctx context.Context, p pagination.Paginator, accessPrimaryDBOnly bool) (int, error) {
+	if !p.IsInitialQuery() {
+		return 0, ucerr.New("can only count users from the start of the list")
+	}
+
+	queryFields, err := p.GetQueryFields()
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	q := fmt.Sprintf("SELECT COUNT(*) FROM users WHERE deleted='0001-01-01 00:00:00' %s;", p.GetWhereClause())
+
+	var count int
+	useReplica := featureflags.IsEnabledGlobally(ctx, featureflags.ReadFromReadReplica)
+	if err := s.db.GetContextWithDirty(ctx, "CountBaseUsers", &count, q, accessPrimaryDBOnly || !useReplica, queryFields...); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return count, nil
+}
+
+// CountBaseUsers returns the number of users in all regions matching the paginator's filter, so callers
+// can report a total without listing every user
+func (umrs *UserMultiRegionStorage) CountBaseUsers(ctx context.Context, p pagination.Paginator, accessPrimaryDBOnly bool) (int, error) {
+	out := runAcrossRegionsOutput{mutex: &sync.Mutex{}}
+
+	if _, err := umrs.runAcrossRegions(ctx, func(ctx context.Context, s *UserStorage, out *runAcrossRegionsOutput) (int, error) {
+		count, err := s.countBaseUsers(ctx, p, accessPrimaryDBOnly)
+		if err != nil {
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+
+		out.mutex.Lock()
+		defer out.mutex.Unlock()
+		out.countBaseUsersOutput += count
+
+		return http.StatusOK, nil
+	}, &out); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	return out.countBaseUsersOutput, nil
+}
+
 type listUsersForEmailOutput struct {
 	users map[region.DataRegion][]BaseUser
 }
@@ -670,7 +670,7 @@ func ExecuteAccessPolicy(
 	}
 	defer apte.cleanup()
 
-	allowed, err := executeAccessPolicy(ctx, ap, accessPolicyContext, apte, s)
+	allowed, err := executeAccessPolicy(ctx, ap, accessPolicyContext, apte, s, nil)
 	if err != nil {
 		return false, apte.getConsoleOutput(), ucerr.Wrap(err)
 	}
@@ -678,12 +678,36 @@ func ExecuteAccessPolicy(
 	return allowed, apte.getConsoleOutput(), nil
 }
 
+// ExplainAccessPolicy executes an access policy like ExecuteAccessPolicy, but also returns the evaluation of every
+// access policy and template that was executed along the way
+func ExplainAccessPolicy(
+	ctx context.Context,
+	ap *policy.AccessPolicy,
+	accessPolicyContext policy.AccessPolicyContext,
+	authzClient *authz.Client,
+	s *storage.Storage,
+) (*idp.AccessPolicyEvaluation, error) {
+	apte, err := newAccessPolicyTemplateExecutor(authzClient, s)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	defer apte.cleanup()
+
+	eval := &idp.AccessPolicyEvaluation{}
+	if _, err := executeAccessPolicy(ctx, ap, accessPolicyContext, apte, s, eval); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return eval, nil
+}
+
 func executeAccessPolicy(
 	ctx context.Context,
 	ap *policy.AccessPolicy,
 	accessPolicyContext policy.AccessPolicyContext,
 	apte *accessPolicyTemplateExecutor,
 	s *storage.Storage,
+	eval *idp.AccessPolicyEvaluation,
 ) (bool, error) {
 	// Request may contain PII so don't log it in prod
 	uclog.DebugfPII(ctx, "executing access policy: %+v", ap)
@@ -691,6 +715,14 @@ func executeAccessPolicy(
 	start := time.Now().UTC()
 	defer logAPDuration(ctx, ap.ID, ap.Version, start)
 
+	// when explaining, record the evaluation of each component as it is executed
+	if eval != nil {
+		eval.AccessPolicyID = ap.ID
+		defer func() {
+			eval.DurationMicroseconds = time.Since(start).Microseconds()
+		}()
+	}
+
 	if accessPolicyContext.Client == nil {
 		accessPolicyContext.Client = policy.ClientContext{}
 	}
@@ -709,11 +741,20 @@ func executeAccessPolicy(
 				return false, ucerr.Wrap(err)
 			}
 
-			allowed, err = executeAccessPolicy(ctx, p.ToClientModel(), accessPolicyContext, apte, s)
+			var componentEval *idp.AccessPolicyEvaluation
+			if eval != nil {
+				componentEval = &idp.AccessPolicyEvaluation{}
+			}
+
+			allowed, err = executeAccessPolicy(ctx, p.ToClientModel(), accessPolicyContext, apte, s, componentEval)
 			if err != nil {
 				return false, ucerr.Wrap(err)
 			}
 
+			if eval != nil {
+				eval.Components = append(eval.Components, *componentEval)
+			}
+
 		} else if component.Template != nil {
 
 			template, err := s.GetLatestAccessPolicyTemplate(ctx, component.Template.ID)
@@ -721,11 +762,23 @@ func executeAccessPolicy(
 				return false, ucerr.Wrap(err)
 			}
 
+			templateStart := time.Now().UTC()
+			consoleStart := len(apte.getConsoleOutput())
+
 			allowed, err = apte.execute(ctx, template, accessPolicyContext, string(bs), component.TemplateParameters)
 			if err != nil {
 				return false, ucerr.Wrap(err)
 			}
 
+			if eval != nil {
+				eval.Components = append(eval.Components, idp.AccessPolicyEvaluation{
+					AccessPolicyTemplateID: template.ID,
+					Allowed:                allowed,
+					Console:                apte.getConsoleOutput()[consoleStart:],
+					DurationMicroseconds:   time.Since(templateStart).Microseconds(),
+				})
+			}
+
 		} else {
 			logAPError(ctx, ap.ID, ap.Version)
 			return false, ucerr.Errorf("unknown component type: %v", component)
@@ -746,5 +799,8 @@ func executeAccessPolicy(
 	}
 
 	logAPResult(ctx, ap.ID, ap.Version, allowed)
+	if eval != nil {
+		eval.Allowed = allowed
+	}
 	return allowed, nil
 }
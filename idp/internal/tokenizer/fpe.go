@@ -0,0 +1,81 @@
+package tokenizer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/idp/policy"
+	"userclouds.com/infra/crypto/fpe"
+	"userclouds.com/infra/ucerr"
+)
+
+// formatPreservingCipher encrypts and decrypts values for a format preserving encryption transformer and
+// token access policy. Since the access policy ID is part of the tweak, a value encrypted for one access
+// policy can't be decrypted by evaluating another.
+type formatPreservingCipher struct {
+	cipher *fpe.Cipher
+	tweak  []byte
+}
+
+func newFormatPreservingCipher(
+	ctx context.Context,
+	s *storage.Storage,
+	transformer *storage.Transformer,
+	tokenAccessPolicyID uuid.UUID,
+) (*formatPreservingCipher, error) {
+	params, err := policy.NewFormatPreservingEncryptionParameters(transformer.Parameters)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	keyHex, err := newPolicySecretResolver(s).ResolveSecret(ctx, params.KeySecret)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, ucerr.Friendlyf(err, "format preserving encryption key secret '%s' must be hex-encoded", params.KeySecret)
+	}
+
+	// the tweak was validated along with the parameters
+	tweak, _ := hex.DecodeString(params.Tweak)
+	tweak = append(tweak, tokenAccessPolicyID.Bytes()...)
+
+	var c *fpe.Cipher
+	switch params.Algorithm {
+	case policy.FormatPreservingEncryptionAlgorithmFF1:
+		c, err = fpe.NewFF1(key, params.Alphabet)
+	case policy.FormatPreservingEncryptionAlgorithmFF31:
+		// FF3-1 tweaks have a fixed size, so we hash the configured tweak and access policy ID down to it
+		hash := sha256.Sum256(tweak)
+		tweak = hash[:fpe.FF31TweakSize]
+		c, err = fpe.NewFF31(key, params.Alphabet)
+	default:
+		return nil, ucerr.Friendlyf(nil, "unsupported format preserving encryption algorithm '%s'", params.Algorithm)
+	}
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &formatPreservingCipher{cipher: c, tweak: tweak}, nil
+}
+
+func (fpc formatPreservingCipher) encrypt(data string) (string, error) {
+	token, err := fpc.cipher.Encrypt(data, fpc.tweak)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return token, nil
+}
+
+func (fpc formatPreservingCipher) decrypt(token string) (string, error) {
+	data, err := fpc.cipher.Decrypt(token, fpc.tweak)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return data, nil
+}
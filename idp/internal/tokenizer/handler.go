@@ -42,7 +42,7 @@ func NewHandler(m2mAuth jsonclient.Option, consoleTenantInfo companyconfig.Tenan
 	return hb.Build(), nil
 }
 
-//go:generate genhandler /tokenizer POST,inspectToken,/tokens/actions/inspect POST,lookupTokens,/tokens/actions/lookup POST,lookupOrCreateTokens,/tokens/actions/lookuporcreate POST,resolveToken,/tokens/actions/resolve method,Token,/tokens collection,AccessPolicyTemplate,h.newAccessPolicyTemplateAuthorizer(),/policies/accesstemplate collection,AccessPolicy,h.newTokenizerAuthorizer(),/policies/access collection,Transformer,h.newTokenizerAuthorizer(),/policies/transformation method,Token,/tokens collection,Secret,h.newSecretAuthorizer(),/policies/secret
+//go:generate genhandler /tokenizer POST,decryptTokens,/tokens/actions/decrypt POST,inspectToken,/tokens/actions/inspect POST,lookupTokens,/tokens/actions/lookup POST,lookupOrCreateTokens,/tokens/actions/lookuporcreate POST,resolveToken,/tokens/actions/resolve POST,revokeTokens,/tokens/actions/revoke method,Token,/tokens collection,AccessPolicyTemplate,h.newAccessPolicyTemplateAuthorizer(),/policies/accesstemplate collection,AccessPolicy,h.newTokenizerAuthorizer(),/policies/access collection,Transformer,h.newTokenizerAuthorizer(),/policies/transformation method,Token,/tokens collection,Secret,h.newSecretAuthorizer(),/policies/secret
 
 type handler struct {
 	logServerClient *logServerClient.Client
@@ -52,6 +52,8 @@ func handlerBuilder(builder *builder.HandlerBuilder, h *handler) {
 		Delete(h.deleteTokenGenerated).
 		End()
 
+	builder.MethodHandler("/tokens/actions/decrypt").Post(h.decryptTokensGenerated)
+
 	builder.MethodHandler("/tokens/actions/inspect").Post(h.inspectTokenGenerated)
 
 	builder.MethodHandler("/tokens/actions/lookup").Post(h.lookupTokensGenerated)
@@ -60,6 +62,29 @@ func handlerBuilder(builder *builder.HandlerBuilder, h *handler) {
 
 	builder.MethodHandler("/tokens/actions/resolve").Post(h.resolveTokenGenerated)
 
+	builder.MethodHandler("/tokens/actions/revoke").Post(h.revokeTokensGenerated)
+
+}
+
+func (h *handler) decryptTokensGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req tokenizer.DecryptTokensRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	var res []tokenizer.ResolveTokenResponse
+	res, code, entries, err := h.decryptTokens(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
 func (h *handler) inspectTokenGenerated(w http.ResponseWriter, r *http.Request) {
@@ -146,6 +171,27 @@ func (h *handler) resolveTokenGenerated(w http.ResponseWriter, r *http.Request)
 	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
+func (h *handler) revokeTokensGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req tokenizer.RevokeTokensRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	var res *tokenizer.RevokeTokensResponse
+	res, code, entries, err := h.revokeTokens(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
+}
+
 func (h *handler) createTokenGenerated(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
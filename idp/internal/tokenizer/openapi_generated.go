@@ -430,6 +430,23 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 		}
 	}
 
+	{
+		op, err := reflector.NewOperationContext(http.MethodPost, "/tokenizer/tokens/actions/decrypt")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Decrypt Tokens")
+		op.SetDescription("This endpoint receives a list of tokens created by a format preserving encryption transformer, applies the access policy they were created with, and returns the decrypted data if the conditions of the access policy are met.")
+		op.SetTags("Tokens")
+		op.AddReqStructure(new(tokenizer.DecryptTokensRequest))
+		op.AddRespStructure(new([]tokenizer.ResolveTokenResponse), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusBadRequest))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
+
 	{
 		op, err := reflector.NewOperationContext(http.MethodPost, "/tokenizer/tokens/actions/inspect")
 		if err != nil {
@@ -501,4 +518,21 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 			uclog.Fatalf(ctx, "failed to add operation: %v", err)
 		}
 	}
+
+	{
+		op, err := reflector.NewOperationContext(http.MethodPost, "/tokenizer/tokens/actions/revoke")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Revoke Tokens")
+		op.SetDescription("This endpoint revokes all tokens that were created with a transformer, an access policy, or both, e.g. in response to a security incident. Revoked tokens can no longer be resolved.")
+		op.SetTags("Tokens")
+		op.AddReqStructure(new(tokenizer.RevokeTokensRequest))
+		op.AddRespStructure(new(tokenizer.RevokeTokensResponse), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusBadRequest))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
 }
@@ -3,6 +3,7 @@ package tokenizer
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -22,6 +23,7 @@ import (
 	"userclouds.com/internal/apiclient"
 	"userclouds.com/internal/auditlog"
 	"userclouds.com/internal/auth"
+	"userclouds.com/internal/auth/m2m"
 	"userclouds.com/internal/multitenant"
 )
 
@@ -89,11 +91,21 @@ func (h handler) createToken(ctx context.Context, req tokenizer.CreateTokenReque
 		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
 	}
 
-	if transformer.TransformType.ToClient() != policy.TransformTypeTokenizeByValue {
+	if transformer.TransformType.ToClient() != policy.TransformTypeTokenizeByValue && !transformer.IsFormatPreservingEncryption() {
 		return nil, http.StatusBadRequest, nil, ucerr.Errorf("invalid transformer type %s", transformer.TransformType)
 	}
 
-	token, _, err := ExecuteTransformer(ctx, s, authzClient, ap.ID, transformer, req.Data, nil)
+	expiresAt, err := getTokenExpiresAt(req.GetExpiresAt)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	// format preserving encryption doesn't store anything that could expire
+	if transformer.IsFormatPreservingEncryption() && !expiresAt.IsZero() {
+		return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(nil, "tokens created by format preserving encryption transformers can't expire")
+	}
+
+	token, _, err := executeTransformerWithExpiration(ctx, s, authzClient, ap.ID, transformer, req.Data, nil, expiresAt)
 	if err != nil {
 		logTransformerError(ctx, req.TransformerRID.ID, transformer.Version)
 		if ucdb.IsUniqueViolation(err) {
@@ -108,6 +120,16 @@ func (h handler) createToken(ctx context.Context, req tokenizer.CreateTokenReque
 	}, http.StatusCreated, nil, nil
 }
 
+// getTokenExpiresAt returns the expiration time for tokens being created, rejecting times that have already passed
+func getTokenExpiresAt(getExpiresAt func(now time.Time) time.Time) (time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := getExpiresAt(now)
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return time.Time{}, ucerr.Friendlyf(nil, "token expiration time %v is in the past", expiresAt)
+	}
+	return expiresAt, nil
+}
+
 type accessPolicyStatus int
 
 const (
@@ -205,11 +227,17 @@ func (h handler) resolveToken(
 
 	apis := map[uuid.UUID]*accessPolicyInfo{}
 	tokenRecordsByToken := make(map[string]storage.TokenRecord, len(tokenRecords))
+	now := time.Now().UTC()
 
 	for _, tr := range tokenRecords {
 		if _, found := tokenRecordsByToken[tr.Token]; found {
 			continue
 		}
+
+		// expired tokens fail to resolve just like tokens that don't exist, even before they're cleaned up
+		if tr.IsExpired(now) {
+			continue
+		}
 		tokenRecordsByToken[tr.Token] = tr
 
 		api, found := apis[tr.AccessPolicyID]
@@ -286,6 +314,102 @@ func (h handler) resolveToken(
 		nil
 }
 
+// OpenAPI Summary: Decrypt Tokens
+// OpenAPI Tags: Tokens
+// OpenAPI Description: This endpoint receives a list of tokens created by a format preserving encryption transformer, applies the access policy they were created with, and returns the decrypted data if the conditions of the access policy are met.
+func (h handler) decryptTokens(
+	ctx context.Context,
+	req tokenizer.DecryptTokensRequest,
+) (resp []tokenizer.ResolveTokenResponse, code int, auditLogEntries []auditlog.Entry, err error) {
+	if len(req.Tokens) > maxTokenBatch {
+		return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(nil, "Too many tokens provided")
+	}
+
+	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	apc := BuildBaseAPContext(ctx, req.Context, policy.ActionResolve)
+
+	s := storage.MustCreateStorage(ctx)
+	ap, err := getAccessPolicyForResourceID(ctx, s, req.AccessPolicyRID)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	transformer, err := getTransformerForResourceID(ctx, s, req.TransformerRID)
+	if err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	if !transformer.IsFormatPreservingEncryption() {
+		return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(nil, "transformer %v is not a format preserving encryption transformer", transformer.ID)
+	}
+
+	fpCipher, err := newFormatPreservingCipher(ctx, s, transformer, ap.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	api, err := newAccessPolicyInfo(ctx, s, authzClient, apc, ap.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	resp = make([]tokenizer.ResolveTokenResponse, 0, len(req.Tokens))
+	failedTokens := []string{}
+	rateLimitedTokens := []string{}
+	decryptedTokens := []string{}
+	resultLimitedTokens := []string{}
+
+	for _, token := range req.Tokens {
+		api.incrementAccessCount()
+
+		data := ""
+		if api.status == accessPolicyStatusSucceeded {
+			// tokens that weren't created by this transformer and access policy may not decrypt at all, and
+			// fail just like tokens that don't exist
+			if data, err = fpCipher.decrypt(token); err != nil {
+				uclog.Debugf(ctx, "failed to decrypt token with transformer %v: %v", transformer.ID, err)
+				data = ""
+			}
+		}
+
+		if data != "" {
+			decryptedTokens = append(decryptedTokens, token)
+		} else {
+			switch api.status {
+			case accessPolicyStatusRateLimited:
+				rateLimitedTokens = append(rateLimitedTokens, token)
+			case accessPolicyStatusResultLimited:
+				resultLimitedTokens = append(resultLimitedTokens, token)
+			default:
+				failedTokens = append(failedTokens, token)
+			}
+		}
+
+		resp = append(resp, tokenizer.ResolveTokenResponse{Data: data, Token: token})
+	}
+
+	return resp,
+		http.StatusOK,
+		auditlog.NewEntryArray(
+			auth.GetAuditLogActor(ctx),
+			internal.AuditLogEventTypeDecryptTokens,
+			auditlog.Payload{
+				"AccessPolicyContext": apc,
+				"Name":                "Tokenizer",
+				"TransformerID":       transformer.ID,
+				"TokensFail":          failedTokens,
+				"TokensRateLimited":   rateLimitedTokens,
+				"TokensResultLimited": resultLimitedTokens,
+				"TokensSuccess":       decryptedTokens,
+			},
+		),
+		nil
+}
+
 func getUserColumnValue(ctx context.Context, userID uuid.UUID, columnID uuid.UUID, purposes []userstore.ResourceID) (string, int, error) {
 
 	ts := multitenant.MustGetTenantState(ctx)
@@ -419,6 +543,10 @@ func (h handler) inspectToken(ctx context.Context, req tokenizer.InspectTokenReq
 		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
 	}
 
+	if tr.IsExpired(time.Now().UTC()) {
+		return nil, http.StatusBadRequest, nil, ucerr.Friendlyf(nil, "token expired at %v", tr.ExpiresAt)
+	}
+
 	clientTransformer := policy.Transformer{}
 	if transformer, err := s.GetTransformerByVersion(ctx, tr.TransformerID, tr.TransformerVersion); err == nil {
 		dtm, err := storage.NewDataTypeManager(ctx, s)
@@ -447,6 +575,9 @@ func (h handler) inspectToken(ctx context.Context, req tokenizer.InspectTokenReq
 		Transformer:  clientTransformer,
 		AccessPolicy: *ap.ToClientModel(),
 	}
+	if !tr.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &tr.ExpiresAt
+	}
 
 	return &resp,
 		http.StatusOK,
@@ -577,10 +708,19 @@ func (h handler) lookupOrCreateTokens(ctx context.Context, req tokenizer.LookupO
 		accessPolicyIDs = append(accessPolicyIDs, dbAP.ID)
 	}
 
-	// Lookup tokens for each piece of data passed in
-	tokens, err := s.BatchListTokensByDataAndPolicy(ctx, req.Data, transformerIDs, accessPolicyIDs)
+	expiresAt, err := getTokenExpiresAt(req.GetExpiresAt)
 	if err != nil {
-		return nil, uchttp.SQLReadErrorMapper(err), nil, ucerr.Wrap(err)
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	// Lookup tokens for each piece of data passed in, unless the caller asked for tokens with a limited
+	// lifetime, since existing tokens wouldn't have that lifetime
+	tokens := make([]string, len(req.Data))
+	if expiresAt.IsZero() {
+		tokens, err = s.BatchListTokensByDataAndPolicy(ctx, req.Data, transformerIDs, accessPolicyIDs)
+		if err != nil {
+			return nil, uchttp.SQLReadErrorMapper(err), nil, ucerr.Wrap(err)
+		}
 	}
 
 	successTokens := []string{}
@@ -595,7 +735,7 @@ func (h handler) lookupOrCreateTokens(ctx context.Context, req tokenizer.LookupO
 
 			ap := accessPolicyIDMap[accessPolicyIDs[i]]
 
-			token, _, err := ExecuteTransformer(ctx, s, authzClient, ap.ID, transformer, req.Data[i], nil)
+			token, _, err := executeTransformerWithExpiration(ctx, s, authzClient, ap.ID, transformer, req.Data[i], nil, expiresAt)
 			if err != nil {
 				logTransformerError(ctx, transformerIDs[i], transformer.Version)
 				if ucdb.IsUniqueViolation(err) {
@@ -625,3 +765,57 @@ func (h handler) lookupOrCreateTokens(ctx context.Context, req tokenizer.LookupO
 		),
 		nil
 }
+
+// errRevokeTokensForbidden is returned for revoke requests from anyone but an admin of the tenant's company or an M2M client,
+// since a single request can revoke every token issued for a transformer or access policy
+var errRevokeTokensForbidden = ucerr.Friendlyf(nil, "You must be an admin to revoke tokens")
+
+// OpenAPI Summary: Revoke Tokens
+// OpenAPI Tags: Tokens
+// OpenAPI Description: This endpoint revokes all tokens that were created with a transformer, an access policy, or both, e.g. in response to a security incident. Revoked tokens can no longer be resolved.
+func (h handler) revokeTokens(ctx context.Context, req tokenizer.RevokeTokensRequest) (*tokenizer.RevokeTokensResponse, int, []auditlog.Entry, error) {
+	if code, err := internal.EnsureCompanyAdmin(ctx, internal.NewAdminChecker, errRevokeTokensForbidden, m2m.SubjectTypeM2M); err != nil {
+		return nil, code, nil, ucerr.Wrap(err)
+	}
+
+	s := storage.MustCreateStorage(ctx)
+
+	transformerID := uuid.Nil
+	if req.TransformerRID != nil {
+		transformer, err := getTransformerForResourceID(ctx, s, *req.TransformerRID)
+		if err != nil {
+			return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+		}
+		transformerID = transformer.ID
+	}
+
+	accessPolicyID := uuid.Nil
+	if req.AccessPolicyRID != nil {
+		ap, err := getAccessPolicyForResourceID(ctx, s, *req.AccessPolicyRID)
+		if err != nil {
+			return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+		}
+		accessPolicyID = ap.ID
+	}
+
+	revokedCount, err := s.RevokeTokenRecords(ctx, transformerID, accessPolicyID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "revoked %d tokens for transformer %v and access policy %v", revokedCount, transformerID, accessPolicyID)
+
+	return &tokenizer.RevokeTokensResponse{RevokedCount: revokedCount},
+		http.StatusOK,
+		auditlog.NewEntryArray(
+			auth.GetAuditLogActor(ctx),
+			internal.AuditLogEventTypeRevokeTokens,
+			auditlog.Payload{
+				"Name":           "Tokenizer",
+				"TransformerID":  transformerID,
+				"AccessPolicyID": accessPolicyID,
+				"RevokedCount":   revokedCount,
+			},
+		),
+		nil
+}
@@ -27,6 +27,7 @@ import (
 	"userclouds.com/infra/middleware"
 	"userclouds.com/infra/oidc"
 	"userclouds.com/infra/request"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/uchttp"
 	"userclouds.com/infra/uchttp/builder"
@@ -749,4 +750,193 @@ func TestTokenHandler(t *testing.T) {
 		assert.IsNil(t, json.Unmarshal(rr.Body.Bytes(), &lookupResp), assert.Must())
 		assert.Equal(t, len(lookupResp.Tokens), 2, assert.Must())
 	})
+
+	t.Run("TestExpirationAndRevoke", func(t *testing.T) {
+		authToken := createAuthToken("expiration")
+
+		transformer := createTransformerHelper(t, policy.Transformer{
+			Name:               "Transformer_7",
+			InputDataType:      datatype.String,
+			OutputDataType:     datatype.String,
+			TransformType:      policy.TransformTypeTokenizeByValue,
+			ReuseExistingToken: true,
+			Function: `function transform(data, params) {
+				return JSON.stringify('xxxxxxxx-xxxx-7xxx-yxxx-xxxxxxxxxxxx'.replace(/[xy]/g, function(c) {
+					var r = Math.random() * 16 | 0, v = c == 'x' ? r : (r & 0x3 | 0x8);
+					return v.toString(16);
+				}));
+			};`,
+		}, h, hostname, authToken)
+
+		apt := createAccessPolicyTemplateHelper(t, policy.AccessPolicyTemplate{
+			Name:     "Template_7",
+			Function: "function policy(x, y) { return true; } /* template 7 */",
+		}, h, hostname, authToken)
+
+		ap := createAccessPolicyHelper(t, policy.AccessPolicy{
+			Name: "Policy_7",
+			Components: []policy.AccessPolicyComponent{
+				{Template: &userstore.ResourceID{ID: apt.ID}},
+			},
+			PolicyType: policy.PolicyTypeCompositeAnd,
+		}, h, hostname, authToken)
+
+		// a token with a ttl records its expiration, and isn't reused for a later request without one
+		crt := tokenizer.CreateTokenRequest{
+			Data:            `"expiration"`,
+			TransformerRID:  userstore.ResourceID{ID: transformer.ID},
+			AccessPolicyRID: userstore.ResourceID{ID: ap.ID},
+			TTL:             3600,
+		}
+		rr := doRequest(t, h, http.MethodPost, paths.CreateToken, crt, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusCreated)
+		var resp tokenizer.CreateTokenResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		tr, err := s.GetTokenRecordByToken(ctx, resp.Token)
+		assert.NoErr(t, err)
+		assert.True(t, tr.ExpiresAt.After(time.Now().UTC().Add(59*time.Minute)))
+
+		rr = doRequest(t, h, http.MethodPost, paths.InspectToken, tokenizer.InspectTokenRequest{Token: resp.Token}, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var inspectResp tokenizer.InspectTokenResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &inspectResp))
+		assert.NotNil(t, inspectResp.ExpiresAt, assert.Must())
+		assert.True(t, inspectResp.ExpiresAt.Equal(tr.ExpiresAt))
+
+		// expiration times in the past are rejected
+		crt.TTL = 0
+		past := time.Now().UTC().Add(-time.Minute)
+		crt.ExpiresAt = &past
+		rr = doRequest(t, h, http.MethodPost, paths.CreateToken, crt, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusBadRequest)
+
+		// an expired token can't be resolved or inspected, even before it's cleaned up
+		expiredToken := fmt.Sprintf(`"%s"`, uuid.Must(uuid.NewV4()))
+		expired := &storage.TokenRecord{
+			BaseModel:      ucdb.NewBase(),
+			Data:           `"expired"`,
+			Token:          expiredToken,
+			TransformerID:  transformer.ID,
+			AccessPolicyID: ap.ID,
+			ExpiresAt:      time.Now().UTC().Add(-time.Minute),
+		}
+		assert.IsNil(t, s.SaveTokenRecord(ctx, expired), assert.Must())
+
+		rr = doRequest(t, h, http.MethodPost, paths.ResolveToken, tokenizer.ResolveTokensRequest{Tokens: []string{resp.Token, expiredToken}}, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var resolveResp []tokenizer.ResolveTokenResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &resolveResp))
+		assert.Equal(t, len(resolveResp), 2, assert.Must())
+		assert.Equal(t, resolveResp[0].Data, `"expiration"`)
+		assert.Equal(t, resolveResp[1].Data, "")
+
+		rr = doRequest(t, h, http.MethodPost, paths.InspectToken, tokenizer.InspectTokenRequest{Token: expiredToken}, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusBadRequest)
+
+		rr = doRequest(t, h, http.MethodPost, paths.LookupToken, tokenizer.LookupTokensRequest{
+			Data:            `"expired"`,
+			TransformerRID:  userstore.ResourceID{ID: transformer.ID},
+			AccessPolicyRID: userstore.ResourceID{ID: ap.ID},
+		}, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var lookupResp tokenizer.LookupTokensResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &lookupResp))
+		assert.Equal(t, len(lookupResp.Tokens), 0)
+
+		assert.NoErr(t, s.CleanExpiredTokenRecords(ctx, 100, false))
+		_, err = s.GetTokenRecordByToken(ctx, expiredToken)
+		assert.NotNil(t, err)
+		_, err = s.GetTokenRecordByToken(ctx, resp.Token)
+		assert.NoErr(t, err)
+
+		// only admins and M2M clients can revoke tokens
+		rr = doRequest(t, h, http.MethodPost, paths.RevokeTokens, tokenizer.RevokeTokensRequest{TransformerRID: &userstore.ResourceID{ID: transformer.ID}}, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusForbidden)
+		_, err = s.GetTokenRecordByToken(ctx, resp.Token)
+		assert.NoErr(t, err)
+
+		m2mToken := fmt.Sprintf("Bearer %s", uctest.CreateJWT(t,
+			oidc.UCTokenClaims{
+				StandardClaims: oidc.StandardClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.Must(uuid.NewV4()).String()}},
+				SubjectType:    m2m.SubjectTypeM2M,
+			},
+			tenant.TenantURL))
+
+		// revoking by transformer revokes the unexpired token
+		rr = doRequest(t, h, http.MethodPost, paths.RevokeTokens, tokenizer.RevokeTokensRequest{TransformerRID: &userstore.ResourceID{ID: transformer.ID}}, m2mToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var revokeResp tokenizer.RevokeTokensResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &revokeResp))
+		assert.Equal(t, revokeResp.RevokedCount, 1)
+		_, err = s.GetTokenRecordByToken(ctx, resp.Token)
+		assert.NotNil(t, err)
+
+		rr = doRequest(t, h, http.MethodPost, paths.RevokeTokens, tokenizer.RevokeTokensRequest{}, m2mToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusBadRequest)
+	})
+
+	t.Run("TestFormatPreservingEncryption", func(t *testing.T) {
+		authToken := createAuthToken("fpe")
+
+		assert.NoErr(t, s.SaveSecret(ctx, &storage.Secret{
+			BaseModel: ucdb.NewBase(),
+			Name:      "fpe_key",
+			Value:     secret.NewTestString("2B7E151628AED2A6ABF7158809CF4F3C"),
+		}))
+
+		transformer := createTransformerHelper(t, policy.Transformer{
+			Name:           "Transformer_8",
+			InputDataType:  datatype.String,
+			OutputDataType: datatype.String,
+			TransformType:  policy.TransformTypeFormatPreservingEncryption,
+			Parameters:     `{"algorithm": "ff1", "alphabet": "0123456789", "key_secret": "fpe_key"}`,
+		}, h, hostname, authToken)
+
+		apt := createAccessPolicyTemplateHelper(t, policy.AccessPolicyTemplate{
+			Name:     "Template_8",
+			Function: "function policy(x, y) { return true; } /* template 8 */",
+		}, h, hostname, authToken)
+
+		ap := createAccessPolicyHelper(t, policy.AccessPolicy{
+			Name: "Policy_8",
+			Components: []policy.AccessPolicyComponent{
+				{Template: &userstore.ResourceID{ID: apt.ID}},
+			},
+			PolicyType: policy.PolicyTypeCompositeAnd,
+		}, h, hostname, authToken)
+
+		// the token has the same format as the data, and isn't stored
+		crt := tokenizer.CreateTokenRequest{
+			Data:            "123-45-6789",
+			TransformerRID:  userstore.ResourceID{ID: transformer.ID},
+			AccessPolicyRID: userstore.ResourceID{ID: ap.ID},
+		}
+		rr := doRequest(t, h, http.MethodPost, paths.CreateToken, crt, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusCreated)
+		var resp tokenizer.CreateTokenResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.NotEqual(t, resp.Token, "123-45-6789")
+		assert.Equal(t, len(resp.Token), len("123-45-6789"))
+		assert.Equal(t, resp.Token[3:4], "-")
+
+		_, err := s.GetTokenRecordByToken(ctx, resp.Token)
+		assert.NotNil(t, err)
+
+		rr = doRequest(t, h, http.MethodPost, paths.DecryptTokens, tokenizer.DecryptTokensRequest{
+			Tokens:          []string{resp.Token},
+			TransformerRID:  userstore.ResourceID{ID: transformer.ID},
+			AccessPolicyRID: userstore.ResourceID{ID: ap.ID},
+		}, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var decryptResp []tokenizer.ResolveTokenResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &decryptResp))
+		assert.Equal(t, len(decryptResp), 1, assert.Must())
+		assert.Equal(t, decryptResp[0].Data, "123-45-6789")
+
+		// format preserving encryption tokens can't expire
+		crt.TTL = 3600
+		rr = doRequest(t, h, http.MethodPost, paths.CreateToken, crt, authToken, hostname)
+		assert.Equal(t, rr.Code, http.StatusBadRequest)
+	})
 }
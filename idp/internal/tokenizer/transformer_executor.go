@@ -28,6 +28,8 @@ type ExecuteTransformerParameters struct {
 	TokenAccessPolicyID uuid.UUID
 	Data                string
 	DataProvenance      *policy.UserstoreDataProvenance
+	// ExpiresAt is the expiration time of any token created by a tokenizing transformer, or zero if it never expires
+	ExpiresAt time.Time
 }
 
 // TransformerExecutor is used to perform transformations for a series of ExecuteTransformerParameters.
@@ -85,7 +87,7 @@ func (te *TransformerExecutor) Execute(
 		}
 		transformerHandlers[transformerAndAPID] = th
 
-		if err = th.addData(tp.Data, tp.DataProvenance); err != nil {
+		if err = th.addData(tp.Data, tp.DataProvenance, tp.ExpiresAt); err != nil {
 			return nil, "", ucerr.Wrap(err)
 		}
 		resultIndices := resultIndicesByTransformerID[transformerAndAPID]
@@ -130,10 +132,12 @@ type transformerHandler struct {
 	nativeFunction    *storage.TransformerFunc
 	jsContext         *ucv8go.Context
 	jsScript          string
+	fpCipher          *formatPreservingCipher
 	tokenAccessPolicy *storage.AccessPolicy
 	consoleBuilder    *strings.Builder
 	data              []string
 	dataProvenance    []*policy.UserstoreDataProvenance
+	expiresAt         []time.Time
 	setupComplete     bool
 }
 
@@ -186,6 +190,7 @@ func newTransformerHandler(
 func (th *transformerHandler) addData(
 	data string,
 	dataProvenance *policy.UserstoreDataProvenance,
+	expiresAt time.Time,
 ) error {
 	if th.transformer.RequiresDataProvenance() {
 		if dataProvenance == nil {
@@ -198,6 +203,7 @@ func (th *transformerHandler) addData(
 
 	th.data = append(th.data, data)
 	th.dataProvenance = append(th.dataProvenance, dataProvenance)
+	th.expiresAt = append(th.expiresAt, expiresAt)
 
 	return nil
 }
@@ -211,6 +217,7 @@ func (th *transformerHandler) cleanupExecution() {
 		th.jsContext = nil
 		th.jsScript = ""
 	}
+	th.fpCipher = nil
 	th.setupComplete = false
 }
 
@@ -310,7 +317,9 @@ func (th transformerHandler) lookup(
 	for i := range th.data {
 		var trs []storage.TokenRecord
 
-		if th.transformer.ReuseExistingToken {
+		// a token with a requested lifetime is never shared, since reusing an existing token
+		// would give it a different lifetime than the caller asked for
+		if th.transformer.ReuseExistingToken && th.expiresAt[i].IsZero() {
 			var err error
 
 			switch th.transformer.TransformType.ToClient() {
@@ -349,6 +358,7 @@ func (th transformerHandler) lookup(
 func (th *transformerHandler) reset() {
 	th.data = []string{}
 	th.dataProvenance = []*policy.UserstoreDataProvenance{}
+	th.expiresAt = []time.Time{}
 	th.consoleBuilder.Reset()
 }
 
@@ -369,6 +379,7 @@ func (th transformerHandler) save(
 			AccessPolicyID:     th.tokenAccessPolicy.ID,
 			Token:              transformedData,
 			Data:               th.data[index],
+			ExpiresAt:          th.expiresAt[index],
 		}
 	case policy.TransformTypeTokenizeByReference:
 		tr = &storage.TokenRecord{
@@ -379,6 +390,7 @@ func (th transformerHandler) save(
 			Token:              transformedData,
 			UserID:             th.dataProvenance[index].UserID,
 			ColumnID:           th.dataProvenance[index].ColumnID,
+			ExpiresAt:          th.expiresAt[index],
 		}
 	default:
 		return true, nil
@@ -418,7 +430,13 @@ func (th *transformerHandler) setupExecution(ctx context.Context) error {
 		return nil
 	}
 
-	if th.nativeFunction == nil {
+	if th.transformer.IsFormatPreservingEncryption() {
+		fpCipher, err := newFormatPreservingCipher(ctx, th.s, th.transformer, th.tokenAccessPolicy.ID)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		th.fpCipher = fpCipher
+	} else if th.nativeFunction == nil {
 		jsContext, cb, err := ucv8go.NewJSContext(ctx, th.authzClient, auditlog.TransformerCustom, newPolicySecretResolver(th.s))
 		if err != nil {
 			return ucerr.Wrap(err)
@@ -435,6 +453,16 @@ func (th *transformerHandler) setupExecution(ctx context.Context) error {
 func (th *transformerHandler) transform(ctx context.Context, index int) (string, error) {
 	if th.nativeFunction != nil {
 		return (*th.nativeFunction)(th.data[index], th.transformer.Parameters), nil
+	} else if th.fpCipher != nil {
+		token, err := th.fpCipher.encrypt(th.data[index])
+		if err != nil {
+			logTransformerError(ctx, th.transformer.ID, th.transformer.Version)
+			return "", ucerr.Wrap(jsonclient.Error{
+				StatusCode: http.StatusBadRequest,
+				Body:       fmt.Sprintf("error executing transformer: %v", ucerr.UserFriendlyMessage(err)),
+			})
+		}
+		return token, nil
 	} else if th.jsContext == nil {
 		return "", ucerr.Errorf("transformer called with nil jsContext for non-native transformer %v", th.transformer.ID)
 	}
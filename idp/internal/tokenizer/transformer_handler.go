@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -455,6 +456,12 @@ func (h handler) testTransformer(w http.ResponseWriter, r *http.Request) {
 		req.Transformer.Name = "temp" // give the transformer a placeholder name so it doesn't fail validation
 	}
 
+	// format preserving encryption depends on the token access policy, and has no function to test
+	if req.Transformer.TransformType == policy.TransformTypeFormatPreservingEncryption {
+		jsonapi.MarshalError(ctx, w, ucerr.Friendlyf(nil, "format preserving encryption transformers can't be tested"), jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
 	// for testing, we always want to transform
 	req.Transformer.TransformType = policy.TransformTypeTransform
 
@@ -499,12 +506,27 @@ func ExecuteTransformer(
 	transformer *storage.Transformer,
 	data string,
 	dataProvenance *policy.UserstoreDataProvenance,
+) (result string, consoleOutput string, err error) {
+	return executeTransformerWithExpiration(ctx, s, authzClient, tokenAccessPolicyID, transformer, data, dataProvenance, time.Time{})
+}
+
+// executeTransformerWithExpiration executes a transformer on the given data, setting the expiration time of any created token
+func executeTransformerWithExpiration(
+	ctx context.Context,
+	s *storage.Storage,
+	authzClient *authz.Client,
+	tokenAccessPolicyID uuid.UUID,
+	transformer *storage.Transformer,
+	data string,
+	dataProvenance *policy.UserstoreDataProvenance,
+	expiresAt time.Time,
 ) (result string, consoleOutput string, err error) {
 	etp := ExecuteTransformerParameters{
 		Transformer:         transformer,
 		TokenAccessPolicyID: tokenAccessPolicyID,
 		Data:                data,
 		DataProvenance:      dataProvenance,
+		ExpiresAt:           expiresAt,
 	}
 
 	te := NewTransformerExecutor(s, authzClient)
@@ -14,7 +14,6 @@ import (
 	"userclouds.com/idp/internal"
 	"userclouds.com/idp/internal/storage"
 	"userclouds.com/idp/internal/storage/column"
-	"userclouds.com/idp/policy"
 	"userclouds.com/idp/userstore"
 	"userclouds.com/infra/pagination"
 	"userclouds.com/infra/ucerr"
@@ -167,8 +166,7 @@ func validateAndPopulateAccessorFields(
 						return ucerr.Errorf("transformer name %s does not match ID %s", a.Columns[i].Transformer.Name, a.Columns[i].Transformer.ID)
 					}
 
-					if storage.InternalTransformTypeFromClient(policy.TransformTypeTokenizeByValue) == tf.TransformType ||
-						storage.InternalTransformTypeFromClient(policy.TransformTypeTokenizeByReference) == tf.TransformType {
+					if tf.RequiresTokenAccessPolicy() {
 						tokenizingTransformer = true
 					}
 				}
@@ -184,8 +182,7 @@ func validateAndPopulateAccessorFields(
 						return ucerr.Errorf("transformer ID %s does not match name %s", a.Columns[i].Transformer.ID, a.Columns[i].Transformer.Name)
 					}
 
-					if storage.InternalTransformTypeFromClient(policy.TransformTypeTokenizeByValue) == tf.TransformType ||
-						storage.InternalTransformTypeFromClient(policy.TransformTypeTokenizeByReference) == tf.TransformType {
+					if tf.RequiresTokenAccessPolicy() {
 						tokenizingTransformer = true
 					}
 				}
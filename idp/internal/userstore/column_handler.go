@@ -54,9 +54,7 @@ func validateAndPopulateColumnFields(
 		c.DefaultTransformer.Name = transformer.Name
 	}
 
-	transformType := transformer.TransformType.ToClient()
-	if transformType == policy.TransformTypeTokenizeByReference ||
-		transformType == policy.TransformTypeTokenizeByValue {
+	if transformer.RequiresTokenAccessPolicy() {
 		if c.DefaultTokenAccessPolicy.Validate() != nil {
 			return ucerr.Friendlyf(nil, "token resolution policy required")
 		}
@@ -0,0 +1,653 @@
+package userstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+
+	"userclouds.com/authz"
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/idp/paths"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/ucjwt"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/uctypes/set"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/auth/m2m"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantmap"
+	tenantplexstorage "userclouds.com/internal/tenantplex/storage"
+	"userclouds.com/worker"
+)
+
+// dsarExportRetention is how long a DSAR export can be downloaded for after it is requested
+const dsarExportRetention = 7 * 24 * time.Hour
+
+// errDSARExportForbidden is returned when a caller that isn't an admin requests or downloads a DSAR export, since
+// the bundle contains everything we store about the user
+var errDSARExportForbidden = ucerr.Friendlyf(nil, "You must be an admin, or use client credentials, to request or download a DSAR export")
+
+func newClientDSARExport(ts *tenantmap.TenantState, e storage.DSARExport) idp.DSARExport {
+	export := idp.DSARExport{
+		ID:        e.ID,
+		UserID:    e.UserID,
+		Status:    idp.DSARExportStatus(e.Status),
+		Error:     e.Error,
+		Created:   e.Created,
+		ExpiresAt: e.ExpiresAt,
+	}
+	if e.Status == storage.DSARExportStatusCompleted && !e.IsExpired(time.Now().UTC()) {
+		export.JSONDownloadURL = ts.GetTenantURL() + paths.DownloadDSARExportPath(e.ID, string(idp.DSARExportFormatJSON))
+		export.HTMLDownloadURL = ts.GetTenantURL() + paths.DownloadDSARExportPath(e.ID, string(idp.DSARExportFormatHTML))
+	}
+	return export
+}
+
+func (h *handler) createDSARExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ts := multitenant.MustGetTenantState(ctx)
+
+	if code, err := ensureAdminOrM2M(ctx, errDSARExportForbidden); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	var req idp.CreateDSARExportRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	// the export is stored in the user's region, since its bundle will contain their data
+	us := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+	_, userRegion, err := us.GetBaseUser(ctx, req.UserID, false)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	export := &storage.DSARExport{
+		UserBaseModel: ucdb.NewUserBase(req.UserID),
+		Status:        storage.DSARExportStatusPending,
+		ExpiresAt:     time.Now().UTC().Add(dsarExportRetention),
+	}
+
+	s := storage.NewUserStorage(ctx, ts.UserRegionDbMap[userRegion], userRegion, ts.ID)
+	if err := s.SaveDSARExport(ctx, export); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+
+	if err := h.workerClient.Send(ctx, worker.DSARExportMessage(ts.ID, export.ID)); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+
+	auditlog.PostMultipleAsync(ctx, auditlog.NewEntryArray(auth.GetAuditLogActor(ctx), internal.AuditLogEventTypeCreateDSARExport,
+		auditlog.Payload{"ID": export.ID, "UserID": export.UserID}))
+
+	jsonapi.Marshal(w, newClientDSARExport(ts, *export), jsonapi.Code(http.StatusCreated))
+}
+
+func (h *handler) getDSARExport(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	ctx := r.Context()
+	ts := multitenant.MustGetTenantState(ctx)
+	us := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+
+	export, _, err := us.GetDSARExport(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusNotFound))
+			return
+		}
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+
+	jsonapi.Marshal(w, newClientDSARExport(ts, *export))
+}
+
+func (h *handler) downloadDSARExport(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	ctx := r.Context()
+	ts := multitenant.MustGetTenantState(ctx)
+	us := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+
+	if code, err := ensureAdminOrM2M(ctx, errDSARExportForbidden); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	format := idp.DSARExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = idp.DSARExportFormatJSON
+	}
+	if err := format.Validate(); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	export, _, err := us.GetDSARExport(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusNotFound))
+			return
+		}
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+	if export.Status != storage.DSARExportStatusCompleted {
+		jsonapi.MarshalError(ctx, w, ucerr.Friendlyf(nil, "DSAR export %v is %v", id, export.Status), jsonapi.Code(http.StatusConflict))
+		return
+	}
+	if export.IsExpired(time.Now().UTC()) {
+		jsonapi.MarshalError(ctx, w, ucerr.Friendlyf(nil, "DSAR export %v has expired", id), jsonapi.Code(http.StatusGone))
+		return
+	}
+
+	auditlog.PostMultipleAsync(ctx, auditlog.NewEntryArray(auth.GetAuditLogActor(ctx), internal.AuditLogEventTypeDownloadDSARExport,
+		auditlog.Payload{"ID": export.ID, "UserID": export.UserID, "Format": format}))
+
+	if format == idp.DSARExportFormatHTML {
+		var bundle idp.DSARBundle
+		if err := json.Unmarshal([]byte(export.Bundle), &bundle); err != nil {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dsar-%v.html"`, export.UserID))
+		if err := renderDSARBundleHTML(w, bundle, export.Signature); err != nil {
+			uclog.Errorf(ctx, "failed to render DSAR export %v: %v", id, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dsar-%v.json"`, export.UserID))
+	jsonapi.Marshal(w, idp.DSARSignedBundle{Bundle: json.RawMessage(export.Bundle), Signature: export.Signature})
+}
+
+// ExportDSAR assembles and signs the bundle for a pending DSAR export, recording on the export whether it succeeded
+func ExportDSAR(ctx context.Context, ts *tenantmap.TenantState, exportID uuid.UUID) error {
+	ctx = multitenant.SetTenantState(ctx, ts)
+	s := storage.NewFromTenantState(ctx, ts)
+
+	export, exportRegion, err := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID).GetDSARExport(ctx, exportID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	us := storage.NewUserStorage(ctx, ts.UserRegionDbMap[exportRegion], exportRegion, ts.ID)
+	if export.Status != storage.DSARExportStatusPending {
+		uclog.Infof(ctx, "DSAR export %v is already %v", exportID, export.Status)
+		return nil
+	}
+
+	bundle, signature, err := buildDSARBundle(ctx, ts, s, *export)
+	if err != nil {
+		export.Status = storage.DSARExportStatusFailed
+		export.Error = ucerr.UserFriendlyMessage(err)
+		if saveErr := us.SaveDSARExport(ctx, export); saveErr != nil {
+			uclog.Errorf(ctx, "failed to mark DSAR export %v failed: %v", exportID, saveErr)
+		}
+		return ucerr.Wrap(err)
+	}
+
+	export.Status = storage.DSARExportStatusCompleted
+	export.Bundle = string(bundle)
+	export.Signature = signature
+	if err := us.SaveDSARExport(ctx, export); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "completed DSAR export %v for user %v", exportID, export.UserID)
+	return nil
+}
+
+func buildDSARBundle(ctx context.Context, ts *tenantmap.TenantState, s *storage.Storage, export storage.DSARExport) ([]byte, string, error) {
+	bundle := idp.DSARBundle{
+		ExportID:           export.ID,
+		TenantID:           ts.ID,
+		UserID:             export.UserID,
+		GeneratedAt:        time.Now().UTC(),
+		Columns:            []idp.DSARColumnValue{},
+		SoftDeletedColumns: []idp.DSARColumnValue{},
+		ConsentedPurposes:  []idp.DSARConsentedPurpose{},
+		Tokens:             []idp.DSARToken{},
+		AuthzEdges:         []idp.DSARAuthzEdge{},
+		Authns:             []idp.UserAuthn{},
+		MFAChannels:        []idp.UserMFAChannel{},
+		AuditLog:           []idp.DSARAuditLogEntry{},
+	}
+
+	cm, err := storage.NewUserstoreColumnManager(ctx, s)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	if err := addDSARUserValues(ctx, ts, s, cm, &bundle); err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+	if err := addDSARTokens(ctx, s, cm, &bundle); err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+	if err := addDSARAuthzEdges(ctx, ts, &bundle); err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+	if err := addDSARAuthns(ctx, s, &bundle); err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+	if err := addDSARAuditLog(ctx, ts, &bundle); err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	bundleJSON, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	signature, err := signDSARBundle(ctx, ts, export, bundleJSON)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	return bundleJSON, signature, nil
+}
+
+func addDSARUserValues(ctx context.Context, ts *tenantmap.TenantState, s *storage.Storage, cm *storage.ColumnManager, bundle *idp.DSARBundle) error {
+	dtm, err := storage.NewDataTypeManager(ctx, s)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	us := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+	_, liveValues, softDeletedValues, userRegion, _, err := us.GetAllUserValues(ctx, cm, dtm, bundle.UserID, false)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	bundle.Region = userRegion
+
+	purposes, err := s.ListPurposesNonPaginated(ctx)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	purposeMap := map[uuid.UUID]storage.Purpose{}
+	for _, p := range purposes {
+		purposeMap[p.ID] = p
+	}
+
+	bundle.Columns = newDSARColumnValues(liveValues, purposeMap)
+	bundle.SoftDeletedColumns = newDSARColumnValues(softDeletedValues, purposeMap)
+
+	// the purposes consented to for any live value, without the per-value retention timeouts
+	consented := set.NewStringSet()
+	for _, v := range bundle.Columns {
+		for _, cp := range v.ConsentedPurposes {
+			if !consented.Contains(cp.Purpose) {
+				consented.Insert(cp.Purpose)
+				bundle.ConsentedPurposes = append(bundle.ConsentedPurposes, idp.DSARConsentedPurpose{Purpose: cp.Purpose, Description: cp.Description})
+			}
+		}
+	}
+
+	return nil
+}
+
+func newDSARColumnValues(
+	values storage.ColumnConsentedValues,
+	purposeMap map[uuid.UUID]storage.Purpose,
+) []idp.DSARColumnValue {
+	columnNames := make([]string, 0, len(values))
+	for columnName := range values {
+		columnNames = append(columnNames, columnName)
+	}
+	sort.Strings(columnNames)
+
+	dsarValues := []idp.DSARColumnValue{}
+	for _, columnName := range columnNames {
+		columnValues := make([]storage.ColumnConsentedValue, 0, len(values[columnName]))
+		for _, v := range values[columnName] {
+			columnValues = append(columnValues, v)
+		}
+		sort.Slice(columnValues, func(i, j int) bool { return columnValues[i].Ordering < columnValues[j].Ordering })
+
+		for _, v := range columnValues {
+			dv := idp.DSARColumnValue{Column: columnName, Value: v.Value, ConsentedPurposes: []idp.DSARConsentedPurpose{}}
+			for _, cp := range v.ConsentedPurposes {
+				if cp.Purpose.IsNil() {
+					continue
+				}
+				dv.ConsentedPurposes = append(dv.ConsentedPurposes, idp.DSARConsentedPurpose{
+					Purpose:          purposeMap[cp.Purpose].Name,
+					Description:      purposeMap[cp.Purpose].Description,
+					RetentionTimeout: cp.RetentionTimeout,
+				})
+			}
+			dsarValues = append(dsarValues, dv)
+		}
+	}
+	return dsarValues
+}
+
+func addDSARTokens(ctx context.Context, s *storage.Storage, cm *storage.ColumnManager, bundle *idp.DSARBundle) error {
+	trs, err := s.ListTokenRecordsByUserID(ctx, bundle.UserID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	transformerIDs := make([]uuid.UUID, 0, len(trs))
+	for _, tr := range trs {
+		transformerIDs = append(transformerIDs, tr.TransformerID)
+	}
+	transformerMap, err := s.GetTransformersMap(ctx, transformerIDs)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	for _, tr := range trs {
+		token := idp.DSARToken{
+			Token:       tr.Token,
+			Transformer: transformerMap[tr.TransformerID].Name,
+			Created:     tr.Created,
+			ExpiresAt:   tr.ExpiresAt,
+		}
+		if c := cm.GetColumnByID(tr.ColumnID); c != nil {
+			token.Column = c.Name
+		}
+		bundle.Tokens = append(bundle.Tokens, token)
+	}
+
+	return nil
+}
+
+// newM2MAuthzClient returns an authz client for the tenant that authenticates as the service itself, for use
+// outside of a request
+func newM2MAuthzClient(ctx context.Context, ts *tenantmap.TenantState) (*authz.Client, error) {
+	tokenSource, err := m2m.GetM2MTokenSource(ctx, ts.ID)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	azc, err := authz.NewClient(ts.GetTenantURL(), authz.JSONClient(tokenSource))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return azc, nil
+}
+
+func addDSARAuthzEdges(ctx context.Context, ts *tenantmap.TenantState, bundle *idp.DSARBundle) error {
+	azc, err := newM2MAuthzClient(ctx, ts)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	edgeTypeNames := map[uuid.UUID]string{}
+	cursor := pagination.CursorBegin
+	for {
+		resp, err := azc.ListEdgesOnObject(ctx, bundle.UserID, authz.Pagination(pagination.StartingAfter(cursor)))
+		if err != nil {
+			if errors.Is(err, authz.ErrObjectNotFound) {
+				return nil
+			}
+			return ucerr.Wrap(err)
+		}
+
+		for _, edge := range resp.Data {
+			if _, found := edgeTypeNames[edge.EdgeTypeID]; !found {
+				et, err := azc.GetEdgeType(ctx, edge.EdgeTypeID)
+				if err != nil {
+					return ucerr.Wrap(err)
+				}
+				edgeTypeNames[edge.EdgeTypeID] = et.TypeName
+			}
+
+			bundle.AuthzEdges = append(bundle.AuthzEdges, idp.DSARAuthzEdge{
+				ID:             edge.ID,
+				EdgeType:       edgeTypeNames[edge.EdgeTypeID],
+				SourceObjectID: edge.SourceObjectID,
+				TargetObjectID: edge.TargetObjectID,
+				Created:        edge.Created,
+				ValidFrom:      edge.ValidFrom,
+				ValidUntil:     edge.ValidUntil,
+			})
+		}
+
+		if !resp.HasNext {
+			break
+		}
+		cursor = resp.Next
+	}
+
+	return nil
+}
+
+func addDSARAuthns(ctx context.Context, s *storage.Storage, bundle *idp.DSARBundle) error {
+	passwordAuthns, err := s.ListPasswordAuthnsForUserID(ctx, bundle.UserID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	oidcAuthns, err := s.ListOIDCAuthnsForUserID(ctx, bundle.UserID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	mfaConfig, err := s.GetUserMFAConfiguration(ctx, bundle.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ucerr.Wrap(err)
+	}
+
+	for _, a := range passwordAuthns {
+		// the password hash is deliberately left out
+		bundle.Authns = append(bundle.Authns, idp.UserAuthn{
+			AuthnType: idp.AuthnTypePassword,
+			Username:  a.Username,
+		})
+	}
+	for _, a := range oidcAuthns {
+		bundle.Authns = append(bundle.Authns, idp.UserAuthn{
+			AuthnType:     idp.AuthnTypeOIDC,
+			OIDCProvider:  a.Type,
+			OIDCIssuerURL: a.OIDCIssuerURL,
+			OIDCSubject:   a.OIDCSubject,
+		})
+	}
+	if mfaConfig != nil {
+		for _, c := range mfaConfig.MFAChannels.Channels {
+			bundle.MFAChannels = append(bundle.MFAChannels, idp.UserMFAChannel{
+				ChannelType:        c.ChannelType,
+				ChannelDescription: c.GetUserDetailDescription(),
+				Primary:            c.Primary,
+				Verified:           c.Verified,
+				LastVerified:       c.LastVerified,
+			})
+		}
+	}
+
+	return nil
+}
+
+// getDSARAuditLogFilter returns the filter for the audit log entries of actions the user took, or that were taken
+// about them, such as accessor executions that selected them by ID
+func getDSARAuditLogFilter(userID uuid.UUID) string {
+	return fmt.Sprintf("((('actor_id',EQ,'%v'),OR,('payload->>UserID',EQ,'%v')),OR,('payload->SelectorValues',HAS,'%v'))", userID, userID, userID)
+}
+
+func addDSARAuditLog(ctx context.Context, ts *tenantmap.TenantState, bundle *idp.DSARBundle) error {
+	als := auditlog.NewStorage(ts.TenantDB)
+
+	pager, err := auditlog.NewEntryPaginatorFromOptions(
+		pagination.Limit(pagination.MaxLimit),
+		pagination.Filter(getDSARAuditLogFilter(bundle.UserID)),
+	)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	for {
+		entries, respFields, err := als.ListEntriesPaginated(ctx, *pager)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		for _, e := range entries {
+			bundle.AuditLog = append(bundle.AuditLog, idp.DSARAuditLogEntry{
+				ID:      e.ID,
+				Type:    string(e.Type),
+				Actor:   e.Actor,
+				Created: e.Created,
+				Payload: e.Payload,
+			})
+		}
+
+		if !pager.AdvanceCursor(*respFields) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// signDSARBundle signs the digest of the bundle with the tenant's signing key, so that the bundle can be verified
+// against the tenant's JWKS without having to canonicalize it
+func signDSARBundle(ctx context.Context, ts *tenantmap.TenantState, export storage.DSARExport, bundleJSON []byte) (string, error) {
+	digest := sha256.Sum256(bundleJSON)
+	signature, err := signWithTenantKey(ctx, ts, jwt.MapClaims{
+		"iss":           ts.GetTenantURL(),
+		"sub":           export.UserID.String(),
+		"jti":           export.ID.String(),
+		"iat":           time.Now().UTC().Unix(),
+		"bundle_sha256": base64.RawURLEncoding.EncodeToString(digest[:]),
+	})
+	return signature, ucerr.Wrap(err)
+}
+
+// signWithTenantKey returns a JWS of the claims signed with the tenant's signing key, which can be verified
+// against the tenant's JWKS
+func signWithTenantKey(ctx context.Context, ts *tenantmap.TenantState, claims jwt.MapClaims) (string, error) {
+	tp, err := tenantplexstorage.New(ctx, ts.TenantDB, ts.CacheConfig).GetTenantPlex(ctx, ts.ID)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	keyText, err := tp.PlexConfig.Keys.PrivateKey.Resolve(ctx)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	privKey, err := ucjwt.LoadRSAPrivateKey([]byte(keyText))
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = tp.PlexConfig.Keys.KeyID
+
+	signature, err := token.SignedString(privKey)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return signature, nil
+}
+
+type dsarPageData struct {
+	Bundle    idp.DSARBundle
+	Signature string
+}
+
+func renderDSARBundleHTML(w io.Writer, bundle idp.DSARBundle, signature string) error {
+	tmp, err := template.New("dsar").Funcs(template.FuncMap{
+		"timestamp": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.UTC().Format(time.RFC3339)
+		},
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), ucerr.Wrap(err)
+		},
+	}).Parse(dsarTemplate)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	return ucerr.Wrap(tmp.Execute(w, dsarPageData{Bundle: bundle, Signature: signature}))
+}
+
+const dsarTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Data export for {{.Bundle.UserID}}</title>
+<style>
+  body { font-family: sans-serif; margin: 2em; }
+  table { border-collapse: collapse; margin-bottom: 2em; }
+  th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+  code { word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Data export for {{.Bundle.UserID}}</h1>
+<p>Generated {{timestamp .Bundle.GeneratedAt}} for tenant {{.Bundle.TenantID}}{{if .Bundle.Region}}, stored in region {{.Bundle.Region}}{{end}}.</p>
+
+<h2>Profile</h2>
+<table>
+<tr><th>Field</th><th>Value</th><th>Consented purposes</th></tr>
+{{range .Bundle.Columns}}<tr><td>{{.Column}}</td><td>{{json .Value}}</td><td>{{range .ConsentedPurposes}}{{.Purpose}}{{if not .RetentionTimeout.IsZero}} (until {{timestamp .RetentionTimeout}}){{end}}<br>{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Deleted data still retained</h2>
+<table>
+<tr><th>Field</th><th>Value</th><th>Retained for</th></tr>
+{{range .Bundle.SoftDeletedColumns}}<tr><td>{{.Column}}</td><td>{{json .Value}}</td><td>{{range .ConsentedPurposes}}{{.Purpose}}{{if not .RetentionTimeout.IsZero}} (until {{timestamp .RetentionTimeout}}){{end}}<br>{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Consented purposes</h2>
+<table>
+<tr><th>Purpose</th><th>Description</th></tr>
+{{range .Bundle.ConsentedPurposes}}<tr><td>{{.Purpose}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+
+<h2>Tokens</h2>
+<table>
+<tr><th>Token</th><th>Field</th><th>Transformer</th><th>Created</th><th>Expires</th></tr>
+{{range .Bundle.Tokens}}<tr><td><code>{{.Token}}</code></td><td>{{.Column}}</td><td>{{.Transformer}}</td><td>{{timestamp .Created}}</td><td>{{timestamp .ExpiresAt}}</td></tr>
+{{end}}</table>
+
+<h2>Relationships</h2>
+<table>
+<tr><th>Relationship</th><th>Source</th><th>Target</th><th>Created</th><th>Valid from</th><th>Valid until</th></tr>
+{{range .Bundle.AuthzEdges}}<tr><td>{{.EdgeType}}</td><td>{{.SourceObjectID}}</td><td>{{.TargetObjectID}}</td><td>{{timestamp .Created}}</td><td>{{timestamp .ValidFrom}}</td><td>{{timestamp .ValidUntil}}</td></tr>
+{{end}}</table>
+
+<h2>Sign-in methods</h2>
+<table>
+<tr><th>Type</th><th>Details</th></tr>
+{{range .Bundle.Authns}}<tr><td>{{.AuthnType}}</td><td>{{if .Username}}{{.Username}}{{else}}{{.OIDCProvider}} {{.OIDCSubject}}{{end}}</td></tr>
+{{end}}{{range .Bundle.MFAChannels}}<tr><td>MFA {{.ChannelType}}</td><td>{{.ChannelDescription}}{{if .Primary}} (primary){{end}}</td></tr>
+{{end}}</table>
+
+<h2>Activity</h2>
+<table>
+<tr><th>Time</th><th>Event</th><th>Details</th></tr>
+{{range .Bundle.AuditLog}}<tr><td>{{timestamp .Created}}</td><td>{{.Type}}</td><td><code>{{json .Payload}}</code></td></tr>
+{{end}}</table>
+
+<h2>Signature</h2>
+<p>The JSON download of this export is signed with the tenant's signing key:</p>
+<p><code>{{.Signature}}</code></p>
+</body>
+</html>
+`
@@ -0,0 +1,73 @@
+package userstore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/internal/auditlog"
+)
+
+func TestNewDSARColumnValues(t *testing.T) {
+	marketing := storage.Purpose{Name: "marketing", Description: "send offers"}
+	marketing.ID = uuid.Must(uuid.NewV4())
+	purposeMap := map[uuid.UUID]storage.Purpose{marketing.ID: marketing}
+	retention := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	values := storage.ColumnConsentedValues{
+		"phone": {
+			uuid.Must(uuid.NewV4()): {ColumnName: "phone", Value: "555-0101", Ordering: 2},
+			uuid.Must(uuid.NewV4()): {ColumnName: "phone", Value: "555-0100", Ordering: 1,
+				ConsentedPurposes: []storage.ConsentedPurpose{{Purpose: marketing.ID, RetentionTimeout: retention}}},
+		},
+		"email": {
+			uuid.Must(uuid.NewV4()): {ColumnName: "email", Value: "alice@example.com", Ordering: 1,
+				ConsentedPurposes: []storage.ConsentedPurpose{{}}},
+		},
+	}
+
+	dvs := newDSARColumnValues(values, purposeMap)
+	assert.Equal(t, len(dvs), 3)
+	assert.Equal(t, dvs[0].Column, "email")
+	assert.Equal(t, len(dvs[0].ConsentedPurposes), 0)
+	assert.Equal(t, dvs[1].Value, "555-0100")
+	assert.Equal(t, dvs[1].ConsentedPurposes, []idp.DSARConsentedPurpose{{Purpose: "marketing", Description: "send offers", RetentionTimeout: retention}})
+	assert.Equal(t, dvs[2].Value, "555-0101")
+}
+
+func TestRenderDSARBundleHTML(t *testing.T) {
+	bundle := idp.DSARBundle{
+		UserID:      uuid.Must(uuid.NewV4()),
+		GeneratedAt: time.Now().UTC(),
+		Columns: []idp.DSARColumnValue{
+			{Column: "name", Value: "<script>alert(1)</script>"},
+		},
+		Authns: []idp.UserAuthn{{AuthnType: idp.AuthnTypePassword, Username: "alice"}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoErr(t, renderDSARBundleHTML(&buf, bundle, "header.payload.signature"))
+
+	page := buf.String()
+	assert.True(t, strings.Contains(page, bundle.UserID.String()))
+	assert.True(t, strings.Contains(page, "alice"))
+	assert.True(t, strings.Contains(page, "header.payload.signature"))
+	assert.False(t, strings.Contains(page, "<script>"))
+}
+
+func TestDSARAuditLogFilter(t *testing.T) {
+	userID := uuid.Must(uuid.NewV4())
+	filter := getDSARAuditLogFilter(userID)
+	assert.Contains(t, filter, "'payload->>UserID',EQ")
+	assert.Contains(t, filter, "'payload->SelectorValues',HAS")
+
+	_, err := auditlog.NewEntryPaginatorFromOptions(pagination.Limit(pagination.MaxLimit), pagination.Filter(filter))
+	assert.NoErr(t, err)
+}
@@ -324,6 +324,7 @@ type accessorExecutor struct {
 	transformerMap      map[uuid.UUID]*storage.Transformer
 	accessPolicyConsole string
 	transformerConsole  string
+	explainer           *executionExplainer
 	numSearchRows       int
 	numSelectorRows     int
 	numReturned         int
@@ -353,6 +354,67 @@ func newAccessorExecutor(
 	}
 }
 
+// getUsersForSelector returns the users matching the accessor's selector that have values for the accessor's
+// columns with all of the given purposes, and whether they need to be re-sorted because they came from more than
+// one region
+func (ae *accessorExecutor) getUsersForSelector(
+	pager *pagination.Paginator,
+	purposeIDs set.Set[uuid.UUID],
+) ([]storage.User, bool, int, error) {
+	ts := multitenant.MustGetTenantState(ae.ctx)
+
+	if ae.req.Region != "" {
+		regDB, ok := ts.UserRegionDbMap[ae.req.Region]
+		if !ok {
+			return nil, false, http.StatusBadRequest, ucerr.Friendlyf(nil, "data region '%s' is not available for tenant", ae.req.Region)
+		}
+		us := storage.NewUserStorage(ae.ctx, regDB, ae.req.Region, ts.ID)
+		users, code, err := us.GetUsersForSelector(
+			ae.ctx,
+			ae.cm,
+			ae.dtm,
+			ae.startTime,
+			ae.accessor.DataLifeCycleState,
+			ae.columns,
+			ae.accessor.SelectorConfig,
+			ae.req.SelectorValues,
+			ae.expectedColumnIDs,
+			purposeIDs,
+			pager,
+			ae.req.AccessPrimaryDBOnly,
+		)
+		if err != nil {
+			return nil, false, code, ucerr.Wrap(err)
+		}
+		return users, false, http.StatusOK, nil
+	}
+
+	umrs := storage.NewUserMultiRegionStorage(ae.ctx, ts.UserRegionDbMap, ts.ID)
+	usersByRegion, code, err := umrs.GetUsersForSelector(
+		ae.ctx,
+		ae.cm,
+		ae.dtm,
+		ae.startTime,
+		ae.accessor.DataLifeCycleState,
+		ae.columns,
+		ae.accessor.SelectorConfig,
+		ae.req.SelectorValues,
+		ae.expectedColumnIDs,
+		purposeIDs,
+		pager,
+		ae.req.AccessPrimaryDBOnly,
+	)
+	if err != nil {
+		return nil, false, code, ucerr.Wrap(err)
+	}
+
+	users := []storage.User{}
+	for _, regionUsers := range usersByRegion {
+		users = append(users, regionUsers...)
+	}
+	return users, len(usersByRegion) > 1, http.StatusOK, nil
+}
+
 func (ae accessorExecutor) auditLogInfo() []auditlog.Entry {
 	if !ae.accessor.IsAuditLogged {
 		return nil
@@ -382,7 +444,13 @@ func (ae *accessorExecutor) checkRateThreshold() (bool, error) {
 		return true, nil
 	}
 
-	allowed, err := ae.thresholdAP.CheckRateThreshold(ae.ctx, ae.s, ae.apContext, ae.accessor.ID)
+	checkRateThreshold := ae.thresholdAP.CheckRateThreshold
+	if ae.explainer != nil {
+		// explaining an execution should not count against the rate threshold
+		checkRateThreshold = ae.thresholdAP.PeekRateThreshold
+	}
+
+	allowed, err := checkRateThreshold(ae.ctx, ae.s, ae.apContext, ae.accessor.ID)
 	if err != nil {
 		return false, ucerr.Wrap(err)
 	}
@@ -749,7 +817,11 @@ func (ae *accessorExecutor) getAllowedUsers() ([]storage.User, *pagination.Respo
 		return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	} else if !allowed {
 		uclog.Infof(ae.ctx, "accessor '%v' execution failed due to rate limit", ae.accessor.ID)
-		if ae.thresholdAP.Thresholds.AnnounceMaxExecutionFailure {
+		if ae.explainer != nil {
+			ae.explainer.explanation.RateThresholdExceeded = true
+		}
+		// an explained execution reports the threshold failure in the explanation rather than as an error
+		if ae.thresholdAP.Thresholds.AnnounceMaxExecutionFailure && ae.explainer == nil {
 			return nil, nil, http.StatusTooManyRequests, ucerr.Friendlyf(nil, "access policy rate threshold exceeded")
 		}
 		return nil, limits.getDefaultResponseFields(), http.StatusOK, nil
@@ -764,56 +836,9 @@ func (ae *accessorExecutor) getAllowedUsers() ([]storage.User, *pagination.Respo
 	needSort := false
 
 	if shouldGetCandidates {
-		ts := multitenant.MustGetTenantState(ae.ctx)
-
-		if ae.req.Region != "" {
-			regDB, ok := ts.UserRegionDbMap[ae.req.Region]
-			if !ok {
-				return nil, nil, http.StatusBadRequest, ucerr.Friendlyf(nil, "data region '%s' is not available for tenant", ae.req.Region)
-			}
-			us := storage.NewUserStorage(ae.ctx, regDB, ae.req.Region, ts.ID)
-			candidateUsers, code, err = us.GetUsersForSelector(
-				ae.ctx,
-				ae.cm,
-				ae.dtm,
-				ae.startTime,
-				ae.accessor.DataLifeCycleState,
-				ae.columns,
-				ae.accessor.SelectorConfig,
-				ae.req.SelectorValues,
-				ae.expectedColumnIDs,
-				ae.expectedPurposeIDs,
-				limits.pager,
-				ae.req.AccessPrimaryDBOnly,
-			)
-			if err != nil {
-				return nil, nil, code, ucerr.Wrap(err)
-			}
-		} else {
-			umrs := storage.NewUserMultiRegionStorage(ae.ctx, ts.UserRegionDbMap, ts.ID)
-			candidateUsersByRegion, code, err := umrs.GetUsersForSelector(
-				ae.ctx,
-				ae.cm,
-				ae.dtm,
-				ae.startTime,
-				ae.accessor.DataLifeCycleState,
-				ae.columns,
-				ae.accessor.SelectorConfig,
-				ae.req.SelectorValues,
-				ae.expectedColumnIDs,
-				ae.expectedPurposeIDs,
-				limits.pager,
-				ae.req.AccessPrimaryDBOnly,
-			)
-			if err != nil {
-				return nil, nil, code, ucerr.Wrap(err)
-			}
-			for _, users := range candidateUsersByRegion {
-				candidateUsers = append(candidateUsers, users...)
-			}
-			if len(candidateUsersByRegion) > 1 {
-				needSort = true
-			}
+		candidateUsers, needSort, code, err = ae.getUsersForSelector(limits.pager, ae.expectedPurposeIDs)
+		if err != nil {
+			return nil, nil, code, ucerr.Wrap(err)
 		}
 	}
 
@@ -839,26 +864,37 @@ func (ae *accessorExecutor) getAllowedUsers() ([]storage.User, *pagination.Respo
 		limits.lastUser = candidateUsers[len(candidateUsers)-1]
 	}
 
+	if ae.explainer != nil && shouldGetCandidates {
+		if err := ae.explainSelectedUsers(limits, candidateUsers); err != nil {
+			return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+	}
+
 	var allowedUsers []storage.User
 	for i, u := range candidateUsers {
 		apContext := ae.apContext
 		apContext.User = u.Profile
 
-		allowed, console, err := tokenizer.ExecuteAccessPolicy(ae.ctx, ae.clientAP, apContext, ae.authzClient, ae.s)
+		allowed, err := ae.executeAccessPolicy(u, apContext)
 		if err != nil {
 			return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 		}
-		ae.accessPolicyConsole += console
 
 		if !allowed {
 			if limits.limit == 0 || len(allowedUsers) < limits.limit {
 				ae.numDenied++
 			}
+			if ae.explainer != nil {
+				ae.explainer.setDecision(u.ID, idp.ExplainDecisionAccessPolicyDenied)
+			}
 			continue
 		}
 
 		if limits.limit == 0 || len(allowedUsers) < limits.limit {
 			allowedUsers = append(allowedUsers, u)
+			if ae.explainer != nil {
+				ae.explainer.setDecision(u.ID, idp.ExplainDecisionAllowed)
+			}
 			if len(allowedUsers) == limits.limit {
 				limits.lastUser = u
 				limits.hasMore = limits.hasMore || i < len(candidateUsers)-1
@@ -871,7 +907,11 @@ func (ae *accessorExecutor) getAllowedUsers() ([]storage.User, *pagination.Respo
 		limits.numAllowed++
 		if limits.maxExceeded() {
 			uclog.Warningf(ae.ctx, "accessor '%v' execution failed due to result limit. num allowed: %d  max limit: %d. Announce: %v", ae.accessor.ID, limits.numAllowed, limits.maxLimit, ae.thresholdAP.Thresholds.AnnounceMaxResultFailure)
-			if ae.thresholdAP.Thresholds.AnnounceMaxResultFailure {
+			if ae.explainer != nil {
+				ae.explainer.explanation.ResultThresholdExceeded = true
+				ae.explainer.setDecision(u.ID, idp.ExplainDecisionResultThresholdExceeded)
+			}
+			if ae.thresholdAP.Thresholds.AnnounceMaxResultFailure && ae.explainer == nil {
 				return nil,
 					nil,
 					http.StatusBadRequest,
@@ -892,6 +932,50 @@ func (ae *accessorExecutor) getAllowedUsers() ([]storage.User, *pagination.Respo
 	return allowedUsers, respFields, http.StatusOK, nil
 }
 
+// executeAccessPolicy executes the accessor's access policy for a user, recording the evaluation of each of its
+// components if the execution is being explained
+func (ae *accessorExecutor) executeAccessPolicy(u storage.User, apContext policy.AccessPolicyContext) (bool, error) {
+	if ae.explainer == nil {
+		allowed, console, err := tokenizer.ExecuteAccessPolicy(ae.ctx, ae.clientAP, apContext, ae.authzClient, ae.s)
+		if err != nil {
+			return false, ucerr.Wrap(err)
+		}
+		ae.accessPolicyConsole += console
+		return allowed, nil
+	}
+
+	eval, err := tokenizer.ExplainAccessPolicy(ae.ctx, ae.clientAP, apContext, ae.authzClient, ae.s)
+	if err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	columnAccessPolicyIDs := map[string]uuid.UUID{}
+	for _, c := range ae.accessorColumns {
+		columnAccessPolicyIDs[c.Name] = c.AccessPolicyID
+	}
+	ae.explainer.setAccessPolicies(u.ID, *eval, columnAccessPolicyIDs)
+
+	return eval.Allowed, nil
+}
+
+// explainSelectedUsers adds the users matched by the selector to the explanation, looking the users up again
+// without the accessor's purposes to find the users and columns that the purposes filtered out
+func (ae *accessorExecutor) explainSelectedUsers(limits *accessorLimits, candidateUsers []storage.User) error {
+	unfilteredUsers, needSort, _, err := ae.getUsersForSelector(limits.pager, set.NewUUIDSet())
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if needSort {
+		if unfilteredUsers, _, err = ae.sortMergedCandidateUsers(unfilteredUsers); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	ae.explainer.explanation.SearchRowCount = ae.numSearchRows
+	ae.explainer.addSelectedUsers(candidateUsers, unfilteredUsers, ae.accessorColumns, limits.hasMore)
+	return nil
+}
+
 func (ae accessorExecutor) getLimits() (*accessorLimits, error) {
 	limits := accessorLimits{
 		pager: ae.pager,
@@ -1004,11 +1088,14 @@ func (ae *accessorExecutor) initialize() error {
 		}
 	}
 
-	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ae.ctx)
-	if err != nil {
-		return ucerr.Wrap(err)
+	// callers without a request to pass through, like background exports, provide their own authz client
+	if ae.authzClient == nil {
+		authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ae.ctx)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		ae.authzClient = authzClient
 	}
-	ae.authzClient = authzClient
 
 	return nil
 }
@@ -1182,94 +1269,174 @@ func (ae *accessorExecutor) transformResults(users []storage.User) ([]string, er
 
 	output := make([]string, 0, len(users))
 	for _, u := range users {
-		// configure the transformers for the profile strings
-		var transformableValues []transformableValue
-		var transformerParams []tokenizer.ExecuteTransformerParameters
-		for i, c := range ae.accessorColumns {
-			value := u.Profile[c.Name]
-			if value == nil {
-				continue
-			}
+		profileValues, err := ae.transformUser(&te, u)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		userOutput, err := json.Marshal(profileValues)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		output = append(output, string(userOutput))
+	}
 
-			transformerID := ae.accessor.TransformerIDs[i]
-			tokenAccessPolicyID := ae.accessor.TokenAccessPolicyIDs[i]
-			if transformerID.IsNil() {
-				transformerID = c.DefaultTransformerID
-				tokenAccessPolicyID = c.DefaultTokenAccessPolicyID
-			}
-			transformer := ae.transformerMap[transformerID]
-			tv, err := newTransformableOutputValue(
-				ae.ctx,
-				ae.dtm,
-				c,
-				*transformer,
-				ae.isArrayColumn(c),
-				value,
-			)
-			if err != nil {
-				return nil, ucerr.Wrap(err)
-			}
+	ae.succeeded = true
+	return output, nil
+}
 
-			if tv.shouldTransform {
-				inputs, err := tv.getTransformableInputs(ae.ctx)
-				if err != nil {
-					return nil, ucerr.Wrap(err)
-				}
+// transformUser returns the transformed values of the accessor columns for the user, keyed by column name
+func (ae *accessorExecutor) transformUser(te *tokenizer.TransformerExecutor, u storage.User) (map[string]any, error) {
+	// configure the transformers for the profile strings
+	var transformableValues []transformableValue
+	var transformerParams []tokenizer.ExecuteTransformerParameters
+	var transformerParamStarts []int
+	for i, c := range ae.accessorColumns {
+		value := u.Profile[c.Name]
+		if value == nil {
+			continue
+		}
 
-				for _, input := range inputs {
-					transformerParams = append(
-						transformerParams,
-						tokenizer.ExecuteTransformerParameters{
-							Transformer:         transformer,
-							TokenAccessPolicyID: tokenAccessPolicyID,
-							Data:                input,
-							DataProvenance:      &policy.UserstoreDataProvenance{UserID: u.ID, ColumnID: c.ID},
-						},
-					)
-					tv.addValueIndex(len(transformerParams) - 1)
-				}
-			}
+		transformerID := ae.accessor.TransformerIDs[i]
+		tokenAccessPolicyID := ae.accessor.TokenAccessPolicyIDs[i]
+		if transformerID.IsNil() {
+			transformerID = c.DefaultTransformerID
+			tokenAccessPolicyID = c.DefaultTokenAccessPolicyID
+		}
+		transformer := ae.transformerMap[transformerID]
+		tv, err := newTransformableOutputValue(
+			ae.ctx,
+			ae.dtm,
+			c,
+			*transformer,
+			ae.isArrayColumn(c),
+			value,
+		)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
 
-			if err := tv.Validate(); err != nil {
+		transformerParamStarts = append(transformerParamStarts, len(transformerParams))
+		if tv.shouldTransform {
+			inputs, err := tv.getTransformableInputs(ae.ctx)
+			if err != nil {
 				return nil, ucerr.Wrap(err)
 			}
 
-			transformableValues = append(transformableValues, *tv)
+			for _, input := range inputs {
+				transformerParams = append(
+					transformerParams,
+					tokenizer.ExecuteTransformerParameters{
+						Transformer:         transformer,
+						TokenAccessPolicyID: tokenAccessPolicyID,
+						Data:                input,
+						DataProvenance:      &policy.UserstoreDataProvenance{UserID: u.ID, ColumnID: c.ID},
+					},
+				)
+				tv.addValueIndex(len(transformerParams) - 1)
+			}
+		}
+
+		if err := tv.Validate(); err != nil {
+			return nil, ucerr.Wrap(err)
 		}
 
-		var transformedValues []string
-		if len(transformerParams) > 0 {
-			var err error
-			var transformerConsole string
+		transformableValues = append(transformableValues, *tv)
+	}
 
-			// execute the transformers
-			uclog.Infof(ae.ctx, "Transforming %v values for accessor %v [%v]", len(transformerParams), ae.accessor.Name, ae.accessor.ID)
-			transformedValues, transformerConsole, err = te.Execute(ae.ctx, transformerParams...)
+	var transformedValues []string
+	skippedColumns := set.NewStringSet()
+	if ae.explainer != nil {
+		// transform each column separately so that the time spent on each can be reported
+		for i, tv := range transformableValues {
+			end := len(transformerParams)
+			if i+1 < len(transformerParamStarts) {
+				end = transformerParamStarts[i+1]
+			}
+			values, skipped, err := ae.explainTransformer(te, u.ID, tv.columnName, transformerParams[transformerParamStarts[i]:end])
 			if err != nil {
-				logAccessorTransformerError(ae.ctx, ae.accessor.ID, ae.accessor.Version)
 				return nil, ucerr.Wrap(err)
 			}
-			ae.transformerConsole += transformerConsole
+			if skipped {
+				skippedColumns.Insert(tv.columnName)
+			}
+			transformedValues = append(transformedValues, values...)
 		}
+	} else if len(transformerParams) > 0 {
+		var err error
+		var transformerConsole string
 
-		// marshal the transformed values
-		profileValues := map[string]any{}
-		for _, tv := range transformableValues {
-			value, err := tv.getValue(ae.ctx, transformedValues)
-			if err != nil {
-				return nil, ucerr.Wrap(err)
-			}
-			profileValues[tv.columnName] = value
+		// execute the transformers
+		uclog.Infof(ae.ctx, "Transforming %v values for accessor %v [%v]", len(transformerParams), ae.accessor.Name, ae.accessor.ID)
+		transformedValues, transformerConsole, err = te.Execute(ae.ctx, transformerParams...)
+		if err != nil {
+			logAccessorTransformerError(ae.ctx, ae.accessor.ID, ae.accessor.Version)
+			return nil, ucerr.Wrap(err)
 		}
-		userOutput, err := json.Marshal(profileValues)
+		ae.transformerConsole += transformerConsole
+	}
+
+	// collect the transformed values
+	profileValues := map[string]any{}
+	for _, tv := range transformableValues {
+		if skippedColumns.Contains(tv.columnName) {
+			continue
+		}
+		value, err := tv.getValue(ae.ctx, transformedValues)
 		if err != nil {
 			return nil, ucerr.Wrap(err)
 		}
-		output = append(output, string(userOutput))
+		profileValues[tv.columnName] = value
 	}
+	return profileValues, nil
+}
 
-	ae.succeeded = true
-	return output, nil
+// explainTransformer executes the transformer parameters for one column of a user, recording the transformer used,
+// its console output, and the time taken in the explanation. Transformers that create tokens are not executed,
+// so that explaining an accessor has no side effects; placeholder values are returned for them and skipped is true.
+func (ae *accessorExecutor) explainTransformer(
+	te *tokenizer.TransformerExecutor,
+	userID uuid.UUID,
+	columnName string,
+	params []tokenizer.ExecuteTransformerParameters,
+) ([]string, bool, error) {
+	row := ae.explainer.getRow(userID)
+	var col *idp.ExplainedColumn
+	if row != nil {
+		for i := range row.Columns {
+			if row.Columns[i].Column == columnName {
+				col = &row.Columns[i]
+				break
+			}
+		}
+	}
+
+	if len(params) == 0 {
+		return nil, false, nil
+	}
+
+	if params[0].Transformer.CreatesTokens() {
+		if col != nil {
+			col.TransformerID = params[0].Transformer.ID
+			col.TransformerName = params[0].Transformer.Name
+			col.TransformerConsole = "transformer creates tokens and was not executed for explain"
+		}
+		return make([]string, len(params)), true, nil
+	}
+
+	start := time.Now().UTC()
+	values, console, err := te.Execute(ae.ctx, params...)
+	if err != nil {
+		logAccessorTransformerError(ae.ctx, ae.accessor.ID, ae.accessor.Version)
+		return nil, false, ucerr.Wrap(err)
+	}
+
+	if col != nil {
+		col.TransformerID = params[0].Transformer.ID
+		col.TransformerName = params[0].Transformer.Name
+		col.TransformerConsole = console
+		col.DurationMicroseconds = time.Since(start).Microseconds()
+	}
+	return values, false, nil
 }
 
 type searchExecutor struct {
@@ -1426,6 +1593,11 @@ func executeAccessor(
 		return nil, http.StatusInternalServerError, ae.auditLogInfo(), ucerr.Wrap(err)
 	}
 
+	if req.Explain {
+		ae.explainer = newExecutionExplainer(accessor.ID, accessor.Version, accessor.PurposeIDs, startTime)
+		ae.explainer.explanation.ThresholdAccessPolicyID = ae.thresholdAP.ID
+	}
+
 	// set up pagination
 
 	if err := ae.setupPagination(paginationOptions); err != nil {
@@ -1449,6 +1621,20 @@ func executeAccessor(
 
 	logAccessorSuccess(ctx, accessor.ID, accessor.Version)
 
+	if ae.explainer != nil {
+		// an explained execution returns the decision trace in place of the data
+		return &idp.ExecuteAccessorResponse{
+				Data:           []string{},
+				Debug:          ae.debugInfo(),
+				Truncated:      ae.truncated,
+				ResponseFields: *respFields,
+				Explanation:    ae.explainer.getExplanation(),
+			},
+			http.StatusOK,
+			ae.auditLogInfo(),
+			nil
+	}
+
 	return &idp.ExecuteAccessorResponse{
 			Data:           output,
 			Debug:          ae.debugInfo(),
@@ -1484,22 +1670,12 @@ func (h *handler) executeAccessorHandler(
 		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
-	includeDebug := false
-	if req.ExecuteAccessorRequest.Debug {
-		authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
-		if err != nil {
-			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
-		}
-		ts := multitenant.MustGetTenantState(ctx)
-		resp, err := authzClient.CheckAttribute(ctx, auth.GetSubjectUUID(ctx), ts.CompanyID, "_admin")
-		if err != nil {
-			return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
-		}
-		if !resp.HasAttribute {
-			return nil, http.StatusForbidden, nil, ucerr.Friendlyf(nil, "You must be an admin to view debug information")
+	if req.ExecuteAccessorRequest.Debug || req.ExecuteAccessorRequest.Explain {
+		if code, err := ensureAdminForExplain(ctx); err != nil {
+			return nil, code, nil, ucerr.Wrap(err)
 		}
-		includeDebug = true
 	}
+	includeDebug := req.ExecuteAccessorRequest.Debug
 
 	resp, code, entries, err :=
 		executeAccessor(
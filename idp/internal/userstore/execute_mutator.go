@@ -35,6 +35,19 @@ func ExecuteMutator(
 	authzClient *authz.Client,
 	searchUpdateConfig *config.SearchUpdateConfig,
 ) ([]uuid.UUID, int, error) {
+	userIDs, _, code, err := executeMutator(ctx, req, tenantID, authzClient, searchUpdateConfig)
+	return userIDs, code, ucerr.Wrap(err)
+}
+
+// executeMutator executes a mutator, or if the request asks for an explanation, evaluates the mutator's access
+// policies for each selected user and returns the decisions made without applying any mutations
+func executeMutator(
+	ctx context.Context,
+	req idp.ExecuteMutatorRequest,
+	tenantID uuid.UUID,
+	authzClient *authz.Client,
+	searchUpdateConfig *config.SearchUpdateConfig,
+) ([]uuid.UUID, *idp.ExecutionExplanation, int, error) {
 	startTime := time.Now().UTC()
 
 	ts := multitenant.MustGetTenantState(ctx)
@@ -43,10 +56,10 @@ func ExecuteMutator(
 	mutator, err := configStorage.GetLatestMutator(ctx, req.MutatorID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, http.StatusBadRequest, ucerr.Wrap(err)
+			return nil, nil, http.StatusBadRequest, ucerr.Wrap(err)
 		}
 
-		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	}
 
 	defer logMutatorDuration(ctx, mutator.ID, mutator.Version, startTime)
@@ -54,18 +67,23 @@ func ExecuteMutator(
 
 	cm, err := storage.NewUserstoreColumnManager(ctx, configStorage)
 	if err != nil {
-		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	}
 	columns := cm.GetColumns()
 
 	columnMutations, code, err := getColumnMutations(ctx, configStorage, columns, mutator, req)
 	if err != nil {
-		return nil, code, ucerr.Wrap(err)
+		return nil, nil, code, ucerr.Wrap(err)
+	}
+
+	var explainer *executionExplainer
+	if req.Explain {
+		explainer = newExecutionExplainer(mutator.ID, mutator.Version, nil, startTime)
 	}
 
 	dtm, err := storage.NewDataTypeManager(ctx, configStorage)
 	if err != nil {
-		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	}
 
 	noColumnIDs := set.NewUUIDSet()
@@ -75,7 +93,7 @@ func ExecuteMutator(
 	if req.Region != "" {
 		regDB, ok := ts.UserRegionDbMap[req.Region]
 		if !ok {
-			return nil, http.StatusBadRequest, ucerr.Friendlyf(nil, "data region '%s' is not available for tenant", req.Region)
+			return nil, nil, http.StatusBadRequest, ucerr.Friendlyf(nil, "data region '%s' is not available for tenant", req.Region)
 		}
 		us := storage.NewUserStorage(ctx, regDB, req.Region, tenantID)
 		var users []storage.User
@@ -95,7 +113,7 @@ func ExecuteMutator(
 		)
 		if err != nil {
 			logMutatorNotFoundError(ctx, mutator.ID, mutator.Version)
-			return nil, code, ucerr.Wrap(err)
+			return nil, nil, code, ucerr.Wrap(err)
 		}
 		if len(users) > 0 {
 			usersByRegion = map[region.DataRegion][]storage.User{req.Region: users}
@@ -118,7 +136,7 @@ func ExecuteMutator(
 		)
 		if err != nil {
 			logMutatorNotFoundError(ctx, mutator.ID, mutator.Version)
-			return nil, code, ucerr.Wrap(err)
+			return nil, nil, code, ucerr.Wrap(err)
 		}
 	}
 
@@ -132,26 +150,46 @@ func ExecuteMutator(
 		)
 	if err != nil {
 		logMutatorConfigError(ctx, mutator.ID, mutator.Version)
-		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	}
 
 	clientAPs := []*policy.AccessPolicy{globalAP, mutatorAP}
 
+	if explainer != nil {
+		explainer.explanation.ThresholdAccessPolicyID = thresholdAP.ID
+		explainedColumns, err := getExplainedMutatorColumns(ctx, configStorage, columns, mutator)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+		for _, users := range usersByRegion {
+			explainer.addMutatedUsers(users, explainedColumns)
+		}
+	}
+
 	// build base context
 	baseAPContext := tokenizer.BuildBaseAPContext(ctx, req.Context, policy.ActionExecute)
 
 	// verify rate threshold is not exceeded if specified
-	allowed, err := thresholdAP.CheckRateThreshold(ctx, configStorage, baseAPContext, mutator.ID)
+	checkRateThreshold := thresholdAP.CheckRateThreshold
+	if explainer != nil {
+		// explaining an execution should not count against the rate threshold
+		checkRateThreshold = thresholdAP.PeekRateThreshold
+	}
+	allowed, err := checkRateThreshold(ctx, configStorage, baseAPContext, mutator.ID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+		return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	}
 
 	if !allowed {
 		uclog.Infof(ctx, "mutator '%v' execution failed due to rate limit", mutator.ID)
+		if explainer != nil {
+			explainer.explanation.RateThresholdExceeded = true
+			return []uuid.UUID{}, explainer.getExplanation(), http.StatusOK, nil
+		}
 		if thresholdAP.Thresholds.AnnounceMaxExecutionFailure {
-			return nil, http.StatusTooManyRequests, ucerr.Friendlyf(nil, "access policy rate threshold exceeded")
+			return nil, nil, http.StatusTooManyRequests, ucerr.Friendlyf(nil, "access policy rate threshold exceeded")
 		}
-		return nil, http.StatusOK, nil
+		return nil, nil, http.StatusOK, nil
 	}
 
 	// filter out users that do not pass access policies
@@ -164,25 +202,48 @@ func ExecuteMutator(
 
 			allowed := true
 			for _, clientAP := range clientAPs {
-				allowed, _, err = tokenizer.ExecuteAccessPolicy(ctx, clientAP, apContext, authzClient, configStorage)
-				if err != nil {
-					logMutatorConfigError(ctx, mutator.ID, mutator.Version)
-					return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+				if explainer != nil {
+					eval, err := tokenizer.ExplainAccessPolicy(ctx, clientAP, apContext, authzClient, configStorage)
+					if err != nil {
+						logMutatorConfigError(ctx, mutator.ID, mutator.Version)
+						return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
+					}
+					explainer.setAccessPolicies(u.ID, *eval, nil)
+					allowed = eval.Allowed
+				} else {
+					allowed, _, err = tokenizer.ExecuteAccessPolicy(ctx, clientAP, apContext, authzClient, configStorage)
+					if err != nil {
+						logMutatorConfigError(ctx, mutator.ID, mutator.Version)
+						return nil, nil, http.StatusInternalServerError, ucerr.Wrap(err)
+					}
 				}
 				if !allowed {
 					break
 				}
 			}
 
+			if !allowed && explainer != nil {
+				explainer.setDecision(u.ID, idp.ExplainDecisionAccessPolicyDenied)
+			}
+
 			if allowed {
 				approvedUsers = append(approvedUsers, u)
 
 				if allowed = thresholdAP.CheckResultThreshold(len(approvedUsers)); !allowed {
 					uclog.Infof(ctx, "mutator '%v' execution failed due to result limit", mutator.ID)
+					if explainer != nil {
+						explainer.explanation.ResultThresholdExceeded = true
+						explainer.setDecision(u.ID, idp.ExplainDecisionResultThresholdExceeded)
+						return []uuid.UUID{}, explainer.getExplanation(), http.StatusOK, nil
+					}
 					if thresholdAP.Thresholds.AnnounceMaxResultFailure {
-						return nil, http.StatusBadRequest, ucerr.Friendlyf(nil, "access policy result threshold exceeded")
+						return nil, nil, http.StatusBadRequest, ucerr.Friendlyf(nil, "access policy result threshold exceeded")
 					}
-					return nil, http.StatusOK, nil
+					return nil, nil, http.StatusOK, nil
+				}
+
+				if explainer != nil {
+					explainer.setDecision(u.ID, idp.ExplainDecisionAllowed)
 				}
 			}
 		}
@@ -192,6 +253,11 @@ func ExecuteMutator(
 		}
 	}
 
+	// an explained execution is a dry run, so stop before applying any mutations
+	if explainer != nil {
+		return []uuid.UUID{}, explainer.getExplanation(), http.StatusOK, nil
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	userIDs := []uuid.UUID{}
@@ -201,7 +267,7 @@ func ExecuteMutator(
 	for r, users := range approvedUsersByRegion {
 		regionDB, ok := ts.UserRegionDbMap[r]
 		if !ok {
-			return nil, http.StatusInternalServerError, ucerr.Friendlyf(nil, "region '%v' not an available remote region", r)
+			return nil, nil, http.StatusInternalServerError, ucerr.Friendlyf(nil, "region '%v' not an available remote region", r)
 		}
 		userStorage := storage.NewUserStorage(ctx, regionDB, r, tenantID)
 
@@ -277,7 +343,7 @@ func ExecuteMutator(
 		logMutatorSuccess(ctx, mutator.ID, mutator.Version)
 	}
 
-	return userIDs, retCode, ucerr.Wrap(firstErr)
+	return userIDs, nil, retCode, ucerr.Wrap(firstErr)
 }
 
 // OpenAPI Summary: Execute Mutator
@@ -289,6 +355,12 @@ func (h *handler) executeMutatorHandler(ctx context.Context, req idp.ExecuteMuta
 		return nil, http.StatusForbidden, nil, ucerr.Wrap(err)
 	}
 
+	if req.Explain {
+		if code, err := ensureAdminForExplain(ctx); err != nil {
+			return nil, code, nil, ucerr.Wrap(err)
+		}
+	}
+
 	ts := multitenant.MustGetTenantState(ctx)
 
 	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
@@ -296,7 +368,7 @@ func (h *handler) executeMutatorHandler(ctx context.Context, req idp.ExecuteMuta
 		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
 	}
 
-	userIDs, code, err := ExecuteMutator(ctx, req, ts.ID, authzClient, h.searchUpdateConfig)
+	userIDs, explanation, code, err := executeMutator(ctx, req, ts.ID, authzClient, h.searchUpdateConfig)
 	if err != nil {
 		switch code {
 		case http.StatusBadRequest:
@@ -312,5 +384,35 @@ func (h *handler) executeMutatorHandler(ctx context.Context, req idp.ExecuteMuta
 		}
 	}
 
-	return &idp.ExecuteMutatorResponse{UserIDs: userIDs}, http.StatusOK, nil, nil
+	return &idp.ExecuteMutatorResponse{UserIDs: userIDs, Explanation: explanation}, http.StatusOK, nil, nil
+}
+
+// getExplainedMutatorColumns returns the columns written by a mutator along with the normalizer used for each
+func getExplainedMutatorColumns(
+	ctx context.Context,
+	s *storage.Storage,
+	columns []storage.Column,
+	mutator *storage.Mutator,
+) ([]idp.ExplainedColumn, error) {
+	normalizerMap, err := s.GetTransformersMap(ctx, mutator.NormalizerIDs)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	explainedColumns := []idp.ExplainedColumn{}
+	for i, columnID := range mutator.ColumnIDs {
+		for _, c := range columns {
+			if c.ID != columnID {
+				continue
+			}
+			col := idp.ExplainedColumn{Column: c.Name, Decision: idp.ExplainDecisionAllowed}
+			if normalizer, found := normalizerMap[mutator.NormalizerIDs[i]]; found {
+				col.TransformerID = normalizer.ID
+				col.TransformerName = normalizer.Name
+			}
+			explainedColumns = append(explainedColumns, col)
+			break
+		}
+	}
+	return explainedColumns, nil
 }
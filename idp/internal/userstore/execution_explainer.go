@@ -0,0 +1,177 @@
+package userstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/ucerr"
+)
+
+// errExplainForbidden is returned for debug and explain requests from anyone but an admin of the tenant's company,
+// since their output describes how the tenant's policies are configured
+var errExplainForbidden = ucerr.Friendlyf(nil, "You must be an admin to view debug information")
+
+// ensureAdminForExplain returns an error unless the caller is an admin of the tenant's company
+func ensureAdminForExplain(ctx context.Context) (int, error) {
+	code, err := internal.EnsureCompanyAdmin(ctx, internal.NewAdminChecker, errExplainForbidden)
+	return code, ucerr.Wrap(err)
+}
+
+// executionExplainer builds the explanation of an accessor or mutator execution. Rows are added for every user
+// matched by the selector, and each row's decision is filled in as the execution proceeds; rows that are never
+// decided were matched but fell beyond the requested page.
+type executionExplainer struct {
+	startTime   time.Time
+	explanation idp.ExecutionExplanation
+	rowIndexes  map[uuid.UUID]int
+}
+
+func newExecutionExplainer(id uuid.UUID, version int, purposeIDs []uuid.UUID, startTime time.Time) *executionExplainer {
+	return &executionExplainer{
+		startTime: startTime,
+		explanation: idp.ExecutionExplanation{
+			ID:         id,
+			Version:    version,
+			PurposeIDs: purposeIDs,
+			Rows:       []idp.ExplainedRow{},
+		},
+		rowIndexes: map[uuid.UUID]int{},
+	}
+}
+
+// addSelectedUsers adds a row for each user matched by the selector. The candidates are the users that have
+// values with all of the expected purposes, and the unfiltered users are the users matched by the selector without
+// regard to purposes, so any user or column that is only in the latter was filtered out by the purposes. If the
+// candidates were truncated to a page, unfiltered users after the last candidate are left for a later page.
+func (ee *executionExplainer) addSelectedUsers(candidates []storage.User, unfiltered []storage.User, columns storage.Columns, truncated bool) {
+	candidatesByID := map[uuid.UUID]storage.User{}
+	for _, u := range candidates {
+		candidatesByID[u.ID] = u
+	}
+
+	lastCandidate := -1
+	for i, u := range unfiltered {
+		if _, found := candidatesByID[u.ID]; found {
+			lastCandidate = i
+		}
+	}
+
+	for i, u := range unfiltered {
+		if truncated && i > lastCandidate {
+			break
+		}
+
+		candidate, found := candidatesByID[u.ID]
+
+		row := idp.ExplainedRow{
+			UserID:         u.ID,
+			Region:         u.Region,
+			AccessPolicies: []idp.AccessPolicyEvaluation{},
+			Columns:        []idp.ExplainedColumn{},
+		}
+		if !found {
+			row.Decision = idp.ExplainDecisionPurposeDenied
+		}
+
+		for _, c := range columns {
+			col := idp.ExplainedColumn{Column: c.Name, Decision: idp.ExplainDecisionNoValue}
+			if _, hasValue := candidate.ColumnValues[c.Name]; found && hasValue {
+				col.Decision = idp.ExplainDecisionAllowed
+			} else if _, hasValue := u.ColumnValues[c.Name]; hasValue {
+				col.Decision = idp.ExplainDecisionPurposeDenied
+			}
+			row.Columns = append(row.Columns, col)
+		}
+
+		ee.rowIndexes[u.ID] = len(ee.explanation.Rows)
+		ee.explanation.Rows = append(ee.explanation.Rows, row)
+	}
+	ee.explanation.SelectorRowCount = len(unfiltered)
+}
+
+// addMutatedUsers adds a row for each user matched by a mutator's selector, each listing the columns that would be
+// mutated along with the normalizer used for each
+func (ee *executionExplainer) addMutatedUsers(users []storage.User, columns []idp.ExplainedColumn) {
+	for _, u := range users {
+		row := idp.ExplainedRow{
+			UserID:         u.ID,
+			Region:         u.Region,
+			AccessPolicies: []idp.AccessPolicyEvaluation{},
+			Columns:        append([]idp.ExplainedColumn{}, columns...),
+		}
+		ee.rowIndexes[u.ID] = len(ee.explanation.Rows)
+		ee.explanation.Rows = append(ee.explanation.Rows, row)
+	}
+	ee.explanation.SelectorRowCount += len(users)
+}
+
+func (ee *executionExplainer) getRow(userID uuid.UUID) *idp.ExplainedRow {
+	i, found := ee.rowIndexes[userID]
+	if !found {
+		return nil
+	}
+	return &ee.explanation.Rows[i]
+}
+
+// setAccessPolicies records the access policies evaluated for a row, and attributes the evaluations of column
+// access policies to their columns
+func (ee *executionExplainer) setAccessPolicies(userID uuid.UUID, eval idp.AccessPolicyEvaluation, columnAccessPolicyIDs map[string]uuid.UUID) {
+	row := ee.getRow(userID)
+	if row == nil {
+		return
+	}
+
+	// the accessor's composite policy has no ID of its own, so report its components directly
+	evals := []idp.AccessPolicyEvaluation{eval}
+	if eval.AccessPolicyID.IsNil() {
+		evals = eval.Components
+	}
+	row.AccessPolicies = append(row.AccessPolicies, evals...)
+
+	for i := range row.Columns {
+		apID, found := columnAccessPolicyIDs[row.Columns[i].Column]
+		if !found {
+			continue
+		}
+		for _, e := range evals {
+			if e.AccessPolicyID == apID {
+				row.Columns[i].AccessPolicy = &e
+				break
+			}
+		}
+	}
+}
+
+// setDecision records the decision for a row, which also applies to any of its columns that had a value
+func (ee *executionExplainer) setDecision(userID uuid.UUID, decision idp.ExplainDecision) {
+	if row := ee.getRow(userID); row != nil {
+		setRowDecision(row, decision)
+	}
+}
+
+func setRowDecision(row *idp.ExplainedRow, decision idp.ExplainDecision) {
+	row.Decision = decision
+	if decision == idp.ExplainDecisionAllowed {
+		return
+	}
+	for i := range row.Columns {
+		if row.Columns[i].Decision == idp.ExplainDecisionAllowed {
+			row.Columns[i].Decision = decision
+		}
+	}
+}
+
+func (ee *executionExplainer) getExplanation() *idp.ExecutionExplanation {
+	for i := range ee.explanation.Rows {
+		if ee.explanation.Rows[i].Decision == "" {
+			setRowDecision(&ee.explanation.Rows[i], idp.ExplainDecisionBeyondLimit)
+		}
+	}
+	ee.explanation.DurationMicroseconds = time.Since(ee.startTime).Microseconds()
+	return &ee.explanation
+}
@@ -0,0 +1,91 @@
+package userstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/assert"
+)
+
+func newExplainerTestUser(columnNames ...string) storage.User {
+	u := storage.User{ColumnValues: storage.ColumnConsentedValues{}}
+	u.ID = uuid.Must(uuid.NewV4())
+	for _, name := range columnNames {
+		u.ColumnValues[name] = map[uuid.UUID]storage.ColumnConsentedValue{
+			uuid.Must(uuid.NewV4()): {ColumnName: name},
+		}
+	}
+	return u
+}
+
+func TestExecutionExplainerSelectedUsers(t *testing.T) {
+	columns := storage.Columns{{Name: "email"}, {Name: "phone"}}
+
+	allowed := newExplainerTestUser("email", "phone")
+	denied := newExplainerTestUser("email")
+	filtered := newExplainerTestUser("email", "phone")
+	later := newExplainerTestUser("email")
+
+	// the candidate for the allowed user was only consented for email
+	allowedCandidate := newExplainerTestUser("email")
+	allowedCandidate.ID = allowed.ID
+
+	ee := newExecutionExplainer(uuid.Must(uuid.NewV4()), 1, nil, time.Now().UTC())
+	ee.addSelectedUsers(
+		[]storage.User{allowedCandidate, denied},
+		[]storage.User{allowed, filtered, denied, later},
+		columns,
+		true,
+	)
+	ee.setDecision(allowed.ID, idp.ExplainDecisionAllowed)
+	ee.setDecision(denied.ID, idp.ExplainDecisionAccessPolicyDenied)
+
+	explanation := ee.getExplanation()
+	assert.Equal(t, explanation.SelectorRowCount, 4)
+	assert.Equal(t, len(explanation.Rows), 3)
+
+	assert.Equal(t, explanation.Rows[0].Decision, idp.ExplainDecisionAllowed)
+	assert.Equal(t, explanation.Rows[0].Columns[0].Decision, idp.ExplainDecisionAllowed)
+	assert.Equal(t, explanation.Rows[0].Columns[1].Decision, idp.ExplainDecisionPurposeDenied)
+
+	assert.Equal(t, explanation.Rows[1].UserID, filtered.ID)
+	assert.Equal(t, explanation.Rows[1].Decision, idp.ExplainDecisionPurposeDenied)
+	assert.Equal(t, explanation.Rows[1].Columns[0].Decision, idp.ExplainDecisionPurposeDenied)
+
+	assert.Equal(t, explanation.Rows[2].Decision, idp.ExplainDecisionAccessPolicyDenied)
+	assert.Equal(t, explanation.Rows[2].Columns[0].Decision, idp.ExplainDecisionAccessPolicyDenied)
+	assert.Equal(t, explanation.Rows[2].Columns[1].Decision, idp.ExplainDecisionNoValue)
+}
+
+func TestExecutionExplainerAccessPolicies(t *testing.T) {
+	columns := storage.Columns{{Name: "email"}, {Name: "phone"}}
+	u := newExplainerTestUser("email", "phone")
+
+	ee := newExecutionExplainer(uuid.Must(uuid.NewV4()), 1, nil, time.Now().UTC())
+	ee.addSelectedUsers([]storage.User{u}, []storage.User{u}, columns, false)
+
+	globalAPID := uuid.Must(uuid.NewV4())
+	emailAPID := uuid.Must(uuid.NewV4())
+	ee.setAccessPolicies(
+		u.ID,
+		idp.AccessPolicyEvaluation{
+			Allowed: false,
+			Components: []idp.AccessPolicyEvaluation{
+				{AccessPolicyID: globalAPID, Allowed: true},
+				{AccessPolicyID: emailAPID, Allowed: false},
+			},
+		},
+		map[string]uuid.UUID{"email": emailAPID},
+	)
+	ee.setDecision(u.ID, idp.ExplainDecisionAccessPolicyDenied)
+
+	explanation := ee.getExplanation()
+	assert.Equal(t, len(explanation.Rows[0].AccessPolicies), 2)
+	assert.NotNil(t, explanation.Rows[0].Columns[0].AccessPolicy)
+	assert.Equal(t, explanation.Rows[0].Columns[0].AccessPolicy.AccessPolicyID, emailAPID)
+	assert.IsNil(t, explanation.Rows[0].Columns[1].AccessPolicy)
+}
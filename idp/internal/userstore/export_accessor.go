@@ -0,0 +1,521 @@
+package userstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/idp"
+	"userclouds.com/idp/config"
+	"userclouds.com/idp/internal"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/idp/internal/tokenizer"
+	userstorePublic "userclouds.com/idp/userstore"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/parquet"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/auth/m2m"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantmap"
+	"userclouds.com/worker"
+)
+
+// accessorExporter pages through all of the users an accessor returns in ID order, applying the accessor's
+// purposes, access policies and transformers to each page, so that an export can be resumed after any page
+type accessorExporter struct {
+	ae             accessorExecutor
+	format         idp.ExportFormat
+	startingAfter  pagination.Cursor
+	selectorConfig userstorePublic.UserSelectorConfig
+	selectorValues userstorePublic.UserSelectorValues
+	numExported    int
+}
+
+func newAccessorExporter(
+	ctx context.Context,
+	searchUpdateConfig *config.SearchUpdateConfig,
+	req idp.ExportAccessorRequest,
+	authzClient *authz.Client,
+) (*accessorExporter, int, error) {
+	s := storage.MustCreateStorage(ctx)
+
+	accessor, err := s.GetLatestAccessor(ctx, req.AccessorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, http.StatusBadRequest, ucerr.Wrap(err)
+		}
+		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	ae := newAccessorExecutor(
+		ctx,
+		s,
+		searchUpdateConfig,
+		idp.ExecuteAccessorRequest{
+			AccessorID:          req.AccessorID,
+			Context:             req.Context,
+			SelectorValues:      req.SelectorValues,
+			Region:              req.Region,
+			AccessPrimaryDBOnly: req.AccessPrimaryDBOnly,
+		},
+		time.Now().UTC(),
+		false,
+		accessor,
+	)
+	ae.authzClient = authzClient
+	if err := ae.initialize(); err != nil {
+		logAccessorConfigError(ctx, accessor.ID, accessor.Version)
+		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	return &accessorExporter{
+			ae:             ae,
+			format:         req.Format,
+			startingAfter:  req.StartingAfter,
+			selectorConfig: accessor.SelectorConfig,
+			selectorValues: req.SelectorValues,
+		},
+		http.StatusOK,
+		nil
+}
+
+func (ex accessorExporter) auditLogInfo() []auditlog.Entry {
+	if !ex.ae.accessor.IsAuditLogged {
+		return nil
+	}
+
+	return auditlog.NewEntryArray(
+		auth.GetAuditLogActor(ex.ae.ctx),
+		internal.AuditLogEventTypeExportAccessor,
+		auditlog.Payload{
+			"ID":                      ex.ae.accessor.ID,
+			"Name":                    ex.ae.accessor.Name,
+			"Version":                 ex.ae.accessor.Version,
+			"Succeeded":               ex.ae.succeeded,
+			"Format":                  ex.format,
+			"StartingAfter":           ex.startingAfter,
+			"SelectorValues":          ex.selectorValues,
+			"RowsExported":            ex.numExported,
+			"AccessPolicyContext":     ex.ae.apContext,
+			"AccessPolicyDeniedCount": ex.ae.numDenied,
+		},
+	)
+}
+
+func (ex accessorExporter) columnNames() []string {
+	names := make([]string, 0, len(ex.ae.accessorColumns))
+	for _, c := range ex.ae.accessorColumns {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// exportPage returns the transformed rows for the page of users after the cursor, and the cursor of the next page
+func (ex *accessorExporter) exportPage(cursor pagination.Cursor) ([]map[string]any, pagination.Cursor, int, error) {
+	// search-based selectors are rewritten while a page is fetched, so each page starts from the original selector,
+	// and the consoles are only used for debug output, so we don't let them grow over the whole export
+	ex.ae.accessor.SelectorConfig = ex.selectorConfig
+	ex.ae.req.SelectorValues = ex.selectorValues
+	ex.ae.accessPolicyConsole = ""
+	ex.ae.transformerConsole = ""
+
+	sortKey := "id"
+	sortOrder := string(pagination.OrderAscending)
+	options := accessorPaginationOptions{SortKey: &sortKey, SortOrder: &sortOrder}
+	if cursor != pagination.CursorBegin {
+		startingAfter := string(cursor)
+		options.StartingAfter = &startingAfter
+	}
+	if err := ex.ae.setupPagination(&options); err != nil {
+		return nil, pagination.CursorBegin, http.StatusBadRequest, ucerr.Wrap(err)
+	}
+
+	users, respFields, code, err := ex.ae.getAllowedUsers()
+	if err != nil {
+		logAccessorNotFoundError(ex.ae.ctx, ex.ae.accessor.ID, ex.ae.accessor.Version)
+		return nil, pagination.CursorBegin, code, ucerr.Wrap(err)
+	}
+
+	te := tokenizer.NewTransformerExecutor(ex.ae.s, ex.ae.authzClient)
+	defer te.CleanupExecution()
+
+	rows := make([]map[string]any, 0, len(users))
+	for _, u := range users {
+		row, err := ex.ae.transformUser(&te, u)
+		if err != nil {
+			return nil, pagination.CursorBegin, http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+		rows = append(rows, row)
+	}
+	ex.numExported += len(rows)
+
+	next := pagination.CursorEnd
+	if respFields.HasNext {
+		next = respFields.Next
+	}
+	return rows, next, http.StatusOK, nil
+}
+
+// export calls writePage with each page of the export after the starting cursor, along with the cursor to resume
+// the export from once that page has been written, until there are no more pages
+func (ex *accessorExporter) export(writePage func(rows []map[string]any, next pagination.Cursor) error) (int, error) {
+	defer logAccessorDuration(ex.ae.ctx, ex.ae.accessor.ID, ex.ae.accessor.Version, ex.ae.startTime)
+	logAccessorCall(ex.ae.ctx, ex.ae.accessor.ID, ex.ae.accessor.Version)
+
+	for cursor := ex.startingAfter; cursor != pagination.CursorEnd; {
+		rows, next, code, err := ex.exportPage(cursor)
+		if err != nil {
+			return code, ucerr.Wrap(err)
+		}
+		if err := writePage(rows, next); err != nil {
+			return http.StatusInternalServerError, ucerr.Wrap(err)
+		}
+		cursor = next
+	}
+
+	ex.ae.succeeded = true
+	logAccessorSuccess(ex.ae.ctx, ex.ae.accessor.ID, ex.ae.accessor.Version)
+	return http.StatusOK, nil
+}
+
+// exportRowWriter writes transformed rows in an export format
+type exportRowWriter interface {
+	writeRows(rows []map[string]any) error
+	writeCheckpoint(cursor pagination.Cursor) error
+	close() error
+}
+
+func newExportRowWriter(format idp.ExportFormat, w io.Writer, columns []string, includeHeader bool) (exportRowWriter, error) {
+	switch format {
+	case idp.ExportFormatNDJSON:
+		return &ndjsonExportWriter{w: w}, nil
+	case idp.ExportFormatCSV:
+		ew := &csvExportWriter{w: w, cw: csv.NewWriter(w), columns: columns}
+		if includeHeader {
+			if err := ew.cw.Write(columns); err != nil {
+				return nil, ucerr.Wrap(err)
+			}
+		}
+		return ew, nil
+	case idp.ExportFormatParquet:
+		return &parquetExportWriter{pw: parquet.NewWriter(w, columns), columns: columns}, nil
+	}
+	return nil, ucerr.Friendlyf(nil, "unsupported export format '%s'", format)
+}
+
+// exportValue returns the string representation of a transformed value, which is the value itself for strings and
+// JSON for everything else, or nil for a missing value
+func exportValue(value any) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if s, ok := value.(string); ok {
+		return &s, nil
+	}
+
+	bs, err := json.Marshal(value)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	s := string(bs)
+	return &s, nil
+}
+
+type ndjsonExportWriter struct {
+	w io.Writer
+}
+
+func (ew *ndjsonExportWriter) writeLine(v any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if _, err := ew.w.Write(append(bs, '\n')); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+func (ew *ndjsonExportWriter) writeRows(rows []map[string]any) error {
+	for _, row := range rows {
+		if err := ew.writeLine(row); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (ew *ndjsonExportWriter) writeCheckpoint(cursor pagination.Cursor) error {
+	return ucerr.Wrap(ew.writeLine(map[string]pagination.Cursor{"_checkpoint": cursor}))
+}
+
+func (ndjsonExportWriter) close() error {
+	return nil
+}
+
+type csvExportWriter struct {
+	w       io.Writer
+	cw      *csv.Writer
+	columns []string
+}
+
+func (ew *csvExportWriter) writeRows(rows []map[string]any) error {
+	for _, row := range rows {
+		record := make([]string, len(ew.columns))
+		for i, c := range ew.columns {
+			value, err := exportValue(row[c])
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			if value != nil {
+				record[i] = *value
+			}
+		}
+		if err := ew.cw.Write(record); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	ew.cw.Flush()
+	return ucerr.Wrap(ew.cw.Error())
+}
+
+func (ew *csvExportWriter) writeCheckpoint(cursor pagination.Cursor) error {
+	ew.cw.Flush()
+	if err := ew.cw.Error(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if _, err := io.WriteString(ew.w, idp.ExportCheckpointPrefixCSV+string(cursor)+"\n"); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+func (ew *csvExportWriter) close() error {
+	ew.cw.Flush()
+	return ucerr.Wrap(ew.cw.Error())
+}
+
+type parquetExportWriter struct {
+	pw      *parquet.Writer
+	columns []string
+}
+
+func (ew *parquetExportWriter) writeRows(rows []map[string]any) error {
+	values := make([][]*string, 0, len(rows))
+	for _, row := range rows {
+		record := make([]*string, len(ew.columns))
+		for i, c := range ew.columns {
+			value, err := exportValue(row[c])
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			record[i] = value
+		}
+		values = append(values, record)
+	}
+	return ucerr.Wrap(ew.pw.WriteRowGroup(values))
+}
+
+// writeCheckpoint is a no-op since a parquet file is only readable once its footer has been written
+func (parquetExportWriter) writeCheckpoint(pagination.Cursor) error {
+	return nil
+}
+
+func (ew *parquetExportWriter) close() error {
+	return ucerr.Wrap(ew.pw.Close())
+}
+
+func exportContentType(format idp.ExportFormat) string {
+	switch format {
+	case idp.ExportFormatCSV:
+		return "text/csv"
+	case idp.ExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// exportAccessor streams an accessor export as the response body, flushing it after every page. Once the response
+// has started, errors can't change its status, so they end the stream early, which clients detect by the missing
+// final checkpoint (or parquet footer).
+func (h *handler) exportAccessor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := h.ensureTenantMember(false); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusForbidden))
+		return
+	}
+
+	var req idp.ExportAccessorRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+	if req.Format == idp.ExportFormatParquet && req.StartingAfter != pagination.CursorBegin {
+		jsonapi.MarshalError(ctx, w, ucerr.Friendlyf(nil, "parquet exports can't be resumed part way"), jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	ex, code, err := newAccessorExporter(ctx, h.searchUpdateConfig, req, nil)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	// resumed CSV exports leave out the header, so that the output can be appended to what was already received
+	ew, err := newExportRowWriter(req.Format, w, ex.columnNames(), req.StartingAfter == pagination.CursorBegin)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentType(req.Format))
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	if _, err := ex.export(func(rows []map[string]any, next pagination.Cursor) error {
+		if err := ew.writeRows(rows); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if req.IncludeCheckpoints {
+			if err := ew.writeCheckpoint(next); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}); err != nil {
+		uclog.Errorf(ctx, "export of accessor %v failed after %d rows: %v", req.AccessorID, ex.numExported, err)
+		auditlog.PostMultipleAsync(ctx, ex.auditLogInfo())
+		return
+	}
+
+	if err := ew.close(); err != nil {
+		uclog.Errorf(ctx, "failed to finish export of accessor %v: %v", req.AccessorID, err)
+	}
+	auditlog.PostMultipleAsync(ctx, ex.auditLogInfo())
+}
+
+// errExportToObjectStoreForbidden is returned when a caller that isn't an admin starts an object store export, since
+// it writes everything an accessor returns with the object store's credentials
+var errExportToObjectStoreForbidden = ucerr.Friendlyf(nil, "You must be an admin, or use client credentials, to export to an object store")
+
+// OpenAPI Summary: Export Accessor To Object Store
+// OpenAPI Tags: Accessors
+// OpenAPI Description: This endpoint starts a background export of all of the data an accessor returns to an object store. Part files and a checkpoint manifest are written under the destination prefix, and sending the same request again resumes an interrupted export from the manifest.
+func (h *handler) exportAccessorToObjectStoreHandler(
+	ctx context.Context,
+	req idp.ExportAccessorToObjectStoreRequest,
+) (*idp.ExportAccessorToObjectStoreResponse, int, []auditlog.Entry, error) {
+	if code, err := ensureAdminOrM2M(ctx, errExportToObjectStoreForbidden); err != nil {
+		return nil, code, nil, ucerr.Wrap(err)
+	}
+
+	if h.workerClient == nil {
+		return nil, http.StatusServiceUnavailable, nil, ucerr.Friendlyf(nil, "object store exports are not available in this environment")
+	}
+
+	ts := multitenant.MustGetTenantState(ctx)
+	s := storage.NewFromTenantState(ctx, ts)
+
+	if _, err := s.GetLatestAccessor(ctx, req.AccessorID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, http.StatusNotFound, nil, ucerr.Friendlyf(err, "accessor %v not found", req.AccessorID)
+		}
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	objectStore, err := s.GetShimObjectStore(ctx, req.Destination.ObjectStoreID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, http.StatusNotFound, nil, ucerr.Friendlyf(err, "object store %v not found", req.Destination.ObjectStoreID)
+		}
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+	if err := validateExportObjectStore(objectStore, req.Destination); err != nil {
+		return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+	}
+
+	msg := worker.ExportAccessorMessage(ts.ID, req, auth.GetSubjectUUID(ctx), auth.GetSubjectType(ctx))
+	if err := h.workerClient.Send(ctx, msg); err != nil {
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	return &idp.ExportAccessorToObjectStoreResponse{CheckpointKey: exportCheckpointKey(req.Destination.Prefix)},
+		http.StatusOK,
+		nil,
+		nil
+}
+
+// ExportAccessorToObjectStore runs an object store export in the background, as the subject that requested it.
+// The export resumes from the checkpoint manifest in the destination if a previous attempt was interrupted.
+func ExportAccessorToObjectStore(
+	ctx context.Context,
+	ts *tenantmap.TenantState,
+	searchUpdateConfig *config.SearchUpdateConfig,
+	req idp.ExportAccessorToObjectStoreRequest,
+	subjectID uuid.UUID,
+	subjectType string,
+) error {
+	// there's no request to pass through, so access policies are evaluated with the tenant's m2m credentials
+	// on behalf of the subject that requested the export
+	tokenSource, err := m2m.GetM2MTokenSource(ctx, ts.ID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	authzClient, err := authz.NewClient(ts.GetTenantURL(), authz.JSONClient(tokenSource))
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	ctx = multitenant.SetTenantState(auth.SetSubjectTypeAndUUID(ctx, subjectID, subjectType), ts)
+
+	s := storage.NewFromTenantState(ctx, ts)
+	objectStore, err := s.GetShimObjectStore(ctx, req.Destination.ObjectStoreID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	client, err := newExportObjectStoreClient(ctx, objectStore, req.Destination)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	sink := newObjectStoreExportSink(ctx, client, req.Destination, req.Format)
+	if err := sink.loadCheckpoint(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if sink.checkpoint.Completed {
+		uclog.Infof(ctx, "export of accessor %v to '%s' already completed", req.AccessorID, req.Destination.Prefix)
+		return nil
+	}
+
+	req.StartingAfter = sink.checkpoint.Cursor
+	ex, _, err := newAccessorExporter(ctx, searchUpdateConfig, req.ExportAccessorRequest, authzClient)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	sink.columns = ex.columnNames()
+
+	_, err = ex.export(sink.writePage)
+	auditlog.PostMultipleAsync(ctx, ex.auditLogInfo())
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "exported %d rows of accessor %v to '%s'", ex.numExported, req.AccessorID, req.Destination.Prefix)
+	return nil
+}
@@ -0,0 +1,208 @@
+package userstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/pagination"
+)
+
+var exportTestRows = []map[string]any{
+	{"name": "alice", "email": "alice@example.com", "phones": []string{"555-0100", "555-0101"}},
+	{"name": "bob,jr", "age": 42},
+}
+
+func TestExportRowWriters(t *testing.T) {
+	columns := []string{"name", "email", "phones", "age"}
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		ew, err := newExportRowWriter(idp.ExportFormatNDJSON, &buf, columns, true)
+		assert.NoErr(t, err)
+		assert.NoErr(t, ew.writeRows(exportTestRows))
+		assert.NoErr(t, ew.writeCheckpoint(pagination.CursorEnd))
+		assert.NoErr(t, ew.close())
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Equal(t, len(lines), 3)
+		assert.Equal(t, lines[0], `{"email":"alice@example.com","name":"alice","phones":["555-0100","555-0101"]}`)
+		assert.Equal(t, lines[1], `{"age":42,"name":"bob,jr"}`)
+		assert.True(t, strings.HasPrefix(lines[2], idp.ExportCheckpointPrefixNDJSON))
+		assert.Equal(t, lines[2], `{"_checkpoint":"end"}`)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		ew, err := newExportRowWriter(idp.ExportFormatCSV, &buf, columns, true)
+		assert.NoErr(t, err)
+		assert.NoErr(t, ew.writeRows(exportTestRows[:1]))
+		assert.NoErr(t, ew.writeCheckpoint("id:123"))
+		assert.NoErr(t, ew.writeRows(exportTestRows[1:]))
+		assert.NoErr(t, ew.close())
+
+		assert.Equal(t, buf.String(), strings.Join([]string{
+			"name,email,phones,age",
+			`alice,alice@example.com,"[""555-0100"",""555-0101""]",`,
+			"#checkpoint:id:123",
+			`"bob,jr",,,42`,
+			"",
+		}, "\n"))
+
+		// resumed exports leave out the header
+		buf.Reset()
+		ew, err = newExportRowWriter(idp.ExportFormatCSV, &buf, columns, false)
+		assert.NoErr(t, err)
+		assert.NoErr(t, ew.writeRows(exportTestRows[1:]))
+		assert.NoErr(t, ew.close())
+		assert.Equal(t, buf.String(), "\"bob,jr\",,,42\n")
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		var buf bytes.Buffer
+		ew, err := newExportRowWriter(idp.ExportFormatParquet, &buf, columns, true)
+		assert.NoErr(t, err)
+		assert.NoErr(t, ew.writeRows(exportTestRows))
+		assert.NoErr(t, ew.writeCheckpoint("id:123"))
+		assert.NoErr(t, ew.close())
+
+		file := buf.String()
+		assert.True(t, strings.HasPrefix(file, "PAR1"))
+		assert.True(t, strings.HasSuffix(file, "PAR1"))
+		assert.Contains(t, file, `["555-0100","555-0101"]`)
+		assert.Contains(t, file, "42")
+	})
+
+	_, err := newExportRowWriter("xml", io.Discard, columns, true)
+	assert.NotNil(t, err)
+}
+
+type fakeExportObjectClient struct {
+	objects map[string][]byte
+	puts    []string
+}
+
+func (c *fakeExportObjectClient) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	bs, found := c.objects[*params.Bucket+"/"+*params.Key]
+	if !found {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(bs))}, nil
+}
+
+func (c *fakeExportObjectClient) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	bs, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	key := *params.Bucket + "/" + *params.Key
+	c.objects[key] = bs
+	c.puts = append(c.puts, key)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestObjectStoreExportSink(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeExportObjectClient{objects: map[string][]byte{}}
+	destination := idp.ExportDestination{ObjectStoreID: uuid.Must(uuid.NewV4()), Bucket: "bucket", Prefix: "exports/users"}
+	checkpointKey := "bucket/exports/users/_checkpoint.json"
+
+	readCheckpoint := func() exportCheckpoint {
+		var checkpoint exportCheckpoint
+		assert.NoErr(t, json.Unmarshal(client.objects[checkpointKey], &checkpoint))
+		return checkpoint
+	}
+
+	newSink := func(format idp.ExportFormat) *objectStoreExportSink {
+		sink := newObjectStoreExportSink(ctx, client, destination, format)
+		sink.columns = []string{"name", "email"}
+		return sink
+	}
+
+	// pages accumulate until a part is full, and then the part and checkpoint are written
+	sink := newSink(idp.ExportFormatNDJSON)
+	assert.NoErr(t, sink.loadCheckpoint())
+	assert.Equal(t, sink.checkpoint.NextPart, 0)
+	assert.NoErr(t, sink.writePage(exportTestRows, "id:1"))
+	assert.Equal(t, len(client.puts), 0)
+
+	sink.partRows = exportPartMaxRows
+	assert.NoErr(t, sink.writePage(exportTestRows[:1], "id:2"))
+	assert.Equal(t, client.puts, []string{"bucket/exports/users/part-00000.ndjson", checkpointKey})
+	assert.Equal(t, strings.Count(string(client.objects["bucket/exports/users/part-00000.ndjson"]), "\n"), 3)
+	checkpoint := readCheckpoint()
+	assert.Equal(t, checkpoint.Cursor, pagination.Cursor("id:2"))
+	assert.Equal(t, checkpoint.NextPart, 1)
+	assert.Equal(t, checkpoint.RowCount, exportPartMaxRows+1)
+	assert.False(t, checkpoint.Completed)
+
+	// a new attempt resumes from the checkpoint and writes the final part
+	sink = newSink(idp.ExportFormatNDJSON)
+	assert.NoErr(t, sink.loadCheckpoint())
+	assert.Equal(t, sink.checkpoint.Cursor, pagination.Cursor("id:2"))
+	assert.NoErr(t, sink.writePage(exportTestRows[1:], pagination.CursorEnd))
+	assert.Equal(t, string(client.objects["bucket/exports/users/part-00001.ndjson"]), "{\"age\":42,\"name\":\"bob,jr\"}\n")
+	checkpoint = readCheckpoint()
+	assert.Equal(t, checkpoint.Cursor, pagination.CursorEnd)
+	assert.Equal(t, checkpoint.NextPart, 2)
+	assert.Equal(t, checkpoint.RowCount, exportPartMaxRows+2)
+	assert.True(t, checkpoint.Completed)
+
+	// an empty final page only updates the checkpoint
+	numPuts := len(client.puts)
+	sink = newSink(idp.ExportFormatNDJSON)
+	assert.NoErr(t, sink.loadCheckpoint())
+	assert.NoErr(t, sink.writePage(nil, pagination.CursorEnd))
+	assert.Equal(t, client.puts[numPuts:], []string{checkpointKey})
+
+	// the destination can't be reused for another format
+	sink = newSink(idp.ExportFormatCSV)
+	assert.NotNil(t, sink.loadCheckpoint())
+}
+
+func TestValidateExportObjectStore(t *testing.T) {
+	objectStore := &storage.ShimObjectStore{
+		Name:         "exports",
+		Type:         storage.ObjectStoreTypeS3,
+		AccessKeyID:  "AKIA",
+		ExportBucket: "export-bucket",
+		ExportPrefix: "tenant/exports/",
+	}
+
+	for _, tc := range []struct {
+		bucket string
+		prefix string
+		valid  bool
+	}{
+		{"export-bucket", "tenant/exports", true},
+		{"export-bucket", "tenant/exports/2026-10-18", true},
+		{"other-bucket", "tenant/exports/2026-10-18", false},
+		{"export-bucket", "tenant/exports-other", false},
+		{"export-bucket", "tenant", false},
+	} {
+		err := validateExportObjectStore(objectStore, idp.ExportDestination{ObjectStoreID: uuid.Must(uuid.NewV4()), Bucket: tc.bucket, Prefix: tc.prefix})
+		if tc.valid {
+			assert.NoErr(t, err, assert.Errorf("%s/%s", tc.bucket, tc.prefix))
+		} else {
+			assert.NotNil(t, err, assert.Errorf("%s/%s", tc.bucket, tc.prefix))
+		}
+	}
+
+	// any prefix in the bucket is allowed if the object store has no export prefix
+	objectStore.ExportPrefix = ""
+	assert.NoErr(t, validateExportObjectStore(objectStore, idp.ExportDestination{Bucket: "export-bucket", Prefix: "anywhere"}))
+
+	// but exports are only allowed once the object store has an export bucket
+	objectStore.ExportBucket = ""
+	assert.NotNil(t, validateExportObjectStore(objectStore, idp.ExportDestination{Bucket: "export-bucket", Prefix: "anywhere"}))
+}
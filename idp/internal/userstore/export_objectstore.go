@@ -0,0 +1,217 @@
+package userstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// exportPartMaxRows is the number of rows after which an object store export starts a new part file; an
+// interrupted export resumes after the last complete part
+const exportPartMaxRows = 50000
+
+// exportCheckpoint is the manifest written alongside the part files of an object store export
+type exportCheckpoint struct {
+	Format    idp.ExportFormat  `json:"format"`
+	Cursor    pagination.Cursor `json:"cursor"`
+	NextPart  int               `json:"next_part"`
+	RowCount  int               `json:"row_count"`
+	Completed bool              `json:"completed"`
+	Updated   time.Time         `json:"updated"`
+}
+
+func exportCheckpointKey(prefix string) string {
+	return path.Join(prefix, "_checkpoint.json")
+}
+
+func exportPartKey(prefix string, part int, format idp.ExportFormat) string {
+	return path.Join(prefix, fmt.Sprintf("part-%05d.%s", part, format))
+}
+
+// exportObjectClient is the subset of the S3 client used to write exports
+type exportObjectClient interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// validateExportObjectStore checks that we can write to the object store without a caller's web identity token,
+// since exports run in the background, and that the destination is within the bucket and prefix the object store
+// allows exports to, since its credentials may be able to write to other buckets
+func validateExportObjectStore(objectStore *storage.ShimObjectStore, dest idp.ExportDestination) error {
+	if objectStore.Type != storage.ObjectStoreTypeS3 {
+		return ucerr.Friendlyf(nil, "exports to '%s' object stores are not supported", objectStore.Type)
+	}
+	if objectStore.AccessKeyID == "" {
+		return ucerr.Friendlyf(nil, "object store '%s' must have an access key to be used for exports", objectStore.Name)
+	}
+	if objectStore.ExportBucket == "" {
+		return ucerr.Friendlyf(nil, "object store '%s' must have an export bucket to be used for exports", objectStore.Name)
+	}
+	if dest.Bucket != objectStore.ExportBucket {
+		return ucerr.Friendlyf(nil, "object store '%s' only allows exports to bucket '%s'", objectStore.Name, objectStore.ExportBucket)
+	}
+	if objectStore.ExportPrefix != "" {
+		// the prefix is matched on whole path segments, so that an export prefix of "exports" doesn't allow "exports-other/"
+		exportPrefix := strings.TrimSuffix(objectStore.ExportPrefix, "/")
+		if dest.Prefix != exportPrefix && !strings.HasPrefix(dest.Prefix, exportPrefix+"/") {
+			return ucerr.Friendlyf(nil, "object store '%s' only allows exports under prefix '%s'", objectStore.Name, objectStore.ExportPrefix)
+		}
+	}
+	return nil
+}
+
+func newExportObjectStoreClient(ctx context.Context, objectStore *storage.ShimObjectStore, dest idp.ExportDestination) (*s3.Client, error) {
+	if err := validateExportObjectStore(objectStore, dest); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	secretAccessKey, err := objectStore.SecretAccessKey.Resolve(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return s3.NewFromConfig(aws.Config{
+		Region:      objectStore.Region,
+		Credentials: aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(objectStore.AccessKeyID, secretAccessKey, "")),
+	}), nil
+}
+
+// objectStoreExportSink buffers exported pages into part files, and records the cursor after each part that's
+// written in the checkpoint manifest
+type objectStoreExportSink struct {
+	ctx         context.Context
+	client      exportObjectClient
+	destination idp.ExportDestination
+	format      idp.ExportFormat
+	columns     []string
+	checkpoint  exportCheckpoint
+	part        bytes.Buffer
+	partWriter  exportRowWriter
+	partRows    int
+}
+
+func newObjectStoreExportSink(
+	ctx context.Context,
+	client exportObjectClient,
+	destination idp.ExportDestination,
+	format idp.ExportFormat,
+) *objectStoreExportSink {
+	return &objectStoreExportSink{
+		ctx:         ctx,
+		client:      client,
+		destination: destination,
+		format:      format,
+		checkpoint:  exportCheckpoint{Format: format},
+	}
+}
+
+func (oes *objectStoreExportSink) putObject(key string, body []byte) error {
+	if _, err := oes.client.PutObject(oes.ctx, &s3.PutObjectInput{
+		Bucket: aws.String(oes.destination.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	}); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// loadCheckpoint reads the checkpoint manifest of a previous attempt at the export, if there is one
+func (oes *objectStoreExportSink) loadCheckpoint() error {
+	out, err := oes.client.GetObject(oes.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(oes.destination.Bucket),
+		Key:    aws.String(exportCheckpointKey(oes.destination.Prefix)),
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil
+		}
+		return ucerr.Wrap(err)
+	}
+	defer out.Body.Close()
+
+	bs, err := io.ReadAll(out.Body)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	var checkpoint exportCheckpoint
+	if err := json.Unmarshal(bs, &checkpoint); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if checkpoint.Format != oes.format {
+		return ucerr.Friendlyf(nil, "'%s' already contains a %s export", oes.destination.Prefix, checkpoint.Format)
+	}
+
+	uclog.Infof(oes.ctx, "resuming export to '%s' at part %d after %d rows", oes.destination.Prefix, checkpoint.NextPart, checkpoint.RowCount)
+	oes.checkpoint = checkpoint
+	return nil
+}
+
+// writePage adds the page to the current part, and writes the part and checkpoint once the part is full or the
+// export is complete
+func (oes *objectStoreExportSink) writePage(rows []map[string]any, next pagination.Cursor) error {
+	if oes.partWriter == nil {
+		pw, err := newExportRowWriter(oes.format, &oes.part, oes.columns, true)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		oes.partWriter = pw
+	}
+
+	if err := oes.partWriter.writeRows(rows); err != nil {
+		return ucerr.Wrap(err)
+	}
+	oes.partRows += len(rows)
+
+	completed := next == pagination.CursorEnd
+	if oes.partRows < exportPartMaxRows && !completed {
+		return nil
+	}
+
+	// skip writing an empty final part, unless the export would otherwise have no parts at all
+	if oes.partRows > 0 || oes.checkpoint.NextPart == 0 {
+		if err := oes.partWriter.close(); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if err := oes.putObject(exportPartKey(oes.destination.Prefix, oes.checkpoint.NextPart, oes.format), oes.part.Bytes()); err != nil {
+			return ucerr.Wrap(err)
+		}
+		oes.checkpoint.NextPart++
+		oes.checkpoint.RowCount += oes.partRows
+	}
+
+	oes.checkpoint.Cursor = next
+	oes.checkpoint.Completed = completed
+	oes.checkpoint.Updated = time.Now().UTC()
+	bs, err := json.Marshal(oes.checkpoint)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := oes.putObject(exportCheckpointKey(oes.destination.Prefix), bs); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	oes.part.Reset()
+	oes.partWriter = nil
+	oes.partRows = 0
+	return nil
+}
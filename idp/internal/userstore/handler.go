@@ -9,6 +9,7 @@ import (
 	"github.com/gofrs/uuid"
 
 	"userclouds.com/idp/config"
+	"userclouds.com/idp/internal"
 	"userclouds.com/infra/jsonapi"
 	"userclouds.com/infra/jsonclient"
 	"userclouds.com/infra/namespace/region"
@@ -16,6 +17,7 @@ import (
 	"userclouds.com/infra/uchttp"
 	"userclouds.com/infra/uchttp/builder"
 	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/auth/m2m"
 	"userclouds.com/internal/companyconfig"
 	"userclouds.com/internal/multitenant"
 	"userclouds.com/internal/security"
@@ -47,6 +49,7 @@ func NewHandler(ctx context.Context, cfg *config.Config, searchUpdateConfig *con
 	}
 	hb := builder.NewHandlerBuilder()
 	handlerBuilder(hb, h)
+	hb.MethodHandler("/api/accessors/actions/export").Post(h.exportAccessor)
 	hb.MethodHandler("/download/codegensdk.go").Get(h.getCodegenGolangSDK)
 	hb.MethodHandler("/download/codegensdk.py").Get(h.getCodegenPythonSDK)
 	hb.MethodHandler("/download/codegensdk.ts").Get(h.getCodegenTypescriptSDK)
@@ -79,10 +82,20 @@ func NewHandler(ctx context.Context, cfg *config.Config, searchUpdateConfig *con
 		WithAuthorizer(h.newRoleBasedAuthorizer())
 	hb.MethodHandler("/config/regions").
 		Get(h.listUserRegions)
+	hb.CollectionHandler("/api/dsarexports").
+		GetOne(h.getDSARExport).
+		Post(h.createDSARExport).
+		WithAuthorizer(h.newRoleBasedAuthorizer()).
+		NestedMethodHandler("/download").
+		Get(h.downloadDSARExport)
+	hb.CollectionHandler("/api/erasures").
+		GetOne(h.getUserErasure).
+		Post(h.createUserErasure).
+		WithAuthorizer(h.newRoleBasedAuthorizer())
 	return hb.Build(), nil
 }
 
-//go:generate genhandler /userstore POST,executeAccessorHandler,/api/accessors POST,executeMutatorHandler,/api/mutators POST,getConsentedPurposesForUser,/api/consentedpurposes collection,UserstoreUser,h.newRoleBasedAuthorizer(),/api/users collection,DataType,h.newRoleBasedAuthorizer(),/config/datatypes collection,Column,h.newRoleBasedAuthorizer(),/config/columns collection,Accessor,h.newRoleBasedAuthorizer(),/config/accessors collection,Mutator,h.newRoleBasedAuthorizer(),/config/mutators collection,Purpose,h.newRoleBasedAuthorizer(),/config/purposes collection,UserSearchIndex,h.newRoleBasedAuthorizer(),/config/searchindices collection,SoftDeletedRetentionDuration,h.newRoleBasedAuthorizer(),/config/softdeletedretentiondurations collection,LiveRetentionDuration,h.newRoleBasedAuthorizer(),/config/liveretentiondurations nestedcollection,SoftDeletedRetentionDuration,h.newNestedRoleBasedAuthorizer(),/softdeletedretentiondurations,Purpose nestedcollection,LiveRetentionDuration,h.newNestedRoleBasedAuthorizer(),/liveretentiondurations,Purpose nestedcollection,SoftDeletedRetentionDuration,h.newNestedRoleBasedAuthorizer(),/softdeletedretentiondurations,Column nestedcollection,LiveRetentionDuration,h.newNestedRoleBasedAuthorizer(),/liveretentiondurations,Column
+//go:generate genhandler /userstore POST,executeAccessorHandler,/api/accessors POST,exportAccessorToObjectStoreHandler,/api/accessors/actions/exporttoobjectstore POST,executeMutatorHandler,/api/mutators POST,getConsentedPurposesForUser,/api/consentedpurposes collection,UserstoreUser,h.newRoleBasedAuthorizer(),/api/users collection,DataType,h.newRoleBasedAuthorizer(),/config/datatypes collection,Column,h.newRoleBasedAuthorizer(),/config/columns collection,Accessor,h.newRoleBasedAuthorizer(),/config/accessors collection,Mutator,h.newRoleBasedAuthorizer(),/config/mutators collection,Purpose,h.newRoleBasedAuthorizer(),/config/purposes collection,UserSearchIndex,h.newRoleBasedAuthorizer(),/config/searchindices collection,SoftDeletedRetentionDuration,h.newRoleBasedAuthorizer(),/config/softdeletedretentiondurations collection,LiveRetentionDuration,h.newRoleBasedAuthorizer(),/config/liveretentiondurations nestedcollection,SoftDeletedRetentionDuration,h.newNestedRoleBasedAuthorizer(),/softdeletedretentiondurations,Purpose nestedcollection,LiveRetentionDuration,h.newNestedRoleBasedAuthorizer(),/liveretentiondurations,Purpose nestedcollection,SoftDeletedRetentionDuration,h.newNestedRoleBasedAuthorizer(),/softdeletedretentiondurations,Column nestedcollection,LiveRetentionDuration,h.newNestedRoleBasedAuthorizer(),/liveretentiondurations,Column
 
 func (h *handler) newRoleBasedAuthorizer() uchttp.CollectionAuthorizer {
 	return &uchttp.MethodAuthorizer{
@@ -137,6 +150,13 @@ func (h *handler) ensureTenantMember(_ /*adminOnly*/ bool) error {
 	return nil // TODO: figure out how to do this w/o calling authz service
 }
 
+// ensureAdminOrM2M returns forbiddenErr unless the caller has an M2M token or is an admin of the tenant's company,
+// for endpoints that hand out or erase everything we store about users
+func ensureAdminOrM2M(ctx context.Context, forbiddenErr error) (int, error) {
+	code, err := internal.EnsureCompanyAdmin(ctx, internal.NewAdminChecker, forbiddenErr, m2m.SubjectTypeM2M)
+	return code, ucerr.Wrap(err)
+}
+
 func (h *handler) getOIDCIssuersList(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ts := multitenant.MustGetTenantState(ctx)
@@ -120,6 +120,8 @@ func handlerBuilder(builder *builder.HandlerBuilder, h *handler) {
 
 	builder.MethodHandler("/api/accessors").Post(h.executeAccessorHandlerGenerated)
 
+	builder.MethodHandler("/api/accessors/actions/exporttoobjectstore").Post(h.exportAccessorToObjectStoreHandlerGenerated)
+
 	builder.MethodHandler("/api/consentedpurposes").Post(h.getConsentedPurposesForUserGenerated)
 
 	builder.MethodHandler("/api/mutators").Post(h.executeMutatorHandlerGenerated)
@@ -189,6 +191,27 @@ func (h *handler) executeMutatorHandlerGenerated(w http.ResponseWriter, r *http.
 	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
+func (h *handler) exportAccessorToObjectStoreHandlerGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req idp.ExportAccessorToObjectStoreRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	var res *idp.ExportAccessorToObjectStoreResponse
+	res, code, entries, err := h.exportAccessorToObjectStoreHandler(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
+}
+
 func (h *handler) getConsentedPurposesForUserGenerated(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gofrs/uuid"
 
@@ -51,11 +52,106 @@ func validateAndPopulateObjectStoreFields(ctx context.Context,
 		} else {
 			return ucerr.Friendlyf(nil, "access policy ID or name is required")
 		}
+
+		// the write access policy is optional, and the object store is read-only without one
+		if objectStore.WriteAccessPolicy.ID != uuid.Nil {
+			ap, err := s.GetLatestAccessPolicy(ctx, objectStore.WriteAccessPolicy.ID)
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			if objectStore.WriteAccessPolicy.Name != "" && objectStore.WriteAccessPolicy.Name != ap.Name {
+				return ucerr.Friendlyf(nil, "write access policy name does not match")
+			}
+			objectStores[i].WriteAccessPolicy.Name = ap.Name
+		} else if objectStore.WriteAccessPolicy.Name != "" {
+			ap, err := s.GetAccessPolicyByName(ctx, objectStore.WriteAccessPolicy.Name)
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			objectStores[i].WriteAccessPolicy.ID = ap.ID
+		}
+
+		if err := validateAndPopulateFieldMappings(ctx, s, objectStores[i].FieldMappings, objectStore.TokenizeOnWrite); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func validateAndPopulateFieldMappings(
+	ctx context.Context,
+	s *storage.Storage,
+	fieldMappings []userstore.ShimObjectStoreFieldMapping,
+	tokenizeOnWrite bool,
+) error {
+	transformerRIDs := make([]userstore.ResourceID, 0, len(fieldMappings))
+	for i, fm := range fieldMappings {
+		var col *storage.Column
+		var err error
+		if fm.Column.ID != uuid.Nil {
+			col, err = s.GetColumn(ctx, fm.Column.ID)
+		} else {
+			col, err = s.GetUserColumnByName(ctx, fm.Column.Name)
+		}
+		if err != nil {
+			return ucerr.Friendlyf(err, "invalid column for field mapping '%s'", fm.FieldPath)
+		}
+		if fm.Column.Name != "" && !strings.EqualFold(fm.Column.Name, col.Name) {
+			return ucerr.Friendlyf(nil, "column name %s does not match ID %s", fm.Column.Name, fm.Column.ID)
+		}
+		fieldMappings[i].Column = userstore.ResourceID{ID: col.ID, Name: col.Name}
+
+		// values are transformed with the column's default transformer unless the mapping overrides it
+		if fm.Transformer.Validate() != nil {
+			fieldMappings[i].Transformer = userstore.ResourceID{ID: col.DefaultTransformerID}
+		}
+		transformerRIDs = append(transformerRIDs, fieldMappings[i].Transformer)
+	}
+
+	transformerMap, err := storage.GetTransformerMapForResourceIDs(ctx, s, true, transformerRIDs...)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	for i, fm := range fieldMappings {
+		var tf *storage.Transformer
+		if fm.Transformer.ID != uuid.Nil {
+			tf, err = transformerMap.ForID(fm.Transformer.ID)
+		} else {
+			tf, err = transformerMap.ForName(fm.Transformer.Name)
+		}
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		if fm.Transformer.Name != "" && !strings.EqualFold(fm.Transformer.Name, tf.Name) {
+			return ucerr.Friendlyf(nil, "transformer name %s does not match ID %s", fm.Transformer.Name, fm.Transformer.ID)
+		}
+		if tf.RequiresDataProvenance() {
+			return ucerr.Friendlyf(nil, "transformer %s for field mapping '%s' can't be used for object store data", tf.Name, fm.FieldPath)
+		}
+		if tokenizeOnWrite && !tf.RequiresTokenAccessPolicy() {
+			return ucerr.Friendlyf(nil, "transformer %s for field mapping '%s' must be a tokenizing transformer to tokenize on write", tf.Name, fm.FieldPath)
+		}
+		fieldMappings[i].Transformer = userstore.ResourceID{ID: tf.ID, Name: tf.Name}
 	}
 
 	return nil
 }
 
+func newStorageFieldMappings(fieldMappings []userstore.ShimObjectStoreFieldMapping) storage.ShimObjectStoreFieldMappings {
+	mappings := storage.ShimObjectStoreFieldMappings{}
+	for _, fm := range fieldMappings {
+		mappings = append(mappings, storage.ShimObjectStoreFieldMapping{
+			KeyPrefix:     fm.KeyPrefix,
+			FieldPath:     fm.FieldPath,
+			ColumnID:      fm.Column.ID,
+			TransformerID: fm.Transformer.ID,
+		})
+	}
+	return mappings
+}
+
 func (h *handler) listObjectStores(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	s := storage.MustCreateStorage(ctx)
@@ -124,13 +220,18 @@ func (h *handler) createObjectStore(w http.ResponseWriter, r *http.Request) {
 	}
 
 	objStore := &storage.ShimObjectStore{
-		BaseModel:      ucdb.NewBase(),
-		Name:           req.ObjectStore.Name,
-		Type:           storage.ObjectStoreType(req.ObjectStore.Type),
-		Region:         req.ObjectStore.Region,
-		AccessKeyID:    req.ObjectStore.AccessKeyID,
-		RoleARN:        req.ObjectStore.RoleARN,
-		AccessPolicyID: req.ObjectStore.AccessPolicy.ID,
+		BaseModel:           ucdb.NewBase(),
+		Name:                req.ObjectStore.Name,
+		Type:                storage.ObjectStoreType(req.ObjectStore.Type),
+		Region:              req.ObjectStore.Region,
+		AccessKeyID:         req.ObjectStore.AccessKeyID,
+		RoleARN:             req.ObjectStore.RoleARN,
+		AccessPolicyID:      req.ObjectStore.AccessPolicy.ID,
+		FieldMappings:       newStorageFieldMappings(req.ObjectStore.FieldMappings),
+		TokenizeOnWrite:     req.ObjectStore.TokenizeOnWrite,
+		WriteAccessPolicyID: req.ObjectStore.WriteAccessPolicy.ID,
+		ExportBucket:        req.ObjectStore.ExportBucket,
+		ExportPrefix:        req.ObjectStore.ExportPrefix,
 	}
 	if req.ObjectStore.ID != uuid.Nil {
 		objStore.ID = req.ObjectStore.ID
@@ -187,6 +288,11 @@ func (h *handler) updateObjectStore(w http.ResponseWriter, r *http.Request, id u
 	objStore.AccessKeyID = req.ObjectStore.AccessKeyID
 	objStore.RoleARN = req.ObjectStore.RoleARN
 	objStore.AccessPolicyID = req.ObjectStore.AccessPolicy.ID
+	objStore.FieldMappings = newStorageFieldMappings(req.ObjectStore.FieldMappings)
+	objStore.TokenizeOnWrite = req.ObjectStore.TokenizeOnWrite
+	objStore.WriteAccessPolicyID = req.ObjectStore.WriteAccessPolicy.ID
+	objStore.ExportBucket = req.ObjectStore.ExportBucket
+	objStore.ExportPrefix = req.ObjectStore.ExportPrefix
 
 	oldSecretKey, err := objStore.SecretAccessKey.Resolve(ctx)
 	if err != nil {
@@ -51,6 +51,26 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 		}
 	}
 
+	{
+		op, err := reflector.NewOperationContext(http.MethodPost, "/userstore/api/accessors/actions/exporttoobjectstore")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Export Accessor To Object Store")
+		op.SetDescription("This endpoint starts a background export of all of the data an accessor returns to an object store. Part files and a checkpoint manifest are written under the destination prefix, and sending the same request again resumes an interrupted export from the manifest.")
+		op.SetTags("Accessors")
+		op.AddReqStructure(new(idp.ExportAccessorToObjectStoreRequest))
+		op.AddRespStructure(new(idp.ExportAccessorToObjectStoreResponse), openapi.WithHTTPStatus(http.StatusOK))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusBadRequest))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusForbidden))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusNotFound))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusServiceUnavailable))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
+
 	{
 		op, err := reflector.NewOperationContext(http.MethodPost, "/userstore/api/consentedpurposes")
 		if err != nil {
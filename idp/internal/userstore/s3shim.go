@@ -2,6 +2,9 @@ package userstore
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"strings"
 
 	"github.com/gofrs/uuid"
 
@@ -35,19 +38,35 @@ func NewIdpS3ShimController(ts *tenantmap.TenantState, jwtVerifier auth.Verifier
 	}
 }
 
-// CheckPermission implements the S3Shim Controller interface
-func (c *IdpS3ShimController) CheckPermission(ctx context.Context, jwt string, path string) (bool, error) {
-
+func (c *IdpS3ShimController) newAuthZClient(ctx context.Context) (*authz.Client, error) {
 	tokenSource, err := m2m.GetM2MTokenSource(ctx, c.ts.ID)
 	if err != nil {
-		return false, ucerr.Wrap(err)
+		return nil, ucerr.Wrap(err)
 	}
 	azc, err := authz.NewClient(c.ts.GetTenantURL(), authz.JSONClient(tokenSource))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return azc, nil
+}
+
+// CheckPermission implements the S3Shim Controller interface
+func (c *IdpS3ShimController) CheckPermission(ctx context.Context, jwt string, method string, path string) (bool, error) {
+	// uploads and deletes are checked against a separate write access policy, and denied if the object store has none
+	accessPolicyID := c.objectStore.AccessPolicyID
+	if method != http.MethodGet && method != http.MethodHead {
+		if c.objectStore.WriteAccessPolicyID.IsNil() {
+			return false, nil
+		}
+		accessPolicyID = c.objectStore.WriteAccessPolicyID
+	}
+
+	azc, err := c.newAuthZClient(ctx)
 	if err != nil {
 		return false, ucerr.Wrap(err)
 	}
 
-	clientContext := policy.ClientContext{"path": path}
+	clientContext := policy.ClientContext{"path": path, "method": method}
 	if ctxToken, err := auth.AddTokenToContext(ctx, jwt, c.jwtVerifier, false); err == nil {
 		ctx = ctxToken
 	} else {
@@ -71,7 +90,7 @@ func (c *IdpS3ShimController) CheckPermission(ctx context.Context, jwt string, p
 			ctx,
 			c.ts.ID,
 			policy.AccessPolicyGlobalAccessorID,
-			c.objectStore.AccessPolicyID)
+			accessPolicyID)
 	if err != nil {
 		return false, ucerr.Wrap(err)
 	}
@@ -95,7 +114,153 @@ func (c *IdpS3ShimController) CheckPermission(ctx context.Context, jwt string, p
 	return true, nil
 }
 
-// TransformData implements the S3Shim Controller interface
-func (c *IdpS3ShimController) TransformData(ctx context.Context, data []byte) ([]byte, error) {
-	return data, nil
+// objectKey returns the key of the object at path, which includes the bucket name that key prefixes don't
+func objectKey(path string) string {
+	if _, key, found := strings.Cut(path, "/"); found {
+		return key
+	}
+	return path
+}
+
+// errUnknownObjectFormat is returned for objects under a mapped key prefix that we can't parse, since we would
+// otherwise have to pass their fields through untransformed
+var errUnknownObjectFormat = ucerr.Friendlyf(nil, "objects with mapped fields must be JSON, NDJSON or CSV")
+
+// matchingFieldMappings returns the field mappings whose key prefix matches the object at path
+func (c *IdpS3ShimController) matchingFieldMappings(path string) []storage.ShimObjectStoreFieldMapping {
+	key := objectKey(path)
+	var fieldMappings []storage.ShimObjectStoreFieldMapping
+	for _, fm := range c.objectStore.FieldMappings {
+		if strings.HasPrefix(key, fm.KeyPrefix) {
+			fieldMappings = append(fieldMappings, fm)
+		}
+	}
+	return fieldMappings
+}
+
+// getFieldMappings returns the field mappings that apply to the object at path, and an error if there are any but
+// the object's format is not one we can parse
+func (c *IdpS3ShimController) getFieldMappings(path string, contentType string) (objectFormat, []storage.ShimObjectStoreFieldMapping, error) {
+	fieldMappings := c.matchingFieldMappings(path)
+	if len(fieldMappings) == 0 {
+		return objectFormatUnknown, nil, nil
+	}
+
+	format := getObjectFormat(objectKey(path), contentType)
+	if format == objectFormatUnknown {
+		return format, nil, ucerr.Wrap(errUnknownObjectFormat)
+	}
+	return format, fieldMappings, nil
+}
+
+// transformObject streams data through the transformers of the field mappings. The transformed data is streamed
+// through a pipe, so any error that happens after the first bytes have been read is returned by the reader.
+func (c *IdpS3ShimController) transformObject(
+	ctx context.Context,
+	format objectFormat,
+	fieldMappings []storage.ShimObjectStoreFieldMapping,
+	data io.Reader,
+) (io.ReadCloser, error) {
+	s := storage.NewFromTenantState(ctx, c.ts)
+
+	transformerIDs := make([]uuid.UUID, 0, len(fieldMappings))
+	for _, fm := range fieldMappings {
+		transformerIDs = append(transformerIDs, fm.TransformerID)
+	}
+	transformerMap, err := storage.GetTransformerMapForIDs(ctx, s, true, transformerIDs...)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	mappings := make([]objectFieldMapping, 0, len(fieldMappings))
+	for _, fm := range fieldMappings {
+		transformer, err := transformerMap.ForID(fm.TransformerID)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		col, err := s.GetColumn(ctx, fm.ColumnID)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		mappings = append(mappings, objectFieldMapping{
+			fieldPath:           fm.FieldPath,
+			transformer:         transformer,
+			tokenAccessPolicyID: col.DefaultTokenAccessPolicyID,
+		})
+	}
+
+	azc, err := c.newAuthZClient(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	te := tokenizer.NewTransformerExecutor(s, azc)
+
+	ot := &objectTransformer{
+		format:   format,
+		mappings: mappings,
+		execute: func(ctx context.Context, params ...tokenizer.ExecuteTransformerParameters) ([]string, error) {
+			results, _, err := te.Execute(ctx, params...)
+			return results, ucerr.Wrap(err)
+		},
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer te.CleanupExecution()
+		pw.CloseWithError(ot.transform(ctx, data, pw))
+	}()
+
+	return pr, nil
+}
+
+// TransformData implements the S3Shim Controller interface, transforming the fields of JSON, NDJSON and CSV objects
+// that are mapped to userstore columns for the object's key. If the object store tokenizes on write, the fields
+// already hold tokens and are returned as stored.
+func (c *IdpS3ShimController) TransformData(ctx context.Context, path string, contentType string, data io.Reader) (io.ReadCloser, bool, error) {
+	if c.objectStore.TokenizeOnWrite {
+		return io.NopCloser(data), false, nil
+	}
+
+	format, fieldMappings, err := c.getFieldMappings(path, contentType)
+	if err != nil {
+		return nil, false, ucerr.Wrap(err)
+	}
+	if len(fieldMappings) == 0 {
+		return io.NopCloser(data), false, nil
+	}
+
+	transformed, err := c.transformObject(ctx, format, fieldMappings, data)
+	if err != nil {
+		return nil, false, ucerr.Wrap(err)
+	}
+	return transformed, true, nil
+}
+
+// TransformsDownload implements the S3Shim Controller interface
+func (c *IdpS3ShimController) TransformsDownload(path string) bool {
+	return !c.objectStore.TokenizeOnWrite && len(c.matchingFieldMappings(path)) > 0
+}
+
+// TransformsUpload implements the S3Shim Controller interface. Uploads of objects in a format we can't parse are
+// transformed too, so that TransformUpload rejects them rather than storing their fields as sent.
+func (c *IdpS3ShimController) TransformsUpload(path string, contentType string) bool {
+	return c.objectStore.TokenizeOnWrite && len(c.matchingFieldMappings(path)) > 0
+}
+
+// TransformUpload implements the S3Shim Controller interface, tokenizing the mapped fields of an uploaded object if
+// the object store tokenizes on write
+func (c *IdpS3ShimController) TransformUpload(ctx context.Context, path string, contentType string, data io.Reader) (io.ReadCloser, bool, error) {
+	if !c.TransformsUpload(path, contentType) {
+		return io.NopCloser(data), false, nil
+	}
+
+	format, fieldMappings, err := c.getFieldMappings(path, contentType)
+	if err != nil {
+		return nil, false, ucerr.Wrap(err)
+	}
+	transformed, err := c.transformObject(ctx, format, fieldMappings, data)
+	if err != nil {
+		return nil, false, ucerr.Wrap(err)
+	}
+	return transformed, true, nil
 }
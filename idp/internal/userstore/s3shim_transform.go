@@ -0,0 +1,442 @@
+package userstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/idp/internal/tokenizer"
+	"userclouds.com/infra/ucerr"
+)
+
+// objectFormat is the format of an object read through the S3 shim, which determines how we find the mapped fields
+type objectFormat string
+
+const (
+	objectFormatUnknown objectFormat = ""
+	objectFormatJSON    objectFormat = "json"
+	objectFormatNDJSON  objectFormat = "ndjson"
+	objectFormatCSV     objectFormat = "csv"
+)
+
+// objectTransformBatchSize is the number of records whose values we transform with a single call to the executor
+const objectTransformBatchSize = 100
+
+// getObjectFormat determines the format of an object from its key's extension, falling back to its content type
+func getObjectFormat(key string, contentType string) objectFormat {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".json":
+		return objectFormatJSON
+	case ".ndjson", ".jsonl":
+		return objectFormatNDJSON
+	case ".csv":
+		return objectFormatCSV
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return objectFormatUnknown
+	}
+	switch mediaType {
+	case "application/json":
+		return objectFormatJSON
+	case "application/x-ndjson", "application/jsonl":
+		return objectFormatNDJSON
+	case "text/csv":
+		return objectFormatCSV
+	}
+	return objectFormatUnknown
+}
+
+// objectFieldMapping is a field mapping that applies to an object, resolved to the transformer to apply
+type objectFieldMapping struct {
+	fieldPath           string
+	transformer         *storage.Transformer
+	tokenAccessPolicyID uuid.UUID
+}
+
+// pendingValue is a value found in a record that will be replaced once its transformer has been executed
+type pendingValue struct {
+	mapping *objectFieldMapping
+	data    string
+	set     func(string)
+}
+
+// executeTransformersFunc executes a batch of transformers, and is a tokenizer.TransformerExecutor in production
+type executeTransformersFunc func(ctx context.Context, params ...tokenizer.ExecuteTransformerParameters) ([]string, error)
+
+// objectTransformer streams an object, transforming the values of the mapped fields in each record
+type objectTransformer struct {
+	format   objectFormat
+	mappings []objectFieldMapping
+	execute  executeTransformersFunc
+}
+
+// transform reads the object from src and writes the transformed object to dst
+func (ot *objectTransformer) transform(ctx context.Context, src io.Reader, dst io.Writer) error {
+	switch ot.format {
+	case objectFormatJSON:
+		return ucerr.Wrap(ot.transformJSON(ctx, src, dst))
+	case objectFormatNDJSON:
+		return ucerr.Wrap(ot.transformNDJSON(ctx, src, dst))
+	case objectFormatCSV:
+		return ucerr.Wrap(ot.transformCSV(ctx, src, dst))
+	}
+	return ucerr.Errorf("unsupported object format '%s'", ot.format)
+}
+
+// executeBatch executes the transformers for a batch of values and replaces them with the results
+func (ot *objectTransformer) executeBatch(ctx context.Context, values []pendingValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	params := make([]tokenizer.ExecuteTransformerParameters, 0, len(values))
+	for _, v := range values {
+		params = append(params, tokenizer.ExecuteTransformerParameters{
+			Transformer:         v.mapping.transformer,
+			TokenAccessPolicyID: v.mapping.tokenAccessPolicyID,
+			Data:                v.data,
+		})
+	}
+
+	results, err := ot.execute(ctx, params...)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if len(results) != len(values) {
+		return ucerr.Errorf("expected %d transformed values, got %d", len(values), len(results))
+	}
+
+	for i, v := range values {
+		v.set(results[i])
+	}
+	return nil
+}
+
+// collectJSONValues finds the values at each mapped field path in a decoded JSON record
+func (ot *objectTransformer) collectJSONValues(record any) ([]pendingValue, error) {
+	var values []pendingValue
+	for i := range ot.mappings {
+		m := &ot.mappings[i]
+		if err := collectJSONPath(m, record, strings.Split(m.fieldPath, "."), nil, &values); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+	}
+	return values, nil
+}
+
+// collectJSONPath walks the remaining keys of a field path from a JSON value, traversing arrays element by element,
+// and adds the values it finds to values; set replaces the value being walked in its parent
+func collectJSONPath(m *objectFieldMapping, value any, keys []string, set func(any), values *[]pendingValue) error {
+	if arr, ok := value.([]any); ok {
+		for i := range arr {
+			if err := collectJSONPath(m, arr[i], keys, func(v any) { arr[i] = v }, values); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+		return nil
+	}
+
+	if len(keys) > 0 {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		child, found := obj[keys[0]]
+		if !found {
+			return nil
+		}
+		return ucerr.Wrap(collectJSONPath(m, child, keys[1:], func(v any) { obj[keys[0]] = v }, values))
+	}
+
+	var data string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		data = v
+	case json.Number:
+		data = v.String()
+	case bool:
+		data = fmt.Sprint(v)
+	default:
+		// transform nested objects as their JSON representation
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		data = string(bs)
+	}
+
+	if set == nil {
+		// the mapped field path is the whole record, which we don't support
+		return nil
+	}
+	*values = append(*values, pendingValue{mapping: m, data: data, set: func(s string) { set(s) }})
+	return nil
+}
+
+// encodeJSON encodes a value without a trailing newline, and without escaping HTML characters the original likely
+// didn't escape either
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// transformJSONRecords transforms a batch of decoded JSON records in place
+func (ot *objectTransformer) transformJSONRecords(ctx context.Context, records []any) error {
+	var values []pendingValue
+	for _, record := range records {
+		recordValues, err := ot.collectJSONValues(record)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		values = append(values, recordValues...)
+	}
+	return ucerr.Wrap(ot.executeBatch(ctx, values))
+}
+
+// transformJSON transforms a JSON object; a top-level array is streamed element by element, while any other
+// top-level value has to be read in full
+func (ot *objectTransformer) transformJSON(ctx context.Context, src io.Reader, dst io.Writer) error {
+	br := bufio.NewReader(src)
+	first, err := peekNonSpace(br)
+	if errors.Is(err, io.EOF) {
+		return nil
+	} else if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+
+	if first != '[' {
+		var record any
+		if err := dec.Decode(&record); err != nil {
+			return ucerr.Wrap(err)
+		}
+		records := []any{record}
+		if err := ot.transformJSONRecords(ctx, records); err != nil {
+			return ucerr.Wrap(err)
+		}
+		bs, err := encodeJSON(records[0])
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		_, err = dst.Write(bs)
+		return ucerr.Wrap(err)
+	}
+
+	// consume the opening bracket
+	if _, err := dec.Token(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if _, err := io.WriteString(dst, "["); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	wroteElement := false
+	records := make([]any, 0, objectTransformBatchSize)
+	flush := func() error {
+		if err := ot.transformJSONRecords(ctx, records); err != nil {
+			return ucerr.Wrap(err)
+		}
+		for _, record := range records {
+			bs, err := encodeJSON(record)
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			if wroteElement {
+				bs = append([]byte(","), bs...)
+			}
+			if _, err := dst.Write(bs); err != nil {
+				return ucerr.Wrap(err)
+			}
+			wroteElement = true
+		}
+		records = records[:0]
+		return nil
+	}
+
+	for dec.More() {
+		var record any
+		if err := dec.Decode(&record); err != nil {
+			return ucerr.Wrap(err)
+		}
+		records = append(records, record)
+		if len(records) == objectTransformBatchSize {
+			if err := flush(); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	// consume the closing bracket
+	if _, err := dec.Token(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	_, err = io.WriteString(dst, "]")
+	return ucerr.Wrap(err)
+}
+
+// peekNonSpace returns the first non-whitespace byte of the reader without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, ucerr.Wrap(err)
+		}
+		if !bytes.ContainsRune([]byte(" \t\r\n"), rune(b)) {
+			return b, ucerr.Wrap(br.UnreadByte())
+		}
+	}
+}
+
+// transformNDJSON transforms newline-delimited JSON, preserving blank lines
+func (ot *objectTransformer) transformNDJSON(ctx context.Context, src io.Reader, dst io.Writer) error {
+	br := bufio.NewReader(src)
+
+	// lines holds each line of the batch, and records the decoded records of the non-blank lines
+	var lines [][]byte
+	var records []any
+	var recordLines []int
+	flush := func() error {
+		if err := ot.transformJSONRecords(ctx, records); err != nil {
+			return ucerr.Wrap(err)
+		}
+		for i, record := range records {
+			bs, err := encodeJSON(record)
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			lines[recordLines[i]] = bs
+		}
+		for _, line := range lines {
+			if _, err := dst.Write(append(line, '\n')); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+		lines, records, recordLines = lines[:0], records[:0], recordLines[:0]
+		return nil
+	}
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return ucerr.Wrap(err)
+		}
+		atEOF := errors.Is(err, io.EOF)
+		if atEOF && len(line) == 0 {
+			break
+		}
+
+		trimmed := bytes.TrimRight(line, "\r\n")
+		if len(bytes.TrimSpace(trimmed)) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(trimmed))
+			dec.UseNumber()
+			var record any
+			if err := dec.Decode(&record); err != nil {
+				return ucerr.Wrap(err)
+			}
+			records = append(records, record)
+			recordLines = append(recordLines, len(lines))
+		}
+		lines = append(lines, trimmed)
+
+		if len(records) == objectTransformBatchSize {
+			if err := flush(); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+		if atEOF {
+			break
+		}
+	}
+
+	return ucerr.Wrap(flush())
+}
+
+// transformCSV transforms CSV data, where field paths refer to the names in the header row
+func (ot *objectTransformer) transformCSV(ctx context.Context, src io.Reader, dst io.Writer) error {
+	r := csv.NewReader(src)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	w := csv.NewWriter(dst)
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	} else if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := w.Write(header); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	columnMappings := map[int]*objectFieldMapping{}
+	for i := range ot.mappings {
+		for col, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), ot.mappings[i].fieldPath) {
+				columnMappings[col] = &ot.mappings[i]
+			}
+		}
+	}
+
+	rows := make([][]string, 0, objectTransformBatchSize)
+	flush := func() error {
+		var values []pendingValue
+		for _, row := range rows {
+			for col, m := range columnMappings {
+				if col >= len(row) || row[col] == "" {
+					continue
+				}
+				values = append(values, pendingValue{mapping: m, data: row[col], set: func(s string) { row[col] = s }})
+			}
+		}
+		if err := ot.executeBatch(ctx, values); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return ucerr.Wrap(err)
+		}
+		rows = rows[:0]
+		return nil
+	}
+
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return ucerr.Wrap(err)
+		}
+		rows = append(rows, row)
+		if len(rows) == objectTransformBatchSize {
+			if err := flush(); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+	}
+
+	return ucerr.Wrap(flush())
+}
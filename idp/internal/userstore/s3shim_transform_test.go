@@ -0,0 +1,142 @@
+package userstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/idp/internal/tokenizer"
+	"userclouds.com/infra/assert"
+)
+
+// newTestObjectTransformer returns a transformer that redacts each mapped value as <field:value>, and counts the
+// number of batches it executes
+func newTestObjectTransformer(format objectFormat, batches *int, fieldPaths ...string) *objectTransformer {
+	ot := &objectTransformer{
+		format: format,
+		execute: func(_ context.Context, params ...tokenizer.ExecuteTransformerParameters) ([]string, error) {
+			*batches++
+			results := make([]string, 0, len(params))
+			for _, p := range params {
+				results = append(results, fmt.Sprintf("<%s:%s>", p.Transformer.Name, p.Data))
+			}
+			return results, nil
+		},
+	}
+	for _, fp := range fieldPaths {
+		ot.mappings = append(ot.mappings, objectFieldMapping{
+			fieldPath:   fp,
+			transformer: &storage.Transformer{Name: fp},
+		})
+	}
+	return ot
+}
+
+func TestObjectFormat(t *testing.T) {
+	assert.Equal(t, getObjectFormat("exports/users.json", ""), objectFormatJSON)
+	assert.Equal(t, getObjectFormat("exports/users.NDJSON", ""), objectFormatNDJSON)
+	assert.Equal(t, getObjectFormat("exports/users.jsonl", "text/csv"), objectFormatNDJSON)
+	assert.Equal(t, getObjectFormat("exports/users.csv", ""), objectFormatCSV)
+	assert.Equal(t, getObjectFormat("exports/users", "text/csv; charset=utf-8"), objectFormatCSV)
+	assert.Equal(t, getObjectFormat("exports/users", "application/x-ndjson"), objectFormatNDJSON)
+	assert.Equal(t, getObjectFormat("exports/users.bin", "application/octet-stream"), objectFormatUnknown)
+
+	// objects under a mapped prefix that we can't parse are denied rather than returned as stored
+	c := NewIdpS3ShimController(nil, nil, nil, &storage.ShimObjectStore{
+		FieldMappings: storage.ShimObjectStoreFieldMappings{{KeyPrefix: "exports/", FieldPath: "email"}},
+	})
+	_, fieldMappings, err := c.getFieldMappings("bucket/exports/users.json", "")
+	assert.NoErr(t, err)
+	assert.Equal(t, len(fieldMappings), 1)
+	_, _, err = c.getFieldMappings("bucket/exports/users.bin", "application/octet-stream")
+	assert.ErrorIs(t, err, errUnknownObjectFormat)
+	_, _, err = c.TransformData(context.Background(), "bucket/exports/users.bin", "application/octet-stream", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, errUnknownObjectFormat)
+	_, fieldMappings, err = c.getFieldMappings("bucket/other/users.bin", "application/octet-stream")
+	assert.NoErr(t, err)
+	assert.Equal(t, len(fieldMappings), 0)
+	assert.True(t, c.TransformsDownload("bucket/exports/users.bin"))
+	assert.False(t, c.TransformsDownload("bucket/other/users.json"))
+}
+
+func TestObjectTransformer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("json_array", func(t *testing.T) {
+		var batches int
+		ot := newTestObjectTransformer(objectFormatJSON, &batches, "email", "contact.phones", "age")
+		src := `[
+			{"name": "alice", "email": "alice@example.com", "contact": {"phones": ["555-0100", "555-0101"]}, "age": 30},
+			{"name": "bob", "email": null, "contact": [{"phones": "555-0102"}], "note": "<b>"}
+		]`
+
+		var dst bytes.Buffer
+		assert.NoErr(t, ot.transform(ctx, strings.NewReader(src), &dst))
+		assert.Equal(t, dst.String(), `[`+
+			`{"age":"<age:30>","contact":{"phones":["<contact.phones:555-0100>","<contact.phones:555-0101>"]},"email":"<email:alice@example.com>","name":"alice"},`+
+			`{"contact":[{"phones":"<contact.phones:555-0102>"}],"email":null,"name":"bob","note":"<b>"}`+
+			`]`)
+		assert.Equal(t, batches, 1)
+	})
+
+	t.Run("json_object", func(t *testing.T) {
+		var batches int
+		ot := newTestObjectTransformer(objectFormatJSON, &batches, "user.ssn")
+
+		var dst bytes.Buffer
+		assert.NoErr(t, ot.transform(ctx, strings.NewReader(`{"user": {"ssn": "123-45-6789", "id": 7}}`), &dst))
+		assert.Equal(t, dst.String(), `{"user":{"id":7,"ssn":"<user.ssn:123-45-6789>"}}`)
+	})
+
+	t.Run("json_batches", func(t *testing.T) {
+		var batches int
+		ot := newTestObjectTransformer(objectFormatJSON, &batches, "email")
+
+		records := make([]string, 0, objectTransformBatchSize+1)
+		for i := range objectTransformBatchSize + 1 {
+			records = append(records, fmt.Sprintf(`{"email":"%d"}`, i))
+		}
+
+		var dst bytes.Buffer
+		assert.NoErr(t, ot.transform(ctx, strings.NewReader("["+strings.Join(records, ",")+"]"), &dst))
+		assert.Equal(t, batches, 2)
+		assert.True(t, strings.HasSuffix(dst.String(), fmt.Sprintf(`{"email":"<email:%d>"}]`, objectTransformBatchSize)))
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var batches int
+		ot := newTestObjectTransformer(objectFormatNDJSON, &batches, "email")
+		src := "{\"email\": \"alice@example.com\"}\n\n{\"name\": \"bob\"}\r\n{\"email\": \"carol@example.com\"}"
+
+		var dst bytes.Buffer
+		assert.NoErr(t, ot.transform(ctx, strings.NewReader(src), &dst))
+		assert.Equal(t, dst.String(), "{\"email\":\"<email:alice@example.com>\"}\n\n{\"name\":\"bob\"}\n{\"email\":\"<email:carol@example.com>\"}\n")
+
+		err := ot.transform(ctx, strings.NewReader("{\"email\": \"x\"}\nnot json\n"), &bytes.Buffer{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var batches int
+		ot := newTestObjectTransformer(objectFormatCSV, &batches, "email", "ssn")
+		src := "name,Email,phone\nalice,alice@example.com,555-0100\n\"bob, jr\",,555-0101\ncarol,carol@example.com\n"
+
+		var dst bytes.Buffer
+		assert.NoErr(t, ot.transform(ctx, strings.NewReader(src), &dst))
+		assert.Equal(t, dst.String(), "name,Email,phone\nalice,<email:alice@example.com>,555-0100\n\"bob, jr\",,555-0101\ncarol,<email:carol@example.com>\n")
+		assert.Equal(t, batches, 1)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		for _, format := range []objectFormat{objectFormatJSON, objectFormatNDJSON, objectFormatCSV} {
+			var batches int
+			var dst bytes.Buffer
+			assert.NoErr(t, newTestObjectTransformer(format, &batches, "email").transform(ctx, strings.NewReader(""), &dst))
+			assert.Equal(t, dst.Len(), 0)
+			assert.Equal(t, batches, 0)
+		}
+	})
+}
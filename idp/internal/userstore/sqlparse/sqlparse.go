@@ -2,8 +2,10 @@ package sqlparse
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+	"unicode"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 
@@ -28,11 +30,62 @@ const (
 	QueryTypeDelete QueryType = "DELETE"
 )
 
+// Param is a reference to a parameter ($1, $2, ...) of a prepared statement, whose value is only known once the
+// statement is bound
+type Param int
+
+// Value is a value written to a column by an INSERT or UPDATE query, which is either a literal (whose Location is
+// the byte offset of the literal in the query, so that it can be replaced), a Param, or an expression we don't
+// evaluate, like a function call or subquery
+type Value struct {
+	Literal    any
+	Location   int
+	Param      Param
+	Expression bool
+}
+
 // Query represents a parsed SQL query
 type Query struct {
 	Type     QueryType
 	Columns  []Column
 	Selector string
+
+	// SelectorValues has an entry for each ? in Selector, which is either the literal value from the query, a Param,
+	// or nil if the value is an expression we don't evaluate
+	SelectorValues []any
+
+	// Table is the table written to by an INSERT, UPDATE or DELETE query
+	Table string
+
+	// Values has a row of values for Columns for each row written by an INSERT or UPDATE query
+	Values [][]Value
+}
+
+// BindSelectorValues returns the selector values with each Param replaced by the corresponding bound parameter
+func (q Query) BindSelectorValues(params []any) ([]any, error) {
+	return bindValues(q.SelectorValues, params)
+}
+
+func bindValues(values []any, params []any) ([]any, error) {
+	bound := make([]any, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case Param:
+			if v < 1 || int(v) > len(params) {
+				return nil, ucerr.Friendlyf(nil, "query references parameter $%d, but %d parameters were bound", v, len(params))
+			}
+			bound = append(bound, params[v-1])
+		case []any:
+			list, err := bindValues(v, params)
+			if err != nil {
+				return nil, ucerr.Wrap(err)
+			}
+			bound = append(bound, list)
+		default:
+			bound = append(bound, v)
+		}
+	}
+	return bound, nil
 }
 
 // Column represents a column in a table
@@ -64,7 +117,8 @@ func NewColumnSet(cols ...Column) set.Set[Column] {
 
 // ParseQuery parses a SQL query string and returns the table name, column names, and selector (in the convention of userstore)
 func ParseQuery(queryString string) (*Query, error) {
-	queryString = strings.TrimSpace(queryString)
+	// only trim the end of the query, so that locations in the parse tree are offsets into the original query
+	queryString = strings.TrimRightFunc(queryString, unicode.IsSpace)
 	tree, err := pg_query.Parse(queryString)
 	if err != nil {
 		return nil, ucerr.Wrap(err)
@@ -92,6 +146,42 @@ func ParseQuery(queryString string) (*Query, error) {
 	return nil, ucerr.Friendlyf(nil, "unsupported query type")
 }
 
+var lineCommentRegex = regexp.MustCompile(`--[^\n]*`)
+var leadingKeywordRegex = regexp.MustCompile(`^[\s(]*([A-Za-z]+)`)
+var writeKeywordRegex = regexp.MustCompile(`(?i)\b(INSERT|REPLACE|MERGE|UPDATE|DELETE)\b`)
+
+var writeKeywordQueryTypes = map[string]QueryType{
+	"INSERT":  QueryTypeInsert,
+	"REPLACE": QueryTypeInsert, // MySQL's INSERT or UPDATE
+	"MERGE":   QueryTypeUpdate,
+	"UPDATE":  QueryTypeUpdate,
+	"DELETE":  QueryTypeDelete,
+}
+
+// GetWriteQueryType returns the type of a query that ParseQuery can't handle if it might write data, ie. if any of
+// its statements is an INSERT, UPDATE or DELETE (or REPLACE or MERGE), or has a WITH clause that mentions one. It
+// errs on the side of classifying a query as a write, and expects string literals and block comments to have
+// already been removed from the query.
+func GetWriteQueryType(sanitizedQuery string) (QueryType, bool) {
+	for _, stmt := range strings.Split(lineCommentRegex.ReplaceAllString(sanitizedQuery, ""), ";") {
+		m := leadingKeywordRegex.FindStringSubmatch(stmt)
+		if m == nil {
+			continue
+		}
+
+		keyword := strings.ToUpper(m[1])
+		if keyword == "WITH" {
+			if m := writeKeywordRegex.FindStringSubmatch(stmt); m != nil {
+				keyword = strings.ToUpper(m[1])
+			}
+		}
+		if queryType, found := writeKeywordQueryTypes[keyword]; found {
+			return queryType, true
+		}
+	}
+	return "", false
+}
+
 func parseSelectStmt(selectStmt *pg_query.SelectStmt) (*Query, error) {
 	fromClause := selectStmt.GetFromClause()
 	if len(fromClause) != 1 {
@@ -146,43 +236,186 @@ func parseSelectStmt(selectStmt *pg_query.SelectStmt) (*Query, error) {
 	}
 
 	selector := "{id} = ANY(?)" // default selector for no where clause
+	selectorValues := []any{nil}
 	if whereClause := selectStmt.GetWhereClause(); whereClause != nil {
 		var err error
-		selector, err = rewriteWhereClauseAsSelector(whereClause, table)
+		selectorValues = []any{}
+		selector, err = rewriteWhereClauseAsSelector(whereClause, table, &selectorValues)
 		if err != nil {
 			return nil, ucerr.Wrap(err)
 		}
 	}
 
 	return &Query{
-		Type:     QueryTypeSelect,
-		Columns:  columns,
-		Selector: selector,
+		Type:           QueryTypeSelect,
+		Columns:        columns,
+		Selector:       selector,
+		SelectorValues: selectorValues,
 	}, nil
 }
 
 func parseUpdateStmt(updateStmt *pg_query.UpdateStmt) (*Query, error) {
-	_ = updateStmt
-	return &Query{
-		Type: QueryTypeUpdate,
-	}, nil
+	table := updateStmt.GetRelation().GetRelname()
+	query := &Query{
+		Type:  QueryTypeUpdate,
+		Table: table,
+	}
+
+	row := []Value{}
+	for _, target := range updateStmt.GetTargetList() {
+		resTarget := target.GetResTarget()
+		if resTarget == nil || resTarget.GetName() == "" {
+			return nil, ucerr.Friendlyf(nil, "unsupported SET clause in UPDATE statement")
+		}
+		query.Columns = append(query.Columns, Column{Table: table, Name: resTarget.GetName()})
+		row = append(row, getWrittenValue(resTarget.GetVal()))
+	}
+	query.Values = [][]Value{row}
+
+	if err := setWriteSelector(query, updateStmt.GetWhereClause(), table); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return query, nil
 }
 
 func parseInsertStmt(insertStmt *pg_query.InsertStmt) (*Query, error) {
-	_ = insertStmt
-	return &Query{
-		Type: QueryTypeInsert,
-	}, nil
+	table := insertStmt.GetRelation().GetRelname()
+	query := &Query{
+		Type:     QueryTypeInsert,
+		Table:    table,
+		Selector: "{id} = ?",
+	}
+
+	for _, col := range insertStmt.GetCols() {
+		resTarget := col.GetResTarget()
+		if resTarget == nil || resTarget.GetName() == "" {
+			return nil, ucerr.Friendlyf(nil, "unsupported column list in INSERT statement")
+		}
+		query.Columns = append(query.Columns, Column{Table: table, Name: resTarget.GetName()})
+	}
+
+	// the rows only have values we can inspect for INSERT ... VALUES
+	valuesLists := insertStmt.GetSelectStmt().GetSelectStmt().GetValuesLists()
+	if len(valuesLists) == 0 {
+		return nil, ucerr.Friendlyf(nil, "only INSERT ... VALUES statements are supported")
+	}
+	for _, valuesList := range valuesLists {
+		items := valuesList.GetList().GetItems()
+		if len(items) != len(query.Columns) {
+			return nil, ucerr.Friendlyf(nil, "INSERT statements must list the columns for the values being inserted")
+		}
+		row := make([]Value, 0, len(items))
+		for _, item := range items {
+			row = append(row, getWrittenValue(item))
+		}
+		query.Values = append(query.Values, row)
+	}
+
+	return query, nil
 }
 
 func parseDeleteStmt(deleteStmt *pg_query.DeleteStmt) (*Query, error) {
-	_ = deleteStmt
-	return &Query{
-		Type: QueryTypeDelete,
-	}, nil
+	table := deleteStmt.GetRelation().GetRelname()
+	query := &Query{
+		Type:  QueryTypeDelete,
+		Table: table,
+	}
+
+	if err := setWriteSelector(query, deleteStmt.GetWhereClause(), table); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return query, nil
+}
+
+func setWriteSelector(query *Query, whereClause *pg_query.Node, table string) error {
+	if whereClause == nil {
+		query.Selector = "{id} = ANY(?)"
+		query.SelectorValues = []any{nil}
+		return nil
+	}
+
+	query.SelectorValues = []any{}
+	selector, err := rewriteWhereClauseAsSelector(whereClause, table, &query.SelectorValues)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	query.Selector = selector
+	return nil
+}
+
+func getWrittenValue(node *pg_query.Node) Value {
+	if typeCast := node.GetTypeCast(); typeCast != nil {
+		return getWrittenValue(typeCast.GetArg())
+	}
+
+	if paramRef := node.GetParamRef(); paramRef != nil {
+		return Value{Param: Param(paramRef.GetNumber()), Location: int(paramRef.GetLocation())}
+	}
+
+	if aConst := node.GetAConst(); aConst != nil {
+		return Value{Literal: getValueFromNode(node), Location: int(aConst.GetLocation())}
+	}
+
+	return Value{Expression: true}
 }
 
-func rewriteWhereClauseAsSelector(whereClause *pg_query.Node, defaultTable string) (string, error) {
+// ReplaceLiterals returns the query with the literal at each location replaced by a string literal with the new
+// value; backslashEscapes should be set for MySQL, where backslashes escape quotes in string literals
+func ReplaceLiterals(queryString string, replacements map[int]string, backslashEscapes bool) (string, error) {
+	locations := make([]int, 0, len(replacements))
+	for location := range replacements {
+		locations = append(locations, location)
+	}
+
+	// replace from the end of the query so earlier locations stay valid
+	sort.Sort(sort.Reverse(sort.IntSlice(locations)))
+	for _, location := range locations {
+		end, err := literalEnd(queryString, location, backslashEscapes)
+		if err != nil {
+			return "", ucerr.Wrap(err)
+		}
+		literal := "'" + strings.ReplaceAll(replacements[location], "'", "''") + "'"
+		queryString = queryString[:location] + literal + queryString[end:]
+	}
+	return queryString, nil
+}
+
+// literalEnd returns the offset just past the string or numeric literal starting at start
+func literalEnd(queryString string, start int, backslashEscapes bool) (int, error) {
+	if start < 0 || start >= len(queryString) {
+		return 0, ucerr.Errorf("literal location %d is outside of the query", start)
+	}
+
+	if quote := queryString[start]; quote == '\'' || quote == '"' {
+		for i := start + 1; i < len(queryString); i++ {
+			switch queryString[i] {
+			case '\\':
+				if backslashEscapes {
+					i++
+				}
+			case quote:
+				// a doubled quote is an escaped quote
+				if i+1 < len(queryString) && queryString[i+1] == quote {
+					i++
+					continue
+				}
+				return i + 1, nil
+			}
+		}
+		return 0, ucerr.Errorf("unterminated string literal at %d", start)
+	}
+
+	end := start
+	for end < len(queryString) && strings.ContainsRune("0123456789+-.eE", rune(queryString[end])) {
+		end++
+	}
+	if end == start {
+		return 0, ucerr.Errorf("unsupported literal at %d", start)
+	}
+	return end, nil
+}
+
+func rewriteWhereClauseAsSelector(whereClause *pg_query.Node, defaultTable string, selectorValues *[]any) (string, error) {
 
 	if b := whereClause.GetBoolExpr(); b != nil {
 		var operator string
@@ -197,7 +430,7 @@ func rewriteWhereClauseAsSelector(whereClause *pg_query.Node, defaultTable strin
 
 		boolParts := []string{}
 		for _, arg := range b.Args {
-			selector, err := rewriteWhereClauseAsSelector(arg, defaultTable)
+			selector, err := rewriteWhereClauseAsSelector(arg, defaultTable, selectorValues)
 			if err != nil {
 				return "", ucerr.Wrap(err)
 			}
@@ -227,6 +460,7 @@ func rewriteWhereClauseAsSelector(whereClause *pg_query.Node, defaultTable strin
 		lColumn, err := getColumnFromNode(a.Lexpr)
 		if err != nil {
 			lStr = "?"
+			*selectorValues = append(*selectorValues, getValueFromNode(a.Lexpr))
 		} else {
 			parts := strings.Split(lColumn.String(), ".")
 			lStr = "{" + parts[len(parts)-1] + "}"
@@ -234,6 +468,7 @@ func rewriteWhereClauseAsSelector(whereClause *pg_query.Node, defaultTable strin
 		rColumn, err := getColumnFromNode(a.Rexpr)
 		if err != nil {
 			rStr = "?"
+			*selectorValues = append(*selectorValues, getValueFromNode(a.Rexpr))
 		} else {
 			parts := strings.Split(rColumn.String(), ".")
 			rStr = "{" + parts[len(parts)-1] + "}"
@@ -247,6 +482,7 @@ func rewriteWhereClauseAsSelector(whereClause *pg_query.Node, defaultTable strin
 		column, err := getColumnFromNode(n.Arg)
 		if err != nil {
 			str = "?"
+			*selectorValues = append(*selectorValues, getValueFromNode(n.Arg))
 		} else {
 			parts := strings.Split(column.String(), ".")
 			str = "{" + parts[len(parts)-1] + "}"
@@ -287,3 +523,49 @@ func getColumnFromNode(node *pg_query.Node) (*Column, error) {
 
 	return column, nil
 }
+
+// getValueFromNode returns the value of a constant, parameter reference, or list of them, and nil for anything else
+func getValueFromNode(node *pg_query.Node) any {
+	if node == nil {
+		return nil
+	}
+
+	if typeCast := node.GetTypeCast(); typeCast != nil {
+		return getValueFromNode(typeCast.GetArg())
+	}
+
+	if paramRef := node.GetParamRef(); paramRef != nil {
+		return Param(paramRef.GetNumber())
+	}
+
+	if aConst := node.GetAConst(); aConst != nil {
+		switch {
+		case aConst.GetIsnull():
+			return nil
+		case aConst.GetIval() != nil:
+			return int(aConst.GetIval().GetIval())
+		case aConst.GetFval() != nil:
+			return aConst.GetFval().GetFval()
+		case aConst.GetBoolval() != nil:
+			return aConst.GetBoolval().GetBoolval()
+		case aConst.GetSval() != nil:
+			return aConst.GetSval().GetSval()
+		}
+		return nil
+	}
+
+	var items []*pg_query.Node
+	if list := node.GetList(); list != nil {
+		items = list.GetItems()
+	} else if arrayExpr := node.GetAArrayExpr(); arrayExpr != nil {
+		items = arrayExpr.GetElements()
+	} else {
+		return nil
+	}
+
+	values := make([]any, 0, len(items))
+	for _, item := range items {
+		values = append(values, getValueFromNode(item))
+	}
+	return values
+}
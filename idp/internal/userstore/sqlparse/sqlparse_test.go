@@ -15,6 +15,7 @@ func TestParseQuery(t *testing.T) {
 	assert.Equal(t, query.Type, sqlparse.QueryTypeSelect)
 	assert.Equal(t, query.Columns, []sqlparse.Column{{Table: "users", Name: "a"}, {Table: "users", Name: "b"}, {Table: "users", Name: "c"}})
 	assert.Equal(t, query.Selector, "({a} = ? OR ({b} = {a} AND {c} = ?))")
+	assert.Equal(t, query.SelectorValues, []any{1, 2})
 
 	// Fails parsing
 	_, err = sqlparse.ParseQuery("SELECT a, b, c FROM users WHERE a = 1 OR (b = a AND c = 2")
@@ -61,4 +62,88 @@ func TestParseQuery(t *testing.T) {
 	q = "SELECT foo FROM bar WHERE baz IS NULL;"
 	_, err = sqlparse.ParseQuery(q)
 	assert.IsNil(t, err)
+
+	// Prepared statement with parameters, which are bound in the order they appear in the selector
+	query, err = sqlparse.ParseQuery("SELECT a FROM users WHERE b = $2 AND c::text = 'x' AND d IN ($1, 3) AND e = $3::uuid")
+	assert.IsNil(t, err)
+	assert.Equal(t, query.Selector, "({b} = ? AND ? = ? AND {d} = ? AND {e} = ?)")
+	assert.Equal(t, query.SelectorValues, []any{sqlparse.Param(2), nil, "x", []any{sqlparse.Param(1), 3}, sqlparse.Param(3)})
+	values, err := query.BindSelectorValues([]any{"one", "two", "three"})
+	assert.IsNil(t, err)
+	assert.Equal(t, values, []any{"two", nil, "x", []any{"one", 3}, "three"})
+	_, err = query.BindSelectorValues([]any{"one"})
+	assert.NotNil(t, err)
+}
+
+func TestParseWriteQuery(t *testing.T) {
+	q := "  INSERT INTO users (id, email, age) VALUES ($1, 'a@b.com', 42), (gen_random_uuid(), $2::text, NULL)"
+	query, err := sqlparse.ParseQuery(q)
+	assert.IsNil(t, err)
+	assert.Equal(t, query.Type, sqlparse.QueryTypeInsert)
+	assert.Equal(t, query.Table, "users")
+	assert.Equal(t, query.Columns, []sqlparse.Column{{Table: "users", Name: "id"}, {Table: "users", Name: "email"}, {Table: "users", Name: "age"}})
+	assert.Equal(t, query.Selector, "{id} = ?")
+	assert.Equal(t, query.Values, [][]sqlparse.Value{
+		{{Param: 1, Location: strings.Index(q, "$1")}, {Literal: "a@b.com", Location: strings.Index(q, "'a@b.com'")}, {Literal: 42, Location: strings.Index(q, "42")}},
+		{{Expression: true}, {Param: 2, Location: strings.Index(q, "$2")}, {Literal: nil, Location: strings.Index(q, "NULL")}},
+	})
+
+	query, err = sqlparse.ParseQuery("UPDATE users SET email = 'x@y.com', age = age + 1 WHERE id = $1")
+	assert.IsNil(t, err)
+	assert.Equal(t, query.Type, sqlparse.QueryTypeUpdate)
+	assert.Equal(t, query.Columns, []sqlparse.Column{{Table: "users", Name: "email"}, {Table: "users", Name: "age"}})
+	assert.Equal(t, query.Values, [][]sqlparse.Value{{{Literal: "x@y.com", Location: 25}, {Expression: true}}})
+	assert.Equal(t, query.Selector, "{id} = ?")
+	assert.Equal(t, query.SelectorValues, []any{sqlparse.Param(1)})
+
+	query, err = sqlparse.ParseQuery("DELETE FROM users")
+	assert.IsNil(t, err)
+	assert.Equal(t, query.Type, sqlparse.QueryTypeDelete)
+	assert.Equal(t, query.Table, "users")
+	assert.Equal(t, query.Selector, "{id} = ANY(?)")
+
+	_, err = sqlparse.ParseQuery("INSERT INTO users SELECT * FROM other_users")
+	assert.NotNil(t, err)
+}
+
+func TestGetWriteQueryType(t *testing.T) {
+	for _, tc := range []struct {
+		query     string
+		queryType sqlparse.QueryType
+		write     bool
+	}{
+		{"INSERT INTO users SELECT * FROM other_users", sqlparse.QueryTypeInsert, true},
+		{"  ( insert into users (email) values ('?') ) ", sqlparse.QueryTypeInsert, true},
+		{"REPLACE INTO users (id, email) VALUES (1, '?')", sqlparse.QueryTypeInsert, true},
+		{"WITH moved AS (DELETE FROM users RETURNING *) SELECT * FROM moved", sqlparse.QueryTypeDelete, true},
+		{"SELECT 1; UPDATE users SET email = '?'", sqlparse.QueryTypeUpdate, true},
+		{"-- just a comment\nDELETE FROM users", sqlparse.QueryTypeDelete, true},
+		{"SELECT updated FROM users", "", false},
+		{"WITH recent AS (SELECT * FROM users) SELECT id FROM recent", "", false},
+		{"SET NAMES utf8mb4", "", false},
+	} {
+		queryType, write := sqlparse.GetWriteQueryType(tc.query)
+		assert.Equal(t, write, tc.write, assert.Errorf("query %s", tc.query))
+		assert.Equal(t, queryType, tc.queryType, assert.Errorf("query %s", tc.query))
+	}
+}
+
+func TestReplaceLiterals(t *testing.T) {
+	q := "INSERT INTO users (email, name, age) VALUES ('a@b.com', 'O''Brien', -42) /* comment */"
+	replaced, err := sqlparse.ReplaceLiterals(q, map[int]string{
+		strings.Index(q, "'a@b.com'"):  "token-1",
+		strings.Index(q, "'O''Brien'"): "it's",
+		strings.Index(q, "-42"):        "token-3",
+	}, false)
+	assert.IsNil(t, err)
+	assert.Equal(t, replaced, "INSERT INTO users (email, name, age) VALUES ('token-1', 'it''s', 'token-3') /* comment */")
+
+	// MySQL strings can use double quotes and backslash escapes
+	q = `UPDATE users SET email = "a\"b@c.com" WHERE id = 1`
+	replaced, err = sqlparse.ReplaceLiterals(q, map[int]string{25: "token"}, true)
+	assert.IsNil(t, err)
+	assert.Equal(t, replaced, "UPDATE users SET email = 'token' WHERE id = 1")
+
+	_, err = sqlparse.ReplaceLiterals(q, map[int]string{7: "token"}, true)
+	assert.NotNil(t, err)
 }
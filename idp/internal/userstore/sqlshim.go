@@ -136,8 +136,9 @@ type transformInfo struct {
 	dtm       *storage.DataTypeManager
 	queryType sqlparse.QueryType
 
-	accessor  *storage.Accessor
-	startTime time.Time
+	accessor       *storage.Accessor
+	selectorValues []any
+	startTime      time.Time
 
 	ctis columnTransformInfoByLowercase
 
@@ -319,6 +320,7 @@ func (h *IdpSQLQueryHandler) HandleQuery(
 	queryString string,
 	tableSchema string,
 	connectionID uuid.UUID,
+	params []any,
 ) (responseType sqlshim.HandleQueryResponse, transformInfoStruct any, reason string, returnError error) {
 	startTime := time.Now().UTC()
 
@@ -378,8 +380,18 @@ func (h *IdpSQLQueryHandler) HandleQuery(
 	query, err := sqlparse.ParseQuery(queryToParse)
 	if err != nil {
 		uclog.DebugfPII(ctx, `failed to parse query "%v": %v`, queryString, err)
+		if queryType, isWrite := sqlparse.GetWriteQueryType(sanitizedQuery); isWrite {
+			// we can't tell which columns (or from where) a write we can't parse writes, so it would bypass
+			// the mutator access policies and tokenization if we passed it through
+			return sqlshim.AccessDenied, transformInfo{queryType: queryType}, "did not parse write query", nil
+		}
 		return sqlshim.Passthrough, nil, "did not parse query", nil // ignore errors, let the proxy handle them
 	}
+	selectorValues, err := query.BindSelectorValues(params)
+	if err != nil {
+		uclog.DebugfPII(ctx, `failed to bind parameters for query "%v": %v`, queryString, err)
+		selectorValues = nil
+	}
 	if tableSchema != "" {
 		for i, c := range query.Columns {
 			query.Columns[i].Table = tableSchema + "." + c.Table
@@ -396,37 +408,10 @@ func (h *IdpSQLQueryHandler) HandleQuery(
 
 	switch query.Type {
 	case sqlparse.QueryTypeUpdate, sqlparse.QueryTypeInsert, sqlparse.QueryTypeDelete:
-		globalAP, _, thresholdAP, err :=
-			s.GetAccessPolicies(
-				ctx,
-				h.ts.ID,
-				policy.AccessPolicyGlobalMutatorID,
-				policy.AccessPolicyAllowAll.ID,
-			)
-		if err != nil {
-			return sqlshim.Passthrough, nil, "failed to get access policies", ucerr.Wrap(err)
-		}
-
-		// sqlshim mutator execution is identified for rate limiting via a well-known sentinel UUID
-		sentinelSQLShimMutationID := uuid.Must(uuid.FromString("7581cba6-a98c-416a-a412-e29c66b7c6be"))
-
-		allowed, err := thresholdAP.CheckRateThreshold(ctx, s, apContext, sentinelSQLShimMutationID)
-		if err != nil {
-			return sqlshim.Passthrough, nil, "failed to check rate threshold", ucerr.Wrap(err)
-		}
-
-		if allowed {
-			allowed, _, err = tokenizer.ExecuteAccessPolicy(ctx, globalAP, apContext, h.azc, s)
-			if err != nil {
-				return sqlshim.Passthrough, nil, "failed to execute access policy", ucerr.Wrap(err)
-			}
-		}
-
-		if !allowed {
-			return sqlshim.AccessDenied, &transformInfo{queryType: query.Type}, "access policy denied", nil
-		}
-
-		return sqlshim.Passthrough, nil, "Update/Insert/Delete query", nil
+		var handled bool
+		responseType, transformInfoStruct, reason, handled, err = h.handleWriteQuery(ctx, s, dbt, queryString, query, sanitizedQuery, tableSchema, keyValuePairs, apContext, params)
+		logUnhandledQuery = !handled
+		return responseType, transformInfoStruct, reason, ucerr.Wrap(err)
 	}
 
 	dtm, err := storage.NewDataTypeManager(ctx, s)
@@ -704,6 +689,7 @@ func (h *IdpSQLQueryHandler) HandleQuery(
 		s:                   s,
 		dtm:                 dtm,
 		accessor:            accessor,
+		selectorValues:      selectorValues,
 		startTime:           startTime,
 		queryType:           query.Type,
 		ctis:                ctis,
@@ -723,13 +709,17 @@ func (h *IdpSQLQueryHandler) HandleQuery(
 // TransformSummary records the summary from a transformed query response
 func (h *IdpSQLQueryHandler) TransformSummary(ctx context.Context, t any, numSelectorRows, numReturned, numDenied int) {
 	ti := t.(transformInfo)
+	if ti.accessor == nil {
+		// write queries are denied before they execute, so there's nothing to summarize
+		return
+	}
 
 	// Log the query to the audit log
 	aei := newAccessorExecutor(
 		ctx,
 		ti.s,
 		nil,
-		idp.ExecuteAccessorRequest{AccessorID: ti.accessor.ID},
+		idp.ExecuteAccessorRequest{AccessorID: ti.accessor.ID, SelectorValues: ti.selectorValues},
 		ti.startTime,
 		false,
 		ti.accessor,
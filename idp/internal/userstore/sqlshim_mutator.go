@@ -0,0 +1,441 @@
+package userstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp/events"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/idp/internal/tokenizer"
+	"userclouds.com/idp/internal/userstore/sqlparse"
+	"userclouds.com/idp/policy"
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/uctypes/set"
+	"userclouds.com/internal/sqlshim"
+)
+
+// sqlshim mutator execution is identified for rate limiting via a well-known sentinel UUID when the query doesn't
+// write any userstore columns
+var sentinelSQLShimMutationID = uuid.Must(uuid.FromString("7581cba6-a98c-416a-a412-e29c66b7c6be"))
+
+const maxMutatorNameLength = 128
+
+// writtenColumn is a userstore column written by an INSERT or UPDATE query, along with its index in the query's
+// columns and the normalizer the mutator applies to it
+type writtenColumn struct {
+	column      storage.Column
+	queryIndex  int
+	normalizer  *storage.Transformer
+	transformer *storage.Transformer
+}
+
+// handleWriteQuery checks an INSERT, UPDATE or DELETE query against the global mutator access policy and the access
+// policy of the mutator for the table and columns it writes, and rewrites any values written to columns whose
+// default transformer tokenizes them; handled is false if the query doesn't touch any userstore columns
+func (h *IdpSQLQueryHandler) handleWriteQuery(
+	ctx context.Context,
+	s *storage.Storage,
+	dbt sqlshim.DatabaseType,
+	queryString string,
+	query *sqlparse.Query,
+	sanitizedQuery string,
+	tableSchema string,
+	keyValuePairs map[string]any,
+	apContext policy.AccessPolicyContext,
+	params []any,
+) (responseType sqlshim.HandleQueryResponse, transformInfoStruct any, reason string, handled bool, returnError error) {
+	table := query.Table
+	if tableSchema != "" {
+		table = tableSchema + "." + table
+	}
+
+	cm, err := storage.NewColumnManager(ctx, s, h.databaseID)
+	if err != nil {
+		return sqlshim.Passthrough, nil, "failed to create column manager", false, ucerr.Wrap(err)
+	}
+
+	// a DELETE removes every column of the rows it matches
+	var columns []storage.Column
+	queryIndexes := map[uuid.UUID]int{}
+	if query.Type == sqlparse.QueryTypeDelete {
+		columns = cm.GetColumnsByTable(table)
+	} else {
+		for i, c := range query.Columns {
+			if col := cm.GetColumnByTableAndName(table, c.Name); col != nil {
+				columns = append(columns, *col)
+				queryIndexes[col.ID] = i
+			}
+		}
+	}
+
+	var mutator *storage.Mutator
+	if len(columns) > 0 {
+		mutator, reason, err = h.getOrCreateMutator(ctx, s, query, table, columns, sanitizedQuery, keyValuePairs)
+		if err != nil {
+			return sqlshim.Passthrough, nil, reason, false, ucerr.Wrap(err)
+		}
+	}
+
+	mutatorAccessPolicyID := policy.AccessPolicyAllowAll.ID
+	rateLimitID := sentinelSQLShimMutationID
+	if mutator != nil {
+		mutatorAccessPolicyID = mutator.AccessPolicyID
+		rateLimitID = mutator.ID
+	}
+
+	globalAP, mutatorAP, thresholdAP, err :=
+		s.GetAccessPolicies(
+			ctx,
+			h.ts.ID,
+			policy.AccessPolicyGlobalMutatorID,
+			mutatorAccessPolicyID,
+		)
+	if err != nil {
+		return sqlshim.Passthrough, nil, "failed to get access policies", false, ucerr.Wrap(err)
+	}
+
+	allowed, err := thresholdAP.CheckRateThreshold(ctx, s, apContext, rateLimitID)
+	if err != nil {
+		return sqlshim.Passthrough, nil, "failed to check rate threshold", false, ucerr.Wrap(err)
+	}
+
+	if allowed {
+		clientAP := &policy.AccessPolicy{
+			PolicyType: policy.PolicyTypeCompositeAnd,
+			Components: []policy.AccessPolicyComponent{
+				{Policy: &userstore.ResourceID{ID: globalAP.ID}},
+				{Policy: &userstore.ResourceID{ID: mutatorAP.ID}},
+			},
+		}
+		allowed, _, err = tokenizer.ExecuteAccessPolicy(ctx, clientAP, apContext, h.azc, s)
+		if err != nil {
+			return sqlshim.Passthrough, nil, "failed to execute access policy", false, ucerr.Wrap(err)
+		}
+	}
+
+	handled = mutator != nil
+	if !allowed {
+		return sqlshim.AccessDenied, transformInfo{queryType: query.Type}, "access policy denied", handled, nil
+	}
+
+	if mutator == nil || query.Type == sqlparse.QueryTypeDelete {
+		return sqlshim.Passthrough, nil, "Update/Insert/Delete query", handled, nil
+	}
+
+	writtenColumns, err := getWrittenColumns(ctx, s, mutator, columns, queryIndexes)
+	if err != nil {
+		return sqlshim.Passthrough, nil, "failed to get mutator transformers", handled, ucerr.Wrap(err)
+	}
+
+	rewrite, err := h.transformWrittenValues(ctx, s, dbt, queryString, query, writtenColumns, params)
+	if err != nil {
+		// we don't want to write untransformed values for columns that should be tokenized
+		uclog.Warningf(ctx, "failed to transform values written by query: %v", err)
+		return sqlshim.AccessDenied, transformInfo{queryType: query.Type}, "failed to transform written values", handled, nil
+	}
+	if rewrite == nil {
+		return sqlshim.Passthrough, nil, "", handled, nil
+	}
+
+	return sqlshim.RewriteQuery, *rewrite, "", handled, nil
+}
+
+// getOrCreateMutator finds the mutator named in the query comments, or one whose columns and selector match the
+// query, and otherwise creates one like we do for accessors
+func (h *IdpSQLQueryHandler) getOrCreateMutator(
+	ctx context.Context,
+	s *storage.Storage,
+	query *sqlparse.Query,
+	table string,
+	columns []storage.Column,
+	sanitizedQuery string,
+	keyValuePairs map[string]any,
+) (*storage.Mutator, string, error) {
+	columnIDs := set.NewUUIDSet()
+	for _, col := range columns {
+		columnIDs.Insert(col.ID)
+	}
+
+	mutatorName, ok := keyValuePairs["mutator_name"].(string)
+	var randomizeName bool
+	if ok {
+		mutator, err := s.GetMutatorByName(ctx, mutatorName)
+		if err == nil {
+			if !mutatorSignatureMatches(mutator, columnIDs, query.Selector) {
+				return nil, "named mutator does not match query signature", ucerr.Friendlyf(nil, "mutator '%s' does not match query signature", mutatorName)
+			}
+			return mutator, "", nil
+		}
+	} else {
+		// unnamed mutator -- generate a name based on the query
+		columnNames := set.NewStringSet()
+		for _, col := range columns {
+			columnNames.Insert(col.Name)
+		}
+		switch query.Type {
+		case sqlparse.QueryTypeInsert:
+			mutatorName = "INSERT_" + strings.Join(columnNames.Items(), "-") + "_INTO_" + table
+		case sqlparse.QueryTypeUpdate:
+			mutatorName = "UPDATE_" + table + "_SET_" + strings.Join(columnNames.Items(), "-") + "_WHERE_" + query.Selector
+		default:
+			mutatorName = "DELETE_FROM_" + table + "_WHERE_" + query.Selector
+		}
+		mutatorName = strings.ReplaceAll(mutatorName, "!", "NOT")
+		mutatorName = accessorNameInvalidChars.ReplaceAllString(mutatorName, "")
+		if len(mutatorName) > maxMutatorNameLength {
+			mutatorName = mutatorName[:maxMutatorNameLength]
+		}
+
+		// check to see if a mutator already exists based on the signature of this query
+		pager, err := storage.NewMutatorPaginatorFromOptions()
+		if err != nil {
+			return nil, "failed to create mutator paginator", ucerr.Wrap(err)
+		}
+		for {
+			mutators, pr, err := s.GetLatestMutators(ctx, *pager)
+			if err != nil {
+				return nil, "failed to get latest mutators", ucerr.Wrap(err)
+			}
+
+			for _, m := range mutators {
+				if mutatorSignatureMatches(&m, columnIDs, query.Selector) {
+					uclog.Infof(ctx, "found existing mutator: %v", m)
+					return &m, "", nil
+				}
+
+				// remember to randomize the name if it's taken by a mutator with a different signature
+				if strings.EqualFold(m.Name, mutatorName) {
+					randomizeName = true
+				}
+			}
+
+			if !pager.AdvanceCursor(*pr) {
+				break
+			}
+		}
+	}
+
+	if randomizeName {
+		if len(mutatorName) > maxMutatorNameLength-5 {
+			mutatorName = mutatorName[:maxMutatorNameLength-5]
+		}
+		mutatorName = mutatorName + "_" + uuid.Must(uuid.NewV4()).String()[:4]
+	}
+
+	uclog.Infof(ctx, "creating new mutator")
+	newMutator := &userstore.Mutator{
+		ID:             uuid.Must(uuid.NewV4()),
+		Name:           mutatorName,
+		Description:    "Mutator generated from query: " + sanitizedQuery,
+		Version:        1,
+		SelectorConfig: userstore.UserSelectorConfig{WhereClause: query.Selector},
+	}
+	for _, col := range columns {
+		newMutator.Columns = append(newMutator.Columns, userstore.ColumnInputConfig{
+			Column:     userstore.ResourceID{ID: col.ID},
+			Normalizer: userstore.ResourceID{ID: policy.TransformerPassthrough.ID},
+		})
+	}
+
+	ap, err := createAllowAllAccessPolicy(ctx, s, h.azc, h.lgsc, h.ts.ID, "AccessPolicyForMutator_"+newMutator.ID.String())
+	if err != nil {
+		return nil, "failed to create access policy", ucerr.Wrap(err)
+	}
+
+	newMutator.AccessPolicy = userstore.ResourceID{ID: ap.ID}
+	if err := newMutator.Validate(); err != nil {
+		if err := tokenizer.DeleteAccessPolicyWithAuthz(ctx, s, h.azc, ap); err != nil {
+			uclog.Errorf(ctx, "failed to delete access policy: %v", err)
+		}
+		return nil, "failed to validate mutator", ucerr.Wrap(err)
+	}
+
+	mm := storage.NewMethodManager(ctx, s)
+	if _, err := mm.CreateMutatorFromClient(ctx, newMutator); err != nil {
+		if err := tokenizer.DeleteAccessPolicyWithAuthz(ctx, s, h.azc, ap); err != nil {
+			uclog.Errorf(ctx, "failed to delete access policy: %v", err)
+		}
+		return nil, "failed to create mutator", ucerr.Wrap(err)
+	}
+
+	// Create event types for the new mutator without blocking the response
+	if h.lgsc != nil {
+		go func() {
+			e := events.GetEventsForMutator(newMutator.ID, newMutator.Version)
+
+			if _, err := h.lgsc.CreateEventTypesForTenant(context.Background(), "idp", uuid.Nil, h.ts.ID, &e); err != nil {
+				uclog.Errorf(ctx, "failed to create event types for mutator %v: %v", newMutator.ID, err)
+			}
+		}()
+	}
+
+	mutator, err := s.GetLatestMutator(ctx, newMutator.ID)
+	if err != nil {
+		return nil, "failed to get latest mutator", ucerr.Wrap(err)
+	}
+	return mutator, "", nil
+}
+
+func mutatorSignatureMatches(mutator *storage.Mutator, columnIDs set.Set[uuid.UUID], selector string) bool {
+	return mutator.SelectorConfig.WhereClause == selector &&
+		set.NewUUIDSet(mutator.ColumnIDs...).Equal(columnIDs)
+}
+
+// getWrittenColumns looks up the mutator's normalizer and the default transformer for each column written by the query
+func getWrittenColumns(
+	ctx context.Context,
+	s *storage.Storage,
+	mutator *storage.Mutator,
+	columns []storage.Column,
+	queryIndexes map[uuid.UUID]int,
+) ([]writtenColumn, error) {
+	normalizerIDs := map[uuid.UUID]uuid.UUID{}
+	for i, columnID := range mutator.ColumnIDs {
+		if i < len(mutator.NormalizerIDs) {
+			normalizerIDs[columnID] = mutator.NormalizerIDs[i]
+		}
+	}
+
+	writtenColumns := make([]writtenColumn, 0, len(columns))
+	for _, col := range columns {
+		wc := writtenColumn{column: col, queryIndex: queryIndexes[col.ID]}
+
+		if normalizerID, found := normalizerIDs[col.ID]; found && normalizerID != policy.TransformerPassthrough.ID {
+			normalizer, err := s.GetLatestTransformer(ctx, normalizerID)
+			if err != nil {
+				return nil, ucerr.Wrap(err)
+			}
+			wc.normalizer = normalizer
+		}
+
+		transformer, err := s.GetLatestTransformer(ctx, col.DefaultTransformerID)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if transformer.RequiresTokenAccessPolicy() {
+			wc.transformer = transformer
+		}
+
+		writtenColumns = append(writtenColumns, wc)
+	}
+	return writtenColumns, nil
+}
+
+// writtenValue is a value written by the query that needs to be normalized or tokenized
+type writtenValue struct {
+	column   writtenColumn
+	value    sqlparse.Value
+	data     string
+	location string
+}
+
+// transformWrittenValues normalizes and tokenizes the values written by the query, and returns how to rewrite the
+// query with the transformed values, or nil if no values need to change
+func (h *IdpSQLQueryHandler) transformWrittenValues(
+	ctx context.Context,
+	s *storage.Storage,
+	dbt sqlshim.DatabaseType,
+	queryString string,
+	query *sqlparse.Query,
+	writtenColumns []writtenColumn,
+	params []any,
+) (*sqlshim.QueryRewrite, error) {
+	var values []writtenValue
+	for _, wc := range writtenColumns {
+		if wc.normalizer == nil && wc.transformer == nil {
+			continue
+		}
+		if wc.transformer != nil && wc.transformer.RequiresDataProvenance() {
+			return nil, ucerr.Errorf("column '%s' is tokenized by reference, which is not supported for writes through the proxy", wc.column.FullName())
+		}
+		if wc.column.IsArray {
+			return nil, ucerr.Errorf("array column '%s' can't be transformed for writes through the proxy", wc.column.FullName())
+		}
+
+		for _, row := range query.Values {
+			v := row[wc.queryIndex]
+			wv := writtenValue{column: wc, value: v}
+			switch {
+			case v.Expression:
+				return nil, ucerr.Errorf("the value written to column '%s' is an expression", wc.column.FullName())
+			case v.Param > 0:
+				if int(v.Param) > len(params) {
+					return nil, ucerr.Errorf("the value written to column '%s' is parameter $%d, which isn't bound", wc.column.FullName(), v.Param)
+				}
+				if params[v.Param-1] == nil {
+					continue
+				}
+				wv.data = fmt.Sprint(params[v.Param-1])
+			case v.Literal == nil:
+				continue
+			default:
+				wv.data = fmt.Sprint(v.Literal)
+			}
+			values = append(values, wv)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	te := tokenizer.NewTransformerExecutor(s, h.azc)
+	defer te.CleanupExecution()
+
+	// normalize first, since that's what the mutator would store, and then tokenize the normalized value
+	for _, step := range []func(writtenColumn) (*storage.Transformer, uuid.UUID){
+		func(wc writtenColumn) (*storage.Transformer, uuid.UUID) { return wc.normalizer, uuid.Nil },
+		func(wc writtenColumn) (*storage.Transformer, uuid.UUID) {
+			return wc.transformer, wc.column.DefaultTokenAccessPolicyID
+		},
+	} {
+		var indexes []int
+		var transformerParams []tokenizer.ExecuteTransformerParameters
+		for i, wv := range values {
+			if transformer, tokenAccessPolicyID := step(wv.column); transformer != nil {
+				indexes = append(indexes, i)
+				transformerParams = append(transformerParams, tokenizer.ExecuteTransformerParameters{
+					Transformer:         transformer,
+					TokenAccessPolicyID: tokenAccessPolicyID,
+					Data:                wv.data,
+				})
+			}
+		}
+		if len(transformerParams) == 0 {
+			continue
+		}
+
+		results, _, err := te.Execute(ctx, transformerParams...)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		for i, result := range results {
+			values[indexes[i]].data = result
+		}
+	}
+
+	rewrite := &sqlshim.QueryRewrite{Params: map[int]string{}}
+	literals := map[int]string{}
+	for _, wv := range values {
+		if wv.value.Param > 0 {
+			rewrite.Params[int(wv.value.Param)-1] = wv.data
+		} else {
+			literals[wv.value.Location] = wv.data
+		}
+	}
+
+	if len(literals) > 0 {
+		rewritten, err := sqlparse.ReplaceLiterals(queryString, literals, dbt == sqlshim.DatabaseTypeMySQL)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		rewrite.Query = rewritten
+	}
+
+	return rewrite, nil
+}
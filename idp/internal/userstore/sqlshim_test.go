@@ -174,6 +174,14 @@ func TestSqlShim(t *testing.T) {
 	assert.Equal(t, entries[12].Type, internal.AuditLogEventTypeExecuteAccessor)
 	assert.Equal(t, entries[12].Payload["Name"], "SELECT_id_FROM_testtest_WHERE_idISNULL")
 
+	// a write we can't parse is denied, since we can't tell which columns it writes
+	_, err = db.ExecContext(ctx, "INSERT INTO test SELECT id + 10, name FROM test")
+	assert.NotNil(t, err)
+
+	entries = getAuditLogEntries(ctx, t, tenantDB)
+	assert.Equal(t, len(entries), 14, assert.Must())
+	assert.Equal(t, entries[13].Type, internal.AuditLogEventTypeSqlshimUnhandledQuery)
+	assert.Equal(t, entries[13].Payload["Reason"], "did not parse write query")
 }
 
 func getAuditLogEntries(ctx context.Context, t *testing.T, tenantDB *ucdb.DB) []auditlog.Entry {
@@ -0,0 +1,500 @@
+package userstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+
+	"userclouds.com/authz"
+	authzhelpers "userclouds.com/authz/helpers"
+	"userclouds.com/idp"
+	"userclouds.com/idp/config"
+	"userclouds.com/idp/internal"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/auth"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantmap"
+	plexhelpers "userclouds.com/plex/helpers"
+	uestorage "userclouds.com/userevent/storage"
+	"userclouds.com/worker"
+)
+
+// userErasureSystems are the systems a user erasure deletes the user's data from, in the order they are erased
+var userErasureSystems = []idp.UserErasureSystem{
+	idp.UserErasureSystemUserstore,
+	idp.UserErasureSystemTokenizer,
+	idp.UserErasureSystemAuthz,
+	idp.UserErasureSystemPlex,
+	idp.UserErasureSystemUserEvents,
+}
+
+// userErasureMaxAttempts is how many times erasing a system is attempted each time the erasure task runs
+const userErasureMaxAttempts = 3
+
+// userErasureRetryBackoff is how long to wait before the first retry of a system, doubling with each retry
+var userErasureRetryBackoff = time.Second
+
+// errUserErasureForbidden is returned when a caller that isn't an admin requests or views a user erasure, since an
+// erasure permanently deletes the user's data from every system
+var errUserErasureForbidden = ucerr.Friendlyf(nil, "You must be an admin, or use client credentials, to request or view a user erasure")
+
+func newClientUserErasure(e storage.UserErasure) idp.UserErasure {
+	erasure := idp.UserErasure{
+		ID:          e.ID,
+		UserID:      e.UserID,
+		Status:      idp.UserErasureStatus(e.Status),
+		Systems:     []idp.UserErasureSystemState{},
+		Created:     e.Created,
+		CompletedAt: e.CompletedAt,
+		Certificate: e.Certificate,
+	}
+	for _, ss := range e.Systems {
+		erasure.Systems = append(erasure.Systems, idp.UserErasureSystemState{
+			System:        ss.System,
+			Status:        idp.UserErasureStatus(ss.Status),
+			Attempts:      ss.Attempts,
+			Error:         ss.Error,
+			DeletedCount:  ss.DeletedCount,
+			RetainedUntil: ss.RetainedUntil,
+			CompletedAt:   ss.CompletedAt,
+		})
+	}
+	return erasure
+}
+
+func (h *handler) createUserErasure(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ts := multitenant.MustGetTenantState(ctx)
+	s := storage.MustCreateStorage(ctx)
+
+	if code, err := ensureAdminOrM2M(ctx, errUserErasureForbidden); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	var req idp.CreateUserErasureRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	// an erasure that has not completed yet (because it failed, or because some of the user's values are being
+	// retained) is resumed rather than started over, since the user may already be gone from some systems
+	erasures, err := s.ListUserErasuresForUserID(ctx, req.UserID)
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+	var erasure *storage.UserErasure
+	for i := range erasures {
+		if erasures[i].Status != storage.UserErasureStatusCompleted {
+			erasure = &erasures[i]
+			break
+		}
+	}
+
+	code := http.StatusOK
+	if erasure == nil {
+		us := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+		if _, _, err := us.GetBaseUser(ctx, req.UserID, false); err != nil {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+			return
+		}
+
+		erasure = &storage.UserErasure{
+			UserBaseModel: ucdb.NewUserBase(req.UserID),
+			Status:        storage.UserErasureStatusPending,
+			Systems:       storage.UserErasureSystemStates{},
+		}
+		for _, system := range userErasureSystems {
+			erasure.Systems = append(erasure.Systems, storage.UserErasureSystemState{
+				System: system,
+				Status: storage.UserErasureStatusPending,
+			})
+		}
+		code = http.StatusCreated
+	}
+
+	erasure.Status = storage.UserErasureStatusPending
+	erasure.UserEventAliases = mergeUserEventAliases(erasure.UserEventAliases, req.UserEventAliases)
+	if err := s.SaveUserErasure(ctx, erasure); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+
+	if err := h.workerClient.Send(ctx, worker.UserErasureMessage(ts.ID, erasure.ID)); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+
+	auditlog.PostMultipleAsync(ctx, auditlog.NewEntryArray(auth.GetAuditLogActor(ctx), internal.AuditLogEventTypeCreateUserErasure,
+		auditlog.Payload{"ID": erasure.ID, "UserID": erasure.UserID}))
+
+	jsonapi.Marshal(w, newClientUserErasure(*erasure), jsonapi.Code(code))
+}
+
+func (h *handler) getUserErasure(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	ctx := r.Context()
+	s := storage.MustCreateStorage(ctx)
+
+	if code, err := ensureAdminOrM2M(ctx, errUserErasureForbidden); err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	erasure, err := s.GetUserErasure(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusNotFound))
+			return
+		}
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+		return
+	}
+
+	jsonapi.Marshal(w, newClientUserErasure(*erasure))
+}
+
+// mergeUserEventAliases adds any new aliases to the existing ones, keeping their order
+func mergeUserEventAliases(existing []string, aliases []string) []string {
+	merged := append([]string{}, existing...)
+	for _, alias := range aliases {
+		found := false
+		for _, m := range merged {
+			if m == alias {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, alias)
+		}
+	}
+	return merged
+}
+
+// EraseUser erases the user of a pending user erasure from every system that has not been erased yet, retrying
+// each system a few times before giving up on it, and certifies the erasure once every system is completed
+func EraseUser(ctx context.Context, ts *tenantmap.TenantState, searchUpdateConfig *config.SearchUpdateConfig, erasureID uuid.UUID) error {
+	ctx = multitenant.SetTenantState(ctx, ts)
+	s := storage.NewFromTenantState(ctx, ts)
+
+	erasure, err := s.GetUserErasure(ctx, erasureID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if erasure.Status != storage.UserErasureStatusPending {
+		uclog.Infof(ctx, "user erasure %v is already %v", erasureID, erasure.Status)
+		return nil
+	}
+
+	erase := func(ctx context.Context, system idp.UserErasureSystem) (int, time.Time, error) {
+		return eraseUserFromSystem(ctx, ts, searchUpdateConfig, s, *erasure, system)
+	}
+	save := func(ctx context.Context) error {
+		return ucerr.Wrap(s.SaveUserErasure(ctx, erasure))
+	}
+	if err := eraseUserErasureSystems(ctx, erasure, erase, save); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	erasure.Status = getUserErasureStatus(erasure.Systems)
+	switch erasure.Status {
+	case storage.UserErasureStatusCompleted:
+		erasure.CompletedAt = time.Now().UTC()
+		certificate, err := certifyUserErasure(ctx, ts, *erasure)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		erasure.Certificate = certificate
+	case storage.UserErasureStatusFailed:
+		uclog.Warningf(ctx, "user erasure %v for user %v failed", erasureID, erasure.UserID)
+	case storage.UserErasureStatusPending:
+		uclog.Infof(ctx, "user erasure %v for user %v is waiting for retained values to expire", erasureID, erasure.UserID)
+	}
+
+	if err := s.SaveUserErasure(ctx, erasure); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	return nil
+}
+
+// isUserErasureDue returns true if an erasure is only waiting for retained values to expire, and all of them have
+// expired by now. Pending systems that aren't waiting on retained values are being erased by a task already.
+func isUserErasureDue(erasure storage.UserErasure, now time.Time) bool {
+	if erasure.Status != storage.UserErasureStatusPending {
+		return false
+	}
+	due := false
+	for _, ss := range erasure.Systems {
+		if ss.Status != storage.UserErasureStatusPending {
+			continue
+		}
+		if ss.RetainedUntil.IsZero() || ss.RetainedUntil.After(now) {
+			return false
+		}
+		due = true
+	}
+	return due
+}
+
+// ResumeUserErasures resumes every pending user erasure of a tenant whose retained values have all expired, so
+// that the user's remaining values are erased and the erasure is certified without another request
+func ResumeUserErasures(ctx context.Context, ts *tenantmap.TenantState, searchUpdateConfig *config.SearchUpdateConfig) error {
+	ctx = multitenant.SetTenantState(ctx, ts)
+	s := storage.NewFromTenantState(ctx, ts)
+
+	pager, err := storage.NewUserErasurePaginatorFromOptions(
+		pagination.Limit(pagination.MaxLimit),
+		pagination.Filter(fmt.Sprintf("('status',EQ,'%s')", storage.UserErasureStatusPending)),
+	)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	resumed := 0
+	for {
+		erasures, respFields, err := s.ListUserErasuresPaginated(ctx, *pager)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		for _, erasure := range erasures {
+			if !isUserErasureDue(erasure, now) {
+				continue
+			}
+			// one erasure failing shouldn't stop the others from being resumed, it will be retried on the next sweep
+			if err := EraseUser(ctx, ts, searchUpdateConfig, erasure.ID); err != nil {
+				uclog.Errorf(ctx, "failed to resume user erasure %v: %v", erasure.ID, err)
+				continue
+			}
+			resumed++
+		}
+
+		if !pager.AdvanceCursor(*respFields) {
+			break
+		}
+	}
+
+	uclog.Infof(ctx, "resumed %d user erasures for tenant %v", resumed, ts.ID)
+	return nil
+}
+
+// getUserErasureStatus returns failed if any system failed, pending if any system is still waiting for retained
+// values to expire, and completed otherwise
+func getUserErasureStatus(systems storage.UserErasureSystemStates) storage.UserErasureStatus {
+	status := storage.UserErasureStatusCompleted
+	for _, ss := range systems {
+		switch ss.Status {
+		case storage.UserErasureStatusFailed:
+			return storage.UserErasureStatusFailed
+		case storage.UserErasureStatusPending:
+			status = storage.UserErasureStatusPending
+		}
+	}
+	return status
+}
+
+// userErasureFunc erases the user of an erasure from a single system, returning the number of records deleted and
+// when the values that are still being retained will expire
+type userErasureFunc func(ctx context.Context, system idp.UserErasureSystem) (int, time.Time, error)
+
+// eraseUserErasureSystems erases the user from every system of an erasure that has not been completed yet, saving
+// the erasure after every system so that the progress is kept even if the task dies part way through
+func eraseUserErasureSystems(ctx context.Context, erasure *storage.UserErasure, erase userErasureFunc, save func(context.Context) error) error {
+	for i := range erasure.Systems {
+		ss := &erasure.Systems[i]
+		if ss.Status == storage.UserErasureStatusCompleted {
+			continue
+		}
+
+		eraseUserErasureSystem(ctx, erasure.UserID, ss, erase)
+
+		if err := save(ctx); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// eraseUserErasureSystem erases the user from a single system, retrying with backoff, and records the outcome on
+// the system state
+func eraseUserErasureSystem(ctx context.Context, userID uuid.UUID, ss *storage.UserErasureSystemState, erase userErasureFunc) {
+	backoff := userErasureRetryBackoff
+	for attempt := 1; attempt <= userErasureMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		ss.Attempts++
+		deletedCount, retainedUntil, err := erase(ctx, ss.System)
+		if err != nil {
+			uclog.Warningf(ctx, "attempt %d to erase user %v from %v failed: %v", attempt, userID, ss.System, err)
+			ss.Status = storage.UserErasureStatusFailed
+			ss.Error = ucerr.UserFriendlyMessage(err)
+			continue
+		}
+
+		ss.Error = ""
+		ss.DeletedCount += deletedCount
+		ss.RetainedUntil = retainedUntil
+		if retainedUntil.IsZero() {
+			ss.Status = storage.UserErasureStatusCompleted
+			ss.CompletedAt = time.Now().UTC()
+		} else {
+			ss.Status = storage.UserErasureStatusPending
+		}
+		return
+	}
+}
+
+// eraseUserFromSystem deletes the user's data from a system, returning the number of records deleted and, for
+// the userstore, when the values that are still being retained for their column and purpose will expire
+func eraseUserFromSystem(
+	ctx context.Context,
+	ts *tenantmap.TenantState,
+	searchUpdateConfig *config.SearchUpdateConfig,
+	s *storage.Storage,
+	erasure storage.UserErasure,
+	system idp.UserErasureSystem,
+) (int, time.Time, error) {
+	switch system {
+	case idp.UserErasureSystemUserstore:
+		return eraseUserFromUserstore(ctx, ts, searchUpdateConfig, s, erasure.UserID)
+	case idp.UserErasureSystemTokenizer:
+		n, err := s.EraseTokenRecordsByUserID(ctx, erasure.UserID)
+		return n, time.Time{}, ucerr.Wrap(err)
+	case idp.UserErasureSystemAuthz:
+		n, err := eraseUserFromAuthz(ctx, ts, erasure.UserID)
+		return n, time.Time{}, ucerr.Wrap(err)
+	case idp.UserErasureSystemPlex:
+		n, err := plexhelpers.RevokePlexTokensForUser(ctx, ts.TenantDB, ts.CacheConfig, erasure.UserID)
+		return n, time.Time{}, ucerr.Wrap(err)
+	case idp.UserErasureSystemUserEvents:
+		logDB, err := ts.GetLogDB(ctx)
+		if err != nil {
+			return 0, time.Time{}, ucerr.Wrap(err)
+		}
+		aliases := mergeUserEventAliases([]string{erasure.UserID.String()}, erasure.UserEventAliases)
+		n, err := uestorage.New(logDB).EraseUserEventsForUserAliases(ctx, aliases)
+		return n, time.Time{}, ucerr.Wrap(err)
+	default:
+		return 0, time.Time{}, ucerr.Errorf("unknown user erasure system '%v'", system)
+	}
+}
+
+// eraseUserFromUserstore deletes the user if they still exist, which keeps any values that must be retained for
+// their column and purpose as soft-deleted values, and then permanently deletes the soft-deleted values whose
+// retention has run out along with any DSAR exports of the user
+func eraseUserFromUserstore(
+	ctx context.Context,
+	ts *tenantmap.TenantState,
+	searchUpdateConfig *config.SearchUpdateConfig,
+	s *storage.Storage,
+	userID uuid.UUID,
+) (int, time.Time, error) {
+	deletedCount := 0
+	for reg, regDB := range ts.UserRegionDbMap {
+		user, err := storage.NewUserStorage(ctx, regDB, reg, ts.ID).GetBaseUser(ctx, userID, true)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// the user is in another region, or was already deleted by an earlier attempt
+				continue
+			}
+			return 0, time.Time{}, ucerr.Wrap(err)
+		}
+
+		azc, err := newM2MAuthzClient(ctx, ts)
+		if err != nil {
+			return 0, time.Time{}, ucerr.Wrap(err)
+		}
+		if _, err := deleteUser(ctx, searchUpdateConfig, user, reg, azc); err != nil {
+			return 0, time.Time{}, ucerr.Wrap(err)
+		}
+		deletedCount++
+	}
+
+	umrs := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
+	valueCount, retainedUntil, err := umrs.EraseExpiredSoftDeletedValuesForUser(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return 0, time.Time{}, ucerr.Wrap(err)
+	}
+
+	exportCount, err := umrs.EraseDSARExportsForUser(ctx, userID)
+	if err != nil {
+		return 0, time.Time{}, ucerr.Wrap(err)
+	}
+
+	return deletedCount + valueCount + exportCount, retainedUntil, nil
+}
+
+// eraseUserFromAuthz deletes the user's authz object, which also deletes all of the edges to and from it, and then
+// purges the history of those edges and pseudonymizes the user as the actor of any authz changes they made. It
+// returns the number of edges deleted plus the number of history records purged or pseudonymized.
+func eraseUserFromAuthz(ctx context.Context, ts *tenantmap.TenantState, userID uuid.UUID) (int, error) {
+	azc, err := newM2MAuthzClient(ctx, ts)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	edgeCount := 0
+	cursor := pagination.CursorBegin
+	for {
+		resp, err := azc.ListEdgesOnObject(ctx, userID, authz.Pagination(pagination.StartingAfter(cursor)))
+		if err != nil {
+			if errors.Is(err, authz.ErrObjectNotFound) {
+				// the object was already deleted by an earlier attempt, but its history may not have been purged
+				edgeCount = 0
+				break
+			}
+			return 0, ucerr.Wrap(err)
+		}
+		edgeCount += len(resp.Data)
+		if !resp.HasNext {
+			break
+		}
+		cursor = resp.Next
+	}
+
+	if err := azc.DeleteObject(ctx, userID); err != nil && !errors.Is(err, authz.ErrObjectNotFound) {
+		return 0, ucerr.Wrap(err)
+	}
+
+	// the history is purged after the object is deleted, since deleting it records the deletes of its edges
+	historyCount, err := authzhelpers.EraseObjectHistoryForTenant(ctx, ts.ID, ts.TenantDB, ts.CacheConfig, userID)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	return edgeCount + historyCount, nil
+}
+
+// certifyUserErasure returns a JWS signed with the tenant's signing key that records the final state of every
+// system the user was erased from
+func certifyUserErasure(ctx context.Context, ts *tenantmap.TenantState, erasure storage.UserErasure) (string, error) {
+	certificate, err := signWithTenantKey(ctx, ts, jwt.MapClaims{
+		"iss":          ts.GetTenantURL(),
+		"sub":          erasure.UserID.String(),
+		"jti":          erasure.ID.String(),
+		"iat":          time.Now().UTC().Unix(),
+		"requested_at": erasure.Created.Unix(),
+		"completed_at": erasure.CompletedAt.Unix(),
+		"systems":      newClientUserErasure(erasure).Systems,
+	})
+	return certificate, ucerr.Wrap(err)
+}
@@ -0,0 +1,180 @@
+package userstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/internal/storage"
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+)
+
+func TestGetUserErasureStatus(t *testing.T) {
+	systems := storage.UserErasureSystemStates{
+		{System: idp.UserErasureSystemUserstore, Status: storage.UserErasureStatusCompleted},
+		{System: idp.UserErasureSystemAuthz, Status: storage.UserErasureStatusCompleted},
+	}
+	assert.Equal(t, getUserErasureStatus(systems), storage.UserErasureStatusCompleted)
+
+	systems[0].Status = storage.UserErasureStatusPending
+	assert.Equal(t, getUserErasureStatus(systems), storage.UserErasureStatusPending)
+
+	systems[1].Status = storage.UserErasureStatusFailed
+	assert.Equal(t, getUserErasureStatus(systems), storage.UserErasureStatusFailed)
+}
+
+func TestMergeUserEventAliases(t *testing.T) {
+	assert.Equal(t, mergeUserEventAliases(nil, nil), []string{})
+	assert.Equal(t, mergeUserEventAliases([]string{"a", "b"}, []string{"b", "c", "c"}), []string{"a", "b", "c"})
+}
+
+func newTestUserErasure() *storage.UserErasure {
+	erasure := &storage.UserErasure{
+		UserBaseModel: ucdb.NewUserBase(uuid.Must(uuid.NewV4())),
+		Status:        storage.UserErasureStatusPending,
+	}
+	for _, system := range userErasureSystems {
+		erasure.Systems = append(erasure.Systems, storage.UserErasureSystemState{System: system, Status: storage.UserErasureStatusPending})
+	}
+	return erasure
+}
+
+func TestEraseUserErasureSystems(t *testing.T) {
+	backoff := userErasureRetryBackoff
+	userErasureRetryBackoff = 0
+	defer func() { userErasureRetryBackoff = backoff }()
+	ctx := context.Background()
+	retainedUntil := time.Now().UTC().Add(time.Hour)
+
+	t.Run("FanOut", func(t *testing.T) {
+		erasure := newTestUserErasure()
+		erasure.Systems[1].Status = storage.UserErasureStatusCompleted
+
+		var erased []idp.UserErasureSystem
+		saves := 0
+		erase := func(_ context.Context, system idp.UserErasureSystem) (int, time.Time, error) {
+			erased = append(erased, system)
+			return 2, time.Time{}, nil
+		}
+		save := func(context.Context) error {
+			saves++
+			return nil
+		}
+		assert.NoErr(t, eraseUserErasureSystems(ctx, erasure, erase, save))
+
+		// every system that isn't completed yet is erased once, in order, and saved after each one
+		expected := append([]idp.UserErasureSystem{userErasureSystems[0]}, userErasureSystems[2:]...)
+		assert.Equal(t, erased, expected)
+		assert.Equal(t, saves, len(expected))
+		for _, ss := range erasure.Systems {
+			assert.Equal(t, ss.Status, storage.UserErasureStatusCompleted)
+		}
+		assert.Equal(t, erasure.Systems[0].DeletedCount, 2)
+		assert.Equal(t, erasure.Systems[0].Attempts, 1)
+		assert.Equal(t, getUserErasureStatus(erasure.Systems), storage.UserErasureStatusCompleted)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		erasure := newTestUserErasure()
+
+		calls := map[idp.UserErasureSystem]int{}
+		erase := func(_ context.Context, system idp.UserErasureSystem) (int, time.Time, error) {
+			calls[system]++
+			switch system {
+			case idp.UserErasureSystemTokenizer:
+				// succeeds on the last attempt
+				if calls[system] < userErasureMaxAttempts {
+					return 0, time.Time{}, ucerr.Friendlyf(nil, "tokenizer unavailable")
+				}
+			case idp.UserErasureSystemPlex:
+				return 0, time.Time{}, ucerr.Friendlyf(nil, "plex unavailable")
+			}
+			return 1, time.Time{}, nil
+		}
+		save := func(context.Context) error { return nil }
+		assert.NoErr(t, eraseUserErasureSystems(ctx, erasure, erase, save))
+
+		for _, ss := range erasure.Systems {
+			switch ss.System {
+			case idp.UserErasureSystemTokenizer:
+				assert.Equal(t, ss.Status, storage.UserErasureStatusCompleted)
+				assert.Equal(t, ss.Attempts, userErasureMaxAttempts)
+				assert.Equal(t, ss.Error, "")
+			case idp.UserErasureSystemPlex:
+				assert.Equal(t, ss.Status, storage.UserErasureStatusFailed)
+				assert.Equal(t, ss.Attempts, userErasureMaxAttempts)
+				assert.Equal(t, ss.Error, "plex unavailable")
+			default:
+				assert.Equal(t, ss.Status, storage.UserErasureStatusCompleted)
+				assert.Equal(t, ss.Attempts, 1)
+			}
+		}
+		// a failed system doesn't stop the others from being erased
+		assert.Equal(t, calls[idp.UserErasureSystemUserEvents], 1)
+		assert.Equal(t, getUserErasureStatus(erasure.Systems), storage.UserErasureStatusFailed)
+
+		// resuming only retries the failed system
+		calls = map[idp.UserErasureSystem]int{}
+		erase = func(_ context.Context, system idp.UserErasureSystem) (int, time.Time, error) {
+			calls[system]++
+			return 1, time.Time{}, nil
+		}
+		assert.NoErr(t, eraseUserErasureSystems(ctx, erasure, erase, save))
+		assert.Equal(t, calls, map[idp.UserErasureSystem]int{idp.UserErasureSystemPlex: 1})
+		assert.Equal(t, getUserErasureStatus(erasure.Systems), storage.UserErasureStatusCompleted)
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		erasure := newTestUserErasure()
+
+		erase := func(_ context.Context, system idp.UserErasureSystem) (int, time.Time, error) {
+			if system == idp.UserErasureSystemUserstore {
+				return 1, retainedUntil, nil
+			}
+			return 1, time.Time{}, nil
+		}
+		save := func(context.Context) error { return nil }
+		assert.NoErr(t, eraseUserErasureSystems(ctx, erasure, erase, save))
+
+		assert.Equal(t, erasure.Systems[0].Status, storage.UserErasureStatusPending)
+		assert.Equal(t, erasure.Systems[0].RetainedUntil, retainedUntil)
+		erasure.Status = getUserErasureStatus(erasure.Systems)
+		assert.Equal(t, erasure.Status, storage.UserErasureStatusPending)
+
+		// the sweep only resumes the erasure once the retained values have expired
+		assert.False(t, isUserErasureDue(*erasure, time.Now().UTC()))
+		assert.True(t, isUserErasureDue(*erasure, retainedUntil.Add(time.Second)))
+
+		erase = func(_ context.Context, system idp.UserErasureSystem) (int, time.Time, error) {
+			return 3, time.Time{}, nil
+		}
+		assert.NoErr(t, eraseUserErasureSystems(ctx, erasure, erase, save))
+		assert.Equal(t, erasure.Systems[0].Status, storage.UserErasureStatusCompleted)
+		assert.Equal(t, erasure.Systems[0].DeletedCount, 4)
+		assert.True(t, erasure.Systems[0].RetainedUntil.IsZero())
+		assert.Equal(t, getUserErasureStatus(erasure.Systems), storage.UserErasureStatusCompleted)
+	})
+}
+
+func TestIsUserErasureDue(t *testing.T) {
+	now := time.Now().UTC()
+
+	// a new erasure is being erased by the task that was sent when it was created
+	erasure := newTestUserErasure()
+	assert.False(t, isUserErasureDue(*erasure, now))
+
+	for i := range erasure.Systems {
+		erasure.Systems[i].Status = storage.UserErasureStatusCompleted
+	}
+	erasure.Systems[0].Status = storage.UserErasureStatusPending
+	erasure.Systems[0].RetainedUntil = now.Add(-time.Minute)
+	assert.True(t, isUserErasureDue(*erasure, now))
+
+	erasure.Status = storage.UserErasureStatusFailed
+	assert.False(t, isUserErasureDue(*erasure, now))
+}
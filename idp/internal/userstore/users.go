@@ -7,6 +7,7 @@ import (
 
 	"github.com/gofrs/uuid"
 
+	"userclouds.com/authz"
 	"userclouds.com/idp"
 	"userclouds.com/idp/config"
 	"userclouds.com/idp/internal/constants"
@@ -180,7 +181,6 @@ func (h *handler) deleteUserstoreUser(ctx context.Context, id uuid.UUID, _ url.V
 // DeleteUser is a helper method for deleting a user
 func DeleteUser(ctx context.Context, searchUpdateConfig *config.SearchUpdateConfig, id uuid.UUID) (int, error) {
 	ts := multitenant.MustGetTenantState(ctx)
-	s := storage.NewFromTenantState(ctx, ts)
 	umrs := storage.NewUserMultiRegionStorage(ctx, ts.UserRegionDbMap, ts.ID)
 
 	user, reg, err := umrs.GetBaseUser(ctx, id, false)
@@ -192,6 +192,26 @@ func DeleteUser(ctx context.Context, searchUpdateConfig *config.SearchUpdateConf
 		return http.StatusForbidden, ucerr.Wrap(err)
 	}
 
+	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, ucerr.Wrap(err)
+	}
+
+	return deleteUser(ctx, searchUpdateConfig, user, reg, authzClient)
+}
+
+// deleteUser removes all of the user's values via the update user mutator, which keeps any that still need to be
+// retained as soft-deleted values, and then soft-deletes the user
+func deleteUser(
+	ctx context.Context,
+	searchUpdateConfig *config.SearchUpdateConfig,
+	user *storage.BaseUser,
+	reg region.DataRegion,
+	authzClient *authz.Client,
+) (int, error) {
+	ts := multitenant.MustGetTenantState(ctx)
+	s := storage.NewFromTenantState(ctx, ts)
+
 	// remove all purposes for all columns via update user mutator
 
 	mutator, err := s.GetLatestMutator(ctx, constants.UpdateUserMutatorID)
@@ -221,11 +241,6 @@ func DeleteUser(ctx context.Context, searchUpdateConfig *config.SearchUpdateConf
 		}
 	}
 
-	authzClient, err := apiclient.NewAuthzClientFromTenantStateWithPassthroughAuth(ctx)
-	if err != nil {
-		return http.StatusInternalServerError, ucerr.Wrap(err)
-	}
-
 	deletedIDs, _, err := ExecuteMutator(
 		ctx,
 		idp.ExecuteMutatorRequest{
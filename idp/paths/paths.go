@@ -62,8 +62,10 @@ var (
 	ListAccessorsPath        = BaseConfigAccessorPath
 	UpdateAccessorPath       = singleConfigAccessorPath
 
-	BaseAccessorPath    = fmt.Sprintf("%s/accessors", BaseAPIPath)
-	ExecuteAccessorPath = BaseAccessorPath
+	BaseAccessorPath                = fmt.Sprintf("%s/accessors", BaseAPIPath)
+	ExecuteAccessorPath             = BaseAccessorPath
+	ExportAccessorPath              = fmt.Sprintf("%s/actions/export", BaseAccessorPath)
+	ExportAccessorToObjectStorePath = fmt.Sprintf("%s/actions/exporttoobjectstore", BaseAccessorPath)
 
 	BaseConfigMutatorPath   = fmt.Sprintf("%s/mutators", BaseConfigPath)
 	singleConfigMutatorPath = func(id uuid.UUID) string {
@@ -96,6 +98,21 @@ var (
 	CreateUserWithMutatorPath       = fmt.Sprintf("%s/users", BaseAPIPath)
 	GetConsentedPurposesForUserPath = fmt.Sprintf("%s/consentedpurposes", BaseAPIPath)
 
+	BaseDSARExportPath   = fmt.Sprintf("%s/dsarexports", BaseAPIPath)
+	CreateDSARExportPath = BaseDSARExportPath
+	GetDSARExportPath    = func(id uuid.UUID) string {
+		return fmt.Sprintf("%s/%s", BaseDSARExportPath, id)
+	}
+	DownloadDSARExportPath = func(id uuid.UUID, format string) string {
+		return fmt.Sprintf("%s/%s/download?format=%s", BaseDSARExportPath, id, format)
+	}
+
+	BaseUserErasurePath   = fmt.Sprintf("%s/erasures", BaseAPIPath)
+	CreateUserErasurePath = BaseUserErasurePath
+	GetUserErasurePath    = func(id uuid.UUID) string {
+		return fmt.Sprintf("%s/%s", BaseUserErasurePath, id)
+	}
+
 	BaseAPIPath = fmt.Sprintf("%s/api", UserStoreBasePath)
 
 	TokenizerBasePath = "/tokenizer"
@@ -107,6 +124,8 @@ var (
 	InspectToken         = fmt.Sprintf("%s/actions/inspect", BaseTokenPath)
 	LookupToken          = fmt.Sprintf("%s/actions/lookup", BaseTokenPath)
 	LookupOrCreateTokens = fmt.Sprintf("%s/actions/lookuporcreate", BaseTokenPath)
+	RevokeTokens         = fmt.Sprintf("%s/actions/revoke", BaseTokenPath)
+	DecryptTokens        = fmt.Sprintf("%s/actions/decrypt", BaseTokenPath)
 
 	BasePolicyPath = fmt.Sprintf("%s/policies", TokenizerBasePath)
 
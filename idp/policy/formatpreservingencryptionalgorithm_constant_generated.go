@@ -0,0 +1,57 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package policy
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t FormatPreservingEncryptionAlgorithm) MarshalText() ([]byte, error) {
+	switch t {
+	case FormatPreservingEncryptionAlgorithmFF1:
+		return []byte("ff1"), nil
+	case FormatPreservingEncryptionAlgorithmFF31:
+		return []byte("ff3-1"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown FormatPreservingEncryptionAlgorithm value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *FormatPreservingEncryptionAlgorithm) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "ff1":
+		*t = FormatPreservingEncryptionAlgorithmFF1
+	case "ff3-1":
+		*t = FormatPreservingEncryptionAlgorithmFF31
+	default:
+		return ucerr.Friendlyf(nil, "unknown FormatPreservingEncryptionAlgorithm value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *FormatPreservingEncryptionAlgorithm) Validate() error {
+	switch *t {
+	case FormatPreservingEncryptionAlgorithmFF1:
+		return nil
+	case FormatPreservingEncryptionAlgorithmFF31:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown FormatPreservingEncryptionAlgorithm value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t FormatPreservingEncryptionAlgorithm) Enum() []any {
+	return []any{
+		"ff1",
+		"ff3-1",
+	}
+}
+
+// AllFormatPreservingEncryptionAlgorithms is a slice of all FormatPreservingEncryptionAlgorithm values
+var AllFormatPreservingEncryptionAlgorithms = []FormatPreservingEncryptionAlgorithm{
+	FormatPreservingEncryptionAlgorithmFF1,
+	FormatPreservingEncryptionAlgorithmFF31,
+}
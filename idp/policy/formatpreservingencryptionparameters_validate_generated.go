@@ -0,0 +1,25 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package policy
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o FormatPreservingEncryptionParameters) Validate() error {
+	if err := o.Algorithm.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.Alphabet == "" {
+		return ucerr.Friendlyf(nil, "FormatPreservingEncryptionParameters.Alphabet can't be empty")
+	}
+	if o.KeySecret == "" {
+		return ucerr.Friendlyf(nil, "FormatPreservingEncryptionParameters.KeySecret can't be empty")
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
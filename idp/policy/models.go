@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"regexp"
 	"strings"
@@ -30,6 +31,10 @@ const (
 
 	// TransformTypeTokenizeByReference is a transformation that tokenizes the userstore reference to the value passed in
 	TransformTypeTokenizeByReference TransformType = "tokenizebyreference"
+
+	// TransformTypeFormatPreservingEncryption is a reversible transformation that encrypts the value passed in
+	// into a value of the same format, which can be decrypted without storing a token
+	TransformTypeFormatPreservingEncryption TransformType = "formatpreservingencryption"
 )
 
 //go:generate genconstant TransformType
@@ -58,7 +63,9 @@ type Transformer struct {
 
 // IsPolicyRequiredForExecution checks the transformation type and returns if an access policy is required to execute the transformer
 func (g Transformer) IsPolicyRequiredForExecution() bool {
-	return g.TransformType == TransformTypeTokenizeByValue || g.TransformType == TransformTypeTokenizeByReference
+	return g.TransformType == TransformTypeTokenizeByValue ||
+		g.TransformType == TransformTypeTokenizeByReference ||
+		g.TransformType == TransformTypeFormatPreservingEncryption
 }
 
 func (g Transformer) extraValidate() error {
@@ -79,6 +86,57 @@ func (g Transformer) extraValidate() error {
 		return ucerr.Friendlyf(nil, "ReuseExistingToken can only be true for tokenization transformers")
 	}
 
+	if g.TransformType == TransformTypeFormatPreservingEncryption {
+		if _, err := NewFormatPreservingEncryptionParameters(g.Parameters); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// FormatPreservingEncryptionAlgorithm is a NIST SP 800-38G format preserving encryption mode
+type FormatPreservingEncryptionAlgorithm string
+
+const (
+	// FormatPreservingEncryptionAlgorithmFF1 is the FF1 mode, which accepts tweaks of any length
+	FormatPreservingEncryptionAlgorithmFF1 FormatPreservingEncryptionAlgorithm = "ff1"
+
+	// FormatPreservingEncryptionAlgorithmFF31 is the FF3-1 mode
+	FormatPreservingEncryptionAlgorithmFF31 FormatPreservingEncryptionAlgorithm = "ff3-1"
+)
+
+//go:generate genconstant FormatPreservingEncryptionAlgorithm
+
+// FormatPreservingEncryptionParameters are the parameters of a TransformTypeFormatPreservingEncryption transformer
+type FormatPreservingEncryptionParameters struct {
+	Algorithm FormatPreservingEncryptionAlgorithm `json:"algorithm"`
+	// Alphabet is the set of characters that are encrypted, e.g. "0123456789"; other characters are left in place
+	Alphabet string `json:"alphabet" validate:"notempty"`
+	// KeySecret is the name of the secret holding the hex-encoded AES key (16, 24 or 32 bytes)
+	KeySecret string `json:"key_secret" validate:"notempty"`
+	// Tweak is an optional hex-encoded tweak, which is combined with the token access policy ID
+	Tweak string `json:"tweak,omitempty"`
+}
+
+//go:generate genvalidate FormatPreservingEncryptionParameters
+
+// NewFormatPreservingEncryptionParameters parses and validates the parameters of a format preserving encryption transformer
+func NewFormatPreservingEncryptionParameters(parameters string) (*FormatPreservingEncryptionParameters, error) {
+	var params FormatPreservingEncryptionParameters
+	if err := json.Unmarshal([]byte(parameters), &params); err != nil {
+		return nil, ucerr.Friendlyf(err, "format preserving encryption transformer parameters must be a JSON dictionary")
+	}
+	if err := params.Validate(); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return &params, nil
+}
+
+func (p FormatPreservingEncryptionParameters) extraValidate() error {
+	if _, err := hex.DecodeString(p.Tweak); err != nil {
+		return ucerr.Friendlyf(err, "format preserving encryption tweak must be hex-encoded")
+	}
 	return nil
 }
 
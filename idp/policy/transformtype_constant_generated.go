@@ -7,6 +7,8 @@ import "userclouds.com/infra/ucerr"
 // MarshalText implements encoding.TextMarshaler (for JSON)
 func (t TransformType) MarshalText() ([]byte, error) {
 	switch t {
+	case TransformTypeFormatPreservingEncryption:
+		return []byte("formatpreservingencryption"), nil
 	case TransformTypePassThrough:
 		return []byte("passthrough"), nil
 	case TransformTypeTokenizeByReference:
@@ -24,6 +26,8 @@ func (t TransformType) MarshalText() ([]byte, error) {
 func (t *TransformType) UnmarshalText(b []byte) error {
 	s := string(b)
 	switch s {
+	case "formatpreservingencryption":
+		*t = TransformTypeFormatPreservingEncryption
 	case "passthrough":
 		*t = TransformTypePassThrough
 	case "tokenizebyreference":
@@ -41,6 +45,8 @@ func (t *TransformType) UnmarshalText(b []byte) error {
 // Validate implements Validateable
 func (t *TransformType) Validate() error {
 	switch *t {
+	case TransformTypeFormatPreservingEncryption:
+		return nil
 	case TransformTypePassThrough:
 		return nil
 	case TransformTypeTokenizeByReference:
@@ -57,6 +63,7 @@ func (t *TransformType) Validate() error {
 // Enum implements Enum
 func (t TransformType) Enum() []any {
 	return []any{
+		"formatpreservingencryption",
 		"passthrough",
 		"tokenizebyreference",
 		"tokenizebyvalue",
@@ -66,6 +73,7 @@ func (t TransformType) Enum() []any {
 
 // AllTransformTypes is a slice of all TransformType values
 var AllTransformTypes = []TransformType{
+	TransformTypeFormatPreservingEncryption,
 	TransformTypePassThrough,
 	TransformTypeTokenizeByReference,
 	TransformTypeTokenizeByValue,
@@ -14,6 +14,7 @@ import (
 	"userclouds.com/idp/internal/authn"
 	"userclouds.com/idp/internal/datamapping"
 	"userclouds.com/idp/internal/s3shim"
+	"userclouds.com/idp/internal/scim"
 	"userclouds.com/idp/internal/sqlshim"
 	"userclouds.com/idp/internal/sqlshim/msqlshim"
 	"userclouds.com/idp/internal/sqlshim/psqlshim"
@@ -83,6 +84,9 @@ func initAPIHandlers(ctx context.Context, hb *builder.HandlerBuilder,
 	authNHandler := perTenantMiddleware.Apply(authn.NewHandler(cfg, searchUpdateConfig, companyConfigStorage))
 	hb.Handle("/authn/", authNHandler)
 
+	scimHandler := perTenantMiddleware.Apply(scim.NewHandler(searchUpdateConfig))
+	hb.Handle("/scim/v2/", scimHandler)
+
 	hUserStore, err := userstore.NewHandler(ctx, cfg, searchUpdateConfig, localWorkerClient, m2mAuth, *consoleTenantInfo)
 	if err != nil {
 		return ucerr.Wrap(err)
@@ -102,12 +102,12 @@ func TestSqlShimObserver(t *testing.T) {
 	assert.NoErr(t, err)
 
 	// Test that IDP sqlshim observer handles queries correctly
-	handleResp, _, reason, err := observer.HandleQuery(proxyCtx, sqlshim.DatabaseTypePostgres, "SELECT * FROM ext_table_2", "", uuid.Nil)
+	handleResp, _, reason, err := observer.HandleQuery(proxyCtx, sqlshim.DatabaseTypePostgres, "SELECT * FROM ext_table_2", "", uuid.Nil, nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, sqlshim.Passthrough, handleResp)
 	assert.Equal(t, "table not found", reason)
 
-	handleResp, tInfo, _, err := observer.HandleQuery(proxyCtx, sqlshim.DatabaseTypePostgres, "SELECT * FROM ext_table", "", uuid.Nil)
+	handleResp, tInfo, _, err := observer.HandleQuery(proxyCtx, sqlshim.DatabaseTypePostgres, "SELECT * FROM ext_table", "", uuid.Nil, nil)
 	assert.NoErr(t, err)
 	assert.Equal(t, sqlshim.TransformResponse, handleResp)
 
@@ -132,7 +132,7 @@ func TestSqlShimObserver(t *testing.T) {
 	_, err = tf.IDPClient.UpdateColumn(tf.Ctx, textCol.ID, *textCol)
 	assert.NoErr(t, err)
 
-	handleResp, tInfo, _, err = observer.HandleQuery(proxyCtx, sqlshim.DatabaseTypePostgres, "SELECT * FROM ext_table", "", uuid.Nil)
+	handleResp, tInfo, _, err = observer.HandleQuery(proxyCtx, sqlshim.DatabaseTypePostgres, "SELECT * FROM ext_table", "", uuid.Nil, nil)
 	assert.NoErr(t, err)
 	assert.Equal(t, sqlshim.TransformResponse, handleResp)
 
@@ -165,7 +165,8 @@ func TestSqlShimObserver(t *testing.T) {
 		sqlshim.DatabaseTypeMySQL,
 		"SELECT `workflow_workflowitem_v3`.`id`, `workflow_workflowitem_v3`.`organization_id` FROM `workflow_workflowitem_v3` WHERE (`workflow_workflowitem_v3`.`organization_id` = 1 AND `workflow_workflowitem_v3`.`live` = 1 AND `workflow_workflowitem_v3`.`object_id` = 1091527 AND `workflow_workflowitem_v3`.`object_type_id` = 450 AND `workflow_workflowitem_v3`.`organization_id` = 1) LIMIT 21;",
 		"dev_shard2",
-		uuid.Nil)
+		uuid.Nil,
+		nil)
 	assert.NoErr(t, err)
 	assert.Equal(t, sqlshim.TransformResponse, handleResp)
 
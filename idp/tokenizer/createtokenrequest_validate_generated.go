@@ -14,5 +14,9 @@ func (o CreateTokenRequest) Validate() error {
 	if err := o.AccessPolicyRID.Validate(); err != nil {
 		return ucerr.Wrap(err)
 	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
 	return nil
 }
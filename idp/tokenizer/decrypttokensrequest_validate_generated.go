@@ -0,0 +1,22 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tokenizer
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o DecryptTokensRequest) Validate() error {
+	if err := o.TransformerRID.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := o.AccessPolicyRID.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -17,10 +17,46 @@ type CreateTokenRequest struct {
 
 	TransformerRID  userstore.ResourceID `json:"transformer_rid"`
 	AccessPolicyRID userstore.ResourceID `json:"access_policy_rid"`
+
+	// ExpiresAt or TTL (in seconds) optionally limit the lifetime of the token, after which it can't be resolved
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"allownil"`
+	TTL       int        `json:"ttl,omitempty"`
+}
+
+func (c *CreateTokenRequest) extraValidate() error {
+	return ucerr.Wrap(validateTokenLifetime(c.ExpiresAt, c.TTL))
 }
 
 //go:generate genvalidate CreateTokenRequest
 
+// GetExpiresAt returns the time at which the requested token should expire, or zero if it shouldn't
+func (c CreateTokenRequest) GetExpiresAt(now time.Time) time.Time {
+	return getTokenExpiresAt(c.ExpiresAt, c.TTL, now)
+}
+
+func validateTokenLifetime(expiresAt *time.Time, ttl int) error {
+	if expiresAt != nil && ttl != 0 {
+		return ucerr.Friendlyf(nil, "only one of expires_at and ttl can be specified")
+	}
+	if expiresAt != nil && expiresAt.IsZero() {
+		return ucerr.Friendlyf(nil, "expires_at can't be zero")
+	}
+	if ttl < 0 {
+		return ucerr.Friendlyf(nil, "ttl can't be negative: %d", ttl)
+	}
+	return nil
+}
+
+func getTokenExpiresAt(expiresAt *time.Time, ttl int, now time.Time) time.Time {
+	if expiresAt != nil {
+		return expiresAt.UTC()
+	}
+	if ttl > 0 {
+		return now.Add(time.Duration(ttl) * time.Second).UTC()
+	}
+	return time.Time{}
+}
+
 // CreateTokenResponse is the response to a CreateToken call
 type CreateTokenResponse struct {
 	Token string `json:"data"`
@@ -48,6 +84,24 @@ type ResolveTokenResponse struct {
 	Token string `json:"token"` // include this in case it's helpful for correlating later?
 }
 
+// DecryptTokensRequest is the data needed to decrypt tokens created by a format preserving encryption transformer,
+// which aren't stored and so must be decrypted with the transformer and access policy that created them
+type DecryptTokensRequest struct {
+	Tokens          []string             `json:"tokens"`
+	TransformerRID  userstore.ResourceID `json:"transformer_rid"`
+	AccessPolicyRID userstore.ResourceID `json:"access_policy_rid"`
+	Context         policy.ClientContext `json:"context"`
+}
+
+func (r DecryptTokensRequest) extraValidate() error {
+	if slices.Contains(r.Tokens, "") {
+		return ucerr.Friendlyf(nil, "token can't be empty")
+	}
+	return nil
+}
+
+//go:generate genvalidate DecryptTokensRequest
+
 // InspectTokenRequest contains the data required to inspect a token
 type InspectTokenRequest struct {
 	Token string `json:"token" validate:"notempty"`
@@ -65,6 +119,8 @@ type InspectTokenResponse struct {
 
 	AccessPolicy policy.AccessPolicy `json:"access_policy"`
 	Transformer  policy.Transformer  `json:"transformer"`
+
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"allownil"`
 }
 
 // LookupTokensRequest contains the data required to lookup a token
@@ -88,18 +144,47 @@ type LookupOrCreateTokensRequest struct {
 
 	TransformerRIDs  []userstore.ResourceID `json:"transformer_rids"`
 	AccessPolicyRIDs []userstore.ResourceID `json:"access_policy_rids"`
+
+	// ExpiresAt or TTL (in seconds) optionally limit the lifetime of any tokens that are created
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"allownil"`
+	TTL       int        `json:"ttl,omitempty"`
 }
 
 func (l *LookupOrCreateTokensRequest) extraValidate() error {
 	if len(l.Data) != len(l.TransformerRIDs) || len(l.Data) != len(l.AccessPolicyRIDs) {
 		return ucerr.New("data, transformer_rid, and access_policy_rid must be the same length")
 	}
-	return nil
+	return ucerr.Wrap(validateTokenLifetime(l.ExpiresAt, l.TTL))
 }
 
 //go:generate genvalidate LookupOrCreateTokensRequest
 
+// GetExpiresAt returns the time at which any created tokens should expire, or zero if they shouldn't
+func (l LookupOrCreateTokensRequest) GetExpiresAt(now time.Time) time.Time {
+	return getTokenExpiresAt(l.ExpiresAt, l.TTL, now)
+}
+
 // LookupOrCreateTokensResponse contains the data returned by a LookupOrCreateTokens call
 type LookupOrCreateTokensResponse struct {
 	Tokens []string `json:"tokens"`
 }
+
+// RevokeTokensRequest revokes all tokens created with a transformer and/or access policy, e.g. in response to an incident
+type RevokeTokensRequest struct {
+	TransformerRID  *userstore.ResourceID `json:"transformer_rid,omitempty" validate:"allownil"`
+	AccessPolicyRID *userstore.ResourceID `json:"access_policy_rid,omitempty" validate:"allownil"`
+}
+
+func (r *RevokeTokensRequest) extraValidate() error {
+	if r.TransformerRID == nil && r.AccessPolicyRID == nil {
+		return ucerr.Friendlyf(nil, "at least one of transformer_rid and access_policy_rid must be specified")
+	}
+	return nil
+}
+
+//go:generate genvalidate RevokeTokensRequest
+
+// RevokeTokensResponse contains the number of tokens revoked by a RevokeTokens call
+type RevokeTokensResponse struct {
+	RevokedCount int `json:"revoked_count"`
+}
@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -33,12 +34,19 @@ func NewTokenizerClient(url string, opts ...Option) *TokenizerClient {
 	return &TokenizerClient{client: sdkclient.New(url, "tokenizer", options.jsonclientOptions...), options: options}
 }
 
-// CreateToken creates a token
-func (c *TokenizerClient) CreateToken(ctx context.Context, data string, transformerRID, accessPolicyRID userstore.ResourceID) (string, error) {
+// CreateToken creates a token, which never expires unless TokenExpiresAt or TokenTTL is passed
+func (c *TokenizerClient) CreateToken(ctx context.Context, data string, transformerRID, accessPolicyRID userstore.ResourceID, opts ...Option) (string, error) {
+	var options options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	req := tokenizer.CreateTokenRequest{
 		Data:            data,
 		TransformerRID:  transformerRID,
 		AccessPolicyRID: accessPolicyRID,
+		ExpiresAt:       options.tokenExpiresAt,
+		TTL:             int(options.tokenTTL / time.Second),
 	}
 	if err := req.Validate(); err != nil {
 		return "", ucerr.Wrap(err)
@@ -89,6 +97,34 @@ func (c *TokenizerClient) ResolveTokens(ctx context.Context, tokens []string, re
 	return v, nil
 }
 
+// DecryptTokens decrypts tokens created by a format preserving encryption transformer with the given access policy
+func (c *TokenizerClient) DecryptTokens(ctx context.Context, tokens []string, transformerRID, accessPolicyRID userstore.ResourceID, resolutionContext policy.ClientContext) ([]string, error) {
+	req := tokenizer.DecryptTokensRequest{
+		Tokens:          tokens,
+		TransformerRID:  transformerRID,
+		AccessPolicyRID: accessPolicyRID,
+		Context:         resolutionContext,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	var res []tokenizer.ResolveTokenResponse
+	if err := c.client.Post(ctx, paths.DecryptTokens, req, &res); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if len(tokens) != len(res) {
+		return nil, ucerr.New("Server returned partial response")
+	}
+
+	v := make([]string, len(res))
+	for i := range res {
+		v[i] = res[i].Data
+	}
+	return v, nil
+}
+
 // InspectToken helps with debugging
 func (c *TokenizerClient) InspectToken(ctx context.Context, token string) (*tokenizer.InspectTokenResponse, error) {
 	req := tokenizer.InspectTokenRequest{
@@ -125,12 +161,20 @@ func (c *TokenizerClient) LookupTokens(ctx context.Context, data string, transfo
 	return res.Tokens, nil
 }
 
-// LookupOrCreateTokens checks to see if a token exists already for given data, and if not, creates them, returning one token for each input tuple (data, transformer, access policy)
-func (c *TokenizerClient) LookupOrCreateTokens(ctx context.Context, data []string, transformerRIDs, accessPolicyRIDs []userstore.ResourceID) ([]string, error) {
+// LookupOrCreateTokens checks to see if a token exists already for given data, and if not, creates them, returning one token for each input tuple (data, transformer, access policy).
+// If TokenExpiresAt or TokenTTL is passed, existing tokens are not reused and all of the tokens are created with that lifetime.
+func (c *TokenizerClient) LookupOrCreateTokens(ctx context.Context, data []string, transformerRIDs, accessPolicyRIDs []userstore.ResourceID, opts ...Option) ([]string, error) {
+	var options options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
 	req := tokenizer.LookupOrCreateTokensRequest{
 		Data:             data,
 		TransformerRIDs:  transformerRIDs,
 		AccessPolicyRIDs: accessPolicyRIDs,
+		ExpiresAt:        options.tokenExpiresAt,
+		TTL:              int(options.tokenTTL / time.Second),
 	}
 	if err := req.Validate(); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -162,6 +206,25 @@ func (c *TokenizerClient) DeleteToken(ctx context.Context, token string) error {
 	return nil
 }
 
+// RevokeTokens revokes all tokens created with the given transformer and/or access policy, either of which may be nil
+// to match any, and returns the number of tokens revoked
+func (c *TokenizerClient) RevokeTokens(ctx context.Context, transformerRID, accessPolicyRID *userstore.ResourceID) (int, error) {
+	req := tokenizer.RevokeTokensRequest{
+		TransformerRID:  transformerRID,
+		AccessPolicyRID: accessPolicyRID,
+	}
+	if err := req.Validate(); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	var res tokenizer.RevokeTokensResponse
+	if err := c.client.Post(ctx, paths.RevokeTokens, req, &res); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	return res.RevokedCount, nil
+}
+
 // TestAccessPolicy tests an access policy without saving it
 func (c *TokenizerClient) TestAccessPolicy(ctx context.Context, accessPolicy policy.AccessPolicy, context policy.AccessPolicyContext) (*tokenizer.TestAccessPolicyResponse, error) {
 	req := tokenizer.TestAccessPolicyRequest{
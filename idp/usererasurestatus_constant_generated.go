@@ -0,0 +1,65 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t UserErasureStatus) MarshalText() ([]byte, error) {
+	switch t {
+	case UserErasureStatusCompleted:
+		return []byte("completed"), nil
+	case UserErasureStatusFailed:
+		return []byte("failed"), nil
+	case UserErasureStatusPending:
+		return []byte("pending"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown UserErasureStatus value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *UserErasureStatus) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "completed":
+		*t = UserErasureStatusCompleted
+	case "failed":
+		*t = UserErasureStatusFailed
+	case "pending":
+		*t = UserErasureStatusPending
+	default:
+		return ucerr.Friendlyf(nil, "unknown UserErasureStatus value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *UserErasureStatus) Validate() error {
+	switch *t {
+	case UserErasureStatusCompleted:
+		return nil
+	case UserErasureStatusFailed:
+		return nil
+	case UserErasureStatusPending:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown UserErasureStatus value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t UserErasureStatus) Enum() []any {
+	return []any{
+		"completed",
+		"failed",
+		"pending",
+	}
+}
+
+// AllUserErasureStatuss is a slice of all UserErasureStatus values
+var AllUserErasureStatuss = []UserErasureStatus{
+	UserErasureStatusCompleted,
+	UserErasureStatusFailed,
+	UserErasureStatusPending,
+}
@@ -0,0 +1,81 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t UserErasureSystem) MarshalText() ([]byte, error) {
+	switch t {
+	case UserErasureSystemAuthz:
+		return []byte("authz"), nil
+	case UserErasureSystemPlex:
+		return []byte("plex"), nil
+	case UserErasureSystemTokenizer:
+		return []byte("tokenizer"), nil
+	case UserErasureSystemUserEvents:
+		return []byte("userevent"), nil
+	case UserErasureSystemUserstore:
+		return []byte("userstore"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown UserErasureSystem value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *UserErasureSystem) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "authz":
+		*t = UserErasureSystemAuthz
+	case "plex":
+		*t = UserErasureSystemPlex
+	case "tokenizer":
+		*t = UserErasureSystemTokenizer
+	case "userevent":
+		*t = UserErasureSystemUserEvents
+	case "userstore":
+		*t = UserErasureSystemUserstore
+	default:
+		return ucerr.Friendlyf(nil, "unknown UserErasureSystem value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *UserErasureSystem) Validate() error {
+	switch *t {
+	case UserErasureSystemAuthz:
+		return nil
+	case UserErasureSystemPlex:
+		return nil
+	case UserErasureSystemTokenizer:
+		return nil
+	case UserErasureSystemUserEvents:
+		return nil
+	case UserErasureSystemUserstore:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown UserErasureSystem value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t UserErasureSystem) Enum() []any {
+	return []any{
+		"authz",
+		"plex",
+		"tokenizer",
+		"userevent",
+		"userstore",
+	}
+}
+
+// AllUserErasureSystems is a slice of all UserErasureSystem values
+var AllUserErasureSystems = []UserErasureSystem{
+	UserErasureSystemAuthz,
+	UserErasureSystemPlex,
+	UserErasureSystemTokenizer,
+	UserErasureSystemUserEvents,
+	UserErasureSystemUserstore,
+}
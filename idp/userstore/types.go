@@ -504,6 +504,22 @@ type ShimObjectStore struct {
 	SecretAccessKey string     `json:"secret_access_key" validate:"skip"`
 	RoleARN         string     `json:"role_arn" validate:"skip"`
 	AccessPolicy    ResourceID `json:"access_policy" validate:"skip"`
+
+	// FieldMappings configure which fields of the stored JSON, NDJSON and CSV objects are transformed when read
+	FieldMappings []ShimObjectStoreFieldMapping `json:"field_mappings,omitempty"`
+
+	// TokenizeOnWrite applies the field mappings (whose transformers must then be tokenizing transformers) to objects
+	// as they are uploaded rather than as they are read, so the raw values never reach the bucket
+	TokenizeOnWrite bool `json:"tokenize_on_write,omitempty"`
+
+	// WriteAccessPolicy is checked for uploads and deletes through the shim instead of AccessPolicy. If it isn't set,
+	// the object store is read-only.
+	WriteAccessPolicy ResourceID `json:"write_access_policy" validate:"skip"`
+
+	// ExportBucket and ExportPrefix restrict where accessor exports can be written with the object store's
+	// credentials. Exports to the object store are rejected if ExportBucket isn't set.
+	ExportBucket string `json:"export_bucket,omitempty"`
+	ExportPrefix string `json:"export_prefix,omitempty"`
 }
 
 func (s *ShimObjectStore) extraValidate() error {
@@ -513,6 +529,26 @@ func (s *ShimObjectStore) extraValidate() error {
 	return nil
 }
 
+// ShimObjectStoreFieldMapping maps a field of the objects stored under a key prefix to a userstore column. For JSON
+// and NDJSON objects the field path is a dot-separated list of keys (arrays along the way are traversed element by
+// element), and for CSV objects it is the name of a header column. The field's values are transformed with the
+// given transformer, or the column's default transformer if none is specified.
+type ShimObjectStoreFieldMapping struct {
+	KeyPrefix   string     `json:"key_prefix"`
+	FieldPath   string     `json:"field_path" validate:"notempty"`
+	Column      ResourceID `json:"column" validate:"skip"`
+	Transformer ResourceID `json:"transformer" validate:"skip"`
+}
+
+func (m *ShimObjectStoreFieldMapping) extraValidate() error {
+	if err := m.Column.Validate(); err != nil {
+		return ucerr.Friendlyf(err, "field mapping for '%s' must specify a column", m.FieldPath)
+	}
+	return nil
+}
+
+//go:generate genvalidate ShimObjectStoreFieldMapping
+
 //go:generate genvalidate ShimObjectStore
 
 // EqualsIgnoringNilIDAndSecret returns true if the two columns are equal, ignoring ID if one is nil, and ignoring secret access key
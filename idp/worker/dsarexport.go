@@ -0,0 +1,16 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp/internal/userstore"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantmap"
+)
+
+// ExportDSAR is a pass-through function to internal function userstore.ExportDSAR
+func ExportDSAR(ctx context.Context, ts *tenantmap.TenantState, exportID uuid.UUID) error {
+	return ucerr.Wrap(userstore.ExportDSAR(ctx, ts, exportID))
+}
@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/config"
+	"userclouds.com/idp/internal/userstore"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantmap"
+)
+
+// ExportAccessorToObjectStore is a pass-through function to internal function userstore.ExportAccessorToObjectStore
+func ExportAccessorToObjectStore(
+	ctx context.Context,
+	ts *tenantmap.TenantState,
+	searchUpdateCfg *config.SearchUpdateConfig,
+	req idp.ExportAccessorToObjectStoreRequest,
+	subjectID uuid.UUID,
+	subjectType string,
+) error {
+	return ucerr.Wrap(userstore.ExportAccessorToObjectStore(ctx, ts, searchUpdateCfg, req, subjectID, subjectType))
+}
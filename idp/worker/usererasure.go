@@ -0,0 +1,22 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp/config"
+	"userclouds.com/idp/internal/userstore"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantmap"
+)
+
+// EraseUser is a pass-through function to internal function userstore.EraseUser
+func EraseUser(ctx context.Context, ts *tenantmap.TenantState, searchUpdateCfg *config.SearchUpdateConfig, erasureID uuid.UUID) error {
+	return ucerr.Wrap(userstore.EraseUser(ctx, ts, searchUpdateCfg, erasureID))
+}
+
+// ResumeUserErasures is a pass-through function to internal function userstore.ResumeUserErasures
+func ResumeUserErasures(ctx context.Context, ts *tenantmap.TenantState, searchUpdateCfg *config.SearchUpdateConfig) error {
+	return ucerr.Wrap(userstore.ResumeUserErasures(ctx, ts, searchUpdateCfg))
+}
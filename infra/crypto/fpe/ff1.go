@@ -0,0 +1,121 @@
+package fpe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"math"
+	"math/big"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const ff1Rounds = 10
+
+// NewFF1 returns an FF1 cipher with the given AES key (16, 24 or 32 bytes) over the given alphabet.
+// FF1 accepts tweaks of any length.
+func NewFF1(key []byte, alphabet string) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ucerr.Friendlyf(err, "FF1 key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+
+	c, err := newCipher(alphabet,
+		func(int) int { return math.MaxInt32 },
+		func(radix int) cryptFunc { return ff1{block: block, radix: radix}.crypt })
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return c, nil
+}
+
+type ff1 struct {
+	block cipher.Block
+	radix int
+}
+
+// crypt implements algorithms 7 (encryption) and 8 (decryption) of SP 800-38G
+func (f ff1) crypt(x []int, tweak []byte, decrypt bool) ([]int, error) {
+	n, t := len(x), len(tweak)
+	u := n / 2
+	v := n - u
+	a, b := x[:u], x[u:]
+
+	radix := big.NewInt(int64(f.radix))
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+
+	// b is the number of bytes needed to hold any v numerals, and d the number of bytes of PRF output used per round
+	bLen := (new(big.Int).Sub(modV, bigOne).BitLen() + 7) / 8
+	d := 4*((bLen+3)/4) + 4
+
+	p := make([]byte, aes.BlockSize)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(f.radix>>16), byte(f.radix>>8), byte(f.radix)
+	p[6], p[7] = ff1Rounds, byte(u)
+	binary.BigEndian.PutUint32(p[8:], uint32(n))
+	binary.BigEndian.PutUint32(p[12:], uint32(t))
+
+	pad := ((-t-bLen-1)%aes.BlockSize + aes.BlockSize) % aes.BlockSize
+	q := make([]byte, t+pad+1+bLen)
+	copy(q, tweak)
+
+	for round := range ff1Rounds {
+		i, src := round, b
+		if decrypt {
+			i, src = ff1Rounds-1-round, a
+		}
+
+		q[t+pad] = byte(i)
+		num(src, radix).FillBytes(q[t+pad+1:])
+
+		y := new(big.Int).SetBytes(f.expand(f.prf(p, q), d))
+
+		m, mod := u, modU
+		if i%2 == 1 {
+			m, mod = v, modV
+		}
+
+		c := new(big.Int)
+		if decrypt {
+			c.Sub(num(b, radix), y)
+		} else {
+			c.Add(num(a, radix), y)
+		}
+		c.Mod(c, mod)
+
+		if decrypt {
+			a, b = str(c, radix, m), a
+		} else {
+			a, b = b, str(c, radix, m)
+		}
+	}
+
+	return append(append([]int{}, a...), b...), nil
+}
+
+// prf is the CBC-MAC of p || q with a zero IV, which is a multiple of the block size by construction
+func (f ff1) prf(p []byte, q []byte) []byte {
+	r := make([]byte, aes.BlockSize)
+	for _, data := range [][]byte{p, q} {
+		for i := 0; i < len(data); i += aes.BlockSize {
+			subtle.XORBytes(r, r, data[i:i+aes.BlockSize])
+			f.block.Encrypt(r, r)
+		}
+	}
+	return r
+}
+
+// expand extends the PRF output r to d bytes by encrypting r xor j for j = 1, 2, ...
+func (f ff1) expand(r []byte, d int) []byte {
+	s := append([]byte{}, r...)
+	for j := uint64(1); len(s) < d; j++ {
+		block := make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(block[8:], j)
+		subtle.XORBytes(block, block, r)
+		f.block.Encrypt(block, block)
+		s = append(s, block...)
+	}
+	return s[:d]
+}
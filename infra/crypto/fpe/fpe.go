@@ -0,0 +1,128 @@
+// Package fpe implements the FF1 and FF3-1 format-preserving encryption modes of NIST SP 800-38G
+package fpe
+
+import (
+	"math"
+	"math/big"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// NIST SP 800-38G requires radix^minlen >= 1,000,000, so that the domain is large enough to resist guessing
+const minDomainSize = 1000000
+
+const maxRadix = 1 << 16
+
+var bigOne = big.NewInt(1)
+
+// cryptFunc encrypts (or decrypts) a string of numerals in the cipher's radix with the given tweak
+type cryptFunc func(numerals []int, tweak []byte, decrypt bool) ([]int, error)
+
+// Cipher is a format-preserving cipher over an alphabet: it encrypts a string into a string of the same length,
+// replacing each character in the alphabet with another character in the alphabet. Characters that aren't in the
+// alphabet (e.g. the dashes in a social security number) are left in place, so the format of the input is preserved.
+type Cipher struct {
+	alphabet []rune
+	indices  map[rune]int
+	minLen   int
+	maxLen   int
+	crypt    cryptFunc
+}
+
+func newCipher(alphabet string, maxLen func(radix int) int, crypt func(radix int) cryptFunc) (*Cipher, error) {
+	runes := []rune(alphabet)
+	if len(runes) < 2 || len(runes) > maxRadix {
+		return nil, ucerr.Friendlyf(nil, "format preserving encryption alphabet must have between 2 and %d characters", maxRadix)
+	}
+
+	indices := make(map[rune]int, len(runes))
+	for i, r := range runes {
+		if _, found := indices[r]; found {
+			return nil, ucerr.Friendlyf(nil, "format preserving encryption alphabet has duplicate character '%c'", r)
+		}
+		indices[r] = i
+	}
+
+	radix := len(runes)
+	return &Cipher{
+		alphabet: runes,
+		indices:  indices,
+		minLen:   max(2, int(math.Ceil(math.Log(minDomainSize)/math.Log(float64(radix))))),
+		maxLen:   maxLen(radix),
+		crypt:    crypt(radix),
+	}, nil
+}
+
+// Encrypt encrypts the characters of s that are in the alphabet, using the given tweak
+func (c Cipher) Encrypt(s string, tweak []byte) (string, error) {
+	out, err := c.apply(s, tweak, false)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return out, nil
+}
+
+// Decrypt reverses Encrypt, given the same tweak
+func (c Cipher) Decrypt(s string, tweak []byte) (string, error) {
+	out, err := c.apply(s, tweak, true)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return out, nil
+}
+
+func (c Cipher) apply(s string, tweak []byte, decrypt bool) (string, error) {
+	runes := []rune(s)
+	var positions []int
+	var numerals []int
+	for i, r := range runes {
+		if index, found := c.indices[r]; found {
+			positions = append(positions, i)
+			numerals = append(numerals, index)
+		}
+	}
+
+	if len(numerals) < c.minLen || len(numerals) > c.maxLen {
+		return "", ucerr.Friendlyf(nil, "format preserving encryption requires between %d and %d characters from the alphabet, got %d", c.minLen, c.maxLen, len(numerals))
+	}
+
+	numerals, err := c.crypt(numerals, tweak, decrypt)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	for i, position := range positions {
+		runes[position] = c.alphabet[numerals[i]]
+	}
+	return string(runes), nil
+}
+
+// num returns the number represented by the numerals in the given radix, most significant numeral first
+func num(numerals []int, radix *big.Int) *big.Int {
+	n := new(big.Int)
+	for _, numeral := range numerals {
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(numeral)))
+	}
+	return n
+}
+
+// str returns the m numerals representing n in the given radix, most significant numeral first
+func str(n *big.Int, radix *big.Int, m int) []int {
+	numerals := make([]int, m)
+	n = new(big.Int).Set(n)
+	digit := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		n.DivMod(n, radix, digit)
+		numerals[i] = int(digit.Int64())
+	}
+	return numerals
+}
+
+func reversed[T any](s []T) []T {
+	r := make([]T, len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
@@ -0,0 +1,118 @@
+package fpe
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"userclouds.com/infra/assert"
+)
+
+const digits = "0123456789"
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	bs, err := hex.DecodeString(s)
+	assert.NoErr(t, err)
+	return bs
+}
+
+func TestFF1(t *testing.T) {
+	key := mustDecodeHex(t, "2B7E151628AED2A6ABF7158809CF4F3C")
+
+	// samples 1-3 from the NIST FF1 examples
+	tcs := []struct {
+		alphabet   string
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{digits, "", "0123456789", "2433477484"},
+		{digits, "39383736353433323130", "0123456789", "6124200773"},
+		{"0123456789abcdefghijklmnopqrstuvwxyz", "3737373770717273373737", "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+	}
+
+	for _, tc := range tcs {
+		c, err := NewFF1(key, tc.alphabet)
+		assert.NoErr(t, err)
+
+		ct, err := c.Encrypt(tc.plaintext, mustDecodeHex(t, tc.tweak))
+		assert.NoErr(t, err)
+		assert.Equal(t, ct, tc.ciphertext)
+
+		pt, err := c.Decrypt(ct, mustDecodeHex(t, tc.tweak))
+		assert.NoErr(t, err)
+		assert.Equal(t, pt, tc.plaintext)
+	}
+}
+
+func TestFF3(t *testing.T) {
+	// sample 1 from the NIST FF3 examples, which exercises the FF3 core that FF3-1 is built on
+	f, err := newFF3(mustDecodeHex(t, "EF4359D8D580AA4F7F036D6F04FC6A94"))
+	assert.NoErr(t, err)
+	f.radix = 10
+
+	tweak := mustDecodeHex(t, "D8E7920AFA330A73")
+	toNumerals := func(s string) []int {
+		numerals := make([]int, len(s))
+		for i, r := range s {
+			numerals[i] = int(r - '0')
+		}
+		return numerals
+	}
+
+	ct := f.crypt(toNumerals("890121234567890000"), tweak, false)
+	assert.Equal(t, ct, toNumerals("750918814058654607"))
+	assert.Equal(t, f.crypt(ct, tweak, true), toNumerals("890121234567890000"))
+}
+
+func TestFF31(t *testing.T) {
+	c, err := NewFF31(mustDecodeHex(t, "EF4359D8D580AA4F7F036D6F04FC6A94"), digits)
+	assert.NoErr(t, err)
+
+	tweak := mustDecodeHex(t, "D8E7920AFA330A")
+	ct, err := c.Encrypt("890121234567890000", tweak)
+	assert.NoErr(t, err)
+	assert.NotEqual(t, ct, "890121234567890000")
+	assert.Equal(t, len(ct), len("890121234567890000"))
+
+	pt, err := c.Decrypt(ct, tweak)
+	assert.NoErr(t, err)
+	assert.Equal(t, pt, "890121234567890000")
+
+	// a different tweak gives a different ciphertext
+	other, err := c.Encrypt("890121234567890000", mustDecodeHex(t, "D8E7920AFA330B"))
+	assert.NoErr(t, err)
+	assert.NotEqual(t, other, ct)
+
+	_, err = c.Encrypt("890121234567890000", mustDecodeHex(t, "D8E7920AFA330A73"))
+	assert.NotNil(t, err)
+
+	// inputs longer than 2 * floor(log10(2^96)) digits aren't supported
+	_, err = c.Encrypt("123456789012345678901234567890123456789012345678901234567", tweak)
+	assert.NotNil(t, err)
+}
+
+func TestFormatPreserved(t *testing.T) {
+	c, err := NewFF1(mustDecodeHex(t, "2B7E151628AED2A6ABF7158809CF4F3C"), digits)
+	assert.NoErr(t, err)
+
+	ct, err := c.Encrypt("123-45-6789", nil)
+	assert.NoErr(t, err)
+	assert.Equal(t, len(ct), len("123-45-6789"))
+	assert.Equal(t, ct[3], byte('-'))
+	assert.Equal(t, ct[6], byte('-'))
+	assert.NotEqual(t, ct, "123-45-6789")
+
+	pt, err := c.Decrypt(ct, nil)
+	assert.NoErr(t, err)
+	assert.Equal(t, pt, "123-45-6789")
+
+	// too few characters from the alphabet for the domain to be safe
+	_, err = c.Encrypt("12-34", nil)
+	assert.NotNil(t, err)
+
+	_, err = NewFF1(mustDecodeHex(t, "2B7E151628AED2A6ABF7158809CF4F3C"), "0120")
+	assert.NotNil(t, err)
+	_, err = NewFF1([]byte("short"), digits)
+	assert.NotNil(t, err)
+}
@@ -20,6 +20,18 @@ func MustRandomBase64(n int) string {
 	return base64.StdEncoding.EncodeToString(b)
 }
 
+// MustRandomBase64URL creates a cryptographically secure n-byte, unpadded base64url-encoded string.
+// Suitable for use in URLs and in protocols (like WebAuthn) that use base64url.
+// Will panic if unable to generate a random string.
+func MustRandomBase64URL(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// If this fails it's not likely recoverable.
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
 // MustRandomHex creates a cryptographically secure n-byte, hex-encoded string.
 // Suitable for use as a token, nonce, etc.
 // Note: hex takes 2 bytes to encode 1 source byte, so the string length
@@ -430,6 +430,9 @@ func (c *Client) makeRequestRetry(ctx context.Context,
 		if res.StatusCode >= http.StatusBadRequest {
 			return ucerr.Wrap(Error{StatusCode: res.StatusCode, Body: body, Headers: res.Header})
 		}
+		if options.responseHeadersFunc != nil {
+			options.responseHeadersFunc(res.Header)
+		}
 		return nil
 	})
 }
@@ -40,6 +40,9 @@ type options struct {
 
 	decodeFunc DecodeFunc
 
+	// responseHeadersFunc is invoked with the headers of successful responses
+	responseHeadersFunc func(http.Header)
+
 	// retryNetworkErrors causes the client to retry requests that fail due to network errors,
 	// up to `maxRetries`, with a `backoff` pause each time
 	retryNetworkErrors bool
@@ -178,6 +181,14 @@ func CustomDecoder(f DecodeFunc) Option {
 	})
 }
 
+// ResponseHeaders allows the caller to inspect the headers of a successful (< 400) response,
+// eg. to read a token that the server returns alongside the response body
+func ResponseHeaders(f func(http.Header)) Option {
+	return optFunc(func(opts *options) {
+		opts.responseHeadersFunc = f
+	})
+}
+
 // RetryNetworkErrors sets whether the client retries on underlying network errors
 // TODO: is this a good idea?
 // TODO: should we have a max retry count, backoff, etc config?
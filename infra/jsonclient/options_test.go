@@ -42,3 +42,28 @@ func TestHeaderFuncOption(t *testing.T) {
 	}))
 	assert.NoErr(t, client.Get(ctx, "/", nil))
 }
+
+func TestResponseHeadersOption(t *testing.T) {
+	ctx := context.Background()
+
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Token", "foo")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	var token string
+	client := jsonclient.New(srv.URL)
+	capture := jsonclient.ResponseHeaders(func(h http.Header) {
+		token = h.Get("X-Token")
+	})
+	assert.NoErr(t, client.Get(ctx, "/", nil, capture))
+	assert.Equal(t, token, "foo")
+
+	// headers of error responses aren't passed to the callback
+	token = ""
+	status = http.StatusBadRequest
+	assert.NotNil(t, client.Get(ctx, "/", nil, capture))
+	assert.Equal(t, token, "")
+}
@@ -0,0 +1,211 @@
+// Package ldap wraps github.com/go-ldap/ldap/v3 with the small subset of LDAP we need to authenticate users against
+// (and read users and groups from) a directory like Active Directory: simple bind and search, only ever over TLS.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// used for each operation if the context has no deadline
+const defaultOperationTimeout = 30 * time.Second
+
+// ErrInvalidCredentials is returned by Bind when the DN or password are wrong
+var ErrInvalidCredentials = ucerr.Friendlyf(nil, "invalid LDAP credentials")
+
+// ErrCleartextConnection is returned by Dial for an ldap:// URL without StartTLS, since binds would send passwords in the clear
+var ErrCleartextConnection = ucerr.Friendlyf(nil, "LDAP connections must use ldaps:// or StartTLS")
+
+// Scope is the scope of a search
+type Scope int
+
+// Scope values
+const (
+	ScopeBaseObject   Scope = goldap.ScopeBaseObject
+	ScopeSingleLevel  Scope = goldap.ScopeSingleLevel
+	ScopeWholeSubtree Scope = goldap.ScopeWholeSubtree
+)
+
+// SearchRequest describes a search
+type SearchRequest struct {
+	BaseDN     string
+	Scope      Scope
+	Filter     string
+	Attributes []string
+
+	// SizeLimit is the maximum number of entries to return, 0 for no (client-requested) limit
+	SizeLimit int
+}
+
+// Entry is a single search result
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// GetAttributeValues returns all values for an attribute, matching the attribute name case-insensitively
+func (e Entry) GetAttributeValues(name string) []string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// GetAttributeValue returns the first value of an attribute, or "" if it isn't set
+func (e Entry) GetAttributeValue(name string) string {
+	if vs := e.GetAttributeValues(name); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// NormalizeDN lowercases a DN and removes whitespace around its RDNs, so that equivalent DNs can be compared
+// (it doesn't handle escaped commas or multi-valued RDNs, which are rare in practice)
+func NormalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(parts[i]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// EscapeFilter escapes a value for use in a filter, see https://www.rfc-editor.org/rfc/rfc4515#section-3
+func EscapeFilter(value string) string {
+	return goldap.EscapeFilter(value)
+}
+
+// EscapeFilterBytes escapes every byte of a binary value (eg. an objectGUID) for use in a filter
+func EscapeFilterBytes(value []byte) string {
+	var sb strings.Builder
+	for _, b := range value {
+		fmt.Fprintf(&sb, `\%02x`, b)
+	}
+	return sb.String()
+}
+
+// ValidateFilter returns an error if a filter string can't be parsed
+func ValidateFilter(filter string) error {
+	if _, err := goldap.CompileFilter(filter); err != nil {
+		return ucerr.Friendlyf(err, "invalid LDAP filter '%s'", filter)
+	}
+	return nil
+}
+
+// Conn is a connection to an LDAP server, which is safe for concurrent use
+type Conn struct {
+	conn *goldap.Conn
+}
+
+// Dial connects to the server at an ldaps:// URL, or at an ldap:// URL if startTLS is set, in which case the
+// connection is upgraded with StartTLS before returning. tlsConfig may be nil to use the system roots.
+func Dial(ctx context.Context, rawURL string, tlsConfig *tls.Config, startTLS bool) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	switch u.Scheme {
+	case "ldaps":
+	case "ldap":
+		if !startTLS {
+			return nil, ucerr.Wrap(ErrCleartextConnection)
+		}
+	default:
+		return nil, ucerr.Errorf("unsupported LDAP URL scheme '%s'", u.Scheme)
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if tlsConfig != nil {
+		cfg = tlsConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = u.Hostname()
+	}
+
+	timeout := operationTimeout(ctx)
+	conn, err := goldap.DialURL(rawURL, goldap.DialWithDialer(&net.Dialer{Timeout: timeout}), goldap.DialWithTLSConfig(cfg))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	conn.SetTimeout(timeout)
+
+	if u.Scheme == "ldap" {
+		if err := conn.StartTLS(cfg); err != nil {
+			conn.Close()
+			return nil, ucerr.Errorf("LDAP StartTLS failed: %w", err)
+		}
+	}
+
+	return &Conn{conn: conn}, nil
+}
+
+// operationTimeout returns the time left before the context's deadline, or the default timeout
+func operationTimeout(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return defaultOperationTimeout
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return ucerr.Wrap(c.conn.Close())
+}
+
+// Bind authenticates the connection with a DN (or, for Active Directory, a UPN) and password
+func (c *Conn) Bind(ctx context.Context, dn, password string) error {
+	// a simple bind with an empty password is an "unauthenticated" bind, which many servers (including AD)
+	// will happily accept for any DN, so we never send one (go-ldap refuses to as well)
+	if password == "" {
+		return ucerr.Wrap(ErrInvalidCredentials)
+	}
+
+	c.conn.SetTimeout(operationTimeout(ctx))
+	if err := c.conn.Bind(dn, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return ucerr.Wrap(ErrInvalidCredentials)
+		}
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// Search performs a search and returns all matching entries (search result references are ignored)
+func (c *Conn) Search(ctx context.Context, sr SearchRequest) ([]Entry, error) {
+	c.conn.SetTimeout(operationTimeout(ctx))
+	res, err := c.conn.Search(goldap.NewSearchRequest(
+		sr.BaseDN,
+		int(sr.Scope),
+		goldap.NeverDerefAliases,
+		sr.SizeLimit,
+		0, // no time limit beyond our own timeout
+		false,
+		sr.Filter,
+		sr.Attributes,
+		nil,
+	))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	entries := make([]Entry, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		entry := Entry{DN: e.DN, Attributes: map[string][]string{}}
+		for _, a := range e.Attributes {
+			entry.Attributes[a.Name] = a.Values
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
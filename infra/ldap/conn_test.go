@@ -0,0 +1,123 @@
+package ldap_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/ldap"
+	"userclouds.com/infra/ldap/ldaptest"
+)
+
+func TestFilters(t *testing.T) {
+	valid := []string{
+		"(uid=alice)",
+		"(objectClass=*)",
+		"(&(objectClass=person)(|(uid=alice)(mail=alice@*)))",
+		"(!(userAccountControl=514))",
+		"(cn=*ali*ce*)",
+		`(cn=a\2ab\28c\29)`,
+	}
+	for _, f := range valid {
+		assert.NoErr(t, ldap.ValidateFilter(f), assert.Errorf("filter %s", f))
+	}
+
+	invalid := []string{
+		"",
+		"uid=alice",
+		"(uid=alice",
+		`(cn=\2)`,
+		`(cn=\zz)`,
+	}
+	for _, f := range invalid {
+		assert.NotNil(t, ldap.ValidateFilter(f), assert.Errorf("filter %s", f))
+	}
+
+	assert.Equal(t, ldap.EscapeFilter(`*)(uid=*`), `\2a\29\28uid=\2a`)
+	assert.Equal(t, ldap.EscapeFilter(`a\b`), `a\5cb`)
+	assert.Equal(t, ldap.EscapeFilterBytes([]byte{0x00, 0x2a, 0xff}), `\00\2a\ff`)
+}
+
+func TestBindAndSearch(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := ldaptest.NewServer()
+	assert.NoErr(t, err)
+	defer s.Close()
+
+	s.AddEntry("cn=svc,dc=example,dc=com", "svcpw", map[string][]string{"cn": {"svc"}})
+	s.AddEntry("ou=people,dc=example,dc=com", "", map[string][]string{"ou": {"people"}})
+	s.AddEntry("uid=alice,ou=people,dc=example,dc=com", "alicepw", map[string][]string{
+		"objectClass": {"person"},
+		"uid":         {"alice"},
+		"mail":        {"alice@example.com"},
+		"memberOf":    {"cn=admins,dc=example,dc=com", "cn=staff,dc=example,dc=com"},
+	})
+	s.AddEntry("uid=bob,ou=people,dc=example,dc=com", "bobpw", map[string][]string{
+		"objectClass": {"person"},
+		"uid":         {"bob"},
+	})
+
+	rootCAs := x509.NewCertPool()
+	assert.True(t, rootCAs.AppendCertsFromPEM([]byte(s.RootCAs())))
+	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+
+	// we never send credentials in the clear
+	_, err = ldap.Dial(ctx, s.URL(), tlsConfig, false)
+	assert.ErrorIs(t, err, ldap.ErrCleartextConnection)
+
+	// nor to a server we don't trust
+	_, err = ldap.Dial(ctx, s.URL(), nil, true)
+	assert.NotNil(t, err)
+
+	conn, err := ldap.Dial(ctx, s.URL(), tlsConfig, true)
+	assert.NoErr(t, err)
+	defer conn.Close()
+
+	// searching requires a bind
+	_, err = conn.Search(ctx, ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(uid=alice)"})
+	assert.True(t, goldap.IsErrorWithCode(err, goldap.LDAPResultInsufficientAccessRights))
+
+	err = conn.Bind(ctx, "cn=svc,dc=example,dc=com", "wrong")
+	assert.ErrorIs(t, err, ldap.ErrInvalidCredentials)
+
+	// we never send unauthenticated binds
+	err = conn.Bind(ctx, "cn=svc,dc=example,dc=com", "")
+	assert.ErrorIs(t, err, ldap.ErrInvalidCredentials)
+	assert.Equal(t, len(s.Binds()), 0)
+
+	assert.NoErr(t, conn.Bind(ctx, "cn=svc,dc=example,dc=com", "svcpw"))
+
+	entries, err := conn.Search(ctx, ldap.SearchRequest{
+		BaseDN:     "dc=example,dc=com",
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     "(&(objectClass=person)(uid=alice))",
+		Attributes: []string{"mail", "memberOf"},
+	})
+	assert.NoErr(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].DN, "uid=alice,ou=people,dc=example,dc=com")
+	assert.Equal(t, entries[0].GetAttributeValue("MAIL"), "alice@example.com")
+	assert.Equal(t, len(entries[0].GetAttributeValues("memberof")), 2)
+	assert.Equal(t, entries[0].GetAttributeValue("uid"), "")
+
+	// an escaped value matches the literal, not a wildcard
+	entries, err = conn.Search(ctx, ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(uid=" + ldap.EscapeFilter("*") + ")"})
+	assert.NoErr(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	entries, err = conn.Search(ctx, ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeSingleLevel, Filter: "(objectClass=person)"})
+	assert.NoErr(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	_, err = conn.Search(ctx, ldap.SearchRequest{BaseDN: "ou=people,dc=example,dc=com", Scope: ldap.ScopeSingleLevel, Filter: "(objectClass=person)", SizeLimit: 1})
+	assert.True(t, goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded))
+
+	// rebinding as a user works on the same connection
+	assert.NoErr(t, conn.Bind(ctx, "uid=bob,ou=people,dc=example,dc=com", "bobpw"))
+	assert.Equal(t, s.Binds(), []string{"cn=svc,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"})
+}
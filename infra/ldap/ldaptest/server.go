@@ -0,0 +1,409 @@
+// Package ldaptest provides an in-process LDAP server for tests
+package ldaptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"userclouds.com/infra/ldap"
+	"userclouds.com/infra/ucerr"
+)
+
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// Server is an in-process LDAP server for tests. It supports StartTLS, simple bind and search (with
+// and, or, not, equality, substring and presence filters) on an ldap:// URL, and like most real
+// directories it requires TLS before binding and an authenticated bind before searching.
+type Server struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	rootCAs   string
+	wg        sync.WaitGroup
+
+	mu        sync.Mutex
+	entries   []ldap.Entry
+	passwords map[string]string
+	conns     map[net.Conn]bool
+	binds     []string
+}
+
+// NewServer starts a server listening on a random local port, with a self-signed certificate for StartTLS
+func NewServer() (*Server, error) {
+	cert, rootCAs, err := selfSignedCertificate()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	s := &Server{
+		listener:  l,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		rootCAs:   rootCAs,
+		passwords: map[string]string{},
+		conns:     map[net.Conn]bool{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func selfSignedCertificate() (tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", ucerr.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldaptest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, "", ucerr.Wrap(err)
+	}
+
+	pemCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pemCert, nil
+}
+
+// URL returns the ldap:// URL of the server, which requires StartTLS
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// RootCAs returns the PEM certificate clients must trust to connect to the server
+func (s *Server) RootCAs() string {
+	return s.rootCAs
+}
+
+// AddEntry adds an entry to the directory; if password is non-empty, the entry can be bound as
+func (s *Server) AddEntry(dn, password string, attributes map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, ldap.Entry{DN: dn, Attributes: attributes})
+	if password != "" {
+		s.passwords[ldap.NormalizeDN(dn)] = password
+	}
+}
+
+// SetPassword changes the password of an existing entry
+func (s *Server) SetPassword(dn, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passwords[ldap.NormalizeDN(dn)] = password
+}
+
+// Binds returns the DNs of all successful binds so far, in order
+func (s *Server) Binds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.binds...)
+}
+
+// Close stops the server and closes any open connections
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[c] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handleConn(c)
+	}
+}
+
+func (s *Server) handleConn(raw net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, raw)
+		s.mu.Unlock()
+		raw.Close()
+	}()
+
+	// ber.ReadPacket doesn't read past the end of each packet, so we can switch to TLS between packets
+	c := raw
+	secure := false
+	bound := false
+	for {
+		msg, err := ber.ReadPacket(c)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+		id, ok := msg.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+		op := msg.Children[1]
+		if op.ClassType != ber.ClassApplication {
+			return
+		}
+
+		var responses []*ber.Packet
+		startTLS := false
+		switch op.Tag {
+		case goldap.ApplicationExtendedRequest:
+			code := uint16(goldap.LDAPResultProtocolError)
+			if len(op.Children) > 0 && str(op.Children[0]) == startTLSOID && !secure {
+				code, startTLS = goldap.LDAPResultSuccess, true
+			}
+			responses = append(responses, newResult(goldap.ApplicationExtendedResponse, code))
+		case goldap.ApplicationBindRequest:
+			code := uint16(goldap.LDAPResultConfidentialityRequired)
+			if secure {
+				code, bound = s.bind(op)
+			}
+			responses = append(responses, newResult(goldap.ApplicationBindResponse, code))
+		case goldap.ApplicationUnbindRequest:
+			return
+		case goldap.ApplicationSearchRequest:
+			if !bound {
+				responses = append(responses, newResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultInsufficientAccessRights))
+				break
+			}
+			responses = append(responses, s.search(op)...)
+		default:
+			return
+		}
+
+		for _, resp := range responses {
+			envelope := ber.NewSequence("LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+			envelope.AppendChild(resp)
+			if _, err := c.Write(envelope.Bytes()); err != nil {
+				return
+			}
+		}
+
+		if startTLS {
+			tc := tls.Server(raw, s.tlsConfig)
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			c = tc
+			secure = true
+		}
+	}
+}
+
+func newResult(op ber.Tag, code uint16) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return p
+}
+
+func (s *Server) bind(op *ber.Packet) (uint16, bool) {
+	if len(op.Children) < 3 {
+		return goldap.LDAPResultUnwillingToPerform, false
+	}
+	dn := str(op.Children[1])
+	password := str(op.Children[2])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expected, ok := s.passwords[ldap.NormalizeDN(dn)]; !ok || password == "" || password != expected {
+		return goldap.LDAPResultInvalidCredentials, false
+	}
+	s.binds = append(s.binds, dn)
+	return goldap.LDAPResultSuccess, true
+}
+
+func (s *Server) search(op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return []*ber.Packet{newResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultUnwillingToPerform)}
+	}
+	base := ldap.NormalizeDN(str(op.Children[0]))
+	scope, _ := op.Children[1].Value.(int64)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attrs []string
+	for _, a := range op.Children[7].Children {
+		attrs = append(attrs, str(a))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var responses []*ber.Packet
+	for _, e := range s.entries {
+		dn := ldap.NormalizeDN(e.DN)
+		switch scope {
+		case goldap.ScopeBaseObject:
+			if dn != base {
+				continue
+			}
+		case goldap.ScopeSingleLevel:
+			if _, parent, ok := strings.Cut(dn, ","); !ok || parent != base {
+				continue
+			}
+		default:
+			if dn != base && !strings.HasSuffix(dn, ","+base) {
+				continue
+			}
+		}
+
+		if !matchFilter(filter, e) {
+			continue
+		}
+
+		if sizeLimit > 0 && int64(len(responses)) == sizeLimit {
+			return append(responses, newResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultSizeLimitExceeded))
+		}
+
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+		entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "DN"))
+		attrsp := ber.NewSequence("Attributes")
+		for name, values := range e.Attributes {
+			if len(attrs) > 0 && !containsFold(attrs, name) {
+				continue
+			}
+			attr := ber.NewSequence("Attribute")
+			attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+			vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+			for _, v := range values {
+				vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "Value"))
+			}
+			attr.AppendChild(vals)
+			attrsp.AppendChild(attr)
+		}
+		entry.AppendChild(attrsp)
+		responses = append(responses, entry)
+	}
+
+	return append(responses, newResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess))
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFilter evaluates a BER-encoded filter against an entry, comparing values case-insensitively
+func matchFilter(f *ber.Packet, e ldap.Entry) bool {
+	if f.ClassType != ber.ClassContext {
+		return false
+	}
+
+	switch f.Tag {
+	case goldap.FilterAnd:
+		for _, c := range f.Children {
+			if !matchFilter(c, e) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, c := range f.Children {
+			if matchFilter(c, e) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return len(f.Children) == 1 && !matchFilter(f.Children[0], e)
+	case goldap.FilterPresent:
+		return len(e.GetAttributeValues(str(f))) > 0
+	case goldap.FilterEqualityMatch:
+		if len(f.Children) != 2 {
+			return false
+		}
+		value := str(f.Children[1])
+		for _, v := range e.GetAttributeValues(str(f.Children[0])) {
+			// normalizing handles DN-valued attributes (eg. member) too
+			if ldap.NormalizeDN(v) == ldap.NormalizeDN(value) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterSubstrings:
+		if len(f.Children) != 2 {
+			return false
+		}
+		for _, v := range e.GetAttributeValues(str(f.Children[0])) {
+			if matchSubstrings(f.Children[1].Children, strings.ToLower(v)) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func matchSubstrings(parts []*ber.Packet, v string) bool {
+	for _, p := range parts {
+		sub := strings.ToLower(str(p))
+		switch p.Tag {
+		case goldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(v, sub) {
+				return false
+			}
+			v = v[len(sub):]
+		case goldap.FilterSubstringsAny:
+			i := strings.Index(v, sub)
+			if i < 0 {
+				return false
+			}
+			v = v[i+len(sub):]
+		case goldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(v, sub) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// str returns the contents of a primitive packet, which ber only decodes into Value for universal types
+func str(p *ber.Packet) string {
+	return p.Data.String()
+}
@@ -2,6 +2,7 @@ package oidc
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strings"
@@ -177,12 +178,32 @@ func (authr *Authenticator) userInfoProfileForToken(ctx context.Context, token *
 // It extracts/gets/returns the raw ID token, access token, and user profile and returns
 // an appropriate HTTP status code.
 func (authr *Authenticator) ProcessAuthCodeCallback(r *http.Request, state string) (*TokenInfo, int, error) {
+	return authr.processAuthCodeCallback(r, state, "")
+}
+
+// ProcessAuthCodeCallbackWithPKCE is like ProcessAuthCodeCallback, but also sends the PKCE code
+// verifier when exchanging the code, and checks that the ID token contains the nonce sent in the
+// authorization request (see AuthCodeURLWithPKCE).
+func (authr *Authenticator) ProcessAuthCodeCallbackWithPKCE(r *http.Request, state string, nonce string, codeVerifier string) (*TokenInfo, int, error) {
+	if state == "" || nonce == "" || codeVerifier == "" {
+		return nil, http.StatusBadRequest, ucerr.New("state, nonce and code verifier are required")
+	}
+	return authr.processAuthCodeCallback(r, state, nonce, oauth2.VerifierOption(codeVerifier))
+}
+
+// AuthCodeURLWithPKCE returns the authorization URL to redirect the user to, with a nonce and a
+// PKCE code challenge for the code verifier
+func (authr *Authenticator) AuthCodeURLWithPKCE(state string, nonce string, codeVerifier string) string {
+	return authr.Config.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
+}
+
+func (authr *Authenticator) processAuthCodeCallback(r *http.Request, state string, nonce string, opts ...oauth2.AuthCodeOption) (*TokenInfo, int, error) {
 	ctx := r.Context()
-	if r.URL.Query().Get("state") != state {
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(state)) != 1 {
 		return nil, http.StatusBadRequest, ucerr.New("invalid state parameter value")
 	}
 
-	token, err := authr.Config.Exchange(ctx, r.URL.Query().Get("code"))
+	token, err := authr.Config.Exchange(ctx, r.URL.Query().Get("code"), opts...)
 	if err != nil {
 		return nil, http.StatusUnauthorized, ucerr.Wrap(err)
 	}
@@ -205,6 +226,11 @@ func (authr *Authenticator) ProcessAuthCodeCallback(r *http.Request, state strin
 		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
 	}
 
+	// the nonce ties the ID token to the authorization request we made, so it can't be replayed
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(verifiedToken.Nonce), []byte(nonce)) != 1 {
+		return nil, http.StatusUnauthorized, ucerr.New("ID token nonce doesn't match")
+	}
+
 	userInfoProfile, err := authr.userInfoProfileForToken(ctx, token)
 	if err != nil {
 		return nil, http.StatusInternalServerError, ucerr.Wrap(err)
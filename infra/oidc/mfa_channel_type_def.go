@@ -13,4 +13,5 @@ const (
 	MFAAuth0EmailChannel         MFAChannelType = "auth0_email"
 	MFAAuth0SMSChannel           MFAChannelType = "auth0_sms"
 	MFARecoveryCodeChannel       MFAChannelType = "recovery_code"
+	MFAWebAuthnChannel           MFAChannelType = "webauthn"
 )
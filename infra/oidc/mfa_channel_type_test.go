@@ -15,7 +15,38 @@ func TestChannelTypeValidity(t *testing.T) {
 	assert.NoErr(t, oidc.MFAAuth0EmailChannel.Validate())
 	assert.NoErr(t, oidc.MFAAuth0SMSChannel.Validate())
 	assert.NoErr(t, oidc.MFARecoveryCodeChannel.Validate())
+	assert.NoErr(t, oidc.MFAWebAuthnChannel.Validate())
 	assert.NotNil(t, oidc.MFAInvalidChannel.Validate())
 	badChannelType := oidc.MFAChannelType("foo")
 	assert.NotNil(t, badChannelType.Validate())
 }
+
+func TestWebAuthnChannel(t *testing.T) {
+	channels := oidc.NewMFAChannels()
+
+	channelTypeID, err := oidc.NewWebAuthnChannelTypeID([]byte("user handle"), "me@example.com")
+	assert.NoErr(t, err)
+
+	pending, err := channels.AddChannel(oidc.MFAWebAuthnChannel, channelTypeID, oidc.DefaultWebAuthnChannelName, false)
+	assert.NoErr(t, err)
+
+	cred, err := pending.GetWebAuthnCredential()
+	assert.NoErr(t, err)
+	assert.False(t, cred.IsRegistered())
+	assert.Equal(t, cred.UserName, "me@example.com")
+
+	// starting another registration replaces the pending one
+	replaced, err := channels.AddChannel(oidc.MFAWebAuthnChannel, channelTypeID, "My Key", false)
+	assert.NoErr(t, err)
+	assert.Equal(t, replaced.ID, pending.ID)
+	assert.Equal(t, len(channels.Channels), 1)
+
+	// a pending channel can't be verified
+	_, err = channels.AddChannel(oidc.MFAWebAuthnChannel, channelTypeID, "My Key", true)
+	assert.NotNil(t, err)
+
+	// non-WebAuthn channels don't have credentials
+	email := oidc.NewMFAChannel(oidc.MFAEmailChannel, "me@example.com", "me@example.com")
+	_, err = email.GetWebAuthnCredential()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,101 @@
+package oidc
+
+import (
+	"fmt"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/webauthn"
+)
+
+// webauthnPendingID is used as the unique ID for a channel that has not completed registration,
+// so that a user can only have one pending WebAuthn registration at a time
+const webauthnPendingID = "pending"
+
+// DefaultWebAuthnChannelName is used when a user does not name their security key or passkey
+const DefaultWebAuthnChannelName = "Security Key"
+
+// NewWebAuthnChannelTypeID returns the channel type ID for a WebAuthn credential that has not yet been
+// registered with an authenticator
+func NewWebAuthnChannelTypeID(userHandle []byte, userName string) (string, error) {
+	channelTypeID, err := webauthn.NewPendingCredential(userHandle, userName).Encode()
+	return channelTypeID, ucerr.Wrap(err)
+}
+
+// GetWebAuthnCredential returns the WebAuthn credential stored for a WebAuthn channel
+func (mfac MFAChannel) GetWebAuthnCredential() (*webauthn.Credential, error) {
+	if mfac.ChannelType != MFAWebAuthnChannel {
+		return nil, ucerr.Errorf("channel '%v' is not a WebAuthn channel", mfac.ID)
+	}
+
+	cred, err := webauthn.DecodeCredential(mfac.ChannelTypeID)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return cred, nil
+}
+
+type mfaWebAuthnChannel struct{}
+
+func (mfaWebAuthnChannel) canConfigure() bool {
+	return true
+}
+
+func (mfaWebAuthnChannel) canReissueChallenge() bool {
+	return true
+}
+
+func (mfaWebAuthnChannel) getAuditLogType() string {
+	return "UC WebAuthn MFA"
+}
+
+func (c mfaWebAuthnChannel) getChallengeDescription(mfac MFAChannel, shouldMask bool, firstChallenge bool) string {
+	if !mfac.Verified {
+		return fmt.Sprintf("Register %s", c.getChannelDescription(mfac, shouldMask))
+	}
+
+	return fmt.Sprintf("Use %s", c.getChannelDescription(mfac, shouldMask))
+}
+
+func (mfaWebAuthnChannel) getChannelDescription(mfac MFAChannel, shouldMask bool) string {
+	return mfac.ChannelName
+}
+
+func (mfaWebAuthnChannel) getRegistrationInfo(MFAChannel) (string, string, bool) {
+	return "", "", false
+}
+
+func (mfaWebAuthnChannel) getUniqueID(mfac MFAChannel) string {
+	cred, err := webauthn.DecodeCredential(mfac.ChannelTypeID)
+	if err != nil || !cred.IsRegistered() {
+		return fmt.Sprintf("%v:%s", MFAWebAuthnChannel, webauthnPendingID)
+	}
+
+	return fmt.Sprintf("%v:%s", MFAWebAuthnChannel, cred.EncodedID())
+}
+
+func (mfaWebAuthnChannel) getUserDetailDescription(mfac MFAChannel) string {
+	cred, err := webauthn.DecodeCredential(mfac.ChannelTypeID)
+	if err != nil || !cred.IsRegistered() {
+		return fmt.Sprintf("%s (pending registration)", mfac.ChannelName)
+	}
+
+	return fmt.Sprintf("%s: %s", mfac.ChannelName, cred.EncodedID())
+}
+
+func (mfaWebAuthnChannel) validateChannel(mfac *MFAChannel) error {
+	cred, err := webauthn.DecodeCredential(mfac.ChannelTypeID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if mfac.Verified && !cred.IsRegistered() {
+		return ucerr.New("verified WebAuthn channel must have a registered credential")
+	}
+
+	return nil
+}
+
+func init() {
+	mfaChannelTypes[MFAWebAuthnChannel] = mfaWebAuthnChannel{}
+}
@@ -60,6 +60,10 @@ type UCTokenClaims struct {
 	SubjectType     string   `json:"subject_type,omitempty" yaml:"subject_type,omitempty"`
 	OrganizationID  string   `json:"organization_id,omitempty" yaml:"organization_id,omitempty"`
 	ImpersonatedBy  string   `json:"impersonated_by,omitempty" yaml:"impersonated_by,omitempty"`
+
+	// Scope and Actor are only set on tokens issued via token exchange (RFC 8693)
+	Scope string      `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Actor *ActorClaim `json:"act,omitempty" yaml:"act,omitempty"`
 }
 
 // private class needed for json unmarshal
@@ -77,6 +81,10 @@ type tokenclaims struct {
 	SubjectType     string   `json:"subject_type,omitempty" yaml:"subject_type,omitempty"`
 	OrganizationID  string   `json:"organization_id,omitempty" yaml:"organization_id,omitempty"`
 	ImpersonatedBy  string   `json:"impersonated_by,omitempty" yaml:"impersonated_by,omitempty"`
+
+	// Scope and Actor are only set on tokens issued via token exchange (RFC 8693)
+	Scope string      `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Actor *ActorClaim `json:"act,omitempty" yaml:"act,omitempty"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler, we need this to handle the audience field being either an array or a string
@@ -116,9 +124,19 @@ func (t *UCTokenClaims) UnmarshalJSON(b []byte) error {
 	t.SubjectType = tc.SubjectType
 	t.OrganizationID = tc.OrganizationID
 	t.ImpersonatedBy = tc.ImpersonatedBy
+	t.Scope = tc.Scope
+	t.Actor = tc.Actor
 	return nil
 }
 
+// ActorClaim identifies the party acting on behalf of a token's subject. A nested Actor identifies
+// the party that was previously acting on the subject's behalf, so that the whole delegation chain
+// is recorded with the current actor outermost. See https://www.rfc-editor.org/rfc/rfc8693#section-4.1
+type ActorClaim struct {
+	Subject string      `json:"sub" yaml:"sub"`
+	Actor   *ActorClaim `json:"act,omitempty" yaml:"act,omitempty"`
+}
+
 // TokenResponse is an OIDC-compliant response from a token endpoint.
 // (either token exchange or resource owner password credential flow).
 // See https://datatracker.ietf.org/doc/html/rfc6749#section-5.1.
@@ -130,6 +148,41 @@ type TokenResponse struct {
 	ExpiresIn    int    `json:"expires_in,omitempty" yaml:"expires_in,omitempty"`
 	IDToken      string `json:"id_token,omitempty" yaml:"id_token,omitempty"`
 
+	// IssuedTokenType and Scope are returned from token exchange requests (RFC 8693)
+	IssuedTokenType string `json:"issued_token_type,omitempty" yaml:"issued_token_type,omitempty"`
+	Scope           string `json:"scope,omitempty" yaml:"scope,omitempty"`
+
 	ErrorType string `json:"error,omitempty" yaml:"error,omitempty"`
 	ErrorDesc string `json:"error_description,omitempty" yaml:"error_description,omitempty"`
 }
+
+// IntrospectionResponse is the response from an OAuth 2.0 token introspection endpoint.
+// Everything but Active is omitted for inactive tokens.
+// See https://www.rfc-editor.org/rfc/rfc7662#section-2.2
+type IntrospectionResponse struct {
+	Active    bool     `json:"active" yaml:"active"`
+	Scope     string   `json:"scope,omitempty" yaml:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	TokenType string   `json:"token_type,omitempty" yaml:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty" yaml:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty" yaml:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty" yaml:"nbf,omitempty"`
+	Subject   string   `json:"sub,omitempty" yaml:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty" yaml:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty" yaml:"iss,omitempty"`
+	JWTID     string   `json:"jti,omitempty" yaml:"jti,omitempty"`
+
+	// Actor is set for tokens issued via token exchange (RFC 8693)
+	Actor *ActorClaim `json:"act,omitempty" yaml:"act,omitempty"`
+}
+
+// DeviceAuthorizationResponse is the response from an OAuth 2.0 device authorization endpoint
+// See https://www.rfc-editor.org/rfc/rfc8628#section-3.2
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code" yaml:"device_code"`
+	UserCode                string `json:"user_code" yaml:"user_code"`
+	VerificationURI         string `json:"verification_uri" yaml:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty" yaml:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in" yaml:"expires_in"`
+	Interval                int    `json:"interval,omitempty" yaml:"interval,omitempty"`
+}
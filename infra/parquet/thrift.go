@@ -0,0 +1,91 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// thrift compact protocol type IDs
+const (
+	compactI32    byte = 5
+	compactI64    byte = 6
+	compactBinary byte = 8
+	compactList   byte = 9
+	compactStruct byte = 12
+)
+
+// compactWriter is just enough of the thrift compact protocol to encode parquet page headers and file metadata.
+// Fields must be written in increasing ID order within each struct.
+type compactWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16
+	lastID  int16
+}
+
+func (cw *compactWriter) fieldHeader(id int16, fieldType byte) {
+	if delta := id - cw.lastID; delta > 0 && delta <= 15 {
+		cw.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		cw.buf.WriteByte(fieldType)
+		cw.varint(zigzag(int64(id)))
+	}
+	cw.lastID = id
+}
+
+func (cw *compactWriter) varint(v uint64) {
+	cw.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (cw *compactWriter) i32Field(id int16, v int32) {
+	cw.fieldHeader(id, compactI32)
+	cw.varint(zigzag(int64(v)))
+}
+
+func (cw *compactWriter) i64Field(id int16, v int64) {
+	cw.fieldHeader(id, compactI64)
+	cw.varint(zigzag(v))
+}
+
+func (cw *compactWriter) stringField(id int16, s string) {
+	cw.fieldHeader(id, compactBinary)
+	cw.binary(s)
+}
+
+func (cw *compactWriter) binary(s string) {
+	cw.varint(uint64(len(s)))
+	cw.buf.WriteString(s)
+}
+
+func (cw *compactWriter) listField(id int16, elemType byte, size int) {
+	cw.fieldHeader(id, compactList)
+	if size < 15 {
+		cw.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		cw.buf.WriteByte(0xF0 | elemType)
+		cw.varint(uint64(size))
+	}
+}
+
+func (cw *compactWriter) structField(id int16) {
+	cw.fieldHeader(id, compactStruct)
+	cw.beginStruct()
+}
+
+// beginStruct starts a nested struct, either as a field or a list element
+func (cw *compactWriter) beginStruct() {
+	cw.lastIDs = append(cw.lastIDs, cw.lastID)
+	cw.lastID = 0
+}
+
+// endStruct writes the stop field for the current struct
+func (cw *compactWriter) endStruct() {
+	cw.buf.WriteByte(0)
+	if n := len(cw.lastIDs); n > 0 {
+		cw.lastID = cw.lastIDs[n-1]
+		cw.lastIDs = cw.lastIDs[:n-1]
+	}
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
@@ -0,0 +1,215 @@
+// Package parquet writes Apache Parquet files with a flat schema of optional UTF-8 string columns.
+// It deliberately supports only what's needed to stream tabular exports: uncompressed, PLAIN encoded
+// data pages, one page per column per row group.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const magic = "PAR1"
+
+// parquet enum values used by the writer
+const (
+	typeByteArray      int32 = 6
+	repetitionOptional int32 = 1
+	convertedTypeUTF8  int32 = 0
+	encodingPlain      int32 = 0
+	encodingRLE        int32 = 3
+	codecUncompressed  int32 = 0
+	pageTypeData       int32 = 0
+	maxDefinitionLevel       = 1
+)
+
+// Writer writes rows of optional string values to a parquet file, one row group per WriteRowGroup call
+type Writer struct {
+	w         io.Writer
+	offset    int64
+	columns   []string
+	rowGroups []rowGroup
+	numRows   int64
+	closed    bool
+}
+
+type rowGroup struct {
+	numRows       int64
+	totalByteSize int64
+	columnChunks  []columnChunk
+}
+
+type columnChunk struct {
+	numValues      int64
+	dataPageOffset int64
+	size           int64
+}
+
+// NewWriter returns a Writer for a file with the given column names
+func NewWriter(w io.Writer, columns []string) *Writer {
+	return &Writer{w: w, columns: columns}
+}
+
+func (pw *Writer) write(bs []byte) error {
+	n, err := pw.w.Write(bs)
+	pw.offset += int64(n)
+	return ucerr.Wrap(err)
+}
+
+// writeHeader writes the leading magic number before the first row group or footer
+func (pw *Writer) writeHeader() error {
+	if pw.offset > 0 {
+		return nil
+	}
+	return ucerr.Wrap(pw.write([]byte(magic)))
+}
+
+// WriteRowGroup writes the rows as a row group; each row must have one value per column, where nil is null
+func (pw *Writer) WriteRowGroup(rows [][]*string) error {
+	if pw.closed {
+		return ucerr.New("parquet writer is closed")
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := pw.writeHeader(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	rg := rowGroup{numRows: int64(len(rows))}
+	for i := range pw.columns {
+		definitionLevels := make([]int, len(rows))
+		var values bytes.Buffer
+		for j, row := range rows {
+			if len(row) != len(pw.columns) {
+				return ucerr.Errorf("row %d has %d values but there are %d columns", j, len(row), len(pw.columns))
+			}
+			if row[i] == nil {
+				continue
+			}
+			definitionLevels[j] = maxDefinitionLevel
+			values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(*row[i]))))
+			values.WriteString(*row[i])
+		}
+
+		levels := encodeLevels(definitionLevels)
+		var page bytes.Buffer
+		page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+		page.Write(levels)
+		page.Write(values.Bytes())
+
+		var header compactWriter
+		header.i32Field(1, pageTypeData)
+		header.i32Field(2, int32(page.Len()))
+		header.i32Field(3, int32(page.Len()))
+		header.structField(5)
+		header.i32Field(1, int32(len(rows)))
+		header.i32Field(2, encodingPlain)
+		header.i32Field(3, encodingRLE)
+		header.i32Field(4, encodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		cc := columnChunk{
+			numValues:      int64(len(rows)),
+			dataPageOffset: pw.offset,
+			size:           int64(header.buf.Len() + page.Len()),
+		}
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return ucerr.Wrap(err)
+		}
+		if err := pw.write(page.Bytes()); err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		rg.columnChunks = append(rg.columnChunks, cc)
+		rg.totalByteSize += cc.size
+	}
+
+	pw.rowGroups = append(pw.rowGroups, rg)
+	pw.numRows += rg.numRows
+	return nil
+}
+
+// Close writes the file footer; it does not close the underlying writer
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	if err := pw.writeHeader(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	var md compactWriter
+	md.i32Field(1, 1)
+
+	md.listField(2, compactStruct, len(pw.columns)+1)
+	md.beginStruct()
+	md.stringField(4, "schema")
+	md.i32Field(5, int32(len(pw.columns)))
+	md.endStruct()
+	for _, c := range pw.columns {
+		md.beginStruct()
+		md.i32Field(1, typeByteArray)
+		md.i32Field(3, repetitionOptional)
+		md.stringField(4, c)
+		md.i32Field(6, convertedTypeUTF8)
+		md.endStruct()
+	}
+
+	md.i64Field(3, pw.numRows)
+
+	md.listField(4, compactStruct, len(pw.rowGroups))
+	for _, rg := range pw.rowGroups {
+		md.beginStruct()
+		md.listField(1, compactStruct, len(rg.columnChunks))
+		for i, cc := range rg.columnChunks {
+			md.beginStruct()
+			md.i64Field(2, cc.dataPageOffset)
+			md.structField(3)
+			md.i32Field(1, typeByteArray)
+			md.listField(2, compactI32, 2)
+			md.varint(zigzag(int64(encodingPlain)))
+			md.varint(zigzag(int64(encodingRLE)))
+			md.listField(3, compactBinary, 1)
+			md.binary(pw.columns[i])
+			md.i32Field(4, codecUncompressed)
+			md.i64Field(5, cc.numValues)
+			md.i64Field(6, cc.size)
+			md.i64Field(7, cc.size)
+			md.i64Field(9, cc.dataPageOffset)
+			md.endStruct()
+			md.endStruct()
+		}
+		md.i64Field(2, rg.totalByteSize)
+		md.i64Field(3, rg.numRows)
+		md.endStruct()
+	}
+
+	md.stringField(6, "userclouds")
+	md.endStruct()
+
+	footer := md.buf.Bytes()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	return ucerr.Wrap(pw.write(footer))
+}
+
+// encodeLevels encodes definition levels with the RLE/bit-packed hybrid encoding, using only RLE runs
+func encodeLevels(levels []int) []byte {
+	var encoded []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		encoded = binary.AppendUvarint(encoded, uint64(j-i)<<1)
+		// a bit width of 1 fits the run value in a single byte
+		encoded = append(encoded, byte(levels[i]))
+		i = j
+	}
+	return encoded
+}
@@ -0,0 +1,157 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"userclouds.com/infra/assert"
+)
+
+// compactReader decodes thrift compact structs generically, so the test can check the file independently of the writer
+type compactReader struct {
+	t  *testing.T
+	bs []byte
+}
+
+func (cr *compactReader) byte() byte {
+	b := cr.bs[0]
+	cr.bs = cr.bs[1:]
+	return b
+}
+
+func (cr *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(cr.bs)
+	assert.True(cr.t, n > 0)
+	cr.bs = cr.bs[n:]
+	return v
+}
+
+func (cr *compactReader) varint() int64 {
+	v := cr.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (cr *compactReader) value(fieldType byte) any {
+	switch fieldType {
+	case compactI32, compactI64:
+		return cr.varint()
+	case compactBinary:
+		n := int(cr.uvarint())
+		s := string(cr.bs[:n])
+		cr.bs = cr.bs[n:]
+		return s
+	case compactList:
+		header := cr.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(cr.uvarint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = cr.value(header & 0x0F)
+		}
+		return list
+	case compactStruct:
+		return cr.structValue()
+	}
+	cr.t.Fatalf("unexpected compact type %d", fieldType)
+	return nil
+}
+
+func (cr *compactReader) structValue() map[int16]any {
+	fields := map[int16]any{}
+	var id int16
+	for {
+		header := cr.byte()
+		if header == 0 {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(cr.varint())
+		}
+		fields[id] = cr.value(header & 0x0F)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	var buf bytes.Buffer
+	pw := NewWriter(&buf, []string{"name", "email"})
+	assert.NoErr(t, pw.WriteRowGroup([][]*string{
+		{str("alice"), str("alice@example.com")},
+		{str("bob"), nil},
+	}))
+	assert.NoErr(t, pw.WriteRowGroup([][]*string{
+		{nil, str("carol@example.com")},
+	}))
+	assert.NotNil(t, pw.WriteRowGroup([][]*string{{str("too few values")}}))
+	assert.NoErr(t, pw.Close())
+
+	file := buf.Bytes()
+	assert.Equal(t, string(file[:4]), magic)
+	assert.Equal(t, string(file[len(file)-4:]), magic)
+
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := &compactReader{t: t, bs: file[len(file)-8-footerLen : len(file)-8]}
+	md := footer.structValue()
+	assert.Equal(t, len(footer.bs), 0)
+	assert.Equal(t, md[3], int64(3))
+
+	schema := md[2].([]any)
+	assert.Equal(t, len(schema), 3)
+	assert.Equal(t, schema[0].(map[int16]any)[5], int64(2))
+	assert.Equal(t, schema[1].(map[int16]any)[4], "name")
+	assert.Equal(t, schema[2].(map[int16]any)[4], "email")
+
+	// read each column chunk's data page back and reassemble the column values
+	columns := map[string][]*string{}
+	for _, rg := range md[4].([]any) {
+		numRows := int(rg.(map[int16]any)[3].(int64))
+		for _, cc := range rg.(map[int16]any)[1].([]any) {
+			cmd := cc.(map[int16]any)[3].(map[int16]any)
+			name := cmd[3].([]any)[0].(string)
+			assert.Equal(t, cmd[5], int64(numRows))
+
+			page := &compactReader{t: t, bs: file[cmd[9].(int64):]}
+			header := page.structValue()
+			assert.Equal(t, header[5].(map[int16]any)[1], int64(numRows))
+			data := page.bs[:header[2].(int64)]
+
+			levelsLen := binary.LittleEndian.Uint32(data)
+			levels := &compactReader{t: t, bs: data[4 : 4+levelsLen]}
+			values := data[4+levelsLen:]
+			for len(levels.bs) > 0 {
+				run := int(levels.uvarint() >> 1)
+				defined := levels.byte() == 1
+				for range run {
+					if !defined {
+						columns[name] = append(columns[name], nil)
+						continue
+					}
+					n := binary.LittleEndian.Uint32(values)
+					columns[name] = append(columns[name], str(string(values[4:4+n])))
+					values = values[4+n:]
+				}
+			}
+			assert.Equal(t, len(values), 0)
+		}
+	}
+
+	assert.Equal(t, columns["name"], []*string{str("alice"), str("bob"), nil})
+	assert.Equal(t, columns["email"], []*string{str("alice@example.com"), nil, str("carol@example.com")})
+}
+
+func TestEmptyFile(t *testing.T) {
+	var buf bytes.Buffer
+	pw := NewWriter(&buf, []string{"name"})
+	assert.NoErr(t, pw.Close())
+	assert.NoErr(t, pw.Close())
+
+	file := buf.Bytes()
+	assert.Equal(t, string(file[:4]), magic)
+	assert.Equal(t, string(file[len(file)-4:]), magic)
+}
@@ -44,7 +44,6 @@ func newWrappedOAuthError(err error, errorType string, code int) error {
 
 // For future reference, here's the mapping of other OAuth errors to HTTP codes
 // that will likely become relevant to us:
-// invalid_scope - http.StatusBadRequest
 // invalid_client - http.StatusBadRequest or http.StatusUnauthorized (depending)
 // insufficient_scope - http.StatusForbidden
 // unauthorized_client - http.StatusForbidden
@@ -71,6 +70,21 @@ var ErrInvalidClientSecret = OAuthError{
 	Code:      http.StatusBadRequest,
 }
 
+// ErrInvalidRefreshToken indicates a refresh token that has been revoked or otherwise can't be used.
+var ErrInvalidRefreshToken = OAuthError{
+	ErrorType: "invalid_grant",
+	ErrorDesc: "invalid refresh token",
+	Code:      http.StatusBadRequest,
+}
+
+// ErrTokenNotIssuedToClient indicates a client tried to revoke a token that was issued to a different client.
+// See https://www.rfc-editor.org/rfc/rfc7009#section-2.1
+var ErrTokenNotIssuedToClient = OAuthError{
+	ErrorType: "unauthorized_client",
+	ErrorDesc: "token was not issued to this client",
+	Code:      http.StatusBadRequest,
+}
+
 // ErrInvalidAuthHeader indicates a bad HTTP Authorization header in an auth'd request.
 var ErrInvalidAuthHeader = OAuthError{
 	ErrorType: "invalid_token",
@@ -85,6 +99,42 @@ var ErrInvalidCodeVerifier = OAuthError{
 	Code:      http.StatusBadRequest,
 }
 
+// ErrInvalidDeviceCode indicates a bad device_code argument in a device authorization grant.
+var ErrInvalidDeviceCode = OAuthError{
+	ErrorType: "invalid_grant",
+	ErrorDesc: "invalid device code",
+	Code:      http.StatusBadRequest,
+}
+
+// ErrAuthorizationPending indicates that the user hasn't yet completed a device authorization request.
+// See https://www.rfc-editor.org/rfc/rfc8628#section-3.5 for this and the other device flow errors.
+var ErrAuthorizationPending = OAuthError{
+	ErrorType: "authorization_pending",
+	ErrorDesc: "the authorization request is still pending",
+	Code:      http.StatusBadRequest,
+}
+
+// ErrSlowDown indicates that a device is polling for a device authorization too quickly.
+var ErrSlowDown = OAuthError{
+	ErrorType: "slow_down",
+	ErrorDesc: "polling too frequently, increase the polling interval",
+	Code:      http.StatusBadRequest,
+}
+
+// ErrAccessDenied indicates that the user denied a device authorization request.
+var ErrAccessDenied = OAuthError{
+	ErrorType: "access_denied",
+	ErrorDesc: "the authorization request was denied",
+	Code:      http.StatusBadRequest,
+}
+
+// ErrExpiredToken indicates that a device code has expired.
+var ErrExpiredToken = OAuthError{
+	ErrorType: "expired_token",
+	ErrorDesc: "the device code has expired",
+	Code:      http.StatusBadRequest,
+}
+
 // NewServerError returns a new internal server error.
 func NewServerError(err error) error {
 	return newWrappedOAuthError(err, "server_error", http.StatusInternalServerError)
@@ -122,3 +172,15 @@ func NewInvalidTokenError(err error) error {
 func NewInvalidClientError(err error) error {
 	return newWrappedOAuthError(err, "invalid_client", http.StatusBadRequest)
 }
+
+// NewInvalidScopeError returns an error signifying that a requested scope is invalid or exceeds
+// the scope that can be granted.
+func NewInvalidScopeError(err error) error {
+	return newWrappedOAuthError(err, "invalid_scope", http.StatusBadRequest)
+}
+
+// NewInvalidTargetError returns an error signifying that a requested audience or resource is
+// unknown or not allowed. See https://www.rfc-editor.org/rfc/rfc8693#section-2.2.2
+func NewInvalidTargetError(err error) error {
+	return newWrappedOAuthError(err, "invalid_target", http.StatusBadRequest)
+}
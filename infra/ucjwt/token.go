@@ -104,6 +104,21 @@ func ParseUCClaimsVerified(token string, key *rsa.PublicKey) (*oidc.UCTokenClaim
 	return &claims, ucerr.Wrap(err)
 }
 
+// ParseUCClaimsVerifiedByKeyID extracts the claims as UCTokenClaims from a token and verifies the signature,
+// expiration, etc, using the public key that getKey returns for the token's 'kid' header (which may be empty)
+func ParseUCClaimsVerifiedByKeyID(token string, getKey func(keyID string) (*rsa.PublicKey, error)) (*oidc.UCTokenClaims, error) {
+	var claims oidc.UCTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		keyID, _ := t.Header["kid"].(string)
+		key, err := getKey(keyID)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return key, nil
+	}, jwt.WithTimeFunc(TimeFunc))
+	return &claims, ucerr.Wrap(err)
+}
+
 // IsExpired returns `true, nil` if the supplied JWT has valid claims and is expired,
 // `false, nil` if it has valid claims and is unexpired, and `true, err` if the claims
 // aren't parseable.
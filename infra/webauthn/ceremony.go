@@ -0,0 +1,239 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// Authenticator data flags, see https://www.w3.org/TR/webauthn-2/#flags
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+const attestationFormatNone = "none"
+
+// URLEncodedBytes is a byte slice that marshals to & from unpadded base64url, which is how
+// browsers serialize binary values in PublicKeyCredential.toJSON()
+type URLEncodedBytes []byte
+
+// MarshalJSON implements json.Marshaler
+func (b URLEncodedBytes) MarshalJSON() ([]byte, error) {
+	bs, err := json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+	return bs, ucerr.Wrap(err)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *URLEncodedBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ucerr.Wrap(err)
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(trimPadding(s))
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	*b = decoded
+	return nil
+}
+
+// RegistrationResponse is the JSON form of the PublicKeyCredential returned by navigator.credentials.create()
+type RegistrationResponse struct {
+	RawID    URLEncodedBytes `json:"rawId"`
+	Type     string          `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBytes `json:"clientDataJSON"`
+		AttestationObject URLEncodedBytes `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the PublicKeyCredential returned by navigator.credentials.get()
+type AssertionResponse struct {
+	RawID    URLEncodedBytes `json:"rawId"`
+	Type     string          `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBytes `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBytes `json:"authenticatorData"`
+		Signature         URLEncodedBytes `json:"signature"`
+		UserHandle        URLEncodedBytes `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// ParseAssertionResponse parses the JSON form of an assertion, eg. so that the caller can look up
+// the credential by ID or user handle before verifying it
+func ParseAssertionResponse(raw []byte) (*AssertionResponse, error) {
+	var ar AssertionResponse
+	if err := json.Unmarshal(raw, &ar); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if ar.Type != "public-key" {
+		return nil, ucerr.Errorf("unexpected credential type '%s'", ar.Type)
+	}
+	if len(ar.RawID) == 0 {
+		return nil, ucerr.New("assertion is missing credential ID")
+	}
+	return &ar, nil
+}
+
+type attestationObject struct {
+	Format   string          `cbor:"fmt"`
+	AuthData []byte          `cbor:"authData"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+}
+
+type authenticatorData struct {
+	flags     byte
+	signCount uint32
+
+	// only set during registration
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte, rp RelyingParty) (*authenticatorData, error) {
+	// 32 byte RP ID hash, 1 byte of flags and a 4 byte signature counter
+	const minLength = 37
+	if len(data) < minLength {
+		return nil, ucerr.Errorf("authenticator data is too short (%d bytes)", len(data))
+	}
+
+	if !bytes.Equal(data[:32], rpIDHash(rp.ID)) {
+		return nil, ucerr.Errorf("authenticator data RP ID hash does not match RP ID '%s'", rp.ID)
+	}
+
+	ad := &authenticatorData{
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if ad.flags&flagUserPresent == 0 {
+		return nil, ucerr.New("user was not present for the ceremony")
+	}
+
+	// a registered credential can be used to log in on its own (as a passkey), so the authenticator must always
+	// have verified the user (eg. with a PIN or biometric) rather than just tested for their presence
+	if ad.flags&flagUserVerified == 0 {
+		return nil, ucerr.New("user was not verified for the ceremony")
+	}
+
+	if ad.flags&flagAttestedCredentialData != 0 {
+		// 16 byte AAGUID followed by a 2 byte credential ID length, the credential ID and the COSE public key
+		rest := data[minLength:]
+		if len(rest) < 18 {
+			return nil, ucerr.New("attested credential data is too short")
+		}
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLen {
+			return nil, ucerr.New("attested credential ID is truncated")
+		}
+		ad.credentialID = rest[:idLen]
+
+		var key cbor.RawMessage
+		if _, err := cbor.UnmarshalFirst(rest[idLen:], &key); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		ad.publicKey = key
+	}
+
+	return ad, nil
+}
+
+// VerifyRegistration verifies the browser's response to a registration ceremony for the given
+// challenge, and returns the newly registered credential (without user information filled in)
+func VerifyRegistration(rp RelyingParty, challenge string, raw []byte) (*Credential, error) {
+	var rr RegistrationResponse
+	if err := json.Unmarshal(raw, &rr); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if rr.Type != "public-key" {
+		return nil, ucerr.Errorf("unexpected credential type '%s'", rr.Type)
+	}
+
+	if err := verifyClientData(rr.Response.ClientDataJSON, clientDataTypeCreate, challenge, rp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	var ao attestationObject
+	if err := cbor.Unmarshal(rr.Response.AttestationObject, &ao); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if ao.Format != attestationFormatNone {
+		return nil, ucerr.Errorf("unsupported attestation format '%s'", ao.Format)
+	}
+
+	ad, err := parseAuthenticatorData(ao.AuthData, rp)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if len(ad.credentialID) == 0 {
+		return nil, ucerr.New("registration is missing attested credential data")
+	}
+	if !bytes.Equal(ad.credentialID, rr.RawID) {
+		return nil, ucerr.New("attested credential ID does not match response credential ID")
+	}
+	if _, err := parsePublicKey(ad.publicKey); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &Credential{
+		ID:        ad.credentialID,
+		PublicKey: ad.publicKey,
+		SignCount: ad.signCount,
+	}, nil
+}
+
+// VerifyAssertion verifies the browser's response to an authentication ceremony for the given
+// challenge against a registered credential, and returns the authenticator's new signature counter
+func VerifyAssertion(rp RelyingParty, challenge string, cred Credential, raw []byte) (uint32, error) {
+	ar, err := ParseAssertionResponse(raw)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	if !cred.IsRegistered() {
+		return 0, ucerr.New("credential has not been registered")
+	}
+	if !bytes.Equal(ar.RawID, cred.ID) {
+		return 0, ucerr.New("assertion credential ID does not match")
+	}
+	if len(ar.Response.UserHandle) > 0 && !bytes.Equal(ar.Response.UserHandle, cred.UserHandle) {
+		return 0, ucerr.New("assertion user handle does not match")
+	}
+
+	if err := verifyClientData(ar.Response.ClientDataJSON, clientDataTypeGet, challenge, rp); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	ad, err := parseAuthenticatorData(ar.Response.AuthenticatorData, rp)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	clientDataHash := sha256.Sum256(ar.Response.ClientDataJSON)
+	signedData := append(append([]byte{}, ar.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := key.verify(signedData, ar.Response.Signature); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	// authenticators that don't implement a counter (eg. most synced passkeys) always return 0;
+	// otherwise the counter must increase or the credential may have been cloned
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return 0, ucerr.Errorf("signature counter %d did not increase past %d", ad.signCount, cred.SignCount)
+	}
+
+	return ad.signCount, nil
+}
@@ -0,0 +1,190 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// COSE key parameters, see https://www.iana.org/assignments/cose/cose.xhtml
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+
+	// key type specific parameters share labels, so eg. -1 is the curve for EC2 & OKP keys but the modulus for RSA keys
+	coseEC2Curve = -1
+	coseEC2X     = -2
+	coseEC2Y     = -3
+	coseOKPCurve = -1
+	coseOKPX     = -2
+	coseRSAN     = -1
+	coseRSAE     = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// COSE algorithm identifiers we support
+const (
+	AlgorithmES256 = -7
+	AlgorithmEdDSA = -8
+	AlgorithmRS256 = -257
+)
+
+// SupportedAlgorithms lists the algorithms we accept, in order of preference
+var SupportedAlgorithms = []int{AlgorithmES256, AlgorithmEdDSA, AlgorithmRS256}
+
+type publicKey interface {
+	verify(data []byte, sig []byte) error
+}
+
+type ecdsaPublicKey struct {
+	key *ecdsa.PublicKey
+}
+
+func (k ecdsaPublicKey) verify(data []byte, sig []byte) error {
+	h := sha256.Sum256(data)
+	if !ecdsa.VerifyASN1(k.key, h[:], sig) {
+		return ucerr.New("invalid ES256 signature")
+	}
+	return nil
+}
+
+type ed25519PublicKey struct {
+	key ed25519.PublicKey
+}
+
+func (k ed25519PublicKey) verify(data []byte, sig []byte) error {
+	if !ed25519.Verify(k.key, data, sig) {
+		return ucerr.New("invalid EdDSA signature")
+	}
+	return nil
+}
+
+type rsaPublicKey struct {
+	key *rsa.PublicKey
+}
+
+func (k rsaPublicKey) verify(data []byte, sig []byte) error {
+	h := sha256.Sum256(data)
+	if err := rsa.VerifyPKCS1v15(k.key, crypto.SHA256, h[:], sig); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+func coseInt(params map[int]cbor.RawMessage, label int) (int, error) {
+	raw, found := params[label]
+	if !found {
+		return 0, ucerr.Errorf("COSE key is missing parameter %d", label)
+	}
+	var v int
+	if err := cbor.Unmarshal(raw, &v); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return v, nil
+}
+
+func coseBytes(params map[int]cbor.RawMessage, label int) ([]byte, error) {
+	raw, found := params[label]
+	if !found {
+		return nil, ucerr.Errorf("COSE key is missing parameter %d", label)
+	}
+	var v []byte
+	if err := cbor.Unmarshal(raw, &v); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return v, nil
+}
+
+// parsePublicKey parses a COSE_Key (https://www.rfc-editor.org/rfc/rfc9052#section-7) into one of the
+// public key types we support
+func parsePublicKey(coseKey []byte) (publicKey, error) {
+	var params map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(coseKey, &params); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	kty, err := coseInt(params, coseKeyType)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	alg, err := coseInt(params, coseAlgorithm)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgorithmES256:
+		crv, err := coseInt(params, coseEC2Curve)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if crv != coseCurveP256 {
+			return nil, ucerr.Errorf("unsupported EC2 curve %d", crv)
+		}
+		x, err := coseBytes(params, coseEC2X)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		y, err := coseBytes(params, coseEC2Y)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ucerr.New("EC2 public key is not on the P-256 curve")
+		}
+		return ecdsaPublicKey{key: key}, nil
+
+	case kty == coseKeyTypeOKP && alg == AlgorithmEdDSA:
+		crv, err := coseInt(params, coseOKPCurve)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if crv != coseCurveEd25519 {
+			return nil, ucerr.Errorf("unsupported OKP curve %d", crv)
+		}
+		x, err := coseBytes(params, coseOKPX)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ucerr.Errorf("invalid Ed25519 public key length %d", len(x))
+		}
+		return ed25519PublicKey{key: ed25519.PublicKey(x)}, nil
+
+	case kty == coseKeyTypeRSA && alg == AlgorithmRS256:
+		n, err := coseBytes(params, coseRSAN)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		e, err := coseBytes(params, coseRSAE)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, ucerr.New("RSA public exponent is too large")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if key.N.BitLen() < 2048 {
+			return nil, ucerr.Errorf("RSA public key is too short (%d bits)", key.N.BitLen())
+		}
+		return rsaPublicKey{key: key}, nil
+	}
+
+	return nil, ucerr.Errorf("unsupported COSE key type %d with algorithm %d", kty, alg)
+}
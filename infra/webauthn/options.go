@@ -0,0 +1,113 @@
+package webauthn
+
+import (
+	"encoding/base64"
+	"time"
+)
+
+// CeremonyTimeout is how long we ask the browser to wait for the user to complete a ceremony
+const CeremonyTimeout = 5 * time.Minute
+
+// we always require user verification, since a registered credential can be used to log in without a password
+const userVerificationRequired = "required"
+
+// PublicKeyCredentialParameters describes a credential type & algorithm we accept
+type PublicKeyCredentialParameters struct {
+	Type      string `json:"type"`
+	Algorithm int    `json:"alg"`
+}
+
+// PublicKeyCredentialDescriptor identifies a registered credential
+type PublicKeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// RelyingPartyEntity is the browser-facing form of the relying party
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity is the browser-facing form of the user a credential is being created for
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// AuthenticatorSelectionCriteria expresses our requirements for the authenticator during registration
+type AuthenticatorSelectionCriteria struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is the JSON form of the options passed to navigator.credentials.create(), with
+// binary values base64url encoded (matching PublicKeyCredentialCreationOptionsJSON)
+type CreationOptions struct {
+	RelyingParty           RelyingPartyEntity              `json:"rp"`
+	User                   UserEntity                      `json:"user"`
+	Challenge              string                          `json:"challenge"`
+	PubKeyCredParams       []PublicKeyCredentialParameters `json:"pubKeyCredParams"`
+	Timeout                int64                           `json:"timeout"`
+	ExcludeCredentials     []PublicKeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelectionCriteria  `json:"authenticatorSelection"`
+	Attestation            string                          `json:"attestation"`
+}
+
+// RequestOptions is the JSON form of the options passed to navigator.credentials.get(), with
+// binary values base64url encoded (matching PublicKeyCredentialRequestOptionsJSON)
+type RequestOptions struct {
+	RelyingPartyID   string                          `json:"rpId"`
+	Challenge        string                          `json:"challenge"`
+	Timeout          int64                           `json:"timeout"`
+	AllowCredentials []PublicKeyCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                          `json:"userVerification"`
+}
+
+// NewCreationOptions returns the options for registering the pending credential, excluding any
+// credentials the user has already registered so the same authenticator isn't registered twice
+func NewCreationOptions(rp RelyingParty, challenge string, cred Credential, existing []Credential) CreationOptions {
+	params := make([]PublicKeyCredentialParameters, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, PublicKeyCredentialParameters{Type: "public-key", Algorithm: alg})
+	}
+
+	exclude := []PublicKeyCredentialDescriptor{}
+	for _, c := range existing {
+		if c.IsRegistered() {
+			exclude = append(exclude, PublicKeyCredentialDescriptor{Type: "public-key", ID: c.EncodedID()})
+		}
+	}
+
+	return CreationOptions{
+		RelyingParty: RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User: UserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(cred.UserHandle),
+			Name:        cred.UserName,
+			DisplayName: cred.UserName,
+		},
+		Challenge:          challenge,
+		PubKeyCredParams:   params,
+		Timeout:            CeremonyTimeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelectionCriteria{
+			// ask for a discoverable credential so the same registration can be used as a passkey, which
+			// is also why the authenticator must verify the user
+			ResidentKey:      "preferred",
+			UserVerification: userVerificationRequired,
+		},
+		Attestation: attestationFormatNone,
+	}
+}
+
+// NewRequestOptions returns the options for asserting the given registered credential
+func NewRequestOptions(rp RelyingParty, challenge string, cred Credential) RequestOptions {
+	return RequestOptions{
+		RelyingPartyID:   rp.ID,
+		Challenge:        challenge,
+		Timeout:          CeremonyTimeout.Milliseconds(),
+		AllowCredentials: []PublicKeyCredentialDescriptor{{Type: "public-key", ID: cred.EncodedID()}},
+		UserVerification: userVerificationRequired,
+	}
+}
@@ -0,0 +1,240 @@
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/webauthn"
+)
+
+// softAuthenticator is a minimal software authenticator with a single ES256 credential
+type softAuthenticator struct {
+	t         *testing.T
+	key       *ecdsa.PrivateKey
+	credID    []byte
+	signCount uint32
+
+	// set to simulate an authenticator that only tests for user presence
+	skipUserVerification bool
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErr(t, err)
+	credID := make([]byte, 16)
+	_, err = rand.Read(credID)
+	assert.NoErr(t, err)
+	return &softAuthenticator{t: t, key: key, credID: credID}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	bs, err := cbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	assert.NoErr(a.t, err)
+	return bs
+}
+
+func (a *softAuthenticator) authData(rpID string, attested bool) []byte {
+	h := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, h[:]...)
+	flags := byte(0x01 | 0x04)
+	if a.skipUserVerification {
+		flags &^= 0x04
+	}
+	if attested {
+		flags |= 0x40
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credID)))
+		data = append(data, a.credID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func clientDataJSON(t *testing.T, typ, challenge, origin string) []byte {
+	bs, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": origin})
+	assert.NoErr(t, err)
+	return bs
+}
+
+func encode(bs []byte) string {
+	return base64.RawURLEncoding.EncodeToString(bs)
+}
+
+func (a *softAuthenticator) register(rp webauthn.RelyingParty, challenge string) []byte {
+	ao, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(rp.ID, true),
+	})
+	assert.NoErr(a.t, err)
+
+	bs, err := json.Marshal(map[string]any{
+		"id":    encode(a.credID),
+		"rawId": encode(a.credID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientDataJSON(a.t, "webauthn.create", challenge, rp.Origin)),
+			"attestationObject": encode(ao),
+		},
+	})
+	assert.NoErr(a.t, err)
+	return bs
+}
+
+func (a *softAuthenticator) assert(rp webauthn.RelyingParty, challenge string, userHandle []byte) []byte {
+	a.signCount++
+	authData := a.authData(rp.ID, false)
+	cd := clientDataJSON(a.t, "webauthn.get", challenge, rp.Origin)
+	cdHash := sha256.Sum256(cd)
+	signed := sha256.Sum256(append(append([]byte{}, authData...), cdHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, signed[:])
+	assert.NoErr(a.t, err)
+
+	bs, err := json.Marshal(map[string]any{
+		"id":    encode(a.credID),
+		"rawId": encode(a.credID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(cd),
+			"authenticatorData": encode(authData),
+			"signature":         encode(sig),
+			"userHandle":        encode(userHandle),
+		},
+	})
+	assert.NoErr(a.t, err)
+	return bs
+}
+
+func TestCeremonies(t *testing.T) {
+	rp := webauthn.RelyingParty{ID: "login.example.com", Name: "Example", Origin: "https://login.example.com"}
+	userHandle := []byte("0123456789abcdef")
+	auth := newSoftAuthenticator(t)
+
+	challenge := webauthn.NewChallenge()
+	cred, err := webauthn.VerifyRegistration(rp, challenge, auth.register(rp, challenge))
+	assert.NoErr(t, err)
+	assert.Equal(t, cred.ID, auth.credID)
+	cred.UserHandle = userHandle
+	cred.UserName = "me@example.com"
+	assert.NoErr(t, cred.Validate())
+
+	encoded, err := cred.Encode()
+	assert.NoErr(t, err)
+	decoded, err := webauthn.DecodeCredential(encoded)
+	assert.NoErr(t, err)
+	assert.Equal(t, *decoded, *cred)
+
+	t.Run("RegistrationWrongChallenge", func(t *testing.T) {
+		_, err := webauthn.VerifyRegistration(rp, webauthn.NewChallenge(), auth.register(rp, challenge))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("RegistrationWithoutUserVerification", func(t *testing.T) {
+		unverified := newSoftAuthenticator(t)
+		unverified.skipUserVerification = true
+		challenge := webauthn.NewChallenge()
+		_, err := webauthn.VerifyRegistration(rp, challenge, unverified.register(rp, challenge))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("RegistrationWrongOrigin", func(t *testing.T) {
+		other := rp
+		other.Origin = "https://evil.example.com"
+		challenge := webauthn.NewChallenge()
+		_, err := webauthn.VerifyRegistration(rp, challenge, auth.register(other, challenge))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Assertion", func(t *testing.T) {
+		challenge := webauthn.NewChallenge()
+		signCount, err := webauthn.VerifyAssertion(rp, challenge, *cred, auth.assert(rp, challenge, userHandle))
+		assert.NoErr(t, err)
+		assert.Equal(t, signCount, auth.signCount)
+		cred.SignCount = signCount
+	})
+
+	t.Run("AssertionReplay", func(t *testing.T) {
+		challenge := webauthn.NewChallenge()
+		resp := auth.assert(rp, challenge, userHandle)
+		signCount, err := webauthn.VerifyAssertion(rp, challenge, *cred, resp)
+		assert.NoErr(t, err)
+		cred.SignCount = signCount
+
+		// the same response can't be used twice since the counter didn't increase
+		_, err = webauthn.VerifyAssertion(rp, challenge, *cred, resp)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("AssertionWrongRPID", func(t *testing.T) {
+		other := rp
+		other.ID = "example.org"
+		challenge := webauthn.NewChallenge()
+		_, err := webauthn.VerifyAssertion(rp, challenge, *cred, auth.assert(other, challenge, userHandle))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("AssertionWrongKey", func(t *testing.T) {
+		imposter := newSoftAuthenticator(t)
+		imposter.credID = auth.credID
+		imposter.signCount = auth.signCount
+		challenge := webauthn.NewChallenge()
+		_, err := webauthn.VerifyAssertion(rp, challenge, *cred, imposter.assert(rp, challenge, userHandle))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("AssertionWithoutUserVerification", func(t *testing.T) {
+		unverified := *auth
+		unverified.skipUserVerification = true
+		challenge := webauthn.NewChallenge()
+		_, err := webauthn.VerifyAssertion(rp, challenge, *cred, unverified.assert(rp, challenge, userHandle))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("AssertionWrongUserHandle", func(t *testing.T) {
+		challenge := webauthn.NewChallenge()
+		_, err := webauthn.VerifyAssertion(rp, challenge, *cred, auth.assert(rp, challenge, []byte("someone else")))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestOptions(t *testing.T) {
+	rp := webauthn.RelyingParty{ID: "login.example.com", Name: "Example", Origin: "https://login.example.com"}
+	pending := webauthn.NewPendingCredential([]byte("handle"), "me@example.com")
+	registered := webauthn.Credential{ID: []byte("cred"), PublicKey: []byte("key"), UserHandle: []byte("handle")}
+
+	co := webauthn.NewCreationOptions(rp, "challenge", pending, []webauthn.Credential{pending, registered})
+	assert.Equal(t, co.User.ID, encode([]byte("handle")))
+	assert.Equal(t, len(co.ExcludeCredentials), 1)
+	assert.Equal(t, co.ExcludeCredentials[0].ID, encode([]byte("cred")))
+
+	assert.Equal(t, co.AuthenticatorSelection.UserVerification, "required")
+
+	ro := webauthn.NewRequestOptions(rp, "challenge", registered)
+	assert.Equal(t, ro.RelyingPartyID, rp.ID)
+	assert.Equal(t, ro.AllowCredentials[0].ID, encode([]byte("cred")))
+	assert.Equal(t, ro.UserVerification, "required")
+}
+
+func TestRelyingPartyValidate(t *testing.T) {
+	assert.NoErr(t, webauthn.RelyingParty{ID: "login.example.com", Origin: "https://login.example.com"}.Validate())
+	assert.NoErr(t, webauthn.RelyingParty{ID: "example.com", Origin: "https://login.example.com:8443"}.Validate())
+	assert.NotNil(t, webauthn.RelyingParty{ID: "login.example.com", Origin: "https://example.com"}.Validate())
+	assert.NotNil(t, webauthn.RelyingParty{ID: "example.com", Origin: "https://notexample.com"}.Validate())
+}
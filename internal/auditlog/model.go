@@ -38,6 +38,7 @@ const (
 	PasswordReset        EventType = "PasswordReset"
 	AccountCreated       EventType = "AccountCreated"
 	AccountImpersonation EventType = "AccountImpersonation"
+	TokenExchange        EventType = "TokenExchange"
 	TenantCreated        EventType = "TenantCreated"
 
 	// AuthZ Events
@@ -97,6 +98,7 @@ func (Entry) getPaginationKeys() pagination.KeyTypes {
 		"actor_id":                  pagination.UUIDKeyType,
 		"payload->'SelectorValues'": pagination.ArrayKeyType,
 		"payload->>'ID'":            pagination.UUIDKeyType,
+		"payload->>'UserID'":        pagination.UUIDKeyType,
 		"payload->>'Version'":       pagination.IntKeyType,
 	}
 }
@@ -7,7 +7,7 @@ import (
 )
 
 // MFAMethodTypes is a comma-delimited list of the supported mfa method options
-const MFAMethodTypes = "email,sms,authenticator,recovery_code"
+const MFAMethodTypes = "email,sms,authenticator,webauthn,recovery_code"
 
 // MFAMethods is a parameter type representing a set of mfa methods.  The
 // parameter value must be a comma-delimited list of mfa methods, each of which
@@ -26,7 +26,9 @@ func GeneratePlexKeys(ctx context.Context, tenantID uuid.UUID) (*tenantplex.Keys
 		return nil, ucerr.Wrap(err)
 	}
 
-	privKey, err := secret.NewString(ctx, universe.ServiceName(), fmt.Sprintf("%v-%s", tenantID, "private-key"), string(privBytes))
+	// include the key ID in the secret name so that a new key doesn't overwrite the private key of a
+	// key that is still active while the tenant's keys are being rotated
+	privKey, err := secret.NewString(ctx, universe.ServiceName(), fmt.Sprintf("%v-%s-%s", tenantID, "private-key", id), string(privBytes))
 	if err != nil {
 		return nil, ucerr.Wrap(err)
 	}
@@ -27,12 +27,25 @@ const (
 
 	// AccessDenied indicates that the query should be blocked and an access denied error returned
 	AccessDenied
+
+	// RewriteQuery indicates that the query should be rewritten as described by the QueryRewrite returned in place of
+	// the transform info before being passed through to the SQL database, and the results passed back without
+	// modification
+	RewriteQuery
 )
 
-// Observer is an interface for handling SQL queries
+// QueryRewrite describes how to rewrite a query, either by replacing the text of the query, or the values of the
+// parameters bound to a prepared statement (indexed from 0)
+type QueryRewrite struct {
+	Query  string
+	Params map[int]string
+}
+
+// Observer is an interface for handling SQL queries; params holds the values bound to a prepared statement, and is
+// nil for queries sent as plain text
 type Observer interface {
 	NotifySchemaSelected(ctx context.Context, schema string)
-	HandleQuery(ctx context.Context, dbt DatabaseType, query string, tableSchema string, connectionID uuid.UUID) (HandleQueryResponse, any, string, error)
+	HandleQuery(ctx context.Context, dbt DatabaseType, query string, tableSchema string, connectionID uuid.UUID, params []any) (HandleQueryResponse, any, string, error)
 	CleanupTransformerExecution(transformInfo any)
 	TransformDataRow(ctx context.Context, colNames []string, values [][]byte, transformInfo any, cumulativeRows int) (bool, error)
 	TransformSummary(ctx context.Context, transformInfo any, numSelectorRows, numReturned, numDenied int)
@@ -0,0 +1,15 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantdb
+
+func init() {
+	UsedColumns["client_assertions"] = []string{
+		"client_id",
+		"created",
+		"deleted",
+		"expires",
+		"id",
+		"jti",
+		"updated",
+	}
+}
@@ -0,0 +1,22 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantdb
+
+func init() {
+	UsedColumns["device_authorizations"] = []string{
+		"client_id",
+		"created",
+		"deleted",
+		"device_code",
+		"expires",
+		"id",
+		"last_polled",
+		"plex_token_id",
+		"poll_interval",
+		"scopes",
+		"session_id",
+		"status",
+		"updated",
+		"user_code",
+	}
+}
@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantdb
+
+func init() {
+	UsedColumns["dsar_exports"] = []string{
+		"bundle",
+		"created",
+		"deleted",
+		"error",
+		"expires_at",
+		"id",
+		"signature",
+		"status",
+		"updated",
+		"user_id",
+	}
+}
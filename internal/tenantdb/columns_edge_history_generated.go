@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantdb
+
+func init() {
+	UsedColumns["edge_history"] = []string{
+		"action",
+		"actor",
+		"created",
+		"deleted",
+		"edge_id",
+		"edge_type_id",
+		"id",
+		"source",
+		"source_object_id",
+		"target_object_id",
+		"updated",
+		"valid_from",
+		"valid_until",
+	}
+}
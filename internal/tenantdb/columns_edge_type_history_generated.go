@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantdb
+
+func init() {
+	UsedColumns["edge_type_history"] = []string{
+		"action",
+		"actor",
+		"attributes",
+		"created",
+		"deleted",
+		"edge_type_id",
+		"id",
+		"organization_id",
+		"source",
+		"source_object_type_id",
+		"target_object_type_id",
+		"type_name",
+		"updated",
+	}
+}
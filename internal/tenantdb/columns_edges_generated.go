@@ -11,5 +11,7 @@ func init() {
 		"source_object_id",
 		"target_object_id",
 		"updated",
+		"valid_from",
+		"valid_until",
 	}
 }
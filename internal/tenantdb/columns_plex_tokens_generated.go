@@ -15,6 +15,7 @@ func init() {
 		"refresh_token",
 		"scopes",
 		"session_id",
+		"subject_token_id",
 		"underlying_token",
 		"updated",
 	}
@@ -8,12 +8,17 @@ func init() {
 		"access_policy_id",
 		"created",
 		"deleted",
+		"export_bucket",
+		"export_prefix",
+		"field_mappings",
 		"id",
 		"name",
 		"region",
 		"role_arn",
 		"secret_access_key",
+		"tokenize_on_write",
 		"type",
 		"updated",
+		"write_access_policy_id",
 	}
 }
@@ -9,6 +9,7 @@ func init() {
 		"created",
 		"data",
 		"deleted",
+		"expires_at",
 		"id",
 		"token",
 		"transformer_id",
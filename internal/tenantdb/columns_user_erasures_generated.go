@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantdb
+
+func init() {
+	UsedColumns["user_erasures"] = []string{
+		"certificate",
+		"completed_at",
+		"created",
+		"deleted",
+		"id",
+		"status",
+		"systems",
+		"updated",
+		"user_event_aliases",
+		"user_id",
+	}
+}
@@ -3469,4 +3469,222 @@ var Migrations = migrate.Migrations{
 		Up:      `ALTER TABLE user_search_indices ADD COLUMN last_regional_bootstrapped_value_ids JSONB NOT NULL DEFAULT '{}'::JSONB;`,
 		Down:    `ALTER TABLE user_search_indices DROP COLUMN last_regional_bootstrapped_value_ids;`,
 	},
+	{
+		Version: 311,
+		Table:   "device_authorizations",
+		Desc:    "add device_authorizations table for the device authorization grant",
+		Up: `CREATE TABLE device_authorizations (
+			id UUID NOT NULL,
+			created TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated TIMESTAMP NOT NULL,
+			deleted TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			client_id VARCHAR NOT NULL,
+			device_code VARCHAR NOT NULL,
+			user_code VARCHAR NOT NULL,
+			scopes VARCHAR NOT NULL,
+			expires TIMESTAMP NOT NULL,
+			poll_interval INT8 NOT NULL,
+			last_polled TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			status INT8 NOT NULL,
+			session_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'::UUID,
+			plex_token_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'::UUID,
+			PRIMARY KEY (deleted, id),
+			UNIQUE (device_code, deleted)
+		);
+		CREATE INDEX device_authorizations_user_code_idx ON device_authorizations (user_code, status);`,
+		Down: `DROP TABLE device_authorizations;`,
+	},
+	{
+		Version: 312,
+		Table:   "edges",
+		Desc:    "add validity window to edges for time-bounded access",
+		Up: `ALTER TABLE edges ADD COLUMN valid_from TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP;
+			ALTER TABLE edges ADD COLUMN valid_until TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP;
+			DROP INDEX edges_updated_time;
+			CREATE INDEX edges_updated_time ON edges (updated) INCLUDE (created, edge_type_id, source_object_id, target_object_id, valid_from, valid_until);
+			CREATE INDEX edges_valid_until_idx ON edges (valid_until);`,
+		Down: `DROP INDEX edges_valid_until_idx;
+			DROP INDEX edges_updated_time;
+			CREATE INDEX edges_updated_time ON edges (updated) INCLUDE (created, edge_type_id, source_object_id, target_object_id);
+			ALTER TABLE edges DROP COLUMN valid_until;
+			ALTER TABLE edges DROP COLUMN valid_from;`,
+	},
+	{
+		Version: 313,
+		Table:   "edge_history",
+		Desc:    "add append-only edge history table for point-in-time authz checks, backfilled from existing edges",
+		Up: `CREATE TABLE edge_history (
+			id UUID NOT NULL DEFAULT gen_random_uuid(),
+			created TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated TIMESTAMP NOT NULL,
+			deleted TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			edge_id UUID NOT NULL,
+			action VARCHAR NOT NULL,
+			source VARCHAR NOT NULL DEFAULT '',
+			actor VARCHAR NOT NULL DEFAULT '',
+			edge_type_id UUID NOT NULL,
+			source_object_id UUID NOT NULL,
+			target_object_id UUID NOT NULL,
+			valid_from TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			valid_until TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			PRIMARY KEY (deleted, id)
+		);
+		CREATE INDEX edge_history_edge_id_created_idx ON edge_history (edge_id, created);
+		INSERT INTO edge_history (id, created, updated, edge_id, action, source, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+			SELECT gen_random_uuid(), created, created, id, 'create', 'backfill', edge_type_id, source_object_id, target_object_id, valid_from, valid_until FROM edges;
+		INSERT INTO edge_history (id, created, updated, edge_id, action, source, edge_type_id, source_object_id, target_object_id, valid_from, valid_until)
+			SELECT gen_random_uuid(), deleted, deleted, id, 'delete', 'backfill', edge_type_id, source_object_id, target_object_id, valid_from, valid_until FROM edges WHERE deleted<>'0001-01-01 00:00:00'::TIMESTAMP;`,
+		Down: `DROP TABLE edge_history;`,
+	},
+	{
+		Version: 314,
+		Table:   "edge_type_history",
+		Desc:    "add append-only edge type history table for point-in-time authz checks, backfilled from existing edge types",
+		Up: `CREATE TABLE edge_type_history (
+			id UUID NOT NULL DEFAULT gen_random_uuid(),
+			created TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated TIMESTAMP NOT NULL,
+			deleted TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			edge_type_id UUID NOT NULL,
+			action VARCHAR NOT NULL,
+			source VARCHAR NOT NULL DEFAULT '',
+			actor VARCHAR NOT NULL DEFAULT '',
+			type_name VARCHAR NOT NULL,
+			source_object_type_id UUID NOT NULL,
+			target_object_type_id UUID NOT NULL,
+			attributes JSONB NOT NULL DEFAULT '[]'::JSONB,
+			organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'::UUID,
+			PRIMARY KEY (deleted, id)
+		);
+		CREATE INDEX edge_type_history_edge_type_id_created_idx ON edge_type_history (edge_type_id, created);
+		INSERT INTO edge_type_history (id, created, updated, edge_type_id, action, source, type_name, source_object_type_id, target_object_type_id, attributes, organization_id)
+			SELECT gen_random_uuid(), created, created, id, 'create', 'backfill', type_name, source_object_type_id, target_object_type_id, attributes, organization_id FROM edge_types;
+		INSERT INTO edge_type_history (id, created, updated, edge_type_id, action, source, type_name, source_object_type_id, target_object_type_id, attributes, organization_id)
+			SELECT gen_random_uuid(), deleted, deleted, id, 'delete', 'backfill', type_name, source_object_type_id, target_object_type_id, attributes, organization_id FROM edge_types WHERE deleted<>'0001-01-01 00:00:00'::TIMESTAMP;`,
+		Down: `DROP TABLE edge_type_history;`,
+	},
+	{
+		Version: 315,
+		Table:   "token_records",
+		Desc:    "add expires_at to token_records for expiring tokens",
+		Up: `ALTER TABLE token_records ADD COLUMN expires_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP;
+			CREATE INDEX token_records_expires_at_idx ON token_records (expires_at);`,
+		Down: `DROP INDEX token_records_expires_at_idx;
+			ALTER TABLE token_records DROP COLUMN expires_at;`,
+	},
+	{
+		Version: 316,
+		Table:   "shim_object_stores",
+		Desc:    "add field_mappings to shim_object_stores for content-aware redaction",
+		Up:      `ALTER TABLE shim_object_stores ADD COLUMN field_mappings JSONB NOT NULL DEFAULT '[]'::JSONB;`,
+		Down:    `ALTER TABLE shim_object_stores DROP COLUMN field_mappings;`,
+	},
+	{
+		Version: 317,
+		Table:   "shim_object_stores",
+		Desc:    "add tokenize_on_write to shim_object_stores for tokenizing uploads through the S3 shim",
+		Up:      `ALTER TABLE shim_object_stores ADD COLUMN tokenize_on_write BOOL NOT NULL DEFAULT false;`,
+		Down:    `ALTER TABLE shim_object_stores DROP COLUMN tokenize_on_write;`,
+	},
+	{
+		Version: 318,
+		Table:   "dsar_exports",
+		Desc:    "add dsar_exports table for data subject access request exports",
+		Up: `CREATE TABLE dsar_exports (
+			id UUID NOT NULL,
+			created TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated TIMESTAMP NOT NULL,
+			deleted TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			user_id UUID NOT NULL,
+			status VARCHAR NOT NULL,
+			error VARCHAR NOT NULL DEFAULT '',
+			bundle VARCHAR NOT NULL DEFAULT '',
+			signature VARCHAR NOT NULL DEFAULT '',
+			expires_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			PRIMARY KEY (deleted, id)
+		);
+		CREATE INDEX dsar_exports_user_id_idx ON dsar_exports (user_id);`,
+		Down: `DROP TABLE dsar_exports;`,
+	},
+	{
+		Version: 319,
+		Table:   "token_records",
+		Desc:    "add user_id index to token_records for DSAR exports",
+		Up:      `CREATE INDEX token_records_user_id_idx ON token_records (user_id);`,
+		Down:    `DROP INDEX token_records_user_id_idx;`,
+	},
+	{
+		Version: 320,
+		Table:   "user_erasures",
+		Desc:    "add user_erasures table for cross-system user erasure",
+		Up: `CREATE TABLE user_erasures (
+			id UUID NOT NULL,
+			created TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated TIMESTAMP NOT NULL,
+			deleted TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			user_id UUID NOT NULL,
+			status VARCHAR NOT NULL,
+			systems JSONB NOT NULL DEFAULT '[]'::JSONB,
+			user_event_aliases VARCHAR[] NOT NULL DEFAULT '{}'::VARCHAR[],
+			certificate VARCHAR NOT NULL DEFAULT '',
+			completed_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			PRIMARY KEY (deleted, id)
+		);
+		CREATE INDEX user_erasures_user_id_idx ON user_erasures (user_id);`,
+		Down: `DROP TABLE user_erasures;`,
+	},
+	{
+		Version: 321,
+		Table:   "client_assertions",
+		Desc:    "add client_assertions table for private_key_jwt replay protection",
+		Up: `CREATE TABLE client_assertions (
+			id UUID NOT NULL,
+			created TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated TIMESTAMP NOT NULL,
+			deleted TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'::TIMESTAMP,
+			client_id VARCHAR NOT NULL,
+			jti VARCHAR NOT NULL,
+			expires TIMESTAMP NOT NULL,
+			PRIMARY KEY (deleted, id),
+			UNIQUE (client_id, jti, deleted)
+		);`,
+		Down: `DROP TABLE client_assertions;`,
+	},
+	{
+		Version: 322,
+		Table:   "client_assertions",
+		Desc:    "add expires index to client_assertions for cleanup",
+		Up:      `CREATE INDEX client_assertions_expires_idx ON client_assertions (expires);`,
+		Down:    `DROP INDEX client_assertions_expires_idx;`,
+	},
+	{
+		Version: 323,
+		Table:   "shim_object_stores",
+		Desc:    "add write_access_policy_id to shim_object_stores so uploads through the S3 shim are denied unless configured",
+		Up:      `ALTER TABLE shim_object_stores ADD COLUMN write_access_policy_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';`,
+		Down:    `ALTER TABLE shim_object_stores DROP COLUMN write_access_policy_id;`,
+	},
+	{
+		Version: 324,
+		Table:   "shim_object_stores",
+		Desc:    "add export_bucket and export_prefix to shim_object_stores to restrict where accessor exports are written",
+		Up: `ALTER TABLE shim_object_stores ADD COLUMN export_bucket VARCHAR NOT NULL DEFAULT '';
+			ALTER TABLE shim_object_stores ADD COLUMN export_prefix VARCHAR NOT NULL DEFAULT '';`,
+		Down: `ALTER TABLE shim_object_stores DROP COLUMN export_bucket;
+			ALTER TABLE shim_object_stores DROP COLUMN export_prefix;`,
+	},
+	{
+		Version: 325,
+		Table:   "plex_tokens",
+		Desc:    "add subject_token_id to plex_tokens so exchanged tokens are revoked with their subject token",
+		Up:      `ALTER TABLE plex_tokens ADD COLUMN subject_token_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';`,
+		Down:    `ALTER TABLE plex_tokens DROP COLUMN subject_token_id;`,
+	},
+	{
+		Version: 326,
+		Table:   "device_authorizations",
+		Desc:    "add expires index to device_authorizations for cleanup",
+		Up:      `CREATE INDEX device_authorizations_expires_idx ON device_authorizations (expires);`,
+		Down:    `DROP INDEX device_authorizations_expires_idx;`,
+	},
 }
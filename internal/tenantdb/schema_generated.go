@@ -136,6 +136,15 @@ var schemas = []string{
     oidc_sub character varying NOT NULL,
     deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
     oidc_issuer_url character varying DEFAULT ''::character varying NOT NULL
+);`,
+	`CREATE TABLE public.client_assertions (
+    id uuid NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    client_id character varying NOT NULL,
+    jti character varying NOT NULL,
+    expires timestamp without time zone NOT NULL
 );`,
 	`CREATE TABLE public.column_value_retention_durations (
     id uuid NOT NULL,
@@ -220,6 +229,64 @@ var schemas = []string{
     updated timestamp without time zone NOT NULL,
     deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
     authenticated_user_id character varying NOT NULL
+);`,
+	`CREATE TABLE public.device_authorizations (
+    id uuid NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    client_id character varying NOT NULL,
+    device_code character varying NOT NULL,
+    user_code character varying NOT NULL,
+    scopes character varying NOT NULL,
+    expires timestamp without time zone NOT NULL,
+    poll_interval bigint NOT NULL,
+    last_polled timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    status bigint NOT NULL,
+    session_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
+    plex_token_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL
+);`,
+	`CREATE TABLE public.dsar_exports (
+    id uuid NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    user_id uuid NOT NULL,
+    status character varying NOT NULL,
+    error character varying DEFAULT ''::character varying NOT NULL,
+    bundle character varying DEFAULT ''::character varying NOT NULL,
+    signature character varying DEFAULT ''::character varying NOT NULL,
+    expires_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+);`,
+	`CREATE TABLE public.edge_history (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    edge_id uuid NOT NULL,
+    action character varying NOT NULL,
+    source character varying DEFAULT ''::character varying NOT NULL,
+    actor character varying DEFAULT ''::character varying NOT NULL,
+    edge_type_id uuid NOT NULL,
+    source_object_id uuid NOT NULL,
+    target_object_id uuid NOT NULL,
+    valid_from timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    valid_until timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+);`,
+	`CREATE TABLE public.edge_type_history (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    edge_type_id uuid NOT NULL,
+    action character varying NOT NULL,
+    source character varying DEFAULT ''::character varying NOT NULL,
+    actor character varying DEFAULT ''::character varying NOT NULL,
+    type_name character varying NOT NULL,
+    source_object_type_id uuid NOT NULL,
+    target_object_type_id uuid NOT NULL,
+    attributes jsonb DEFAULT '[]'::jsonb NOT NULL,
+    organization_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL
 );`,
 	`CREATE TABLE public.edge_types (
     id uuid NOT NULL,
@@ -239,7 +306,9 @@ var schemas = []string{
     edge_type_id uuid NOT NULL,
     source_object_id uuid NOT NULL,
     target_object_id uuid NOT NULL,
-    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    valid_from timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    valid_until timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
 );`,
 	`CREATE TABLE public.idp_data_import_jobs (
     id uuid NOT NULL,
@@ -420,7 +489,8 @@ var schemas = []string{
     scopes character varying NOT NULL,
     session_id uuid NOT NULL,
     underlying_token character varying DEFAULT ''::character varying NOT NULL,
-    refresh_token character varying DEFAULT ''::character varying NOT NULL
+    refresh_token character varying DEFAULT ''::character varying NOT NULL,
+    subject_token_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL
 );`,
 	`CREATE TABLE public.policy_secrets (
     id uuid NOT NULL,
@@ -472,7 +542,12 @@ var schemas = []string{
     access_key_id character varying NOT NULL,
     secret_access_key character varying NOT NULL,
     access_policy_id uuid NOT NULL,
-    role_arn character varying DEFAULT ''::character varying NOT NULL
+    role_arn character varying DEFAULT ''::character varying NOT NULL,
+    field_mappings jsonb DEFAULT '[]'::jsonb NOT NULL,
+    tokenize_on_write boolean DEFAULT false NOT NULL,
+    write_access_policy_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
+    export_bucket character varying DEFAULT ''::character varying NOT NULL,
+    export_prefix character varying DEFAULT ''::character varying NOT NULL
 );`,
 	`CREATE TABLE public.sqlshim_databases (
     id uuid NOT NULL,
@@ -500,7 +575,8 @@ var schemas = []string{
     data character varying NOT NULL,
     user_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
     column_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
-    transformer_version integer DEFAULT 0 NOT NULL
+    transformer_version integer DEFAULT 0 NOT NULL,
+    expires_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
 );`,
 	`CREATE TABLE public.transformers (
     id uuid NOT NULL,
@@ -567,6 +643,18 @@ var schemas = []string{
     consented_purpose_ids uuid[] NOT NULL,
     retention_timeouts character varying[] NOT NULL,
     value_type bigint DEFAULT 0 NOT NULL
+);`,
+	`CREATE TABLE public.user_erasures (
+    id uuid NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    deleted timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    user_id uuid NOT NULL,
+    status character varying NOT NULL,
+    systems jsonb DEFAULT '[]'::jsonb NOT NULL,
+    user_event_aliases character varying[] DEFAULT '{}'::character varying[] NOT NULL,
+    certificate character varying DEFAULT ''::character varying NOT NULL,
+    completed_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
 );`,
 	`CREATE TABLE public.user_mfa_configuration (
     id uuid NOT NULL,
@@ -631,6 +719,10 @@ var schemas = []string{
     ADD CONSTRAINT authns_social_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.authns_social
     ADD CONSTRAINT authns_social_type_oidc_issuer_url_oidc_sub_deleted_key UNIQUE (type, oidc_issuer_url, oidc_sub, deleted);`,
+	`ALTER TABLE ONLY public.client_assertions
+    ADD CONSTRAINT client_assertions_client_id_jti_deleted_key UNIQUE (client_id, jti, deleted);`,
+	`ALTER TABLE ONLY public.client_assertions
+    ADD CONSTRAINT client_assertions_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.column_value_retention_durations
     ADD CONSTRAINT column_value_retention_durati_deleted_duration_type_purpose_key UNIQUE (deleted, duration_type, purpose_id, column_id);`,
 	`ALTER TABLE ONLY public.column_value_retention_durations
@@ -653,6 +745,16 @@ var schemas = []string{
     ADD CONSTRAINT delegation_invites_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.delegation_states
     ADD CONSTRAINT delegation_states_pkey PRIMARY KEY (deleted, id);`,
+	`ALTER TABLE ONLY public.device_authorizations
+    ADD CONSTRAINT device_authorizations_device_code_deleted_key UNIQUE (device_code, deleted);`,
+	`ALTER TABLE ONLY public.device_authorizations
+    ADD CONSTRAINT device_authorizations_pkey PRIMARY KEY (deleted, id);`,
+	`ALTER TABLE ONLY public.dsar_exports
+    ADD CONSTRAINT dsar_exports_pkey PRIMARY KEY (deleted, id);`,
+	`ALTER TABLE ONLY public.edge_history
+    ADD CONSTRAINT edge_history_pkey PRIMARY KEY (deleted, id);`,
+	`ALTER TABLE ONLY public.edge_type_history
+    ADD CONSTRAINT edge_type_history_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.edge_types
     ADD CONSTRAINT edge_types_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.edge_types
@@ -739,6 +841,8 @@ var schemas = []string{
     ADD CONSTRAINT user_column_pre_delete_values_column_id_varchar_unique_valu_key UNIQUE (column_id, varchar_unique_value);`,
 	`ALTER TABLE ONLY public.user_column_pre_delete_values
     ADD CONSTRAINT user_column_pre_delete_values_pk PRIMARY KEY (id);`,
+	`ALTER TABLE ONLY public.user_erasures
+    ADD CONSTRAINT user_erasures_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.user_mfa_configuration
     ADD CONSTRAINT user_mfa_configuration_pkey PRIMARY KEY (deleted, id);`,
 	`ALTER TABLE ONLY public.user_search_indices
@@ -749,8 +853,15 @@ var schemas = []string{
     ADD CONSTRAINT users_pkey PRIMARY KEY (deleted, id);`,
 	`CREATE INDEX authns_password_user_id_idx ON public.authns_password USING btree (user_id);`,
 	`CREATE INDEX authns_social_user_id_idx ON public.authns_social USING btree (user_id);`,
+	`CREATE INDEX device_authorizations_user_code_idx ON public.device_authorizations USING btree (user_code, status);`,
+	`CREATE INDEX dsar_exports_user_id_idx ON public.dsar_exports USING btree (user_id);`,
+	`CREATE INDEX edge_history_edge_id_created_idx ON public.edge_history USING btree (edge_id, created);`,
+	`CREATE INDEX edge_type_history_edge_type_id_created_idx ON public.edge_type_history USING btree (edge_type_id, created);`,
 	`CREATE INDEX edges_target_object_id_idx ON public.edges USING btree (target_object_id);`,
-	`CREATE INDEX edges_updated_time ON public.edges USING btree (updated) INCLUDE (created, edge_type_id, source_object_id, target_object_id);`,
+	`CREATE INDEX edges_updated_time ON public.edges USING btree (updated) INCLUDE (created, edge_type_id, source_object_id, target_object_id, valid_from, valid_until);`,
+	`CREATE INDEX edges_valid_until_idx ON public.edges USING btree (valid_until);`,
+	`CREATE INDEX token_records_expires_at_idx ON public.token_records USING btree (expires_at);`,
+	`CREATE INDEX token_records_user_id_idx ON public.token_records USING btree (user_id);`,
 	`CREATE INDEX idp_sync_runs_active_provider_id_deleted_idx ON public.idp_sync_runs USING btree (active_provider_id, deleted);`,
 	`CREATE INDEX user_column_post_delete_values_boolean ON public.user_column_post_delete_values USING btree (column_id, user_id, boolean_value);`,
 	`CREATE INDEX user_column_post_delete_values_int ON public.user_column_post_delete_values USING btree (column_id, user_id, int_value);`,
@@ -773,4 +884,7 @@ var schemas = []string{
 	`CREATE INDEX user_column_pre_delete_values_column_varchar_trgm ON public.user_column_pre_delete_values USING gin (column_id, varchar_value public.gin_trgm_ops);`,
 	`CREATE INDEX user_column_pre_delete_values_column_varchar_unique_trgm ON public.user_column_pre_delete_values USING gin (column_id, varchar_unique_value public.gin_trgm_ops);`,
 	`CREATE INDEX user_column_pre_delete_values_user_id_idx ON public.user_column_pre_delete_values USING btree (user_id);`,
+	`CREATE INDEX user_erasures_user_id_idx ON public.user_erasures USING btree (user_id);`,
+	`CREATE INDEX client_assertions_expires_idx ON public.client_assertions USING btree (expires);`,
+	`CREATE INDEX device_authorizations_expires_idx ON public.device_authorizations USING btree (expires);`,
 }
@@ -20,6 +20,9 @@ func (o App) Validate() error {
 	if err := o.ClientSecret.Validate(); err != nil {
 		return ucerr.Wrap(err)
 	}
+	if err := o.TokenEndpointAuthMethod.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
 	if err := o.TokenValidity.Validate(); err != nil {
 		return ucerr.Wrap(err)
 	}
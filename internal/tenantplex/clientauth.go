@@ -0,0 +1,115 @@
+package tenantplex
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"gopkg.in/square/go-jose.v2"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// TokenEndpointAuthMethod is how an app authenticates itself to the token endpoint, per
+// https://www.rfc-editor.org/rfc/rfc7591#section-2
+type TokenEndpointAuthMethod string
+
+// TokenEndpointAuthMethod constants
+const (
+	// TokenEndpointAuthMethodDefault lets an app send its client secret either via basic auth or in
+	// the request body, which is how plex apps have always authenticated
+	TokenEndpointAuthMethodDefault TokenEndpointAuthMethod = ""
+
+	TokenEndpointAuthMethodClientSecretBasic TokenEndpointAuthMethod = "client_secret_basic"
+	TokenEndpointAuthMethodClientSecretPost  TokenEndpointAuthMethod = "client_secret_post"
+
+	// TokenEndpointAuthMethodPrivateKeyJWT apps authenticate with a JWT signed by one of their
+	// registered keys (https://www.rfc-editor.org/rfc/rfc7523#section-2.2)
+	TokenEndpointAuthMethodPrivateKeyJWT TokenEndpointAuthMethod = "private_key_jwt"
+
+	// TokenEndpointAuthMethodTLSClientAuth apps authenticate with a CA-issued TLS client certificate
+	// that has the registered subject DN (https://www.rfc-editor.org/rfc/rfc8705#section-2.1)
+	TokenEndpointAuthMethodTLSClientAuth TokenEndpointAuthMethod = "tls_client_auth"
+
+	// TokenEndpointAuthMethodSelfSignedTLSClientAuth apps authenticate with a TLS client certificate
+	// whose public key is one of their registered keys (https://www.rfc-editor.org/rfc/rfc8705#section-2.2)
+	TokenEndpointAuthMethodSelfSignedTLSClientAuth TokenEndpointAuthMethod = "self_signed_tls_client_auth"
+)
+
+//go:generate genconstant TokenEndpointAuthMethod
+
+// SupportedTokenEndpointAuthMethods is the list of token endpoint auth methods we advertise in discovery
+var SupportedTokenEndpointAuthMethods = []TokenEndpointAuthMethod{
+	TokenEndpointAuthMethodClientSecretBasic,
+	TokenEndpointAuthMethodClientSecretPost,
+	TokenEndpointAuthMethodPrivateKeyJWT,
+	TokenEndpointAuthMethodTLSClientAuth,
+	TokenEndpointAuthMethodSelfSignedTLSClientAuth,
+}
+
+// UsesClientSecret returns true if the app authenticates with its shared client secret
+func (app App) UsesClientSecret() bool {
+	switch app.TokenEndpointAuthMethod {
+	case TokenEndpointAuthMethodDefault,
+		TokenEndpointAuthMethodClientSecretBasic,
+		TokenEndpointAuthMethodClientSecretPost:
+		return true
+	}
+	return false
+}
+
+// usesJWKS returns true if the app authenticates with one of its registered public keys
+func (app App) usesJWKS() bool {
+	return app.TokenEndpointAuthMethod == TokenEndpointAuthMethodPrivateKeyJWT ||
+		app.TokenEndpointAuthMethod == TokenEndpointAuthMethodSelfSignedTLSClientAuth
+}
+
+// GetJWKS returns the app's inline JWK set, or nil if the app registered a JWKS URI instead
+func (app App) GetJWKS() (*jose.JSONWebKeySet, error) {
+	if app.JWKS == "" {
+		return nil, nil
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(app.JWKS), &jwks); err != nil {
+		return nil, ucerr.Friendlyf(err, "app '%s' has an invalid JWKS", app.Name)
+	}
+	return &jwks, nil
+}
+
+func (app App) validateClientAuth() error {
+	if app.JWKS != "" && app.JWKSURI != "" {
+		return ucerr.Friendlyf(nil, "app '%s' can't specify both a JWKS and a JWKS URI", app.Name)
+	}
+
+	jwks, err := app.GetJWKS()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if jwks != nil {
+		for _, k := range jwks.Keys {
+			if !k.IsPublic() {
+				return ucerr.Friendlyf(nil, "app '%s' JWKS key '%s' must be a public key", app.Name, k.KeyID)
+			}
+		}
+	}
+
+	if app.JWKSURI != "" {
+		u, err := url.Parse(app.JWKSURI)
+		if err != nil {
+			return ucerr.Friendlyf(err, "failed to parse JWKS URI '%s' in app '%s'", app.JWKSURI, app.Name)
+		}
+		if u.Scheme != "https" {
+			return ucerr.Friendlyf(nil, "JWKS URI '%s' in app '%s' must use https", app.JWKSURI, app.Name)
+		}
+	}
+
+	if app.usesJWKS() && app.JWKS == "" && app.JWKSURI == "" {
+		return ucerr.Friendlyf(nil, "app '%s' must specify a JWKS or JWKS URI to use %s", app.Name, app.TokenEndpointAuthMethod)
+	}
+
+	if app.TokenEndpointAuthMethod == TokenEndpointAuthMethodTLSClientAuth && app.TLSClientAuthSubjectDN == "" {
+		return ucerr.Friendlyf(nil, "app '%s' must specify a TLS client auth subject DN to use %s", app.Name, app.TokenEndpointAuthMethod)
+	}
+
+	return nil
+}
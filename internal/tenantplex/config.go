@@ -63,9 +63,16 @@ type TenantConfig struct {
 	// If signups are disabled, this is the email address that will be used to create the first account
 	BootstrapAccountEmails []string `yaml:"bootstrap_account_emails" json:"bootstrap_account_emails,omitempty"`
 
+	// Keys is the active key, which is used to sign all new tokens
 	Keys Keys `yaml:"keys,omitempty" json:"keys"`
 
+	// KeySet holds the tenant's other signing keys, which are published alongside the active key
+	// while they are next or retiring
+	KeySet SigningKeys `yaml:"key_set,omitempty" json:"key_set,omitempty"`
+
 	PageParameters pageparams.ParameterByNameByPageType `yaml:"page_parameters" json:"page_parameters"`
+
+	SCIM SCIMConfig `yaml:"scim,omitempty" json:"scim"`
 }
 
 //go:generate gendbjson TenantConfig
@@ -157,6 +164,10 @@ func (tenant *TenantConfig) applyReadOnlySettings(source TenantConfig) {
 
 	// apply plex map read-only settings
 	tenant.PlexMap.applyReadOnlySettings(source.PlexMap)
+
+	// signing keys can only be changed by rotating or revoking them
+	tenant.Keys = source.Keys
+	tenant.KeySet = source.KeySet
 }
 
 // UpdateUISettings updates the tenant config for the UI, filtering out read-only
@@ -179,6 +190,9 @@ func (tenant *TenantConfig) filterReadOnlySettings() {
 
 	// filter plex map read-only settings
 	tenant.PlexMap.filterReadOnlySettings()
+
+	// clear the signing keys that are not active
+	tenant.KeySet = nil
 }
 
 func (tenant *TenantConfig) decodeSecrets(ctx context.Context) error {
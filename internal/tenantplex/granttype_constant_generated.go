@@ -21,6 +21,8 @@ func (t GrantType) MarshalText() ([]byte, error) {
 		return []byte("password"), nil
 	case GrantTypeRefreshToken:
 		return []byte("refresh_token"), nil
+	case GrantTypeTokenExchange:
+		return []byte("urn:ietf:params:oauth:grant-type:token-exchange"), nil
 	case GrantTypeUnknown:
 		return []byte(""), nil
 	default:
@@ -46,6 +48,8 @@ func (t *GrantType) UnmarshalText(b []byte) error {
 		*t = GrantTypePassword
 	case "refresh_token":
 		*t = GrantTypeRefreshToken
+	case "urn:ietf:params:oauth:grant-type:token-exchange":
+		*t = GrantTypeTokenExchange
 	case "":
 		*t = GrantTypeUnknown
 	default:
@@ -71,6 +75,8 @@ func (t *GrantType) Validate() error {
 		return nil
 	case GrantTypeRefreshToken:
 		return nil
+	case GrantTypeTokenExchange:
+		return nil
 	default:
 		return ucerr.Friendlyf(nil, "unknown GrantType value '%s'", *t)
 	}
@@ -86,6 +92,7 @@ func (t GrantType) Enum() []any {
 		"mfa",
 		"password",
 		"refresh_token",
+		"urn:ietf:params:oauth:grant-type:token-exchange",
 	}
 }
 
@@ -98,4 +105,5 @@ var AllGrantTypes = []GrantType{
 	GrantTypeMFA,
 	GrantTypePassword,
 	GrantTypeRefreshToken,
+	GrantTypeTokenExchange,
 }
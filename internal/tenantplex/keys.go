@@ -1,7 +1,12 @@
 package tenantplex
 
 import (
+	"crypto/rsa"
+	"time"
+
 	"userclouds.com/infra/secret"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/ucjwt"
 )
 
 // Keys handles JWK keys
@@ -15,3 +20,227 @@ type Keys struct {
 }
 
 //go:generate genvalidate Keys
+
+// KeyState is the rotation state of a tenant signing key
+type KeyState string
+
+// KeyState constants
+const (
+	// KeyStateNext keys are published but not yet used for signing, so that relying parties
+	// can pick them up before they become active
+	KeyStateNext KeyState = "next"
+
+	// KeyStateActive is the single key used to sign new tokens
+	KeyStateActive KeyState = "active"
+
+	// KeyStateRetiring keys are no longer used for signing, but are still published until
+	// the tokens they signed have expired
+	KeyStateRetiring KeyState = "retiring"
+
+	// KeyStateRevoked keys are no longer published, so tokens they signed are no longer honored
+	KeyStateRevoked KeyState = "revoked"
+)
+
+//go:generate genconstant KeyState
+
+// SigningKey is a tenant signing key that is not the active key, along with its rotation state
+type SigningKey struct {
+	Keys    Keys      `yaml:"keys" json:"keys"`
+	State   KeyState  `yaml:"state" json:"state"`
+	Created time.Time `yaml:"created" json:"created"`
+
+	// ActivateAt is when a next key will become the active key
+	ActivateAt time.Time `yaml:"activate_at,omitempty" json:"activate_at,omitempty"`
+
+	// RetireAt is when a retiring key will be removed from the key set
+	RetireAt time.Time `yaml:"retire_at,omitempty" json:"retire_at,omitempty"`
+
+	// RevokedAt is when a key was revoked
+	RevokedAt time.Time `yaml:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+//go:generate genvalidate SigningKey
+
+// SigningKeys is the set of a tenant's signing keys other than its active key
+type SigningKeys []SigningKey
+
+// Validate implements Validateable
+func (sk SigningKeys) Validate() error {
+	keyIDs := map[string]bool{}
+	numNext := 0
+	for _, k := range sk {
+		if err := k.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		if keyIDs[k.Keys.KeyID] {
+			return ucerr.Friendlyf(nil, "key ID '%s' is used by more than one signing key", k.Keys.KeyID)
+		}
+		keyIDs[k.Keys.KeyID] = true
+
+		switch k.State {
+		case KeyStateActive:
+			return ucerr.Friendlyf(nil, "signing key '%s' can't be active, since the active key is stored separately", k.Keys.KeyID)
+		case KeyStateNext:
+			numNext++
+		}
+	}
+	if numNext > 1 {
+		return ucerr.Friendlyf(nil, "only one signing key can be next")
+	}
+	return nil
+}
+
+// GetPublishedKeys returns the keys that tokens are honored from, which are the active key followed by
+// any next and retiring keys
+func (tenant *TenantConfig) GetPublishedKeys() []Keys {
+	keys := []Keys{tenant.Keys}
+	for _, k := range tenant.KeySet {
+		if k.State == KeyStateNext || k.State == KeyStateRetiring {
+			keys = append(keys, k.Keys)
+		}
+	}
+	return keys
+}
+
+// GetPublicKey returns the published public key with the specified key ID, or the active key if
+// the key ID is empty
+func (tenant *TenantConfig) GetPublicKey(keyID string) (*rsa.PublicKey, error) {
+	if keyID == "" {
+		keyID = tenant.Keys.KeyID
+	}
+	for _, k := range tenant.GetPublishedKeys() {
+		if k.KeyID == keyID {
+			pk, err := ucjwt.LoadRSAPublicKey([]byte(k.PublicKey))
+			if err != nil {
+				return nil, ucerr.Wrap(err)
+			}
+			return pk, nil
+		}
+	}
+	return nil, ucerr.Errorf("key ID '%s' is not a published signing key", keyID)
+}
+
+// GetNextKey returns the key that is scheduled to become active, if any
+func (tenant *TenantConfig) GetNextKey() *SigningKey {
+	for i := range tenant.KeySet {
+		if tenant.KeySet[i].State == KeyStateNext {
+			return &tenant.KeySet[i]
+		}
+	}
+	return nil
+}
+
+// GetKeyRetirementPeriod returns how long a key must be published after it stops being used for
+// signing, which is the longest validity of any token an app can be issued
+func (tenant *TenantConfig) GetKeyRetirementPeriod() time.Duration {
+	var maxValidity int64
+	apps := tenant.PlexMap.Apps
+	if tenant.PlexMap.EmployeeApp != nil {
+		apps = append(apps[:len(apps):len(apps)], *tenant.PlexMap.EmployeeApp)
+	}
+	for _, app := range apps {
+		maxValidity = max(maxValidity, app.TokenValidity.Access, app.TokenValidity.Refresh, app.TokenValidity.ImpersonateUser)
+	}
+	return time.Duration(maxValidity) * time.Second
+}
+
+// ScheduleKeyRotation adds a next key that will become the active key at activateAt. The key is
+// published immediately so that relying parties can pick it up before it is used for signing.
+func (tenant *TenantConfig) ScheduleKeyRotation(keys Keys, now time.Time, activateAt time.Time) error {
+	if tenant.GetNextKey() != nil {
+		return ucerr.Friendlyf(nil, "a key rotation is already scheduled")
+	}
+
+	tenant.KeySet = append(tenant.KeySet, SigningKey{
+		Keys:       keys,
+		State:      KeyStateNext,
+		Created:    now,
+		ActivateAt: activateAt,
+	})
+	return nil
+}
+
+// AdvanceKeyRotation activates the next key if it is due, and removes retiring keys whose
+// tokens have all expired. It returns whether the key set changed.
+func (tenant *TenantConfig) AdvanceKeyRotation(now time.Time) bool {
+	changed := false
+
+	if next := tenant.GetNextKey(); next != nil && !next.ActivateAt.After(now) {
+		tenant.activateNextKey(now)
+		changed = true
+	}
+
+	keySet := SigningKeys{}
+	for _, k := range tenant.KeySet {
+		if k.State == KeyStateRetiring && !k.RetireAt.After(now) {
+			changed = true
+			continue
+		}
+		keySet = append(keySet, k)
+	}
+	tenant.KeySet = keySet
+
+	return changed
+}
+
+// activateNextKey makes the next key the active key, and moves the previously active key to retiring
+func (tenant *TenantConfig) activateNextKey(now time.Time) {
+	keySet := SigningKeys{}
+	for _, k := range tenant.KeySet {
+		if k.State != KeyStateNext {
+			keySet = append(keySet, k)
+			continue
+		}
+
+		keySet = append(keySet, SigningKey{
+			Keys:     tenant.Keys,
+			State:    KeyStateRetiring,
+			RetireAt: now.Add(tenant.GetKeyRetirementPeriod()),
+		})
+		tenant.Keys = k.Keys
+	}
+	tenant.KeySet = keySet
+}
+
+// RevokeKey revokes a published key so that tokens it signed are no longer honored. If the active
+// key is revoked, the next key is activated immediately, or if there is no next key, the key
+// returned by newKeys becomes the active key.
+func (tenant *TenantConfig) RevokeKey(keyID string, now time.Time, newKeys func() (*Keys, error)) error {
+	if keyID == tenant.Keys.KeyID {
+		if tenant.GetNextKey() == nil {
+			keys, err := newKeys()
+			if err != nil {
+				return ucerr.Wrap(err)
+			}
+			if err := tenant.ScheduleKeyRotation(*keys, now, now); err != nil {
+				return ucerr.Wrap(err)
+			}
+		}
+		tenant.activateNextKey(now)
+
+		// the previously active key was just moved to retiring, but it must not be published
+		for i := range tenant.KeySet {
+			if tenant.KeySet[i].Keys.KeyID == keyID {
+				tenant.KeySet[i].State = KeyStateRevoked
+				tenant.KeySet[i].RetireAt = time.Time{}
+				tenant.KeySet[i].RevokedAt = now
+			}
+		}
+		return nil
+	}
+
+	for i := range tenant.KeySet {
+		if tenant.KeySet[i].Keys.KeyID != keyID {
+			continue
+		}
+		if tenant.KeySet[i].State == KeyStateRevoked {
+			return ucerr.Friendlyf(nil, "key '%s' is already revoked", keyID)
+		}
+		tenant.KeySet[i].State = KeyStateRevoked
+		tenant.KeySet[i].RevokedAt = now
+		return nil
+	}
+
+	return ucerr.Friendlyf(nil, "key '%s' is not one of the tenant's signing keys", keyID)
+}
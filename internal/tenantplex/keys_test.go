@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"userclouds.com/infra/assert"
 )
@@ -28,3 +29,59 @@ func TestSecretKey(t *testing.T) {
 	assert.Equal(t, s, "private")
 	assert.Equal(t, k.PublicKey, "public")
 }
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now().UTC()
+	tc := TenantConfig{
+		Keys: Keys{KeyID: "first"},
+		PlexMap: PlexMap{
+			Apps: []App{{TokenValidity: TokenValidity{Access: 60, Refresh: 3600, ImpersonateUser: 60}}},
+		},
+	}
+
+	assert.NoErr(t, tc.ScheduleKeyRotation(Keys{KeyID: "second", PublicKey: "public"}, now, now.Add(time.Hour)))
+	assert.NotNil(t, tc.ScheduleKeyRotation(Keys{KeyID: "third"}, now, now.Add(time.Hour)))
+	assert.NoErr(t, tc.KeySet.Validate())
+	assert.Equal(t, len(tc.GetPublishedKeys()), 2)
+
+	// the next key isn't due yet
+	assert.False(t, tc.AdvanceKeyRotation(now))
+	assert.Equal(t, tc.Keys.KeyID, "first")
+
+	// once it is due, it becomes active and the previous key retires after the longest token validity
+	assert.True(t, tc.AdvanceKeyRotation(now.Add(time.Hour)))
+	assert.Equal(t, tc.Keys.KeyID, "second")
+	assert.Equal(t, len(tc.KeySet), 1)
+	assert.Equal(t, tc.KeySet[0].Keys.KeyID, "first")
+	assert.Equal(t, tc.KeySet[0].State, KeyStateRetiring)
+	assert.Equal(t, tc.KeySet[0].RetireAt, now.Add(2*time.Hour))
+	assert.Equal(t, len(tc.GetPublishedKeys()), 2)
+
+	assert.False(t, tc.AdvanceKeyRotation(now.Add(time.Hour+time.Minute)))
+	assert.True(t, tc.AdvanceKeyRotation(now.Add(2*time.Hour)))
+	assert.Equal(t, len(tc.KeySet), 0)
+	assert.Equal(t, len(tc.GetPublishedKeys()), 1)
+}
+
+func TestKeyRevocation(t *testing.T) {
+	now := time.Now().UTC()
+	tc := TenantConfig{Keys: Keys{KeyID: "first"}}
+	newKeys := func() (*Keys, error) {
+		return &Keys{KeyID: "replacement"}, nil
+	}
+
+	// revoking the active key with no next key activates a replacement
+	assert.NoErr(t, tc.RevokeKey("first", now, newKeys))
+	assert.Equal(t, tc.Keys.KeyID, "replacement")
+	assert.Equal(t, len(tc.KeySet), 1)
+	assert.Equal(t, tc.KeySet[0].State, KeyStateRevoked)
+	assert.Equal(t, len(tc.GetPublishedKeys()), 1)
+	assert.NotNil(t, tc.RevokeKey("first", now, newKeys))
+
+	// revoking a next key stops publishing it, and revoking the active key then needs a new key
+	assert.NoErr(t, tc.ScheduleKeyRotation(Keys{KeyID: "next"}, now, now.Add(time.Hour)))
+	assert.NoErr(t, tc.RevokeKey("next", now, newKeys))
+	assert.IsNil(t, tc.GetNextKey())
+	assert.Equal(t, len(tc.GetPublishedKeys()), 1)
+	assert.NotNil(t, tc.RevokeKey("unknown", now, newKeys))
+}
@@ -0,0 +1,73 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t KeyState) MarshalText() ([]byte, error) {
+	switch t {
+	case KeyStateActive:
+		return []byte("active"), nil
+	case KeyStateNext:
+		return []byte("next"), nil
+	case KeyStateRetiring:
+		return []byte("retiring"), nil
+	case KeyStateRevoked:
+		return []byte("revoked"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown KeyState value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *KeyState) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "active":
+		*t = KeyStateActive
+	case "next":
+		*t = KeyStateNext
+	case "retiring":
+		*t = KeyStateRetiring
+	case "revoked":
+		*t = KeyStateRevoked
+	default:
+		return ucerr.Friendlyf(nil, "unknown KeyState value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *KeyState) Validate() error {
+	switch *t {
+	case KeyStateActive:
+		return nil
+	case KeyStateNext:
+		return nil
+	case KeyStateRetiring:
+		return nil
+	case KeyStateRevoked:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown KeyState value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t KeyState) Enum() []any {
+	return []any{
+		"active",
+		"next",
+		"retiring",
+		"revoked",
+	}
+}
+
+// AllKeyStates is a slice of all KeyState values
+var AllKeyStates = []KeyState{
+	KeyStateActive,
+	KeyStateNext,
+	KeyStateRetiring,
+	KeyStateRevoked,
+}
@@ -0,0 +1,197 @@
+package tenantplex
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"strings"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/crypto"
+	"userclouds.com/infra/ldap"
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/ucerr"
+)
+
+// LDAPUsernamePlaceholder is replaced by the (escaped) username in LDAPProvider.UserFilter
+const LDAPUsernamePlaceholder = "{username}"
+
+// LDAPAttributeMapping maps an LDAP attribute (eg. "mail" or "displayName") onto a token claim
+type LDAPAttributeMapping struct {
+	Claim     string `yaml:"claim" json:"claim" validate:"notempty"`
+	Attribute string `yaml:"attribute" json:"attribute" validate:"notempty"`
+}
+
+//go:generate genvalidate LDAPAttributeMapping
+
+// LDAPGroupMapping grants users who are members of an LDAP group an authz edge to an object
+// (eg. a group or organization), which is added or removed on each login to keep it in sync
+type LDAPGroupMapping struct {
+	GroupDN    string    `yaml:"group_dn" json:"group_dn" validate:"notempty"`
+	ObjectID   uuid.UUID `yaml:"object_id" json:"object_id" validate:"notnil"`
+	EdgeTypeID uuid.UUID `yaml:"edge_type_id" json:"edge_type_id" validate:"notnil"`
+}
+
+//go:generate genvalidate LDAPGroupMapping
+
+// LDAPApp defines an LDAP login app to map to plex apps. LDAP has no concept of apps, so this
+// only exists so that plex apps can refer to the provider like any other.
+type LDAPApp struct {
+	ID   uuid.UUID `yaml:"id" json:"id" validate:"notnil"`
+	Name string    `yaml:"name" json:"name" validate:"notempty"`
+}
+
+//go:generate genvalidate LDAPApp
+
+// LDAPProvider defines config for an LDAP directory (eg. on-prem Active Directory) that users
+// log in to with a username and password
+type LDAPProvider struct {
+	// URL is the ldaps:// URL of the directory server, or its ldap:// URL if StartTLS is set
+	URL string `yaml:"url" json:"url" validate:"notempty"`
+
+	// StartTLS upgrades ldap:// connections to TLS before binding, since we never send passwords in the clear
+	StartTLS bool `yaml:"start_tls,omitempty" json:"start_tls,omitempty"`
+
+	// RootCAs optionally contains PEM certificates to trust, for directories using a private CA
+	RootCAs string `yaml:"root_cas,omitempty" json:"root_cas,omitempty"`
+
+	// BindDN and BindPassword are the service account used to look up users
+	BindDN       string        `yaml:"bind_dn" json:"bind_dn" validate:"notempty"`
+	BindPassword secret.String `yaml:"bind_password" json:"bind_password"`
+
+	// BaseDN is the subtree searched for users
+	BaseDN string `yaml:"base_dn" json:"base_dn" validate:"notempty"`
+
+	// UserFilter finds a user by username, eg. "(&(objectClass=user)(sAMAccountName={username}))"
+	UserFilter string `yaml:"user_filter" json:"user_filter" validate:"notempty"`
+
+	// IDAttribute is the immutable attribute used as the user ID, usually "objectGUID" for Active Directory
+	// or "entryUUID" for OpenLDAP. Values that aren't UUIDs are mapped onto a stable UUID.
+	IDAttribute string `yaml:"id_attribute" json:"id_attribute" validate:"notempty"`
+
+	// AttributeMappings describes how LDAP attributes become token claims; if empty, a default
+	// mapping from the standard mail, displayName and cn attributes is used
+	AttributeMappings []LDAPAttributeMapping `yaml:"attribute_mappings,omitempty" json:"attribute_mappings,omitempty"`
+
+	// GroupAttribute is the user attribute listing the DNs of the groups they are a member of,
+	// defaulting to "memberOf"
+	GroupAttribute string             `yaml:"group_attribute,omitempty" json:"group_attribute,omitempty"`
+	GroupMappings  []LDAPGroupMapping `yaml:"group_mappings,omitempty" json:"group_mappings,omitempty"`
+
+	Apps []LDAPApp `yaml:"apps,omitempty" json:"apps"`
+}
+
+//go:generate genvalidate LDAPProvider
+
+// DefaultLDAPAttributeMappings are used if an LDAPProvider doesn't specify any
+var DefaultLDAPAttributeMappings = []LDAPAttributeMapping{
+	{Claim: "email", Attribute: "mail"},
+	{Claim: "name", Attribute: "displayName"},
+	{Claim: "nickname", Attribute: "cn"},
+}
+
+// GetAttributeMappings returns the configured attribute mappings, or the defaults
+func (p LDAPProvider) GetAttributeMappings() []LDAPAttributeMapping {
+	if len(p.AttributeMappings) > 0 {
+		return p.AttributeMappings
+	}
+	return DefaultLDAPAttributeMappings
+}
+
+// GetGroupAttribute returns the attribute listing a user's groups
+func (p LDAPProvider) GetGroupAttribute() string {
+	if p.GroupAttribute != "" {
+		return p.GroupAttribute
+	}
+	return "memberOf"
+}
+
+// GetUserFilter returns the filter to find a user by username
+func (p LDAPProvider) GetUserFilter(username string) string {
+	return strings.ReplaceAll(p.UserFilter, LDAPUsernamePlaceholder, ldap.EscapeFilter(username))
+}
+
+// GetRootCAs returns the pool of additional CAs to trust, or nil to use the system pool
+func (p LDAPProvider) GetRootCAs() (*x509.CertPool, error) {
+	if p.RootCAs == "" {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(p.RootCAs)) {
+		return nil, ucerr.Friendlyf(nil, "LDAPProvider.RootCAs doesn't contain any valid PEM certificates")
+	}
+	return pool, nil
+}
+
+func (p LDAPProvider) extraValidate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return ucerr.Friendlyf(err, "LDAPProvider.URL '%s' is invalid", p.URL)
+	}
+	switch u.Scheme {
+	case "ldaps":
+		if p.StartTLS {
+			return ucerr.Friendlyf(nil, "LDAPProvider.StartTLS can't be used with an ldaps:// URL")
+		}
+	case "ldap":
+		// binds send the password in the clear, so plain ldap:// is only OK if we upgrade it to TLS first
+		if !p.StartTLS {
+			return ucerr.Friendlyf(nil, "LDAPProvider.URL '%s' must use ldaps://, or set LDAPProvider.StartTLS", p.URL)
+		}
+	default:
+		return ucerr.Friendlyf(nil, "LDAPProvider.URL must be an ldaps:// or ldap:// URL, got '%s'", p.URL)
+	}
+
+	if _, err := p.GetRootCAs(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if !strings.Contains(p.UserFilter, LDAPUsernamePlaceholder) {
+		return ucerr.Friendlyf(nil, "LDAPProvider.UserFilter must contain %s", LDAPUsernamePlaceholder)
+	}
+	if err := ldap.ValidateFilter(p.GetUserFilter("username")); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	claims := map[string]bool{}
+	for _, m := range p.AttributeMappings {
+		if claims[m.Claim] {
+			return ucerr.Friendlyf(nil, "claim '%s' is mapped to more than one LDAP attribute", m.Claim)
+		}
+		claims[m.Claim] = true
+	}
+	if claims["sub"] {
+		return ucerr.Friendlyf(nil, "the 'sub' claim is always set from LDAPProvider.IDAttribute and can't be mapped")
+	}
+
+	return nil
+}
+
+// EncodeSecrets will replace any UI secrets in the provider config with actual secrets
+func (p *LDAPProvider) EncodeSecrets(ctx context.Context, providerID uuid.UUID, source *LDAPProvider) error {
+	// easy case first
+	if p.BindPassword == secret.EmptyString {
+		return nil
+	}
+
+	// no changes
+	if p.BindPassword == secret.UIPlaceholder && source != nil {
+		p.BindPassword = source.BindPassword
+		return nil
+	}
+
+	// must be a new secret, or a new provider
+	sec, err := p.BindPassword.MarshalText()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	ns, err := crypto.CreateClientSecret(ctx, "ldap"+providerID.String(), string(sec))
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	p.BindPassword = *ns
+	return nil
+}
@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o LDAPAttributeMapping) Validate() error {
+	if o.Claim == "" {
+		return ucerr.Friendlyf(nil, "LDAPAttributeMapping.Claim can't be empty")
+	}
+	if o.Attribute == "" {
+		return ucerr.Friendlyf(nil, "LDAPAttributeMapping.Attribute can't be empty")
+	}
+	return nil
+}
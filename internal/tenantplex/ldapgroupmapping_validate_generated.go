@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o LDAPGroupMapping) Validate() error {
+	if o.GroupDN == "" {
+		return ucerr.Friendlyf(nil, "LDAPGroupMapping.GroupDN can't be empty")
+	}
+	if o.ObjectID.IsNil() {
+		return ucerr.Friendlyf(nil, "LDAPGroupMapping.ObjectID can't be nil")
+	}
+	if o.EdgeTypeID.IsNil() {
+		return ucerr.Friendlyf(nil, "LDAPGroupMapping.EdgeTypeID can't be nil")
+	}
+	return nil
+}
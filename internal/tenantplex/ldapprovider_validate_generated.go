@@ -0,0 +1,49 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o LDAPProvider) Validate() error {
+	if o.URL == "" {
+		return ucerr.Friendlyf(nil, "LDAPProvider.URL can't be empty")
+	}
+	if o.BindDN == "" {
+		return ucerr.Friendlyf(nil, "LDAPProvider.BindDN can't be empty")
+	}
+	if err := o.BindPassword.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.BaseDN == "" {
+		return ucerr.Friendlyf(nil, "LDAPProvider.BaseDN can't be empty")
+	}
+	if o.UserFilter == "" {
+		return ucerr.Friendlyf(nil, "LDAPProvider.UserFilter can't be empty")
+	}
+	if o.IDAttribute == "" {
+		return ucerr.Friendlyf(nil, "LDAPProvider.IDAttribute can't be empty")
+	}
+	for _, item := range o.AttributeMappings {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	for _, item := range o.GroupMappings {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	for _, item := range o.Apps {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -21,7 +21,7 @@ func (tenant *TenantConfig) classifyMFAMethods() (disabledMFAMethods string, ena
 	for mt := range strings.SplitSeq(paramtype.MFAMethodTypes, ",") {
 		enabled := false
 		switch oidc.MFAChannelType(mt) {
-		case oidc.MFAAuthenticatorChannel:
+		case oidc.MFAAuthenticatorChannel, oidc.MFAWebAuthnChannel:
 			enabled = true
 		case oidc.MFAEmailChannel:
 			// a valid TenantConfig will always have a valid email provider
@@ -31,6 +31,8 @@ const (
 	ProviderTypeEmployee ProviderType = "employee"
 	ProviderTypeUC       ProviderType = "uc"
 	ProviderTypeCognito  ProviderType = "cognito"
+	ProviderTypeLDAP     ProviderType = "ldap"
+	ProviderTypeOIDC     ProviderType = "oidc"
 )
 
 // PlexMap configures a Plex instance and maps Plex apps (client IDs)
@@ -55,9 +57,11 @@ type Provider struct {
 	Name string       `yaml:"name" json:"name" validate:"notempty"`
 	Type ProviderType `yaml:"type" json:"type"`
 
-	Auth0   *Auth0Provider   `yaml:"auth0,omitempty" json:"auth0,omitempty" validate:"allownil"`
-	UC      *UCProvider      `yaml:"uc,omitempty" json:"uc,omitempty" validate:"allownil"`
-	Cognito *CognitoProvider `yaml:"cognito,omitempty" json:"cognito,omitempty" validate:"allownil"`
+	Auth0   *Auth0Provider        `yaml:"auth0,omitempty" json:"auth0,omitempty" validate:"allownil"`
+	UC      *UCProvider           `yaml:"uc,omitempty" json:"uc,omitempty" validate:"allownil"`
+	Cognito *CognitoProvider      `yaml:"cognito,omitempty" json:"cognito,omitempty" validate:"allownil"`
+	LDAP    *LDAPProvider         `yaml:"ldap,omitempty" json:"ldap,omitempty" validate:"allownil"`
+	OIDC    *UpstreamOIDCProvider `yaml:"oidc,omitempty" json:"oidc,omitempty" validate:"allownil"`
 }
 
 //go:generate genvalidate Provider
@@ -100,6 +104,22 @@ func (p *Provider) DecodeSecrets(ctx context.Context) error {
 		}
 	}
 
+	if p.LDAP != nil {
+		s, err := p.LDAP.BindPassword.ResolveForUI(ctx)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		p.LDAP.BindPassword = *s
+	}
+
+	if p.OIDC != nil {
+		s, err := p.OIDC.ClientSecret.ResolveForUI(ctx)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		p.OIDC.ClientSecret = *s
+	}
+
 	return nil
 }
 
@@ -164,6 +184,32 @@ func (p *Provider) EncodeSecrets(ctx context.Context, source *PlexMap) error {
 		}
 	}
 
+	if p.LDAP != nil {
+		var sourceLDAPProvider *LDAPProvider
+		for i, sourceProvider := range source.Providers {
+			if p.ID == sourceProvider.ID {
+				sourceLDAPProvider = source.Providers[i].LDAP
+			}
+		}
+
+		if err := p.LDAP.EncodeSecrets(ctx, p.ID, sourceLDAPProvider); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	if p.OIDC != nil {
+		var sourceOIDCProvider *UpstreamOIDCProvider
+		for i, sourceProvider := range source.Providers {
+			if p.ID == sourceProvider.ID {
+				sourceOIDCProvider = source.Providers[i].OIDC
+			}
+		}
+
+		if err := p.OIDC.EncodeSecrets(ctx, p.ID, sourceOIDCProvider); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
@@ -328,6 +374,7 @@ const (
 	GrantTypeClientCredentials GrantType = "client_credentials"
 	GrantTypePassword          GrantType = "password"
 	GrantTypeDeviceCode        GrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeTokenExchange     GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
 
 	// non-standard grant types
 	// Auth0 maps these to different grant types, but then doesn't let you
@@ -348,6 +395,8 @@ var SupportedGrantTypes = GrantTypes{
 	GrantTypeRefreshToken,
 	GrantTypeClientCredentials,
 	GrantTypePassword,
+	GrantTypeDeviceCode,
+	GrantTypeTokenExchange,
 }
 
 // Contains returns true if the given GrantType is in the array
@@ -366,6 +415,18 @@ type App struct {
 	ClientID     string        `yaml:"client_id" json:"client_id" validate:"notempty"`
 	ClientSecret secret.String `yaml:"client_secret" json:"client_secret"`
 
+	// TokenEndpointAuthMethod is how the app authenticates to the token endpoint. If it isn't set,
+	// the app can send its client secret either via basic auth or in the request body.
+	TokenEndpointAuthMethod TokenEndpointAuthMethod `yaml:"token_endpoint_auth_method,omitempty" json:"token_endpoint_auth_method,omitempty"`
+
+	// JWKS (a JSON-encoded JWK set) or JWKSURI specify the app's public keys, which are used to
+	// verify private_key_jwt client assertions and self-signed TLS client certificates
+	JWKS    string `yaml:"jwks,omitempty" json:"jwks,omitempty"`
+	JWKSURI string `yaml:"jwks_uri,omitempty" json:"jwks_uri,omitempty"`
+
+	// TLSClientAuthSubjectDN is the subject DN the app's certificate must have for tls_client_auth
+	TLSClientAuthSubjectDN string `yaml:"tls_client_auth_subject_dn,omitempty" json:"tls_client_auth_subject_dn,omitempty"`
+
 	RestrictedAccess bool `yaml:"restricted_access" json:"restricted_access"`
 
 	TokenValidity TokenValidity `yaml:"token_validity" json:"token_validity"`
@@ -385,6 +446,8 @@ type App struct {
 
 	ImpersonateUserConfig ImpersonateUserConfig `yaml:"impersonate_user_config" json:"impersonate_user_config" validate:"skip"`
 
+	TokenExchangeConfig TokenExchangeConfig `yaml:"token_exchange_config,omitempty" json:"token_exchange_config,omitempty" validate:"skip"`
+
 	SAMLIDP *SAMLIDP `yaml:"saml_idp" json:"saml_idp,omitempty" validate:"allownil"`
 }
 
@@ -467,8 +530,13 @@ func (app App) Equals(o *App) bool {
 		app.Description == o.Description &&
 		app.ClientID == o.ClientID &&
 		app.ClientSecret == o.ClientSecret &&
+		app.TokenEndpointAuthMethod == o.TokenEndpointAuthMethod &&
+		app.JWKS == o.JWKS &&
+		app.JWKSURI == o.JWKSURI &&
+		app.TLSClientAuthSubjectDN == o.TLSClientAuthSubjectDN &&
 		app.SyncedFromProvider == o.SyncedFromProvider &&
-		app.ImpersonateUserConfig == o.ImpersonateUserConfig
+		app.ImpersonateUserConfig == o.ImpersonateUserConfig &&
+		slices.Equal(app.TokenExchangeConfig.AllowedAudiences, o.TokenExchangeConfig.AllowedAudiences)
 }
 
 // DecodeSecrets will replace any secrets in the provider config with
@@ -547,6 +615,18 @@ type ImpersonateUserConfig struct {
 	BypassCompanyAdminCheck bool   `yaml:"bypass_company_admin_check" json:"bypass_company_admin_check"`
 }
 
+// TokenExchangeConfig defines the rules for exchanging tokens via the token exchange grant (RFC 8693).
+// AllowedAudiences lists the audiences (eg. downstream service URLs) the app may request exchanged
+// tokens for; if it's empty, the app can't exchange tokens even if the grant type is enabled.
+type TokenExchangeConfig struct {
+	AllowedAudiences []string `yaml:"allowed_audiences" json:"allowed_audiences"`
+}
+
+// CanExchangeForAudience returns true if the app may request an exchanged token for the given audience
+func (app App) CanExchangeForAudience(audience string) bool {
+	return slices.Contains(app.TokenExchangeConfig.AllowedAudiences, audience)
+}
+
 // Validate implements Validateable
 func (app *App) extraValidate() error {
 	for i, uri := range app.AllowedRedirectURIs {
@@ -581,6 +661,16 @@ func (app *App) extraValidate() error {
 		}
 	}
 
+	if err := app.validateClientAuth(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	for _, aud := range app.TokenExchangeConfig.AllowedAudiences {
+		if aud == "" {
+			return ucerr.Friendlyf(nil, "app '%s' can't allow token exchange for an empty audience", app.Name)
+		}
+	}
+
 	return nil
 }
 
@@ -968,6 +1058,14 @@ func (p *Provider) extraValidate() error {
 		if p.Cognito == nil {
 			return ucerr.New("Cognito config is required for Cognito provider")
 		}
+	case ProviderTypeLDAP:
+		if p.LDAP == nil {
+			return ucerr.New("LDAP config is required for LDAP provider")
+		}
+	case ProviderTypeOIDC:
+		if p.OIDC == nil {
+			return ucerr.New("OIDC config is required for OIDC provider")
+		}
 	default:
 		return ucerr.Friendlyf(nil, "unrecognized provider.Type %s", p.Type)
 	}
@@ -995,6 +1093,18 @@ func (p *Provider) ValidateAppID(providerAppID uuid.UUID) error {
 				return nil
 			}
 		}
+	case ProviderTypeLDAP:
+		for _, a := range p.LDAP.Apps {
+			if providerAppID == a.ID {
+				return nil
+			}
+		}
+	case ProviderTypeOIDC:
+		for _, a := range p.OIDC.Apps {
+			if providerAppID == a.ID {
+				return nil
+			}
+		}
 	default:
 		return ucerr.Friendlyf(nil, "unrecognized provider.Type %s", p.Type)
 	}
@@ -29,6 +29,16 @@ func (o Provider) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.LDAP != nil {
+		if err := o.LDAP.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	if o.OIDC != nil {
+		if err := o.OIDC.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	// .extraValidate() lets you do any validation you can't express in codegen tags yet
 	if err := o.extraValidate(); err != nil {
 		return ucerr.Wrap(err)
@@ -0,0 +1,54 @@
+package tenantplex
+
+import (
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// SCIMAttributeMapping maps a SCIM user attribute path (eg. "userName", "name.givenName",
+// "emails.value" or "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department")
+// onto a userstore column. Sub-attributes of multi-valued attributes like "emails.value"
+// refer to the primary value.
+type SCIMAttributeMapping struct {
+	Attribute string `yaml:"attribute" json:"attribute" validate:"notempty"`
+	Column    string `yaml:"column" json:"column" validate:"notempty"`
+}
+
+//go:generate genvalidate SCIMAttributeMapping
+
+// SCIMConfig configures inbound SCIM 2.0 provisioning for a tenant
+type SCIMConfig struct {
+	// UserAttributeMappings describes how SCIM user attributes are stored in the userstore; if empty,
+	// a default mapping onto the built-in email, name, nickname and picture columns is used
+	UserAttributeMappings []SCIMAttributeMapping `yaml:"user_attribute_mappings,omitempty" json:"user_attribute_mappings,omitempty"`
+
+	// GroupMemberRole is the name of the authz edge type used to connect SCIM group members to groups;
+	// if empty, the built-in member role is used
+	GroupMemberRole string `yaml:"group_member_role,omitempty" json:"group_member_role,omitempty"`
+}
+
+//go:generate genvalidate SCIMConfig
+
+func (c SCIMConfig) extraValidate() error {
+	attributes := map[string]bool{}
+	columns := map[string]bool{}
+	for _, m := range c.UserAttributeMappings {
+		attribute := strings.ToLower(m.Attribute)
+		if attributes[attribute] {
+			return ucerr.Friendlyf(nil, "SCIM attribute '%s' is mapped more than once", m.Attribute)
+		}
+		attributes[attribute] = true
+
+		if columns[m.Column] {
+			return ucerr.Friendlyf(nil, "column '%s' is mapped to more than one SCIM attribute", m.Column)
+		}
+		columns[m.Column] = true
+	}
+
+	if len(c.UserAttributeMappings) > 0 && !attributes["username"] {
+		return ucerr.Friendlyf(nil, "SCIM attribute mappings must include 'userName'")
+	}
+
+	return nil
+}
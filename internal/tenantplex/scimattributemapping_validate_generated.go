@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o SCIMAttributeMapping) Validate() error {
+	if o.Attribute == "" {
+		return ucerr.Friendlyf(nil, "SCIMAttributeMapping.Attribute can't be empty")
+	}
+	if o.Column == "" {
+		return ucerr.Friendlyf(nil, "SCIMAttributeMapping.Column can't be empty")
+	}
+	return nil
+}
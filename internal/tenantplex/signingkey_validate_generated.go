@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o SigningKey) Validate() error {
+	if err := o.Keys.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := o.State.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
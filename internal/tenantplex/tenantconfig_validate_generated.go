@@ -20,9 +20,15 @@ func (o TenantConfig) Validate() error {
 	if err := o.Keys.Validate(); err != nil {
 		return ucerr.Wrap(err)
 	}
+	if err := o.KeySet.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
 	if err := o.PageParameters.Validate(); err != nil {
 		return ucerr.Wrap(err)
 	}
+	if err := o.SCIM.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
 	// .extraValidate() lets you do any validation you can't express in codegen tags yet
 	if err := o.extraValidate(); err != nil {
 		return ucerr.Wrap(err)
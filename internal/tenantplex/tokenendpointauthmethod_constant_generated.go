@@ -0,0 +1,89 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t TokenEndpointAuthMethod) MarshalText() ([]byte, error) {
+	switch t {
+	case TokenEndpointAuthMethodClientSecretBasic:
+		return []byte("client_secret_basic"), nil
+	case TokenEndpointAuthMethodClientSecretPost:
+		return []byte("client_secret_post"), nil
+	case TokenEndpointAuthMethodDefault:
+		return []byte(""), nil
+	case TokenEndpointAuthMethodPrivateKeyJWT:
+		return []byte("private_key_jwt"), nil
+	case TokenEndpointAuthMethodSelfSignedTLSClientAuth:
+		return []byte("self_signed_tls_client_auth"), nil
+	case TokenEndpointAuthMethodTLSClientAuth:
+		return []byte("tls_client_auth"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown TokenEndpointAuthMethod value '%s'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *TokenEndpointAuthMethod) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "client_secret_basic":
+		*t = TokenEndpointAuthMethodClientSecretBasic
+	case "client_secret_post":
+		*t = TokenEndpointAuthMethodClientSecretPost
+	case "":
+		*t = TokenEndpointAuthMethodDefault
+	case "private_key_jwt":
+		*t = TokenEndpointAuthMethodPrivateKeyJWT
+	case "self_signed_tls_client_auth":
+		*t = TokenEndpointAuthMethodSelfSignedTLSClientAuth
+	case "tls_client_auth":
+		*t = TokenEndpointAuthMethodTLSClientAuth
+	default:
+		return ucerr.Friendlyf(nil, "unknown TokenEndpointAuthMethod value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *TokenEndpointAuthMethod) Validate() error {
+	switch *t {
+	case TokenEndpointAuthMethodClientSecretBasic:
+		return nil
+	case TokenEndpointAuthMethodClientSecretPost:
+		return nil
+	case TokenEndpointAuthMethodDefault:
+		return nil
+	case TokenEndpointAuthMethodPrivateKeyJWT:
+		return nil
+	case TokenEndpointAuthMethodSelfSignedTLSClientAuth:
+		return nil
+	case TokenEndpointAuthMethodTLSClientAuth:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown TokenEndpointAuthMethod value '%s'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t TokenEndpointAuthMethod) Enum() []any {
+	return []any{
+		"client_secret_basic",
+		"client_secret_post",
+		"",
+		"private_key_jwt",
+		"self_signed_tls_client_auth",
+		"tls_client_auth",
+	}
+}
+
+// AllTokenEndpointAuthMethods is a slice of all TokenEndpointAuthMethod values
+var AllTokenEndpointAuthMethods = []TokenEndpointAuthMethod{
+	TokenEndpointAuthMethodClientSecretBasic,
+	TokenEndpointAuthMethodClientSecretPost,
+	TokenEndpointAuthMethodDefault,
+	TokenEndpointAuthMethodPrivateKeyJWT,
+	TokenEndpointAuthMethodSelfSignedTLSClientAuth,
+	TokenEndpointAuthMethodTLSClientAuth,
+}
@@ -0,0 +1,137 @@
+package tenantplex
+
+import (
+	"context"
+	"net/url"
+	"slices"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/crypto"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/ucerr"
+)
+
+// UpstreamOIDCClaimMapping copies a claim from the upstream IdP (eg. "email" or "groups") into a userstore column
+type UpstreamOIDCClaimMapping struct {
+	Claim  string `yaml:"claim" json:"claim" validate:"notempty"`
+	Column string `yaml:"column" json:"column" validate:"notempty"`
+}
+
+//go:generate genvalidate UpstreamOIDCClaimMapping
+
+// UpstreamOIDCApp defines an upstream OIDC login app to map to plex apps. All plex apps share the
+// provider's client, so this only exists so that plex apps can refer to the provider like any other.
+type UpstreamOIDCApp struct {
+	ID   uuid.UUID `yaml:"id" json:"id" validate:"notnil"`
+	Name string    `yaml:"name" json:"name" validate:"notempty"`
+}
+
+//go:generate genvalidate UpstreamOIDCApp
+
+// UpstreamOIDCProvider defines config for an arbitrary OIDC IdP (eg. Okta, Keycloak or Ping) that a
+// tenant's login is delegated to. Users are redirected to the IdP to log in, and are then linked to or
+// provisioned in the tenant's userstore.
+type UpstreamOIDCProvider struct {
+	// IssuerURL is used to discover the IdP's endpoints and keys, and must match the issuer of its tokens
+	IssuerURL    string        `yaml:"issuer_url" json:"issuer_url" validate:"notempty"`
+	ClientID     string        `yaml:"client_id" json:"client_id" validate:"notempty"`
+	ClientSecret secret.String `yaml:"client_secret" json:"client_secret"`
+
+	// Scopes requested from the IdP, defaulting to "openid profile email"
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+
+	// LinkAccountsByEmail links an upstream identity to an existing userstore user with the same email
+	// the first time they log in, but only if the IdP says the email is verified. Otherwise a new user
+	// is always provisioned.
+	LinkAccountsByEmail bool `yaml:"link_accounts_by_email" json:"link_accounts_by_email"`
+
+	// DisableJITProvisioning stops users who aren't already in the userstore (or linked to an
+	// existing user) from logging in, instead of creating them on their first login
+	DisableJITProvisioning bool `yaml:"disable_jit_provisioning" json:"disable_jit_provisioning"`
+
+	// ClaimMappings describes which userstore columns are set from upstream claims on each login;
+	// if empty, the standard profile claims are mapped onto the columns of the same name
+	ClaimMappings []UpstreamOIDCClaimMapping `yaml:"claim_mappings,omitempty" json:"claim_mappings,omitempty"`
+
+	Apps []UpstreamOIDCApp `yaml:"apps,omitempty" json:"apps"`
+}
+
+//go:generate genvalidate UpstreamOIDCProvider
+
+// DefaultUpstreamOIDCClaimMappings are used if an UpstreamOIDCProvider doesn't specify any
+var DefaultUpstreamOIDCClaimMappings = []UpstreamOIDCClaimMapping{
+	{Claim: "email", Column: "email"},
+	{Claim: "email_verified", Column: "email_verified"},
+	{Claim: "name", Column: "name"},
+	{Claim: "nickname", Column: "nickname"},
+	{Claim: "picture", Column: "picture"},
+}
+
+// GetClaimMappings returns the configured claim mappings, or the defaults
+func (p UpstreamOIDCProvider) GetClaimMappings() []UpstreamOIDCClaimMapping {
+	if len(p.ClaimMappings) > 0 {
+		return p.ClaimMappings
+	}
+	return DefaultUpstreamOIDCClaimMappings
+}
+
+// GetScopes returns the scopes to request from the IdP
+func (p UpstreamOIDCProvider) GetScopes() []string {
+	if len(p.Scopes) > 0 {
+		return p.Scopes
+	}
+	return oidc.SplitTokens(oidc.DefaultScopes)
+}
+
+func (p UpstreamOIDCProvider) extraValidate() error {
+	u, err := url.Parse(p.IssuerURL)
+	if err != nil {
+		return ucerr.Friendlyf(err, "UpstreamOIDCProvider.IssuerURL '%s' is invalid", p.IssuerURL)
+	}
+	if u.Scheme != "https" && u.Hostname() != "localhost" {
+		return ucerr.Friendlyf(nil, "UpstreamOIDCProvider.IssuerURL must be an https:// URL, got '%s'", p.IssuerURL)
+	}
+
+	if len(p.Scopes) > 0 && !slices.Contains(p.Scopes, "openid") {
+		return ucerr.Friendlyf(nil, "UpstreamOIDCProvider.Scopes must include 'openid'")
+	}
+
+	columns := map[string]bool{}
+	for _, m := range p.ClaimMappings {
+		if columns[m.Column] {
+			return ucerr.Friendlyf(nil, "column '%s' is mapped from more than one claim", m.Column)
+		}
+		columns[m.Column] = true
+	}
+
+	return nil
+}
+
+// EncodeSecrets will replace any UI secrets in the provider config with actual secrets
+func (p *UpstreamOIDCProvider) EncodeSecrets(ctx context.Context, providerID uuid.UUID, source *UpstreamOIDCProvider) error {
+	// easy case first
+	if p.ClientSecret == secret.EmptyString {
+		return nil
+	}
+
+	// no changes
+	if p.ClientSecret == secret.UIPlaceholder && source != nil {
+		p.ClientSecret = source.ClientSecret
+		return nil
+	}
+
+	// must be a new secret, or a new provider
+	sec, err := p.ClientSecret.MarshalText()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	ns, err := crypto.CreateClientSecret(ctx, "oidc"+providerID.String(), string(sec))
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	p.ClientSecret = *ns
+	return nil
+}
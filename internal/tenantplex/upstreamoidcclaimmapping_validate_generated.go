@@ -0,0 +1,18 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o UpstreamOIDCClaimMapping) Validate() error {
+	if o.Claim == "" {
+		return ucerr.Friendlyf(nil, "UpstreamOIDCClaimMapping.Claim can't be empty")
+	}
+	if o.Column == "" {
+		return ucerr.Friendlyf(nil, "UpstreamOIDCClaimMapping.Column can't be empty")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package tenantplex
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o UpstreamOIDCProvider) Validate() error {
+	if o.IssuerURL == "" {
+		return ucerr.Friendlyf(nil, "UpstreamOIDCProvider.IssuerURL can't be empty")
+	}
+	if o.ClientID == "" {
+		return ucerr.Friendlyf(nil, "UpstreamOIDCProvider.ClientID can't be empty")
+	}
+	if err := o.ClientSecret.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	for _, item := range o.ClaimMappings {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	for _, item := range o.Apps {
+		if err := item.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
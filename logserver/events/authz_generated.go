@@ -26,6 +26,14 @@ const (
 	EventAuthzCheckAttributeDBWrite                               uclog.EventCode = 4411
 	EventAuthzCheckAttributeDBWriteDuration                       uclog.EventCode = 4379
 	EventAuthzCheckAttributeDuration                              uclog.EventCode = 2441
+	EventAuthzCheckAttributes                                     uclog.EventCode = 7836
+	EventAuthzCheckAttributesDBGet                                uclog.EventCode = 7843
+	EventAuthzCheckAttributesDBGetDuration                        uclog.EventCode = 7839
+	EventAuthzCheckAttributesDBSelect                             uclog.EventCode = 7837
+	EventAuthzCheckAttributesDBSelectDuration                     uclog.EventCode = 7842
+	EventAuthzCheckAttributesDBWrite                              uclog.EventCode = 7840
+	EventAuthzCheckAttributesDBWriteDuration                      uclog.EventCode = 7838
+	EventAuthzCheckAttributesDuration                             uclog.EventCode = 7841
 	EventAuthzCreateAuditLogEntry                                 uclog.EventCode = 2520
 	EventAuthzCreateAuditLogEntryDuration                         uclog.EventCode = 2521
 	EventAuthzCreateEdge                                          uclog.EventCode = 2010
@@ -164,6 +172,7 @@ const (
 	EventAuthzDeleteOrganizationDBWrite                           uclog.EventCode = 4427
 	EventAuthzDeleteOrganizationDBWriteDuration                   uclog.EventCode = 4354
 	EventAuthzDeleteOrganizationDuration                          uclog.EventCode = 3970
+	EventAuthzEdgeExpired                                         uclog.EventCode = 7844
 	EventAuthzGetAuditLogEntry                                    uclog.EventCode = 2510
 	EventAuthzGetAuditLogEntryDuration                            uclog.EventCode = 2511
 	EventAuthzGetEdge                                             uclog.EventCode = 2000
@@ -316,6 +325,14 @@ const (
 	EventAuthzListOrganizationsPaginatedDBWrite                   uclog.EventCode = 4308
 	EventAuthzListOrganizationsPaginatedDBWriteDuration           uclog.EventCode = 4439
 	EventAuthzListOrganizationsPaginatedDuration                  uclog.EventCode = 3631
+	EventAuthzListSourcesWithAttribute                            uclog.EventCode = 7833
+	EventAuthzListSourcesWithAttributeDBGet                       uclog.EventCode = 7834
+	EventAuthzListSourcesWithAttributeDBGetDuration               uclog.EventCode = 7828
+	EventAuthzListSourcesWithAttributeDBSelect                    uclog.EventCode = 7830
+	EventAuthzListSourcesWithAttributeDBSelectDuration            uclog.EventCode = 7829
+	EventAuthzListSourcesWithAttributeDBWrite                     uclog.EventCode = 7835
+	EventAuthzListSourcesWithAttributeDBWriteDuration             uclog.EventCode = 7831
+	EventAuthzListSourcesWithAttributeDuration                    uclog.EventCode = 7832
 	EventAuthzMigrateEdgeType                                     uclog.EventCode = 4027
 	EventAuthzMigrateEdgeTypeDBGet                                uclog.EventCode = 4352
 	EventAuthzMigrateEdgeTypeDBGetDuration                        uclog.EventCode = 4436
@@ -360,6 +377,7 @@ const (
 )
 
 var authzEventMap = map[string]uclog.LogEventTypeInfo{
+	"authz.EdgeExpired":                                             {Name: "Authz Edge Expired", NormalizedName: "AuthzEdgeExpired", Code: EventAuthzEdgeExpired, Service: service.AuthZ, Subcategory: "event", URL: "", Category: uclog.EventCategorySystem},
 	"authz.HandleGetDeployed.Count":                                 {Name: "Get Deployed Build", NormalizedName: "GetDeployedBuild", Code: EventAuthzHandleGetDeployed, Service: service.AuthZ, Subcategory: "function", Ignore: true, URL: "/deployed", Category: uclog.EventCategoryCall},
 	"authz.HandleGetDeployed.Duration":                              {Name: "Get Deployed Build", NormalizedName: "GetDeployedBuild", Code: EventAuthzHandleGetDeployedDuration, Service: service.AuthZ, Subcategory: "function", Ignore: true, URL: "/deployed", Category: uclog.EventCategoryDuration},
 	"authz.Startup":                                                 {Name: "Authz Startup", NormalizedName: "AuthzStartup", Code: EventAuthzStartup, Service: service.AuthZ, Subcategory: "event", URL: "", Category: uclog.EventCategorySystem},
@@ -371,6 +389,14 @@ var authzEventMap = map[string]uclog.LogEventTypeInfo{
 	"authz.checkAttribute-fm.DBWriteCount":                          {Name: "Check Attribute", NormalizedName: "CheckAttribute", Code: EventAuthzCheckAttributeDBWrite, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"authz.checkAttribute-fm.DBWriteDuration":                       {Name: "Check Attribute", NormalizedName: "CheckAttribute", Code: EventAuthzCheckAttributeDBWriteDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"authz.checkAttribute-fm.Duration":                              {Name: "Check Attribute", NormalizedName: "CheckAttribute", Code: EventAuthzCheckAttributeDuration, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.checkAttributes-fm.Count":                                {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributes, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"authz.checkAttributes-fm.DBGetCount":                           {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDBGet, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"authz.checkAttributes-fm.DBGetDuration":                        {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDBGetDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.checkAttributes-fm.DBSelectCount":                        {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDBSelect, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"authz.checkAttributes-fm.DBSelectDuration":                     {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDBSelectDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.checkAttributes-fm.DBWriteCount":                         {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDBWrite, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"authz.checkAttributes-fm.DBWriteDuration":                      {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDBWriteDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.checkAttributes-fm.Duration":                             {Name: "Check Attributes", NormalizedName: "CheckAttributes", Code: EventAuthzCheckAttributesDuration, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"authz.createAuditLogEntry-fm.Count":                            {Name: "Create Audit Log Entry", NormalizedName: "CreateAuditLogEntry", Code: EventAuthzCreateAuditLogEntry, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"authz.createAuditLogEntry-fm.Duration":                         {Name: "Create Audit Log Entry", NormalizedName: "CreateAuditLogEntry", Code: EventAuthzCreateAuditLogEntryDuration, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"authz.createEdge-fm.Count":                                     {Name: "Create Edge", NormalizedName: "CreateEdge", Code: EventAuthzCreateEdge, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
@@ -659,6 +685,14 @@ var authzEventMap = map[string]uclog.LogEventTypeInfo{
 	"authz.listOrganizationsPaginated-fm.DBWriteCount":              {Name: "List Organizations Paginated", NormalizedName: "ListOrganizationsPaginated", Code: EventAuthzListOrganizationsPaginatedDBWrite, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"authz.listOrganizationsPaginated-fm.DBWriteDuration":           {Name: "List Organizations Paginated", NormalizedName: "ListOrganizationsPaginated", Code: EventAuthzListOrganizationsPaginatedDBWriteDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"authz.listOrganizationsPaginated-fm.Duration":                  {Name: "List Organizations Paginated", NormalizedName: "ListOrganizationsPaginated", Code: EventAuthzListOrganizationsPaginatedDuration, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.listSourcesWithAttribute-fm.Count":                       {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttribute, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"authz.listSourcesWithAttribute-fm.DBGetCount":                  {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDBGet, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"authz.listSourcesWithAttribute-fm.DBGetDuration":               {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDBGetDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.listSourcesWithAttribute-fm.DBSelectCount":               {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDBSelect, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"authz.listSourcesWithAttribute-fm.DBSelectDuration":            {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDBSelectDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.listSourcesWithAttribute-fm.DBWriteCount":                {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDBWrite, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"authz.listSourcesWithAttribute-fm.DBWriteDuration":             {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDBWriteDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"authz.listSourcesWithAttribute-fm.Duration":                    {Name: "List Sources With Attribute", NormalizedName: "ListSourcesWithAttribute", Code: EventAuthzListSourcesWithAttributeDuration, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"authz.migrateEdgeType-fm.Count":                                {Name: "Migrate Edge Type", NormalizedName: "MigrateEdgeType", Code: EventAuthzMigrateEdgeType, Service: service.AuthZ, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"authz.migrateEdgeType-fm.DBGetCount":                           {Name: "Migrate Edge Type", NormalizedName: "MigrateEdgeType", Code: EventAuthzMigrateEdgeTypeDBGet, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"authz.migrateEdgeType-fm.DBGetDuration":                        {Name: "Migrate Edge Type", NormalizedName: "MigrateEdgeType", Code: EventAuthzMigrateEdgeTypeDBGetDuration, Service: service.AuthZ, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -86,6 +86,14 @@ const (
 	EventIDPCreateColumnHandlerDBSelectDuration                         uclog.EventCode = 5540
 	EventIDPCreateColumnHandlerDBWrite                                  uclog.EventCode = 5528
 	EventIDPCreateColumnHandlerDBWriteDuration                          uclog.EventCode = 5401
+	EventIDPCreateDSARExport                                            uclog.EventCode = 7877
+	EventIDPCreateDSARExportDBGet                                       uclog.EventCode = 7878
+	EventIDPCreateDSARExportDBGetDuration                               uclog.EventCode = 7879
+	EventIDPCreateDSARExportDBSelect                                    uclog.EventCode = 7880
+	EventIDPCreateDSARExportDBSelectDuration                            uclog.EventCode = 7881
+	EventIDPCreateDSARExportDBWrite                                     uclog.EventCode = 7882
+	EventIDPCreateDSARExportDBWriteDuration                             uclog.EventCode = 7883
+	EventIDPCreateDSARExportDuration                                    uclog.EventCode = 7884
 	EventIDPCreateDataSource                                            uclog.EventCode = 7296
 	EventIDPCreateDataSourceDBGet                                       uclog.EventCode = 7281
 	EventIDPCreateDataSourceDBGetDuration                               uclog.EventCode = 7289
@@ -286,6 +294,14 @@ const (
 	EventIDPCreateUserDBWrite                                           uclog.EventCode = 5509
 	EventIDPCreateUserDBWriteDuration                                   uclog.EventCode = 5438
 	EventIDPCreateUserDuration                                          uclog.EventCode = 1651
+	EventIDPCreateUserErasure                                           uclog.EventCode = 7901
+	EventIDPCreateUserErasureDBGet                                      uclog.EventCode = 7902
+	EventIDPCreateUserErasureDBGetDuration                              uclog.EventCode = 7903
+	EventIDPCreateUserErasureDBSelect                                   uclog.EventCode = 7904
+	EventIDPCreateUserErasureDBSelectDuration                           uclog.EventCode = 7905
+	EventIDPCreateUserErasureDBWrite                                    uclog.EventCode = 7906
+	EventIDPCreateUserErasureDBWriteDuration                            uclog.EventCode = 7907
+	EventIDPCreateUserErasureDuration                                   uclog.EventCode = 7908
 	EventIDPCreateUserSearchIndex                                       uclog.EventCode = 7750
 	EventIDPCreateUserSearchIndexDBGet                                  uclog.EventCode = 7756
 	EventIDPCreateUserSearchIndexDBGetDuration                          uclog.EventCode = 7771
@@ -334,6 +350,14 @@ const (
 	EventIDPDbStatsHandlerDBWrite                                       uclog.EventCode = 5832
 	EventIDPDbStatsHandlerDBWriteDuration                               uclog.EventCode = 5831
 	EventIDPDbStatsHandlerDuration                                      uclog.EventCode = 5837
+	EventIDPDecryptTokens                                               uclog.EventCode = 7853
+	EventIDPDecryptTokensDBGet                                          uclog.EventCode = 7854
+	EventIDPDecryptTokensDBGetDuration                                  uclog.EventCode = 7855
+	EventIDPDecryptTokensDBSelect                                       uclog.EventCode = 7856
+	EventIDPDecryptTokensDBSelectDuration                               uclog.EventCode = 7857
+	EventIDPDecryptTokensDBWrite                                        uclog.EventCode = 7858
+	EventIDPDecryptTokensDBWriteDuration                                uclog.EventCode = 7859
+	EventIDPDecryptTokensDuration                                       uclog.EventCode = 7860
 	EventIDPDeleteAccessPolicy                                          uclog.EventCode = 4262
 	EventIDPDeleteAccessPolicyDBGet                                     uclog.EventCode = 5233
 	EventIDPDeleteAccessPolicyDBGetDuration                             uclog.EventCode = 5464
@@ -594,6 +618,14 @@ const (
 	EventIDPDeleteUserstoreUserDBWrite                                  uclog.EventCode = 6955
 	EventIDPDeleteUserstoreUserDBWriteDuration                          uclog.EventCode = 6958
 	EventIDPDeleteUserstoreUserDuration                                 uclog.EventCode = 6950
+	EventIDPDownloadDSARExport                                          uclog.EventCode = 7885
+	EventIDPDownloadDSARExportDBGet                                     uclog.EventCode = 7886
+	EventIDPDownloadDSARExportDBGetDuration                             uclog.EventCode = 7887
+	EventIDPDownloadDSARExportDBSelect                                  uclog.EventCode = 7888
+	EventIDPDownloadDSARExportDBSelectDuration                          uclog.EventCode = 7889
+	EventIDPDownloadDSARExportDBWrite                                   uclog.EventCode = 7890
+	EventIDPDownloadDSARExportDBWriteDuration                           uclog.EventCode = 7891
+	EventIDPDownloadDSARExportDuration                                  uclog.EventCode = 7892
 	EventIDPExecuteAccessPolicy                                         uclog.EventCode = 4244
 	EventIDPExecuteAccessPolicyDuration                                 uclog.EventCode = 4248
 	EventIDPExecuteAccessorHandlerDBGet                                 uclog.EventCode = 5230
@@ -612,6 +644,22 @@ const (
 	EventIDPExecuteMutatorHandlerDuration                               uclog.EventCode = 3932
 	EventIDPExecuteTransformer                                          uclog.EventCode = 4261
 	EventIDPExecuteTransformerDuration                                  uclog.EventCode = 4226
+	EventIDPExportAccessor                                              uclog.EventCode = 7861
+	EventIDPExportAccessorDBGet                                         uclog.EventCode = 7862
+	EventIDPExportAccessorDBGetDuration                                 uclog.EventCode = 7863
+	EventIDPExportAccessorDBSelect                                      uclog.EventCode = 7864
+	EventIDPExportAccessorDBSelectDuration                              uclog.EventCode = 7865
+	EventIDPExportAccessorDBWrite                                       uclog.EventCode = 7866
+	EventIDPExportAccessorDBWriteDuration                               uclog.EventCode = 7867
+	EventIDPExportAccessorDuration                                      uclog.EventCode = 7868
+	EventIDPExportAccessorToObjectStoreHandler                          uclog.EventCode = 7869
+	EventIDPExportAccessorToObjectStoreHandlerDBGet                     uclog.EventCode = 7870
+	EventIDPExportAccessorToObjectStoreHandlerDBGetDuration             uclog.EventCode = 7871
+	EventIDPExportAccessorToObjectStoreHandlerDBSelect                  uclog.EventCode = 7872
+	EventIDPExportAccessorToObjectStoreHandlerDBSelectDuration          uclog.EventCode = 7873
+	EventIDPExportAccessorToObjectStoreHandlerDBWrite                   uclog.EventCode = 7874
+	EventIDPExportAccessorToObjectStoreHandlerDBWriteDuration           uclog.EventCode = 7875
+	EventIDPExportAccessorToObjectStoreHandlerDuration                  uclog.EventCode = 7876
 	EventIDPGetAccessPolicy                                             uclog.EventCode = 4253
 	EventIDPGetAccessPolicyDBGet                                        uclog.EventCode = 5453
 	EventIDPGetAccessPolicyDBGetDuration                                uclog.EventCode = 5340
@@ -696,6 +744,14 @@ const (
 	EventIDPGetConsentedPurposesForUserDBWrite                          uclog.EventCode = 5260
 	EventIDPGetConsentedPurposesForUserDBWriteDuration                  uclog.EventCode = 5477
 	EventIDPGetConsentedPurposesForUserDuration                         uclog.EventCode = 4206
+	EventIDPGetDSARExport                                               uclog.EventCode = 7893
+	EventIDPGetDSARExportDBGet                                          uclog.EventCode = 7894
+	EventIDPGetDSARExportDBGetDuration                                  uclog.EventCode = 7895
+	EventIDPGetDSARExportDBSelect                                       uclog.EventCode = 7896
+	EventIDPGetDSARExportDBSelectDuration                               uclog.EventCode = 7897
+	EventIDPGetDSARExportDBWrite                                        uclog.EventCode = 7898
+	EventIDPGetDSARExportDBWriteDuration                                uclog.EventCode = 7899
+	EventIDPGetDSARExportDuration                                       uclog.EventCode = 7900
 	EventIDPGetDataSource                                               uclog.EventCode = 7288
 	EventIDPGetDataSourceDBGet                                          uclog.EventCode = 7204
 	EventIDPGetDataSourceDBGetDuration                                  uclog.EventCode = 7191
@@ -930,6 +986,14 @@ const (
 	EventIDPGetUserDBWrite                                              uclog.EventCode = 5304
 	EventIDPGetUserDBWriteDuration                                      uclog.EventCode = 5299
 	EventIDPGetUserDuration                                             uclog.EventCode = 2111
+	EventIDPGetUserErasure                                              uclog.EventCode = 7909
+	EventIDPGetUserErasureDBGet                                         uclog.EventCode = 7910
+	EventIDPGetUserErasureDBGetDuration                                 uclog.EventCode = 7911
+	EventIDPGetUserErasureDBSelect                                      uclog.EventCode = 7912
+	EventIDPGetUserErasureDBSelectDuration                              uclog.EventCode = 7913
+	EventIDPGetUserErasureDBWrite                                       uclog.EventCode = 7914
+	EventIDPGetUserErasureDBWriteDuration                               uclog.EventCode = 7915
+	EventIDPGetUserErasureDuration                                      uclog.EventCode = 7916
 	EventIDPGetUserSearchIndex                                          uclog.EventCode = 7769
 	EventIDPGetUserSearchIndexDBGet                                     uclog.EventCode = 7762
 	EventIDPGetUserSearchIndexDBGetDuration                             uclog.EventCode = 7777
@@ -1350,6 +1414,14 @@ const (
 	EventIDPResolveTokenDBWrite                                         uclog.EventCode = 5414
 	EventIDPResolveTokenDBWriteDuration                                 uclog.EventCode = 5422
 	EventIDPResolveTokenDuration                                        uclog.EventCode = 4240
+	EventIDPRevokeTokens                                                uclog.EventCode = 7845
+	EventIDPRevokeTokensDBGet                                           uclog.EventCode = 7846
+	EventIDPRevokeTokensDBGetDuration                                   uclog.EventCode = 7847
+	EventIDPRevokeTokensDBSelect                                        uclog.EventCode = 7848
+	EventIDPRevokeTokensDBSelectDuration                                uclog.EventCode = 7849
+	EventIDPRevokeTokensDBWrite                                         uclog.EventCode = 7850
+	EventIDPRevokeTokensDBWriteDuration                                 uclog.EventCode = 7851
+	EventIDPRevokeTokensDuration                                        uclog.EventCode = 7852
 	EventIDPServeHTTP                                                   uclog.EventCode = 7587
 	EventIDPServeHTTPDBGet                                              uclog.EventCode = 7590
 	EventIDPServeHTTPDBGetDuration                                      uclog.EventCode = 7596
@@ -1863,6 +1935,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.createColumnHandler-fm.DBWriteCount":                             {Name: "Create Column Handler", NormalizedName: "CreateColumnHandler", Code: EventIDPCreateColumnHandlerDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.createColumnHandler-fm.DBWriteDuration":                          {Name: "Create Column Handler", NormalizedName: "CreateColumnHandler", Code: EventIDPCreateColumnHandlerDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.createColumnHandler-fm.Duration":                                 {Name: "Create Column", NormalizedName: "CreateColumn", Code: EventIDPcreatecolumnhandlerDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createDSARExport-fm.Count":                                       {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExport, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.createDSARExport-fm.DBGetCount":                                  {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.createDSARExport-fm.DBGetDuration":                               {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createDSARExport-fm.DBSelectCount":                               {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.createDSARExport-fm.DBSelectDuration":                            {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createDSARExport-fm.DBWriteCount":                                {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.createDSARExport-fm.DBWriteDuration":                             {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createDSARExport-fm.Duration":                                    {Name: "Create DSAR Export", NormalizedName: "CreateDSARExport", Code: EventIDPCreateDSARExportDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.createDataSource-fm.Count":                                       {Name: "Create Data Source", NormalizedName: "CreateDataSource", Code: EventIDPCreateDataSource, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.createDataSource-fm.DBGetCount":                                  {Name: "Create Data Source", NormalizedName: "CreateDataSource", Code: EventIDPCreateDataSourceDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.createDataSource-fm.DBGetDuration":                               {Name: "Create Data Source", NormalizedName: "CreateDataSource", Code: EventIDPCreateDataSourceDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -2063,6 +2143,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.createUser-fm.DBWriteCount":                                      {Name: "Create User", NormalizedName: "CreateUser", Code: EventIDPCreateUserDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.createUser-fm.DBWriteDuration":                                   {Name: "Create User", NormalizedName: "CreateUser", Code: EventIDPCreateUserDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.createUser-fm.Duration":                                          {Name: "Create User", NormalizedName: "CreateUser", Code: EventIDPCreateUserDuration, Service: service.IDP, Subcategory: "function", URL: "/authn/users", Category: uclog.EventCategoryDuration},
+	"idp.createUserErasure-fm.Count":                                      {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasure, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.createUserErasure-fm.DBGetCount":                                 {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.createUserErasure-fm.DBGetDuration":                              {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createUserErasure-fm.DBSelectCount":                              {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.createUserErasure-fm.DBSelectDuration":                           {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createUserErasure-fm.DBWriteCount":                               {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.createUserErasure-fm.DBWriteDuration":                            {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.createUserErasure-fm.Duration":                                   {Name: "Create User Erasure", NormalizedName: "CreateUserErasure", Code: EventIDPCreateUserErasureDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.createUserSearchIndex-fm.Count":                                  {Name: "Create User Search Index", NormalizedName: "CreateUserSearchIndex", Code: EventIDPCreateUserSearchIndex, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.createUserSearchIndex-fm.DBGetCount":                             {Name: "Create User Search Index", NormalizedName: "CreateUserSearchIndex", Code: EventIDPCreateUserSearchIndexDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.createUserSearchIndex-fm.DBGetDuration":                          {Name: "Create User Search Index", NormalizedName: "CreateUserSearchIndex", Code: EventIDPCreateUserSearchIndexDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -2111,6 +2199,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.dbStatsHandler-fm.DBWriteCount":                                  {Name: "Db Stats Handler", NormalizedName: "DbStatsHandler", Code: EventIDPDbStatsHandlerDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.dbStatsHandler-fm.DBWriteDuration":                               {Name: "Db Stats Handler", NormalizedName: "DbStatsHandler", Code: EventIDPDbStatsHandlerDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.dbStatsHandler-fm.Duration":                                      {Name: "Db Stats Handler", NormalizedName: "DbStatsHandler", Code: EventIDPDbStatsHandlerDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.decryptTokens-fm.Count":                                          {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokens, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.decryptTokens-fm.DBGetCount":                                     {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.decryptTokens-fm.DBGetDuration":                                  {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.decryptTokens-fm.DBSelectCount":                                  {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.decryptTokens-fm.DBSelectDuration":                               {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.decryptTokens-fm.DBWriteCount":                                   {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.decryptTokens-fm.DBWriteDuration":                                {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.decryptTokens-fm.Duration":                                       {Name: "Decrypt Tokens", NormalizedName: "DecryptTokens", Code: EventIDPDecryptTokensDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.deleteAccessPolicy-fm.Count":                                     {Name: "Delete Access Policy", NormalizedName: "DeleteAccessPolicy", Code: EventIDPDeleteAccessPolicy, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.deleteAccessPolicy-fm.DBGetCount":                                {Name: "Delete Access Policy", NormalizedName: "DeleteAccessPolicy", Code: EventIDPDeleteAccessPolicyDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.deleteAccessPolicy-fm.DBGetDuration":                             {Name: "Delete Access Policy", NormalizedName: "DeleteAccessPolicy", Code: EventIDPDeleteAccessPolicyDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -2375,6 +2471,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.deleteUserstoreUser-fm.DBWriteCount":                             {Name: "Delete Userstore User", NormalizedName: "DeleteUserstoreUser", Code: EventIDPDeleteUserstoreUserDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.deleteUserstoreUser-fm.DBWriteDuration":                          {Name: "Delete Userstore User", NormalizedName: "DeleteUserstoreUser", Code: EventIDPDeleteUserstoreUserDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.deleteUserstoreUser-fm.Duration":                                 {Name: "Delete Userstore User", NormalizedName: "DeleteUserstoreUser", Code: EventIDPDeleteUserstoreUserDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.downloadDSARExport-fm.Count":                                     {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExport, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.downloadDSARExport-fm.DBGetCount":                                {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.downloadDSARExport-fm.DBGetDuration":                             {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.downloadDSARExport-fm.DBSelectCount":                             {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.downloadDSARExport-fm.DBSelectDuration":                          {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.downloadDSARExport-fm.DBWriteCount":                              {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.downloadDSARExport-fm.DBWriteDuration":                           {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.downloadDSARExport-fm.Duration":                                  {Name: "Download DSAR Export", NormalizedName: "DownloadDSARExport", Code: EventIDPDownloadDSARExportDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.executeAccessPolicy-fm.Count":                                    {Name: "Execute Access Policy", NormalizedName: "ExecuteAccessPolicy", Code: EventIDPExecuteAccessPolicy, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.executeAccessPolicy-fm.Duration":                                 {Name: "Execute Access Policy", NormalizedName: "ExecuteAccessPolicy", Code: EventIDPExecuteAccessPolicyDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.executeAccessorHandler-fm.Count":                                 {Name: "Execute Accessor", NormalizedName: "ExecuteAccessor", Code: EventIDPexecuteaccessorhandler, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
@@ -2395,6 +2499,22 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.executeMutatorHandler-fm.Duration":                               {Name: "Execute Mutator Handler", NormalizedName: "ExecuteMutatorHandler", Code: EventIDPExecuteMutatorHandlerDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.executeTransformer-fm.Count":                                     {Name: "Execute Transformer", NormalizedName: "ExecuteTransformer", Code: EventIDPExecuteTransformer, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.executeTransformer-fm.Duration":                                  {Name: "Execute Transformer", NormalizedName: "ExecuteTransformer", Code: EventIDPExecuteTransformerDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessor-fm.Count":                                         {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessor, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.exportAccessor-fm.DBGetCount":                                    {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.exportAccessor-fm.DBGetDuration":                                 {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessor-fm.DBSelectCount":                                 {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.exportAccessor-fm.DBSelectDuration":                              {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessor-fm.DBWriteCount":                                  {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.exportAccessor-fm.DBWriteDuration":                               {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessor-fm.Duration":                                      {Name: "Export Accessor", NormalizedName: "ExportAccessor", Code: EventIDPExportAccessorDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessorToObjectStoreHandler-fm.Count":                     {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandler, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.exportAccessorToObjectStoreHandler-fm.DBGetCount":                {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.exportAccessorToObjectStoreHandler-fm.DBGetDuration":             {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessorToObjectStoreHandler-fm.DBSelectCount":             {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.exportAccessorToObjectStoreHandler-fm.DBSelectDuration":          {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessorToObjectStoreHandler-fm.DBWriteCount":              {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.exportAccessorToObjectStoreHandler-fm.DBWriteDuration":           {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.exportAccessorToObjectStoreHandler-fm.Duration":                  {Name: "Export Accessor To Object Store Handler", NormalizedName: "ExportAccessorToObjectStoreHandler", Code: EventIDPExportAccessorToObjectStoreHandlerDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.getAccessPolicy-fm.Count":                                        {Name: "Get Access Policy", NormalizedName: "GetAccessPolicy", Code: EventIDPGetAccessPolicy, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.getAccessPolicy-fm.DBGetCount":                                   {Name: "Get Access Policy", NormalizedName: "GetAccessPolicy", Code: EventIDPGetAccessPolicyDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.getAccessPolicy-fm.DBGetDuration":                                {Name: "Get Access Policy", NormalizedName: "GetAccessPolicy", Code: EventIDPGetAccessPolicyDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -2475,6 +2595,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.getConsentedPurposesForUser-fm.DBWriteCount":                     {Name: "Get Consented Purposes For User", NormalizedName: "GetConsentedPurposesForUser", Code: EventIDPGetConsentedPurposesForUserDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.getConsentedPurposesForUser-fm.DBWriteDuration":                  {Name: "Get Consented Purposes For User", NormalizedName: "GetConsentedPurposesForUser", Code: EventIDPGetConsentedPurposesForUserDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.getConsentedPurposesForUser-fm.Duration":                         {Name: "Get Consented Purposes For User", NormalizedName: "GetConsentedPurposesForUser", Code: EventIDPGetConsentedPurposesForUserDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getDSARExport-fm.Count":                                          {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExport, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.getDSARExport-fm.DBGetCount":                                     {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.getDSARExport-fm.DBGetDuration":                                  {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getDSARExport-fm.DBSelectCount":                                  {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.getDSARExport-fm.DBSelectDuration":                               {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getDSARExport-fm.DBWriteCount":                                   {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.getDSARExport-fm.DBWriteDuration":                                {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getDSARExport-fm.Duration":                                       {Name: "Get DSAR Export", NormalizedName: "GetDSARExport", Code: EventIDPGetDSARExportDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.getDataSource-fm.Count":                                          {Name: "Get Data Source", NormalizedName: "GetDataSource", Code: EventIDPGetDataSource, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.getDataSource-fm.DBGetCount":                                     {Name: "Get Data Source", NormalizedName: "GetDataSource", Code: EventIDPGetDataSourceDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.getDataSource-fm.DBGetDuration":                                  {Name: "Get Data Source", NormalizedName: "GetDataSource", Code: EventIDPGetDataSourceDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -2709,6 +2837,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.getUserColumnValue-fm.DBWriteCount":                              {Name: "Get User Column Value", NormalizedName: "GetUserColumnValue", Code: EventIDPGetUserColumnValueDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.getUserColumnValue-fm.DBWriteDuration":                           {Name: "Get User Column Value", NormalizedName: "GetUserColumnValue", Code: EventIDPGetUserColumnValueDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.getUserColumnValue-fm.Duration":                                  {Name: "Get User Column Value", NormalizedName: "GetUserColumnValue", Code: EventIDPGetUserColumnValueDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getUserErasure-fm.Count":                                         {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasure, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.getUserErasure-fm.DBGetCount":                                    {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.getUserErasure-fm.DBGetDuration":                                 {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getUserErasure-fm.DBSelectCount":                                 {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.getUserErasure-fm.DBSelectDuration":                              {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getUserErasure-fm.DBWriteCount":                                  {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.getUserErasure-fm.DBWriteDuration":                               {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.getUserErasure-fm.Duration":                                      {Name: "Get User Erasure", NormalizedName: "GetUserErasure", Code: EventIDPGetUserErasureDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.getUserSearchIndex-fm.Count":                                     {Name: "Get User Search Index", NormalizedName: "GetUserSearchIndex", Code: EventIDPGetUserSearchIndex, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.getUserSearchIndex-fm.DBGetCount":                                {Name: "Get User Search Index", NormalizedName: "GetUserSearchIndex", Code: EventIDPGetUserSearchIndexDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.getUserSearchIndex-fm.DBGetDuration":                             {Name: "Get User Search Index", NormalizedName: "GetUserSearchIndex", Code: EventIDPGetUserSearchIndexDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -3059,6 +3195,14 @@ var idpEventMap = map[string]uclog.LogEventTypeInfo{
 	"idp.resolveToken-fm.DBWriteCount":                                    {Name: "Resolve Token", NormalizedName: "ResolveToken", Code: EventIDPResolveTokenDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.resolveToken-fm.DBWriteDuration":                                 {Name: "Resolve Token", NormalizedName: "ResolveToken", Code: EventIDPResolveTokenDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.resolveToken-fm.Duration":                                        {Name: "Resolve Token", NormalizedName: "ResolveToken", Code: EventIDPResolveTokenDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.revokeTokens-fm.Count":                                           {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokens, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
+	"idp.revokeTokens-fm.DBGetCount":                                      {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.revokeTokens-fm.DBGetDuration":                                   {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.revokeTokens-fm.DBSelectCount":                                   {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDBSelect, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.revokeTokens-fm.DBSelectDuration":                                {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDBSelectDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.revokeTokens-fm.DBWriteCount":                                    {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDBWrite, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
+	"idp.revokeTokens-fm.DBWriteDuration":                                 {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDBWriteDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
+	"idp.revokeTokens-fm.Duration":                                        {Name: "Revoke Tokens", NormalizedName: "RevokeTokens", Code: EventIDPRevokeTokensDuration, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryDuration},
 	"idp.setAccessorUserSearchIndex-fm.Count":                             {Name: "Set Accessor User Search Index", NormalizedName: "SetAccessorUserSearchIndex", Code: EventIDPSetAccessorUserSearchIndex, Service: service.IDP, Subcategory: "function", URL: "", Category: uclog.EventCategoryCall},
 	"idp.setAccessorUserSearchIndex-fm.DBGetCount":                        {Name: "Set Accessor User Search Index", NormalizedName: "SetAccessorUserSearchIndex", Code: EventIDPSetAccessorUserSearchIndexDBGet, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryCount},
 	"idp.setAccessorUserSearchIndex-fm.DBGetDuration":                     {Name: "Set Accessor User Search Index", NormalizedName: "SetAccessorUserSearchIndex", Code: EventIDPSetAccessorUserSearchIndexDBGetDuration, Service: service.IDP, Subcategory: "db", URL: "", Category: uclog.EventCategoryDuration},
@@ -9,8 +9,15 @@ import (
 	"userclouds.com/plex/internal/storage"
 )
 
-// CleanPlexTokensForTenant cleans up plex tokens for a tenant
+// CleanPlexTokensForTenant cleans up plex tokens, the expired client assertions recorded to prevent replay, and
+// expired device authorizations for a tenant
 func CleanPlexTokensForTenant(ctx context.Context, tenantDB *ucdb.DB, cacheCfg *cache.Config, maxCandidates int, dryRun bool) error {
 	s := storage.New(ctx, tenantDB, cacheCfg)
-	return ucerr.Wrap(s.CleanPlexTokens(ctx, maxCandidates, dryRun))
+	if err := s.CleanPlexTokens(ctx, maxCandidates, dryRun); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if err := s.CleanClientAssertions(ctx, maxCandidates, dryRun); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return ucerr.Wrap(s.CleanDeviceAuthorizations(ctx, maxCandidates, dryRun))
 }
@@ -0,0 +1,20 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/cache"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/plex/internal/storage"
+)
+
+// RevokePlexTokensForUser deletes all of the plex tokens and login sessions of a user, returning the number of
+// tokens deleted
+func RevokePlexTokensForUser(ctx context.Context, tenantDB *ucdb.DB, cacheCfg *cache.Config, userID uuid.UUID) (int, error) {
+	s := storage.New(ctx, tenantDB, cacheCfg)
+	n, err := s.RevokePlexTokensForSubject(ctx, userID.String())
+	return n, ucerr.Wrap(err)
+}
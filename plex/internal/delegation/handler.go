@@ -55,6 +55,8 @@ func NewHandler(factory provider.Factory, jwtVerifier auth.Verifier, consoleTena
 	hb.HandleFunc("/accept", h.acceptInvite)
 
 	hb.HandleFunc(paths.Auth0RedirectCallbackPath, h.oidcProviderCallback)
+	hb.HandleFunc(paths.UpstreamOIDCLoginPath, h.upstreamOIDCLogin)
+	hb.HandleFunc(paths.UpstreamOIDCCallbackPath, h.upstreamOIDCCallback)
 	hb.HandleFunc(paths.AccountChooser, h.chooseAccount)
 
 	return hb.Build()
@@ -0,0 +1,189 @@
+package delegation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uchttp"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/loginapp"
+	"userclouds.com/plex/internal/oidc"
+	"userclouds.com/plex/internal/otp"
+	"userclouds.com/plex/internal/provider/upstreamoidc"
+	"userclouds.com/plex/internal/storage"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// activeUpstreamOIDCProvider returns the tenant's active provider, which must be an upstream OIDC provider
+func activeUpstreamOIDCProvider(tc tenantplex.TenantConfig) (*tenantplex.Provider, int, error) {
+	p, err := tc.PlexMap.GetActiveProvider()
+	if err != nil {
+		return nil, http.StatusInternalServerError, ucerr.New("missing active provider")
+	}
+
+	if p.Type != tenantplex.ProviderTypeOIDC {
+		return nil, http.StatusFailedDependency, ucerr.New("upstream OIDC login not valid with active provider type != OIDC")
+	}
+	return p, http.StatusOK, nil
+}
+
+// upstreamOIDCLogin starts a login delegated to an upstream OIDC provider, by setting a cookie
+// with the state, nonce and PKCE verifier and redirecting the user to the provider
+func (h *handler) upstreamOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sessionID, err := uuid.FromString(r.URL.Query().Get("session_id"))
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	if _, err := s.GetOIDCLoginSession(ctx, sessionID); err != nil {
+		uchttp.Error(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	tc := tenantconfig.MustGet(ctx)
+	p, code, err := activeUpstreamOIDCProvider(tc)
+	if err != nil {
+		uchttp.Error(ctx, w, err, code)
+		return
+	}
+
+	authr, err := upstreamoidc.NewAuthenticator(ctx, p.OIDC)
+	if err != nil {
+		uchttp.Error(ctx, w, ucerr.Errorf("failed to create new auth: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ls := upstreamoidc.NewLoginState(sessionID)
+	cookie, err := ls.Cookie(isSecure(ctx))
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, cookie)
+
+	uchttp.Redirect(w, r, authr.AuthCodeURLWithPKCE(ls.State, ls.Nonce, ls.CodeVerifier), http.StatusFound)
+}
+
+// upstreamOIDCCallback finishes a login delegated to an upstream OIDC provider, by linking or
+// provisioning the user in the userstore and issuing our own token for them
+func (h *handler) upstreamOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// the login state cookie binds the callback to the browser that started the login, and can only be used once
+	ls, err := upstreamoidc.LoginStateFromRequest(r)
+	http.SetCookie(w, upstreamoidc.ClearLoginStateCookie(isSecure(ctx)))
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	session, err := s.GetOIDCLoginSession(ctx, ls.SessionID)
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	tc := tenantconfig.MustGet(ctx)
+	p, code, err := activeUpstreamOIDCProvider(tc)
+	if err != nil {
+		uchttp.Error(ctx, w, err, code)
+		return
+	}
+
+	// the IdP reports failures (eg. the user denying consent) as an error instead of a code
+	if idpErr := r.URL.Query().Get("error"); idpErr != "" {
+		uchttp.Error(ctx, w, ucerr.Errorf("upstream IdP returned error '%s': %s", idpErr, r.URL.Query().Get("error_description")), http.StatusUnauthorized)
+		return
+	}
+
+	plexApp, _, err := tc.PlexMap.FindAppForClientID(session.ClientID)
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+
+	authr, err := upstreamoidc.NewAuthenticator(ctx, p.OIDC)
+	if err != nil {
+		uchttp.Error(ctx, w, ucerr.Errorf("failed to create new auth: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	tokenInfo, code, err := authr.ProcessAuthCodeCallbackWithPKCE(r, ls.State, ls.Nonce, ls.CodeVerifier)
+	if err != nil {
+		uchttp.Error(ctx, w, err, code)
+		return
+	}
+
+	profile, created, err := upstreamoidc.FederateUser(ctx, &tc, p.OIDC, plexApp, tokenInfo.Profile)
+	if err != nil {
+		if errors.Is(err, upstreamoidc.ErrProvisioningDisabled) {
+			uchttp.Error(ctx, w, err, http.StatusForbidden)
+			return
+		}
+		uchttp.Error(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if created {
+		if err := loginapp.AddLoginAccessForUserIfNecessary(ctx, tc, plexApp, profile.ID); err != nil {
+			uchttp.Error(ctx, w, err, http.StatusInternalServerError)
+			return
+		}
+	} else {
+		hasAccess, err := loginapp.CheckLoginAccessForUser(ctx, tc, plexApp, profile.ID)
+		if err != nil {
+			jsonapi.MarshalErrorL(ctx, w, err, "RestrictedAccessError")
+			return
+		}
+		if !hasAccess {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.Friendlyf(nil, "You are not permitted to login to this app"), "RestrictedAccessDenied", jsonapi.Code(http.StatusForbidden))
+			return
+		}
+	}
+
+	tenantURL := tenantconfig.MustGetTenantURLString(ctx)
+	if err := storage.GenerateUserPlexToken(ctx, tenantURL, &tc, s, profile, session, tokenInfo); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "CreateTokenError")
+		return
+	}
+
+	// If this session has an invite, bind it to this user to mark it used and fail only
+	// if the invite was already used.
+	if err := otp.BindInviteToUser(ctx, s, session, profile.ID, profile.Email, plexApp); err != nil &&
+		!errors.Is(err, otp.ErrNoInviteAssociatedWithSession) {
+		if errors.Is(err, otp.ErrInviteBoundToAnotherUser) {
+			jsonapi.MarshalErrorL(ctx, w, err, "InviteAlreadyBound", jsonapi.Code(http.StatusBadRequest))
+			return
+		}
+		jsonapi.MarshalErrorL(ctx, w, err, "BindInvite")
+		return
+	}
+
+	redirectURL, err := oidc.NewLoginResponse(ctx, session)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "NewLoginResponse")
+		return
+	}
+
+	auditlog.Post(ctx, auditlog.NewEntry(profile.ID, auditlog.LoginSuccess,
+		auditlog.Payload{"ID": plexApp.ID, "Name": plexApp.Name, "Actor": profile.Email, "Type": "OIDC", "OIDCIssuerURL": p.OIDC.IssuerURL, "OIDCSubject": tokenInfo.Claims.Subject}))
+
+	uchttp.Redirect(w, r, redirectURL.RedirectTo, http.StatusFound)
+}
+
+// isSecure returns whether cookies should be marked secure, since they only work over https
+func isSecure(ctx context.Context) bool {
+	return strings.HasPrefix(tenantconfig.MustGetTenantURLString(ctx), "https://")
+}
@@ -54,14 +54,8 @@ func (h *handler) handleEmployeeAuthCallback(w http.ResponseWriter, r *http.Requ
 
 	// extract claims and verify that issuer is console and employee id is valid
 
-	pk, err := ucjwt.LoadRSAPublicKey([]byte(tc.Keys.PublicKey))
-	if err != nil {
-		uchttp.Error(ctx, w, err, http.StatusInternalServerError)
-		return
-	}
-
 	idToken := query.Get("id_token")
-	claims, err := ucjwt.ParseUCClaimsVerified(idToken, pk)
+	claims, err := ucjwt.ParseUCClaimsVerifiedByKeyID(idToken, tc.GetPublicKey)
 	if err != nil {
 		uchttp.Error(ctx, w, err, http.StatusBadRequest)
 		return
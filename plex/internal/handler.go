@@ -2,7 +2,6 @@ package internal
 
 import (
 	"context"
-	"crypto/rsa"
 	"fmt"
 	"net/http"
 
@@ -45,6 +44,11 @@ func Factory(f provider.Factory) Option {
 	return optFunc(func(h *handler) { h.factory = f })
 }
 
+// ClientCertificates returns an Option that configures how TLS client certificates are received and verified
+func ClientCertificates(cfg oidc.ClientCertificateConfig) Option {
+	return optFunc(func(h *handler) { h.certConfig = cfg })
+}
+
 type handler struct {
 	checker              security.ReqValidator
 	mfaHandler           *mfaHandler
@@ -53,6 +57,7 @@ type handler struct {
 	factory              provider.Factory
 	consoleTenantInfo    companyconfig.TenantInfo
 	m2mAuth              jsonclient.Option
+	certConfig           oidc.ClientCertificateConfig
 }
 
 func (h *handler) applyOptions(opts []Option) {
@@ -72,7 +77,7 @@ func NewHandler(
 	m2mAuth jsonclient.Option,
 	consoleTenantInfo companyconfig.TenantInfo,
 	consoleEP *service.Endpoint,
-	consolePK *rsa.PublicKey,
+	getConsolePublicKey oidc.ConsolePublicKeyGetter,
 	opts ...Option,
 ) (http.Handler, error) {
 	h := &handler{
@@ -100,7 +105,7 @@ func NewHandler(
 
 	// API to trigger starting passwordless login.
 	if emailClient != nil {
-		plHandler := newPasswordlessHandler(validator, *emailClient, h.factory)
+		plHandler := newPasswordlessHandler(validator, *emailClient, h.factory, h.mfaHandler)
 		hb.Handle("/passwordless", plHandler)
 	}
 
@@ -130,7 +135,7 @@ func NewHandler(
 		hb.Handle(paths.ResetPasswordRootPath, resetPasswordHandler)
 	}
 
-	oidcHandler, authorize, userinfo := oidc.NewHandler(h.factory, consolePK)
+	oidcHandler, authorize, userinfo := oidc.NewHandler(h.factory, getConsolePublicKey, h.certConfig)
 	hb.Handle("/oidc/", oidcHandler)
 
 	// we also map the same three URLS (/authorize, /token, /userinfo) to these paths
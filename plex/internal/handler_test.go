@@ -21,6 +21,7 @@ import (
 	cachetesthelpers "userclouds.com/infra/cache/testhelpers"
 	"userclouds.com/infra/crypto"
 	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/ucjwt"
 	"userclouds.com/internal/multitenant"
@@ -776,6 +777,16 @@ func TestRefreshToken(t *testing.T) {
 		ctx := multitenant.SetTenantState(ctx, tenantmap.NewTenantState(tf.Tenant, tf.Company, uctest.MustParseURL(tf.Tenant.TenantURL), nil, nil, nil, "", nil, false, nil, nil))
 		refreshToken, err := token.CreateRefreshTokenJWT(ctx, &tc, tokenID, subject, "", "", audience, ucjwt.DefaultValidityRefresh)
 		assert.NoErr(t, err)
+		assert.NoErr(t, tf.Storage.SavePlexToken(ctx, &storage.PlexToken{
+			BaseModel:    ucdb.NewBaseWithID(tokenID),
+			ClientID:     clientID,
+			AuthCode:     crypto.GenerateOpaqueAccessToken(),
+			AccessToken:  "unused",
+			RefreshToken: refreshToken,
+			IDPSubject:   subject,
+			Scopes:       testScope,
+			SessionID:    storage.NonInteractiveSessionID,
+		}))
 
 		refreshTokenClaims, err := ucjwt.ParseUCClaimsVerified(refreshToken, tf.PublicKey)
 		assert.NoErr(t, err)
@@ -787,6 +798,165 @@ func TestRefreshToken(t *testing.T) {
 		assert.Equal(t, claims.Subject, subject)
 		assert.Equal(t, claims.Audience, audience)
 	})
+
+	t.Run("RevokedRefreshToken", func(t *testing.T) {
+		t.Parallel()
+
+		// a validly-signed refresh token with no (or a revoked) plex token behind it
+		tokenID, err := uuid.NewV4()
+		assert.NoErr(t, err)
+
+		ctx := multitenant.SetTenantState(ctx, tenantmap.NewTenantState(tf.Tenant, tf.Company, uctest.MustParseURL(tf.Tenant.TenantURL), nil, nil, nil, "", nil, false, nil, nil))
+		refreshToken, err := token.CreateRefreshTokenJWT(ctx, &tc, tokenID, "revokeduser", "", "", []string{tf.Tenant.TenantURL}, ucjwt.DefaultValidityRefresh)
+		assert.NoErr(t, err)
+
+		_, _, err = refreshTokenTokenExchange(tf, clientID, clientSecret, refreshToken)
+		assert.NotNil(t, err, assert.Must())
+		var oauthe ucerr.OAuthError
+		assert.True(t, errors.As(err, &oauthe), assert.Must())
+		assert.Equal(t, oauthe.Code, http.StatusBadRequest)
+		assert.Equal(t, oauthe.ErrorType, "invalid_grant")
+	})
+
+	t.Run("OtherClientRefreshToken", func(t *testing.T) {
+		t.Parallel()
+
+		// a refresh token issued to another client can't be used by this one
+		tokenID, err := uuid.NewV4()
+		assert.NoErr(t, err)
+
+		ctx := multitenant.SetTenantState(ctx, tenantmap.NewTenantState(tf.Tenant, tf.Company, uctest.MustParseURL(tf.Tenant.TenantURL), nil, nil, nil, "", nil, false, nil, nil))
+		refreshToken, err := token.CreateRefreshTokenJWT(ctx, &tc, tokenID, "otheruser", "", "", []string{tf.Tenant.TenantURL}, ucjwt.DefaultValidityRefresh)
+		assert.NoErr(t, err)
+		assert.NoErr(t, tf.Storage.SavePlexToken(ctx, &storage.PlexToken{
+			BaseModel:    ucdb.NewBaseWithID(tokenID),
+			ClientID:     "other_client_id",
+			AuthCode:     crypto.GenerateOpaqueAccessToken(),
+			AccessToken:  "unused",
+			RefreshToken: refreshToken,
+			IDPSubject:   "otheruser",
+			Scopes:       testScope,
+			SessionID:    storage.NonInteractiveSessionID,
+		}))
+
+		_, _, err = refreshTokenTokenExchange(tf, clientID, clientSecret, refreshToken)
+		assert.NotNil(t, err, assert.Must())
+		var oauthe ucerr.OAuthError
+		assert.True(t, errors.As(err, &oauthe), assert.Must())
+		assert.Equal(t, oauthe.Code, http.StatusBadRequest)
+		assert.Equal(t, oauthe.ErrorType, "invalid_grant")
+	})
+}
+
+func postClientAuthedForm(tf *test.Fixture, path string, query url.Values, clientID, clientSecret string) *http.Response {
+	query.Set("client_id", clientID)
+	query.Set("client_secret", clientSecret)
+	req := tf.RequestFactory.NewRequest(http.MethodPost, path, strings.NewReader(query.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	tf.Handler.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func introspect(t *testing.T, tf *test.Fixture, clientID, clientSecret, tok string) oidc.IntrospectionResponse {
+	t.Helper()
+	resp := postClientAuthedForm(tf, "/oidc/introspect", url.Values{"token": []string{tok}}, clientID, clientSecret)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, assert.Must())
+	var ir oidc.IntrospectionResponse
+	assert.NoErr(t, json.NewDecoder(resp.Body).Decode(&ir))
+	return ir
+}
+
+func TestIntrospectAndRevoke(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tcb, clientID := test.NewBasicTenantConfigBuilder()
+	cs := tcb.SwitchToApp(0).ClientSecret()
+	clientSecret, err := cs.Resolve(ctx)
+	assert.NoErr(t, err)
+	appID := tcb.SwitchToApp(0).ID()
+
+	otherApp := tcb.AddApp()
+	otherClientID := otherApp.ClientID()
+	ocs := otherApp.ClientSecret()
+	otherClientSecret, err := ocs.Resolve(ctx)
+	assert.NoErr(t, err)
+
+	tc := tcb.Build()
+	tf := test.NewFixture(t, tc)
+
+	t.Run("BadClient", func(t *testing.T) {
+		t.Parallel()
+
+		resp := postClientAuthedForm(tf, "/oidc/introspect", url.Values{"token": []string{"foo"}}, clientID, "bad_secret")
+		assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+		resp = postClientAuthedForm(tf, "/oidc/revoke", url.Values{"token": []string{"foo"}}, "bad_client_id", clientSecret)
+		assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		t.Parallel()
+
+		resp := postClientAuthedForm(tf, "/oidc/introspect", url.Values{}, clientID, clientSecret)
+		assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+		resp = postClientAuthedForm(tf, "/oidc/revoke", url.Values{}, clientID, clientSecret)
+		assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		t.Parallel()
+
+		ir := introspect(t, tf, clientID, clientSecret, "badtoken")
+		assert.False(t, ir.Active)
+		assert.Equal(t, ir.ClientID, "")
+
+		// revoking an invalid token is a no-op
+		resp := postClientAuthedForm(tf, "/oidc/revoke", url.Values{"token": []string{"badtoken"}}, clientID, clientSecret)
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		tokenClaims, tokenResponse, err := clientCredentialsTokenExchange(tf, clientID, clientSecret, "")
+		assert.NoErr(t, err)
+
+		// any client in the tenant (eg. a resource server) can introspect the token
+		ir := introspect(t, tf, otherClientID, otherClientSecret, tokenResponse.AccessToken)
+		assert.True(t, ir.Active)
+		assert.Equal(t, ir.ClientID, clientID)
+		assert.Equal(t, ir.Subject, appID.String())
+		assert.Equal(t, ir.JWTID, tokenClaims.ID)
+		assert.Equal(t, ir.TokenType, "Bearer")
+		assert.Equal(t, ir.ExpiresAt, tokenClaims.ExpiresAt.Unix())
+
+		ir = introspect(t, tf, clientID, clientSecret, tokenResponse.RefreshToken)
+		assert.True(t, ir.Active)
+		assert.Equal(t, ir.TokenType, "")
+
+		// but only the client it was issued to can revoke it
+		resp := postClientAuthedForm(tf, "/oidc/revoke", url.Values{"token": []string{tokenResponse.AccessToken}}, otherClientID, otherClientSecret)
+		assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+		var oauthe ucerr.OAuthError
+		assert.NoErr(t, json.NewDecoder(resp.Body).Decode(&oauthe))
+		assert.Equal(t, oauthe.ErrorType, "unauthorized_client")
+
+		resp = postClientAuthedForm(tf, "/oidc/revoke", url.Values{"token": []string{tokenResponse.RefreshToken}, "token_type_hint": []string{"refresh_token"}}, clientID, clientSecret)
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+		// revoking the refresh token revokes the whole grant
+		ir = introspect(t, tf, clientID, clientSecret, tokenResponse.AccessToken)
+		assert.False(t, ir.Active)
+		ir = introspect(t, tf, clientID, clientSecret, tokenResponse.RefreshToken)
+		assert.False(t, ir.Active)
+
+		_, _, err = refreshTokenTokenExchange(tf, clientID, clientSecret, tokenResponse.RefreshToken)
+		assert.NotNil(t, err, assert.Must())
+		var refreshErr ucerr.OAuthError
+		assert.True(t, errors.As(err, &refreshErr), assert.Must())
+		assert.Equal(t, refreshErr.ErrorType, "invalid_grant")
+	})
 }
 
 func TestClientCredentials(t *testing.T) {
@@ -256,12 +256,7 @@ func (h *handler) impersonateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check that the incoming request has a valid access token.
-	pk, err := ucjwt.LoadRSAPublicKey([]byte(tc.Keys.PublicKey))
-	if err != nil {
-		jsonapi.MarshalErrorL(ctx, w, err, "FailedToLoadPublicKey", jsonapi.Code(http.StatusBadRequest))
-		return
-	}
-	claims, err := ucjwt.ParseUCClaimsVerified(req.AccessToken, pk)
+	claims, err := ucjwt.ParseUCClaimsVerifiedByKeyID(req.AccessToken, tc.GetPublicKey)
 	if err != nil {
 		jsonapi.MarshalErrorL(ctx, w, err, "FailedToParseClaims", jsonapi.Code(http.StatusBadRequest))
 		return
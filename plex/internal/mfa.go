@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
@@ -16,6 +17,7 @@ import (
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uchttp/builder"
 	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/webauthn"
 	"userclouds.com/internal/auditlog"
 	"userclouds.com/plex"
 	"userclouds.com/plex/internal/addauthn"
@@ -119,6 +121,26 @@ func getMFASession(ctx context.Context, s *storage.Storage, sessionID uuid.UUID)
 	return session, mfaState, nil
 }
 
+// webAuthnRelyingParty returns the relying party for WebAuthn ceremonies run by the Plex UI for the
+// session. Credentials are scoped to the host the user is logging in on, which may be a custom domain.
+func webAuthnRelyingParty(ctx context.Context, session *storage.OIDCLoginSession) (webauthn.RelyingParty, error) {
+	tc := tenantconfig.MustGet(ctx)
+	app, _, err := tc.PlexMap.FindAppForClientID(session.ClientID)
+	if err != nil {
+		return webauthn.RelyingParty{}, ucerr.Wrap(err)
+	}
+
+	uiURL := reactdev.UIBaseURL(ctx)
+	origin := url.URL{Scheme: tenantconfig.MustGetTenantURL(ctx).Scheme, Host: uiURL.Host}
+
+	rp := webauthn.RelyingParty{ID: uiURL.Hostname(), Name: app.Name, Origin: origin.String()}
+	if err := rp.Validate(); err != nil {
+		return rp, ucerr.Wrap(err)
+	}
+
+	return rp, nil
+}
+
 func saveMFAState(ctx context.Context, s *storage.Storage, mfaState *storage.MFAState, channelID uuid.UUID, challengeState storage.MFAChallengeState) error {
 	mfaState.ChannelID = channelID
 	mfaState.ChallengeState = challengeState
@@ -764,6 +786,44 @@ type MFASubmitSettings struct {
 	RegistrationQRCode       string                   `json:"registration_qr_code"`
 	CustomerServiceLink      string                   `json:"customer_service_link"`
 	Purpose                  storage.MFAPurpose       `json:"mfa_purpose"`
+
+	// only one of these is set, and only for WebAuthn channels that can submit a response
+	WebAuthnCreationOptions *webauthn.CreationOptions `json:"webauthn_creation_options,omitempty"`
+	WebAuthnRequestOptions  *webauthn.RequestOptions  `json:"webauthn_request_options,omitempty"`
+}
+
+// setWebAuthnOptions adds the options the browser needs to run the registration or authentication
+// ceremony for the challenge that was issued on the channel
+func (mss *MFASubmitSettings) setWebAuthnOptions(ctx context.Context, session *storage.OIDCLoginSession, mfaState *storage.MFAState, channel infraoidc.MFAChannel) error {
+	rp, err := webAuthnRelyingParty(ctx, session)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	cred, err := channel.GetWebAuthnCredential()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if cred.IsRegistered() {
+		options := webauthn.NewRequestOptions(rp, channel.ChallengeKey, *cred)
+		mss.WebAuthnRequestOptions = &options
+		return nil
+	}
+
+	var existing []webauthn.Credential
+	for _, c := range mfaState.SupportedChannels.Channels {
+		if c.ChannelType != infraoidc.MFAWebAuthnChannel || c.ID == channel.ID {
+			continue
+		}
+		if existingCred, err := c.GetWebAuthnCredential(); err == nil {
+			existing = append(existing, *existingCred)
+		}
+	}
+
+	options := webauthn.NewCreationOptions(rp, channel.ChallengeKey, *cred, existing)
+	mss.WebAuthnCreationOptions = &options
+	return nil
 }
 
 func (h *mfaHandler) mfaGetSubmitSettingsHandler(w http.ResponseWriter, r *http.Request) {
@@ -849,6 +909,13 @@ func (h *mfaHandler) mfaGetSubmitSettingsHandler(w http.ResponseWriter, r *http.
 		resp.RegistrationQRCode = registrationQRCode
 	}
 
+	if channel.ChannelType == infraoidc.MFAWebAuthnChannel && resp.CanSubmitCode {
+		if err := resp.setWebAuthnOptions(ctx, session, mfaState, channel); err != nil {
+			jsonapi.MarshalErrorL(ctx, w, err, "WebAuthnOptionsError")
+			return
+		}
+	}
+
 	jsonapi.Marshal(w, resp)
 }
 
@@ -959,7 +1026,25 @@ func (h *mfaHandler) mfaSubmitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idpResp, err := client.MFALogin(ctx, mfaState.Token, req.MFACode, channel)
+	// for WebAuthn channels the code is the browser's response to the ceremony, which the IDP
+	// verifies against the relying party the ceremony was run for
+
+	mfaCode := req.MFACode
+	if channel.ChannelType == infraoidc.MFAWebAuthnChannel {
+		rp, err := webAuthnRelyingParty(ctx, session)
+		if err != nil {
+			jsonapi.MarshalErrorL(ctx, w, err, "WebAuthnRelyingPartyError")
+			return
+		}
+
+		mfaCode, err = webauthn.CeremonyResponse{RelyingParty: rp, Response: json.RawMessage(req.MFACode)}.Encode()
+		if err != nil {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(err), "InvalidWebAuthnResponse")
+			return
+		}
+	}
+
+	idpResp, err := client.MFALogin(ctx, mfaState.Token, mfaCode, channel)
 	if err != nil {
 		jsonapi.MarshalErrorL(ctx, w, err, "FailedMFALogin")
 		return
@@ -0,0 +1,318 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/storage"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+const (
+	// clientAssertionTypeJWTBearer is the only client assertion type we support
+	// https://www.rfc-editor.org/rfc/rfc7523#section-2.2
+	clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// we record the ID of every client assertion until it expires to prevent replay, so we don't
+	// accept long-lived assertions (they're meant to be generated per-request anyways)
+	maxClientAssertionLifetime = time.Hour
+	clientAssertionLeeway      = time.Minute
+
+	// TLS is terminated by our load balancer, which (when configured for mutual TLS) forwards the URL-encoded
+	// PEM leaf certificate to us; we only trust it if the deployment says the load balancer sets it
+	clientCertificateHeader = "X-Amzn-Mtls-Clientcert-Leaf"
+
+	appJWKSCacheTTL = 5 * time.Minute
+)
+
+type cachedJWKS struct {
+	keys    *jose.JSONWebKeySet
+	fetched time.Time
+}
+
+// appJWKSCache caches JWK sets fetched from app JWKS URIs, keyed by URI
+var appJWKSCache = struct {
+	sync.Mutex
+	entries map[string]cachedJWKS
+}{entries: map[string]cachedJWKS{}}
+
+// getAppJWKS returns the app's registered public keys, either inline or fetched from its JWKS URI
+func getAppJWKS(ctx context.Context, app *tenantplex.App) (*jose.JSONWebKeySet, error) {
+	if app.JWKSURI == "" {
+		jwks, err := app.GetJWKS()
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if jwks == nil {
+			return nil, ucerr.Errorf("app '%s' has no registered keys", app.Name)
+		}
+		return jwks, nil
+	}
+
+	appJWKSCache.Lock()
+	cached, ok := appJWKSCache.entries[app.JWKSURI]
+	appJWKSCache.Unlock()
+	if ok && time.Since(cached.fetched) < appJWKSCacheTTL {
+		return cached.keys, nil
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err := jsonclient.New(app.JWKSURI).Get(ctx, "", &jwks); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	appJWKSCache.Lock()
+	appJWKSCache.entries[app.JWKSURI] = cachedJWKS{keys: &jwks, fetched: time.Now().UTC()}
+	appJWKSCache.Unlock()
+
+	return &jwks, nil
+}
+
+// isValidClientAssertionAudience returns true if aud identifies this tenant, either as the issuer
+// or as one of our OIDC endpoints (eg. the token endpoint, per RFC 7523 section 3)
+func isValidClientAssertionAudience(ctx context.Context, aud string) bool {
+	issuers := []string{tenantconfig.MustGetTenantURLString(ctx)}
+
+	// if the actually-used host is different from the primary, accept both
+	if tenantURL := multitenant.MustGetTenantState(ctx).GetTenantURL(); tenantURL != issuers[0] {
+		issuers = append(issuers, tenantURL)
+	}
+
+	for _, iss := range issuers {
+		iss = strings.TrimSuffix(iss, "/")
+		if aud == iss || strings.HasPrefix(aud, iss+"/oidc/") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateClientAssertion authenticates an app using a private_key_jwt client assertion
+// https://www.rfc-editor.org/rfc/rfc7523#section-3
+func validateClientAssertion(ctx context.Context, postForm *url.Values) (*tenantplex.App, error) {
+	if assertionType := postForm.Get("client_assertion_type"); assertionType != clientAssertionTypeJWTBearer {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "unsupported client_assertion_type '%s'", assertionType))
+	}
+
+	tok, err := jwt.ParseSigned(postForm.Get("client_assertion"))
+	if err != nil {
+		return nil, ucerr.NewInvalidClientError(err)
+	}
+
+	// the issuer (and subject) of the assertion is the client ID, which we need to look up the key
+	var unverified jwt.Claims
+	if err := tok.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, ucerr.NewInvalidClientError(err)
+	}
+	clientID := unverified.Issuer
+	if formClientID := postForm.Get("client_id"); formClientID != "" && formClientID != clientID {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "client_id does not match client assertion issuer"))
+	}
+
+	tc := tenantconfig.MustGet(ctx)
+	plexApp, _, err := tc.PlexMap.FindAppForClientID(clientID)
+	if err != nil {
+		return nil, ucerr.NewInvalidClientError(err)
+	}
+
+	if plexApp.TokenEndpointAuthMethod != tenantplex.TokenEndpointAuthMethodPrivateKeyJWT {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "app '%s' is not configured to use %s", plexApp.Name, tenantplex.TokenEndpointAuthMethodPrivateKeyJWT))
+	}
+
+	jwks, err := getAppJWKS(ctx, plexApp)
+	if err != nil {
+		return nil, ucerr.NewInvalidClientError(err)
+	}
+
+	var keys []jose.JSONWebKey
+	if len(tok.Headers) > 0 && tok.Headers[0].KeyID != "" {
+		keys = jwks.Key(tok.Headers[0].KeyID)
+	} else {
+		keys = jwks.Keys
+	}
+
+	var claims jwt.Claims
+	verified := false
+	for _, key := range keys {
+		if err := tok.Claims(key.Key, &claims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "client assertion signature could not be verified"))
+	}
+
+	now := time.Now().UTC()
+	if err := claims.ValidateWithLeeway(jwt.Expected{Issuer: clientID, Subject: clientID, Time: now}, clientAssertionLeeway); err != nil {
+		return nil, ucerr.NewInvalidClientError(err)
+	}
+
+	if claims.Expiry == nil {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "client assertion must have an expiration time"))
+	}
+	expires := claims.Expiry.Time().UTC()
+	if expires.After(now.Add(maxClientAssertionLifetime)) {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "client assertion must expire within %v", maxClientAssertionLifetime))
+	}
+
+	validAudience := false
+	for _, aud := range claims.Audience {
+		if isValidClientAssertionAudience(ctx, aud) {
+			validAudience = true
+			break
+		}
+	}
+	if !validAudience {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "client assertion audience does not identify this server"))
+	}
+
+	if claims.ID == "" {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "client assertion must have a jti"))
+	}
+	if err := storage.RecordClientAssertion(ctx, tenantconfig.MustGetStorage(ctx), clientID, claims.ID, expires); err != nil {
+		if errors.Is(err, storage.ErrClientAssertionReplayed) {
+			return nil, ucerr.NewInvalidClientError(err)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	return plexApp, nil
+}
+
+// ClientCertificateConfig configures how the token endpoint receives and verifies TLS client certificates
+type ClientCertificateConfig struct {
+	// TrustHeader is set if the deployment's load balancer verifies client certificates and forwards them
+	// in clientCertificateHeader, which is otherwise stripped from requests
+	TrustHeader bool
+
+	// ClientCAs verifies certificates presented by tls_client_auth apps, which are rejected if it's nil
+	ClientCAs *x509.CertPool
+}
+
+type clientCertificateConfigKey struct{}
+
+func withClientCertificateConfig(r *http.Request, cfg ClientCertificateConfig) *http.Request {
+	if !cfg.TrustHeader {
+		r.Header.Del(clientCertificateHeader)
+	}
+	return r.WithContext(context.WithValue(r.Context(), clientCertificateConfigKey{}, cfg))
+}
+
+func getClientCertificateConfig(ctx context.Context) ClientCertificateConfig {
+	if cfg, ok := ctx.Value(clientCertificateConfigKey{}).(ClientCertificateConfig); ok {
+		return cfg
+	}
+	return ClientCertificateConfig{}
+}
+
+// getClientCertificate returns the TLS client certificate presented with the request, or nil if there isn't one
+func getClientCertificate(r *http.Request) (*x509.Certificate, error) {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0], nil
+	}
+
+	if !getClientCertificateConfig(r.Context()).TrustHeader {
+		return nil, nil
+	}
+
+	header := r.Header.Get(clientCertificateHeader)
+	if header == "" {
+		return nil, nil
+	}
+
+	certPEM, err := url.QueryUnescape(header)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, ucerr.New("failed to decode client certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return cert, nil
+}
+
+// validateClientCertificate authenticates an app using a TLS client certificate
+// https://www.rfc-editor.org/rfc/rfc8705#section-2
+func validateClientCertificate(ctx context.Context, r *http.Request, plexApp *tenantplex.App) error {
+	cert, err := getClientCertificate(r)
+	if err != nil {
+		return ucerr.NewInvalidClientError(err)
+	}
+	if cert == nil {
+		return ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "app '%s' must present a TLS client certificate", plexApp.Name))
+	}
+
+	now := time.Now().UTC()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "TLS client certificate is not currently valid"))
+	}
+
+	switch plexApp.TokenEndpointAuthMethod {
+	case tenantplex.TokenEndpointAuthMethodTLSClientAuth:
+		// we don't rely on the load balancer's trust store, which may trust CAs that shouldn't be able
+		// to issue certificates for apps
+		clientCAs := getClientCertificateConfig(r.Context()).ClientCAs
+		if clientCAs == nil {
+			return ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "TLS client authentication is not configured"))
+		}
+		if _, err := cert.Verify(x509.VerifyOptions{
+			Roots:       clientCAs,
+			CurrentTime: now,
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}); err != nil {
+			return ucerr.NewInvalidClientError(ucerr.Friendlyf(err, "TLS client certificate is not issued by a trusted CA"))
+		}
+
+		if cert.Subject.String() != plexApp.TLSClientAuthSubjectDN {
+			return ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "TLS client certificate subject does not match"))
+		}
+		return nil
+
+	case tenantplex.TokenEndpointAuthMethodSelfSignedTLSClientAuth:
+		jwks, err := getAppJWKS(ctx, plexApp)
+		if err != nil {
+			return ucerr.NewInvalidClientError(err)
+		}
+
+		certKey := jose.JSONWebKey{Key: cert.PublicKey}
+		certThumbprint, err := certKey.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return ucerr.NewInvalidClientError(err)
+		}
+
+		for _, key := range jwks.Keys {
+			thumbprint, err := key.Thumbprint(crypto.SHA256)
+			if err != nil {
+				continue
+			}
+			if string(thumbprint) == string(certThumbprint) {
+				return nil
+			}
+		}
+		return ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "TLS client certificate does not match any registered key"))
+	}
+
+	return ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "app '%s' is not configured for TLS client authentication", plexApp.Name))
+}
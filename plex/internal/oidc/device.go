@@ -0,0 +1,412 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uchttp"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/multitenant"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/provider"
+	"userclouds.com/plex/internal/storage"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// Paths for the OAuth 2.0 Device Authorization Grant (https://www.rfc-editor.org/rfc/rfc8628),
+// relative to the root of the OIDC handler
+const (
+	deviceAuthorizationPath = "/device/code"
+	deviceVerificationPath  = "/device"
+	deviceCallbackPath      = "/device/callback"
+	deviceDenyCallbackPath  = "/device/callback/deny"
+)
+
+// deviceURL returns the absolute URL for one of the device flow paths, using the
+// actually-used tenant URL so users stay on the host their device was talking to
+func deviceURL(ctx context.Context, path string) (*url.URL, error) {
+	u, err := url.Parse(multitenant.MustGetTenantState(ctx).GetTenantURL())
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	u.Path = "/oidc" + path
+	return u, nil
+}
+
+// deviceAuthorization is the device authorization endpoint, where a device (or CLI, etc) that can't
+// easily host a browser requests a device code (which it polls the token endpoint with) and a user code
+// (which the user enters on another device to log in and approve the request).
+// https://www.rfc-editor.org/rfc/rfc8628#section-3.1
+func (h *Handler) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(err), "DeviceAuthParseError")
+		return
+	}
+
+	plexApp, err := validateClient(ctx, r, &r.PostForm)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "InvalidClient", jsonapi.Code(http.StatusUnauthorized))
+		return
+	}
+
+	if !plexApp.GrantTypes.Contains(tenantplex.GrantTypeDeviceCode) {
+		jsonapi.MarshalError(ctx, w,
+			ucerr.Friendlyf(nil, "Client is not authorized to use grant type %s", tenantplex.GrantTypeDeviceCode),
+			jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	// the user will log in through our normal login flow, which requires the openid scope
+	scopes := oidc.SplitTokens(r.PostForm.Get("scope"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid"}
+	}
+	if err := validateScopes(scopes); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(err), "InvalidScope")
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	da, err := storage.CreateDeviceAuthorization(ctx, s, plexApp.ClientID, strings.Join(scopes, " "))
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedDeviceAuthCreate")
+		return
+	}
+
+	verificationURL, err := deviceURL(ctx, deviceVerificationPath)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedVerificationURL")
+		return
+	}
+	verificationURI := verificationURL.String()
+	verificationURL.RawQuery = url.Values{"user_code": []string{storage.FormatUserCode(da.UserCode)}}.Encode()
+
+	jsonapi.Marshal(w, oidc.DeviceAuthorizationResponse{
+		DeviceCode:              da.DeviceCode,
+		UserCode:                storage.FormatUserCode(da.UserCode),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURL.String(),
+		ExpiresIn:               int(storage.DeviceCodeValidity.Seconds()),
+		Interval:                da.PollInterval,
+	})
+}
+
+// deviceVerificationPage renders the page where users enter the user code shown on their device
+func (h *Handler) deviceVerificationPage(w http.ResponseWriter, r *http.Request) {
+	renderDevicePage(w, r, devicePageData{UserCode: r.URL.Query().Get("user_code")})
+}
+
+// deviceVerification handles the user code form submission, and kicks off the normal login flow
+// for the matching device authorization. The user has to log in to deny the request as well as to
+// approve it, since otherwise anyone who guessed a user code could deny it.
+func (h *Handler) deviceVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		renderDevicePage(w, r, devicePageData{Error: "Something went wrong, please try again."})
+		return
+	}
+
+	userCode := r.PostForm.Get("user_code")
+	s := tenantconfig.MustGetStorage(ctx)
+	da, err := s.GetPendingDeviceAuthorizationForUserCode(ctx, userCode)
+	if err != nil {
+		if !errors.Is(err, storage.ErrDeviceCodeNotFound) {
+			uclog.Errorf(ctx, "failed to look up device authorization: %v", err)
+		}
+		renderDevicePage(w, r, devicePageData{UserCode: userCode, Error: "That code is invalid or has expired. Please check the code on your device and try again."})
+		return
+	}
+
+	tc := tenantconfig.MustGet(ctx)
+	plexApp, _, err := tc.PlexMap.FindAppForClientID(da.ClientID)
+	if err != nil {
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "InvalidClientID")
+		return
+	}
+
+	callbackPath := deviceCallbackPath
+	if r.PostForm.Get("action") == "deny" {
+		callbackPath = deviceDenyCallbackPath
+	}
+	callbackURL, err := deviceURL(ctx, callbackPath)
+	if err != nil {
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedCallbackURL")
+		return
+	}
+
+	// we use a normal auth code login session that redirects back to our own callback, which
+	// lets us reuse all of the login machinery (MFA, social, etc) unchanged. The state identifies
+	// the device authorization so the callback can attach the resulting tokens to it.
+	sessionID, err := storage.CreateOIDCLoginSession(ctx, s, da.ClientID,
+		storage.ResponseTypes{storage.AuthorizationCodeResponseType}, callbackURL, da.ID.String(), da.Scopes)
+	if err != nil {
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedLoginSession")
+		return
+	}
+
+	da.SessionID = sessionID
+	if err := s.UpdateDeviceAuthorizationIfStatus(ctx, da, storage.DeviceAuthorizationStatusPending); err != nil {
+		if errors.Is(err, storage.ErrDeviceCodeNotFound) {
+			renderDevicePage(w, r, devicePageData{UserCode: userCode, Error: "That code is invalid or has expired. Please check the code on your device and try again."})
+			return
+		}
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedDeviceAuthSave")
+		return
+	}
+
+	amc, err := provider.NewActiveClient(ctx, h.factory, da.ClientID)
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+
+	loginURL, err := amc.LoginURL(ctx, sessionID, plexApp)
+	if err != nil {
+		uchttp.Error(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+
+	uchttp.Redirect(w, r, loginURL.String(), http.StatusFound)
+}
+
+// deviceCallback is where the login session started by deviceVerification lands after the user logs in to approve
+// the request, at which point we mark the device authorization approved so the device's next poll will get its tokens
+func (h *Handler) deviceCallback(w http.ResponseWriter, r *http.Request) {
+	h.completeDeviceAuthorization(w, r, storage.DeviceAuthorizationStatusApproved)
+}
+
+// deviceDenyCallback is where the login session started by deviceVerification lands after the user logs in to deny
+// the request, at which point we mark the device authorization denied so the device's next poll will fail
+func (h *Handler) deviceDenyCallback(w http.ResponseWriter, r *http.Request) {
+	h.completeDeviceAuthorization(w, r, storage.DeviceAuthorizationStatusDenied)
+}
+
+func (h *Handler) completeDeviceAuthorization(w http.ResponseWriter, r *http.Request, status storage.DeviceAuthorizationStatus) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	daID, err := uuid.FromString(query.Get("state"))
+	if err != nil {
+		uchttp.ErrorL(ctx, w, ucerr.Friendlyf(err, "invalid state"), http.StatusBadRequest, "InvalidState")
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	da, err := s.GetDeviceAuthorization(ctx, daID)
+	if err != nil {
+		uchttp.ErrorL(ctx, w, ucerr.Friendlyf(err, "invalid state"), http.StatusBadRequest, "DeviceAuthNotFound")
+		return
+	}
+
+	if da.Status != storage.DeviceAuthorizationStatusPending || da.IsExpired() {
+		renderDevicePage(w, r, devicePageData{Done: true, Message: "This request has expired or was already completed. Please start again on your device."})
+		return
+	}
+
+	plexToken, err := s.GetPlexTokenForAuthCode(ctx, query.Get("code"))
+	if err != nil {
+		uchttp.ErrorL(ctx, w, ucerr.Wrap(err), http.StatusBadRequest, "AuthCodeNotFound")
+		return
+	}
+
+	// make sure the code we got came from the login session we started for this request
+	if da.SessionID == uuid.Nil || plexToken.SessionID != da.SessionID || plexToken.ClientID != da.ClientID {
+		uchttp.ErrorL(ctx, w, ucerr.Friendlyf(nil, "invalid code"), http.StatusBadRequest, "MismatchedSession")
+		return
+	}
+
+	// the code is only for us, so make sure it can't be used again here or at the token endpoint
+	if err := s.ConsumePlexTokenAuthCode(ctx, plexToken); err != nil {
+		if errors.Is(err, storage.ErrCodeNotFound) {
+			uchttp.ErrorL(ctx, w, ucerr.Friendlyf(err, "invalid code"), http.StatusBadRequest, "AuthCodeUsed")
+			return
+		}
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedAuthCodeConsume")
+		return
+	}
+
+	da.Status = status
+	if status == storage.DeviceAuthorizationStatusApproved {
+		da.PlexTokenID = plexToken.ID
+	}
+	if err := s.UpdateDeviceAuthorizationIfStatus(ctx, da, storage.DeviceAuthorizationStatusPending); err != nil {
+		if errors.Is(err, storage.ErrDeviceCodeNotFound) {
+			renderDevicePage(w, r, devicePageData{Done: true, Message: "This request has expired or was already completed. Please start again on your device."})
+			return
+		}
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedDeviceAuthSave")
+		return
+	}
+
+	if status == storage.DeviceAuthorizationStatusDenied {
+		// the device will never get the tokens from the login, so there's no reason to keep them around
+		if err := s.RevokePlexToken(ctx, plexToken); err != nil {
+			uclog.Errorf(ctx, "failed to revoke plex token %v for denied device authorization %v: %v", plexToken.ID, da.ID, err)
+		}
+		renderDevicePage(w, r, devicePageData{Done: true, Message: "The request was denied. You can close this window."})
+		return
+	}
+
+	renderDevicePage(w, r, devicePageData{Done: true, Message: "Your device is now signed in. You can close this window."})
+}
+
+// deviceCodeTokenExchange handles the device polling the token endpoint with its device code
+// https://www.rfc-editor.org/rfc/rfc8628#section-3.4
+func (h *Handler) deviceCodeTokenExchange(w http.ResponseWriter, r *http.Request, s *storage.Storage, postForm *url.Values, plexApp *tenantplex.App) {
+	ctx := r.Context()
+
+	deviceCode := postForm.Get("device_code")
+	if deviceCode == "" {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "required parameter 'device_code' missing or malformed")), "MissingDeviceCode")
+		return
+	}
+
+	da, err := s.GetDeviceAuthorizationForDeviceCode(ctx, deviceCode)
+	if errors.Is(err, storage.ErrDeviceCodeNotFound) {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrInvalidDeviceCode), "DeviceCodeNotFound")
+		return
+	} else if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "DeviceCodeError")
+		return
+	}
+
+	// the device code is bound to the client that requested it
+	if da.ClientID != plexApp.ClientID {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrInvalidDeviceCode), "MismatchedDeviceClient")
+		return
+	}
+
+	if da.IsExpired() {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrExpiredToken), "DeviceCodeExpired")
+		return
+	}
+
+	switch da.Status {
+	case storage.DeviceAuthorizationStatusDenied:
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrAccessDenied), "DeviceAuthDenied")
+		return
+	case storage.DeviceAuthorizationStatusRedeemed:
+		// don't allow the same device code to be redeemed more than once
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrInvalidDeviceCode), "DeviceCodeUsed")
+		return
+	}
+
+	now := time.Now().UTC()
+	tooSoon := !da.LastPolled.IsZero() && now.Before(da.LastPolled.Add(time.Duration(da.PollInterval)*time.Second))
+	da.LastPolled = now
+	if tooSoon {
+		da.PollInterval += storage.DeviceSlowDownIncrement
+	}
+
+	loadedStatus := da.Status
+	if da.Status == storage.DeviceAuthorizationStatusApproved && !tooSoon {
+		da.Status = storage.DeviceAuthorizationStatusRedeemed
+	}
+
+	// only save if nobody else changed the status since we loaded it, so that concurrent polls can't both redeem
+	// the device code, and a poll can't overwrite the user's approval
+	if err := s.UpdateDeviceAuthorizationIfStatus(ctx, da, loadedStatus); err != nil {
+		if !errors.Is(err, storage.ErrDeviceCodeNotFound) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedDeviceAuthSave")
+			return
+		}
+		if loadedStatus == storage.DeviceAuthorizationStatusApproved {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrInvalidDeviceCode), "DeviceCodeUsed")
+			return
+		}
+		// the user approved or denied the request while we were handling this poll, so the next one will tell
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrAuthorizationPending), "DeviceAuthPending")
+		return
+	}
+
+	if tooSoon {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrSlowDown), "DeviceSlowDown")
+		return
+	}
+
+	if da.Status != storage.DeviceAuthorizationStatusRedeemed {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrAuthorizationPending), "DeviceAuthPending")
+		return
+	}
+
+	token, err := s.GetPlexToken(ctx, da.PlexTokenID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedPlexTokenGet")
+		return
+	}
+
+	jsonapi.Marshal(w, oidc.TokenResponse{
+		TokenType:    "Bearer",
+		IDToken:      token.IDToken,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	})
+}
+
+type devicePageData struct {
+	UserCode string
+	Error    string
+	Done     bool
+	Message  string
+}
+
+func renderDevicePage(w http.ResponseWriter, r *http.Request, data devicePageData) {
+	ctx := r.Context()
+
+	tmp, err := template.New("html").Parse(deviceTemplate)
+	if err != nil {
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedToParseTemplate")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmp.Execute(w, data); err != nil {
+		uchttp.ErrorL(ctx, w, err, http.StatusInternalServerError, "FailedToExecuteTemplate")
+		return
+	}
+}
+
+const deviceTemplate = `
+<html>
+<body style="display: flex;">
+
+<div
+      style="
+        margin: auto;
+        border: 1px solid;
+        border-radius: 5px;
+        padding: 10 25 25 15px;
+      "
+    >
+      <h3>Connect a device</h3>
+	{{- if .Done }}
+	<p>{{ .Message }}</p>
+	{{- else }}
+	<p>Enter the code displayed on your device:</p>
+	{{- if .Error }}
+	<p style="color: red;">{{ .Error }}</p>
+	{{- end }}
+	<form method="POST">
+		<input type="text" name="user_code" value="{{ .UserCode }}" autocomplete="off" autofocus />
+		<button type="submit" name="action" value="approve">Continue</button>
+		<button type="submit" name="action" value="deny">Deny</button>
+	</form>
+	{{- end }}
+</div>
+
+</body>
+</html>
+`
@@ -18,3 +18,33 @@ func NewTestHandler(pf provider.Factory) *Handler {
 func ValidateClient(ctx context.Context, r *http.Request, pf *url.Values) (*tenantplex.App, error) {
 	return validateClient(ctx, r, pf)
 }
+
+// DeviceAuthorization is exported only for testing (in _test.go files)
+func (h *Handler) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	h.deviceAuthorization(w, r)
+}
+
+// WithClientCertificateConfig is exported only for testing (in _test.go files)
+func WithClientCertificateConfig(r *http.Request, cfg ClientCertificateConfig) *http.Request {
+	return withClientCertificateConfig(r, cfg)
+}
+
+// DeviceVerificationPage is exported only for testing (in _test.go files)
+func (h *Handler) DeviceVerificationPage(w http.ResponseWriter, r *http.Request) {
+	h.deviceVerificationPage(w, r)
+}
+
+// DeviceVerification is exported only for testing (in _test.go files)
+func (h *Handler) DeviceVerification(w http.ResponseWriter, r *http.Request) {
+	h.deviceVerification(w, r)
+}
+
+// DeviceCallback is exported only for testing (in _test.go files)
+func (h *Handler) DeviceCallback(w http.ResponseWriter, r *http.Request) {
+	h.deviceCallback(w, r)
+}
+
+// DeviceDenyCallback is exported only for testing (in _test.go files)
+func (h *Handler) DeviceDenyCallback(w http.ResponseWriter, r *http.Request) {
+	h.deviceDenyCallback(w, r)
+}
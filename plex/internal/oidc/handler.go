@@ -27,17 +27,22 @@ import (
 	"userclouds.com/plex/internal/token"
 )
 
+// ConsolePublicKeyGetter returns the console tenant's published public key with the specified key ID,
+// or its active key if the key ID is empty
+type ConsolePublicKeyGetter func(ctx context.Context, keyID string) (*rsa.PublicKey, error)
+
 // Handler handles Plex OIDC requests
 type Handler struct {
 	*uchttp.ServeMux
 
-	factory   provider.Factory
-	consolePK *rsa.PublicKey
+	factory             provider.Factory
+	getConsolePublicKey ConsolePublicKeyGetter
+	certConfig          ClientCertificateConfig
 }
 
 // NewHandler returns an OIDC http handler, along with specific authorize & userinfo handlers to multihome them for auth0 compat
-func NewHandler(factory provider.Factory, consolePK *rsa.PublicKey) (http.Handler, func(http.ResponseWriter, *http.Request), func(http.ResponseWriter, *http.Request)) {
-	h := &Handler{factory: factory, consolePK: consolePK}
+func NewHandler(factory provider.Factory, getConsolePublicKey ConsolePublicKeyGetter, certConfig ClientCertificateConfig) (http.Handler, func(http.ResponseWriter, *http.Request), func(http.ResponseWriter, *http.Request)) {
+	h := &Handler{factory: factory, getConsolePublicKey: getConsolePublicKey, certConfig: certConfig}
 
 	hb := builder.NewHandlerBuilder()
 	handlerBuilder(hb, h)
@@ -47,6 +52,13 @@ func NewHandler(factory provider.Factory, consolePK *rsa.PublicKey) (http.Handle
 	hb.HandleFunc("/employeetoken", h.m2mEmployeeTokenExchange)
 	hb.HandleFunc("/userinfo", h.UserInfoHandler)
 
+	hb.HandleFunc(deviceAuthorizationPath, h.deviceAuthorization)
+	hb.MethodHandler(deviceVerificationPath).Get(h.deviceVerificationPage).Post(h.deviceVerification)
+	hb.HandleFunc(deviceCallbackPath, h.deviceCallback)
+	hb.HandleFunc(deviceDenyCallbackPath, h.deviceDenyCallback)
+	hb.HandleFunc(introspectPath, h.introspect)
+	hb.HandleFunc(revokePath, h.revoke)
+
 	h.ServeMux = hb.Build()
 
 	return h, h.Authorize, h.UserInfoHandler
@@ -54,6 +66,11 @@ func NewHandler(factory provider.Factory, consolePK *rsa.PublicKey) (http.Handle
 
 //go:generate genhandler /oidc --public
 
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.ServeMux.ServeHTTP(w, withClientCertificateConfig(r, h.certConfig))
+}
+
 func validateScopes(scopes []string) error {
 	openIDFound := false
 	for i := range scopes {
@@ -219,10 +236,19 @@ func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) {
 
 // takes both request & postForm because we've already validated postform earlier
 // RFC6749 section 2.3 outlines acceptable forms of client authorization, mostly either
-// by passing client ID & secret in the POST body, or via Basic Auth
+// by passing client ID & secret in the POST body, or via Basic Auth. We also support
+// private_key_jwt (RFC7523) and mutual TLS (RFC8705) client authentication.
 func validateClient(ctx context.Context, r *http.Request, postForm *url.Values) (*tenantplex.App, error) {
+	if postForm.Has("client_assertion") {
+		app, err := validateClientAssertion(ctx, postForm)
+		return app, ucerr.Wrap(err)
+	}
+
+	usedBasicAuth := false
 	clientID, clientSecret, ok := r.BasicAuth()
 	if ok {
+		usedBasicAuth = true
+
 		// this is a strange bug I ran into while building a nodejs sample, but TL;DR RFC 6749 says
 		// that if you're using basicauth as a client auth method, you must encode the client ID & secret
 		// according to application/x-www-form-urlencoded, which means we need to unescape them here
@@ -254,6 +280,26 @@ func validateClient(ctx context.Context, r *http.Request, postForm *url.Values)
 		return nil, ucerr.NewInvalidClientError(err)
 	}
 
+	switch plexApp.TokenEndpointAuthMethod {
+	case tenantplex.TokenEndpointAuthMethodTLSClientAuth, tenantplex.TokenEndpointAuthMethodSelfSignedTLSClientAuth:
+		if err := validateClientCertificate(ctx, r, plexApp); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		return plexApp, nil
+	case tenantplex.TokenEndpointAuthMethodClientSecretBasic:
+		if !usedBasicAuth {
+			return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "app '%s' must authenticate with %s", plexApp.Name, plexApp.TokenEndpointAuthMethod))
+		}
+	case tenantplex.TokenEndpointAuthMethodClientSecretPost:
+		if usedBasicAuth {
+			return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "app '%s' must authenticate with %s", plexApp.Name, plexApp.TokenEndpointAuthMethod))
+		}
+	}
+
+	if !plexApp.UsesClientSecret() {
+		return nil, ucerr.NewInvalidClientError(ucerr.Friendlyf(nil, "app '%s' must authenticate with %s", plexApp.Name, plexApp.TokenEndpointAuthMethod))
+	}
+
 	cs, err := plexApp.ClientSecret.Resolve(ctx)
 	if err != nil {
 		return nil, ucerr.Wrap(ucerr.ErrInvalidClientSecret) // TODO (sgarrity 6/24): technically 500?
@@ -268,11 +314,12 @@ func validateClient(ctx context.Context, r *http.Request, postForm *url.Values)
 
 // TokenExchange is the OAuth/OIDC-compliant endpoint for all things related to token exchange.
 // At the moment it supports:
-// 1. Authorization Code flow (exchange a code for a token).
+// 1. Authorization Code flow (exchange a code for a token), optionally with PKCE ("pixie").
 // 2. Client Credentials flow (exchange client ID + client credentials for a token).
-// TODO:
-// 3. PKCE ("pixie") support for Authorization Code flow
-// 4. Refresh token
+// 3. Refresh token
+// 4. Resource Owner Password flow
+// 5. Device Authorization flow (exchange a device code for a token once the user approves).
+// 6. Token Exchange (exchange a user's access token for a narrower one to call downstream services).
 func (h *Handler) TokenExchange(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -315,11 +362,17 @@ func (h *Handler) TokenExchange(w http.ResponseWriter, r *http.Request) {
 		h.clientCredentialsTokenExchange(w, r, s, &r.PostForm, plexApp)
 		return
 	case tenantplex.GrantTypeRefreshToken:
-		h.refreshTokenTokenExchange(w, r, &r.PostForm)
+		h.refreshTokenTokenExchange(w, r, s, &r.PostForm)
 		return
 	case tenantplex.GrantTypePassword:
 		h.passwordTokenExchange(w, r, s, &r.PostForm, plexApp)
 		return
+	case tenantplex.GrantTypeDeviceCode:
+		h.deviceCodeTokenExchange(w, r, s, &r.PostForm, plexApp)
+		return
+	case tenantplex.GrantTypeTokenExchange:
+		h.subjectTokenExchange(w, r, s, &r.PostForm, plexApp)
+		return
 	}
 
 	// This can't be reached but we'll guard against it anyways
@@ -362,8 +415,11 @@ func (h *Handler) m2mEmployeeTokenExchange(w http.ResponseWriter, r *http.Reques
 		audiences = append(audiences, tenantURL)
 	}
 
-	// Parse the subject JWT, using the console public key (console tenant is required to have issued the JWT)
-	claims, err := ucjwt.ParseUCClaimsVerified(subjectJWT, h.consolePK)
+	// Parse the subject JWT, using the console public key (console tenant is required to have issued the JWT).
+	// We look the key up for each request, since the console tenant's keys can be rotated while we're running
+	claims, err := ucjwt.ParseUCClaimsVerifiedByKeyID(subjectJWT, func(keyID string) (*rsa.PublicKey, error) {
+		return h.getConsolePublicKey(ctx, keyID)
+	})
 	if err != nil {
 		jsonapi.MarshalErrorL(ctx, w, err, "FailedToParseClaims", jsonapi.Code(http.StatusBadRequest))
 		return
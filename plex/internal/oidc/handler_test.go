@@ -3,18 +3,29 @@ package oidc_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
 
 	"userclouds.com/idp"
 	"userclouds.com/infra/assert"
@@ -22,6 +33,7 @@ import (
 	"userclouds.com/infra/jsonapi"
 	"userclouds.com/infra/oidc"
 	"userclouds.com/infra/secret"
+	"userclouds.com/infra/ucjwt"
 	"userclouds.com/internal/multitenant"
 	"userclouds.com/internal/tenantmap"
 	"userclouds.com/internal/tenantplex"
@@ -31,6 +43,7 @@ import (
 	plexOIDC "userclouds.com/plex/internal/oidc"
 	"userclouds.com/plex/internal/provider"
 	"userclouds.com/plex/internal/provider/iface"
+	"userclouds.com/plex/internal/storage"
 	"userclouds.com/plex/internal/tenantconfig"
 	"userclouds.com/plex/manager"
 )
@@ -198,3 +211,491 @@ func TestPasswordGrant(t *testing.T) {
 	assert.Equal(t, tokenResponse.TokenType, "Bearer")
 	assert.Equal(t, tokenResponse.IDToken, "") // no ID tokens for ROPC
 }
+
+func TestDeviceCodeGrant(t *testing.T) {
+	ctx := context.Background()
+	cc, lc, ccs := testhelpers.NewTestStorage(t)
+	company, ten, tdb := testhelpers.ProvisionConsoleCompanyAndTenant(ctx, t, ccs, cc, lc)
+
+	mgr := manager.NewFromDB(tdb, cachetesthelpers.NewCacheConfig())
+	tp, err := mgr.GetTenantPlex(ctx, ten.ID)
+	assert.NoErr(t, err)
+
+	h := plexOIDC.NewTestHandler(provider.ProdFactory{})
+
+	app := tp.PlexConfig.PlexMap.Apps[0]
+	cs, err := app.ClientSecret.Resolve(ctx)
+	assert.NoErr(t, err)
+
+	tc := &tenantplex.TenantConfig{
+		PlexMap: tp.PlexConfig.PlexMap,
+		Keys:    testkeys.Config,
+	}
+	ctx = tenantconfig.TESTONLYSetTenantConfig(tc)
+	ctx = multitenant.SetTenantState(ctx, tenantmap.NewTenantState(ten, company, uctest.MustParseURL(ten.TenantURL), tdb, nil, nil, "", ccs, false, nil, nil))
+
+	post := func(handler http.HandlerFunc, vals url.Values) *httptest.ResponseRecorder {
+		vals.Set("client_id", app.ClientID)
+		vals.Set("client_secret", cs)
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(vals.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(ctx)
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		return rr
+	}
+
+	// the device grant must be enabled on the app
+	rr := post(h.DeviceAuthorization, url.Values{"scope": []string{"openid profile"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), "Client is not authorized to use grant type")
+
+	tp.PlexConfig.PlexMap.Apps[0].GrantTypes = tenantplex.GrantTypes{tenantplex.GrantTypeDeviceCode}
+	assert.NoErr(t, mgr.SaveTenantPlex(ctx, tp))
+
+	rr = post(h.DeviceAuthorization, url.Values{"scope": []string{"openid profile"}})
+	assert.Equal(t, rr.Code, http.StatusOK, assert.Must())
+	var dar oidc.DeviceAuthorizationResponse
+	assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &dar))
+	assert.NotEqual(t, dar.DeviceCode, "")
+	assert.Equal(t, len(dar.UserCode), 9) // XXXX-XXXX
+	assert.True(t, strings.HasSuffix(dar.VerificationURI, "/oidc/device"))
+	assert.Contains(t, dar.VerificationURIComplete, "user_code=")
+	assert.Equal(t, dar.Interval, storage.DefaultDevicePollInterval)
+
+	poll := func() *httptest.ResponseRecorder {
+		return post(h.TokenExchange, url.Values{
+			"grant_type":  []string{string(tenantplex.GrantTypeDeviceCode)},
+			"device_code": []string{dar.DeviceCode},
+		})
+	}
+
+	// the user hasn't approved yet
+	rr = poll()
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"authorization_pending"`)
+
+	// polling again right away should get us slowed down
+	rr = poll()
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"slow_down"`)
+
+	s := tenantconfig.MustGetStorage(ctx)
+	da, err := s.GetPendingDeviceAuthorizationForUserCode(ctx, strings.ToLower(dar.UserCode))
+	assert.NoErr(t, err)
+	assert.Equal(t, da.DeviceCode, dar.DeviceCode)
+	assert.Equal(t, da.PollInterval, storage.DefaultDevicePollInterval+storage.DeviceSlowDownIncrement)
+
+	// simulate the user logging in on the verification page
+	profile := &iface.UserProfile{ID: uuid.Must(uuid.NewV4()).String()}
+	token, err := storage.GenerateUserPlexTokenWithoutSession(ctx, tc, s, profile, []string{"openid"}, &app)
+	assert.NoErr(t, err)
+	da.Status = storage.DeviceAuthorizationStatusApproved
+	da.PlexTokenID = token.ID
+	da.LastPolled = time.Time{}
+	assert.NoErr(t, s.SaveDeviceAuthorization(ctx, da))
+
+	rr = poll()
+	assert.Equal(t, rr.Code, http.StatusOK, assert.Must())
+	var tokenResponse oidc.TokenResponse
+	assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &tokenResponse))
+	assert.Equal(t, tokenResponse.TokenType, "Bearer")
+	assert.Equal(t, tokenResponse.AccessToken, token.AccessToken)
+
+	// device codes can only be redeemed once
+	da, err = s.GetDeviceAuthorizationForDeviceCode(ctx, dar.DeviceCode)
+	assert.NoErr(t, err)
+	da.LastPolled = time.Time{}
+	assert.NoErr(t, s.SaveDeviceAuthorization(ctx, da))
+	rr = poll()
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"invalid_grant"`)
+}
+
+func TestDeviceVerificationAndCallback(t *testing.T) {
+	ctx := context.Background()
+	cc, lc, ccs := testhelpers.NewTestStorage(t)
+	company, ten, tdb := testhelpers.ProvisionConsoleCompanyAndTenant(ctx, t, ccs, cc, lc)
+
+	mgr := manager.NewFromDB(tdb, cachetesthelpers.NewCacheConfig())
+	tp, err := mgr.GetTenantPlex(ctx, ten.ID)
+	assert.NoErr(t, err)
+	tp.PlexConfig.PlexMap.Apps[0].GrantTypes = tenantplex.GrantTypes{tenantplex.GrantTypeDeviceCode}
+	assert.NoErr(t, mgr.SaveTenantPlex(ctx, tp))
+
+	h := plexOIDC.NewTestHandler(provider.ProdFactory{})
+
+	app := tp.PlexConfig.PlexMap.Apps[0]
+	cs, err := app.ClientSecret.Resolve(ctx)
+	assert.NoErr(t, err)
+
+	tc := &tenantplex.TenantConfig{
+		PlexMap: tp.PlexConfig.PlexMap,
+		Keys:    testkeys.Config,
+	}
+	ctx = tenantconfig.TESTONLYSetTenantConfig(tc)
+	ctx = multitenant.SetTenantState(ctx, tenantmap.NewTenantState(ten, company, uctest.MustParseURL(ten.TenantURL), tdb, nil, nil, "", ccs, false, nil, nil))
+	s := tenantconfig.MustGetStorage(ctx)
+
+	serve := func(handler http.HandlerFunc, method string, target string, vals url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(vals.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(ctx)
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		return rr
+	}
+
+	startDeviceAuthorization := func() oidc.DeviceAuthorizationResponse {
+		rr := serve(h.DeviceAuthorization, http.MethodPost, "/", url.Values{"client_id": []string{app.ClientID}, "client_secret": []string{cs}})
+		assert.Equal(t, rr.Code, http.StatusOK, assert.Must())
+		var dar oidc.DeviceAuthorizationResponse
+		assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &dar))
+		return dar
+	}
+
+	poll := func(dar oidc.DeviceAuthorizationResponse) *httptest.ResponseRecorder {
+		return serve(h.TokenExchange, http.MethodPost, "/", url.Values{
+			"client_id":     []string{app.ClientID},
+			"client_secret": []string{cs},
+			"grant_type":    []string{string(tenantplex.GrantTypeDeviceCode)},
+			"device_code":   []string{dar.DeviceCode},
+		})
+	}
+
+	// simulates the user logging in through the session started by the verification page, and returns the code
+	// the login redirects back to the callback with
+	login := func(da *storage.DeviceAuthorization) *storage.PlexToken {
+		profile := &iface.UserProfile{ID: uuid.Must(uuid.NewV4()).String()}
+		pt, err := storage.GenerateUserPlexTokenWithoutSession(ctx, tc, s, profile, []string{"openid"}, &app)
+		assert.NoErr(t, err)
+		pt.SessionID = da.SessionID
+		assert.NoErr(t, s.SavePlexToken(ctx, pt))
+		return pt
+	}
+
+	dar := startDeviceAuthorization()
+
+	// the verification page is prefilled with the code from the complete verification URI
+	rr := serve(h.DeviceVerificationPage, http.MethodGet, "/?user_code="+url.QueryEscape(dar.UserCode), url.Values{})
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Contains(t, rr.Body.String(), dar.UserCode)
+
+	// unknown codes are rejected without starting a login
+	rr = serve(h.DeviceVerification, http.MethodPost, "/", url.Values{"user_code": []string{"BCDF-GHJK"}, "action": []string{"approve"}})
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Contains(t, rr.Body.String(), "That code is invalid or has expired")
+
+	// a known code starts a login that lands on the callback
+	rr = serve(h.DeviceVerification, http.MethodPost, "/", url.Values{"user_code": []string{dar.UserCode}, "action": []string{"approve"}})
+	assert.Equal(t, rr.Code, http.StatusFound, assert.Must())
+	da, err := s.GetDeviceAuthorizationForDeviceCode(ctx, dar.DeviceCode)
+	assert.NoErr(t, err)
+	assert.NotEqual(t, da.SessionID, uuid.Nil)
+	assert.Equal(t, da.Status, storage.DeviceAuthorizationStatusPending)
+	session, err := s.GetOIDCLoginSession(ctx, da.SessionID)
+	assert.NoErr(t, err)
+	assert.True(t, strings.HasSuffix(session.RedirectURI, "/oidc/device/callback"))
+
+	// codes that weren't issued for the login session are rejected
+	profile := &iface.UserProfile{ID: uuid.Must(uuid.NewV4()).String()}
+	otherToken, err := storage.GenerateUserPlexTokenWithoutSession(ctx, tc, s, profile, []string{"openid"}, &app)
+	assert.NoErr(t, err)
+	rr = serve(h.DeviceCallback, http.MethodGet, "/?"+url.Values{"state": []string{da.ID.String()}, "code": []string{otherToken.AuthCode}}.Encode(), url.Values{})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+
+	pt := login(da)
+	callbackQuery := "/?" + url.Values{"state": []string{da.ID.String()}, "code": []string{pt.AuthCode}}.Encode()
+	rr = serve(h.DeviceCallback, http.MethodGet, callbackQuery, url.Values{})
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Contains(t, rr.Body.String(), "Your device is now signed in")
+
+	da, err = s.GetDeviceAuthorizationForDeviceCode(ctx, dar.DeviceCode)
+	assert.NoErr(t, err)
+	assert.Equal(t, da.Status, storage.DeviceAuthorizationStatusApproved)
+	assert.Equal(t, da.PlexTokenID, pt.ID)
+
+	// the callback consumes the code, so it can't be used again
+	_, err = s.GetPlexTokenForAuthCode(ctx, pt.AuthCode)
+	assert.ErrorIs(t, err, storage.ErrCodeNotFound)
+	rr = serve(h.DeviceCallback, http.MethodGet, callbackQuery, url.Values{})
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Contains(t, rr.Body.String(), "This request has expired or was already completed")
+
+	rr = poll(dar)
+	assert.Equal(t, rr.Code, http.StatusOK, assert.Must())
+	var tokenResponse oidc.TokenResponse
+	assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &tokenResponse))
+	assert.Equal(t, tokenResponse.AccessToken, pt.AccessToken)
+
+	// denying a request requires logging in too
+	dar = startDeviceAuthorization()
+	rr = serve(h.DeviceVerification, http.MethodPost, "/", url.Values{"user_code": []string{dar.UserCode}, "action": []string{"deny"}})
+	assert.Equal(t, rr.Code, http.StatusFound, assert.Must())
+	da, err = s.GetDeviceAuthorizationForDeviceCode(ctx, dar.DeviceCode)
+	assert.NoErr(t, err)
+	assert.Equal(t, da.Status, storage.DeviceAuthorizationStatusPending)
+	session, err = s.GetOIDCLoginSession(ctx, da.SessionID)
+	assert.NoErr(t, err)
+	assert.True(t, strings.HasSuffix(session.RedirectURI, "/oidc/device/callback/deny"))
+
+	pt = login(da)
+	rr = serve(h.DeviceDenyCallback, http.MethodGet, "/?"+url.Values{"state": []string{da.ID.String()}, "code": []string{pt.AuthCode}}.Encode(), url.Values{})
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Contains(t, rr.Body.String(), "The request was denied")
+
+	da, err = s.GetDeviceAuthorizationForDeviceCode(ctx, dar.DeviceCode)
+	assert.NoErr(t, err)
+	assert.Equal(t, da.Status, storage.DeviceAuthorizationStatusDenied)
+	_, err = s.GetPlexToken(ctx, pt.ID)
+	assert.NotNil(t, err)
+
+	rr = poll(dar)
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"access_denied"`)
+
+	// expired device authorizations are cleaned up
+	da.Expires = time.Now().UTC().Add(-time.Hour)
+	assert.NoErr(t, s.SaveDeviceAuthorization(ctx, da))
+	assert.NoErr(t, s.CleanDeviceAuthorizations(ctx, 10, false))
+	_, err = s.GetDeviceAuthorizationForDeviceCode(ctx, dar.DeviceCode)
+	assert.ErrorIs(t, err, storage.ErrDeviceCodeNotFound)
+}
+
+func TestPrivateKeyJWTClientAuth(t *testing.T) {
+	ctx := context.Background()
+	cc, lc, ccs := testhelpers.NewTestStorage(t)
+	company, ten, tdb := testhelpers.ProvisionConsoleCompanyAndTenant(ctx, t, ccs, cc, lc)
+
+	mgr := manager.NewFromDB(tdb, cachetesthelpers.NewCacheConfig())
+	tp, err := mgr.GetTenantPlex(ctx, ten.ID)
+	assert.NoErr(t, err)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoErr(t, err)
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: privateKey.Public(), KeyID: "key1", Algorithm: "RS256", Use: "sig"}}})
+	assert.NoErr(t, err)
+
+	app := &tp.PlexConfig.PlexMap.Apps[0]
+	cs, err := app.ClientSecret.Resolve(ctx)
+	assert.NoErr(t, err)
+	app.TokenEndpointAuthMethod = tenantplex.TokenEndpointAuthMethodPrivateKeyJWT
+	app.JWKS = string(jwks)
+	assert.NoErr(t, app.Validate())
+
+	ctx = tenantconfig.TESTONLYSetTenantConfig(&tenantplex.TenantConfig{PlexMap: tp.PlexConfig.PlexMap})
+	ctx = multitenant.SetTenantState(ctx, tenantmap.NewTenantState(ten, company, uctest.MustParseURL(ten.TenantURL), tdb, nil, nil, "", ccs, false, nil, nil))
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: privateKey, KeyID: "key1"}}, nil)
+	assert.NoErr(t, err)
+	newAssertion := func(aud string) string {
+		now := time.Now().UTC()
+		assertion, err := josejwt.Signed(signer).Claims(josejwt.Claims{
+			Issuer:   app.ClientID,
+			Subject:  app.ClientID,
+			Audience: josejwt.Audience{aud},
+			ID:       uuid.Must(uuid.NewV4()).String(),
+			IssuedAt: josejwt.NewNumericDate(now),
+			Expiry:   josejwt.NewNumericDate(now.Add(time.Minute)),
+		}).CompactSerialize()
+		assert.NoErr(t, err)
+		return assertion
+	}
+	validate := func(vals url.Values) (*tenantplex.App, error) {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		return plexOIDC.ValidateClient(ctx, r, &vals)
+	}
+	withAssertion := func(assertion string) url.Values {
+		return url.Values{
+			"client_assertion_type": []string{"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      []string{assertion},
+		}
+	}
+
+	assertion := newAssertion(ten.TenantURL + "/oidc/token")
+	got, err := validate(withAssertion(assertion))
+	assert.NoErr(t, err)
+	assert.Equal(t, got.ClientID, app.ClientID)
+
+	// the same assertion can't be used twice
+	_, err = validate(withAssertion(assertion))
+	assert.ErrorIs(t, err, storage.ErrClientAssertionReplayed)
+
+	// assertions meant for another server are rejected
+	_, err = validate(withAssertion(newAssertion("https://example.com/oidc/token")))
+	assert.NotNil(t, err)
+
+	// and the app can no longer use its client secret
+	_, err = validate(url.Values{"client_id": []string{app.ClientID}, "client_secret": []string{cs}})
+	assert.NotNil(t, err)
+}
+
+func TestTLSClientAuth(t *testing.T) {
+	newCA := func() (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoErr(t, err)
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Test CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		assert.NoErr(t, err)
+		cert, err := x509.ParseCertificate(der)
+		assert.NoErr(t, err)
+		return cert, key
+	}
+	newClientCertPEM := func(ca *x509.Certificate, caKey *ecdsa.PrivateKey) string {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoErr(t, err)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "client.example.com", Organization: []string{"Example"}},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, key.Public(), caKey)
+		assert.NoErr(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	ca, caKey := newCA()
+	otherCA, otherCAKey := newCA()
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+
+	app := tenantplex.App{
+		ClientID:                "tls-client",
+		TokenEndpointAuthMethod: tenantplex.TokenEndpointAuthMethodTLSClientAuth,
+		TLSClientAuthSubjectDN:  "CN=client.example.com,O=Example",
+	}
+	ctx := tenantconfig.TESTONLYSetTenantConfig(&tenantplex.TenantConfig{PlexMap: tenantplex.PlexMap{Apps: []tenantplex.App{app}}})
+
+	validate := func(certPEM string, cfg plexOIDC.ClientCertificateConfig) (*http.Request, error) {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("X-Amzn-Mtls-Clientcert-Leaf", url.QueryEscape(certPEM))
+		r = plexOIDC.WithClientCertificateConfig(r, cfg)
+		_, err := plexOIDC.ValidateClient(ctx, r, &url.Values{"client_id": []string{app.ClientID}})
+		return r, err
+	}
+
+	certPEM := newClientCertPEM(ca, caKey)
+	_, err := validate(certPEM, plexOIDC.ClientCertificateConfig{TrustHeader: true, ClientCAs: clientCAs})
+	assert.NoErr(t, err)
+
+	// the header is only trusted (and otherwise stripped) if the deployment says the load balancer sets it
+	r, err := validate(certPEM, plexOIDC.ClientCertificateConfig{ClientCAs: clientCAs})
+	assert.NotNil(t, err)
+	assert.Equal(t, r.Header.Get("X-Amzn-Mtls-Clientcert-Leaf"), "")
+
+	// certificates must be issued by a configured CA
+	_, err = validate(newClientCertPEM(otherCA, otherCAKey), plexOIDC.ClientCertificateConfig{TrustHeader: true, ClientCAs: clientCAs})
+	assert.NotNil(t, err)
+	_, err = validate(certPEM, plexOIDC.ClientCertificateConfig{TrustHeader: true})
+	assert.NotNil(t, err)
+}
+
+func TestTokenExchangeGrant(t *testing.T) {
+	ctx := context.Background()
+	cc, lc, ccs := testhelpers.NewTestStorage(t)
+	company, ten, tdb := testhelpers.ProvisionConsoleCompanyAndTenant(ctx, t, ccs, cc, lc)
+
+	mgr := manager.NewFromDB(tdb, cachetesthelpers.NewCacheConfig())
+	tp, err := mgr.GetTenantPlex(ctx, ten.ID)
+	assert.NoErr(t, err)
+
+	h := plexOIDC.NewTestHandler(provider.ProdFactory{})
+
+	app := &tp.PlexConfig.PlexMap.Apps[0]
+	cs, err := app.ClientSecret.Resolve(ctx)
+	assert.NoErr(t, err)
+	app.GrantTypes = tenantplex.GrantTypes{tenantplex.GrantTypeTokenExchange}
+	app.TokenExchangeConfig.AllowedAudiences = []string{"https://orders.example.com"}
+
+	tc := &tenantplex.TenantConfig{
+		PlexMap: tp.PlexConfig.PlexMap,
+		Keys:    testkeys.Config,
+	}
+	ctx = tenantconfig.TESTONLYSetTenantConfig(tc)
+	ctx = multitenant.SetTenantState(ctx, tenantmap.NewTenantState(ten, company, uctest.MustParseURL(ten.TenantURL), tdb, nil, nil, "", ccs, false, nil, nil))
+
+	s := tenantconfig.MustGetStorage(ctx)
+	profile := &iface.UserProfile{ID: uuid.Must(uuid.NewV4()).String()}
+	subjectToken, err := storage.GenerateUserPlexTokenWithoutSession(ctx, tc, s, profile, []string{"openid", "profile"}, app)
+	assert.NoErr(t, err)
+
+	exchange := func(vals url.Values) *httptest.ResponseRecorder {
+		vals.Set("grant_type", string(tenantplex.GrantTypeTokenExchange))
+		vals.Set("client_id", app.ClientID)
+		vals.Set("client_secret", cs)
+		vals.Set("subject_token_type", "urn:ietf:params:oauth:token-type:access_token")
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(vals.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(ctx)
+		rr := httptest.NewRecorder()
+		h.TokenExchange(rr, r)
+		return rr
+	}
+
+	// the app isn't allowed to request tokens for other audiences
+	rr := exchange(url.Values{"subject_token": []string{subjectToken.AccessToken}, "audience": []string{"https://billing.example.com"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"invalid_target"`)
+
+	// or to broaden the scope
+	rr = exchange(url.Values{"subject_token": []string{subjectToken.AccessToken}, "audience": []string{"https://orders.example.com"}, "scope": []string{"openid email"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"invalid_scope"`)
+
+	rr = exchange(url.Values{"subject_token": []string{subjectToken.AccessToken}, "audience": []string{"https://orders.example.com"}, "scope": []string{"openid"}})
+	assert.Equal(t, rr.Code, http.StatusOK, assert.Must())
+	var tokenResponse oidc.TokenResponse
+	assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &tokenResponse))
+	assert.Equal(t, tokenResponse.IssuedTokenType, "urn:ietf:params:oauth:token-type:access_token")
+	assert.Equal(t, tokenResponse.Scope, "openid")
+
+	claims, err := ucjwt.ParseUCClaimsVerifiedByKeyID(tokenResponse.AccessToken, tc.GetPublicKey)
+	assert.NoErr(t, err)
+	assert.Equal(t, claims.Subject, profile.ID)
+	assert.Equal(t, claims.Audience, []string{"https://orders.example.com"})
+	assert.Equal(t, claims.Actor.Subject, app.ClientID)
+
+	// exchanging the exchanged token again records the whole actor chain
+	app.TokenExchangeConfig.AllowedAudiences = append(app.TokenExchangeConfig.AllowedAudiences, "https://shipping.example.com")
+	rr = exchange(url.Values{"subject_token": []string{tokenResponse.AccessToken}, "audience": []string{"https://shipping.example.com"}})
+	assert.Equal(t, rr.Code, http.StatusOK, assert.Must())
+	assert.NoErr(t, json.Unmarshal(rr.Body.Bytes(), &tokenResponse))
+	claims, err = ucjwt.ParseUCClaimsVerifiedByKeyID(tokenResponse.AccessToken, tc.GetPublicKey)
+	assert.NoErr(t, err)
+	assert.Equal(t, claims.Actor.Subject, app.ClientID)
+	assert.NotNil(t, claims.Actor.Actor, assert.Must())
+	assert.Equal(t, claims.Actor.Actor.Subject, app.ClientID)
+
+	// the app can't exchange tokens that were issued to another app, or act as another app
+	otherApp := *app
+	otherApp.ClientID = "other-client"
+	otherToken, err := storage.GenerateUserPlexTokenWithoutSession(ctx, tc, s, profile, []string{"openid", "profile"}, &otherApp)
+	assert.NoErr(t, err)
+	rr = exchange(url.Values{"subject_token": []string{otherToken.AccessToken}, "audience": []string{"https://orders.example.com"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), "subject_token was not issued to app")
+	rr = exchange(url.Values{"subject_token": []string{subjectToken.AccessToken}, "audience": []string{"https://orders.example.com"},
+		"actor_token": []string{otherToken.AccessToken}, "actor_token_type": []string{"urn:ietf:params:oauth:token-type:access_token"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), "actor_token was not issued to app")
+
+	// revoked tokens can't be exchanged
+	assert.NoErr(t, s.RevokePlexToken(ctx, subjectToken))
+	rr = exchange(url.Values{"subject_token": []string{subjectToken.AccessToken}, "audience": []string{"https://orders.example.com"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), `"error":"invalid_request"`)
+
+	// and neither can the tokens that were exchanged for them
+	rr = exchange(url.Values{"subject_token": []string{tokenResponse.AccessToken}, "audience": []string{"https://shipping.example.com"}})
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), "subject_token is invalid, expired or revoked")
+}
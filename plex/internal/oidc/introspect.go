@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/ucjwt"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/plex/internal/storage"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+const (
+	introspectPath = "/introspect"
+	revokePath     = "/revoke"
+)
+
+// errTokenNotFound means we couldn't tie a token back to a valid, unrevoked PlexToken.
+// Per RFC 7662 & RFC 7009 this isn't reported to the caller as an error.
+var errTokenNotFound = ucerr.New("token not found")
+
+// lookupPlexToken validates a token issued by this tenant and loads the PlexToken it was issued with.
+// Every token we issue for a PlexToken (including refreshed access tokens) uses the PlexToken ID as its 'jti'.
+func lookupPlexToken(ctx context.Context, s *storage.Storage, token string) (*storage.PlexToken, *oidc.UCTokenClaims, error) {
+	tc := tenantconfig.MustGet(ctx)
+
+	claims, err := ucjwt.ParseUCClaimsVerifiedByKeyID(token, tc.GetPublicKey)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(errTokenNotFound)
+	}
+
+	tokenID, err := uuid.FromString(claims.ID)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(errTokenNotFound)
+	}
+
+	pt, err := s.GetPlexToken(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ucerr.Wrap(errTokenNotFound)
+		}
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	// ID tokens share the jti of the PlexToken, but they aren't bearer credentials
+	if token == pt.IDToken {
+		return nil, nil, ucerr.Wrap(errTokenNotFound)
+	}
+
+	if err := ensureSubjectTokensActive(ctx, s, pt); err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	return pt, claims, nil
+}
+
+// ensureSubjectTokensActive returns errTokenNotFound if a token issued by token exchange was exchanged for a
+// subject token that has since been revoked (or was itself exchanged for one that has), so that revoking a token
+// also revokes every token delegated from it
+func ensureSubjectTokensActive(ctx context.Context, s *storage.Storage, pt *storage.PlexToken) error {
+	for subjectTokenID := pt.SubjectTokenID; !subjectTokenID.IsNil(); {
+		subjectPT, err := s.GetPlexToken(ctx, subjectTokenID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ucerr.Wrap(errTokenNotFound)
+			}
+			return ucerr.Wrap(err)
+		}
+		subjectTokenID = subjectPT.SubjectTokenID
+	}
+	return nil
+}
+
+// introspect implements OAuth 2.0 Token Introspection, which lets resource servers ask whether
+// an access or refresh token is still active. See https://www.rfc-editor.org/rfc/rfc7662
+func (h *Handler) introspect(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(err), "IntrospectParseError")
+		return
+	}
+
+	if _, err := validateClient(ctx, r, &r.PostForm); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "InvalidClient", jsonapi.Code(http.StatusUnauthorized))
+		return
+	}
+
+	// NB: we don't need token_type_hint since we can tell the token type from its claims
+	token := r.PostForm.Get("token")
+	if token == "" {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "required parameter 'token' missing")), "MissingToken")
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	pt, claims, err := lookupPlexToken(ctx, s, token)
+	if err != nil {
+		if !errors.Is(err, errTokenNotFound) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedTokenLookup")
+			return
+		}
+		jsonapi.Marshal(w, oidc.IntrospectionResponse{Active: false})
+		return
+	}
+
+	resp := oidc.IntrospectionResponse{
+		Active:   true,
+		Scope:    pt.Scopes,
+		ClientID: pt.ClientID,
+		Subject:  claims.Subject,
+		Issuer:   claims.Issuer,
+		JWTID:    claims.ID,
+		Audience: claims.Audience,
+		Actor:    claims.Actor,
+	}
+	if len(claims.RefreshAudience) == 0 {
+		resp.TokenType = "Bearer"
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		resp.NotBefore = claims.NotBefore.Unix()
+	}
+
+	jsonapi.Marshal(w, resp)
+}
+
+// revoke implements OAuth 2.0 Token Revocation. Revoking either the access or refresh token
+// revokes the whole PlexToken, since they were issued together. See https://www.rfc-editor.org/rfc/rfc7009
+func (h *Handler) revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(err), "RevokeParseError")
+		return
+	}
+
+	plexApp, err := validateClient(ctx, r, &r.PostForm)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "InvalidClient", jsonapi.Code(http.StatusUnauthorized))
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "required parameter 'token' missing")), "MissingToken")
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	pt, _, err := lookupPlexToken(ctx, s, token)
+	if err != nil {
+		if !errors.Is(err, errTokenNotFound) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedTokenLookup")
+			return
+		}
+		// invalid (or already-revoked) tokens aren't an error per RFC 7009 section 2.2
+		uclog.Debugf(ctx, "ignoring revocation request for unknown token")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if pt.ClientID != plexApp.ClientID {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrTokenNotIssuedToClient), "TokenNotIssuedToClient")
+		return
+	}
+
+	if err := s.RevokePlexToken(ctx, pt); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedTokenRevoke")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,6 +1,8 @@
 package oidc
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"net/url"
 	"time"
@@ -11,10 +13,11 @@ import (
 	"userclouds.com/infra/oidc"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/ucjwt"
+	"userclouds.com/plex/internal/storage"
 	"userclouds.com/plex/internal/tenantconfig"
 )
 
-func (h *Handler) refreshTokenTokenExchange(w http.ResponseWriter, r *http.Request, postForm *url.Values) {
+func (h *Handler) refreshTokenTokenExchange(w http.ResponseWriter, r *http.Request, s *storage.Storage, postForm *url.Values) {
 	ctx := r.Context()
 	tc := tenantconfig.MustGet(ctx)
 	tu := tenantconfig.MustGetTenantURLString(ctx)
@@ -25,12 +28,6 @@ func (h *Handler) refreshTokenTokenExchange(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	pk, err := ucjwt.LoadRSAPublicKey([]byte(tc.Keys.PublicKey))
-	if err != nil {
-		jsonapi.MarshalErrorL(ctx, w, err, "FailedToLoadPublicKey", jsonapi.Code(http.StatusBadRequest))
-		return
-	}
-
 	keyText, err := tc.Keys.PrivateKey.Resolve(ctx)
 	if err != nil {
 		jsonapi.MarshalErrorL(ctx, w, err, "FailedToFindPrivateKey", jsonapi.Code(http.StatusBadRequest))
@@ -43,7 +40,7 @@ func (h *Handler) refreshTokenTokenExchange(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	claims, err := ucjwt.ParseUCClaimsVerified(refreshToken, pk)
+	claims, err := ucjwt.ParseUCClaimsVerifiedByKeyID(refreshToken, tc.GetPublicKey)
 	if err != nil {
 		jsonapi.MarshalErrorL(ctx, w, err, "FailedToParseClaims", jsonapi.Code(http.StatusBadRequest))
 		return
@@ -61,6 +58,22 @@ func (h *Handler) refreshTokenTokenExchange(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// make sure the refresh token hasn't been revoked, and was issued to the client using it. We only look it
+	// up once the client has authenticated, so unauthenticated callers can't probe which tokens are revoked.
+	pt, err := s.GetPlexToken(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrInvalidRefreshToken), "RevokedRefreshToken")
+			return
+		}
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedPlexTokenGet")
+		return
+	}
+	if pt.ClientID != app.ClientID {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Wrap(ucerr.ErrInvalidRefreshToken), "MismatchedRefreshClient")
+		return
+	}
+
 	// don't let the new token expire later than the refresh token
 	validFor := min(app.TokenValidity.Access, int64(claims.ExpiresAt.Sub(time.Now().UTC()).Seconds()))
 	claims.Audience = claims.RefreshAudience // move the refresh audience to the audience
@@ -0,0 +1,188 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/auditlog"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/storage"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// token type identifiers for token exchange, see https://www.rfc-editor.org/rfc/rfc8693#section-3
+// NB: we only exchange (and issue) access tokens, but since our access tokens are JWTs we accept either type
+const (
+	tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	tokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+func isExchangeableTokenType(tokenType string) bool {
+	return tokenType == tokenTypeAccessToken || tokenType == tokenTypeJWT
+}
+
+// lookupExchangeableToken validates a subject or actor token, which must be an unexpired, unrevoked
+// access token issued by this tenant
+func lookupExchangeableToken(ctx context.Context, s *storage.Storage, token string) (*storage.PlexToken, *oidc.UCTokenClaims, error) {
+	pt, claims, err := lookupPlexToken(ctx, s, token)
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	// refresh tokens aren't bearer credentials for the subject, so they can't be exchanged
+	if len(claims.RefreshAudience) > 0 {
+		return nil, nil, ucerr.Wrap(errTokenNotFound)
+	}
+
+	return pt, claims, nil
+}
+
+// isTokenIssuedForApp returns true if a token was issued to the app, or lists the app's client ID as an audience
+// (e.g. because another app exchanged a token with it as the audience, to call it on a user's behalf)
+func isTokenIssuedForApp(pt *storage.PlexToken, claims *oidc.UCTokenClaims, plexApp *tenantplex.App) bool {
+	return pt.ClientID == plexApp.ClientID || slices.Contains(claims.Audience, plexApp.ClientID)
+}
+
+// splitScopes handles both the space-delimited scopes we store for interactive logins and
+// the comma-delimited scopes we store for ROPC
+func splitScopes(scopes string) []string {
+	return strings.FieldsFunc(scopes, func(r rune) bool { return r == ' ' || r == ',' })
+}
+
+// subjectTokenExchange implements the OAuth 2.0 Token Exchange grant, which lets a service that received a
+// user's access token get a new token for the same user with a narrower audience and scope, so that it can
+// call downstream services on the user's behalf. See https://www.rfc-editor.org/rfc/rfc8693
+func (h *Handler) subjectTokenExchange(w http.ResponseWriter, r *http.Request, s *storage.Storage, postForm *url.Values, plexApp *tenantplex.App) {
+	ctx := r.Context()
+
+	subjectToken := postForm.Get("subject_token")
+	if subjectToken == "" {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "required parameter 'subject_token' missing")), "MissingSubjectToken")
+		return
+	}
+	if tokenType := postForm.Get("subject_token_type"); !isExchangeableTokenType(tokenType) {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "unsupported subject_token_type '%s'", tokenType)), "InvalidSubjectTokenType")
+		return
+	}
+	if tokenType := postForm.Get("requested_token_type"); tokenType != "" && tokenType != tokenTypeAccessToken {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "unsupported requested_token_type '%s'", tokenType)), "InvalidRequestedTokenType")
+		return
+	}
+
+	subjectPT, subjectClaims, err := lookupExchangeableToken(ctx, s, subjectToken)
+	if err != nil {
+		if errors.Is(err, errTokenNotFound) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(err, "subject_token is invalid, expired or revoked")), "InvalidSubjectToken")
+			return
+		}
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedTokenLookup")
+		return
+	}
+
+	// the app can only exchange tokens that were issued to it, or that were exchanged for it as an audience,
+	// so that it can't act on behalf of users whose tokens it obtained some other way
+	if !isTokenIssuedForApp(subjectPT, subjectClaims, plexApp) {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "subject_token was not issued to app '%s'", plexApp.Name)), "InvalidSubjectTokenAudience")
+		return
+	}
+
+	// the actor is the party that will use the exchanged token: either the subject of the actor token
+	// if one was provided, or otherwise the app making the request
+	actor := &oidc.ActorClaim{Subject: plexApp.ClientID}
+	if actorToken := postForm.Get("actor_token"); actorToken != "" {
+		if tokenType := postForm.Get("actor_token_type"); !isExchangeableTokenType(tokenType) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "unsupported actor_token_type '%s'", tokenType)), "InvalidActorTokenType")
+			return
+		}
+
+		actorPT, actorClaims, err := lookupExchangeableToken(ctx, s, actorToken)
+		if err != nil {
+			if errors.Is(err, errTokenNotFound) {
+				jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(err, "actor_token is invalid, expired or revoked")), "InvalidActorToken")
+				return
+			}
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewServerError(err), "FailedTokenLookup")
+			return
+		}
+
+		// the actor token must have been issued to the calling app, otherwise an app could claim to act as any
+		// party whose token it holds
+		if actorPT.ClientID != plexApp.ClientID {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "actor_token was not issued to app '%s'", plexApp.Name)), "InvalidActorToken")
+			return
+		}
+		actor.Subject = actorClaims.Subject
+	} else if postForm.Has("actor_token_type") {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "'actor_token_type' specified without 'actor_token'")), "UnexpectedActorTokenType")
+		return
+	}
+
+	// if the subject token was itself exchanged, keep the chain of prior actors
+	actor.Actor = subjectClaims.Actor
+
+	// we treat 'audience' (logical names) and 'resource' (URIs) the same way, since
+	// either way the app needs to be explicitly allowed to request them
+	audiences := append(slices.Clone((*postForm)["audience"]), (*postForm)["resource"]...)
+	if len(audiences) == 0 {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewInvalidTargetError(ucerr.Friendlyf(nil, "at least one 'audience' or 'resource' must be specified")), "MissingAudience")
+		return
+	}
+	for _, aud := range audiences {
+		if !plexApp.CanExchangeForAudience(aud) {
+			jsonapi.MarshalErrorL(ctx, w, ucerr.NewInvalidTargetError(ucerr.Friendlyf(nil, "app '%s' is not allowed to request tokens for audience '%s'", plexApp.Name, aud)), "AudienceNotAllowed")
+			return
+		}
+	}
+
+	// the exchanged token can only narrow the subject token's scope
+	scopes := splitScopes(subjectPT.Scopes)
+	if scopeParam := postForm.Get("scope"); scopeParam != "" {
+		requested := oidc.SplitTokens(scopeParam)
+		for _, scope := range requested {
+			if !slices.Contains(scopes, scope) {
+				jsonapi.MarshalErrorL(ctx, w, ucerr.NewInvalidScopeError(ucerr.Friendlyf(nil, "scope '%s' was not granted to the subject token", scope)), "ScopeNotAllowed")
+				return
+			}
+		}
+		scopes = requested
+	}
+
+	// and it can't outlive the subject token
+	validFor := plexApp.TokenValidity.Access
+	if subjectClaims.ExpiresAt != nil {
+		if remaining := int64(time.Until(subjectClaims.ExpiresAt.Time).Seconds()); remaining < validFor {
+			validFor = remaining
+		}
+	}
+	if validFor <= 0 {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.NewRequestError(ucerr.Friendlyf(nil, "subject_token is invalid, expired or revoked")), "InvalidSubjectToken")
+		return
+	}
+
+	tc := tenantconfig.MustGet(ctx)
+	plexToken, err := storage.GenerateDelegatedPlexToken(ctx, &tc, s, plexApp, subjectPT, subjectClaims, audiences, scopes, actor, validFor)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "FailedToGenerateDelegatedToken")
+		return
+	}
+
+	auditlog.Post(ctx, auditlog.NewEntry(actor.Subject, auditlog.TokenExchange,
+		auditlog.Payload{"ID": plexApp.ID, "Name": plexApp.Name, "Actor ID": actor.Subject, "Subject ID": subjectClaims.Subject,
+			"Subject Token ID": subjectPT.ID, "Token ID": plexToken.ID, "Audience": audiences, "Scope": plexToken.Scopes}))
+
+	jsonapi.Marshal(w, oidc.TokenResponse{
+		TokenType:       "Bearer",
+		AccessToken:     plexToken.AccessToken,
+		ExpiresIn:       int(validFor),
+		IssuedTokenType: tokenTypeAccessToken,
+		Scope:           plexToken.Scopes,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gofrs/uuid"
 
 	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/oidc"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uchttp"
 	"userclouds.com/infra/uchttp/builder"
@@ -17,6 +18,7 @@ import (
 	"userclouds.com/plex/internal/loginapp"
 	"userclouds.com/plex/internal/otp"
 	"userclouds.com/plex/internal/provider"
+	"userclouds.com/plex/internal/storage"
 	"userclouds.com/plex/internal/tenantconfig"
 )
 
@@ -40,21 +42,25 @@ func (plr PasswordlessLoginRequest) extraValidate() error {
 }
 
 type passwordlessHandler struct {
-	checker security.ReqValidator
-	email   email.Client
-	factory provider.Factory
+	checker    security.ReqValidator
+	email      email.Client
+	factory    provider.Factory
+	mfaHandler *mfaHandler
 }
 
-func newPasswordlessHandler(checker security.ReqValidator, email email.Client, factory provider.Factory) http.Handler {
+func newPasswordlessHandler(checker security.ReqValidator, email email.Client, factory provider.Factory, mfaHandler *mfaHandler) http.Handler {
 	h := &passwordlessHandler{
-		checker: checker,
-		email:   email,
-		factory: factory,
+		checker:    checker,
+		email:      email,
+		factory:    factory,
+		mfaHandler: mfaHandler,
 	}
 
 	hb := builder.NewHandlerBuilder()
 	// API to trigger starting passwordless login.
 	hb.MethodHandler("/start").Post(h.passwordlessStartHandler)
+	// API to trigger starting a passkey (WebAuthn) login.
+	hb.MethodHandler("/passkey").Post(h.passkeyStartHandler)
 	return hb.Build()
 }
 
@@ -130,3 +136,118 @@ func (h *passwordlessHandler) passwordlessStartHandler(w http.ResponseWriter, r
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// passkeyStartHandler triggers a passkey login flow, where the user logs in with a WebAuthn credential
+// they previously registered as an MFA channel instead of a password. We issue a WebAuthn challenge
+// for the user's credential and hand off to the MFA submit page, which completes the login.
+// TODO: support usernameless login with discoverable credentials.
+func (h *passwordlessHandler) passkeyStartHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req PasswordlessLoginRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "InvalidRequest")
+		return
+	}
+
+	uclog.Infof(ctx, "passkey login request for %s", req.Email)
+
+	if h.checker.IsCallBlocked(ctx, req.Email) {
+		uchttp.ErrorL(ctx, w, ucerr.New("call volume exceeded"), http.StatusForbidden, "CallBlocked")
+		return
+	}
+
+	s := tenantconfig.MustGetStorage(ctx)
+	session, err := s.GetOIDCLoginSession(ctx, req.SessionID)
+	if err != nil {
+		uclog.Debugf(ctx, "invalid session ID specified: %s", req.SessionID)
+		jsonapi.MarshalErrorL(ctx, w, ucerr.New("invalid session_id specified"), "InvalidID", jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	tc := tenantconfig.MustGet(ctx)
+	app, _, err := tc.PlexMap.FindAppForClientID(session.ClientID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "FindApp")
+		return
+	}
+
+	_, channelTypes, err := tc.GetMFASettings(session.ClientID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "GetMFASettingsError")
+		return
+	}
+	if !channelTypes[oidc.MFAWebAuthnChannel] {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Friendlyf(nil, "Passkey login is not enabled for this app"), "PasskeysNotEnabled", jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	mgmtClient, err := provider.NewActiveManagementClient(ctx, h.factory, session.ClientID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "ProviderInitErr")
+		return
+	}
+
+	userID, err := otp.ResolveEmailToUser(ctx, mgmtClient, req.Email)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "ResolveEmailToUser")
+		return
+	}
+
+	hasAccess, err := loginapp.CheckLoginAccessForUser(ctx, tc, app, userID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "RestrictedAccessError")
+		return
+	}
+	if !hasAccess {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Friendlyf(nil, "You are not permitted to login to this app"), "RestrictedAccessDenied", jsonapi.Code(http.StatusForbidden))
+		return
+	}
+
+	// find the user's registered passkeys, preferring the primary MFA channel if it is one
+
+	activeClient, err := provider.NewActiveClient(ctx, h.factory, session.ClientID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "ProviderInitErr")
+		return
+	}
+
+	idpResp, err := activeClient.MFAGetChannels(ctx, userID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "MFAGetChannelsError")
+		return
+	}
+
+	passkeys, _ := idpResp.SupportedMFAChannels.GetVerifiedChannels(oidc.MFAChannelTypeSet{oidc.MFAWebAuthnChannel: true})
+	if len(passkeys.Channels) == 0 {
+		jsonapi.MarshalErrorL(ctx, w, ucerr.Friendlyf(nil, "No passkey is registered for this account"), "NoPasskeys", jsonapi.Code(http.StatusBadRequest))
+		return
+	}
+
+	var passkey oidc.MFAChannel
+	if primary, err := passkeys.FindPrimaryChannel(); err == nil {
+		passkey = primary
+	} else {
+		for _, c := range passkeys.Channels {
+			if c.LastVerified.After(passkey.LastVerified) || passkey.ID.IsNil() {
+				passkey = c
+			}
+		}
+	}
+
+	// issue the challenge and redirect to the MFA submit page to run the ceremony
+
+	mfaState, err := h.mfaHandler.createMFAState(ctx, session, storage.MFAPurposeLogin, idpResp.MFAToken, idpResp.MFAProvider, passkeys, false)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "CreateMFAStateError")
+		return
+	}
+
+	resp, err := h.mfaHandler.issueMFAChallenge(ctx, s, session, mfaState, passkey.ID)
+	if err != nil {
+		jsonapi.MarshalErrorL(ctx, w, err, "FailedMFAChallenge")
+		return
+	}
+
+	jsonapi.Marshal(w, resp)
+}
@@ -38,6 +38,14 @@ const (
 // and redirecting them back again to the customer
 const Auth0RedirectCallbackPath = "/callback"
 
+// UpstreamOIDCLoginPath starts a login with an upstream OIDC provider, by binding the login to the browser
+// with a cookie before redirecting the user to the provider
+const UpstreamOIDCLoginPath = "/oidclogin"
+
+// UpstreamOIDCCallbackPath is the callback for upstream OIDC providers, which the user is always redirected
+// to for login, where we link or provision the user before issuing our own token
+const UpstreamOIDCCallbackPath = "/oidccallback"
+
 // AccountChooser lets you choose between delegated accounts
 // TODO: get rid of trailing Path in names in paths package
 const AccountChooser = "/chooser"
@@ -15,7 +15,9 @@ import (
 	"userclouds.com/plex/internal/provider/cognito"
 	"userclouds.com/plex/internal/provider/employee"
 	"userclouds.com/plex/internal/provider/iface"
+	"userclouds.com/plex/internal/provider/ldap"
 	"userclouds.com/plex/internal/provider/uc"
+	"userclouds.com/plex/internal/provider/upstreamoidc"
 	"userclouds.com/plex/internal/tenantconfig"
 )
 
@@ -124,6 +126,10 @@ func (pf ProdFactory) NewClient(ctx context.Context, provider tenantplex.Provide
 			return nil, ucerr.New("Email client is required for cognito provider")
 		}
 		return cognito.NewClient(ctx, provider.ID, provider.Name, provider.Cognito, providerAppID, plexClientID, *pf.EmailClient)
+	case tenantplex.ProviderTypeLDAP:
+		return ldap.NewClient(ctx, provider.ID, provider.Name, provider.LDAP, providerAppID, plexClientID)
+	case tenantplex.ProviderTypeOIDC:
+		return upstreamoidc.NewClient(ctx, provider.ID, provider.Name, provider.OIDC, providerAppID)
 	default:
 		return nil, ucerr.Errorf("unrecognized provider.Type %s", provider.Type)
 	}
@@ -143,6 +149,10 @@ func (ProdFactory) NewManagementClient(ctx context.Context, tc *tenantplex.Tenan
 		return uc.NewManagementClient(ctx, tc, p.ID, p.Name, *p.UC, appID, appOrgID)
 	case tenantplex.ProviderTypeCognito:
 		return cognito.NewManagementClient(ctx, tc, p.ID, p.Name, p.Cognito, appID, appOrgID)
+	case tenantplex.ProviderTypeLDAP:
+		return ldap.NewManagementClient(ctx, p.ID, p.Name, p.LDAP)
+	case tenantplex.ProviderTypeOIDC:
+		return upstreamoidc.NewManagementClient(ctx, tc, p.ID, p.Name, appID, appOrgID)
 	default:
 		return nil, ucerr.Errorf("unrecognized provider.Type %s", p.Type)
 	}
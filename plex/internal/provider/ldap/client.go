@@ -0,0 +1,186 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/idp"
+	"userclouds.com/infra/jsonclient"
+	infraLDAP "userclouds.com/infra/ldap"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/apiclient"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/paths"
+	"userclouds.com/plex/internal/provider/iface"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// edgeClient is the subset of the authz client used to sync group membership, so it can be faked in tests
+type edgeClient interface {
+	CreateObject(ctx context.Context, id, typeID uuid.UUID, alias string, opts ...authz.Option) (*authz.Object, error)
+	FindEdge(ctx context.Context, sourceObjectID, targetObjectID, edgeTypeID uuid.UUID, opts ...authz.Option) (*authz.Edge, error)
+	CreateEdge(ctx context.Context, id, sourceObjectID, targetObjectID, edgeTypeID uuid.UUID, opts ...authz.Option) (*authz.Edge, error)
+	DeleteEdge(ctx context.Context, edgeID uuid.UUID, opts ...authz.Option) error
+}
+
+type authClient struct {
+	iface.BaseClient
+	directory
+
+	orgID uuid.UUID
+
+	// only set if the provider has group mappings
+	edges edgeClient
+}
+
+// NewClient creates an LDAP provider client that implements iface.Client.
+func NewClient(ctx context.Context,
+	id uuid.UUID,
+	name string,
+	p *tenantplex.LDAPProvider,
+	providerAppID uuid.UUID,
+	plexClientID string) (iface.Client, error) {
+	tc := tenantconfig.MustGet(ctx)
+	loginApp, _, err := tc.PlexMap.FindAppForClientID(plexClientID)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	var edges edgeClient
+	if len(p.GroupMappings) > 0 {
+		azc, err := apiclient.NewAuthzClientFromTenantStateWithClientSecret(ctx, loginApp.ClientID, loginApp.ClientSecret)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		edges = azc
+	}
+
+	return newClient(id, name, p, providerAppID, loginApp.OrganizationID, edges)
+}
+
+func newClient(id uuid.UUID, name string, p *tenantplex.LDAPProvider, providerAppID uuid.UUID, orgID uuid.UUID, edges edgeClient) (*authClient, error) {
+	// appID has been validated to exist already
+	if !slices.ContainsFunc(p.Apps, func(a tenantplex.LDAPApp) bool { return a.ID == providerAppID }) {
+		return nil, ucerr.Errorf("app ID %v not found in provider %s (%v) despite validation", providerAppID, name, id)
+	}
+
+	if len(p.GroupMappings) > 0 && edges == nil {
+		return nil, ucerr.Errorf("LDAP provider %s (%v) has group mappings but no authz client", name, id)
+	}
+
+	return &authClient{
+		directory: directory{id: id, name: name, p: p},
+		orgID:     orgID,
+		edges:     edges,
+	}, nil
+}
+
+// UsernamePasswordLogin looks up the user with the service account, then binds as them to check the password
+func (c *authClient) UsernamePasswordLogin(ctx context.Context, username, password string) (*iface.LoginResponseWithClaims, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	defer conn.Close()
+
+	entry, err := c.findUser(ctx, conn, c.p.GetUserFilter(username))
+	if err != nil {
+		if errors.Is(err, iface.ErrUserNotFound) {
+			return nil, ucerr.Wrap(jsonclient.ErrIncorrectUsernamePassword)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	if err := conn.Bind(ctx, entry.DN, password); err != nil {
+		if errors.Is(err, infraLDAP.ErrInvalidCredentials) {
+			return nil, ucerr.Wrap(jsonclient.ErrIncorrectUsernamePassword)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	claims, err := c.claimsFromEntry(entry)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	// we sync groups on every login, and fail the login if we can't, since otherwise a user removed
+	// from a group in the directory could keep the access it granted
+	userID, err := c.userID(entry)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	if err := c.syncGroups(ctx, userID, entry); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &iface.LoginResponseWithClaims{
+		Status: idp.LoginStatusSuccess,
+		Claims: claims,
+	}, nil
+}
+
+// syncGroups adds or removes the authz edge for each group mapping to match the user's group memberships
+func (c *authClient) syncGroups(ctx context.Context, userID uuid.UUID, entry *infraLDAP.Entry) error {
+	if len(c.p.GroupMappings) == 0 {
+		return nil
+	}
+
+	groups := map[string]bool{}
+	for _, dn := range entry.GetAttributeValues(c.p.GetGroupAttribute()) {
+		groups[infraLDAP.NormalizeDN(dn)] = true
+	}
+
+	userObjectCreated := false
+	for _, gm := range c.p.GroupMappings {
+		isMember := groups[infraLDAP.NormalizeDN(gm.GroupDN)]
+
+		edge, err := c.edges.FindEdge(ctx, userID, gm.ObjectID, gm.EdgeTypeID)
+		if err != nil && !errors.Is(err, authz.ErrEdgeNotFound) {
+			return ucerr.Wrap(err)
+		}
+		hasEdge := err == nil
+
+		if isMember && !hasEdge {
+			// directory users don't otherwise exist in authz, so create the user object before its first edge
+			if !userObjectCreated {
+				if _, err := c.edges.CreateObject(ctx, userID, authz.UserObjectTypeID, "", authz.OrganizationID(c.orgID), authz.IfNotExists()); err != nil {
+					return ucerr.Wrap(err)
+				}
+				userObjectCreated = true
+			}
+
+			if _, err := c.edges.CreateEdge(ctx, uuid.Must(uuid.NewV4()), userID, gm.ObjectID, gm.EdgeTypeID, authz.IfNotExists()); err != nil {
+				return ucerr.Wrap(err)
+			}
+			uclog.Debugf(ctx, "added edge for LDAP group %s to user %v", gm.GroupDN, userID)
+		} else if !isMember && hasEdge {
+			if err := c.edges.DeleteEdge(ctx, edge.ID); err != nil {
+				return ucerr.Wrap(err)
+			}
+			uclog.Debugf(ctx, "removed edge for LDAP group %s from user %v", gm.GroupDN, userID)
+		}
+	}
+
+	return nil
+}
+
+func (c *authClient) LoginURL(ctx context.Context, sessionID uuid.UUID, app *tenantplex.App) (*url.URL, error) {
+	return paths.LoginURL(ctx, sessionID)
+}
+
+func (c *authClient) Logout(ctx context.Context, redirectURL string) (string, error) {
+	// LDAP has no session of its own, so there's nothing to clear
+	return redirectURL, nil
+}
+
+func (c authClient) String() string {
+	// NOTE: non-pointer receiver required for this to work on both pointer & non-pointer types
+	return fmt.Sprintf("type '%s', name: '%s', id: '%v'", tenantplex.ProviderTypeLDAP, c.name, c.id)
+}
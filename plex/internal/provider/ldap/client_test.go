@@ -0,0 +1,221 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/idp"
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/ldap/ldaptest"
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/provider/iface"
+)
+
+type edgeKey struct {
+	source, target, edgeType uuid.UUID
+}
+
+type fakeEdgeClient struct {
+	objects map[uuid.UUID]bool
+	edges   map[edgeKey]uuid.UUID
+}
+
+func newFakeEdgeClient() *fakeEdgeClient {
+	return &fakeEdgeClient{objects: map[uuid.UUID]bool{}, edges: map[edgeKey]uuid.UUID{}}
+}
+
+func (f *fakeEdgeClient) CreateObject(_ context.Context, id, _ uuid.UUID, _ string, _ ...authz.Option) (*authz.Object, error) {
+	f.objects[id] = true
+	return &authz.Object{}, nil
+}
+
+func (f *fakeEdgeClient) FindEdge(_ context.Context, source, target, edgeType uuid.UUID, _ ...authz.Option) (*authz.Edge, error) {
+	id, ok := f.edges[edgeKey{source, target, edgeType}]
+	if !ok {
+		return nil, ucerr.Wrap(authz.ErrEdgeNotFound)
+	}
+	e := &authz.Edge{SourceObjectID: source, TargetObjectID: target, EdgeTypeID: edgeType}
+	e.ID = id
+	return e, nil
+}
+
+func (f *fakeEdgeClient) CreateEdge(_ context.Context, id, source, target, edgeType uuid.UUID, _ ...authz.Option) (*authz.Edge, error) {
+	if !f.objects[source] {
+		return nil, ucerr.New("source object doesn't exist")
+	}
+	f.edges[edgeKey{source, target, edgeType}] = id
+	return &authz.Edge{}, nil
+}
+
+func (f *fakeEdgeClient) DeleteEdge(_ context.Context, id uuid.UUID, _ ...authz.Option) error {
+	for k, v := range f.edges {
+		if v == id {
+			delete(f.edges, k)
+			return nil
+		}
+	}
+	return ucerr.Wrap(authz.ErrEdgeNotFound)
+}
+
+func TestLDAPProvider(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := ldaptest.NewServer()
+	assert.NoErr(t, err)
+	defer s.Close()
+
+	aliceID := uuid.Must(uuid.NewV4())
+	bobGUID := uuid.Must(uuid.FromString("01020304-0506-0708-090a-0b0c0d0e0f10"))
+
+	s.AddEntry("cn=svc,dc=corp,dc=example", "svcpw", nil)
+	s.AddEntry("cn=Alice Smith,ou=Users,dc=corp,dc=example", "alicepw", map[string][]string{
+		"objectClass":    {"user"},
+		"sAMAccountName": {"alice"},
+		"objectGUID":     {aliceID.String()},
+		"mail":           {"alice@corp.example"},
+		"displayName":    {"Alice Smith"},
+		"department":     {"Engineering"},
+		"memberOf":       {"CN=Engineers, OU=Groups, DC=corp, DC=example"},
+	})
+	// AD returns objectGUID as raw bytes
+	s.AddEntry("cn=Bob,ou=Users,dc=corp,dc=example", "bobpw", map[string][]string{
+		"objectClass":    {"user"},
+		"sAMAccountName": {"bob"},
+		"objectGUID":     {string([]byte{0x04, 0x03, 0x02, 0x01, 0x06, 0x05, 0x08, 0x07, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10})},
+		"mail":           {"bob@corp.example"},
+	})
+
+	engineersID := uuid.Must(uuid.NewV4())
+	adminsID := uuid.Must(uuid.NewV4())
+	memberEdgeTypeID := uuid.Must(uuid.NewV4())
+	appID := uuid.Must(uuid.NewV4())
+	providerID := uuid.Must(uuid.NewV4())
+
+	p := &tenantplex.LDAPProvider{
+		URL:          s.URL(),
+		StartTLS:     true,
+		RootCAs:      s.RootCAs(),
+		BindDN:       "cn=svc,dc=corp,dc=example",
+		BindPassword: secret.NewTestString("svcpw"),
+		BaseDN:       "ou=Users,dc=corp,dc=example",
+		UserFilter:   "(&(objectClass=user)(sAMAccountName={username}))",
+		IDAttribute:  "objectGUID",
+		AttributeMappings: []tenantplex.LDAPAttributeMapping{
+			{Claim: "email", Attribute: "mail"},
+			{Claim: "name", Attribute: "displayName"},
+			{Claim: "department", Attribute: "department"},
+		},
+		GroupMappings: []tenantplex.LDAPGroupMapping{
+			{GroupDN: "cn=engineers,ou=groups,dc=corp,dc=example", ObjectID: engineersID, EdgeTypeID: memberEdgeTypeID},
+			{GroupDN: "cn=admins,ou=groups,dc=corp,dc=example", ObjectID: adminsID, EdgeTypeID: memberEdgeTypeID},
+		},
+		Apps: []tenantplex.LDAPApp{{ID: appID, Name: "corp"}},
+	}
+	assert.NoErr(t, p.Validate())
+
+	// passwords are never sent in the clear
+	cleartext := *p
+	cleartext.StartTLS = false
+	assert.NotNil(t, cleartext.Validate())
+
+	edges := newFakeEdgeClient()
+	c, err := newClient(providerID, "corp ad", p, appID, uuid.Must(uuid.NewV4()), edges)
+	assert.NoErr(t, err)
+
+	t.Run("Login", func(t *testing.T) {
+		resp, err := c.UsernamePasswordLogin(ctx, "alice", "alicepw")
+		assert.NoErr(t, err)
+		assert.Equal(t, resp.Status, idp.LoginStatusSuccess)
+		assert.Equal(t, resp.Claims["sub"], aliceID.String())
+		assert.Equal(t, resp.Claims["email"], "alice@corp.example")
+		assert.Equal(t, resp.Claims["name"], "Alice Smith")
+		assert.Equal(t, resp.Claims["department"], "Engineering")
+
+		// we bound as the service account, then as alice
+		binds := s.Binds()
+		assert.Equal(t, binds[len(binds)-1], "cn=Alice Smith,ou=Users,dc=corp,dc=example")
+	})
+
+	t.Run("BadCredentials", func(t *testing.T) {
+		for _, tc := range []struct{ username, password string }{
+			{"alice", "wrong"},
+			{"alice", ""},
+			{"nobody", "alicepw"},
+			{"*", "alicepw"},
+			{"alice)(sAMAccountName=*", "alicepw"},
+		} {
+			_, err := c.UsernamePasswordLogin(ctx, tc.username, tc.password)
+			assert.True(t, errors.Is(err, jsonclient.ErrIncorrectUsernamePassword), assert.Errorf("%s/%s: %v", tc.username, tc.password, err))
+		}
+	})
+
+	t.Run("GroupSync", func(t *testing.T) {
+		edges.edges = map[edgeKey]uuid.UUID{}
+		edges.objects = map[uuid.UUID]bool{}
+
+		// a stale edge for a group alice isn't in any more
+		edges.objects[aliceID] = true
+		edges.edges[edgeKey{aliceID, adminsID, memberEdgeTypeID}] = uuid.Must(uuid.NewV4())
+
+		_, err := c.UsernamePasswordLogin(ctx, "alice", "alicepw")
+		assert.NoErr(t, err)
+		assert.Equal(t, len(edges.edges), 1)
+		_, ok := edges.edges[edgeKey{aliceID, engineersID, memberEdgeTypeID}]
+		assert.True(t, ok)
+
+		// logging in again is a no-op
+		_, err = c.UsernamePasswordLogin(ctx, "alice", "alicepw")
+		assert.NoErr(t, err)
+		assert.Equal(t, len(edges.edges), 1)
+
+		// bob isn't in any mapped groups
+		resp, err := c.UsernamePasswordLogin(ctx, "bob", "bobpw")
+		assert.NoErr(t, err)
+		assert.Equal(t, resp.Claims["sub"], bobGUID.String())
+		assert.False(t, edges.objects[bobGUID])
+	})
+
+	t.Run("Management", func(t *testing.T) {
+		mc, err := NewManagementClient(ctx, providerID, "corp ad", p)
+		assert.NoErr(t, err)
+
+		profile, err := mc.GetUser(ctx, bobGUID.String())
+		assert.NoErr(t, err)
+		assert.Equal(t, profile.ID, bobGUID.String())
+		assert.Equal(t, profile.Email, "bob@corp.example")
+
+		_, err = mc.GetUser(ctx, uuid.Must(uuid.NewV4()).String())
+		assert.ErrorIs(t, err, iface.ErrUserNotFound)
+
+		profiles, err := mc.ListUsersForEmail(ctx, "alice@corp.example", idp.AuthnTypePassword)
+		assert.NoErr(t, err)
+		assert.Equal(t, len(profiles), 1)
+		assert.Equal(t, profiles[0].Name, "Alice Smith")
+
+		profiles, err = mc.ListUsersForEmail(ctx, "alice@corp.example", idp.AuthnTypeOIDC)
+		assert.NoErr(t, err)
+		assert.Equal(t, len(profiles), 0)
+
+		_, err = mc.CreateUserWithPassword(ctx, "carol", "pw", iface.UserProfile{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DerivedIDs", func(t *testing.T) {
+		uidProvider := *p
+		uidProvider.IDAttribute = "sAMAccountName"
+		uidProvider.GroupMappings = nil
+		uc, err := newClient(providerID, "corp ad", &uidProvider, appID, uuid.Nil, nil)
+		assert.NoErr(t, err)
+
+		resp, err := uc.UsernamePasswordLogin(ctx, "alice", "alicepw")
+		assert.NoErr(t, err)
+		assert.Equal(t, resp.Claims["sub"], uuid.NewV5(providerID, "alice").String())
+	})
+}
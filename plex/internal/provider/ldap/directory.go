@@ -0,0 +1,165 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"slices"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+
+	infraLDAP "userclouds.com/infra/ldap"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/provider/iface"
+)
+
+// Active Directory stores objectGUID as 16 raw bytes, with the first three fields little-endian
+const adObjectGUIDAttribute = "objectGUID"
+
+// directory holds the config shared by the auth and management clients to talk to an LDAP provider
+type directory struct {
+	id   uuid.UUID
+	name string
+	p    *tenantplex.LDAPProvider
+}
+
+// connect dials the directory and binds as the service account
+func (d directory) connect(ctx context.Context) (*infraLDAP.Conn, error) {
+	rootCAs, err := d.p.GetRootCAs()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	conn, err := infraLDAP.Dial(ctx, d.p.URL, &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}, d.p.StartTLS)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	password, err := d.p.BindPassword.Resolve(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, ucerr.Wrap(err)
+	}
+
+	if err := conn.Bind(ctx, d.p.BindDN, password); err != nil {
+		conn.Close()
+		return nil, ucerr.Errorf("failed to bind to LDAP provider %v as service account: %w", d.id, err)
+	}
+
+	return conn, nil
+}
+
+// userAttributes returns the attributes we need to read from user entries
+func (d directory) userAttributes() []string {
+	attrs := []string{d.p.IDAttribute, d.p.GetGroupAttribute()}
+	for _, m := range d.p.GetAttributeMappings() {
+		attrs = append(attrs, m.Attribute)
+	}
+	return attrs
+}
+
+// userObjectFilter matches all users, by replacing the username in the user filter with a wildcard
+func (d directory) userObjectFilter() string {
+	return strings.ReplaceAll(d.p.UserFilter, tenantplex.LDAPUsernamePlaceholder, "*")
+}
+
+func (d directory) searchUsers(ctx context.Context, conn *infraLDAP.Conn, filter string) ([]infraLDAP.Entry, error) {
+	entries, err := conn.Search(ctx, infraLDAP.SearchRequest{
+		BaseDN:     d.p.BaseDN,
+		Scope:      infraLDAP.ScopeWholeSubtree,
+		Filter:     filter,
+		Attributes: d.userAttributes(),
+	})
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return entries, nil
+}
+
+// findUser returns the single user matching filter, or iface.ErrUserNotFound
+func (d directory) findUser(ctx context.Context, conn *infraLDAP.Conn, filter string) (*infraLDAP.Entry, error) {
+	entries, err := d.searchUsers(ctx, conn, filter)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if len(entries) == 0 {
+		return nil, ucerr.Wrap(iface.ErrUserNotFound)
+	} else if len(entries) > 1 {
+		// an ambiguous filter is a config error, and we certainly shouldn't guess which user is logging in
+		return nil, ucerr.Errorf("LDAP provider %v returned %d users for filter %s", d.id, len(entries), filter)
+	}
+	return &entries[0], nil
+}
+
+// swapGUIDByteOrder converts between the mixed-endian byte order of an AD objectGUID and
+// the big-endian order of a UUID (the conversion is its own inverse)
+func swapGUIDByteOrder(guid []byte) []byte {
+	bs := slices.Clone(guid)
+	bs[0], bs[1], bs[2], bs[3] = bs[3], bs[2], bs[1], bs[0]
+	bs[4], bs[5] = bs[5], bs[4]
+	bs[6], bs[7] = bs[7], bs[6]
+	return bs
+}
+
+// userID returns the UUID we use for a user, from the configured ID attribute
+func (d directory) userID(entry *infraLDAP.Entry) (uuid.UUID, error) {
+	value := entry.GetAttributeValue(d.p.IDAttribute)
+	if value == "" {
+		return uuid.Nil, ucerr.Errorf("LDAP entry %s has no %s attribute", entry.DN, d.p.IDAttribute)
+	}
+
+	if strings.EqualFold(d.p.IDAttribute, adObjectGUIDAttribute) && len(value) == 16 {
+		return uuid.FromBytesOrNil(swapGUIDByteOrder([]byte(value))), nil
+	}
+
+	if id, err := uuid.FromString(value); err == nil {
+		return id, nil
+	}
+
+	// otherwise derive a stable ID, scoped to this provider
+	return uuid.NewV5(d.id, value), nil
+}
+
+// userIDFilter returns a filter to find a user by the ID returned by userID. Derived IDs can't be
+// reversed, so this only works if the ID attribute holds a UUID.
+func (d directory) userIDFilter(id uuid.UUID) string {
+	value := infraLDAP.EscapeFilter(id.String())
+	if strings.EqualFold(d.p.IDAttribute, adObjectGUIDAttribute) {
+		value = infraLDAP.EscapeFilterBytes(swapGUIDByteOrder(id.Bytes()))
+	}
+	return "(&" + d.userObjectFilter() + "(" + d.p.IDAttribute + "=" + value + "))"
+}
+
+// claimsFromEntry maps a user's attributes onto token claims
+func (d directory) claimsFromEntry(entry *infraLDAP.Entry) (jwt.MapClaims, error) {
+	id, err := d.userID(entry)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	claims := jwt.MapClaims{"sub": id.String()}
+	for _, m := range d.p.GetAttributeMappings() {
+		if v := entry.GetAttributeValue(m.Attribute); v != "" {
+			claims[m.Claim] = v
+		}
+	}
+	return claims, nil
+}
+
+// profileFromEntry maps a user's attributes onto a user profile
+func (d directory) profileFromEntry(entry *infraLDAP.Entry) (*iface.UserProfile, error) {
+	claims, err := d.claimsFromEntry(entry)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	profile := &iface.UserProfile{ID: claims["sub"].(string)}
+	profile.Email, _ = claims["email"].(string)
+	profile.Name, _ = claims["name"].(string)
+	profile.Nickname, _ = claims["nickname"].(string)
+	profile.Picture, _ = claims["picture"].(string)
+	return profile, nil
+}
@@ -0,0 +1,93 @@
+package ldap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	infraLDAP "userclouds.com/infra/ldap"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/provider/iface"
+)
+
+// mgmtClient looks up users in the directory. The directory is managed by the customer, so we
+// never create or modify users in it, and those methods aren't supported.
+type mgmtClient struct {
+	iface.BaseManagementClient
+	directory
+}
+
+// NewManagementClient returns a new client that is configured to only perform management tasks
+func NewManagementClient(ctx context.Context, id uuid.UUID, name string, p *tenantplex.LDAPProvider) (iface.ManagementClient, error) {
+	return &mgmtClient{directory: directory{id: id, name: name, p: p}}, nil
+}
+
+func (c *mgmtClient) GetUser(ctx context.Context, userID string) (*iface.UserProfile, error) {
+	id, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, ucerr.Wrap(iface.ErrUserNotFound)
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	defer conn.Close()
+
+	entry, err := c.findUser(ctx, conn, c.userIDFilter(id))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	profile, err := c.profileFromEntry(entry)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	return profile, nil
+}
+
+func (c *mgmtClient) ListUsersForEmail(ctx context.Context, email string, authnType idp.AuthnType) ([]iface.UserProfile, error) {
+	// directory users only ever log in with a password
+	if authnType != idp.AuthnTypePassword && authnType != idp.AuthnTypeAll {
+		return []iface.UserProfile{}, nil
+	}
+
+	var emailAttribute string
+	for _, m := range c.p.GetAttributeMappings() {
+		if m.Claim == "email" {
+			emailAttribute = m.Attribute
+		}
+	}
+	if emailAttribute == "" {
+		return []iface.UserProfile{}, nil
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	defer conn.Close()
+
+	entries, err := c.searchUsers(ctx, conn, "(&"+c.userObjectFilter()+"("+emailAttribute+"="+infraLDAP.EscapeFilter(email)+"))")
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	profiles := make([]iface.UserProfile, 0, len(entries))
+	for i := range entries {
+		profile, err := c.profileFromEntry(&entries[i])
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		profiles = append(profiles, *profile)
+	}
+	return profiles, nil
+}
+
+func (c mgmtClient) String() string {
+	// NOTE: non-pointer receiver required for this to work on both pointer & non-pointer types
+	return fmt.Sprintf("type '%s', name: '%s', id: '%v'", tenantplex.ProviderTypeLDAP, c.name, c.id)
+}
@@ -38,6 +38,7 @@ func (lc *loginChallenger) issueChallenge(ctx context.Context, code string, c oi
 	switch c.ChannelType {
 	case oidc.MFAAuthenticatorChannel:
 	case oidc.MFARecoveryCodeChannel:
+	case oidc.MFAWebAuthnChannel:
 	case oidc.MFAEmailChannel:
 		return ucerr.Wrap(lc.issueEmailChallenge(ctx, &tc, app, code, c, ct))
 	case oidc.MFASMSChannel:
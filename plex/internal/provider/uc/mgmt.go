@@ -25,7 +25,8 @@ type mgmtClient struct {
 	idp *idp.ManagementClient
 }
 
-func newMgmtClient(ctx context.Context, tc *tenantplex.TenantConfig, tenantURL string, appID uuid.UUID, appOrgID uuid.UUID) (*idp.ManagementClient, error) {
+// NewIDPManagementClient returns an IDP management client for the userstore at tenantURL, authenticated as the plex app
+func NewIDPManagementClient(ctx context.Context, tc *tenantplex.TenantConfig, tenantURL string, appID uuid.UUID, appOrgID uuid.UUID) (*idp.ManagementClient, error) {
 	// We don't use the subject field, and the audience field is the URL of the tenant for now
 	jwt, err := newJWT(ctx, tc, tenantURL, appID, appOrgID)
 	if err != nil {
@@ -45,7 +46,7 @@ func (c mgmtClient) String() string {
 // object with less config (and client implements both iface.Client & iface.ManagementClient), but
 // that will likely change as we get more mature.
 func NewManagementClient(ctx context.Context, tc *tenantplex.TenantConfig, id uuid.UUID, name string, uc tenantplex.UCProvider, appID uuid.UUID, appOrgID uuid.UUID) (iface.ManagementClient, error) {
-	mc, err := newMgmtClient(ctx, tc, uc.IDPURL, appID, appOrgID)
+	mc, err := NewIDPManagementClient(ctx, tc, uc.IDPURL, appID, appOrgID)
 	if err != nil {
 		return nil, ucerr.Wrap(err)
 	}
@@ -0,0 +1,75 @@
+package upstreamoidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/paths"
+	"userclouds.com/plex/internal/provider/iface"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// authClient delegates login to the upstream IdP by redirecting users to it, rather than to
+// the plex login UI
+type authClient struct {
+	iface.BaseClient
+
+	id   uuid.UUID
+	name string
+	p    *tenantplex.UpstreamOIDCProvider
+}
+
+// NewClient creates an upstream OIDC provider client that implements iface.Client.
+func NewClient(ctx context.Context, id uuid.UUID, name string, p *tenantplex.UpstreamOIDCProvider, providerAppID uuid.UUID) (iface.Client, error) {
+	// appID has been validated to exist already
+	if !slices.ContainsFunc(p.Apps, func(a tenantplex.UpstreamOIDCApp) bool { return a.ID == providerAppID }) {
+		return nil, ucerr.Errorf("app ID %v not found in provider %s (%v) despite validation", providerAppID, name, id)
+	}
+
+	return &authClient{id: id, name: name, p: p}, nil
+}
+
+// CallbackURL returns the URL the upstream IdP redirects back to after login, which must be
+// registered as a redirect URI for the provider's client in the IdP
+func CallbackURL(ctx context.Context) string {
+	return fmt.Sprintf("%s/delegation%s", tenantconfig.MustGetTenantURLString(ctx), paths.UpstreamOIDCCallbackPath)
+}
+
+// NewAuthenticator returns an authenticator for the upstream IdP, using discovery to find its endpoints
+func NewAuthenticator(ctx context.Context, p *tenantplex.UpstreamOIDCProvider) (*oidc.Authenticator, error) {
+	a, err := oidc.NewAuthenticator(ctx, p.IssuerURL, p.ClientID, p.ClientSecret, CallbackURL(ctx))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	a.Config.Scopes = p.GetScopes()
+	return a, nil
+}
+
+// LoginURL sends the user to our login endpoint for the provider, which sets the LoginState cookie
+// before redirecting them to the upstream IdP (we can't set cookies here)
+func (c *authClient) LoginURL(ctx context.Context, sessionID uuid.UUID, app *tenantplex.App) (*url.URL, error) {
+	loginURL, err := url.Parse(fmt.Sprintf("%s/delegation%s", tenantconfig.MustGetTenantURLString(ctx), paths.UpstreamOIDCLoginPath))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+	loginURL.RawQuery = url.Values{"session_id": []string{sessionID.String()}}.Encode()
+	return loginURL, nil
+}
+
+func (c *authClient) Logout(ctx context.Context, redirectURL string) (string, error) {
+	// we only clear our own session; the user stays logged in to the upstream IdP, as they
+	// would with any other IdP they use for SSO
+	return redirectURL, nil
+}
+
+func (c authClient) String() string {
+	// NOTE: non-pointer receiver required for this to work on both pointer & non-pointer types
+	return fmt.Sprintf("type '%s', name: '%s', id: '%v'", tenantplex.ProviderTypeOIDC, c.name, c.id)
+}
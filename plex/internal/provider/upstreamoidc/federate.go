@@ -0,0 +1,156 @@
+package upstreamoidc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/provider/iface"
+	"userclouds.com/plex/internal/provider/uc"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// ErrProvisioningDisabled is returned when a user who isn't in the userstore logs in via the
+// upstream IdP, but JIT provisioning is disabled
+var ErrProvisioningDisabled = ucerr.Friendlyf(nil, "Your account has not been set up yet. Please contact your administrator.")
+
+// userstoreClient is the subset of the IDP management client used to federate users, so it can be faked in tests
+type userstoreClient interface {
+	GetUserBaseProfileForOIDC(ctx context.Context, provider oidc.ProviderType, issuerURL string, oidcSubject string) (*idp.UserBaseProfileAndAuthnResponse, error)
+	GetUserBaseProfileAndAuthN(ctx context.Context, id uuid.UUID, opts ...idp.Option) (*idp.UserBaseProfileAndAuthnResponse, error)
+	ListUserBaseProfilesAndAuthNForEmail(ctx context.Context, email string, authnType idp.AuthnType) ([]idp.UserBaseProfileAndAuthnResponse, error)
+	CreateUserWithOIDC(ctx context.Context, provider oidc.ProviderType, issuerURL string, subject string, profile userstore.Record, opts ...idp.Option) (uuid.UUID, error)
+	AddOIDCAuthnToUser(ctx context.Context, userID string, provider oidc.ProviderType, issuerURL string, oidcSubject string) error
+	UpdateUser(ctx context.Context, id uuid.UUID, req idp.UpdateUserRequest) (*idp.UserResponse, error)
+}
+
+// FederateUser finds the userstore user for an identity from the upstream IdP, linking it to an existing
+// user or provisioning a new one if necessary, and updates the user's mapped columns from the IdP's claims.
+// It returns the user's profile, and whether they were just created.
+func FederateUser(ctx context.Context, tc *tenantplex.TenantConfig, p *tenantplex.UpstreamOIDCProvider, app *tenantplex.App, claims map[string]any) (*iface.UserProfile, bool, error) {
+	mc, err := uc.NewIDPManagementClient(ctx, tc, tenantconfig.MustGetTenantURLString(ctx), app.ID, app.OrganizationID)
+	if err != nil {
+		return nil, false, ucerr.Wrap(err)
+	}
+
+	return federateUser(ctx, mc, p, claims, !p.DisableJITProvisioning && !tc.DisableSignUps)
+}
+
+func federateUser(ctx context.Context, us userstoreClient, p *tenantplex.UpstreamOIDCProvider, claims map[string]any, allowProvisioning bool) (*iface.UserProfile, bool, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, false, ucerr.New("upstream IdP didn't return a 'sub' claim")
+	}
+
+	record := mapClaims(p, claims)
+
+	// upstream identities are stored as custom OIDC authns, keyed by issuer and subject
+	var userID string
+	user, err := us.GetUserBaseProfileForOIDC(ctx, oidc.ProviderTypeCustom, p.IssuerURL, subject)
+	if err == nil {
+		userID = user.ID
+	} else if !errors.Is(iface.ClassifyGetUserError(err), iface.ErrUserNotFound) {
+		return nil, false, ucerr.Wrap(err)
+	}
+
+	if userID == "" && p.LinkAccountsByEmail {
+		userID, err = linkUserByEmail(ctx, us, p, subject, claims)
+		if err != nil {
+			return nil, false, ucerr.Wrap(err)
+		}
+	}
+
+	created := false
+	if userID == "" {
+		if !allowProvisioning {
+			return nil, false, ucerr.Wrap(ErrProvisioningDisabled)
+		}
+
+		id, err := us.CreateUserWithOIDC(ctx, oidc.ProviderTypeCustom, p.IssuerURL, subject, record)
+		if err != nil {
+			return nil, false, ucerr.Wrap(err)
+		}
+		userID = id.String()
+		created = true
+		uclog.Infof(ctx, "provisioned user %v for upstream OIDC subject %s", id, subject)
+	}
+
+	id, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, false, ucerr.Wrap(err)
+	}
+
+	// keep the mapped columns in sync with the IdP, which is the source of truth for them
+	if !created && len(record) > 0 {
+		if _, err := us.UpdateUser(ctx, id, idp.UpdateUserRequest{Profile: record}); err != nil {
+			return nil, false, ucerr.Wrap(err)
+		}
+	}
+
+	resp, err := us.GetUserBaseProfileAndAuthN(ctx, id)
+	if err != nil {
+		return nil, false, ucerr.Wrap(iface.ClassifyGetUserError(err))
+	}
+	return (*iface.UserProfile)(resp), created, nil
+}
+
+// linkUserByEmail adds the upstream identity to an existing user with the same email, returning the
+// user's ID or "" if there is no user to link to
+func linkUserByEmail(ctx context.Context, us userstoreClient, p *tenantplex.UpstreamOIDCProvider, subject string, claims map[string]any) (string, error) {
+	// we only trust the IdP's email if it vouches for it, since otherwise anyone able to set their
+	// email in the IdP could take over the userstore account that owns it
+	email, _ := claims["email"].(string)
+	if verified, _ := claims["email_verified"].(bool); email == "" || !verified {
+		return "", nil
+	}
+
+	users, err := us.ListUserBaseProfilesAndAuthNForEmail(ctx, email, idp.AuthnTypeAll)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	if len(users) == 0 {
+		return "", nil
+	} else if len(users) > 1 {
+		// we shouldn't guess which account this is
+		return "", ucerr.Friendlyf(nil, "More than one account uses the email %s. Please contact your administrator.", email)
+	}
+
+	// if the account never verified its email, whoever signed up for it may not own the email, and linking
+	// would let them in once the real owner logs in via the IdP, so we provision a separate user instead
+	if !users[0].EmailVerified {
+		uclog.Infof(ctx, "not linking upstream OIDC subject %s to user %s with unverified email", subject, users[0].ID)
+		return "", nil
+	}
+
+	// a different identity from this IdP already owns the account, so this isn't the same person
+	for _, authn := range users[0].Authns {
+		if authn.AuthnType == idp.AuthnTypeOIDC && authn.OIDCProvider == oidc.ProviderTypeCustom && authn.OIDCIssuerURL == p.IssuerURL {
+			return "", ucerr.Friendlyf(nil, "The account using the email %s is already linked to another user. Please contact your administrator.", email)
+		}
+	}
+
+	if err := us.AddOIDCAuthnToUser(ctx, users[0].ID, oidc.ProviderTypeCustom, p.IssuerURL, subject); err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	uclog.Infof(ctx, "linked upstream OIDC subject %s to existing user %s", subject, users[0].ID)
+	return users[0].ID, nil
+}
+
+// mapClaims returns the userstore columns to set from the IdP's claims. Claims the IdP didn't
+// return are left out, rather than clearing the column.
+func mapClaims(p *tenantplex.UpstreamOIDCProvider, claims map[string]any) userstore.Record {
+	record := userstore.Record{}
+	for _, m := range p.GetClaimMappings() {
+		if v, ok := claims[m.Claim]; ok && v != nil {
+			record[m.Column] = v
+		}
+	}
+	return record
+}
@@ -0,0 +1,237 @@
+package upstreamoidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/assert"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/secret"
+	"userclouds.com/internal/tenantplex"
+)
+
+type fakeUser struct {
+	profile userstore.Record
+	authns  []idp.UserAuthn
+}
+
+type fakeUserstore struct {
+	users map[uuid.UUID]*fakeUser
+}
+
+func newFakeUserstore() *fakeUserstore {
+	return &fakeUserstore{users: map[uuid.UUID]*fakeUser{}}
+}
+
+var errNotFound = jsonclient.Error{StatusCode: http.StatusNotFound}
+
+func (f *fakeUserstore) response(id uuid.UUID) *idp.UserBaseProfileAndAuthnResponse {
+	u := f.users[id]
+	resp := &idp.UserBaseProfileAndAuthnResponse{ID: id.String(), Authns: u.authns}
+	resp.Email, _ = u.profile["email"].(string)
+	resp.EmailVerified, _ = u.profile["email_verified"].(bool)
+	resp.Name, _ = u.profile["name"].(string)
+	return resp
+}
+
+func (f *fakeUserstore) GetUserBaseProfileForOIDC(_ context.Context, provider oidc.ProviderType, issuerURL string, subject string) (*idp.UserBaseProfileAndAuthnResponse, error) {
+	for id, u := range f.users {
+		for _, a := range u.authns {
+			if a.OIDCProvider == provider && a.OIDCIssuerURL == issuerURL && a.OIDCSubject == subject {
+				return f.response(id), nil
+			}
+		}
+	}
+	return nil, errNotFound
+}
+
+func (f *fakeUserstore) GetUserBaseProfileAndAuthN(_ context.Context, id uuid.UUID, _ ...idp.Option) (*idp.UserBaseProfileAndAuthnResponse, error) {
+	if _, ok := f.users[id]; !ok {
+		return nil, errNotFound
+	}
+	return f.response(id), nil
+}
+
+func (f *fakeUserstore) ListUserBaseProfilesAndAuthNForEmail(_ context.Context, email string, _ idp.AuthnType) ([]idp.UserBaseProfileAndAuthnResponse, error) {
+	var resps []idp.UserBaseProfileAndAuthnResponse
+	for id, u := range f.users {
+		if u.profile["email"] == email {
+			resps = append(resps, *f.response(id))
+		}
+	}
+	return resps, nil
+}
+
+func (f *fakeUserstore) CreateUserWithOIDC(_ context.Context, provider oidc.ProviderType, issuerURL string, subject string, profile userstore.Record, _ ...idp.Option) (uuid.UUID, error) {
+	id := uuid.Must(uuid.NewV4())
+	f.users[id] = &fakeUser{profile: profile, authns: []idp.UserAuthn{idp.NewOIDCAuthn(provider, issuerURL, subject)}}
+	return id, nil
+}
+
+func (f *fakeUserstore) AddOIDCAuthnToUser(_ context.Context, userID string, provider oidc.ProviderType, issuerURL string, subject string) error {
+	u := f.users[uuid.FromStringOrNil(userID)]
+	u.authns = append(u.authns, idp.NewOIDCAuthn(provider, issuerURL, subject))
+	return nil
+}
+
+func (f *fakeUserstore) UpdateUser(_ context.Context, id uuid.UUID, req idp.UpdateUserRequest) (*idp.UserResponse, error) {
+	for k, v := range req.Profile {
+		f.users[id].profile[k] = v
+	}
+	return &idp.UserResponse{ID: id}, nil
+}
+
+func TestFederateUser(t *testing.T) {
+	ctx := context.Background()
+
+	p := &tenantplex.UpstreamOIDCProvider{
+		IssuerURL:    "https://corp.okta.example",
+		ClientID:     "plex",
+		ClientSecret: secret.NewTestString("secret"),
+		ClaimMappings: []tenantplex.UpstreamOIDCClaimMapping{
+			{Claim: "email", Column: "email"},
+			{Claim: "email_verified", Column: "email_verified"},
+			{Claim: "name", Column: "name"},
+			{Claim: "department", Column: "department"},
+		},
+		Apps: []tenantplex.UpstreamOIDCApp{{ID: uuid.Must(uuid.NewV4()), Name: "corp"}},
+	}
+	assert.NoErr(t, p.Validate())
+
+	t.Run("Provisioning", func(t *testing.T) {
+		us := newFakeUserstore()
+
+		claims := map[string]any{"sub": "00u1", "email": "alice@corp.example", "name": "Alice", "department": "Engineering"}
+		profile, created, err := federateUser(ctx, us, p, claims, true)
+		assert.NoErr(t, err)
+		assert.True(t, created)
+		assert.Equal(t, profile.Email, "alice@corp.example")
+		id := uuid.FromStringOrNil(profile.ID)
+		assert.Equal(t, us.users[id].profile["department"], "Engineering")
+
+		// logging in again finds the same user, and updates the mapped columns
+		claims["department"] = "Sales"
+		claims["name"] = "Alice Smith"
+		profile, created, err = federateUser(ctx, us, p, claims, true)
+		assert.NoErr(t, err)
+		assert.False(t, created)
+		assert.Equal(t, profile.ID, id.String())
+		assert.Equal(t, profile.Name, "Alice Smith")
+		assert.Equal(t, us.users[id].profile["department"], "Sales")
+		assert.Equal(t, len(us.users), 1)
+
+		// existing users can still log in if provisioning is disabled, but new ones can't
+		_, _, err = federateUser(ctx, us, p, claims, false)
+		assert.NoErr(t, err)
+		_, _, err = federateUser(ctx, us, p, map[string]any{"sub": "00u2", "email": "bob@corp.example"}, false)
+		assert.True(t, errors.Is(err, ErrProvisioningDisabled))
+
+		_, _, err = federateUser(ctx, us, p, map[string]any{"email": "bob@corp.example"}, true)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("AccountLinking", func(t *testing.T) {
+		us := newFakeUserstore()
+		existingID := uuid.Must(uuid.NewV4())
+		us.users[existingID] = &fakeUser{
+			profile: userstore.Record{"email": "carol@corp.example", "email_verified": true},
+			authns:  []idp.UserAuthn{{AuthnType: idp.AuthnTypePassword, Username: "carol"}},
+		}
+
+		// linking is off by default
+		profile, created, err := federateUser(ctx, us, p, map[string]any{"sub": "00u3", "email": "carol@corp.example", "email_verified": true}, true)
+		assert.NoErr(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, profile.ID, existingID.String())
+
+		linking := *p
+		linking.LinkAccountsByEmail = true
+		us.users = map[uuid.UUID]*fakeUser{existingID: us.users[existingID]}
+
+		// unverified emails are never linked
+		profile, created, err = federateUser(ctx, us, &linking, map[string]any{"sub": "00u3", "email": "carol@corp.example", "email_verified": false}, true)
+		assert.NoErr(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, profile.ID, existingID.String())
+
+		// nor are accounts whose own email was never verified, since whoever created them may not own it
+		us.users = map[uuid.UUID]*fakeUser{existingID: us.users[existingID]}
+		us.users[existingID].profile["email_verified"] = false
+		profile, created, err = federateUser(ctx, us, &linking, map[string]any{"sub": "00u3", "email": "carol@corp.example", "email_verified": true}, true)
+		assert.NoErr(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, profile.ID, existingID.String())
+
+		us.users = map[uuid.UUID]*fakeUser{existingID: us.users[existingID]}
+		us.users[existingID].profile["email_verified"] = true
+		profile, created, err = federateUser(ctx, us, &linking, map[string]any{"sub": "00u3", "email": "carol@corp.example", "email_verified": true, "name": "Carol"}, true)
+		assert.NoErr(t, err)
+		assert.False(t, created)
+		assert.Equal(t, profile.ID, existingID.String())
+		assert.Equal(t, profile.Name, "Carol")
+		assert.Equal(t, len(us.users[existingID].authns), 2)
+
+		// a different upstream identity with the same email can't take over the linked account
+		_, _, err = federateUser(ctx, us, &linking, map[string]any{"sub": "00u4", "email": "carol@corp.example", "email_verified": true}, true)
+		assert.NotNil(t, err)
+		assert.Equal(t, len(us.users), 1)
+	})
+
+	t.Run("LoginState", func(t *testing.T) {
+		ls := NewLoginState(uuid.Must(uuid.NewV4()))
+		cookie, err := ls.Cookie(true)
+		assert.NoErr(t, err)
+		assert.True(t, cookie.HttpOnly)
+
+		r := httptest.NewRequest(http.MethodGet, "/delegation/oidccallback?code=x&state="+url.QueryEscape(ls.State), nil)
+		r.AddCookie(cookie)
+		got, err := LoginStateFromRequest(r)
+		assert.NoErr(t, err)
+		assert.Equal(t, *got, ls)
+
+		// the state has to match the cookie, and the cookie has to be there
+		r = httptest.NewRequest(http.MethodGet, "/delegation/oidccallback?code=x&state="+ls.SessionID.String(), nil)
+		r.AddCookie(cookie)
+		_, err = LoginStateFromRequest(r)
+		assert.NotNil(t, err)
+
+		r = httptest.NewRequest(http.MethodGet, "/delegation/oidccallback?code=x&state="+url.QueryEscape(ls.State), nil)
+		_, err = LoginStateFromRequest(r)
+		assert.NotNil(t, err)
+
+		// every login gets its own state, nonce and verifier
+		other := NewLoginState(ls.SessionID)
+		assert.NotEqual(t, other.State, ls.State)
+		assert.NotEqual(t, other.Nonce, ls.Nonce)
+		assert.NotEqual(t, other.CodeVerifier, ls.CodeVerifier)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		bad := *p
+		bad.IssuerURL = "http://corp.okta.example"
+		assert.NotNil(t, bad.Validate())
+
+		bad = *p
+		bad.Scopes = []string{"profile", "email"}
+		assert.NotNil(t, bad.Validate())
+
+		bad = *p
+		bad.ClaimMappings = append([]tenantplex.UpstreamOIDCClaimMapping{{Claim: "preferred_username", Column: "name"}}, p.ClaimMappings...)
+		assert.NotNil(t, bad.Validate())
+
+		defaults := *p
+		defaults.ClaimMappings = nil
+		record := mapClaims(&defaults, map[string]any{"email": "dave@corp.example", "department": "Sales"})
+		assert.Equal(t, len(record), 1)
+		assert.Equal(t, record["email"], "dave@corp.example")
+	})
+}
@@ -0,0 +1,101 @@
+package upstreamoidc
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"golang.org/x/oauth2"
+
+	"userclouds.com/infra/crypto"
+	"userclouds.com/infra/ucerr"
+)
+
+// LoginStateCookieName is the cookie holding the LoginState while the user is at the upstream IdP
+const LoginStateCookieName = "uc_upstream_oidc"
+
+// loginStateMaxAge is how long the user has to log in at the upstream IdP
+const loginStateMaxAge = 15 * time.Minute
+
+// LoginState is kept in a cookie between redirecting the user to the upstream IdP and its callback,
+// which binds the callback to the browser that started the login. The state sent to the IdP is
+// random, rather than the login session ID, so a leaked callback URL can't be used to finish the login.
+type LoginState struct {
+	SessionID    uuid.UUID `json:"session_id"`
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+}
+
+// NewLoginState returns a new LoginState for the plex login session
+func NewLoginState(sessionID uuid.UUID) LoginState {
+	return LoginState{
+		SessionID:    sessionID,
+		State:        crypto.MustRandomBase64URL(32),
+		Nonce:        crypto.MustRandomBase64URL(32),
+		CodeVerifier: oauth2.GenerateVerifier(),
+	}
+}
+
+// Cookie returns the cookie to store the LoginState in
+func (ls LoginState) Cookie(secure bool) (*http.Cookie, error) {
+	bs, err := json.Marshal(ls)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &http.Cookie{
+		Name:     LoginStateCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(bs),
+		Path:     "/delegation",
+		MaxAge:   int(loginStateMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		// Lax so the cookie is sent on the top-level redirect back from the IdP
+		SameSite: http.SameSiteLaxMode,
+	}, nil
+}
+
+// ClearLoginStateCookie returns a cookie that removes the LoginState, so it can only be used once
+func ClearLoginStateCookie(secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     LoginStateCookieName,
+		Path:     "/delegation",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// LoginStateFromRequest returns the LoginState from the callback request's cookie, after checking
+// that the state returned by the IdP matches it
+func LoginStateFromRequest(r *http.Request) (*LoginState, error) {
+	c, err := r.Cookie(LoginStateCookieName)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	bs, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	var ls LoginState
+	if err := json.Unmarshal(bs, &ls); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if ls.SessionID.IsNil() || ls.State == "" || ls.Nonce == "" || ls.CodeVerifier == "" {
+		return nil, ucerr.New("incomplete upstream OIDC login state")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(ls.State)) != 1 {
+		return nil, ucerr.New("state doesn't match the login state cookie")
+	}
+
+	return &ls, nil
+}
@@ -0,0 +1,50 @@
+package upstreamoidc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/internal/provider/iface"
+	"userclouds.com/plex/internal/provider/uc"
+	"userclouds.com/plex/internal/tenantconfig"
+)
+
+// mgmtClient manages users in the tenant's userstore, which is where users from the upstream IdP
+// are provisioned. Users only ever log in via the IdP, so password methods aren't supported.
+type mgmtClient struct {
+	iface.ManagementClient
+
+	id   uuid.UUID
+	name string
+}
+
+// NewManagementClient returns a new client that is configured to only perform management tasks
+func NewManagementClient(ctx context.Context, tc *tenantplex.TenantConfig, id uuid.UUID, name string, appID uuid.UUID, appOrgID uuid.UUID) (iface.ManagementClient, error) {
+	mc, err := uc.NewManagementClient(ctx, tc, id, name, tenantplex.UCProvider{IDPURL: tenantconfig.MustGetTenantURLString(ctx)}, appID, appOrgID)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &mgmtClient{ManagementClient: mc, id: id, name: name}, nil
+}
+
+func (c *mgmtClient) CreateUserWithPassword(ctx context.Context, username, password string, profile iface.UserProfile) (string, error) {
+	return "", ucerr.New("method 'CreateUserWithPassword' not supported by client")
+}
+
+func (c *mgmtClient) UpdateUsernamePassword(ctx context.Context, username, password string) error {
+	return ucerr.New("method 'UpdateUsernamePassword' not supported by client")
+}
+
+func (c *mgmtClient) AddPasswordAuthnToUser(ctx context.Context, userID, username, password string) error {
+	return ucerr.New("method 'AddPasswordAuthnToUser' not supported by client")
+}
+
+func (c mgmtClient) String() string {
+	// NOTE: non-pointer receiver required for this to work on both pointer & non-pointer types
+	return fmt.Sprintf("type '%s', name: '%s', id: '%v'", tenantplex.ProviderTypeOIDC, c.name, c.id)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// we keep client assertion IDs for a while after the assertions expire, since their expiry is checked with some leeway
+const clientAssertionCleanupDelay = 10 * time.Minute
+
+// ErrClientAssertionReplayed is returned when an app tries to reuse a client assertion
+var ErrClientAssertionReplayed = ucerr.New("client assertion has already been used")
+
+// RecordClientAssertion saves the ID of a client assertion that an app has used to authenticate, and
+// returns ErrClientAssertionReplayed if the app has already used an assertion with the same ID.
+func RecordClientAssertion(ctx context.Context, s *Storage, clientID string, jti string, expires time.Time) error {
+	ca := &ClientAssertion{
+		BaseModel: ucdb.NewBase(),
+		ClientID:  clientID,
+		JTI:       jti,
+		Expires:   expires,
+	}
+
+	// we rely on the (client_id, jti, deleted) unique constraint rather than checking first, since
+	// two concurrent requests with the same assertion would otherwise both succeed
+	if err := s.SaveClientAssertion(ctx, ca); err != nil {
+		if ucdb.IsUniqueViolation(err) {
+			return ucerr.Wrap(ErrClientAssertionReplayed)
+		}
+		return ucerr.Wrap(err)
+	}
+
+	return nil
+}
+
+// CleanClientAssertions deletes the IDs of up to maxCandidates expired client assertions, which can no longer be
+// replayed, only counting them if dryRun is true
+func (s *Storage) CleanClientAssertions(ctx context.Context, maxCandidates int, dryRun bool) error {
+	if maxCandidates < 1 {
+		return ucerr.Errorf("maxCandidates must be greater than or equal to one: %d", maxCandidates)
+	}
+
+	cutoff := time.Now().UTC().Add(-clientAssertionCleanupDelay)
+
+	if dryRun {
+		const q = "SELECT COUNT(*) FROM (SELECT id FROM client_assertions WHERE expires < $1 LIMIT $2) expired;"
+		var count int
+		if err := s.db.GetContext(ctx, "CleanClientAssertions", &count, q, cutoff, maxCandidates); err != nil {
+			return ucerr.Wrap(err)
+		}
+		uclog.Infof(ctx, "would delete %d expired client assertions", count)
+		return nil
+	}
+
+	const q = "DELETE FROM client_assertions WHERE id IN (SELECT id FROM client_assertions WHERE expires < $1 LIMIT $2);"
+	res, err := s.db.ExecContext(ctx, "CleanClientAssertions", q, cutoff, maxCandidates)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	uclog.Infof(ctx, "deleted %d expired client assertions", count)
+	return nil
+}
@@ -0,0 +1,31 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// SaveClientAssertion saves a ClientAssertion
+func (s *Storage) SaveClientAssertion(ctx context.Context, obj *ClientAssertion) error {
+	if err := obj.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return ucerr.Wrap(s.saveInnerClientAssertion(ctx, obj))
+}
+
+// SaveClientAssertion saves a ClientAssertion
+func (s *Storage) saveInnerClientAssertion(ctx context.Context, obj *ClientAssertion) error {
+	const q = "INSERT INTO client_assertions (id, updated, deleted, client_id, jti, expires) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, client_id = $3, jti = $4, expires = $5 WHERE (client_assertions.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveClientAssertion", obj, q, obj.ID, obj.Deleted, obj.ClientID, obj.JTI, obj.Expires); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ucerr.Friendlyf(err, "ClientAssertion %v not found", obj.ID)
+		}
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
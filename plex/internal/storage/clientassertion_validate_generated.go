@@ -0,0 +1,21 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ClientAssertion) Validate() error {
+	if err := o.BaseModel.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.ClientID == "" {
+		return ucerr.Friendlyf(nil, "ClientAssertion.ClientID (%v) can't be empty", o.ID)
+	}
+	if o.JTI == "" {
+		return ucerr.Friendlyf(nil, "ClientAssertion.JTI (%v) can't be empty", o.ID)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"userclouds.com/infra/crypto"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// Constants for device authorization requests
+// TODO: should these be configurable per-app?
+const (
+	DeviceCodeValidity        = 10 * time.Minute
+	DefaultDevicePollInterval = 5 // seconds
+
+	// per https://www.rfc-editor.org/rfc/rfc8628#section-3.5, a client that is told to slow down
+	// must increase its polling interval by 5 seconds for this and all subsequent requests
+	DeviceSlowDownIncrement = 5 // seconds
+
+	// user codes are entered by hand, so per https://www.rfc-editor.org/rfc/rfc8628#section-6.1
+	// we use a 20 character consonant-only alphabet (no vowels to avoid accidentally spelling words,
+	// and no easily-confused characters), giving 20^8 (~34 bits) of entropy
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+
+	// we keep device authorizations for a while after they expire, so a device that polls just after
+	// its code expires is told so rather than that the code is invalid
+	deviceAuthorizationCleanupDelay = 10 * time.Minute
+)
+
+// ErrDeviceCodeNotFound represents a missing (or already-expired) device or user code
+var ErrDeviceCodeNotFound = ucerr.New("device authorization not found")
+
+func generateUserCode() string {
+	alphabetSize := big.NewInt(int64(len(userCodeAlphabet)))
+
+	var sb strings.Builder
+	for range userCodeLength {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			// If this fails it's not likely recoverable.
+			panic(err)
+		}
+		sb.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return sb.String()
+}
+
+// NormalizeUserCode strips the formatting characters we (and users) add to user codes
+// and upper-cases the result, so that "bcdf-ghjk" and "BCDFGHJK" match the same code.
+func NormalizeUserCode(userCode string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(userCode))
+}
+
+// FormatUserCode returns a user code in the XXXX-XXXX form we display to users
+func FormatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// CreateDeviceAuthorization creates and saves a new pending device authorization request
+func CreateDeviceAuthorization(ctx context.Context, s *Storage, clientID string, scopes string) (*DeviceAuthorization, error) {
+	da := &DeviceAuthorization{
+		BaseModel:    ucdb.NewBase(),
+		ClientID:     clientID,
+		DeviceCode:   crypto.MustRandomHex(crypto.OpaqueTokenBytes),
+		UserCode:     generateUserCode(),
+		Scopes:       scopes,
+		Expires:      time.Now().UTC().Add(DeviceCodeValidity),
+		PollInterval: DefaultDevicePollInterval,
+		Status:       DeviceAuthorizationStatusPending,
+	}
+
+	if err := s.SaveDeviceAuthorization(ctx, da); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return da, nil
+}
+
+// GetDeviceAuthorizationForDeviceCode loads a DeviceAuthorization by looking up the device code.
+func (s *Storage) GetDeviceAuthorizationForDeviceCode(ctx context.Context, deviceCode string) (*DeviceAuthorization, error) {
+	const q = "SELECT id, created, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id FROM device_authorizations WHERE device_code=$1 AND deleted='0001-01-01 00:00:00';"
+
+	var obj DeviceAuthorization
+	if err := s.db.GetContext(ctx, "GetDeviceAuthorizationForDeviceCode", &obj, q, deviceCode); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Wrap(ErrDeviceCodeNotFound)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &obj, nil
+}
+
+// GetPendingDeviceAuthorizationForUserCode loads the unexpired, pending DeviceAuthorization for a user code.
+// User codes are short and may repeat over time, so we only ever match pending, unexpired requests.
+func (s *Storage) GetPendingDeviceAuthorizationForUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error) {
+	const q = "SELECT id, created, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id FROM device_authorizations WHERE user_code=$1 AND status=$2 AND expires>$3 AND deleted='0001-01-01 00:00:00' ORDER BY created DESC LIMIT 1;"
+
+	var obj DeviceAuthorization
+	if err := s.db.GetContext(ctx, "GetPendingDeviceAuthorizationForUserCode", &obj, q, NormalizeUserCode(userCode), DeviceAuthorizationStatusPending, time.Now().UTC()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Wrap(ErrDeviceCodeNotFound)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &obj, nil
+}
+
+// UpdateDeviceAuthorizationIfStatus saves the status, polling state, login session and plex token of a device
+// authorization, but only if its status hasn't changed from the given one since it was loaded, so that (for example)
+// a device code can't be redeemed by two concurrent requests, or an approval overwritten by a concurrent poll.
+// It returns ErrDeviceCodeNotFound if the status has changed.
+func (s *Storage) UpdateDeviceAuthorizationIfStatus(ctx context.Context, da *DeviceAuthorization, status DeviceAuthorizationStatus) error {
+	if err := da.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	const q = "UPDATE device_authorizations SET updated=CLOCK_TIMESTAMP(), status=$3, poll_interval=$4, last_polled=$5, session_id=$6, plex_token_id=$7 WHERE id=$1 AND status=$2 AND deleted='0001-01-01 00:00:00';"
+	res, err := s.db.ExecContext(ctx, "UpdateDeviceAuthorizationIfStatus", q, da.ID, status, da.Status, da.PollInterval, da.LastPolled, da.SessionID, da.PlexTokenID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if count == 0 {
+		return ucerr.Wrap(ErrDeviceCodeNotFound)
+	}
+	return nil
+}
+
+// CleanDeviceAuthorizations deletes up to maxCandidates expired device authorizations, only counting them if
+// dryRun is true. The tokens issued for approved requests are cleaned up separately along with other plex tokens.
+func (s *Storage) CleanDeviceAuthorizations(ctx context.Context, maxCandidates int, dryRun bool) error {
+	if maxCandidates < 1 {
+		return ucerr.Errorf("maxCandidates must be greater than or equal to one: %d", maxCandidates)
+	}
+
+	cutoff := time.Now().UTC().Add(-deviceAuthorizationCleanupDelay)
+
+	if dryRun {
+		const q = "SELECT COUNT(*) FROM (SELECT id FROM device_authorizations WHERE expires < $1 LIMIT $2) expired;"
+		var count int
+		if err := s.db.GetContext(ctx, "CleanDeviceAuthorizations", &count, q, cutoff, maxCandidates); err != nil {
+			return ucerr.Wrap(err)
+		}
+		uclog.Infof(ctx, "would delete %d expired device authorizations", count)
+		return nil
+	}
+
+	const q = "DELETE FROM device_authorizations WHERE id IN (SELECT id FROM device_authorizations WHERE expires < $1 LIMIT $2);"
+	res, err := s.db.ExecContext(ctx, "CleanDeviceAuthorizations", q, cutoff, maxCandidates)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	uclog.Infof(ctx, "deleted %d expired device authorizations", count)
+	return nil
+}
@@ -0,0 +1,181 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uctypes/set"
+)
+
+// IsDeviceAuthorizationSoftDeleted returns true if the id is associated with a soft-deleted row but no undeleted rows
+func (s *Storage) IsDeviceAuthorizationSoftDeleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	const q = "/* lint-sql-ok */ SELECT deleted FROM device_authorizations WHERE id=$1 ORDER By deleted LIMIT 1;"
+
+	var deleted time.Time
+	if err := s.db.GetContext(ctx, "IsDeviceAuthorizationSoftDeleted", &deleted, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, ucerr.Wrap(err)
+	}
+
+	return !deleted.IsZero(), nil
+}
+
+// GetDeviceAuthorization loads a DeviceAuthorization by ID
+func (s *Storage) GetDeviceAuthorization(ctx context.Context, id uuid.UUID) (*DeviceAuthorization, error) {
+	const q = "SELECT id, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id, created FROM device_authorizations WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+
+	var obj DeviceAuthorization
+	if err := s.db.GetContext(ctx, "GetDeviceAuthorization", &obj, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Friendlyf(err, "DeviceAuthorization %v not found", id)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+	return &obj, nil
+}
+
+// GetDeviceAuthorizationSoftDeleted loads a DeviceAuthorization by ID iff it's soft-deleted
+func (s *Storage) GetDeviceAuthorizationSoftDeleted(ctx context.Context, id uuid.UUID) (*DeviceAuthorization, error) {
+	const q = "SELECT id, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id, created FROM device_authorizations WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+
+	var obj DeviceAuthorization
+	if err := s.db.GetContext(ctx, "GetDeviceAuthorizationSoftDeleted", &obj, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ucerr.Friendlyf(err, "soft-deleted DeviceAuthorization %v not found", id)
+		}
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &obj, nil
+}
+
+// GetDeviceAuthorizationsForIDs loads multiple DeviceAuthorization for a given list of IDs
+func (s *Storage) GetDeviceAuthorizationsForIDs(ctx context.Context, errorOnMissing bool, ids ...uuid.UUID) ([]DeviceAuthorization, error) {
+	items := make([]DeviceAuthorization, 0, len(ids))
+
+	missed := set.NewUUIDSet(ids...) // Assume we will miss all keys, and remove from this list if we get them from the cache
+	dirty := true
+	if missed.Size() > 0 {
+		itemsFromDB, err := s.getDeviceAuthorizationsHelperForIDs(ctx, dirty, true, missed.Items()...)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		items = append(items, itemsFromDB...)
+	}
+
+	return items, nil
+}
+
+// getDeviceAuthorizationsHelperForIDs loads multiple DeviceAuthorization for a given list of IDs from the DB
+func (s *Storage) getDeviceAuthorizationsHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]DeviceAuthorization, error) {
+	const q = "SELECT id, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id, created FROM device_authorizations WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	var objects []DeviceAuthorization
+	if err := s.db.SelectContextWithDirty(ctx, "GetDeviceAuthorizationsForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if errorOnMissing && len(ids) != len(objects) {
+		requestedIDs := set.NewUUIDSet(ids...)
+		loadedIDs := set.NewUUIDSet()
+		for _, obj := range objects {
+			loadedIDs.Insert(obj.ID)
+		}
+		missingIDs := requestedIDs.Difference(loadedIDs)
+		return nil, ucerr.Friendlyf(nil, "Not all requested DeviceAuthorizations  were loaded. requested: %v loaded: %v missing: [%v]", len(ids), len(objects), missingIDs)
+	}
+	return objects, nil
+}
+
+// ListDeviceAuthorizationsPaginated loads a paginated list of DeviceAuthorizations for the specified paginator settings
+func (s *Storage) ListDeviceAuthorizationsPaginated(ctx context.Context, p pagination.Paginator) ([]DeviceAuthorization, *pagination.ResponseFields, error) {
+	return s.listInnerDeviceAuthorizationsPaginated(ctx, p, false)
+}
+
+// listInnerDeviceAuthorizationsPaginated loads a paginated list of DeviceAuthorizations for the specified paginator settings
+func (s *Storage) listInnerDeviceAuthorizationsPaginated(ctx context.Context, p pagination.Paginator, forceDBRead bool) ([]DeviceAuthorization, *pagination.ResponseFields, error) {
+	queryFields, err := p.GetQueryFields()
+	if err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+
+	// the inner query requires an alias for postgres, so we always call it tmp
+	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
+	q := fmt.Sprintf("SELECT id, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id, created FROM (SELECT id, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id, created FROM device_authorizations WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+
+	var objsDB []DeviceAuthorization
+	if err := s.db.SelectContext(ctx, "ListDeviceAuthorizationsPaginated", &objsDB, q, queryFields...); err != nil {
+		return nil, nil, ucerr.Wrap(err)
+	}
+	objs, respFields := pagination.ProcessResults(objsDB, p.GetCursor(), p.GetLimit(), p.IsForward(), p.GetSortKey())
+	if respFields.HasNext {
+		if err := p.ValidateCursor(respFields.Next); err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+	}
+
+	if respFields.HasPrev {
+		if err := p.ValidateCursor(respFields.Prev); err != nil {
+			return nil, nil, ucerr.Wrap(err)
+		}
+	}
+
+	return objs, &respFields, nil
+}
+
+// SaveDeviceAuthorization saves a DeviceAuthorization
+func (s *Storage) SaveDeviceAuthorization(ctx context.Context, obj *DeviceAuthorization) error {
+	if err := obj.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return ucerr.Wrap(s.saveInnerDeviceAuthorization(ctx, obj))
+}
+
+// SaveDeviceAuthorization saves a DeviceAuthorization
+func (s *Storage) saveInnerDeviceAuthorization(ctx context.Context, obj *DeviceAuthorization) error {
+	const q = "INSERT INTO device_authorizations (id, updated, deleted, client_id, device_code, user_code, scopes, expires, poll_interval, last_polled, status, session_id, plex_token_id) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, client_id = $3, device_code = $4, user_code = $5, scopes = $6, expires = $7, poll_interval = $8, last_polled = $9, status = $10, session_id = $11, plex_token_id = $12 WHERE (device_authorizations.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveDeviceAuthorization", obj, q, obj.ID, obj.Deleted, obj.ClientID, obj.DeviceCode, obj.UserCode, obj.Scopes, obj.Expires, obj.PollInterval, obj.LastPolled, obj.Status, obj.SessionID, obj.PlexTokenID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ucerr.Friendlyf(err, "DeviceAuthorization %v not found", obj.ID)
+		}
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteDeviceAuthorization soft-deletes a DeviceAuthorization which is currently alive
+// Note that this will fail on an already-deleted object (since we don't want to re-delete
+// tombstoned objects and corrupt the deletion timestamp)
+func (s *Storage) DeleteDeviceAuthorization(ctx context.Context, objID uuid.UUID) error {
+	return ucerr.Wrap(s.deleteInnerDeviceAuthorization(ctx, objID, false))
+}
+
+// deleteInnerDeviceAuthorization soft-deletes a DeviceAuthorization which is currently alive
+func (s *Storage) deleteInnerDeviceAuthorization(ctx context.Context, objID uuid.UUID, wrappedDelete bool) error {
+	const q = "UPDATE device_authorizations SET deleted=CLOCK_TIMESTAMP() WHERE id=$1 AND deleted='0001-01-01 00:00:00' RETURNING deleted;"
+	res, err := s.db.ExecContext(ctx, "DeleteDeviceAuthorization", q, objID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Errorf("Error deleting DeviceAuthorization %v: %w", objID, err)
+	}
+	if ra == 0 {
+		// we wrap sql.ErrNoRows here to be consistent
+		return ucerr.Friendlyf(sql.ErrNoRows, "DeviceAuthorization %v not found", objID)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"fmt"
+	"net/http"
+
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+)
+
+// GetCursor is part of the pagination.PageableType interface
+func (o DeviceAuthorization) GetCursor(k pagination.Key) pagination.Cursor {
+	if k == "id" {
+		return pagination.Cursor(fmt.Sprintf("id:%v", o.GetID()))
+	}
+	return pagination.CursorBegin
+}
+
+// GetPaginationKeys is part of the pagination.PageableType interface
+func (o DeviceAuthorization) GetPaginationKeys() pagination.KeyTypes {
+	keyTypes := pagination.KeyTypes{}
+	keyTypes["id"] = pagination.UUIDKeyType
+	return keyTypes
+}
+
+// NewDeviceAuthorizationPaginatorFromOptions generates a paginator for a DeviceAuthorization
+func NewDeviceAuthorizationPaginatorFromOptions(
+	options ...pagination.Option,
+) (*pagination.Paginator, error) {
+	var resultType DeviceAuthorization
+	options = append(options, pagination.ResultType(resultType))
+	pager, err := pagination.ApplyOptions(options...)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if cursor := resultType.GetCursor(pager.GetSortKey()); cursor == pagination.CursorBegin {
+		return nil, ucerr.Friendlyf(nil, "sort key '%s' is unsupported", pager.GetSortKey())
+	}
+
+	return pager, nil
+}
+
+// NewDeviceAuthorizationPaginatorFromQuery generates a paginator for a DeviceAuthorization
+func NewDeviceAuthorizationPaginatorFromQuery(
+	query pagination.Query,
+	defaultOptions ...pagination.Option,
+) (*pagination.Paginator, error) {
+	var resultType DeviceAuthorization
+	defaultOptions = append(defaultOptions, pagination.ResultType(resultType))
+	pager, err := pagination.NewPaginatorFromQuery(query, defaultOptions...)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if cursor := resultType.GetCursor(pager.GetSortKey()); cursor == pagination.CursorBegin {
+		return nil, ucerr.Friendlyf(nil, "sort key '%s' is unsupported", pager.GetSortKey())
+	}
+
+	return pager, nil
+}
+
+// NewDeviceAuthorizationPaginatorFromRequest generates a paginator and cursor maker for a DeviceAuthorization
+func NewDeviceAuthorizationPaginatorFromRequest(
+	r *http.Request,
+	defaultOptions ...pagination.Option,
+) (*pagination.Paginator, error) {
+	var resultType DeviceAuthorization
+	defaultOptions = append(defaultOptions, pagination.ResultType(resultType))
+	pager, err := pagination.NewPaginatorFromRequest(r, defaultOptions...)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if cursor := resultType.GetCursor(pager.GetSortKey()); cursor == pagination.CursorBegin {
+		return nil, ucerr.Friendlyf(nil, "sort key '%s' is unsupported", pager.GetSortKey())
+	}
+
+	return pager, nil
+}
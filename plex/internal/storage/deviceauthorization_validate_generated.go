@@ -0,0 +1,31 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o DeviceAuthorization) Validate() error {
+	if err := o.BaseModel.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.ClientID == "" {
+		return ucerr.Friendlyf(nil, "DeviceAuthorization.ClientID (%v) can't be empty", o.ID)
+	}
+	if o.DeviceCode == "" {
+		return ucerr.Friendlyf(nil, "DeviceAuthorization.DeviceCode (%v) can't be empty", o.ID)
+	}
+	if o.UserCode == "" {
+		return ucerr.Friendlyf(nil, "DeviceAuthorization.UserCode (%v) can't be empty", o.ID)
+	}
+	if o.Scopes == "" {
+		return ucerr.Friendlyf(nil, "DeviceAuthorization.Scopes (%v) can't be empty", o.ID)
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package storage
+
+import "userclouds.com/infra/ucerr"
+
+// MarshalText implements encoding.TextMarshaler (for JSON)
+func (t DeviceAuthorizationStatus) MarshalText() ([]byte, error) {
+	switch t {
+	case DeviceAuthorizationStatusApproved:
+		return []byte("approved"), nil
+	case DeviceAuthorizationStatusDenied:
+		return []byte("denied"), nil
+	case DeviceAuthorizationStatusInvalid:
+		return []byte("invalid"), nil
+	case DeviceAuthorizationStatusPending:
+		return []byte("pending"), nil
+	case DeviceAuthorizationStatusRedeemed:
+		return []byte("redeemed"), nil
+	default:
+		return nil, ucerr.Friendlyf(nil, "unknown DeviceAuthorizationStatus value '%d'", t)
+	}
+}
+
+// UnmarshalText implements encoding.TextMarshaler (for JSON)
+func (t *DeviceAuthorizationStatus) UnmarshalText(b []byte) error {
+	s := string(b)
+	switch s {
+	case "approved":
+		*t = DeviceAuthorizationStatusApproved
+	case "denied":
+		*t = DeviceAuthorizationStatusDenied
+	case "invalid":
+		*t = DeviceAuthorizationStatusInvalid
+	case "pending":
+		*t = DeviceAuthorizationStatusPending
+	case "redeemed":
+		*t = DeviceAuthorizationStatusRedeemed
+	default:
+		return ucerr.Friendlyf(nil, "unknown DeviceAuthorizationStatus value '%s'", s)
+	}
+	return nil
+}
+
+// Validate implements Validateable
+func (t *DeviceAuthorizationStatus) Validate() error {
+	switch *t {
+	case DeviceAuthorizationStatusApproved:
+		return nil
+	case DeviceAuthorizationStatusDenied:
+		return nil
+	case DeviceAuthorizationStatusPending:
+		return nil
+	case DeviceAuthorizationStatusRedeemed:
+		return nil
+	default:
+		return ucerr.Friendlyf(nil, "unknown DeviceAuthorizationStatus value '%d'", *t)
+	}
+}
+
+// Enum implements Enum
+func (t DeviceAuthorizationStatus) Enum() []any {
+	return []any{
+		"approved",
+		"denied",
+		"pending",
+		"redeemed",
+	}
+}
+
+// AllDeviceAuthorizationStatuss is a slice of all DeviceAuthorizationStatus values
+var AllDeviceAuthorizationStatuss = []DeviceAuthorizationStatus{
+	DeviceAuthorizationStatusApproved,
+	DeviceAuthorizationStatusDenied,
+	DeviceAuthorizationStatusPending,
+	DeviceAuthorizationStatusRedeemed,
+}
+
+// just here for easier debugging
+func (t DeviceAuthorizationStatus) String() string {
+	bs, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(bs)
+}
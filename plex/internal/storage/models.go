@@ -134,6 +134,63 @@ type PKCEState struct {
 
 //go:generate genvalidate PKCEState
 
+// DeviceAuthorization tracks an OAuth 2.0 Device Authorization Grant (RFC 8628) request from the time
+// the device and user codes are issued until the device redeems the device code for tokens.
+type DeviceAuthorization struct {
+	ucdb.BaseModel
+
+	ClientID   string    `db:"client_id" validate:"notempty"`
+	DeviceCode string    `db:"device_code" validate:"notempty"`
+	UserCode   string    `db:"user_code" validate:"notempty"`
+	Scopes     string    `db:"scopes" validate:"notempty"`
+	Expires    time.Time `db:"expires"`
+
+	// PollInterval is the minimum number of seconds the device must wait between token requests,
+	// and LastPolled is the time of the most recent token request for this device code
+	PollInterval int       `db:"poll_interval"`
+	LastPolled   time.Time `db:"last_polled"`
+
+	Status DeviceAuthorizationStatus `db:"status"`
+
+	// If not uuid.Nil, this refers to the OIDCLoginSession started from the verification page
+	SessionID uuid.UUID `db:"session_id"`
+
+	// If not uuid.Nil, this refers to the PlexToken issued when the user approved the request
+	PlexTokenID uuid.UUID `db:"plex_token_id"`
+}
+
+func (da DeviceAuthorization) extraValidate() error {
+	if da.PollInterval <= 0 {
+		return ucerr.Errorf("DeviceAuthorization.PollInterval (%v) must be positive", da.ID)
+	}
+	if da.Status == DeviceAuthorizationStatusApproved && da.PlexTokenID == uuid.Nil {
+		return ucerr.Errorf("DeviceAuthorization.PlexTokenID (%v) can't be nil if DeviceAuthorization.Status is approved", da.ID)
+	}
+	return nil
+}
+
+// IsExpired returns true if the device code can no longer be used
+func (da DeviceAuthorization) IsExpired() bool {
+	return time.Now().UTC().After(da.Expires)
+}
+
+//go:generate genpageable DeviceAuthorization
+
+//go:generate genvalidate DeviceAuthorization
+
+// ClientAssertion records the ID (jti) of a private_key_jwt client assertion (RFC 7523) that an app
+// has already used to authenticate, so that the same assertion can't be replayed before it expires.
+// Expired client assertions are deleted by the plex token cleanup (see CleanClientAssertions).
+type ClientAssertion struct {
+	ucdb.BaseModel
+
+	ClientID string    `db:"client_id" validate:"notempty"`
+	JTI      string    `db:"jti" validate:"notempty"`
+	Expires  time.Time `db:"expires"`
+}
+
+//go:generate genvalidate ClientAssertion
+
 // DelegationState contains state for a login session that allows delegation
 type DelegationState struct {
 	ucdb.BaseModel
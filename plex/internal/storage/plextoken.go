@@ -8,6 +8,7 @@ import (
 
 	"github.com/gofrs/uuid"
 
+	"userclouds.com/infra/crypto"
 	"userclouds.com/infra/pagination"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
@@ -42,6 +43,10 @@ type PlexToken struct {
 	// TODO: we current store this as a string to account for different IDP types (e.g. Google, Okta, etc.)
 	// but it's quite possible it should actually be an *oidc.TokenInfo struct?
 	UnderlyingToken string `db:"underlying_token"` // optional
+
+	// If the token was issued by a token exchange, this is the ID of the subject token it was exchanged
+	// for, so that it stops being valid when the subject token is revoked. Otherwise it is uuid.Nil.
+	SubjectTokenID uuid.UUID `db:"subject_token_id"`
 }
 
 func (pt PlexToken) getCursor(key pagination.Key, cursor *pagination.Cursor) {
@@ -80,6 +85,33 @@ func (pt PlexToken) isInteractive() bool {
 //go:generate genpageable PlexToken
 //go:generate genvalidate PlexToken
 
+// removePlexToken detaches a plex token from its login session (if applicable) and deletes it
+func (s *Storage) removePlexToken(ctx context.Context, pt PlexToken) error {
+	if pt.isInteractive() {
+		if session, err := s.GetOIDCLoginSession(ctx, pt.SessionID); err == nil {
+			if session.PlexTokenID == pt.ID {
+				session.PlexTokenID = uuid.Nil
+				if err := s.SaveOIDCLoginSession(ctx, session); err != nil {
+					return ucerr.Wrap(err)
+				}
+
+				uclog.Infof(
+					ctx,
+					"detached plex token '%v' from session '%v'",
+					pt.ID,
+					pt.SessionID,
+				)
+			}
+		}
+	}
+
+	if err := s.deletePlexToken(ctx, pt.ID); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	return nil
+}
+
 func (s *Storage) cleanPlexToken(ctx context.Context, candidate PlexToken, dryRun bool) error {
 	if !candidate.isExpired() {
 		return nil
@@ -88,29 +120,7 @@ func (s *Storage) cleanPlexToken(ctx context.Context, candidate PlexToken, dryRu
 	uclog.Debugf(ctx, "detected expired plex token '%v'", candidate.ID)
 
 	if !dryRun {
-		// detach the plex token from the session if applicable
-
-		if candidate.isInteractive() {
-			if session, err := s.GetOIDCLoginSession(ctx, candidate.SessionID); err == nil {
-				if session.PlexTokenID == candidate.ID {
-					session.PlexTokenID = uuid.Nil
-					if err := s.SaveOIDCLoginSession(ctx, session); err != nil {
-						return ucerr.Wrap(err)
-					}
-
-					uclog.Infof(
-						ctx,
-						"detached expired plex token '%v' from session '%v'",
-						candidate.ID,
-						candidate.SessionID,
-					)
-				}
-			}
-		}
-
-		// delete the plex token
-
-		if err := s.deletePlexToken(ctx, candidate.ID); err != nil {
+		if err := s.removePlexToken(ctx, candidate); err != nil {
 			return ucerr.Wrap(err)
 		}
 
@@ -120,6 +130,65 @@ func (s *Storage) cleanPlexToken(ctx context.Context, candidate PlexToken, dryRu
 	return nil
 }
 
+// RevokePlexToken deletes a plex token (and with it the access & refresh tokens that were issued
+// with it) so that it can no longer be refreshed or introspected as active.
+func (s *Storage) RevokePlexToken(ctx context.Context, pt *PlexToken) error {
+	if err := s.removePlexToken(ctx, *pt); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "revoked plex token '%v'", pt.ID)
+	return nil
+}
+
+// ConsumePlexTokenAuthCode replaces the auth code of a plex token with a new random one, so that the code we
+// redirected the user agent with can't be used again, and returns ErrCodeNotFound if it has already been used
+func (s *Storage) ConsumePlexTokenAuthCode(ctx context.Context, pt *PlexToken) error {
+	authCode := crypto.GenerateOpaqueAccessToken()
+
+	const q = "UPDATE plex_tokens SET updated=CLOCK_TIMESTAMP(), auth_code=$3 WHERE id=$1 AND auth_code=$2 AND deleted='0001-01-01 00:00:00';"
+	res, err := s.db.ExecContext(ctx, "ConsumePlexTokenAuthCode", q, pt.ID, pt.AuthCode, authCode)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if count == 0 {
+		return ucerr.Wrap(ErrCodeNotFound)
+	}
+
+	pt.AuthCode = authCode
+	return nil
+}
+
+// RevokePlexTokensForSubject deletes all of the plex tokens issued to a user, along with the login sessions
+// they were issued in, and returns the number of tokens deleted
+func (s *Storage) RevokePlexTokensForSubject(ctx context.Context, idpSubject string) (int, error) {
+	const q = "SELECT id, created, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id FROM plex_tokens WHERE idp_subject=$1 AND deleted='0001-01-01 00:00:00';"
+
+	var pts []PlexToken
+	if err := s.db.SelectContext(ctx, "RevokePlexTokensForSubject", &pts, q, idpSubject); err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	for _, pt := range pts {
+		if err := s.removePlexToken(ctx, pt); err != nil {
+			return 0, ucerr.Wrap(err)
+		}
+
+		if pt.isInteractive() && !pt.SessionID.IsNil() {
+			if err := s.DeleteOIDCLoginSession(ctx, pt.SessionID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return 0, ucerr.Wrap(err)
+			}
+		}
+	}
+
+	uclog.Infof(ctx, "revoked %d plex tokens for subject '%v'", len(pts), idpSubject)
+	return len(pts), nil
+}
+
 // CleanPlexTokens will look for expired and unreferenced plex tokens, evaluating
 // up to maxCandidates tokens and only actually deleting the plex tokens if dryRun is false
 func (s *Storage) CleanPlexTokens(ctx context.Context, maxCandidates int, dryRun bool) error {
@@ -35,7 +35,7 @@ func (s *Storage) IsPlexTokenSoftDeleted(ctx context.Context, id uuid.UUID) (boo
 
 // GetPlexToken loads a PlexToken by ID
 func (s *Storage) GetPlexToken(ctx context.Context, id uuid.UUID) (*PlexToken, error) {
-	const q = "SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, created FROM plex_tokens WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id, created FROM plex_tokens WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
 
 	var obj PlexToken
 	if err := s.db.GetContext(ctx, "GetPlexToken", &obj, q, id); err != nil {
@@ -49,7 +49,7 @@ func (s *Storage) GetPlexToken(ctx context.Context, id uuid.UUID) (*PlexToken, e
 
 // GetPlexTokenSoftDeleted loads a PlexToken by ID iff it's soft-deleted
 func (s *Storage) GetPlexTokenSoftDeleted(ctx context.Context, id uuid.UUID) (*PlexToken, error) {
-	const q = "SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, created FROM plex_tokens WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id, created FROM plex_tokens WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
 
 	var obj PlexToken
 	if err := s.db.GetContext(ctx, "GetPlexTokenSoftDeleted", &obj, q, id); err != nil {
@@ -81,7 +81,7 @@ func (s *Storage) GetPlexTokensForIDs(ctx context.Context, errorOnMissing bool,
 
 // getPlexTokensHelperForIDs loads multiple PlexToken for a given list of IDs from the DB
 func (s *Storage) getPlexTokensHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]PlexToken, error) {
-	const q = "SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, created FROM plex_tokens WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id, created FROM plex_tokens WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
 	var objects []PlexToken
 	if err := s.db.SelectContextWithDirty(ctx, "GetPlexTokensForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -113,7 +113,7 @@ func (s *Storage) listInnerPlexTokensPaginated(ctx context.Context, p pagination
 
 	// the inner query requires an alias for postgres, so we always call it tmp
 	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
-	q := fmt.Sprintf("SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, created FROM (SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, created FROM plex_tokens WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+	q := fmt.Sprintf("SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id, created FROM (SELECT id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id, created FROM plex_tokens WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
 
 	var objsDB []PlexToken
 	if err := s.db.SelectContext(ctx, "ListPlexTokensPaginated", &objsDB, q, queryFields...); err != nil {
@@ -145,8 +145,8 @@ func (s *Storage) SavePlexToken(ctx context.Context, obj *PlexToken) error {
 
 // SavePlexToken saves a PlexToken
 func (s *Storage) saveInnerPlexToken(ctx context.Context, obj *PlexToken) error {
-	const q = "INSERT INTO plex_tokens (id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, client_id = $3, auth_code = $4, access_token = $5, id_token = $6, refresh_token = $7, idp_subject = $8, scopes = $9, session_id = $10, underlying_token = $11 WHERE (plex_tokens.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
-	if err := s.db.GetContext(ctx, "SavePlexToken", obj, q, obj.ID, obj.Deleted, obj.ClientID, obj.AuthCode, obj.AccessToken, obj.IDToken, obj.RefreshToken, obj.IDPSubject, obj.Scopes, obj.SessionID, obj.UnderlyingToken); err != nil {
+	const q = "INSERT INTO plex_tokens (id, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, client_id = $3, auth_code = $4, access_token = $5, id_token = $6, refresh_token = $7, idp_subject = $8, scopes = $9, session_id = $10, underlying_token = $11, subject_token_id = $12 WHERE (plex_tokens.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SavePlexToken", obj, q, obj.ID, obj.Deleted, obj.ClientID, obj.AuthCode, obj.AccessToken, obj.IDToken, obj.RefreshToken, obj.IDPSubject, obj.Scopes, obj.SessionID, obj.UnderlyingToken, obj.SubjectTokenID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ucerr.Friendlyf(err, "PlexToken %v not found", obj.ID)
 		}
@@ -270,6 +270,29 @@ func (mfacs MFAChallengeState) IsFirstChallenge() bool {
 	return mfacs == MFAChallengeStateIssued
 }
 
+// DeviceAuthorizationStatus indicates where a device authorization request is in its lifecycle
+type DeviceAuthorizationStatus int
+
+const (
+	// DeviceAuthorizationStatusInvalid implies the value was never initialized
+	DeviceAuthorizationStatusInvalid DeviceAuthorizationStatus = 0
+
+	// DeviceAuthorizationStatusPending means the user has not yet approved or denied the request
+	DeviceAuthorizationStatusPending DeviceAuthorizationStatus = 1
+
+	// DeviceAuthorizationStatusApproved means the user logged in and approved the request,
+	// but the device has not yet picked up its tokens
+	DeviceAuthorizationStatusApproved DeviceAuthorizationStatus = 2
+
+	// DeviceAuthorizationStatusDenied means the user denied the request
+	DeviceAuthorizationStatusDenied DeviceAuthorizationStatus = 3
+
+	// DeviceAuthorizationStatusRedeemed means the device has exchanged the device code for tokens
+	DeviceAuthorizationStatusRedeemed DeviceAuthorizationStatus = 4
+)
+
+//go:generate genconstant DeviceAuthorizationStatus
+
 // SessionOption represents an optional parameter for creating an OIDC Login Session.
 type SessionOption interface {
 	apply(*OIDCLoginSession) error
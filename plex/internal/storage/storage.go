@@ -91,7 +91,7 @@ func (s *Storage) initializeCache(ctx context.Context, useTestPrefix bool, cc *c
 
 // GetPlexTokenForAuthCode loads a PlexToken by looking up the auth code.
 func (s *Storage) GetPlexTokenForAuthCode(ctx context.Context, authCode string) (*PlexToken, error) {
-	const q = "SELECT id, created, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token FROM plex_tokens WHERE auth_code=$1 AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, created, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id FROM plex_tokens WHERE auth_code=$1 AND deleted='0001-01-01 00:00:00';"
 
 	var obj PlexToken
 	if err := s.db.GetContext(ctx, "GetPlexTokenForAuthCode", &obj, q, authCode); err != nil {
@@ -106,7 +106,7 @@ func (s *Storage) GetPlexTokenForAuthCode(ctx context.Context, authCode string)
 
 // GetPlexTokenForAccessToken loads a PlexToken by looking up the access token.
 func (s *Storage) GetPlexTokenForAccessToken(ctx context.Context, token string) (*PlexToken, error) {
-	const q = "SELECT id, created, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token FROM plex_tokens WHERE access_token=$1 AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, created, updated, deleted, client_id, auth_code, access_token, id_token, refresh_token, idp_subject, scopes, session_id, underlying_token, subject_token_id FROM plex_tokens WHERE access_token=$1 AND deleted='0001-01-01 00:00:00';"
 
 	var obj PlexToken
 	if err := s.db.GetContext(ctx, "GetPlexTokenForAccessToken", &obj, q, token); err != nil {
@@ -140,6 +140,10 @@ func (s *Storage) ListRecentOTPStates(ctx context.Context, purpose OTPPurpose, e
 
 //go:generate genorm DelegationInvite delegation_invites tenantdb
 
+//go:generate genorm DeviceAuthorization device_authorizations tenantdb
+
+//go:generate genorm --noget --nolist --nodelete ClientAssertion client_assertions tenantdb
+
 //go:generate genorm --nodelete PlexToken plex_tokens tenantdb
 
 //go:generate genorm SAMLSession saml_sessions tenantdb
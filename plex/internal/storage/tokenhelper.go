@@ -223,3 +223,45 @@ func GenerateM2MPlexToken(ctx context.Context, tc *tenantplex.TenantConfig, stor
 
 	return plexToken, nil
 }
+
+// GenerateDelegatedPlexToken generates an access token for the subject of an existing token, to be used by an actor
+// on the subject's behalf (via token exchange), and stores it in the DB. We don't issue a refresh token, since the
+// delegated token shouldn't outlive the subject token it was exchanged for.
+func GenerateDelegatedPlexToken(ctx context.Context,
+	tc *tenantplex.TenantConfig,
+	s *Storage,
+	plexApp *tenantplex.App,
+	subjectToken *PlexToken,
+	subjectClaims *oidc.UCTokenClaims,
+	audiences []string,
+	scopes []string,
+	actor *oidc.ActorClaim,
+	validFor int64) (*PlexToken, error) {
+
+	tokenID := uuid.Must(uuid.NewV4())
+	iss := multitenant.MustGetTenantState(ctx).GetTenantURL()
+	scope := strings.Join(scopes, " ")
+
+	accessToken, err := token.CreateDelegatedAccessTokenJWT(ctx, tc, tokenID, subjectClaims.Subject, subjectClaims.SubjectType, subjectClaims.OrganizationID, iss, audiences, scope, actor, validFor)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	plexToken := &PlexToken{
+		BaseModel:   ucdb.NewBaseWithID(tokenID),
+		AuthCode:    crypto.GenerateOpaqueAccessToken(),
+		ClientID:    plexApp.ClientID,
+		AccessToken: accessToken,
+		IDPSubject:  subjectToken.IDPSubject,
+		Scopes:      scope,
+		SessionID:   NonInteractiveSessionID,
+		// link the delegated token to the subject token, so revoking the subject token revokes it too
+		SubjectTokenID: subjectToken.ID,
+	}
+
+	if err := s.SavePlexToken(ctx, plexToken); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return plexToken, nil
+}
@@ -51,6 +51,35 @@ func CreateAccessTokenJWT(ctx context.Context, tc *tenantplex.TenantConfig, toke
 		OrganizationID: organizationID,
 	}
 
+	j, err := signAccessToken(ctx, tc, tokenID, claims, issuer, validFor)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return j, nil
+}
+
+// CreateDelegatedAccessTokenJWT creates & signs a JWT for an access token issued via token exchange. In addition
+// to the usual access token claims, it carries the scope it was issued for and the chain of actors (see RFC 8693).
+func CreateDelegatedAccessTokenJWT(ctx context.Context, tc *tenantplex.TenantConfig, tokenID uuid.UUID, subject string, subjectType string, organizationID string, issuer string, audiences []string, scope string, actor *oidc.ActorClaim, validFor int64) (string, error) {
+	claims := oidc.UCTokenClaims{
+		StandardClaims: oidc.StandardClaims{
+			RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+			Audience:         audiences,
+		},
+		SubjectType:    subjectType,
+		OrganizationID: organizationID,
+		Scope:          scope,
+		Actor:          actor,
+	}
+
+	j, err := signAccessToken(ctx, tc, tokenID, claims, issuer, validFor)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	return j, nil
+}
+
+func signAccessToken(ctx context.Context, tc *tenantplex.TenantConfig, tokenID uuid.UUID, claims oidc.UCTokenClaims, issuer string, validFor int64) (string, error) {
 	keyText, err := tc.Keys.PrivateKey.Resolve(ctx)
 	if err != nil {
 		return "", ucerr.Wrap(err)
@@ -12,6 +12,7 @@ import (
 	"userclouds.com/infra/uchttp/builder"
 	"userclouds.com/infra/ucjwt"
 	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/tenantplex"
 	acmeHandler "userclouds.com/plex/internal/acme"
 	"userclouds.com/plex/internal/tenantconfig"
 )
@@ -42,11 +43,17 @@ type OpenIDProviderJSON struct {
 	TokenURL      string   `json:"token_endpoint"`
 	JWKSURL       string   `json:"jwks_uri"`
 	UserInfoURL   string   `json:"userinfo_endpoint"`
+	DeviceAuthURL string   `json:"device_authorization_endpoint"`
+	IntrospectURL string   `json:"introspection_endpoint"`
+	RevokeURL     string   `json:"revocation_endpoint"`
 	Algorithms    []string `json:"id_token_signing_alg_values_supported"`
 	SubjectTypes  []string `json:"subject_types_supported"`
 	Scopes        []string `json:"scopes_supported"`
 	Claims        []string `json:"claims_supported"`
 	ResponseTypes []string `json:"response_types_supported"`
+
+	TokenEndpointAuthMethods    []tenantplex.TokenEndpointAuthMethod `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthAlgorithms []string                             `json:"token_endpoint_auth_signing_alg_values_supported"`
 }
 
 // OpenIDConfiguration returns the "well-known" OIDC config info
@@ -67,11 +74,17 @@ func (h *handler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
 		TokenURL:      baseURL + "/oidc/token",
 		JWKSURL:       baseURL + "/.well-known/jwks.json",
 		UserInfoURL:   baseURL + "/oidc/userinfo",
+		DeviceAuthURL: baseURL + "/oidc/device/code",
+		IntrospectURL: baseURL + "/oidc/introspect",
+		RevokeURL:     baseURL + "/oidc/revoke",
 		Algorithms:    []string{"RS256"},
 		SubjectTypes:  []string{"public"},
 		Scopes:        []string{"openid", "profile"},
 		Claims:        []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "name", "email"},
 		ResponseTypes: []string{"id_token"},
+
+		TokenEndpointAuthMethods:    tenantplex.SupportedTokenEndpointAuthMethods,
+		TokenEndpointAuthAlgorithms: []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"},
 	}
 
 	jsonapi.Marshal(w, providerJSON)
@@ -82,21 +95,23 @@ func (h *handler) JSONWebKeySet(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	tc := tenantconfig.MustGet(ctx)
-	pubKey, err := ucjwt.LoadRSAPublicKey([]byte(tc.Keys.PublicKey))
-	if err != nil {
-		jsonapi.MarshalErrorL(ctx, w, err, "FailedToLoadPublicKey")
-		return
-	}
 
-	keyset := &jose.JSONWebKeySet{
-		Keys: []jose.JSONWebKey{
-			{
-				Algorithm: "RS256",
-				Key:       pubKey,
-				Use:       "sig",
-				KeyID:     tc.Keys.KeyID,
-			},
-		},
+	// publish the next and retiring keys along with the active key, so that tokens stay
+	// verifiable while the tenant's keys are being rotated
+	keyset := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, k := range tc.GetPublishedKeys() {
+		pubKey, err := ucjwt.LoadRSAPublicKey([]byte(k.PublicKey))
+		if err != nil {
+			jsonapi.MarshalErrorL(ctx, w, err, "FailedToLoadPublicKey")
+			return
+		}
+
+		keyset.Keys = append(keyset.Keys, jose.JSONWebKey{
+			Algorithm: "RS256",
+			Key:       pubKey,
+			Use:       "sig",
+			KeyID:     k.KeyID,
+		})
 	}
 
 	jsonapi.Marshal(w, keyset)
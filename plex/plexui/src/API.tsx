@@ -448,6 +448,31 @@ class InternalClient {
     }
   }
 
+  async startPasskeyLogin(
+    sessionID: string,
+    email: string
+  ): Promise<void | APIError> {
+    const url = makePlexURL('/passwordless/passkey');
+    const req = {
+      session_id: sessionID,
+      email,
+    };
+    try {
+      const rawResponse = await fetch(url, {
+        method: 'POST',
+        body: JSON.stringify(req),
+      });
+      const jsonResponse = await tryGetJSON(rawResponse);
+      const typedResponse = jsonResponse as {
+        redirect_to: string;
+      };
+      window.location.replace(typedResponse.redirect_to);
+      return undefined;
+    } catch (e) {
+      return makeAPIError(e);
+    }
+  }
+
   async finishPasswordlessLogin(
     sessionID: string,
     email: string,
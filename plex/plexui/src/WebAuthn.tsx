@@ -0,0 +1,129 @@
+import {
+  MFASubmitSettings,
+  WebAuthnCredentialDescriptor,
+} from './models/MFASubmitSettings';
+
+// Plex sends binary values (challenges, user handles, credential IDs) base64url encoded,
+// and expects the browser's response encoded the same way.
+
+const base64URLToBuffer = (value: string): ArrayBuffer => {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const padded = base64.padEnd(
+    base64.length + ((4 - (base64.length % 4)) % 4),
+    '='
+  );
+  const binary = atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+};
+
+const bufferToBase64URL = (buffer: ArrayBuffer | null): string | undefined => {
+  if (!buffer) {
+    return undefined;
+  }
+  const bytes = new Uint8Array(buffer);
+  let binary = '';
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary)
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=+$/, '');
+};
+
+const toDescriptors = (
+  descriptors: WebAuthnCredentialDescriptor[]
+): PublicKeyCredentialDescriptor[] =>
+  descriptors.map((d) => ({
+    type: 'public-key',
+    id: base64URLToBuffer(d.id),
+  }));
+
+export const isWebAuthnSupported = () =>
+  typeof window !== 'undefined' && !!window.PublicKeyCredential;
+
+// runWebAuthnCeremony runs the registration or authentication ceremony described by the
+// MFA submit settings, and returns the JSON-encoded credential to submit as the MFA code
+export const runWebAuthnCeremony = async (
+  settings: MFASubmitSettings
+): Promise<string> => {
+  if (!isWebAuthnSupported()) {
+    throw new Error('This browser does not support security keys or passkeys');
+  }
+
+  if (settings.webauthn_creation_options) {
+    const options = settings.webauthn_creation_options;
+    const credential = (await navigator.credentials.create({
+      publicKey: {
+        rp: options.rp,
+        user: {
+          id: base64URLToBuffer(options.user.id),
+          name: options.user.name,
+          displayName: options.user.displayName,
+        },
+        challenge: base64URLToBuffer(options.challenge),
+        pubKeyCredParams: options.pubKeyCredParams.map((p) => ({
+          type: 'public-key',
+          alg: p.alg,
+        })),
+        timeout: options.timeout,
+        excludeCredentials: toDescriptors(options.excludeCredentials),
+        authenticatorSelection: {
+          residentKey: options.authenticatorSelection
+            .residentKey as ResidentKeyRequirement,
+          userVerification: options.authenticatorSelection
+            .userVerification as UserVerificationRequirement,
+        },
+        attestation: options.attestation as AttestationConveyancePreference,
+      },
+    })) as PublicKeyCredential | null;
+    if (!credential) {
+      throw new Error('Security key registration was cancelled');
+    }
+    const response = credential.response as AuthenticatorAttestationResponse;
+    return JSON.stringify({
+      id: credential.id,
+      rawId: bufferToBase64URL(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: bufferToBase64URL(response.clientDataJSON),
+        attestationObject: bufferToBase64URL(response.attestationObject),
+      },
+    });
+  }
+
+  if (settings.webauthn_request_options) {
+    const options = settings.webauthn_request_options;
+    const credential = (await navigator.credentials.get({
+      publicKey: {
+        rpId: options.rpId,
+        challenge: base64URLToBuffer(options.challenge),
+        timeout: options.timeout,
+        allowCredentials: toDescriptors(options.allowCredentials),
+        userVerification:
+          options.userVerification as UserVerificationRequirement,
+      },
+    })) as PublicKeyCredential | null;
+    if (!credential) {
+      throw new Error('Security key sign in was cancelled');
+    }
+    const response = credential.response as AuthenticatorAssertionResponse;
+    return JSON.stringify({
+      id: credential.id,
+      rawId: bufferToBase64URL(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: bufferToBase64URL(response.clientDataJSON),
+        authenticatorData: bufferToBase64URL(response.authenticatorData),
+        signature: bufferToBase64URL(response.signature),
+        userHandle: bufferToBase64URL(response.userHandle),
+      },
+    });
+  }
+
+  throw new Error('No security key challenge has been issued');
+};
@@ -1,3 +1,27 @@
+export interface WebAuthnCredentialDescriptor {
+  type: string;
+  id: string;
+}
+
+export interface WebAuthnCreationOptions {
+  rp: { id: string; name: string };
+  user: { id: string; name: string; displayName: string };
+  challenge: string;
+  pubKeyCredParams: Array<{ type: string; alg: number }>;
+  timeout: number;
+  excludeCredentials: WebAuthnCredentialDescriptor[];
+  authenticatorSelection: { residentKey: string; userVerification: string };
+  attestation: string;
+}
+
+export interface WebAuthnRequestOptions {
+  rpId: string;
+  challenge: string;
+  timeout: number;
+  allowCredentials: WebAuthnCredentialDescriptor[];
+  userVerification: string;
+}
+
 export interface MFASubmitSettings {
   channel_type: string;
   channel_id: string;
@@ -11,4 +35,6 @@ export interface MFASubmitSettings {
   registration_qr_code: string;
   customer_service_link: string;
   mfa_purpose: string;
+  webauthn_creation_options?: WebAuthnCreationOptions;
+  webauthn_request_options?: WebAuthnRequestOptions;
 }
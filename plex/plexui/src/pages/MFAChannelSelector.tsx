@@ -4,6 +4,7 @@ import { Ellipsis, LoginStyles } from '@userclouds/sharedui';
 import {
   IconEmail,
   IconRecoveryCode,
+  IconShieldKeyhole,
   IconSms,
   IconAuthenticatorApp,
   Text,
@@ -28,6 +29,9 @@ const getIcon = (channelType: string) => {
   if (channelType === 'authenticator') {
     return <IconAuthenticatorApp />;
   }
+  if (channelType === 'webauthn') {
+    return <IconShieldKeyhole />;
+  }
 };
 
 const MFAChannelSelector: React.FC = () => {
@@ -8,6 +8,7 @@ import {
   IconEmail,
   IconRecoveryCode,
   IconRotate,
+  IconShieldKeyhole,
   IconSms,
   IconStarLine,
   IconStarSolid,
@@ -36,6 +37,9 @@ const getIcon = (channelType: string) => {
   if (channelType === 'authenticator') {
     return <IconAuthenticatorApp />;
   }
+  if (channelType === 'webauthn') {
+    return <IconShieldKeyhole />;
+  }
 };
 
 const MFAConfigure: React.FC = () => {
@@ -22,6 +22,9 @@ const getSecurityTextPrompt = (channelType: string) => {
   if (channelType === 'sms') {
     return 'Enter a phone number we can text a verification code to.';
   }
+  if (channelType === 'webauthn') {
+    return 'Name your security key or passkey so you can recognize it later.';
+  }
   return '';
 };
 
@@ -76,11 +79,12 @@ const MFAConfigureChannel: React.FC = () => {
   const onSubmit = async () => {
     if (sessionID) {
       setError('');
-      if (channelType && channelTypeID) {
+      // security keys & passkeys don't need a name; the IDP will pick a default
+      if (channelType && (channelTypeID || channelType === 'webauthn')) {
         const maybeError = await API.mfaCreateChannel(
           sessionID,
           channelType,
-          channelTypeID
+          channelTypeID?.trim() || ''
         );
         if (maybeError instanceof APIError) {
           const message = maybeError.message
@@ -168,6 +172,22 @@ const MFAConfigureChannel: React.FC = () => {
                 </Label>
               </>
             )}
+            {channelType === 'webauthn' && (
+              <>
+                <Label className={Styles.formElement} htmlFor="form_mfa">
+                  Name
+                  <input
+                    className={Styles.textInput}
+                    name="form_mfa"
+                    value={channelTypeID || ''}
+                    placeholder="Security Key"
+                    onChange={(e: React.ChangeEvent<HTMLInputElement>) => {
+                      setChannelTypeID(e.target.value);
+                    }}
+                  />
+                </Label>
+              </>
+            )}
             {channelType === 'authenticator' && (
               <>
                 <Label className={Styles.formElement} htmlFor="form_mfa">
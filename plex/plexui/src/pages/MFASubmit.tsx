@@ -12,6 +12,7 @@ import requestParams from '../models/MFASubmitPageRequest';
 import { mungePageParameters } from '../models/PageParametersResponse';
 import { MFASubmitSettings } from '../models/MFASubmitSettings';
 import { MFAPurpose } from '../models/MFAPurpose';
+import { runWebAuthnCeremony } from '../WebAuthn';
 import Styles from './MFA.module.css';
 
 const MFASubmit: React.FC = () => {
@@ -68,11 +69,25 @@ const MFASubmit: React.FC = () => {
     );
   }
 
+  const isWebAuthn = settings.channel_type === 'webauthn';
+
   const onCodeSubmit = async (configure = false) => {
     setStatusText(params.loginStartStatusText);
     setIsError(false);
     setDisabled(true);
-    const maybeError = await API.mfaSubmit(sessionID, code, configure);
+    let mfaCode = code;
+    if (isWebAuthn) {
+      // the "code" for a security key or passkey is the browser's response to the challenge
+      try {
+        mfaCode = await runWebAuthnCeremony(settings);
+      } catch (e) {
+        setStatusText(`${params.loginFailStatusText}: ${(e as Error).message}`);
+        setIsError(true);
+        setDisabled(false);
+        return;
+      }
+    }
+    const maybeError = await API.mfaSubmit(sessionID, mfaCode, configure);
     if (maybeError) {
       // on success it redirects and returns empty str, so if we get here it must be a failure
       setStatusText(`${params.loginFailStatusText}: ${maybeError.message}`);
@@ -143,7 +158,7 @@ const MFASubmit: React.FC = () => {
             onCodeSubmit();
           }}
         >
-          {settings.can_submit_code && (
+          {settings.can_submit_code && !isWebAuthn && (
             <Label className={Styles.formElement}>
               Input Code
               <input
@@ -209,7 +224,7 @@ const MFASubmit: React.FC = () => {
                         backgroundColor: `${params.actionButtonFillColor}`,
                       }}
                     >
-                      Verify Code
+                      {isWebAuthn ? 'Register Security Key' : 'Verify Code'}
                     </button>
 
                     {settings.mfa_purpose === MFAPurpose.LoginSetup && (
@@ -220,7 +235,9 @@ const MFASubmit: React.FC = () => {
                           borderColor: `${params.pageTextColor}`,
                         }}
                       >
-                        Verify Code and Manage MFA Methods
+                        {isWebAuthn
+                          ? 'Register Security Key and Manage MFA Methods'
+                          : 'Verify Code and Manage MFA Methods'}
                       </button>
                     )}
                   </>
@@ -245,7 +262,7 @@ const MFASubmit: React.FC = () => {
                       API.getNewChallenge(sessionID, settings.channel_id);
                     }}
                   >
-                    Re-Send Code
+                    {isWebAuthn ? 'Try Again' : 'Re-Send Code'}
                   </Button>
                 )}
                 {canChangeChannel && (
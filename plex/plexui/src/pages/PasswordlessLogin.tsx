@@ -4,6 +4,7 @@ import { Ellipsis, LoginStyles } from '@userclouds/sharedui';
 import API from '../API';
 import requestParams from '../models/PasswordlessLoginPageRequest';
 import { mungePageParameters } from '../models/PageParametersResponse';
+import { isWebAuthnSupported } from '../WebAuthn';
 
 const PasswordlessLogin: React.FC = () => {
   const [params, setParams] = useState<Record<string, string>>();
@@ -69,6 +70,19 @@ const PasswordlessLogin: React.FC = () => {
     setDisabled(false);
   };
 
+  const onPasskeySubmit = async () => {
+    setStatusText(params.loginStartStatusText);
+    setIsError(false);
+    setDisabled(true);
+    // on success this redirects to the MFA submit page, which runs the passkey ceremony
+    const maybeError = await API.startPasskeyLogin(sessionID, email);
+    if (maybeError) {
+      setStatusText(`Login failed: ${maybeError.message}`);
+      setIsError(true);
+      setDisabled(false);
+    }
+  };
+
   const onCodeSubmit = async () => {
     setStatusText(params.loginStartStatusText);
     setIsError(false);
@@ -169,6 +183,23 @@ const PasswordlessLogin: React.FC = () => {
               color: params.actionButtonTextColor,
             }}
           />
+          {!emailSent && isWebAuthnSupported() && (
+            <button
+              type="button"
+              className={LoginStyles.loginButton}
+              disabled={!email}
+              onClick={onPasskeySubmit}
+              style={{
+                background: 'transparent',
+                border: `2px solid ${
+                  params.actionButtonBorderColor || params.pageTextColor
+                }`,
+                color: params.pageTextColor,
+              }}
+            >
+              Sign in with a passkey
+            </button>
+          )}
         </fieldset>
       </form>
     </main>
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"context"
+	"crypto/rsa"
 	"net/http"
 	"os"
 
@@ -13,8 +14,8 @@ import (
 	"userclouds.com/infra/jsonclient"
 	"userclouds.com/infra/middleware"
 	"userclouds.com/infra/service"
+	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uchttp/builder"
-	"userclouds.com/infra/ucjwt"
 	"userclouds.com/infra/uclog"
 	"userclouds.com/infra/ucreact"
 	"userclouds.com/infra/uctypes/messaging/email"
@@ -99,16 +100,26 @@ func initAPIHandlers(
 	if err != nil {
 		uclog.Fatalf(ctx, "Failed to get console tenant state: %v", err)
 	}
-	mgr := manager.NewFromDB(consoleTenantState.TenantDB, consoleTenantState.CacheConfig)
-	tp, err := mgr.GetTenantPlex(ctx, consoleTenantID)
+	consoleMgr := manager.NewFromDB(consoleTenantState.TenantDB, consoleTenantState.CacheConfig)
+	tp, err := consoleMgr.GetTenantPlex(ctx, consoleTenantID)
 	if err != nil {
 		uclog.Fatalf(ctx, "Failed to get console tenant state: %v", err)
 	}
-	consolePK, err := ucjwt.LoadRSAPublicKey([]byte(tp.PlexConfig.Keys.PublicKey))
-	if err != nil {
+	if _, err := tp.PlexConfig.GetPublicKey(""); err != nil {
 		uclog.Fatalf(context.Background(), "failed to load console tenant public key: %v", err)
 	}
 
+	// the console tenant's keys can be rotated while we're running, so we look them up (through the
+	// tenant plex cache) whenever we verify a token it issued
+	getConsolePublicKey := func(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+		tp, err := consoleMgr.GetTenantPlex(ctx, consoleTenantID)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		pk, err := tp.PlexConfig.GetPublicKey(keyID)
+		return pk, ucerr.Wrap(err)
+	}
+
 	// NB: The shared multitenant.Middleware resolves the tenant from a request's Host header,
 	// and then the tenantconfig.Middleware loads & caches the Plex-specific configs.
 	perTenantMiddleware := middleware.Chain(
@@ -125,7 +136,17 @@ func initAPIHandlers(
 			uclog.Fatalf(ctx, "Failed to get console endpoint: %v", err)
 		}
 	}
-	plexHandler, err := internal.NewHandler(companyConfigStorage, jwtVerifier, reqChecker, emailClient, tcCache, qc, m2mAuth, *consoleTenantInfo, consoleEP, consolePK, handlerOpts...)
+	if cfg.MTLS != nil {
+		clientCAs, err := cfg.MTLS.GetClientCAs()
+		if err != nil {
+			uclog.Fatalf(ctx, "Failed to load mTLS client CAs: %v", err)
+		}
+		handlerOpts = append(handlerOpts, internal.ClientCertificates(oidc.ClientCertificateConfig{
+			TrustHeader: cfg.MTLS.TrustClientCertificateHeader,
+			ClientCAs:   clientCAs,
+		}))
+	}
+	plexHandler, err := internal.NewHandler(companyConfigStorage, jwtVerifier, reqChecker, emailClient, tcCache, qc, m2mAuth, *consoleTenantInfo, consoleEP, getConsolePublicKey, handlerOpts...)
 	if err != nil {
 		uclog.Fatalf(ctx, "Failed to create Plex handler: %v", err)
 	}
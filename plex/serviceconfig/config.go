@@ -5,6 +5,8 @@
 package serviceconfig
 
 import (
+	"crypto/x509"
+
 	"github.com/gofrs/uuid"
 
 	"userclouds.com/infra/acme"
@@ -50,6 +52,7 @@ type ServiceConfig struct {
 	FeatureFlagConfig *featureflags.Config `yaml:"featureflags,omitempty" json:"featureflags" validate:"allownil"`
 	Sentry            *ucsentry.Config     `yaml:"sentry,omitempty" json:"sentry" validate:"allownil"`
 	Tracing           *uctrace.Config      `yaml:"tracing,omitempty" json:"tracing" validate:"allownil"`
+	MTLS              *MTLSConfig          `yaml:"mtls,omitempty" json:"mtls,omitempty" validate:"allownil"`
 }
 
 //go:generate gendbjson ServiceConfig
@@ -87,6 +90,37 @@ func (cfg *ServiceConfig) extraValidate() error {
 	return nil
 }
 
+// MTLSConfig configures mutual TLS client authentication (RFC 8705) at the token endpoint. TLS is terminated by
+// the load balancer, so plex only sees the client certificates it forwards, and only if configured to trust them.
+type MTLSConfig struct {
+	// TrustClientCertificateHeader must only be set if plex is only reachable through a load balancer that verifies
+	// client certificates and sets (overwriting anything sent by the client) the X-Amzn-Mtls-Clientcert-Leaf header,
+	// since otherwise a client could present any certificate it likes
+	TrustClientCertificateHeader bool `yaml:"trust_client_certificate_header" json:"trust_client_certificate_header"`
+
+	// ClientCAs is a PEM bundle of the CA (and any intermediate) certificates that issue certificates to apps using
+	// tls_client_auth, which we verify those certificates against
+	ClientCAs string `yaml:"client_cas,omitempty" json:"client_cas,omitempty"`
+}
+
+//go:generate genvalidate MTLSConfig
+
+func (cfg *MTLSConfig) extraValidate() error {
+	if _, err := cfg.GetClientCAs(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
+
+// GetClientCAs returns the pool of CAs that tls_client_auth certificates are verified against
+func (cfg *MTLSConfig) GetClientCAs() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if cfg.ClientCAs != "" && !pool.AppendCertsFromPEM([]byte(cfg.ClientCAs)) {
+		return nil, ucerr.Friendlyf(nil, "MTLSConfig.ClientCAs doesn't contain any PEM certificates")
+	}
+	return pool, nil
+}
+
 // IsConsoleEndpointDefined returns true if the console endpoint is defined
 func (cfg *ServiceConfig) IsConsoleEndpointDefined() bool {
 	return cfg.ConsoleURL != ""
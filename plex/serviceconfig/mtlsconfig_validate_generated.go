@@ -0,0 +1,16 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package serviceconfig
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o MTLSConfig) Validate() error {
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -58,6 +58,11 @@ func (o ServiceConfig) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.MTLS != nil {
+		if err := o.MTLS.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	// .extraValidate() lets you do any validation you can't express in codegen tags yet
 	if err := o.extraValidate(); err != nil {
 		return ucerr.Wrap(err)
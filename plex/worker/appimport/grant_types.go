@@ -22,6 +22,8 @@ func mapAuth0GrantTypesToUC(grantTypes []string) (tenantplex.GrantTypes, error)
 		case "refresh_token":
 			fallthrough
 		case "urn:ietf:params:oauth:grant-type:device_code":
+			fallthrough
+		case "urn:ietf:params:oauth:grant-type:token-exchange":
 			gts = append(gts, tenantplex.GrantType(gt))
 
 		case "http://auth0.com/oauth/grant-type/password-realm":
@@ -1,7 +1,12 @@
 package storage
 
 import (
+	"context"
+
+	"github.com/lib/pq"
+
 	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
 )
 
 // Storage defines the interface for storing per-tenant user events.
@@ -18,3 +23,19 @@ func New(db *ucdb.DB) *Storage {
 }
 
 //go:generate genorm userevent.UserEvent user_events logdb
+
+// EraseUserEventsForUserAliases permanently deletes every event (including soft-deleted ones) reported under
+// any of the given user aliases, and returns the number of events deleted
+func (s *Storage) EraseUserEventsForUserAliases(ctx context.Context, userAliases []string) (int, error) {
+	const q = "DELETE FROM user_events WHERE user_alias=ANY($1);"
+
+	res, err := s.db.ExecContext(ctx, "EraseUserEventsForUserAliases", q, pq.Array(userAliases))
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	return int(ra), nil
+}
@@ -0,0 +1,15 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package worker
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o DSARExportParams) Validate() error {
+	if o.ExportID.IsNil() {
+		return ucerr.Friendlyf(nil, "DSARExportParams.ExportID can't be nil")
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package worker
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o ExportAccessorParams) Validate() error {
+	if err := o.Request.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package cleanup
+
+import (
+	"context"
+	"net/http"
+
+	authzhelpers "userclouds.com/authz/helpers"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/companyconfig"
+	"userclouds.com/internal/tenantmap"
+	"userclouds.com/worker"
+)
+
+// CleanExpiredAuthzEdgesForTenant deletes authz edges whose validity window has ended for a tenant
+func CleanExpiredAuthzEdgesForTenant(ctx context.Context, ts *tenantmap.TenantState, params worker.DataCleanupParams) error {
+	uclog.Infof(ctx, "Cleaning expired authz edges for tenant %v  max: %d dry run: %v", ts.ID, params.MaxCandidates, params.DryRun)
+	return ucerr.Wrap(authzhelpers.CleanExpiredEdgesForTenant(ctx, ts.ID, ts.TenantDB, ts.CacheConfig, params.MaxCandidates, params.DryRun))
+}
+
+// CleanExpiredAuthzEdgesForAllTenantsHandler returns a handler that dispatches expired authz edge cleanup tasks for all tenants
+func CleanExpiredAuthzEdgesForAllTenantsHandler(ccs *companyconfig.Storage, wc workerclient.Client) http.HandlerFunc {
+	return cleanExpiredForAllTenantsHandler("clean-expired-authz-edges", ccs, wc, worker.AuthzExpiredEdgeCleanupMessage)
+}
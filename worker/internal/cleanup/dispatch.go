@@ -0,0 +1,86 @@
+package cleanup
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/companyconfig"
+	"userclouds.com/worker"
+)
+
+// CleanExpiredResponse represents the response from dispatching a cleanup of expired data for all tenants
+type CleanExpiredResponse struct {
+	TenantsCount  int  `json:"tenants_count" yaml:"tenants_count"`
+	DryRun        bool `json:"dry_run" yaml:"dry_run"`
+	MaxCandidates int  `json:"max_candidates" yaml:"max_candidates"`
+}
+
+// cleanExpiredForAllTenantsHandler returns a handler that dispatches the cleanup message returned by newMessage for
+// all tenants. Unlike the userstore cleanup, expired data is already inert so we delete it unless asked not to.
+func cleanExpiredForAllTenantsHandler(
+	handlerName string,
+	ccs *companyconfig.Storage,
+	wc workerclient.Client,
+	newMessage func(tenantID uuid.UUID, maxCandidates int, dryRun bool) worker.Message,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uclog.SetHandlerName(ctx, handlerName)
+		qp := r.URL.Query()
+		dryRun := qp.Get("dry-run") == "true"
+
+		maxCandidates, err := getMaxCandidates(qp)
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusBadRequest))
+			return
+		}
+
+		tenantsCount, err := dispatchForAllTenants(ctx, ccs, wc, func(tenantID uuid.UUID) worker.Message {
+			return newMessage(tenantID, maxCandidates, dryRun)
+		})
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+			return
+		}
+		uclog.Infof(ctx, "dispatched %s tasks for %d tenants. dry-run=%v, max-candidates=%d", handlerName, tenantsCount, dryRun, maxCandidates)
+
+		jsonapi.Marshal(w, CleanExpiredResponse{
+			TenantsCount:  tenantsCount,
+			DryRun:        dryRun,
+			MaxCandidates: maxCandidates,
+		})
+	}
+}
+
+// dispatchForAllTenants sends the message returned by newMessage for each tenant, and returns the number of
+// tenants it was sent for
+func dispatchForAllTenants(ctx context.Context, ccs *companyconfig.Storage, wc workerclient.Client, newMessage func(tenantID uuid.UUID) worker.Message) (int, error) {
+	pager, err := companyconfig.NewTenantPaginatorFromOptions(pagination.Limit(pagination.MaxLimit))
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	tenantsCount := 0
+	for {
+		tenants, pr, err := ccs.ListTenantsPaginated(ctx, *pager)
+		if err != nil {
+			return tenantsCount, ucerr.Wrap(err)
+		}
+		for _, tenant := range tenants {
+			if err := wc.Send(ctx, newMessage(tenant.ID)); err != nil {
+				return tenantsCount, ucerr.Wrap(err)
+			}
+			tenantsCount++
+		}
+		if !pager.AdvanceCursor(*pr) {
+			break
+		}
+	}
+	return tenantsCount, nil
+}
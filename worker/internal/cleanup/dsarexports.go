@@ -0,0 +1,25 @@
+package cleanup
+
+import (
+	"context"
+	"net/http"
+
+	"userclouds.com/idp/helpers"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/companyconfig"
+	"userclouds.com/internal/tenantmap"
+	"userclouds.com/worker"
+)
+
+// CleanExpiredDSARExportsForTenant clears the bundles of DSAR exports that have expired for a tenant
+func CleanExpiredDSARExportsForTenant(ctx context.Context, ts *tenantmap.TenantState, params worker.DataCleanupParams) error {
+	uclog.Infof(ctx, "Cleaning expired DSAR exports for tenant %v  max: %d dry run: %v", ts.ID, params.MaxCandidates, params.DryRun)
+	return ucerr.Wrap(helpers.CleanExpiredDSARExportsForTenant(ctx, ts, params.MaxCandidates, params.DryRun))
+}
+
+// CleanExpiredDSARExportsForAllTenantsHandler returns a handler that dispatches expired DSAR export cleanup tasks for all tenants
+func CleanExpiredDSARExportsForAllTenantsHandler(ccs *companyconfig.Storage, wc workerclient.Client) http.HandlerFunc {
+	return cleanExpiredForAllTenantsHandler("clean-expired-dsar-exports", ccs, wc, worker.DSARExpiredExportCleanupMessage)
+}
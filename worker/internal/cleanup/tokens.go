@@ -0,0 +1,25 @@
+package cleanup
+
+import (
+	"context"
+	"net/http"
+
+	"userclouds.com/idp/helpers"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/companyconfig"
+	"userclouds.com/internal/tenantmap"
+	"userclouds.com/worker"
+)
+
+// CleanExpiredTokensForTenant deletes tokenizer tokens that have expired for a tenant
+func CleanExpiredTokensForTenant(ctx context.Context, ts *tenantmap.TenantState, params worker.DataCleanupParams) error {
+	uclog.Infof(ctx, "Cleaning expired tokens for tenant %v  max: %d dry run: %v", ts.ID, params.MaxCandidates, params.DryRun)
+	return ucerr.Wrap(helpers.CleanExpiredTokensForTenant(ctx, ts, params.MaxCandidates, params.DryRun))
+}
+
+// CleanExpiredTokensForAllTenantsHandler returns a handler that dispatches expired token cleanup tasks for all tenants
+func CleanExpiredTokensForAllTenantsHandler(ccs *companyconfig.Storage, wc workerclient.Client) http.HandlerFunc {
+	return cleanExpiredForAllTenantsHandler("clean-expired-tokens", ccs, wc, worker.TokenizerExpiredTokenCleanupMessage)
+}
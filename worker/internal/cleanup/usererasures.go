@@ -0,0 +1,34 @@
+package cleanup
+
+import (
+	"net/http"
+
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/companyconfig"
+	"userclouds.com/worker"
+)
+
+// ResumeUserErasuresResponse represents the response from dispatching the user erasure sweep
+type ResumeUserErasuresResponse struct {
+	TenantsCount int `json:"tenants_count" yaml:"tenants_count"`
+}
+
+// ResumeUserErasuresForAllTenantsHandler returns a handler that dispatches tasks to resume the pending user
+// erasures whose retained values have expired for all tenants
+func ResumeUserErasuresForAllTenantsHandler(ccs *companyconfig.Storage, wc workerclient.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uclog.SetHandlerName(ctx, "resume-user-erasures")
+
+		tenantsCount, err := dispatchForAllTenants(ctx, ccs, wc, worker.ResumeUserErasuresMessage)
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+			return
+		}
+		uclog.Infof(ctx, "dispatched user erasure sweep tasks for %d tenants", tenantsCount)
+
+		jsonapi.Marshal(w, ResumeUserErasuresResponse{TenantsCount: tenantsCount})
+	}
+}
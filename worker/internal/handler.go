@@ -16,6 +16,7 @@ import (
 	"github.com/gofrs/uuid"
 
 	idpConfig "userclouds.com/idp/config"
+	idpWorker "userclouds.com/idp/worker"
 	acmeinfra "userclouds.com/infra/acme"
 	"userclouds.com/infra/cache"
 	"userclouds.com/infra/dnsclient"
@@ -46,6 +47,7 @@ import (
 	"userclouds.com/worker/internal/acme"
 	"userclouds.com/worker/internal/cachetool"
 	"userclouds.com/worker/internal/cleanup"
+	"userclouds.com/worker/internal/keyrotation"
 	"userclouds.com/worker/internal/searchindex"
 	"userclouds.com/worker/internal/sqlshimingest"
 	"userclouds.com/worker/internal/tenant"
@@ -401,11 +403,62 @@ func (h *handler) runTask(ctx context.Context, ts *tenantmap.TenantState, msg *w
 		return ucerr.Wrap(cachetool.LogCache(ctx, h.cacheCfg, h.companyConfigStorage, *msg.LogCache))
 	case worker.TaskDataImport:
 		return ucerr.Wrap(dataImport(ctx, h.wc, ts, msg.DataImportParams.JobID, msg.DataImportParams.ObjectReady))
+	case worker.TaskExportAccessor:
+		p := msg.ExportAccessorParams
+		if p == nil {
+			return ucerr.Errorf("missing export accessor params")
+		}
+		if msg.SourceRegion == region.Current() {
+			return ucerr.Wrap(idpWorker.ExportAccessorToObjectStore(
+				ctx,
+				ts,
+				&idpConfig.SearchUpdateConfig{SearchCfg: h.openSearchCfg},
+				p.Request,
+				p.SubjectID,
+				p.SubjectType,
+			))
+		}
+		uclog.Infof(ctx, "Requeue %s message from region %v (need it to run in that region not in %v)", msg.Task, msg.SourceRegion, region.Current())
+		return ucerr.Wrap(h.wc.Send(ctx, *msg))
+	case worker.TaskDSARExport:
+		if msg.DSARExportParams == nil {
+			return ucerr.Errorf("missing DSAR export params")
+		}
+		if msg.SourceRegion == region.Current() {
+			return ucerr.Wrap(idpWorker.ExportDSAR(ctx, ts, msg.DSARExportParams.ExportID))
+		}
+		uclog.Infof(ctx, "Requeue %s message from region %v (need it to run in that region not in %v)", msg.Task, msg.SourceRegion, region.Current())
+		return ucerr.Wrap(h.wc.Send(ctx, *msg))
+	case worker.TaskUserErasure:
+		if msg.UserErasureParams == nil {
+			return ucerr.Errorf("missing user erasure params")
+		}
+		if msg.SourceRegion == region.Current() {
+			return ucerr.Wrap(idpWorker.EraseUser(
+				ctx,
+				ts,
+				&idpConfig.SearchUpdateConfig{SearchCfg: h.openSearchCfg},
+				msg.UserErasureParams.ErasureID,
+			))
+		}
+		uclog.Infof(ctx, "Requeue %s message from region %v (need it to run in that region not in %v)", msg.Task, msg.SourceRegion, region.Current())
+		return ucerr.Wrap(h.wc.Send(ctx, *msg))
+	case worker.TaskResumeUserErasures:
+		if msg.SourceRegion == region.Current() {
+			return ucerr.Wrap(idpWorker.ResumeUserErasures(ctx, ts, &idpConfig.SearchUpdateConfig{SearchCfg: h.openSearchCfg}))
+		}
+		uclog.Infof(ctx, "Requeue %s message from region %v (need it to run in that region not in %v)", msg.Task, msg.SourceRegion, region.Current())
+		return ucerr.Wrap(h.wc.Send(ctx, *msg))
 	case worker.TaskPlexTokenDataCleanup:
 		if msg.PlexTokenDataCleanup == nil {
 			return ucerr.Errorf("missing plex token data cleanup params")
 		}
 		return ucerr.Wrap(cleanup.CleanPlexTokensForTenant(ctx, msg.TenantID, ts.TenantDB, h.cacheCfg, *msg.PlexTokenDataCleanup))
+	case worker.TaskPlexKeyRotation:
+		if msg.PlexKeyRotationParams == nil {
+			return ucerr.Errorf("missing plex key rotation params")
+		}
+		return ucerr.Wrap(keyrotation.RotatePlexKeysForTenant(ctx, msg.TenantID, ts.TenantDB, h.cacheCfg, *msg.PlexKeyRotationParams))
 	case worker.TaskUserStoreDataCleanup:
 		if msg.UserStoreDataCleanup == nil {
 			return ucerr.Errorf("missing user store data cleanup params")
@@ -415,6 +468,21 @@ func (h *handler) runTask(ctx context.Context, ts *tenantmap.TenantState, msg *w
 		}
 		uclog.Infof(ctx, "Requeue %s message from region %v (need it to run in that region not in %v)", msg.Task, msg.SourceRegion, region.Current())
 		return ucerr.Wrap(h.wc.Send(ctx, *msg))
+	case worker.TaskAuthzExpiredEdgeCleanup:
+		if msg.AuthzExpiredEdgeCleanup == nil {
+			return ucerr.Errorf("missing authz expired edge cleanup params")
+		}
+		return ucerr.Wrap(cleanup.CleanExpiredAuthzEdgesForTenant(ctx, ts, *msg.AuthzExpiredEdgeCleanup))
+	case worker.TaskTokenizerExpiredTokenCleanup:
+		if msg.TokenizerExpiredTokenCleanup == nil {
+			return ucerr.Errorf("missing tokenizer expired token cleanup params")
+		}
+		return ucerr.Wrap(cleanup.CleanExpiredTokensForTenant(ctx, ts, *msg.TokenizerExpiredTokenCleanup))
+	case worker.TaskDSARExpiredExportCleanup:
+		if msg.DSARExpiredExportCleanup == nil {
+			return ucerr.Errorf("missing DSAR expired export cleanup params")
+		}
+		return ucerr.Wrap(cleanup.CleanExpiredDSARExportsForTenant(ctx, ts, *msg.DSARExpiredExportCleanup))
 	case worker.TaskIngestSqlshimDatabaseSchema:
 		if msg.IngestSqlshimDatabaseSchemasParams == nil {
 			return ucerr.Errorf("missing ingest sqlshim database schema params")
@@ -0,0 +1,112 @@
+package keyrotation
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/cache"
+	"userclouds.com/infra/jsonapi"
+	"userclouds.com/infra/pagination"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/infra/workerclient"
+	"userclouds.com/internal/companyconfig"
+	"userclouds.com/internal/provisioning"
+	"userclouds.com/internal/tenantplex"
+	"userclouds.com/plex/manager"
+	"userclouds.com/worker"
+)
+
+// RotatePlexKeysForTenant revokes the requested signing key for a tenant, activates the tenant's next
+// signing key if it is due, and removes retiring keys once the tokens they signed have expired
+func RotatePlexKeysForTenant(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	tenantDB *ucdb.DB,
+	cacheCfg *cache.Config,
+	params worker.PlexKeyRotationParams,
+) error {
+	mgr := manager.NewFromDB(tenantDB, cacheCfg)
+	tp, err := mgr.GetTenantPlex(ctx, tenantID)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	changed := false
+
+	if params.RevokeKeyID != "" {
+		if err := tp.PlexConfig.RevokeKey(
+			params.RevokeKeyID,
+			now,
+			func() (*tenantplex.Keys, error) {
+				return provisioning.GeneratePlexKeys(ctx, tenantID)
+			},
+		); err != nil {
+			return ucerr.Wrap(err)
+		}
+		uclog.Infof(ctx, "revoked signing key '%s' for tenant %v", params.RevokeKeyID, tenantID)
+		changed = true
+	}
+
+	if tp.PlexConfig.AdvanceKeyRotation(now) {
+		uclog.Infof(ctx, "advanced signing key rotation for tenant %v, active key is now '%s'", tenantID, tp.PlexConfig.Keys.KeyID)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return ucerr.Wrap(mgr.SaveTenantPlex(ctx, tp))
+}
+
+// RotatePlexKeysResponse represents the response from dispatching the signing key rotation tasks
+type RotatePlexKeysResponse struct {
+	TenantsCount int `json:"tenants_count" yaml:"tenants_count"`
+}
+
+// RotatePlexKeysForAllTenantsHandler returns a handler that dispatches signing key rotation tasks for all tenants
+func RotatePlexKeysForAllTenantsHandler(ccs *companyconfig.Storage, wc workerclient.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uclog.SetHandlerName(ctx, "rotate-plex-keys")
+
+		tenantsCount, err := dispatchRotatePlexKeys(ctx, ccs, wc)
+		if err != nil {
+			jsonapi.MarshalError(ctx, w, err, jsonapi.Code(http.StatusInternalServerError))
+			return
+		}
+
+		jsonapi.Marshal(w, RotatePlexKeysResponse{TenantsCount: tenantsCount})
+	}
+}
+
+func dispatchRotatePlexKeys(ctx context.Context, ccs *companyconfig.Storage, wc workerclient.Client) (int, error) {
+	pager, err := companyconfig.NewTenantPaginatorFromOptions(pagination.Limit(pagination.MaxLimit))
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+	tenantsCount := 0
+	for {
+		tenants, pr, err := ccs.ListTenantsPaginated(ctx, *pager)
+		if err != nil {
+			return tenantsCount, ucerr.Wrap(err)
+		}
+		for _, tenant := range tenants {
+			if err := wc.Send(ctx, worker.PlexKeyRotationMessage(tenant.ID, "")); err != nil {
+				return tenantsCount, ucerr.Wrap(err)
+			}
+			tenantsCount++
+		}
+		if !pager.AdvanceCursor(*pr) {
+			break
+		}
+	}
+	uclog.Infof(ctx, "dispatched signing key rotation tasks for %d tenants", tenantsCount)
+	return tenantsCount, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gofrs/uuid"
 
+	"userclouds.com/idp"
 	"userclouds.com/infra/namespace/region"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/internal/companyconfig"
@@ -27,8 +28,15 @@ type Message struct {
 	LogCache                             *LogCacheParams                       `json:"log_cache" validate:"allownil"`                                 // used for TaskLogCache
 	TenantDNS                            *TenantDNSTaskParams                  `json:"tenant_dns" validate:"allownil"`                                // used for TaskValidateDNS & TaskNewTenantCNAME
 	DataImportParams                     *DataImportParams                     `json:"data_import_params" validate:"allownil"`                        // used for TaskDataImport
+	ExportAccessorParams                 *ExportAccessorParams                 `json:"export_accessor_params" validate:"allownil"`                    // used for TaskExportAccessor
+	DSARExportParams                     *DSARExportParams                     `json:"dsar_export_params" validate:"allownil"`                        // used for TaskDSARExport
+	UserErasureParams                    *UserErasureParams                    `json:"user_erasure_params" validate:"allownil"`                       // used for TaskUserErasure
 	PlexTokenDataCleanup                 *DataCleanupParams                    `json:"plex_token_data_cleanup" validate:"allownil"`                   // used for TaskPlexTokenDataCleanup
+	PlexKeyRotationParams                *PlexKeyRotationParams                `json:"plex_key_rotation_params" validate:"allownil"`                  // used for TaskPlexKeyRotation
 	UserStoreDataCleanup                 *DataCleanupParams                    `json:"userstore_data_cleanup" validate:"allownil"`                    // used for TaskUserStoreDataCleanup
+	AuthzExpiredEdgeCleanup              *DataCleanupParams                    `json:"authz_expired_edge_cleanup" validate:"allownil"`                // used for TaskAuthzExpiredEdgeCleanup
+	TokenizerExpiredTokenCleanup         *DataCleanupParams                    `json:"tokenizer_expired_token_cleanup" validate:"allownil"`           // used for TaskTokenizerExpiredTokenCleanup
+	DSARExpiredExportCleanup             *DataCleanupParams                    `json:"dsar_expired_export_cleanup" validate:"allownil"`               // used for TaskDSARExpiredExportCleanup
 	TenantURLProvisioningParams          *TenantURLProvisioningParams          `json:"tenant_url_provisioning_params" validate:"allownil"`            // used for TaskProvisionTenantURLs
 	IngestSqlshimDatabaseSchemasParams   *IngestSqlshimDatabaseSchemasParams   `json:"ingest_sqlshim_database_schemas" validate:"allownil"`           // used for TaskIngestSqlshimDatabaseSchemas
 	ProvisionTenantOpenSearchIndexParams *ProvisionTenantOpenSearchIndexParams `json:"provision_tenant_open_search_index_params" validate:"allownil"` // used for TaskProvisionTenantOpenSearchIndex
@@ -265,6 +273,29 @@ type DataImportParams struct {
 
 //go:generate genvalidate DataImportParams
 
+// ExportAccessorParams defines the parameters for the ExportAccessor task
+type ExportAccessorParams struct {
+	Request     idp.ExportAccessorToObjectStoreRequest `json:"request"`
+	SubjectID   uuid.UUID                              `json:"subject_id"`   // the subject that requested the export, whose access is checked
+	SubjectType string                                 `json:"subject_type"` // the authz object type of the subject
+}
+
+//go:generate genvalidate ExportAccessorParams
+
+// DSARExportParams defines the parameters for the DSARExport task
+type DSARExportParams struct {
+	ExportID uuid.UUID `json:"export_id" validate:"notnil"`
+}
+
+//go:generate genvalidate DSARExportParams
+
+// UserErasureParams defines the parameters for the UserErasure task
+type UserErasureParams struct {
+	ErasureID uuid.UUID `json:"erasure_id" validate:"notnil"`
+}
+
+//go:generate genvalidate UserErasureParams
+
 // DataCleanupParams defines the parameters for the DataCleanup tasks
 type DataCleanupParams struct {
 	DryRun        bool `json:"dry_run"`
@@ -273,6 +304,15 @@ type DataCleanupParams struct {
 
 //go:generate genvalidate DataCleanupParams
 
+// PlexKeyRotationParams defines the parameters for the PlexKeyRotation task, which activates a
+// tenant's next signing key once it is due and removes retiring keys once their tokens have expired
+type PlexKeyRotationParams struct {
+	// RevokeKeyID is the ID of a signing key to revoke immediately, if any
+	RevokeKeyID string `json:"revoke_key_id,omitempty"`
+}
+
+//go:generate genvalidate PlexKeyRotationParams
+
 // TenantURLProvisioningParams defines the parameters for the ProvisionTenantURLs task
 type TenantURLProvisioningParams struct {
 	AddEKSURLs bool `json:"add_eks_urls"`
@@ -321,6 +361,60 @@ func DataImportMessage(tenantID uuid.UUID, importJobID uuid.UUID, objectReady bo
 	}
 }
 
+// ExportAccessorMessage creates a message to export the data an accessor returns to an object store
+func ExportAccessorMessage(
+	tenantID uuid.UUID,
+	req idp.ExportAccessorToObjectStoreRequest,
+	subjectID uuid.UUID,
+	subjectType string,
+) Message {
+	return Message{
+		Task:     TaskExportAccessor,
+		TenantID: tenantID,
+		ExportAccessorParams: &ExportAccessorParams{
+			Request:     req,
+			SubjectID:   subjectID,
+			SubjectType: subjectType,
+		},
+	}
+}
+
+// DSARExportMessage creates a message to assemble the bundle of a data subject access request export
+func DSARExportMessage(tenantID uuid.UUID, exportID uuid.UUID) Message {
+	return Message{
+		Task:             TaskDSARExport,
+		TenantID:         tenantID,
+		DSARExportParams: &DSARExportParams{ExportID: exportID},
+	}
+}
+
+// UserErasureMessage creates a message to erase a user from every system that stores data about them
+func UserErasureMessage(tenantID uuid.UUID, erasureID uuid.UUID) Message {
+	return Message{
+		Task:              TaskUserErasure,
+		TenantID:          tenantID,
+		UserErasureParams: &UserErasureParams{ErasureID: erasureID},
+	}
+}
+
+// PlexKeyRotationMessage creates a message to advance the signing key rotation for a tenant,
+// revoking the specified key first if revokeKeyID is not empty
+func PlexKeyRotationMessage(tenantID uuid.UUID, revokeKeyID string) Message {
+	return Message{
+		Task:                  TaskPlexKeyRotation,
+		TenantID:              tenantID,
+		PlexKeyRotationParams: &PlexKeyRotationParams{RevokeKeyID: revokeKeyID},
+	}
+}
+
+// ResumeUserErasuresMessage creates a message to resume a tenant's pending user erasures whose retained values have expired
+func ResumeUserErasuresMessage(tenantID uuid.UUID) Message {
+	return Message{
+		Task:     TaskResumeUserErasures,
+		TenantID: tenantID,
+	}
+}
+
 // PlexTokenDataCleanupMessage creates a message to trigger plex token data cleanup for a tenant
 func PlexTokenDataCleanupMessage(tenantID uuid.UUID, maxCandidates int, dryRun bool) Message {
 	return Message{
@@ -345,6 +439,42 @@ func UserStoreDataCleanupMessage(tenantID uuid.UUID, maxCandidates int, dryRun b
 	}
 }
 
+// AuthzExpiredEdgeCleanupMessage creates a message to trigger cleanup of expired authz edges for a tenant
+func AuthzExpiredEdgeCleanupMessage(tenantID uuid.UUID, maxCandidates int, dryRun bool) Message {
+	return Message{
+		Task:     TaskAuthzExpiredEdgeCleanup,
+		TenantID: tenantID,
+		AuthzExpiredEdgeCleanup: &DataCleanupParams{
+			DryRun:        dryRun,
+			MaxCandidates: maxCandidates,
+		},
+	}
+}
+
+// TokenizerExpiredTokenCleanupMessage creates a message to trigger cleanup of expired tokenizer tokens for a tenant
+func TokenizerExpiredTokenCleanupMessage(tenantID uuid.UUID, maxCandidates int, dryRun bool) Message {
+	return Message{
+		Task:     TaskTokenizerExpiredTokenCleanup,
+		TenantID: tenantID,
+		TokenizerExpiredTokenCleanup: &DataCleanupParams{
+			DryRun:        dryRun,
+			MaxCandidates: maxCandidates,
+		},
+	}
+}
+
+// DSARExpiredExportCleanupMessage creates a message to trigger clearing the bundles of expired DSAR exports for a tenant
+func DSARExpiredExportCleanupMessage(tenantID uuid.UUID, maxCandidates int, dryRun bool) Message {
+	return Message{
+		Task:     TaskDSARExpiredExportCleanup,
+		TenantID: tenantID,
+		DSARExpiredExportCleanup: &DataCleanupParams{
+			DryRun:        dryRun,
+			MaxCandidates: maxCandidates,
+		},
+	}
+}
+
 // ProvisionTenantURLsMessage creates a message to create a new tenant CNAME
 func ProvisionTenantURLsMessage(tenantID uuid.UUID, addEKSURLs, deleteURLs, dryRun bool) Message {
 	return Message{
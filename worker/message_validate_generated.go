@@ -46,16 +46,51 @@ func (o Message) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.ExportAccessorParams != nil {
+		if err := o.ExportAccessorParams.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	if o.DSARExportParams != nil {
+		if err := o.DSARExportParams.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	if o.UserErasureParams != nil {
+		if err := o.UserErasureParams.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	if o.PlexTokenDataCleanup != nil {
 		if err := o.PlexTokenDataCleanup.Validate(); err != nil {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.PlexKeyRotationParams != nil {
+		if err := o.PlexKeyRotationParams.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	if o.UserStoreDataCleanup != nil {
 		if err := o.UserStoreDataCleanup.Validate(); err != nil {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.AuthzExpiredEdgeCleanup != nil {
+		if err := o.AuthzExpiredEdgeCleanup.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	if o.TokenizerExpiredTokenCleanup != nil {
+		if err := o.TokenizerExpiredTokenCleanup.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+	if o.DSARExpiredExportCleanup != nil {
+		if err := o.DSARExpiredExportCleanup.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	if o.TenantURLProvisioningParams != nil {
 		if err := o.TenantURLProvisioningParams.Validate(); err != nil {
 			return ucerr.Wrap(err)
@@ -0,0 +1,8 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package worker
+
+// Validate implements Validateable
+func (o PlexKeyRotationParams) Validate() error {
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"userclouds.com/worker/internal"
 	"userclouds.com/worker/internal/acme"
 	"userclouds.com/worker/internal/cleanup"
+	"userclouds.com/worker/internal/keyrotation"
 	"userclouds.com/worker/internal/usersync"
 	"userclouds.com/worker/internal/watchdog"
 )
@@ -100,6 +101,11 @@ func addCronEndpoints(companyConfigStorage *companyconfig.Storage, tm *tenantmap
 	addCronEndPoint(hb, "/checkcnames", acme.CheckAllCNAMEsHandler(companyConfigStorage, tm, wc))
 	addCronEndPoint(hb, "/watchdog/slowprov", watchdog.SlowProvisionWatchdog(companyConfigStorage))
 	addCronEndPoint(hb, "/clean-userstore-data", cleanup.CleanUserStoreForAllTenantsHandler(companyConfigStorage, wc))
+	addCronEndPoint(hb, "/clean-expired-authz-edges", cleanup.CleanExpiredAuthzEdgesForAllTenantsHandler(companyConfigStorage, wc))
+	addCronEndPoint(hb, "/resume-user-erasures", cleanup.ResumeUserErasuresForAllTenantsHandler(companyConfigStorage, wc))
+	addCronEndPoint(hb, "/clean-expired-tokens", cleanup.CleanExpiredTokensForAllTenantsHandler(companyConfigStorage, wc))
+	addCronEndPoint(hb, "/clean-expired-dsar-exports", cleanup.CleanExpiredDSARExportsForAllTenantsHandler(companyConfigStorage, wc))
+	addCronEndPoint(hb, "/rotate-plex-keys", keyrotation.RotatePlexKeysForAllTenantsHandler(companyConfigStorage, wc))
 }
 
 func addCronEndPoint(hb *builder.HandlerBuilder, endpoint string, handler http.Handler) {
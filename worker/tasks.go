@@ -18,8 +18,16 @@ const (
 	TaskClearCache                     Task = "clear_cache"
 	TaskLogCache                       Task = "log_cache"
 	TaskDataImport                     Task = "data_import"
+	TaskExportAccessor                 Task = "export_accessor"
+	TaskDSARExport                     Task = "dsar_export"
+	TaskUserErasure                    Task = "user_erasure"
+	TaskResumeUserErasures             Task = "resume_user_erasures"
 	TaskPlexTokenDataCleanup           Task = "plex_token_data_cleanup"
+	TaskPlexKeyRotation                Task = "plex_key_rotation"
 	TaskUserStoreDataCleanup           Task = "userstore_data_cleanup"
+	TaskAuthzExpiredEdgeCleanup        Task = "authz_expired_edge_cleanup"
+	TaskTokenizerExpiredTokenCleanup   Task = "tokenizer_expired_token_cleanup"
+	TaskDSARExpiredExportCleanup       Task = "dsar_expired_export_cleanup"
 	TaskProvisionTenantURLs            Task = "provision_tenant_urls"
 	TaskIngestSqlshimDatabaseSchema    Task = "ingest_sqlshim_database_schema"
 	TaskProvisionTenantOpenSearchIndex Task = "provision_tenant_opensearch_index"
@@ -0,0 +1,15 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package worker
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o UserErasureParams) Validate() error {
+	if o.ErasureID.IsNil() {
+		return ucerr.Friendlyf(nil, "UserErasureParams.ErasureID can't be nil")
+	}
+	return nil
+}